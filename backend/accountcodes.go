@@ -30,6 +30,7 @@ import (
 // - regular: for unified accounts
 // - split: for the individual accounts split from a unified account, if the keystore does not support unified accounts, such as the BitBox01.
 // - erc20: for ERC20 token accounts
+// - watch-only: for accounts imported from an extended public key
 
 // regularAccountCode returns an account code based on a keystore root fingerprint, a coin code and
 // an account number.
@@ -37,6 +38,12 @@ func regularAccountCode(rootFingerprint []byte, coinCode coin.Code, accountNumbe
 	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-%d", rootFingerprint, coinCode, accountNumber))
 }
 
+// watchOnlyAccountCode returns an account code for a watch-only account based on the fingerprint
+// of the imported extended public key and the coin code.
+func watchOnlyAccountCode(xpubFingerprint []byte, coinCode coin.Code) accountsTypes.Code {
+	return accountsTypes.Code(fmt.Sprintf("v0-watch-%x-%s", xpubFingerprint, coinCode))
+}
+
 // splitAccountCode returns an account code for split accounts, made by exploding a unified account
 // into one account per signing configuration. This only applies to BTC/LTC.
 func splitAccountCode(parentCode accountsTypes.Code, scriptType signing.ScriptType) accountsTypes.Code {
@@ -0,0 +1,134 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+)
+
+// accountObservations counts how many frontend views observe each account. Account events (subject
+// prefix `account/<code>/` and the legacy account events) are only sent to the frontend for
+// observed accounts, so idle accounts don't cost any event building and serialization.
+type accountObservations struct {
+	counts map[accountsTypes.Code]int
+	// missed contains the accounts which dropped events since they were last observed.
+	missed map[accountsTypes.Code]bool
+	// stopRelaying contains the functions unobserving the events of the loaded accounts.
+	stopRelaying map[accountsTypes.Code]func()
+	lock         locker.Locker
+}
+
+func newAccountObservations() *accountObservations {
+	return &accountObservations{
+		counts:       map[accountsTypes.Code]int{},
+		missed:       map[accountsTypes.Code]bool{},
+		stopRelaying: map[accountsTypes.Code]func(){},
+	}
+}
+
+// observe increments the observation count of the account. It returns true if the account was not
+// observed before and missed events in the meantime.
+func (o *accountObservations) observe(code accountsTypes.Code) bool {
+	defer o.lock.Lock()()
+	o.counts[code]++
+	if o.counts[code] > 1 {
+		return false
+	}
+	missed := o.missed[code]
+	delete(o.missed, code)
+	return missed
+}
+
+// unobserve decrements the observation count of the account.
+func (o *accountObservations) unobserve(code accountsTypes.Code) {
+	defer o.lock.Lock()()
+	if o.counts[code] <= 1 {
+		delete(o.counts, code)
+		return
+	}
+	o.counts[code]--
+}
+
+// observed returns true if the account is observed. Otherwise, the account is marked as having
+// missed events, so the frontend can be told to reload the account once it is observed again.
+// Only call this right before emitting or dropping an event.
+func (o *accountObservations) observed(code accountsTypes.Code) bool {
+	defer o.lock.Lock()()
+	if o.counts[code] > 0 {
+		return true
+	}
+	o.missed[code] = true
+	return false
+}
+
+// relaying stores the function which stops relaying the events of a loaded account.
+func (o *accountObservations) relaying(code accountsTypes.Code, unobserve func()) {
+	defer o.lock.Lock()()
+	o.stopRelaying[code] = unobserve
+}
+
+// stopRelayingEvents unobserves the events of an account which is being closed.
+func (o *accountObservations) stopRelayingEvents(code accountsTypes.Code) {
+	unlock := o.lock.Lock()
+	unobserve := o.stopRelaying[code]
+	delete(o.stopRelaying, code)
+	unlock()
+	if unobserve != nil {
+		unobserve()
+	}
+}
+
+// ObserveAccount registers interest of a frontend view in the events of an account. Calls are
+// reference counted and each call must be balanced by a call to UnobserveAccount(). Observing only
+// affects which events are sent to the frontend, not syncing or user notifications.
+func (backend *Backend) ObserveAccount(code accountsTypes.Code) {
+	if backend.accountObservations.observe(code) {
+		// The view could have loaded stale data before it started observing. Tell it to reload.
+		backend.events <- AccountEvent{Type: "account", Code: code, Data: string(accountsTypes.EventStatusChanged)}
+		backend.events <- AccountEvent{Type: "account", Code: code, Data: string(accountsTypes.EventSyncDone)}
+	}
+}
+
+// UnobserveAccount releases an observation registered with ObserveAccount().
+func (backend *Backend) UnobserveAccount(code accountsTypes.Code) {
+	backend.accountObservations.unobserve(code)
+}
+
+// emitAccountEvent sends a legacy account event to the frontend if the account is observed.
+func (backend *Backend) emitAccountEvent(code accountsTypes.Code, event accountsTypes.Event) {
+	backend.emitAccountEventWithMeta(code, event, nil)
+}
+
+// emitAccountEventWithMeta is like emitAccountEvent, passing along data about the event.
+func (backend *Backend) emitAccountEventWithMeta(
+	code accountsTypes.Code, event accountsTypes.Event, meta interface{}) {
+	if !backend.accountObservations.observed(code) {
+		return
+	}
+	backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event), Meta: meta}
+}
+
+// notifyAccountEvent returns an observer relaying the events of an account to the frontend if the
+// account is observed.
+func (backend *Backend) notifyAccountEvent(code accountsTypes.Code) func(observable.Event) {
+	return func(event observable.Event) {
+		if !backend.accountObservations.observed(code) {
+			return
+		}
+		backend.Notify(event)
+	}
+}
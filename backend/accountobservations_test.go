@@ -0,0 +1,197 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// drainEvents returns all events which are currently queued for the frontend.
+func drainEvents(b *Backend) []interface{} {
+	events := []interface{}{}
+	for {
+		select {
+		case event := <-b.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func addTestBtcAccounts(t testing.TB, b *Backend, count int) []accountsTypes.Code {
+	coin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	codes := []accountsTypes.Code{}
+	for i := 0; i < count; i++ {
+		code := accountsTypes.Code(fmt.Sprintf("test-btc-account-%d", i))
+		b.createAndAddAccount(coin, &config.Account{
+			Code: code,
+			Name: string(code),
+			SigningConfigurations: signing.Configurations{
+				signing.NewBitcoinConfiguration(
+					signing.ScriptTypeP2WPKH,
+					[]byte{0x55, 0x55, 0x55, 0x55},
+					mustKeypath(fmt.Sprintf("m/84'/0'/%d'", i)),
+					test.TstMustXKey("xpub6Cxa67Bfe1Aw5VvLM1Ppua9x28CXH1zUYoAuBzFRjR6hWnA6aUcny84KYkeVcZWnWXxKSkxCEyMA8xic54ydBPWm5oziXpsXq6nX8FELMQn")),
+			},
+		})
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func TestAccountObservations(t *testing.T) {
+	o := newAccountObservations()
+	require.False(t, o.observe("a"))
+	require.True(t, o.observed("a"))
+	require.False(t, o.observe("a"))
+	o.unobserve("a")
+	require.True(t, o.observed("a"))
+	o.unobserve("a")
+	require.False(t, o.observed("a"))
+	// Unbalanced unobserve calls are ignored.
+	o.unobserve("a")
+	require.False(t, o.observed("b"))
+	// Both accounts missed an event while unobserved.
+	require.True(t, o.observe("a"))
+	require.True(t, o.observe("b"))
+	require.False(t, o.observe("b"))
+}
+
+func TestAccountEventsObservation(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	codes := addTestBtcAccounts(t, b, 2)
+	drainEvents(b)
+	var notified []observable.Event
+	b.Observe(func(event observable.Event) { notified = append(notified, event) })
+
+	account := b.Accounts().lookup(codes[0])
+	require.NotNil(t, account)
+	config := account.Config()
+
+	// Unobserved: events are dropped.
+	require.False(t, config.IsObserved())
+	config.OnEvent(accountsTypes.EventSyncDone)
+	b.notifyAccountEvent(codes[0])(observable.Event{Subject: "account/test/synced-addresses-count"})
+	require.Empty(t, drainEvents(b))
+	require.Empty(t, notified)
+
+	// Observing the account tells the frontend to reload it, as it missed events.
+	b.ObserveAccount(codes[0])
+	require.True(t, config.IsObserved())
+	require.Equal(t, []interface{}{
+		AccountEvent{Type: "account", Code: codes[0], Data: string(accountsTypes.EventStatusChanged)},
+		AccountEvent{Type: "account", Code: codes[0], Data: string(accountsTypes.EventSyncDone)},
+	}, drainEvents(b))
+
+	config.OnEvent(accountsTypes.EventSyncDone)
+	b.notifyAccountEvent(codes[0])(observable.Event{Subject: "account/test/synced-addresses-count"})
+	require.Equal(t, []interface{}{
+		AccountEvent{Type: "account", Code: codes[0], Data: string(accountsTypes.EventSyncDone)},
+	}, drainEvents(b))
+	require.Len(t, notified, 1)
+
+	// Events of the other account are still dropped.
+	b.Accounts().lookup(codes[1]).Config().OnEvent(accountsTypes.EventSyncDone)
+	require.Empty(t, drainEvents(b))
+
+	// No reload when observing again without missed events.
+	b.UnobserveAccount(codes[0])
+	b.ObserveAccount(codes[0])
+	require.Empty(t, drainEvents(b))
+}
+
+func TestAccountEventsUnobservedOnClose(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	unobserved := map[accountsTypes.Code]int{}
+	b.makeBtcAccount = func(config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, log *logrus.Entry) accounts.Interface {
+		account := MockBtcAccount(t, config, coin, gapLimits, log)
+		account.ObserveFunc = func(func(observable.Event)) func() {
+			return func() { unobserved[config.Config.Code]++ }
+		}
+		return account
+	}
+	codes := addTestBtcAccounts(t, b, 2)
+	require.Empty(t, unobserved)
+
+	b.uninitAccounts(true)
+	require.Empty(t, b.Accounts())
+	require.Equal(t, map[accountsTypes.Code]int{codes[0]: 1, codes[1]: 1}, unobserved)
+
+	// Closing again does not unobserve twice.
+	b.accountObservations.stopRelayingEvents(codes[0])
+	require.Equal(t, 1, unobserved[codes[0]])
+}
+
+// BenchmarkIdleAccountEvents measures the cost of the events produced by 10 accounts, e.g. when
+// new exchange rates arrive or while the accounts are syncing, including the JSON serialization
+// done when relaying the events to the frontend.
+func BenchmarkIdleAccountEvents(b *testing.B) {
+	run := func(b *testing.B, observed bool) {
+		b.Helper()
+		backend := newBackend(b, testnetDisabled, regtestDisabled)
+		defer backend.Close()
+		codes := addTestBtcAccounts(b, backend, 10)
+		if observed {
+			for _, code := range codes {
+				backend.ObserveAccount(code)
+			}
+		}
+		backend.Observe(func(event observable.Event) {
+			_, _ = json.Marshal(event)
+		})
+		drainEvents(backend)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, code := range codes {
+				config := backend.Accounts().lookup(code).Config()
+				config.OnEvent(accountsTypes.EventSyncDone)
+				// Like the sync progress of a btc account, the payload is only built if observed.
+				if config.IsObserved() {
+					backend.notifyAccountEvent(code)(observable.Event{
+						Subject: fmt.Sprintf("account/%s/synced-addresses-count", code),
+						Action:  action.Replace,
+						Object:  i,
+					})
+				}
+			}
+			for _, event := range drainEvents(backend) {
+				_, _ = json.Marshal(event)
+			}
+		}
+	}
+	b.Run("observed", func(b *testing.B) { run(b, true) })
+	b.Run("unobserved", func(b *testing.B) { run(b, false) })
+}
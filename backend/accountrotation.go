@@ -0,0 +1,403 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"math/big"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	// AccountRotationStageDone means the funds were moved and the old account was archived. It is
+	// only used in events, as the rotation state is removed at this point.
+	AccountRotationStageDone config.AccountRotationStage = "done"
+	// AccountRotationStageCanceled means the rotation was canceled before the funds were moved.
+	// The new account is kept. It is only used in events.
+	AccountRotationStageCanceled config.AccountRotationStage = "canceled"
+)
+
+// accountRotationHighFeePercentage is the fee, relative to the amount moved, above which the user
+// is warned about the fee of the consolidation transaction.
+const accountRotationHighFeePercentage = 10
+
+// AccountRotationWarning is a warning to show to the user before moving the funds.
+type AccountRotationWarning string
+
+const (
+	// AccountRotationWarningPrivacy means that the consolidation transaction spends all coins of
+	// the account together, which links all its addresses publicly.
+	AccountRotationWarningPrivacy AccountRotationWarning = "privacy"
+	// AccountRotationWarningHighFee means that the fee is a large part of the amount moved.
+	AccountRotationWarningHighFee AccountRotationWarning = "highFee"
+)
+
+// AccountRotationStatus is the payload of the `account-rotation` event, which is emitted whenever
+// the rotation of an account advances to the next stage.
+type AccountRotationStatus struct {
+	AccountCode    accountsTypes.Code          `json:"accountCode"`
+	NewAccountCode accountsTypes.Code          `json:"newAccountCode"`
+	Stage          config.AccountRotationStage `json:"stage"`
+}
+
+// AccountRotationProposal describes the consolidation transaction moving all funds to the new
+// account.
+type AccountRotationProposal struct {
+	RecipientAddress string                   `json:"recipientAddress"`
+	Amount           coinpkg.FormattedAmount  `json:"amount"`
+	Fee              coinpkg.FormattedAmount  `json:"fee"`
+	Total            coinpkg.FormattedAmount  `json:"total"`
+	Warnings         []AccountRotationWarning `json:"warnings"`
+}
+
+func (backend *Backend) emitAccountRotationStatus(
+	code accountsTypes.Code, newCode accountsTypes.Code, stage config.AccountRotationStage) {
+	backend.Notify(observable.Event{
+		Subject: "account-rotation",
+		Action:  action.Replace,
+		Object: AccountRotationStatus{
+			AccountCode:    code,
+			NewAccountCode: newCode,
+			Stage:          stage,
+		},
+	})
+}
+
+// AccountRotation returns the rotation state of the account, or nil if the account is not being
+// rotated.
+func (backend *Backend) AccountRotation(code accountsTypes.Code) *config.AccountRotation {
+	accountsConfig := backend.config.AccountsConfig()
+	acct := accountsConfig.Lookup(code)
+	if acct == nil {
+		return nil
+	}
+	return acct.Rotation
+}
+
+// setAccountRotation persists the rotation state of the account and emits `eventStage`. A nil
+// rotation removes the rotation state.
+func (backend *Backend) setAccountRotation(
+	code accountsTypes.Code,
+	rotation *config.AccountRotation,
+	eventStage config.AccountRotationStage,
+) error {
+	var newCode accountsTypes.Code
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(code)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", code)
+		}
+		if rotation == nil && acct.Rotation != nil {
+			newCode = acct.Rotation.NewAccountCode
+		}
+		acct.Rotation = rotation
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rotation != nil {
+		newCode = rotation.NewAccountCode
+	}
+	backend.emitAccountRotationStatus(code, newCode, eventStage)
+	return nil
+}
+
+// StartAccountRotation starts moving all funds of a Bitcoin or Litecoin account to a fresh
+// account. The next account of the same coin and keystore is created (or an unused hidden one is
+// activated), and its code is returned. If the account is already being rotated, the code of the
+// existing new account is returned.
+//
+// The remaining steps are AccountRotationProposal() and SendAccountRotation(), after which the
+// account is archived. The rotation can be aborted using CancelAccountRotation() until the funds
+// are sent.
+func (backend *Backend) StartAccountRotation(code accountsTypes.Code) (accountsTypes.Code, error) {
+	defer backend.accountRotationLock.Lock()()
+	if rotation := backend.AccountRotation(code); rotation != nil {
+		return rotation.NewAccountCode, nil
+	}
+	account, err := backend.GetAccountFromCode(code)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := account.Coin().(*btc.Coin); !ok {
+		return "", errp.New("Only Bitcoin and Litecoin accounts can be rotated")
+	}
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return "", errp.New("Keystore not found")
+	}
+	// The new account is derived from the connected keystore, so it must be the keystore of the
+	// account.
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return "", err
+	}
+	if !account.Config().Config.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+		return "", errp.New("The connected keystore does not belong to the account")
+	}
+	newCode, err := backend.CreateAndPersistAccountConfig(account.Coin().Code(), "", keystore)
+	if err != nil {
+		return "", err
+	}
+	backend.log.WithField("code", code).WithField("new-code", newCode).Info("Started account rotation")
+	err = backend.setAccountRotation(
+		code,
+		&config.AccountRotation{NewAccountCode: newCode, Stage: config.AccountRotationStageCreated},
+		config.AccountRotationStageCreated,
+	)
+	if err != nil {
+		return "", err
+	}
+	return newCode, nil
+}
+
+// rotationAccounts returns the account being rotated and the new account, if the rotation is in
+// the given stage. Both accounts are initialized.
+func (backend *Backend) rotationAccounts(
+	code accountsTypes.Code, stage config.AccountRotationStage) (accounts.Interface, accounts.Interface, error) {
+	rotation := backend.AccountRotation(code)
+	if rotation == nil {
+		return nil, nil, errp.Newf("Account %s is not being rotated", code)
+	}
+	if rotation.Stage != stage {
+		return nil, nil, errp.Newf("Unexpected account rotation stage: %s", rotation.Stage)
+	}
+	account, err := backend.GetAccountFromCode(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	newAccount, err := backend.GetAccountFromCode(rotation.NewAccountCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, newAccount, nil
+}
+
+// rotationRecipientAddress returns the address to which the funds are moved: the first unused
+// receive address of the new account.
+func rotationRecipientAddress(newAccount accounts.Interface) (string, error) {
+	addressLists := newAccount.GetUnusedReceiveAddresses()
+	if len(addressLists) == 0 || len(addressLists[0].Addresses) == 0 {
+		return "", errp.New("The new account has no receive address")
+	}
+	return addressLists[0].Addresses[0].EncodeForHumans(), nil
+}
+
+// AccountRotationProposal prepares the transaction sending all funds of the account to the first
+// unused receive address of the new account. It can be called again, e.g. with a different fee
+// target, until the transaction is sent with SendAccountRotation().
+func (backend *Backend) AccountRotationProposal(
+	code accountsTypes.Code,
+	feeTargetCode accounts.FeeTargetCode,
+	customFee string,
+) (*AccountRotationProposal, error) {
+	defer backend.accountRotationLock.Lock()()
+	if err := backend.checkViewOnly(); err != nil {
+		return nil, err
+	}
+	account, newAccount, err := backend.rotationAccounts(code, config.AccountRotationStageCreated)
+	if err != nil {
+		return nil, err
+	}
+	recipientAddress, err := rotationRecipientAddress(newAccount)
+	if err != nil {
+		return nil, err
+	}
+	args := &accounts.TxProposalArgs{
+		RecipientAddress: recipientAddress,
+		Amount:           coinpkg.NewSendAmountAll(),
+		FeeTargetCode:    feeTargetCode,
+		CustomFee:        customFee,
+	}
+	amount, fee, total, err := account.TxProposal(args)
+	if err != nil {
+		delete(backend.accountRotationProposals, code)
+		return nil, err
+	}
+	backend.accountRotationProposals[code] = args
+	warnings := []AccountRotationWarning{AccountRotationWarningPrivacy}
+	feePercentage := new(big.Int).Mul(fee.BigInt(), big.NewInt(100))
+	if total.BigInt().Sign() > 0 &&
+		feePercentage.Cmp(new(big.Int).Mul(total.BigInt(), big.NewInt(accountRotationHighFeePercentage))) >= 0 {
+		warnings = append(warnings, AccountRotationWarningHighFee)
+	}
+	formatAmount := func(amount coinpkg.Amount, isFee bool) coinpkg.FormattedAmount {
+		return coinpkg.FormatAmountAsJSON(
+			amount,
+			account.Coin(),
+			isFee,
+			account.Config().RateUpdater,
+			util.FormatBtcAsSat(account.Config().BtcCurrencyUnit),
+			false,
+		)
+	}
+	return &AccountRotationProposal{
+		RecipientAddress: recipientAddress,
+		Amount:           formatAmount(amount, false),
+		Fee:              formatAmount(fee, true),
+		Total:            formatAmount(total, false),
+		Warnings:         warnings,
+	}, nil
+}
+
+// SendAccountRotation signs and broadcasts the transaction prepared by AccountRotationProposal(),
+// and archives the account afterwards. If the app is interrupted before the account is archived,
+// the rotation is completed when the account is synced the next time.
+//
+// SendTx() sends the tx proposal made last for the account, which could have been replaced in the
+// meantime, e.g. by the send screen of the account. The transaction is therefore built again from
+// the args of AccountRotationProposal() right before it is sent.
+func (backend *Backend) SendAccountRotation(code accountsTypes.Code) error {
+	defer backend.accountRotationLock.Lock()()
+	if err := backend.checkViewOnly(); err != nil {
+		return err
+	}
+	account, newAccount, err := backend.rotationAccounts(code, config.AccountRotationStageCreated)
+	if err != nil {
+		return err
+	}
+	args, ok := backend.accountRotationProposals[code]
+	if !ok {
+		return errp.New("The account rotation transaction was not proposed")
+	}
+	// The receive address shown to the user must still be the first unused one of the new account.
+	recipientAddress, err := rotationRecipientAddress(newAccount)
+	if err != nil {
+		return err
+	}
+	if recipientAddress != args.RecipientAddress {
+		delete(backend.accountRotationProposals, code)
+		return errp.New("The account rotation transaction is outdated")
+	}
+	if _, _, _, err := account.TxProposal(args); err != nil {
+		return err
+	}
+	newCode := newAccount.Config().Config.Code
+	err = backend.setAccountRotation(
+		code,
+		&config.AccountRotation{NewAccountCode: newCode, Stage: config.AccountRotationStageSending},
+		config.AccountRotationStageSending,
+	)
+	if err != nil {
+		return err
+	}
+	account.ProposeTxNote("Moved to " + newAccount.Config().Config.Name)
+	if err := account.SendTx(); err != nil {
+		// Not sent, the user can try again.
+		rollbackErr := backend.setAccountRotation(
+			code,
+			&config.AccountRotation{NewAccountCode: newCode, Stage: config.AccountRotationStageCreated},
+			config.AccountRotationStageCreated,
+		)
+		if rollbackErr != nil {
+			backend.log.WithError(rollbackErr).Error("Could not reset the account rotation stage")
+		}
+		return err
+	}
+	delete(backend.accountRotationProposals, code)
+	err = backend.setAccountRotation(
+		code,
+		&config.AccountRotation{NewAccountCode: newCode, Stage: config.AccountRotationStageBroadcast},
+		config.AccountRotationStageBroadcast,
+	)
+	if err != nil {
+		return err
+	}
+	return backend.finishAccountRotation(code)
+}
+
+// finishAccountRotation archives the account after the funds were sent.
+func (backend *Backend) finishAccountRotation(code accountsTypes.Code) error {
+	if err := backend.SetAccountActive(code, false); err != nil {
+		return err
+	}
+	backend.log.WithField("code", code).Info("Finished account rotation")
+	return backend.setAccountRotation(code, nil, AccountRotationStageDone)
+}
+
+// CancelAccountRotation aborts the rotation of the account, if the funds were not sent yet. The
+// new account is kept.
+func (backend *Backend) CancelAccountRotation(code accountsTypes.Code) error {
+	defer backend.accountRotationLock.Lock()()
+	rotation := backend.AccountRotation(code)
+	if rotation == nil {
+		return nil
+	}
+	if rotation.Stage != config.AccountRotationStageCreated {
+		return errp.Newf("The account rotation can't be canceled in stage %s", rotation.Stage)
+	}
+	delete(backend.accountRotationProposals, code)
+	return backend.setAccountRotation(code, nil, AccountRotationStageCanceled)
+}
+
+// maybeResumeAccountRotation completes the rotation of the account if the app was interrupted
+// after sending the funds. It is called when the account is synced. If it is unknown whether the
+// transaction was broadcast, it is considered broadcast if the account has no funds left.
+// Otherwise, the rotation goes back to the stage before sending, so the user can try again.
+func (backend *Backend) maybeResumeAccountRotation(account accounts.Interface) {
+	code := account.Config().Config.Code
+	if rotation := backend.AccountRotation(code); rotation == nil ||
+		rotation.Stage == config.AccountRotationStageCreated {
+		return
+	}
+	defer backend.accountRotationLock.Lock()()
+	// Read again, a rotation which was in progress might have finished in the meantime.
+	rotation := backend.AccountRotation(code)
+	if rotation == nil {
+		return
+	}
+	log := backend.log.WithField("code", code)
+	switch rotation.Stage {
+	case config.AccountRotationStageSending:
+		balance, err := account.Balance()
+		if err != nil {
+			log.WithError(err).Error("Could not get the balance to resume the account rotation")
+			return
+		}
+		if balance.Available().BigInt().Sign() != 0 || balance.Incoming().BigInt().Sign() != 0 {
+			log.Info("The account rotation transaction was not broadcast")
+			err := backend.setAccountRotation(
+				code,
+				&config.AccountRotation{
+					NewAccountCode: rotation.NewAccountCode,
+					Stage:          config.AccountRotationStageCreated,
+				},
+				config.AccountRotationStageCreated,
+			)
+			if err != nil {
+				log.WithError(err).Error("Could not reset the account rotation stage")
+			}
+			return
+		}
+		log.Info("Resuming the account rotation")
+		if err := backend.finishAccountRotation(code); err != nil {
+			log.WithError(err).Error("Could not finish the account rotation")
+		}
+	case config.AccountRotationStageBroadcast:
+		log.Info("Resuming the account rotation")
+		if err := backend.finishAccountRotation(code); err != nil {
+			log.WithError(err).Error("Could not finish the account rotation")
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRotation(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerKeystore(ks)
+
+	var stages []config.AccountRotationStage
+	b.Observe(func(event observable.Event) {
+		if event.Subject == "account-rotation" {
+			stages = append(stages, event.Object.(AccountRotationStatus).Stage)
+		}
+	})
+	const code = accountsTypes.Code("v0-55555555-btc-0")
+	const newCode = accountsTypes.Code("v0-55555555-btc-1")
+	mockAccount := func(code accountsTypes.Code) *accountsMocks.InterfaceMock {
+		return b.Accounts().lookup(code).(*accountsMocks.InterfaceMock)
+	}
+
+	// Only BTC based accounts can be rotated.
+	_, err := b.StartAccountRotation("v0-55555555-eth-0")
+	require.Error(t, err)
+	require.Nil(t, b.AccountRotation("v0-55555555-eth-0"))
+
+	// Proposing before starting fails.
+	_, err = b.AccountRotationProposal(code, accounts.FeeTargetCodeNormal, "")
+	require.Error(t, err)
+
+	rotatedCode, err := b.StartAccountRotation(code)
+	require.NoError(t, err)
+	require.Equal(t, newCode, rotatedCode)
+	require.NotNil(t, b.Accounts().lookup(newCode))
+	require.Equal(t,
+		&config.AccountRotation{NewAccountCode: newCode, Stage: config.AccountRotationStageCreated},
+		b.AccountRotation(code))
+	// Starting again continues the same rotation.
+	numAccounts := len(b.Config().AccountsConfig().Accounts)
+	rotatedCode, err = b.StartAccountRotation(code)
+	require.NoError(t, err)
+	require.Equal(t, newCode, rotatedCode)
+	require.Len(t, b.Config().AccountsConfig().Accounts, numAccounts)
+
+	// All funds are sent to the new account.
+	var txProposalArgs *accounts.TxProposalArgs
+	mockAccount(code).TxProposalFunc = func(args *accounts.TxProposalArgs) (
+		coinpkg.Amount, coinpkg.Amount, coinpkg.Amount, error) {
+		txProposalArgs = args
+		return coinpkg.NewAmountFromInt64(9000),
+			coinpkg.NewAmountFromInt64(1000),
+			coinpkg.NewAmountFromInt64(10000),
+			nil
+	}
+	proposal, err := b.AccountRotationProposal(code, accounts.FeeTargetCodeNormal, "")
+	require.NoError(t, err)
+	expectedAddress := mockAccount(newCode).GetUnusedReceiveAddresses()[0].Addresses[0].EncodeForHumans()
+	require.Equal(t, expectedAddress, txProposalArgs.RecipientAddress)
+	require.Equal(t, coinpkg.NewSendAmountAll(), txProposalArgs.Amount)
+	require.Equal(t, accounts.FeeTargetCodeNormal, txProposalArgs.FeeTargetCode)
+	require.Equal(t, expectedAddress, proposal.RecipientAddress)
+	require.Equal(t, "0.00009000", proposal.Amount.Amount)
+	require.Equal(t, "0.00001000", proposal.Fee.Amount)
+	require.Equal(t,
+		[]AccountRotationWarning{AccountRotationWarningPrivacy, AccountRotationWarningHighFee},
+		proposal.Warnings)
+
+	// Sending fails, e.g. because the user aborted on the device. The user can try again.
+	var note string
+	mockAccount(code).ProposeTxNoteFunc = func(n string) { note = n }
+	mockAccount(code).SendTxFunc = func() error { return errors.New("aborted") }
+	stages = nil
+	require.Error(t, b.SendAccountRotation(code))
+	require.Equal(t,
+		[]config.AccountRotationStage{config.AccountRotationStageSending, config.AccountRotationStageCreated},
+		stages)
+	require.Equal(t, config.AccountRotationStageCreated, b.AccountRotation(code).Stage)
+
+	// Sending succeeds, the account is archived. The transaction is built again before sending,
+	// as another tx proposal for the account replaced it in the meantime.
+	rotationArgs := txProposalArgs
+	_, _, _, err = mockAccount(code).TxProposal(&accounts.TxProposalArgs{
+		RecipientAddress: "other",
+		Amount:           coinpkg.NewSendAmount("1"),
+	})
+	require.NoError(t, err)
+	var sentArgs *accounts.TxProposalArgs
+	mockAccount(code).SendTxFunc = func() error {
+		sentArgs = txProposalArgs
+		return nil
+	}
+	stages = nil
+	require.NoError(t, b.SendAccountRotation(code))
+	require.Equal(t, rotationArgs, sentArgs)
+	require.Equal(t,
+		[]config.AccountRotationStage{
+			config.AccountRotationStageSending,
+			config.AccountRotationStageBroadcast,
+			AccountRotationStageDone,
+		},
+		stages)
+	require.Equal(t, "Moved to Bitcoin 2", note)
+	require.Nil(t, b.AccountRotation(code))
+	require.True(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+	require.False(t, b.Config().AccountsConfig().Lookup(newCode).Inactive)
+
+	// Archived accounts can't be rotated.
+	_, err = b.StartAccountRotation(code)
+	require.Error(t, err)
+}
+
+func TestAccountRotationSendWithoutProposal(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerKeystore(ks)
+
+	const code = accountsTypes.Code("v0-55555555-btc-0")
+	mockAccount := b.Accounts().lookup(code).(*accountsMocks.InterfaceMock)
+	sent := false
+	mockAccount.SendTxFunc = func() error {
+		sent = true
+		return nil
+	}
+	_, err := b.StartAccountRotation(code)
+	require.NoError(t, err)
+
+	// Only the transaction of AccountRotationProposal() is sent.
+	require.Error(t, b.SendAccountRotation(code))
+	require.False(t, sent)
+	require.Equal(t, config.AccountRotationStageCreated, b.AccountRotation(code).Stage)
+	require.False(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+}
+
+func TestAccountRotationWrongKeystore(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerKeystore(ks)
+
+	// Another keystore is connected, e.g. after the first one was swapped without being
+	// deregistered.
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint2, nil
+	}
+	ks.ExtendedPublicKeyFunc = keystoreHelper2().ExtendedPublicKey
+	const code = accountsTypes.Code("v0-55555555-btc-0")
+	_, err := b.StartAccountRotation(code)
+	require.Error(t, err)
+	require.Nil(t, b.AccountRotation(code))
+	// The other keystore's first account can be added in the background as a hidden unused
+	// account, but the rotation must not have added it as a new account.
+	if newAccount := b.Config().AccountsConfig().Lookup("v0-66666666-btc-0"); newAccount != nil {
+		require.True(t, newAccount.HiddenBecauseUnused)
+	}
+}
+
+func TestAccountRotationCancel(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerKeystore(ks)
+
+	const code = accountsTypes.Code("v0-55555555-btc-0")
+	require.NoError(t, b.CancelAccountRotation(code))
+	_, err := b.StartAccountRotation(code)
+	require.NoError(t, err)
+	require.NoError(t, b.CancelAccountRotation(code))
+	require.Nil(t, b.AccountRotation(code))
+	// The new account is kept.
+	require.NotNil(t, b.Accounts().lookup("v0-55555555-btc-1"))
+	require.False(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+}
+
+func TestAccountRotationResume(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerKeystore(ks)
+
+	const code = accountsTypes.Code("v0-55555555-btc-0")
+	newCode, err := b.StartAccountRotation(code)
+	require.NoError(t, err)
+	// Simulate an app interrupted while sending.
+	setStage := func(stage config.AccountRotationStage) {
+		require.NoError(t, b.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+			accountsConfig.Lookup(code).Rotation = &config.AccountRotation{NewAccountCode: newCode, Stage: stage}
+			return nil
+		}))
+	}
+	setStage(config.AccountRotationStageSending)
+	account := b.Accounts().lookup(code).(*accountsMocks.InterfaceMock)
+
+	// Funds left: the tx was not broadcast.
+	account.BalanceFunc = func() (*accounts.Balance, error) {
+		return accounts.NewBalance(coinpkg.NewAmountFromInt64(1), coinpkg.NewAmountFromInt64(0)), nil
+	}
+	b.maybeResumeAccountRotation(account)
+	require.Equal(t, config.AccountRotationStageCreated, b.AccountRotation(code).Stage)
+	require.False(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+
+	// Nothing happens in the created stage.
+	b.maybeResumeAccountRotation(account)
+	require.Equal(t, config.AccountRotationStageCreated, b.AccountRotation(code).Stage)
+
+	// No funds left: the tx was broadcast.
+	setStage(config.AccountRotationStageSending)
+	account.BalanceFunc = func() (*accounts.Balance, error) {
+		return accounts.NewBalance(coinpkg.NewAmountFromInt64(0), coinpkg.NewAmountFromInt64(0)), nil
+	}
+	b.maybeResumeAccountRotation(account)
+	require.Nil(t, b.AccountRotation(code))
+	require.True(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+
+	// Interrupted after broadcasting.
+	require.NoError(t, b.SetAccountActive(code, true))
+	setStage(config.AccountRotationStageBroadcast)
+	b.maybeResumeAccountRotation(b.Accounts().lookup(code))
+	require.Nil(t, b.AccountRotation(code))
+	require.True(t, b.Config().AccountsConfig().Lookup(code).Inactive)
+}
@@ -22,8 +22,10 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
@@ -46,16 +48,33 @@ const (
 	errAccountAlreadyExists errp.ErrorCode = "accountAlreadyExists"
 	// ErrAccountLimitReached is returned when adding an account if no more accounts can be added.
 	errAccountLimitReached errp.ErrorCode = "accountLimitReached"
+	// errAccountPreviousUnused is returned when adding an account after an account without
+	// transactions, beyond the accounts which are always scanned, see accountsLegacyScanCount.
+	errAccountPreviousUnused errp.ErrorCode = "accountPreviousUnused"
+	// errAccountNameEmpty is returned when renaming an account to an empty or blank name.
+	errAccountNameEmpty errp.ErrorCode = "accountNameEmpty"
+	// errAccountNameTooLong is returned when renaming an account to a name longer than
+	// maxAccountNameLength.
+	errAccountNameTooLong errp.ErrorCode = "accountNameTooLong"
 )
 
+// maxAccountNameLength is the maximum number of characters (runes) of an account name.
+const maxAccountNameLength = 64
+
 // hardenedKeystart is the BIP44 offset to make a keypath element hardened.
 const hardenedKeystart uint32 = hdkeychain.HardenedKeyStart
 
-// accountsHardlimit is the maximum possible number of accounts per coin and keystore.  This is
-// useful in recovery, so we can scan a fixed number of accounts to discover all funds.  The
-// alternative (or a complement) would be an accounts gap limit, similar to Bitcoin's address gap
-// limit, but simply use a hard limit for simplicity.
-const accountsHardLimit = 5
+// accountsHardLimit is the maximum possible number of accounts per coin and keystore. The account
+// number is the account' element of the BIP44 keypath, which the BitBox02 limits to at most 99.
+// Accounts are discovered sequentially, stopping after the first unused account, see
+// `maybeAddHiddenUnusedAccounts()`.
+const accountsHardLimit = 100
+
+// accountsLegacyScanCount is the number of accounts which are always scanned during accounts
+// discovery, even if they are unused. Before accounts discovery was introduced, the BitBoxApp
+// allowed the manual creation of this many accounts, so these could be used without the previous
+// ones being used.
+const accountsLegacyScanCount = 5
 
 // AccountsList is an accounts.Interface slice which implements a lookup method.
 type AccountsList []accounts.Interface
@@ -102,10 +121,12 @@ func sortAccounts(accounts []accounts.Interface) {
 	compareCoin := func(coin1, coin2 coinpkg.Coin) int {
 		getOrder := func(c coinpkg.Coin) (int, bool) {
 			order, ok := map[coinpkg.Code]int{
-				coinpkg.CodeBTC:  0,
-				coinpkg.CodeTBTC: 1,
-				coinpkg.CodeLTC:  2,
-				coinpkg.CodeTLTC: 3,
+				coinpkg.CodeBTC:   0,
+				coinpkg.CodeTBTC:  1,
+				coinpkg.CodeTBTC4: 2,
+				coinpkg.CodeSBTC:  3,
+				coinpkg.CodeLTC:   4,
+				coinpkg.CodeTLTC:  5,
 			}[c.Code()]
 			if ok {
 				return order, true
@@ -116,11 +137,11 @@ func sortAccounts(accounts []accounts.Interface) {
 			if ok {
 				switch ethCoin.ChainID() {
 				case params.MainnetChainConfig.ChainID.Uint64():
-					return 4, true
+					return 6, true
 				case params.GoerliChainConfig.ChainID.Uint64():
-					return 5, true
+					return 7, true
 				case params.SepoliaChainConfig.ChainID.Uint64():
-					return 6, true
+					return 8, true
 				}
 			}
 			return 0, false
@@ -199,14 +220,15 @@ func (backend *Backend) filterAccounts(accountsConfig *config.AccountsConfig, fi
 	return accounts
 }
 
-// SupportedCoins returns the list of coins that can be used with the given keystore.
-func (backend *Backend) SupportedCoins(keystore keystore.Keystore) []coinpkg.Code {
+// networkCoinCodes returns the codes of the coins (excluding tokens) of the network the backend
+// runs on: mainnet, testnet or regtest.
+func (backend *Backend) networkCoinCodes() []coinpkg.Code {
 	allCoins := []coinpkg.Code{
-		coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC,
+		coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC,
 		coinpkg.CodeLTC, coinpkg.CodeTLTC,
 		coinpkg.CodeETH, coinpkg.CodeGOETH, coinpkg.CodeSEPETH,
 	}
-	var availableCoins []coinpkg.Code
+	var coinCodes []coinpkg.Code
 	for _, coinCode := range allCoins {
 		if _, isTestnet := coinpkg.TestnetCoins[coinCode]; !backend.arguments.Regtest() && isTestnet != backend.Testing() {
 			// Don't load testnet accounts when running normally, nor mainnet accounts when running
@@ -218,6 +240,15 @@ func (backend *Backend) SupportedCoins(keystore keystore.Keystore) []coinpkg.Cod
 			// in regtest mode.
 			continue
 		}
+		coinCodes = append(coinCodes, coinCode)
+	}
+	return coinCodes
+}
+
+// SupportedCoins returns the list of coins that can be used with the given keystore.
+func (backend *Backend) SupportedCoins(keystore keystore.Keystore) []coinpkg.Code {
+	var availableCoins []coinpkg.Code
+	for _, coinCode := range backend.networkCoinCodes() {
 		coin, err := backend.Coin(coinCode)
 		if err != nil {
 			backend.log.WithError(err).Errorf("AvailableCoins")
@@ -262,7 +293,7 @@ func (backend *Backend) accountFiatBalance(account accounts.Interface, fiat stri
 	}
 	fiatValue := new(big.Rat).Mul(
 		new(big.Rat).SetFrac(
-			balance.Available().BigInt(),
+			balance.Owned().BigInt(),
 			coinDecimals,
 		),
 		new(big.Rat).SetFloat64(price),
@@ -432,39 +463,15 @@ func (backend *Backend) createAndPersistAccountConfig(
 		WithField("coinCode", coinCode).
 		WithField("accountNumber", accountNumber)
 	log.Info("Persisting new account config")
-	accountNumberHardened := uint32(accountNumber) + hardenedKeystart
 
 	switch coinCode {
-	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
-		bip44Coin := 1 + hardenedKeystart
-		if coinCode == coinpkg.CodeBTC {
-			bip44Coin = hardenedKeystart
-		}
-		return accountCode, backend.persistBTCAccountConfig(keystore, coin,
-			accountCode,
-			hiddenBecauseUnused,
-			name,
-			[]scriptTypeWithKeypath{
-				{signing.ScriptTypeP2WPKH, signing.NewAbsoluteKeypathFromUint32(84+hardenedKeystart, bip44Coin, accountNumberHardened)},
-				{signing.ScriptTypeP2TR, signing.NewAbsoluteKeypathFromUint32(86+hardenedKeystart, bip44Coin, accountNumberHardened)},
-				{signing.ScriptTypeP2WPKHP2SH, signing.NewAbsoluteKeypathFromUint32(49+hardenedKeystart, bip44Coin, accountNumberHardened)},
-				{signing.ScriptTypeP2PKH, signing.NewAbsoluteKeypathFromUint32(44+hardenedKeystart, bip44Coin, accountNumberHardened)},
-			},
-			accountsConfig,
-		)
-	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
-		bip44Coin := 1 + hardenedKeystart
-		if coinCode == coinpkg.CodeLTC {
-			bip44Coin = 2 + hardenedKeystart
-		}
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC,
+		coinpkg.CodeLTC, coinpkg.CodeTLTC:
 		return accountCode, backend.persistBTCAccountConfig(keystore, coin,
 			accountCode,
 			hiddenBecauseUnused,
 			name,
-			[]scriptTypeWithKeypath{
-				{signing.ScriptTypeP2WPKH, signing.NewAbsoluteKeypathFromUint32(84+hardenedKeystart, bip44Coin, accountNumberHardened)},
-				{signing.ScriptTypeP2WPKHP2SH, signing.NewAbsoluteKeypathFromUint32(49+hardenedKeystart, bip44Coin, accountNumberHardened)},
-			},
+			btcScriptTypesWithKeypaths(coinCode, accountNumber),
 			accountsConfig,
 		)
 	case coinpkg.CodeETH, coinpkg.CodeGOETH, coinpkg.CodeSEPETH:
@@ -527,6 +534,9 @@ func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accoun
 		return 0, err
 	}
 	nextAccountNumber := uint16(0)
+	// Whether the account with the highest account number has transactions. Legacy accounts can
+	// have multiple configs with the same account number.
+	lastUsed := false
 	for _, account := range accountsConfig.Accounts {
 		if coinCode != account.CoinCode {
 			continue
@@ -541,8 +551,12 @@ func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accoun
 		if err != nil {
 			continue
 		}
-		if accountNumber+1 > nextAccountNumber {
+		switch {
+		case accountNumber+1 > nextAccountNumber:
 			nextAccountNumber = accountNumber + 1
+			lastUsed = account.Used
+		case accountNumber+1 == nextAccountNumber:
+			lastUsed = lastUsed || account.Used
 		}
 	}
 	if !keystore.SupportsMultipleAccounts() && nextAccountNumber >= 1 {
@@ -552,6 +566,12 @@ func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accoun
 	if nextAccountNumber >= accountsHardLimit {
 		return 0, errp.WithStack(errAccountLimitReached)
 	}
+	// Accounts discovery stops at the first account without transactions after the first
+	// accountsLegacyScanCount accounts, so an account added after it would not be found again when
+	// restoring the wallet (BIP44: no new account if the previous one has no transaction history).
+	if nextAccountNumber >= accountsLegacyScanCount && !lastUsed {
+		return 0, errp.WithStack(errAccountPreviousUnused)
+	}
 	return nextAccountNumber, nil
 }
 
@@ -667,10 +687,16 @@ func (backend *Backend) SetTokenActive(accountCode accountsTypes.Code, tokenCode
 	return nil
 }
 
-// RenameAccount renames an account in the accounts database.
+// RenameAccount renames an account in the accounts database. Leading and trailing whitespace is
+// removed from the name. The account code, which identifies the account e.g. in its database
+// folder, does not change.
 func (backend *Backend) RenameAccount(accountCode accountsTypes.Code, name string) error {
+	name = strings.TrimSpace(name)
 	if name == "" {
-		return errp.New("Name cannot be empty")
+		return errp.WithStack(errAccountNameEmpty)
+	}
+	if utf8.RuneCountInString(name) > maxAccountNameLength {
+		return errp.WithStack(errAccountNameTooLong)
 	}
 	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
 		acct := accountsConfig.Lookup(accountCode)
@@ -687,6 +713,150 @@ func (backend *Backend) RenameAccount(accountCode accountsTypes.Code, name strin
 	return nil
 }
 
+// SetAccountShowUsedAddresses sets whether receive addresses which already have a transaction
+// history are listed when receiving.
+func (backend *Backend) SetAccountShowUsedAddresses(accountCode accountsTypes.Code, show bool) error {
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		acct.ShowUsedAddresses = show
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.emitAccountsStatusChanged()
+	return nil
+}
+
+// SetAccountPreferredScriptType sets the script type of the receive addresses which are handed out
+// first in a unified account. The script type must be one of the script types the account can
+// receive on. The addresses of the other script types are still watched. An empty
+// script type resets the preference to the order of the signing configurations.
+func (backend *Backend) SetAccountPreferredScriptType(
+	accountCode accountsTypes.Code, scriptType signing.ScriptType) error {
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if scriptType == "" {
+			acct.PreferredScriptType = ""
+			return nil
+		}
+		// Insured accounts can only receive on native segwit.
+		if acct.InsuranceStatus == string(bitsurance.ActiveStatus) && scriptType != signing.ScriptTypeP2WPKH {
+			return errp.Newf("Account %s can't receive on script type %s", accountCode, scriptType)
+		}
+		for _, signingConfig := range acct.SigningConfigurations {
+			if signingConfig.BitcoinSimple != nil && signingConfig.ScriptType() == scriptType {
+				acct.PreferredScriptType = scriptType
+				return nil
+			}
+		}
+		return errp.Newf("Account %s has no configuration of script type %s", accountCode, scriptType)
+	})
+	if err != nil {
+		return err
+	}
+	backend.emitAccountsStatusChanged()
+	// Prompt a refresh of the receive addresses.
+	backend.emitAccountEvent(accountCode, accountsTypes.EventStatusChanged)
+	return nil
+}
+
+// maxMinConfirmations limits the MinConfirmations setting of accounts.
+const maxMinConfirmations = 100
+
+// SetAccountMinConfirmations sets the number of confirmations outputs need to be spent, and
+// whether our own unconfirmed outputs, e.g. change, can be spent before.
+func (backend *Backend) SetAccountMinConfirmations(
+	accountCode accountsTypes.Code, minConfirmations int, spendUnconfirmedChange bool) error {
+	if minConfirmations < 0 || minConfirmations > maxMinConfirmations {
+		return errp.Newf("minConfirmations must be between 0 and %d", maxMinConfirmations)
+	}
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if btcScriptTypesWithKeypaths(acct.CoinCode, 0) == nil {
+			// Only Bitcoin-based accounts spend outputs.
+			return errp.Newf("minConfirmations is not supported for account %s", accountCode)
+		}
+		acct.MinConfirmations = minConfirmations
+		acct.SpendUnconfirmedChange = spendUnconfirmedChange
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.emitAccountsStatusChanged()
+	// Whether our own unconfirmed change is available or pending depends on the settings.
+	backend.emitAccountEvent(accountCode, accountsTypes.EventStatusChanged)
+	return nil
+}
+
+// maxDustThreshold limits the DustThreshold setting of accounts in satoshi, so that a typo can't
+// hide the coins of an account.
+const maxDustThreshold = 10000
+
+// SetAccountDustThreshold sets the value in satoshi below which received outputs are hidden, and
+// whether outputs of our own transactions are shown nevertheless, see config.Account.DustThreshold.
+func (backend *Backend) SetAccountDustThreshold(
+	accountCode accountsTypes.Code, dustThreshold int64, showOwnDust bool) error {
+	if dustThreshold < 0 || dustThreshold > maxDustThreshold {
+		return errp.Newf("dustThreshold must be between 0 and %d", maxDustThreshold)
+	}
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if btcScriptTypesWithKeypaths(acct.CoinCode, 0) == nil {
+			// Only Bitcoin-based accounts receive outputs.
+			return errp.Newf("dustThreshold is not supported for account %s", accountCode)
+		}
+		acct.DustThreshold = dustThreshold
+		acct.ShowOwnDust = showOwnDust
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.emitAccountsStatusChanged()
+	// The balance and the listed transactions depend on the setting.
+	backend.emitAccountEvent(accountCode, accountsTypes.EventStatusChanged)
+	return nil
+}
+
+// SetAccountRequireVerifiedTxs sets whether confirmed transactions paying to the account are only
+// available once they were verified against the block headers, see
+// config.Account.RequireVerifiedTxs.
+func (backend *Backend) SetAccountRequireVerifiedTxs(accountCode accountsTypes.Code, require bool) error {
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if btcScriptTypesWithKeypaths(acct.CoinCode, 0) == nil {
+			// Only Bitcoin-based accounts verify transactions against the block headers.
+			return errp.Newf("requireVerifiedTxs is not supported for account %s", accountCode)
+		}
+		acct.RequireVerifiedTxs = require
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.emitAccountsStatusChanged()
+	// Whether unverified payments are available or incoming depends on the setting.
+	backend.emitAccountEvent(accountCode, accountsTypes.EventStatusChanged)
+	return nil
+}
+
 // copyBool makes a copy, so that multiple values do not share the same reference. This avoids
 // potential future bugs if someone modified a flag like `*account.Watch = X`,
 // accidentally changing the value for many accounts that share the same reference.
@@ -744,7 +914,8 @@ func (backend *Backend) addAccount(account accounts.Interface) {
 	backend.accounts = append(backend.accounts, account)
 	sortAccounts(backend.accounts)
 
-	account.Observe(backend.Notify)
+	code := account.Config().Config.Code
+	backend.accountObservations.relaying(code, account.Observe(backend.notifyAccountEvent(code)))
 	if backend.onAccountInit != nil {
 		backend.onAccountInit(account)
 	}
@@ -769,6 +940,10 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 				ErrorCode    string `json:"errorCode,omitempty"`
 				ErrorMessage string `json:"errorMessage"`
 			}
+			if persistedConfig.WatchOnly {
+				// There is no keystore to connect to.
+				return nil, errp.WithStack(errors.ErrWatchOnly)
+			}
 			accountRootFingerprint, err := persistedConfig.SigningConfigurations.RootFingerprint()
 			if err != nil {
 				return nil, err
@@ -859,21 +1034,44 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 			return ks, err
 		},
 		OnEvent: func(event accountsTypes.Event) {
-			backend.events <- AccountEvent{
-				Type: "account", Code: persistedConfig.Code,
-				Data: string(event),
+			if event == accountsTypes.EventSyncProgress {
+				if account != nil {
+					backend.emitAccountEventWithMeta(persistedConfig.Code, event, account.SyncProgress())
+				}
+				return
 			}
+			backend.emitAccountEvent(persistedConfig.Code, event)
 			if account != nil && event == accountsTypes.EventSyncDone {
 				backend.notifyNewTxs(account)
+				// The transactions are fetched asynchronously, as the synchronizer is still locked
+				// while this event is being handled.
+				go backend.emitNewTransactionEvents(account)
+				go backend.maybeResumeAccountRotation(account)
 			}
 		},
 		RateUpdater: backend.ratesUpdater,
 		GetNotifier: func(configurations signing.Configurations) accounts.Notifier {
-			return backend.notifier.ForAccount(persistedConfig.Code)
+			rootFingerprint, err := configurations.RootFingerprint()
+			if err != nil {
+				backend.log.WithError(err).Error("Could not get the root fingerprint of the account")
+			}
+			return backend.notifier.ForAccount(persistedConfig.Code, rootFingerprint)
 		},
 		GetSaveFilename:  backend.environment.GetSaveFilename,
 		UnsafeSystemOpen: backend.environment.SystemOpen,
 		BtcCurrencyUnit:  backend.config.AppConfig().Backend.BtcUnit,
+		IsObserved: func() bool {
+			return backend.accountObservations.observed(persistedConfig.Code)
+		},
+		ModifyConfig: func(f func(*config.Account) error) error {
+			return backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+				acct := accountsConfig.Lookup(persistedConfig.Code)
+				if acct == nil {
+					return errp.Newf("Could not find account %s", persistedConfig.Code)
+				}
+				return f(acct)
+			})
+		},
 	}
 
 	switch specificCoin := coin.(type) {
@@ -971,6 +1169,36 @@ type scriptTypeWithKeypath struct {
 	keypath    signing.AbsoluteKeypath
 }
 
+// btcScriptTypesWithKeypaths returns the script types of a new account of a Bitcoin-based coin,
+// with their account-level keypaths. Returns nil for other coins.
+func btcScriptTypesWithKeypaths(coinCode coinpkg.Code, accountNumber uint16) []scriptTypeWithKeypath {
+	accountNumberHardened := uint32(accountNumber) + hardenedKeystart
+	switch coinCode {
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC:
+		bip44Coin := 1 + hardenedKeystart
+		if coinCode == coinpkg.CodeBTC {
+			bip44Coin = hardenedKeystart
+		}
+		return []scriptTypeWithKeypath{
+			{signing.ScriptTypeP2WPKH, signing.NewAbsoluteKeypathFromUint32(84+hardenedKeystart, bip44Coin, accountNumberHardened)},
+			{signing.ScriptTypeP2TR, signing.NewAbsoluteKeypathFromUint32(86+hardenedKeystart, bip44Coin, accountNumberHardened)},
+			{signing.ScriptTypeP2WPKHP2SH, signing.NewAbsoluteKeypathFromUint32(49+hardenedKeystart, bip44Coin, accountNumberHardened)},
+			{signing.ScriptTypeP2PKH, signing.NewAbsoluteKeypathFromUint32(44+hardenedKeystart, bip44Coin, accountNumberHardened)},
+		}
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		bip44Coin := 1 + hardenedKeystart
+		if coinCode == coinpkg.CodeLTC {
+			bip44Coin = 2 + hardenedKeystart
+		}
+		return []scriptTypeWithKeypath{
+			{signing.ScriptTypeP2WPKH, signing.NewAbsoluteKeypathFromUint32(84+hardenedKeystart, bip44Coin, accountNumberHardened)},
+			{signing.ScriptTypeP2WPKHP2SH, signing.NewAbsoluteKeypathFromUint32(49+hardenedKeystart, bip44Coin, accountNumberHardened)},
+		}
+	default:
+		return nil
+	}
+}
+
 // adds a combined BTC account with the given script types.
 func (backend *Backend) persistBTCAccountConfig(
 	keystore keystore.Keystore,
@@ -1256,6 +1484,8 @@ func (backend *Backend) maybeAddP2TR(keystore keystore.Keystore, accounts []*con
 	for _, account := range accounts {
 		if account.CoinCode == coinpkg.CodeBTC ||
 			account.CoinCode == coinpkg.CodeTBTC ||
+			account.CoinCode == coinpkg.CodeTBTC4 ||
+			account.CoinCode == coinpkg.CodeSBTC ||
 			account.CoinCode == coinpkg.CodeRBTC {
 			coin, err := backend.Coin(account.CoinCode)
 			if err != nil {
@@ -1315,6 +1545,7 @@ func (backend *Backend) initAccounts(force bool) {
 	backend.uninitAccounts(force)
 
 	backend.initPersistedAccounts()
+	backend.closeUnusedCoins()
 
 	backend.emitAccountsStatusChanged()
 
@@ -1335,6 +1566,27 @@ func (backend *Backend) ReinitializeAccounts() {
 	backend.initAccounts(true)
 }
 
+// closeUnusedCoins closes the coins which are not used by any account anymore, e.g. after their
+// accounts were deactivated, releasing their server connections and headers DB. A closed coin is
+// initialized again when an account using it is initialized.
+//
+// The accountsAndKeystoreLock must be held when calling this function.
+func (backend *Backend) closeUnusedCoins() {
+	used := map[coinpkg.Code]struct{}{}
+	for _, account := range backend.accounts {
+		used[account.Coin().Code()] = struct{}{}
+	}
+	defer backend.coinsLock.Lock()()
+	for code, coin := range backend.coins {
+		if _, ok := used[code]; ok {
+			continue
+		}
+		if err := coin.Close(); err != nil {
+			backend.log.WithError(err).WithField("code", code).Error("Could not close unused coin")
+		}
+	}
+}
+
 // The accountsAndKeystoreLock must be held when calling this function.
 // if force is true, all accounts are uninitialized, even if they are watch-only.
 func (backend *Backend) uninitAccounts(force bool) {
@@ -1362,14 +1614,22 @@ func (backend *Backend) uninitAccounts(force bool) {
 			keep = append(keep, account)
 			continue
 		}
-		if backend.onAccountUninit != nil {
-			backend.onAccountUninit(account)
-		}
-		account.Close()
+		backend.closeAccount(account)
 	}
 	backend.accounts = keep
 }
 
+// closeAccount stops relaying the events of the account and closes it. The caller removes it from
+// the accounts.
+// The accountsAndKeystoreLock must be held when calling this function.
+func (backend *Backend) closeAccount(account accounts.Interface) {
+	backend.accountObservations.stopRelayingEvents(account.Config().Config.Code)
+	if backend.onAccountUninit != nil {
+		backend.onAccountUninit(account)
+	}
+	account.Close()
+}
+
 // maybeAddHiddenUnusedAccounts adds a hidden account for scanning to facilitate accounts discovery.
 // A hidden account is added per coin if:
 //   - the highest account is used (so another one needs to be scanned) OR
@@ -1430,11 +1690,23 @@ func (backend *Backend) maybeAddHiddenUnusedAccounts() {
 				maxAccount = accountConfig
 			}
 		}
+		if ks, err := cfg.LookupKeystore(rootFingerprint); err == nil {
+			if progress, ok := ks.AccountsDiscovery[coinCode]; ok && progress.Canceled {
+				log.Info("accounts discovery canceled, not adding a hidden account")
+				return nil
+			}
+		}
 		// Account scan gap limit:
 		// - Previous account must be used for the next one to be scanned, but:
 		// - The first 5 accounts are always scanned as before we had accounts discovery, the
 		//   BitBoxApp allowed manual creation of 5 accounts, so we need to always scan these.
-		if maxAccount == nil || maxAccount.Used || maxAccountNumber < accountsHardLimit {
+		//   The wallet birthday does not change this, as the seed can have been used with an older
+		//   BitBoxApp before it was restored. It only bounds the headers sync.
+		// - No account is added beyond the hard limit.
+		if maxAccountNumber+1 >= accountsHardLimit {
+			return nil
+		}
+		if maxAccount == nil || maxAccount.Used || maxAccountNumber < accountsLegacyScanCount {
 			accountCode, err := backend.createAndPersistAccountConfig(
 				coinCode,
 				uint16(maxAccountNumber+1),
@@ -1457,17 +1729,7 @@ func (backend *Backend) maybeAddHiddenUnusedAccounts() {
 		return nil
 	}
 
-	// Enable accounts discovery for these coins.
-	var coinCodes []coinpkg.Code
-	switch {
-	case backend.arguments.Regtest():
-		coinCodes = []coinpkg.Code{coinpkg.CodeRBTC}
-	case backend.arguments.Testing():
-		coinCodes = []coinpkg.Code{coinpkg.CodeTBTC, coinpkg.CodeTLTC}
-	default:
-		coinCodes = []coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeLTC}
-	}
-	for _, coinCode := range coinCodes {
+	for _, coinCode := range backend.accountsDiscoveryCoinCodes() {
 		var newAccountCode *accountsTypes.Code
 		err = backend.config.ModifyAccountsConfig(func(cfg *config.AccountsConfig) error {
 			newAccountCode = do(cfg, coinCode)
@@ -1504,21 +1766,41 @@ func (backend *Backend) checkAccountUsed(account accounts.Interface) {
 		}
 	}
 	log := backend.log.WithField("accountCode", account.Config().Config.Code)
+	if account.Config().Config.Used {
+		// An account stays used, so the discovery does not need to initialize it to check it
+		// again. This does not keep active accounts from syncing at startup, as e.g. the account
+		// summary (see ChartData()) initializes all of them, but archived accounts are only synced
+		// when they are needed.
+		backend.setAccountDiscoveryChecked(account)
+		if rootFingerprint, err := account.Config().Config.SigningConfigurations.RootFingerprint(); err == nil {
+			backend.emitAccountsDiscovery(rootFingerprint, account.Config().Config.CoinCode)
+		}
+		return
+	}
 	if err := account.Initialize(); err != nil {
 		log.WithError(err).Error("error initializing account")
+		backend.setAccountDiscoveryFailure(account, ErrAccountsDiscoveryInitialize)
 		return
 	}
 	txs, err := account.Transactions()
 	if err != nil {
 		log.WithError(err).Error("discoverAccount")
+		backend.setAccountDiscoveryFailure(account, ErrAccountsDiscoveryTransactions)
 		return
 	}
+	backend.setAccountDiscoveryChecked(account)
+	// Emitted after the next hidden account was added, so the discovery is not reported as done in
+	// between.
+	if rootFingerprint, err := account.Config().Config.SigningConfigurations.RootFingerprint(); err == nil {
+		defer backend.emitAccountsDiscovery(rootFingerprint, account.Config().Config.CoinCode)
+	}
 
 	if len(txs) == 0 {
 		// Invoke this here too because even if an account is unused, we scan up to 5 accounts.
 		backend.maybeAddHiddenUnusedAccounts()
 		return
 	}
+	backend.checkWalletBirthday(account, txs)
 	log.Info("marking account as used")
 	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
 		acct := accountsConfig.Lookup(account.Config().Config.Code)
@@ -1549,6 +1831,41 @@ func (backend *Backend) checkAccountUsed(account accounts.Interface) {
 	backend.maybeAddHiddenUnusedAccounts()
 }
 
+// checkWalletBirthday discards the wallet birthday of the account's keystore if the account has
+// transactions before the birthday, so that the headers sync does not skip the headers before it.
+func (backend *Backend) checkWalletBirthday(account accounts.Interface, txs accounts.OrderedTransactions) {
+	log := backend.log.WithField("accountCode", account.Config().Config.Code)
+	rootFingerprint, err := account.Config().Config.SigningConfigurations.RootFingerprint()
+	if err != nil {
+		log.WithError(err).Error("checkWalletBirthday")
+		return
+	}
+	discarded := false
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		ks, err := accountsConfig.LookupKeystore(rootFingerprint)
+		if err != nil || ks.Birthday == nil {
+			return nil
+		}
+		for _, tx := range txs {
+			if ks.Birthday.IsBefore(tx.Height, tx.Timestamp) {
+				log.WithField("txID", tx.TxID).Warning(
+					"transaction before the wallet birthday found, discarding the birthday")
+				ks.Birthday = nil
+				discarded = true
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("checkWalletBirthday")
+		return
+	}
+	if discarded {
+		backend.updateHeadersBirthday()
+	}
+}
+
 // LookupEthAccountCode takes an Ethereum address and returns the corresponding account code and account name
 // Used for handling Wallet Connect requests from anywhere in the app
 // Implemented only for pure ETH accounts (not ERC20s), as all Wallet Connect interactions are handled through the root ETH accounts.
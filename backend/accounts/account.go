@@ -19,7 +19,9 @@ import (
 	"io"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/btcsuite/btcd/wire"
@@ -42,6 +44,28 @@ type TxProposalArgs struct {
 	CustomFee     string
 	SelectedUTXOs map[wire.OutPoint]struct{}
 	Note          string
+	// OpReturnData is embedded in an OP_RETURN output if not empty. Only applies to BTC/LTC.
+	OpReturnData []byte
+	// SelectionSeed, if not nil, replaces the random seed of the order of the inputs and outputs, so
+	// that the transaction can be reproduced from the same UTXO set. Only applies to BTC/LTC.
+	SelectionSeed *int64
+	// Recipients, if not empty, replaces RecipientAddress and Amount to pay multiple recipients in a
+	// single transaction. Sending all funds is only possible with a single recipient. Only applies
+	// to BTC/LTC.
+	Recipients []Recipient
+	// SpendFrozen allows spending frozen outputs if they are selected explicitly in SelectedUTXOs.
+	// Only applies to BTC/LTC.
+	SpendFrozen bool
+	// CoverUnconfirmedParents raises the fee if unconfirmed outputs, e.g. change, are spent, so that
+	// the transaction and the unconfirmed transactions it spends from pay the fee rate together.
+	// Only applies to BTC/LTC.
+	CoverUnconfirmedParents bool
+}
+
+// Recipient is an address and the amount to pay to it.
+type Recipient struct {
+	Address string
+	Amount  coin.SendAmount
 }
 
 // Interface is the API of a Account.
@@ -62,6 +86,8 @@ type Interface interface {
 	Initialize() error
 	// Synced indicates whether the account has loaded and finished the initial sync.
 	Synced() bool
+	// SyncProgress returns the progress of the current sync. See types.EventSyncProgress.
+	SyncProgress() types.SyncProgress
 	// If there was a network connection issue, this returns the network error.
 	Offline() error
 	// FatalError indicates that there was a fatal error in handling the account. When this happens,
@@ -78,6 +104,14 @@ type Interface interface {
 	SendTx() error
 	FeeTargets() ([]FeeTarget, FeeTargetCode)
 	TxProposal(*TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error)
+	// PinnedRatesSnapshot returns the rates snapshot pinned when the first tx proposal of the
+	// current send was created. All fiat values of the proposal should be computed using this
+	// snapshot. Returns nil if there is no active proposal, if it was sent already, or if the pin
+	// expired, in which case the proposal can't be sent anymore.
+	PinnedRatesSnapshot() *rates.Snapshot
+	// SentConversions returns the fiat values of a transaction sent by the app, converted with the
+	// rates pinned while it was proposed. Returns nil if none were stored.
+	SentConversions(txID string) *notes.SentConversions
 	// GetUnusedReceiveAddresses gets a list of list of receive addresses. The result can be one
 	// list of addresses, or if there are multiple types of addresses (e.g. `bc1...` vs `3...`), a
 	// list of lists.
@@ -100,4 +134,7 @@ type Interface interface {
 // Info holds account information.
 type Info struct {
 	SigningConfigurations []*signing.Configuration `json:"signingConfigurations"`
+	// Descriptors are the output descriptors of the Bitcoin signing configurations, in the same
+	// order, e.g. to import the account as a watch-only wallet into Bitcoin Core.
+	Descriptors []*signing.Descriptors `json:"descriptors,omitempty"`
 }
@@ -14,24 +14,84 @@
 
 package accounts
 
-import "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+import (
+	"math/big"
 
-// Balance contains the available and incoming balance of an account.
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// Balance contains the available, incoming, frozen, pending change and unverified balance of an
+// account.
 type Balance struct {
-	available coin.Amount
-	incoming  coin.Amount
+	available     coin.Amount
+	incoming      coin.Amount
+	frozen        coin.Amount
+	pendingChange coin.Amount
+	unverified    coin.Amount
 }
 
 // NewBalance creates a new balance with the given amounts.
 func NewBalance(available coin.Amount, incoming coin.Amount) *Balance {
 	return &Balance{
-		available: available,
-		incoming:  incoming,
+		available:     available,
+		incoming:      incoming,
+		frozen:        coin.NewAmountFromInt64(0),
+		pendingChange: coin.NewAmountFromInt64(0),
+		unverified:    coin.NewAmountFromInt64(0),
+	}
+}
+
+// WithoutHidden returns a copy of the balance where the given amounts of coins the user does not
+// want to see, e.g. dust, are removed from the available and incoming balance.
+func (balance *Balance) WithoutHidden(available coin.Amount, incoming coin.Amount) *Balance {
+	return &Balance{
+		available:     coin.NewAmount(new(big.Int).Sub(balance.available.BigInt(), available.BigInt())),
+		incoming:      coin.NewAmount(new(big.Int).Sub(balance.incoming.BigInt(), incoming.BigInt())),
+		frozen:        balance.frozen,
+		pendingChange: balance.pendingChange,
+		unverified:    balance.unverified,
+	}
+}
+
+// WithFrozen returns a copy of the balance where the given amount of frozen coins is moved from
+// the available balance to the frozen balance.
+func (balance *Balance) WithFrozen(frozen coin.Amount) *Balance {
+	return &Balance{
+		available:     coin.NewAmount(new(big.Int).Sub(balance.available.BigInt(), frozen.BigInt())),
+		incoming:      balance.incoming,
+		frozen:        frozen,
+		pendingChange: balance.pendingChange,
+		unverified:    balance.unverified,
 	}
 }
 
-// Available returns the sum of all unspent coins in the account.
-// The amounts of unconfirmed outgoing transfers are no longer included (but their change is).
+// WithPendingChange returns a copy of the balance where the given amount of unconfirmed change is
+// moved from the available balance to the pending change balance.
+func (balance *Balance) WithPendingChange(pendingChange coin.Amount) *Balance {
+	return &Balance{
+		available:     coin.NewAmount(new(big.Int).Sub(balance.available.BigInt(), pendingChange.BigInt())),
+		incoming:      balance.incoming,
+		frozen:        balance.frozen,
+		pendingChange: pendingChange,
+		unverified:    balance.unverified,
+	}
+}
+
+// WithUnverified returns a copy of the balance where the given amount of confirmed but not yet
+// verified coins is moved from the available balance to the incoming balance.
+func (balance *Balance) WithUnverified(unverified coin.Amount) *Balance {
+	return &Balance{
+		available:     coin.NewAmount(new(big.Int).Sub(balance.available.BigInt(), unverified.BigInt())),
+		incoming:      coin.NewAmount(new(big.Int).Add(balance.incoming.BigInt(), unverified.BigInt())),
+		frozen:        balance.frozen,
+		pendingChange: balance.pendingChange,
+		unverified:    unverified,
+	}
+}
+
+// Available returns the sum of all unspent coins in the account which can be spent.
+// The amounts of unconfirmed outgoing transfers are no longer included (but their change is,
+// unless it is pending, see PendingChange()).
 func (balance *Balance) Available() coin.Amount {
 	return balance.available
 }
@@ -40,3 +100,41 @@ func (balance *Balance) Available() coin.Amount {
 func (balance *Balance) Incoming() coin.Amount {
 	return balance.incoming
 }
+
+// Frozen returns the sum of the unspent coins the user excluded from spending. They are not
+// included in Available().
+func (balance *Balance) Frozen() coin.Amount {
+	return balance.frozen
+}
+
+// PendingChange returns the sum of the change outputs of our own unconfirmed transactions which
+// can't be spent until they confirm, according to the settings of the account. They are not
+// included in Available().
+func (balance *Balance) PendingChange() coin.Amount {
+	return balance.pendingChange
+}
+
+// Unverified returns the sum of the coins of confirmed transactions which were not verified against
+// the block headers yet, if the account requires it. They are included in Incoming() instead of
+// Available().
+func (balance *Balance) Unverified() coin.Amount {
+	return balance.unverified
+}
+
+// Owned returns the sum of all unspent coins of the account except the incoming ones, i.e.
+// Available() + Frozen() + PendingChange(). Unlike Available(), it does not change when the
+// MinConfirmations settings or the frozen coins change, so it is used for the total balances.
+// Unverified coins are not owned until they are verified.
+func (balance *Balance) Owned() coin.Amount {
+	owned := new(big.Int).Add(balance.available.BigInt(), balance.frozen.BigInt())
+	return coin.NewAmount(owned.Add(owned, balance.pendingChange.BigInt()))
+}
+
+// Equal returns true if all components of both balances are equal.
+func (balance *Balance) Equal(other *Balance) bool {
+	return balance.available.BigInt().Cmp(other.available.BigInt()) == 0 &&
+		balance.incoming.BigInt().Cmp(other.incoming.BigInt()) == 0 &&
+		balance.frozen.BigInt().Cmp(other.frozen.BigInt()) == 0 &&
+		balance.pendingChange.BigInt().Cmp(other.pendingChange.BigInt()) == 0 &&
+		balance.unverified.BigInt().Cmp(other.unverified.BigInt()) == 0
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalance(t *testing.T) {
+	balance := NewBalance(coin.NewAmountFromInt64(1000), coin.NewAmountFromInt64(10))
+	require.Equal(t, int64(1000), balance.Owned().BigInt().Int64())
+
+	split := balance.WithFrozen(coin.NewAmountFromInt64(100)).WithPendingChange(coin.NewAmountFromInt64(200))
+	require.Equal(t, int64(700), split.Available().BigInt().Int64())
+	require.Equal(t, int64(10), split.Incoming().BigInt().Int64())
+	require.Equal(t, int64(100), split.Frozen().BigInt().Int64())
+	require.Equal(t, int64(200), split.PendingChange().BigInt().Int64())
+	require.Equal(t, int64(1000), split.Owned().BigInt().Int64())
+
+	require.True(t, balance.Equal(NewBalance(coin.NewAmountFromInt64(1000), coin.NewAmountFromInt64(10))))
+	require.False(t, balance.Equal(NewBalance(coin.NewAmountFromInt64(1000), coin.NewAmountFromInt64(0))))
+	require.False(t, balance.Equal(split))
+	require.False(t, split.Equal(split.WithPendingChange(coin.NewAmountFromInt64(0))))
+
+	unverified := split.WithUnverified(coin.NewAmountFromInt64(300))
+	require.Equal(t, int64(400), unverified.Available().BigInt().Int64())
+	require.Equal(t, int64(310), unverified.Incoming().BigInt().Int64())
+	require.Equal(t, int64(300), unverified.Unverified().BigInt().Int64())
+	require.Equal(t, int64(200), unverified.PendingChange().BigInt().Int64())
+	require.Equal(t, int64(700), unverified.Owned().BigInt().Int64())
+	require.False(t, split.Equal(unverified))
+
+	withoutHidden := balance.WithoutHidden(coin.NewAmountFromInt64(1), coin.NewAmountFromInt64(2))
+	require.Equal(t, int64(999), withoutHidden.Available().BigInt().Int64())
+	require.Equal(t, int64(8), withoutHidden.Incoming().BigInt().Int64())
+	require.Equal(t, int64(999), withoutHidden.Owned().BigInt().Int64())
+}
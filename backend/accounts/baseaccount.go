@@ -24,6 +24,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/synchronizer"
@@ -37,6 +38,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ratesPinDuration is how long a rates snapshot pinned for a send stays valid. The tx proposals
+// made with it can't be sent anymore afterwards.
+const ratesPinDuration = 10 * time.Minute
+
 // AccountConfig holds account configuration.
 type AccountConfig struct {
 	// Pointer to persisted config. Do not modify this directly. Use
@@ -54,6 +59,12 @@ type AccountConfig struct {
 	UnsafeSystemOpen func(filename string) error
 	// BtcCurrencyUnit is the unit which should be used to format fiat amounts values expressed in BTC..
 	BtcCurrencyUnit coin.BtcUnit
+	// IsObserved returns true if the frontend currently observes the events of this account. Can be
+	// nil, in which case the account is always considered observed.
+	IsObserved func() bool
+	// ModifyConfig calls f with the persisted config of the account and persists the changes, see
+	// `backend.config.ModifyAccountsConfig()`.
+	ModifyConfig func(f func(*config.Account) error) error
 }
 
 // BaseAccount is an account struct with common functionality to all coin accounts.
@@ -76,6 +87,21 @@ type BaseAccount struct {
 	proposedTxNote   string
 	proposedTxNoteMu sync.Mutex
 
+	// pinnedRates is the rates snapshot used for all fiat values of the tx proposals of a send, so
+	// they don't change while the user is editing and confirming the transaction. Nil if no rates
+	// were available when it was pinned. The pin and the proposals made with it expire at
+	// pinnedRatesExpiry, which is zero if nothing is pinned.
+	pinnedRates       *rates.Snapshot
+	pinnedRatesExpiry time.Time
+	pinnedRatesMu     sync.Mutex
+
+	syncProgress syncProgress
+	syncMetrics  syncMetrics
+
+	// unobserveRates stops observing the rates, either via the coin or the rate updater. Set in
+	// Initialize().
+	unobserveRates func()
+
 	log *logrus.Entry
 }
 
@@ -87,9 +113,15 @@ func NewBaseAccount(config *AccountConfig, coin coin.Coin, log *logrus.Entry) *B
 		log:    log,
 	}
 	account.Synchronizer = synchronizer.NewSynchronizer(
-		func() { config.OnEvent(types.EventSyncStarted) },
 		func() {
-			if account.synced.CompareAndSwap(false, true) {
+			account.resetSyncProgress()
+			account.startSyncMetrics()
+			config.OnEvent(types.EventSyncStarted)
+		},
+		func() {
+			initial := account.synced.CompareAndSwap(false, true)
+			account.finishSyncMetrics(initial)
+			if initial {
 				config.OnEvent(types.EventStatusChanged)
 			}
 			config.OnEvent(types.EventSyncDone)
@@ -117,6 +149,11 @@ func (account *BaseAccount) Synced() bool {
 // Close stops the account.
 func (account *BaseAccount) Close() {
 	account.synced.Store(false)
+	account.closeSyncProgress()
+	if account.unobserveRates != nil {
+		account.unobserveRates()
+		account.unobserveRates = nil
+	}
 }
 
 // ResetSynced sets synced to false.
@@ -152,11 +189,16 @@ func (account *BaseAccount) Initialize(accountIdentifier string) error {
 		return err
 	}
 
-	// An account syncdone event is generated when new rates are available. This allows the frontend
-	// to reload the relevant data.
-	if account.config.RateUpdater != nil {
-		account.config.RateUpdater.Observe(func(e observable.Event) {
-			if e.Subject == rates.RatesEventSubject {
+	// An account syncdone event is generated when new rates or historical rates are available. This
+	// allows the frontend to reload the relevant data. Coins which observe the rate updater
+	// themselves stop doing so when they are closed.
+	if ratesObserver, ok := account.coin.(coin.RatesObserver); ok {
+		account.unobserveRates = ratesObserver.ObserveRates(func() {
+			account.config.OnEvent(types.EventSyncDone)
+		})
+	} else if account.config.RateUpdater != nil {
+		account.unobserveRates = account.config.RateUpdater.Observe(func(e observable.Event) {
+			if e.Subject == rates.RatesEventSubject || e.Subject == rates.HistoricalRatesEventSubject {
 				account.config.OnEvent(types.EventSyncDone)
 			}
 		})
@@ -165,6 +207,13 @@ func (account *BaseAccount) Initialize(accountIdentifier string) error {
 	return nil
 }
 
+// Observed returns true if the frontend observes the events of this account. Events whose payload
+// is costly to build can be skipped if the account is not observed. This must not be used to skip
+// any state changes.
+func (account *BaseAccount) Observed() bool {
+	return account.config.IsObserved == nil || account.config.IsObserved()
+}
+
 // Notes returns the notes instance of this account.
 func (account *BaseAccount) Notes() *notes.Notes {
 	return account.notes
@@ -244,6 +293,83 @@ func (account *BaseAccount) GetAndClearProposedTxNote() string {
 	return account.proposedTxNote
 }
 
+// ratesPinExpired returns true if the pin has expired. `pinnedRatesMu` must be held.
+func (account *BaseAccount) ratesPinExpired() bool {
+	return !account.pinnedRatesExpiry.IsZero() && time.Now().After(account.pinnedRatesExpiry)
+}
+
+// PinRatesSnapshot pins the latest rates snapshot for a send. It should be called whenever a tx
+// proposal is created. The first call pins the snapshot, and the following ones keep it while
+// the user edits the transaction, until it is sent or the pin expires after ratesPinDuration.
+func (account *BaseAccount) PinRatesSnapshot() {
+	account.pinnedRatesMu.Lock()
+	defer account.pinnedRatesMu.Unlock()
+
+	if !account.pinnedRatesExpiry.IsZero() && !account.ratesPinExpired() {
+		return
+	}
+	account.pinnedRates = nil
+	if account.config.RateUpdater != nil {
+		account.pinnedRates = account.config.RateUpdater.LatestSnapshot()
+	}
+	account.pinnedRatesExpiry = time.Now().Add(ratesPinDuration)
+}
+
+// CheckRatesPin returns errors.ErrTxProposalExpired if the pin of the tx proposals has expired,
+// in which case the proposal must not be sent, as its fiat values were shown with outdated rates.
+// The expired pin is cleared, so that the next tx proposal pins the latest rates.
+func (account *BaseAccount) CheckRatesPin() error {
+	account.pinnedRatesMu.Lock()
+	defer account.pinnedRatesMu.Unlock()
+
+	if account.ratesPinExpired() {
+		account.pinnedRates = nil
+		account.pinnedRatesExpiry = time.Time{}
+		return errp.WithStack(errors.ErrTxProposalExpired)
+	}
+	return nil
+}
+
+// UnpinRatesSnapshot stores the fiat values of a sent transaction converted with the pinned
+// rates, see SentConversions(), and clears the pin, so that the next send pins the latest rates.
+// It should be called when the tx proposal was sent.
+func (account *BaseAccount) UnpinRatesSnapshot(txID string, amount, fee, total coin.Amount) {
+	account.pinnedRatesMu.Lock()
+	defer account.pinnedRatesMu.Unlock()
+
+	if snapshot := account.pinnedRates; snapshot != nil && !account.ratesPinExpired() {
+		formatBtcAsSats := account.config.BtcCurrencyUnit == coin.BtcUnitSats
+		conversions := &notes.SentConversions{
+			RatesSnapshotID: snapshot.ID,
+			Amount:          coin.ConversionsFromSnapshot(amount, account.coin, false, snapshot, formatBtcAsSats, false),
+			Fee:             coin.ConversionsFromSnapshot(fee, account.coin, true, snapshot, formatBtcAsSats, false),
+			Total:           coin.ConversionsFromSnapshot(total, account.coin, false, snapshot, formatBtcAsSats, false),
+		}
+		if err := account.notes.SetSentConversions(txID, conversions); err != nil {
+			// Not critical.
+			account.log.WithError(err).Error("Failed to store the fiat values of a sent transaction")
+		}
+	}
+	account.pinnedRates = nil
+	account.pinnedRatesExpiry = time.Time{}
+}
+
+// PinnedRatesSnapshot implements accounts.Interface.
+func (account *BaseAccount) PinnedRatesSnapshot() *rates.Snapshot {
+	account.pinnedRatesMu.Lock()
+	defer account.pinnedRatesMu.Unlock()
+
+	if account.ratesPinExpired() {
+		return nil
+	}
+	return account.pinnedRates
+}
+
+// SentConversions implements accounts.Interface.
+func (account *BaseAccount) SentConversions(txID string) *notes.SentConversions {
+	return account.notes.SentConversions(txID)
+}
+
 // SetTxNote implements accounts.Account.
 func (account *BaseAccount) SetTxNote(txID string, note string) error {
 	if _, err := account.notes.SetTxNote(txID, note); err != nil {
@@ -281,6 +407,7 @@ func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionDa
 			TxTypeReceive:  "received",
 			TxTypeSend:     "sent",
 			TxTypeSendSelf: "sent_to_yourself",
+			TxTypeComplex:  "complex",
 		}[transaction.Type]
 		feeString := ""
 		fee := transaction.Fee
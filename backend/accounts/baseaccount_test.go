@@ -22,12 +22,16 @@ import (
 	"testing"
 	"time"
 
+	accountsErrors "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/stretchr/testify/require"
@@ -237,3 +241,211 @@ func TestBaseAccount(t *testing.T) {
 
 	})
 }
+
+func TestPinRatesSnapshot(t *testing.T) {
+	rateUpdater := rates.MockRateUpdater()
+	defer rateUpdater.Stop()
+	account := NewBaseAccount(
+		&AccountConfig{
+			Config:      &config.Account{Code: "test"},
+			RateUpdater: rateUpdater,
+			NotesFolder: test.TstTempDir("baseaccount_test_notesfolder"),
+			OnEvent:     func(types.Event) {},
+		},
+		&mocks.CoinMock{
+			UnitFunc:        func(bool) string { return "BTC" },
+			ToUnitFunc:      func(amount coin.Amount, isFee bool) float64 { return float64(amount.BigInt().Int64()) / 1e8 },
+			ActiveFiatsFunc: func() []string { return nil },
+		},
+		logging.Get().WithGroup("baseaccount_test"),
+	)
+	require.NoError(t, account.Initialize("test-account-identifier"))
+	defer account.Close()
+	require.Nil(t, account.PinnedRatesSnapshot())
+
+	account.PinRatesSnapshot()
+	snapshot := account.PinnedRatesSnapshot()
+	require.NotNil(t, snapshot)
+	require.Equal(t, rateUpdater.LatestSnapshot(), snapshot)
+
+	// Further proposals while the user edits the transaction keep the pinned snapshot.
+	account.PinRatesSnapshot()
+	require.Same(t, snapshot, account.PinnedRatesSnapshot())
+	require.NoError(t, account.CheckRatesPin())
+
+	// The fiat values of the sent transaction are stored.
+	account.UnpinRatesSnapshot(
+		"sent-tx-id",
+		coin.NewAmountFromInt64(100000000),
+		coin.NewAmountFromInt64(1000),
+		coin.NewAmountFromInt64(100001000),
+	)
+	require.Nil(t, account.PinnedRatesSnapshot())
+	sentConversions := account.SentConversions("sent-tx-id")
+	require.NotNil(t, sentConversions)
+	require.Equal(t, snapshot.ID, sentConversions.RatesSnapshotID)
+	require.Equal(t, map[string]string{"USD": "21.00"}, sentConversions.Amount)
+	require.Equal(t, map[string]string{"USD": "0.00"}, sentConversions.Fee)
+	require.Equal(t, map[string]string{"USD": "21.00"}, sentConversions.Total)
+	require.Nil(t, account.SentConversions("unknown-tx-id"))
+
+	// The pin expires, and with it the proposal.
+	account.PinRatesSnapshot()
+	require.NotSame(t, snapshot, account.PinnedRatesSnapshot())
+	account.pinnedRatesExpiry = time.Now().Add(-time.Second)
+	require.Nil(t, account.PinnedRatesSnapshot())
+	account.UnpinRatesSnapshot("expired-tx-id", coin.NewAmountFromInt64(1), coin.NewAmountFromInt64(1), coin.NewAmountFromInt64(2))
+	require.Nil(t, account.SentConversions("expired-tx-id"))
+
+	account.PinRatesSnapshot()
+	account.pinnedRatesExpiry = time.Now().Add(-time.Second)
+	require.Equal(t, accountsErrors.ErrTxProposalExpired, errp.Cause(account.CheckRatesPin()))
+	// The next proposal pins the latest rates again.
+	account.PinRatesSnapshot()
+	require.NotNil(t, account.PinnedRatesSnapshot())
+	require.NoError(t, account.CheckRatesPin())
+}
+
+func TestCloseUnobservesRates(t *testing.T) {
+	rateUpdater := rates.MockRateUpdater()
+	defer rateUpdater.Stop()
+	events := 0
+	account := NewBaseAccount(
+		&AccountConfig{
+			Config:      &config.Account{Code: "test"},
+			RateUpdater: rateUpdater,
+			NotesFolder: test.TstTempDir("baseaccount_test_notesfolder"),
+			OnEvent: func(event types.Event) {
+				if event == types.EventSyncDone {
+					events++
+				}
+			},
+		},
+		&mocks.CoinMock{},
+		logging.Get().WithGroup("baseaccount_test"),
+	)
+	require.NoError(t, account.Initialize("test-account-identifier"))
+
+	rateUpdater.Notify(observable.Event{Subject: rates.RatesEventSubject})
+	require.Equal(t, 1, events)
+
+	account.Close()
+	rateUpdater.Notify(observable.Event{Subject: rates.RatesEventSubject})
+	require.Equal(t, 1, events)
+}
+
+func TestSyncProgress(t *testing.T) {
+	events := make(chan types.Event, 10)
+	account := NewBaseAccount(
+		&AccountConfig{OnEvent: func(event types.Event) { events <- event }},
+		&mocks.CoinMock{},
+		logging.Get().WithGroup("baseaccount_test"),
+	)
+	nextEvent := func() types.Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(2 * syncProgressInterval):
+			require.Fail(t, "event not fired")
+			return ""
+		}
+	}
+	requireNoEvent := func() {
+		select {
+		case event := <-events:
+			require.Fail(t, "unexpected event", event)
+		default:
+		}
+	}
+
+	done := account.Synchronizer.IncRequestsCounter()
+	require.Equal(t, types.EventSyncStarted, nextEvent())
+
+	// The first change is emitted immediately.
+	account.AddSyncProgress(types.SyncProgress{AddressesTotal: 20})
+	require.Equal(t, types.EventSyncProgress, nextEvent())
+	require.Equal(t, types.SyncProgress{AddressesTotal: 20}, account.SyncProgress())
+
+	// Further changes within the interval are emitted once at the end of the interval.
+	start := time.Now()
+	account.AddSyncProgress(types.SyncProgress{AddressesSubscribed: 1})
+	account.AddSyncProgress(types.SyncProgress{AddressesSubscribed: 1, HistoriesTotal: 1})
+	account.AddSyncProgress(types.SyncProgress{HistoriesFetched: 1, TransactionsIndexed: 3})
+	requireNoEvent()
+	require.Equal(t, types.EventSyncProgress, nextEvent())
+	require.GreaterOrEqual(t, time.Since(start), syncProgressInterval/2)
+	requireNoEvent()
+	require.Equal(t,
+		types.SyncProgress{
+			AddressesSubscribed: 2,
+			AddressesTotal:      20,
+			HistoriesFetched:    1,
+			HistoriesTotal:      1,
+			TransactionsIndexed: 3,
+		},
+		account.SyncProgress())
+
+	done()
+	require.Equal(t, types.EventStatusChanged, nextEvent())
+	require.Equal(t, types.EventSyncDone, nextEvent())
+
+	// The progress starts over with the next sync.
+	done = account.Synchronizer.IncRequestsCounter()
+	require.Equal(t, types.EventSyncStarted, nextEvent())
+	require.Equal(t, types.SyncProgress{}, account.SyncProgress())
+	done()
+	require.Equal(t, types.EventSyncDone, nextEvent())
+
+	// No events after closing.
+	account.Close()
+	account.AddSyncProgress(types.SyncProgress{AddressesTotal: 1})
+	time.Sleep(syncProgressInterval)
+	requireNoEvent()
+}
+
+func TestSyncMetrics(t *testing.T) {
+	account := NewBaseAccount(
+		&AccountConfig{OnEvent: func(types.Event) {}},
+		&mocks.CoinMock{},
+		logging.Get().WithGroup("baseaccount_test"),
+	)
+	require.Empty(t, account.SyncMetricsHistory())
+
+	// Ignored outside of a sync.
+	account.RecordSyncPhase(types.SyncPhaseSubscribe, 1, time.Second)
+
+	done := account.Synchronizer.IncRequestsCounter()
+	account.RecordSyncPhase(types.SyncPhaseSubscribe, 1, 20*time.Millisecond)
+	account.RecordSyncPhase(types.SyncPhaseSubscribe, 1, 30*time.Millisecond)
+	account.RecordSyncPhase(types.SyncPhaseTxFetch, 5, 100*time.Millisecond)
+	done()
+
+	history := account.SyncMetricsHistory()
+	require.Len(t, history, 1)
+	require.Equal(t, types.SyncMetricsVersion, history[0].Version)
+	require.True(t, history[0].Initial)
+	require.GreaterOrEqual(t, history[0].DurationMillis, int64(0))
+	require.Equal(t,
+		map[types.SyncPhase]types.SyncPhaseMetrics{
+			types.SyncPhaseSubscribe: {Count: 2, TotalMillis: 50, MaxMillis: 30},
+			types.SyncPhaseTxFetch:   {Count: 5, TotalMillis: 100, MaxMillis: 100},
+		},
+		history[0].Phases)
+
+	// The returned history is a copy.
+	history[0].Phases[types.SyncPhaseProcessing] = types.SyncPhaseMetrics{Count: 1}
+	require.NotContains(t, account.SyncMetricsHistory()[0].Phases, types.SyncPhaseProcessing)
+
+	// Only the last syncs are kept, and only the first one is the initial sync.
+	for i := 0; i < maxSyncMetrics; i++ {
+		done := account.Synchronizer.IncRequestsCounter()
+		account.RecordSyncPhase(types.SyncPhaseHistoryFetch, i+1, time.Millisecond)
+		done()
+	}
+	history = account.SyncMetricsHistory()
+	require.Len(t, history, maxSyncMetrics)
+	for i, metrics := range history {
+		require.False(t, metrics.Initial)
+		require.Equal(t, i+1, metrics.Phases[types.SyncPhaseHistoryFetch].Count)
+	}
+}
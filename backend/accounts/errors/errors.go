@@ -16,6 +16,7 @@ package errors
 
 import (
 	errpkg "errors"
+	"fmt"
 )
 
 // TxValidationError represents errors in the tx proposal input data.
@@ -25,27 +26,98 @@ func (err TxValidationError) Error() string {
 	return string(err)
 }
 
+// RecipientError is a validation error of one of multiple recipients of a transaction.
+type RecipientError struct {
+	// Index is the position of the recipient in the list of recipients.
+	Index int
+	Err   TxValidationError
+}
+
+func (err *RecipientError) Error() string {
+	return fmt.Sprintf("recipient %d: %s", err.Index, err.Err)
+}
+
 var (
 	// ErrFeesNotAvailable is returned when there was an error estimating fees.
 	ErrFeesNotAvailable = TxValidationError("feesNotAvailable")
-	// ErrInvalidAddress is used when the recipient address is invalid or does not match the correct
-	// network.
+	// ErrInvalidAddress is used when the recipient address is invalid and none of the more specific
+	// address errors below apply.
 	ErrInvalidAddress = TxValidationError("invalidAddress")
+	// ErrAddressWrongNetwork is used when the recipient address is valid, but belongs to a
+	// different network, e.g. a testnet address in a mainnet account.
+	ErrAddressWrongNetwork = TxValidationError("addressWrongNetwork")
+	// ErrAddressInvalidChecksum is used when the checksum of the recipient address does not match,
+	// e.g. because of a typo.
+	ErrAddressInvalidChecksum = TxValidationError("addressInvalidChecksum")
+	// ErrAddressUnsupportedType is used when the recipient address is well-formed, but its type is
+	// not supported by the coin, e.g. a future witness version.
+	ErrAddressUnsupportedType = TxValidationError("addressUnsupportedType")
 	// ErrInvalidAmount is used when the user entered amount is malformatted or not positive.
 	ErrInvalidAmount = TxValidationError("invalidAmount")
 	// ErrInsufficientFunds is returned when there are not enough funds to cover the target amount
 	// and fee.
 	ErrInsufficientFunds = TxValidationError("insufficientFunds")
+	// ErrInsufficientConfirmedFunds is returned when the outputs with enough confirmations, see
+	// config.Account.MinConfirmations, do not cover the target amount and fee, but all outputs
+	// would.
+	ErrInsufficientConfirmedFunds = TxValidationError("insufficientConfirmedFunds")
+	// ErrTooManyRecipients is returned when a transaction would pay more recipients than allowed.
+	ErrTooManyRecipients = TxValidationError("tooManyRecipients")
+	// ErrSendAllMultipleRecipients is returned when sending all funds is requested together with
+	// other recipients, as only a single recipient can receive all funds.
+	ErrSendAllMultipleRecipients = TxValidationError("sendAllMultipleRecipients")
+	// ErrMultipleRecipientsNotSupported is returned when multiple recipients are requested for a
+	// coin which does not support multiple outputs.
+	ErrMultipleRecipientsNotSupported = TxValidationError("multipleRecipientsNotSupported")
+	// ErrFlaggedCoinsMixed is returned when the coins selected using coin control include coins
+	// flagged by the user together with coins that are not flagged with the same label.
+	ErrFlaggedCoinsMixed = TxValidationError("flaggedCoinsMixed")
+	// ErrFrozenOutputSelected is returned when the coins selected using coin control include a
+	// frozen coin and spending frozen coins was not allowed explicitly.
+	ErrFrozenOutputSelected = TxValidationError("frozenOutputSelected")
+	// ErrNothingToConsolidate is returned when there are not at least two outputs which can be
+	// consolidated.
+	ErrNothingToConsolidate = TxValidationError("nothingToConsolidate")
+	// ErrConsolidationUneconomical is returned when the fee of a consolidation transaction is higher
+	// than the fee it is expected to save later.
+	ErrConsolidationUneconomical = TxValidationError("consolidationUneconomical")
+	// ErrDustAmount is returned when the amount of an output is so small that the output is
+	// considered dust and the transaction would not be relayed by the network.
+	ErrDustAmount = TxValidationError("dustAmount")
+	// ErrOpReturnDataTooLarge is returned when the data to be embedded in an OP_RETURN output is
+	// larger than allowed by the standard relay policy.
+	ErrOpReturnDataTooLarge = TxValidationError("opReturnDataTooLarge")
+	// ErrTooManyOpReturnOutputs is returned when a transaction would have more than one OP_RETURN
+	// output, which makes it non-standard.
+	ErrTooManyOpReturnOutputs = TxValidationError("tooManyOpReturnOutputs")
 	// ErrFeeTooLow is returned when the custom fee the user entered is too low to be able to
 	// broadcast the transaction.
 	ErrFeeTooLow = TxValidationError("feeTooLow")
+	// ErrInvalidPrivateKey is returned when a private key to be swept is malformatted.
+	ErrInvalidPrivateKey = TxValidationError("invalidPrivateKey")
+	// ErrPrivateKeyEmpty is returned when no private key to be swept was provided.
+	ErrPrivateKeyEmpty = TxValidationError("privateKeyEmpty")
+	// ErrPrivateKeyWrongNetwork is returned when a private key to be swept belongs to a different
+	// network than the account, e.g. a mainnet key for a testnet account.
+	ErrPrivateKeyWrongNetwork = TxValidationError("privateKeyWrongNetwork")
 	// ErrAccountNotsynced is used when the account sync has not successfully finished.
 	ErrAccountNotsynced = TxValidationError("accountNotSynced")
+	// ErrTxProposalExpired is returned when sending a tx proposal whose fiat values were shown with
+	// rates that were pinned too long ago. A new proposal has to be made.
+	ErrTxProposalExpired = TxValidationError("txProposalExpired")
 
 	// ErrNotAvailable is returned if data required is not available yet. Example: the headers are
 	// not synced yet, which is a prerequisite to making a timeseries of the portfolio.
 	ErrNotAvailable = errpkg.New("notAvailable")
 
+	// ErrWatchOnly is returned when signing is attempted in a watch-only account, which was imported
+	// from an extended public key and has no keystore.
+	ErrWatchOnly = errpkg.New("watchOnly")
+
+	// ErrGapLimitExhausted is returned if all receive addresses up to the gap limit have been
+	// handed out without receiving a payment, so funds sent to another address would not be found.
+	ErrGapLimitExhausted = errpkg.New("gapLimitExhausted")
+
 	// ERC20InsufficientGasFunds is returned when there is not enough ETH to pay the erc20 transaction fee.
 	ERC20InsufficientGasFunds = errpkg.New("erc20InsufficientGasFunds")
 )
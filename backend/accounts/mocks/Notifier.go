@@ -23,6 +23,34 @@ func (_m *Notifier) Delete(id []byte) error {
 	return r0
 }
 
+// HasTransactions provides a mock function with given fields:
+func (_m *Notifier) HasTransactions() (bool, bool, error) {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func() bool); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // MarkAllNotified provides a mock function with given fields:
 func (_m *Notifier) MarkAllNotified() error {
 	ret := _m.Called()
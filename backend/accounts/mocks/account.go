@@ -6,7 +6,9 @@ package mocks
 import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"io"
 	"sync"
@@ -70,15 +72,24 @@ var _ accounts.Interface = &InterfaceMock{}
 //			OfflineFunc: func() error {
 //				panic("mock out the Offline method")
 //			},
+//			PinnedRatesSnapshotFunc: func() *rates.Snapshot {
+//				panic("mock out the PinnedRatesSnapshot method")
+//			},
 //			ProposeTxNoteFunc: func(s string)  {
 //				panic("mock out the ProposeTxNote method")
 //			},
 //			SendTxFunc: func() error {
 //				panic("mock out the SendTx method")
 //			},
+//			SentConversionsFunc: func(txID string) *notes.SentConversions {
+//				panic("mock out the SentConversions method")
+//			},
 //			SetTxNoteFunc: func(txID string, note string) error {
 //				panic("mock out the SetTxNote method")
 //			},
+//			SyncProgressFunc: func() types.SyncProgress {
+//				panic("mock out the SyncProgress method")
+//			},
 //			SyncedFunc: func() bool {
 //				panic("mock out the Synced method")
 //			},
@@ -149,15 +160,24 @@ type InterfaceMock struct {
 	// OfflineFunc mocks the Offline method.
 	OfflineFunc func() error
 
+	// PinnedRatesSnapshotFunc mocks the PinnedRatesSnapshot method.
+	PinnedRatesSnapshotFunc func() *rates.Snapshot
+
 	// ProposeTxNoteFunc mocks the ProposeTxNote method.
 	ProposeTxNoteFunc func(s string)
 
 	// SendTxFunc mocks the SendTx method.
 	SendTxFunc func() error
 
+	// SentConversionsFunc mocks the SentConversions method.
+	SentConversionsFunc func(txID string) *notes.SentConversions
+
 	// SetTxNoteFunc mocks the SetTxNote method.
 	SetTxNoteFunc func(txID string, note string) error
 
+	// SyncProgressFunc mocks the SyncProgress method.
+	SyncProgressFunc func() types.SyncProgress
+
 	// SyncedFunc mocks the Synced method.
 	SyncedFunc func() bool
 
@@ -229,6 +249,9 @@ type InterfaceMock struct {
 		// Offline holds details about calls to the Offline method.
 		Offline []struct {
 		}
+		// PinnedRatesSnapshot holds details about calls to the PinnedRatesSnapshot method.
+		PinnedRatesSnapshot []struct {
+		}
 		// ProposeTxNote holds details about calls to the ProposeTxNote method.
 		ProposeTxNote []struct {
 			// S is the s argument value.
@@ -237,6 +260,11 @@ type InterfaceMock struct {
 		// SendTx holds details about calls to the SendTx method.
 		SendTx []struct {
 		}
+		// SentConversions holds details about calls to the SentConversions method.
+		SentConversions []struct {
+			// TxID is the txID argument value.
+			TxID string
+		}
 		// SetTxNote holds details about calls to the SetTxNote method.
 		SetTxNote []struct {
 			// TxID is the txID argument value.
@@ -244,6 +272,9 @@ type InterfaceMock struct {
 			// Note is the note argument value.
 			Note string
 		}
+		// SyncProgress holds details about calls to the SyncProgress method.
+		SyncProgress []struct {
+		}
 		// Synced holds details about calls to the Synced method.
 		Synced []struct {
 		}
@@ -282,9 +313,12 @@ type InterfaceMock struct {
 	lockNotifier                  sync.RWMutex
 	lockObserve                   sync.RWMutex
 	lockOffline                   sync.RWMutex
+	lockPinnedRatesSnapshot       sync.RWMutex
 	lockProposeTxNote             sync.RWMutex
 	lockSendTx                    sync.RWMutex
+	lockSentConversions           sync.RWMutex
 	lockSetTxNote                 sync.RWMutex
+	lockSyncProgress              sync.RWMutex
 	lockSynced                    sync.RWMutex
 	lockTransactions              sync.RWMutex
 	lockTxNote                    sync.RWMutex
@@ -738,6 +772,33 @@ func (mock *InterfaceMock) OfflineCalls() []struct {
 	return calls
 }
 
+// PinnedRatesSnapshot calls PinnedRatesSnapshotFunc.
+func (mock *InterfaceMock) PinnedRatesSnapshot() *rates.Snapshot {
+	if mock.PinnedRatesSnapshotFunc == nil {
+		panic("InterfaceMock.PinnedRatesSnapshotFunc: method is nil but Interface.PinnedRatesSnapshot was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockPinnedRatesSnapshot.Lock()
+	mock.calls.PinnedRatesSnapshot = append(mock.calls.PinnedRatesSnapshot, callInfo)
+	mock.lockPinnedRatesSnapshot.Unlock()
+	return mock.PinnedRatesSnapshotFunc()
+}
+
+// PinnedRatesSnapshotCalls gets all the calls that were made to PinnedRatesSnapshot.
+// Check the length with:
+//
+//	len(mockedInterface.PinnedRatesSnapshotCalls())
+func (mock *InterfaceMock) PinnedRatesSnapshotCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockPinnedRatesSnapshot.RLock()
+	calls = mock.calls.PinnedRatesSnapshot
+	mock.lockPinnedRatesSnapshot.RUnlock()
+	return calls
+}
+
 // ProposeTxNote calls ProposeTxNoteFunc.
 func (mock *InterfaceMock) ProposeTxNote(s string) {
 	if mock.ProposeTxNoteFunc == nil {
@@ -797,6 +858,38 @@ func (mock *InterfaceMock) SendTxCalls() []struct {
 	return calls
 }
 
+// SentConversions calls SentConversionsFunc.
+func (mock *InterfaceMock) SentConversions(txID string) *notes.SentConversions {
+	if mock.SentConversionsFunc == nil {
+		panic("InterfaceMock.SentConversionsFunc: method is nil but Interface.SentConversions was just called")
+	}
+	callInfo := struct {
+		TxID string
+	}{
+		TxID: txID,
+	}
+	mock.lockSentConversions.Lock()
+	mock.calls.SentConversions = append(mock.calls.SentConversions, callInfo)
+	mock.lockSentConversions.Unlock()
+	return mock.SentConversionsFunc(txID)
+}
+
+// SentConversionsCalls gets all the calls that were made to SentConversions.
+// Check the length with:
+//
+//	len(mockedInterface.SentConversionsCalls())
+func (mock *InterfaceMock) SentConversionsCalls() []struct {
+	TxID string
+} {
+	var calls []struct {
+		TxID string
+	}
+	mock.lockSentConversions.RLock()
+	calls = mock.calls.SentConversions
+	mock.lockSentConversions.RUnlock()
+	return calls
+}
+
 // SetTxNote calls SetTxNoteFunc.
 func (mock *InterfaceMock) SetTxNote(txID string, note string) error {
 	if mock.SetTxNoteFunc == nil {
@@ -833,6 +926,33 @@ func (mock *InterfaceMock) SetTxNoteCalls() []struct {
 	return calls
 }
 
+// SyncProgress calls SyncProgressFunc.
+func (mock *InterfaceMock) SyncProgress() types.SyncProgress {
+	if mock.SyncProgressFunc == nil {
+		panic("InterfaceMock.SyncProgressFunc: method is nil but Interface.SyncProgress was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockSyncProgress.Lock()
+	mock.calls.SyncProgress = append(mock.calls.SyncProgress, callInfo)
+	mock.lockSyncProgress.Unlock()
+	return mock.SyncProgressFunc()
+}
+
+// SyncProgressCalls gets all the calls that were made to SyncProgress.
+// Check the length with:
+//
+//	len(mockedInterface.SyncProgressCalls())
+func (mock *InterfaceMock) SyncProgressCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockSyncProgress.RLock()
+	calls = mock.calls.SyncProgress
+	mock.lockSyncProgress.RUnlock()
+	return calls
+}
+
 // Synced calls SyncedFunc.
 func (mock *InterfaceMock) Synced() bool {
 	if mock.SyncedFunc == nil {
@@ -0,0 +1,57 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+)
+
+type nameLogField struct {
+	config *config.Account
+}
+
+// String implements fmt.Stringer.
+func (field nameLogField) String() string {
+	return field.config.Name
+}
+
+// NameLogField returns a log field value which formats to the current name of the account, so
+// that log entries show the new name after the account was renamed.
+func NameLogField(config *config.Account) fmt.Stringer {
+	return nameLogField{config: config}
+}
+
+// ExportFilename returns the suggested file name of an export of the account, made of the time,
+// the current name of the account and the given suffix, e.g.
+// "2024-01-02-at-15-04-05-My-Bitcoin-export.csv". Characters of the name which are not safe in file
+// names are replaced with dashes.
+func ExportFilename(config *config.Account, now time.Time, suffix string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '-'
+	}, config.Name)
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = string(config.Code)
+	}
+	return fmt.Sprintf("%s-%s-%s", now.Format("2006-01-02-at-15-04-05"), name, suffix)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameLogField(t *testing.T) {
+	accountConfig := &config.Account{Code: "v0-55555555-btc-0", Name: "Bitcoin"}
+	field := NameLogField(accountConfig)
+	require.Equal(t, "Bitcoin", fmt.Sprint(field))
+	accountConfig.Name = "Savings"
+	require.Equal(t, "Savings", fmt.Sprint(field))
+}
+
+func TestExportFilename(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	require.Equal(t,
+		"2024-01-02-at-15-04-05-My-Bitcoin-export.csv",
+		ExportFilename(&config.Account{Code: "v0-55555555-btc-0", Name: "My Bitcoin"}, now, "export.csv"))
+	require.Equal(t,
+		"2024-01-02-at-15-04-05-Spar-Konto-utxos.json",
+		ExportFilename(&config.Account{Code: "v0-55555555-btc-0", Name: "/Spar Konto/"}, now, "utxos.json"))
+	// The code is used if the name has no characters safe in file names.
+	require.Equal(t,
+		"2024-01-02-at-15-04-05-v0-55555555-btc-0-export.csv",
+		ExportFilename(&config.Account{Code: "v0-55555555-btc-0", Name: "../"}, now, "export.csv"))
+}
@@ -18,6 +18,7 @@ package notes
 import (
 	"encoding/json"
 	"os"
+	"sort"
 	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -26,12 +27,46 @@ import (
 // MaxNoteLen is the maximum length per note.
 const MaxNoteLen = 1024
 
+// MaxFlags is the maximum number of entries in the flag list.
+const MaxFlags = 10000
+
+// MaxFrozenOutputs is the maximum number of frozen outputs.
+const MaxFrozenOutputs = 10000
+
 // Data is the notes JSON data serialized to disk.
 type Data struct {
-	// More fields to be added when we can label more stuff, e.g. receive addresses, utxos, etc.
+	// More fields to be added when we can label more stuff, e.g. utxos, etc.
 
 	// a map of transaction ID to transaction note.
 	TransactionNotes map[string]string `json:"transactions"`
+
+	// a map of address ID (see accounts.Address.ID()) to address note.
+	AddressNotes map[string]string `json:"addresses,omitempty"`
+
+	// a map of outpoint ("txid:index") to the note of the transaction input spending it.
+	InputNotes map[string]string `json:"inputs,omitempty"`
+
+	// a map of outpoint ("txid:index") to output note.
+	OutputNotes map[string]string `json:"outputs,omitempty"`
+
+	// a map of address or outpoint to the label the user gave the origin of the coins received
+	// there, e.g. to keep coins refunded from a hack separate from the others.
+	Flags map[string]string `json:"flags,omitempty"`
+
+	// a set of outpoints the user excluded from spending, e.g. dust received in a dusting attack.
+	FrozenOutputs map[string]bool `json:"frozenOutputs,omitempty"`
+
+	// a map of transaction ID to the fiat values shown when the transaction was sent.
+	SentConversions map[string]*SentConversions `json:"sentConversions,omitempty"`
+}
+
+// SentConversions are the fiat values of a sent transaction, converted with the rates snapshot
+// pinned while the user confirmed it. The conversions are keyed by fiat currency.
+type SentConversions struct {
+	RatesSnapshotID string            `json:"ratesSnapshotID"`
+	Amount          map[string]string `json:"amount"`
+	Fee             map[string]string `json:"fee"`
+	Total           map[string]string `json:"total"`
 }
 
 // read deserializes the json files into notes. If the file does not exist yet, no error is
@@ -119,6 +154,260 @@ func (notes *Notes) TxNote(txID string) string {
 	return notes.data.TransactionNotes[txID]
 }
 
+// setNote sets the note of `key` in the given notes map, creating the map if needed. An empty note
+// removes the note. Returns true if the note changed. Requires the dataMu write lock.
+func (notes *Notes) setNote(notesMap *map[string]string, key string, note string) (bool, error) {
+	if len(note) > MaxNoteLen {
+		return false, errp.Newf("Length of note must be smaller than %d. Got %d", MaxNoteLen, len(note))
+	}
+	if (*notesMap)[key] == note {
+		return false, nil
+	}
+	if *notesMap == nil {
+		*notesMap = map[string]string{}
+	}
+	if note == "" {
+		delete(*notesMap, key)
+	} else {
+		(*notesMap)[key] = note
+	}
+	return true, write(notes.data, notes.filename)
+}
+
+func copyNotes(notesMap map[string]string) map[string]string {
+	result := make(map[string]string, len(notesMap))
+	for key, note := range notesMap {
+		result[key] = note
+	}
+	return result
+}
+
+// SetAddressNote sets the note of the address with the given ID. An empty note removes the note.
+// Returns true if the note changed.
+func (notes *Notes) SetAddressNote(addressID string, note string) (bool, error) {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if addressID == "" {
+		return false, errp.New("Address ID must not be empty")
+	}
+	return notes.setNote(&notes.data.AddressNotes, addressID, note)
+}
+
+// AddressNote returns the note of the address with the given ID, or the empty string if there is
+// none.
+func (notes *Notes) AddressNote(addressID string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.AddressNotes[addressID]
+}
+
+// AddressNotes returns a copy of the address notes, keyed by address ID.
+func (notes *Notes) AddressNotes() map[string]string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return copyNotes(notes.data.AddressNotes)
+}
+
+// SetInputNote sets the note of the transaction input spending the given outpoint. An empty note
+// removes the note. Returns true if the note changed.
+func (notes *Notes) SetInputNote(outPoint string, note string) (bool, error) {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if outPoint == "" {
+		return false, errp.New("Input outpoint must not be empty")
+	}
+	return notes.setNote(&notes.data.InputNotes, outPoint, note)
+}
+
+// InputNote returns the note of the transaction input spending the given outpoint, or the empty
+// string if there is none.
+func (notes *Notes) InputNote(outPoint string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.InputNotes[outPoint]
+}
+
+// InputNotes returns a copy of the input notes, keyed by the spent outpoint.
+func (notes *Notes) InputNotes() map[string]string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return copyNotes(notes.data.InputNotes)
+}
+
+// SetOutputNote sets the note of the given outpoint. An empty note removes the note. Returns true if
+// the note changed.
+func (notes *Notes) SetOutputNote(outPoint string, note string) (bool, error) {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if outPoint == "" {
+		return false, errp.New("Output outpoint must not be empty")
+	}
+	return notes.setNote(&notes.data.OutputNotes, outPoint, note)
+}
+
+// OutputNote returns the note of the given outpoint, or the empty string if there is none.
+func (notes *Notes) OutputNote(outPoint string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.OutputNotes[outPoint]
+}
+
+// OutputNotes returns a copy of the output notes, keyed by outpoint.
+func (notes *Notes) OutputNotes() map[string]string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return copyNotes(notes.data.OutputNotes)
+}
+
+func validateFlag(key string, label string) error {
+	if key == "" {
+		return errp.New("Flagged address or outpoint must not be empty")
+	}
+	if label == "" {
+		return errp.Newf("Label of %s must not be empty", key)
+	}
+	if len(label) > MaxNoteLen {
+		return errp.Newf("Length of label must be smaller than %d. Got %d", MaxNoteLen, len(label))
+	}
+	return nil
+}
+
+// SetFlags replaces the flag list, a map of address or outpoint to a label.
+func (notes *Notes) SetFlags(flags map[string]string) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if len(flags) > MaxFlags {
+		return errp.Newf("The flag list must not have more than %d entries. Got %d", MaxFlags, len(flags))
+	}
+	newFlags := make(map[string]string, len(flags))
+	for key, label := range flags {
+		if err := validateFlag(key, label); err != nil {
+			return err
+		}
+		newFlags[key] = label
+	}
+	notes.data.Flags = newFlags
+	return write(notes.data, notes.filename)
+}
+
+// AddFlag adds an address or outpoint to the flag list, replacing the label if it was flagged
+// already.
+func (notes *Notes) AddFlag(key string, label string) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if err := validateFlag(key, label); err != nil {
+		return err
+	}
+	if _, ok := notes.data.Flags[key]; !ok && len(notes.data.Flags) >= MaxFlags {
+		return errp.Newf("The flag list must not have more than %d entries", MaxFlags)
+	}
+	if notes.data.Flags == nil {
+		notes.data.Flags = map[string]string{}
+	}
+	notes.data.Flags[key] = label
+	return write(notes.data, notes.filename)
+}
+
+// Flag returns the label of a flagged address or outpoint. Returns the empty string if it is not
+// flagged.
+func (notes *Notes) Flag(key string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.Flags[key]
+}
+
+// Flags returns a copy of the flag list.
+func (notes *Notes) Flags() map[string]string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	flags := make(map[string]string, len(notes.data.Flags))
+	for key, label := range notes.data.Flags {
+		flags[key] = label
+	}
+	return flags
+}
+
+// SetOutputFrozen freezes or unfreezes an outpoint. Unfrozen outpoints are deleted instead of
+// being stored as false. Returns whether the frozen status was modified.
+func (notes *Notes) SetOutputFrozen(outPoint string, frozen bool) (bool, error) {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if outPoint == "" {
+		return false, errp.New("Frozen outpoint must not be empty")
+	}
+	if notes.data.FrozenOutputs[outPoint] == frozen {
+		return false, nil
+	}
+	if frozen && len(notes.data.FrozenOutputs) >= MaxFrozenOutputs {
+		return false, errp.Newf("There must not be more than %d frozen outputs", MaxFrozenOutputs)
+	}
+	if notes.data.FrozenOutputs == nil {
+		notes.data.FrozenOutputs = map[string]bool{}
+	}
+	if frozen {
+		notes.data.FrozenOutputs[outPoint] = true
+	} else {
+		delete(notes.data.FrozenOutputs, outPoint)
+	}
+	return true, write(notes.data, notes.filename)
+}
+
+// OutputFrozen returns whether the outpoint is frozen.
+func (notes *Notes) OutputFrozen(outPoint string) bool {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.FrozenOutputs[outPoint]
+}
+
+// FrozenOutputs returns the frozen outpoints, sorted.
+func (notes *Notes) FrozenOutputs() []string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	outPoints := make([]string, 0, len(notes.data.FrozenOutputs))
+	for outPoint := range notes.data.FrozenOutputs {
+		outPoints = append(outPoints, outPoint)
+	}
+	sort.Strings(outPoints)
+	return outPoints
+}
+
+// SetSentConversions stores the fiat values of a sent transaction.
+func (notes *Notes) SetSentConversions(txID string, conversions *SentConversions) error {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if notes.data.SentConversions == nil {
+		notes.data.SentConversions = map[string]*SentConversions{}
+	}
+	notes.data.SentConversions[txID] = conversions
+	return write(notes.data, notes.filename)
+}
+
+// SentConversions returns the fiat values of a sent transaction, or nil if none were stored. You
+// must not modify the returned object.
+func (notes *Notes) SentConversions(txID string) *SentConversions {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.SentConversions[txID]
+}
+
 // Data retrieves all stored notes. You must not modify the returned object.
 func (notes *Notes) Data() *Data {
 	notes.dataMu.RLock()
@@ -89,6 +89,183 @@ func TestMaxLen(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestFlags(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "", notes.Flag("address-1"))
+
+	require.NoError(t, notes.SetFlags(map[string]string{
+		"address-1":  "refund",
+		"outpoint-1": "exchange",
+	}))
+	require.Equal(t, "refund", notes.Flag("address-1"))
+	require.NoError(t, notes.AddFlag("outpoint-2", "refund"))
+	require.Equal(t, "refund", notes.Flag("outpoint-2"))
+
+	// Invalid lists are rejected as a whole.
+	require.Error(t, notes.SetFlags(map[string]string{"address-2": "refund", "": "refund"}))
+	require.Error(t, notes.SetFlags(map[string]string{"address-2": ""}))
+	require.Error(t, notes.AddFlag("address-2", strings.Repeat("x", 1025)))
+	require.Equal(t, "", notes.Flag("address-2"))
+
+	// The returned list is a copy.
+	flags := notes.Flags()
+	flags["address-1"] = "changed"
+	require.Equal(t, "refund", notes.Flag("address-1"))
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t,
+		map[string]string{
+			"address-1":  "refund",
+			"outpoint-1": "exchange",
+			"outpoint-2": "refund",
+		},
+		notes.Flags())
+
+	require.NoError(t, notes.SetFlags(nil))
+	require.Empty(t, notes.Flags())
+}
+
+func TestFrozenOutputs(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+	require.False(t, notes.OutputFrozen("outpoint-1"))
+	require.Empty(t, notes.FrozenOutputs())
+
+	changed, err := notes.SetOutputFrozen("outpoint-2", true)
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputFrozen("outpoint-1", true)
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputFrozen("outpoint-1", true)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.True(t, notes.OutputFrozen("outpoint-1"))
+
+	_, err = notes.SetOutputFrozen("", true)
+	require.Error(t, err)
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, []string{"outpoint-1", "outpoint-2"}, notes.FrozenOutputs())
+
+	changed, err = notes.SetOutputFrozen("outpoint-1", false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputFrozen("outpoint-3", false)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, []string{"outpoint-2"}, notes.FrozenOutputs())
+}
+
+func TestSentConversions(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+	require.Nil(t, notes.SentConversions("tx-id"))
+
+	conversions := &SentConversions{
+		RatesSnapshotID: "3",
+		Amount:          map[string]string{"USD": "21.00"},
+		Fee:             map[string]string{"USD": "0.10"},
+		Total:           map[string]string{"USD": "21.10"},
+	}
+	require.NoError(t, notes.SetSentConversions("tx-id", conversions))
+	require.Equal(t, conversions, notes.SentConversions("tx-id"))
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, conversions, notes.SentConversions("tx-id"))
+	require.Nil(t, notes.SentConversions("other-tx-id"))
+}
+
+func TestAddressNotes(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "", notes.AddressNote("address-1"))
+	require.Empty(t, notes.AddressNotes())
+
+	changed, err := notes.SetAddressNote("address-1", "from exchange")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetAddressNote("address-1", "from exchange")
+	require.NoError(t, err)
+	require.False(t, changed)
+	changed, err = notes.SetAddressNote("address-2", "donations")
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	_, err = notes.SetAddressNote("", "note")
+	require.Error(t, err)
+	_, err = notes.SetAddressNote("address-3", strings.Repeat("x", MaxNoteLen+1))
+	require.Error(t, err)
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "from exchange", notes.AddressNote("address-1"))
+	require.Equal(t, map[string]string{
+		"address-1": "from exchange",
+		"address-2": "donations",
+	}, notes.AddressNotes())
+
+	changed, err = notes.SetAddressNote("address-1", "")
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, map[string]string{"address-2": "donations"}, notes.AddressNotes())
+}
+
+func TestInputAndOutputNotes(t *testing.T) {
+	const outPoint1 = "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:0"
+	const outPoint2 = "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:1"
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+	require.Empty(t, notes.InputNotes())
+	require.Empty(t, notes.OutputNotes())
+
+	changed, err := notes.SetInputNote(outPoint1, "paid rent")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputNote(outPoint1, "salary")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputNote(outPoint1, "salary")
+	require.NoError(t, err)
+	require.False(t, changed)
+	changed, err = notes.SetOutputNote(outPoint2, "change")
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	_, err = notes.SetInputNote("", "note")
+	require.Error(t, err)
+	_, err = notes.SetOutputNote("", "note")
+	require.Error(t, err)
+	_, err = notes.SetOutputNote(outPoint1, strings.Repeat("x", MaxNoteLen+1))
+	require.Error(t, err)
+
+	// Reload notes. Input and output notes of the same outpoint are kept apart.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "paid rent", notes.InputNote(outPoint1))
+	require.Equal(t, "salary", notes.OutputNote(outPoint1))
+	require.Equal(t, "", notes.InputNote(outPoint2))
+	require.Equal(t, map[string]string{outPoint1: "paid rent"}, notes.InputNotes())
+
+	changed, err = notes.SetOutputNote(outPoint1, "")
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, map[string]string{outPoint2: "change"}, notes.OutputNotes())
+}
+
 func TestMergeLegacy(t *testing.T) {
 	filename := test.TstTempFile("account-notes")
 	notes, err := LoadNotes(filename)
@@ -26,4 +26,7 @@ type Notifier interface {
 	UnnotifiedCount() (int, error)
 	// MarkAllNotified moves all ids from the 'unnotified' set to the 'seen' set.
 	MarkAllNotified() error
+	// HasTransactions returns whether a transaction was ever seen in the account, and in any account
+	// of the same keystore.
+	HasTransactions() (account bool, keystore bool, err error)
 }
@@ -0,0 +1,111 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// maxSyncMetrics is the number of past syncs whose metrics are kept.
+const maxSyncMetrics = 10
+
+// syncMetrics records the timings of the current sync and keeps the ones of the last syncs. Only
+// timestamps are taken, so that recording does not slow down the sync.
+type syncMetrics struct {
+	// current is nil if no sync is running.
+	current *types.SyncMetrics
+	// history contains the last maxSyncMetrics syncs, oldest first.
+	history []types.SyncMetrics
+	lock    locker.Locker
+}
+
+// startSyncMetrics is called when a sync starts.
+func (account *BaseAccount) startSyncMetrics() {
+	defer account.syncMetrics.lock.Lock()()
+	account.syncMetrics.current = &types.SyncMetrics{
+		Version: types.SyncMetricsVersion,
+		Started: time.Now(),
+		Phases:  map[types.SyncPhase]types.SyncPhaseMetrics{},
+	}
+}
+
+// finishSyncMetrics is called when a sync is done. The summary of the first sync after the account
+// was initialized is logged, so that users reporting slowness can share it.
+func (account *BaseAccount) finishSyncMetrics(initial bool) {
+	m := &account.syncMetrics
+	unlock := m.lock.Lock()
+	if m.current == nil {
+		unlock()
+		return
+	}
+	current := *m.current
+	m.current = nil
+	current.Initial = initial
+	current.DurationMillis = time.Since(current.Started).Milliseconds()
+	m.history = append(m.history, current)
+	if len(m.history) > maxSyncMetrics {
+		m.history = m.history[len(m.history)-maxSyncMetrics:]
+	}
+	unlock()
+
+	if !initial {
+		return
+	}
+	log := account.log.
+		WithField("sync-metrics-version", current.Version).
+		WithField("duration-ms", current.DurationMillis)
+	for phase, metrics := range current.Phases {
+		log = log.
+			WithField(string(phase)+"-count", metrics.Count).
+			WithField(string(phase)+"-total-ms", metrics.TotalMillis).
+			WithField(string(phase)+"-max-ms", metrics.MaxMillis)
+	}
+	log.Info("Initial sync done")
+}
+
+// RecordSyncPhase adds `count` runs of a sync phase which took `duration` in total to the metrics
+// of the current sync. It is ignored if no sync is running.
+func (account *BaseAccount) RecordSyncPhase(phase types.SyncPhase, count int, duration time.Duration) {
+	defer account.syncMetrics.lock.Lock()()
+	current := account.syncMetrics.current
+	if current == nil {
+		return
+	}
+	metrics := current.Phases[phase]
+	metrics.Count += count
+	metrics.TotalMillis += duration.Milliseconds()
+	if duration.Milliseconds() > metrics.MaxMillis {
+		metrics.MaxMillis = duration.Milliseconds()
+	}
+	current.Phases[phase] = metrics
+}
+
+// SyncMetricsHistory returns the metrics of the last finished syncs, oldest first.
+func (account *BaseAccount) SyncMetricsHistory() []types.SyncMetrics {
+	defer account.syncMetrics.lock.RLock()()
+	history := make([]types.SyncMetrics, len(account.syncMetrics.history))
+	for index, metrics := range account.syncMetrics.history {
+		phases := make(map[types.SyncPhase]types.SyncPhaseMetrics, len(metrics.Phases))
+		for phase, phaseMetrics := range metrics.Phases {
+			phases[phase] = phaseMetrics
+		}
+		metrics.Phases = phases
+		history[index] = metrics
+	}
+	return history
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// syncProgressInterval is the minimum time between two EventSyncProgress events.
+const syncProgressInterval = 500 * time.Millisecond
+
+// syncProgress holds the progress of the current sync and rate-limits the progress events.
+type syncProgress struct {
+	progress    types.SyncProgress
+	lastEmitted time.Time
+	// scheduled is true if an event is scheduled at the end of the current interval, so that the
+	// last changes within an interval are not lost.
+	scheduled bool
+	closed    bool
+	lock      locker.Locker
+}
+
+// SyncProgress implements Interface.
+func (account *BaseAccount) SyncProgress() types.SyncProgress {
+	defer account.syncProgress.lock.RLock()()
+	return account.syncProgress.progress
+}
+
+// resetSyncProgress is called when a sync starts.
+func (account *BaseAccount) resetSyncProgress() {
+	defer account.syncProgress.lock.Lock()()
+	account.syncProgress.progress = types.SyncProgress{}
+}
+
+// AddSyncProgress adds the given counts to the progress of the current sync, and fires
+// EventSyncProgress at most once per syncProgressInterval.
+func (account *BaseAccount) AddSyncProgress(delta types.SyncProgress) {
+	p := &account.syncProgress
+	unlock := p.lock.Lock()
+	p.progress.AddressesSubscribed += delta.AddressesSubscribed
+	p.progress.AddressesTotal += delta.AddressesTotal
+	p.progress.HistoriesFetched += delta.HistoriesFetched
+	p.progress.HistoriesTotal += delta.HistoriesTotal
+	p.progress.TransactionsIndexed += delta.TransactionsIndexed
+	if p.closed || p.scheduled {
+		unlock()
+		return
+	}
+	sinceLastEmitted := time.Since(p.lastEmitted)
+	if sinceLastEmitted < syncProgressInterval {
+		p.scheduled = true
+		unlock()
+		time.AfterFunc(syncProgressInterval-sinceLastEmitted, func() {
+			unlock := p.lock.Lock()
+			p.scheduled = false
+			if p.closed {
+				unlock()
+				return
+			}
+			p.lastEmitted = time.Now()
+			unlock()
+			account.config.OnEvent(types.EventSyncProgress)
+		})
+		return
+	}
+	p.lastEmitted = time.Now()
+	unlock()
+	account.config.OnEvent(types.EventSyncProgress)
+}
+
+// closeSyncProgress stops emitting progress events.
+func (account *BaseAccount) closeSyncProgress() {
+	defer account.syncProgress.lock.Lock()()
+	account.syncProgress.closed = true
+}
@@ -17,7 +17,9 @@ package accounts
 import (
 	"encoding/json"
 	"math/big"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
@@ -36,6 +38,10 @@ const (
 	TxTypeSend TxType = "send"
 	// TxTypeSendSelf is a tx from out account to our account.
 	TxTypeSendSelf TxType = "sendSelf"
+	// TxTypeComplex is a tx which spends from and pays to both our account and external parties,
+	// e.g. a coinjoin, a payjoin or a batched exchange withdrawal also spending our deposit. See
+	// TransactionData.GrossIn and TransactionData.GrossOut.
+	TxTypeComplex TxType = "complex"
 )
 
 // TxStatus is the status of the tx and helps the frontend show the appropriate information.
@@ -82,8 +88,7 @@ type TransactionData struct {
 	// (e.g. an internal/smart contract tx shown semantically, as well as the raw zero value
 	// contract execution tx).
 	InternalID string
-	// Height is the block number at which this tx confirmed, or 0 if unconfirmed. -1 if unconfirmed
-	// with an unconfirmed parent.
+	// Height is the block number at which this tx confirmed, or 0 if unconfirmed.
 	Height int
 	// NumConfirmations is the number of confirmations. 0 for unconfirmed.
 	NumConfirmations int
@@ -94,7 +99,9 @@ type TransactionData struct {
 	Status TxStatus
 	// Type returns the type of the transaction.
 	Type TxType
-	// Amount is always >0 and is the amount received or sent (not including the fee).
+	// Amount is always >0 and is the amount received or sent (not including the fee). For
+	// TxTypeComplex, it is the absolute net effect on the balance of the account, including our
+	// share of the fee.
 	Amount coin.Amount
 	// Balance is balance of the account at the time of this transaction. It is the sum of all
 	// transactions up to this point.
@@ -114,8 +121,19 @@ type TransactionData struct {
 	// Size is the serialized tx size in bytes.
 	Size int64
 	// Weight is the tx weight.
-	Weight           int64
+	Weight int64
+	// GrossIn is the sum of the outputs paying to our account, including change. Only set for
+	// TxTypeComplex.
+	GrossIn *coin.Amount
+	// GrossOut is the sum of our outputs spent by the tx. Only set for TxTypeComplex.
+	GrossOut         *coin.Amount
 	CreatedTimestamp *time.Time
+	// Verified is true if the inclusion of the tx in its block was verified using the block
+	// headers (SPV). nil for coins which don't verify transactions.
+	Verified *bool
+	// LastBroadcast is the time the wallet last broadcast the tx. Only set for outgoing txs which
+	// are not confirmed yet and are broadcast again periodically.
+	LastBroadcast *time.Time
 
 	// --- Fields only used for ETH follow
 
@@ -137,16 +155,18 @@ type byHeight []*TransactionData
 
 func (s byHeight) Len() int { return len(s) }
 func (s byHeight) Less(i, j int) bool {
-	// Secondary sort by the time we've first seen the tx in the app.
-	if s[i].CreatedTimestamp != nil && s[j].CreatedTimestamp != nil {
-		if (s[i].Height == s[j].Height) || (s[i].Height <= 0 && s[j].Height <= 0) {
+	iConfirmed, jConfirmed := s[i].isConfirmed(), s[j].isConfirmed()
+	if s[i].Height == s[j].Height || (!iConfirmed && !jConfirmed) {
+		// Secondary sort by the time we've first seen the tx in the app.
+		if s[i].CreatedTimestamp != nil && s[j].CreatedTimestamp != nil {
 			return s[i].CreatedTimestamp.Before(*s[j].CreatedTimestamp)
 		}
+		return false
 	}
-	if s[j].Height <= 0 {
+	if !jConfirmed {
 		return true
 	}
-	if s[i].Height <= 0 {
+	if !iConfirmed {
 		return false
 	}
 	return s[i].Height < s[j].Height
@@ -178,6 +198,10 @@ func NewOrderedTransactions(txs []*TransactionData) OrderedTransactions {
 			if tx.Fee != nil && !tx.FeeIsDifferentUnit {
 				balance.Sub(balance, tx.Fee.BigInt())
 			}
+		case TxTypeComplex:
+			// The fee is included, as we only know our share of it implicitly.
+			balance.Add(balance, tx.GrossIn.BigInt())
+			balance.Sub(balance, tx.GrossOut.BigInt())
 		case TxTypeSendSelf:
 			// Subtract only fee. Ethereum: it is deducted even if the tx failed, as the tx was
 			// mined.
@@ -211,6 +235,89 @@ func (c *TimeseriesEntry) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// Listed returns the transactions which are shown in the transaction history. ERC20 transactions
+// with a zero amount are skipped to mitigate address poisoning attacks.
+func (txs OrderedTransactions) Listed() OrderedTransactions {
+	listed := make(OrderedTransactions, 0, len(txs))
+	for _, tx := range txs {
+		if tx.IsErc20 && tx.Amount.BigInt().Sign() == 0 {
+			continue
+		}
+		listed = append(listed, tx)
+	}
+	return listed
+}
+
+// TransactionsFilter selects transactions of the transaction history, see
+// OrderedTransactions.Filter(). The zero value matches all transactions.
+type TransactionsFilter struct {
+	// Types are the accepted transaction types. All types are accepted if empty.
+	Types []TxType
+	// From and To limit the time of the transaction, both inclusive. The time is the block time if
+	// the tx is confirmed and the time the tx was first seen otherwise. Transactions without a time
+	// do not match if a time range is set.
+	From *time.Time
+	To   *time.Time
+	// MinAmount and MaxAmount limit the amount of the transaction, both inclusive.
+	MinAmount *big.Int
+	MaxAmount *big.Int
+	// Query, if not empty, must be contained in the note or in one of the addresses of the
+	// transaction, ignoring case.
+	Query string
+}
+
+// Filter returns the transactions matching the filter, in the same order. txNote returns the note of
+// a transaction by its internal ID.
+//
+// The transactions are scanned linearly. The list is cached by the accounts and the filter does not
+// access the database, so this is fast even for long histories.
+func (txs OrderedTransactions) Filter(
+	filter *TransactionsFilter, txNote func(internalID string) string) OrderedTransactions {
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	result := OrderedTransactions{}
+	for _, tx := range txs {
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, tx.Type) {
+			continue
+		}
+		if filter.From != nil || filter.To != nil {
+			txTime := tx.Timestamp
+			if txTime == nil {
+				txTime = tx.CreatedTimestamp
+			}
+			if txTime == nil ||
+				(filter.From != nil && txTime.Before(*filter.From)) ||
+				(filter.To != nil && txTime.After(*filter.To)) {
+				continue
+			}
+		}
+		if filter.MinAmount != nil && tx.Amount.BigInt().Cmp(filter.MinAmount) < 0 {
+			continue
+		}
+		if filter.MaxAmount != nil && tx.Amount.BigInt().Cmp(filter.MaxAmount) > 0 {
+			continue
+		}
+		if query != "" && !tx.containsText(query, txNote) {
+			continue
+		}
+		result = append(result, tx)
+	}
+	return result
+}
+
+// containsText returns true if the lowercase query is contained in the note or in one of the
+// addresses of the transaction.
+func (tx *TransactionData) containsText(query string, txNote func(internalID string) string) bool {
+	if strings.Contains(strings.ToLower(txNote(tx.InternalID)), query) {
+		return true
+	}
+	for _, address := range tx.Addresses {
+		if strings.Contains(strings.ToLower(address.Address), query) {
+			return true
+		}
+	}
+	return false
+}
+
 // EarliestTime returns the timestamp of the latest transaction. Zero is returned if there is no
 // transaction with a timestamp. Returns `errors.ErrNotAvailable` if timestamp data is missing.
 func (txs OrderedTransactions) EarliestTime() (time.Time, error) {
@@ -15,6 +15,7 @@
 package accounts
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
@@ -231,3 +232,106 @@ func TestOrderedTransactionsWithFailedTransactions(t *testing.T) {
 		require.Equal(t, coin.NewAmountFromInt64(expectedBalances[i]), ordered[i].Balance, i)
 	}
 }
+
+// TestOrderedTransactionsUnconfirmedWithoutCreatedTimestamp checks that unconfirmed txs are sorted
+// consistently even if the first seen time is unknown.
+func TestOrderedTransactionsUnconfirmedWithoutCreatedTimestamp(t *testing.T) {
+	txs := []*TransactionData{
+		{Height: 0, InternalID: "unconfirmed1", Amount: coin.NewAmountFromInt64(1)},
+		{Height: 10, InternalID: "confirmed1", Amount: coin.NewAmountFromInt64(1)},
+		{Height: 0, InternalID: "unconfirmed2", Amount: coin.NewAmountFromInt64(1)},
+		{Height: 20, InternalID: "confirmed2", Amount: coin.NewAmountFromInt64(1)},
+	}
+	require.False(t, byHeight(txs).Less(0, 2))
+	require.False(t, byHeight(txs).Less(2, 0))
+
+	ordered := NewOrderedTransactions(txs)
+	ids := []string{}
+	for _, tx := range ordered {
+		ids = append(ids, tx.InternalID)
+	}
+	require.ElementsMatch(t, []string{"unconfirmed1", "unconfirmed2"}, ids[:2])
+	require.Equal(t, []string{"confirmed2", "confirmed1"}, ids[2:])
+}
+
+func TestOrderedTransactionsListed(t *testing.T) {
+	txs := OrderedTransactions{
+		{InternalID: "btc", Amount: coin.NewAmountFromInt64(0)},
+		{InternalID: "erc20", Amount: coin.NewAmountFromInt64(1), IsErc20: true},
+		{InternalID: "erc20-zero", Amount: coin.NewAmountFromInt64(0), IsErc20: true},
+	}
+	listed := txs.Listed()
+	require.Len(t, listed, 2)
+	require.Equal(t, "btc", listed[0].InternalID)
+	require.Equal(t, "erc20", listed[1].InternalID)
+	require.Len(t, txs, 3)
+}
+
+func TestOrderedTransactionsFilter(t *testing.T) {
+	tt := func(t time.Time) *time.Time { return &t }
+	txs := OrderedTransactions{
+		{
+			InternalID:       "unconfirmed",
+			Type:             TxTypeReceive,
+			Amount:           coin.NewAmountFromInt64(500),
+			CreatedTimestamp: tt(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+			Addresses:        []AddressAndAmount{{Address: "bc1qReceive"}},
+		},
+		{
+			InternalID: "send",
+			Type:       TxTypeSend,
+			Amount:     coin.NewAmountFromInt64(200),
+			Timestamp:  tt(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)),
+			Addresses:  []AddressAndAmount{{Address: "bc1qshop"}},
+		},
+		{
+			InternalID: "self",
+			Type:       TxTypeSendSelf,
+			Amount:     coin.NewAmountFromInt64(100),
+			Timestamp:  tt(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		{
+			InternalID: "no time",
+			Type:       TxTypeReceive,
+			Amount:     coin.NewAmountFromInt64(50),
+		},
+	}
+	notes := map[string]string{"self": "Consolidation", "send": "Coffee"}
+	txNote := func(internalID string) string { return notes[internalID] }
+	ids := func(filter *TransactionsFilter) []string {
+		result := []string{}
+		for _, tx := range txs.Filter(filter, txNote) {
+			result = append(result, tx.InternalID)
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"unconfirmed", "send", "self", "no time"}, ids(&TransactionsFilter{}))
+	require.Equal(t, []string{"unconfirmed", "no time"},
+		ids(&TransactionsFilter{Types: []TxType{TxTypeReceive}}))
+	require.Equal(t, []string{"send", "self"},
+		ids(&TransactionsFilter{Types: []TxType{TxTypeSend, TxTypeSendSelf}}))
+
+	// Time range, both inclusive. The unconfirmed tx uses the time it was first seen.
+	require.Equal(t, []string{"unconfirmed", "send"},
+		ids(&TransactionsFilter{From: tt(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC))}))
+	require.Equal(t, []string{"send", "self"},
+		ids(&TransactionsFilter{To: tt(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC))}))
+
+	// Amount range, both inclusive.
+	require.Equal(t, []string{"send", "self"}, ids(&TransactionsFilter{
+		MinAmount: big.NewInt(100),
+		MaxAmount: big.NewInt(200),
+	}))
+
+	// Text search in notes and addresses, ignoring case.
+	require.Equal(t, []string{"send"}, ids(&TransactionsFilter{Query: "coffee"}))
+	require.Equal(t, []string{"unconfirmed"}, ids(&TransactionsFilter{Query: " RECEIVE "}))
+	require.Equal(t, []string{}, ids(&TransactionsFilter{Query: "nothing"}))
+
+	// Filters are combined.
+	require.Equal(t, []string{"self"}, ids(&TransactionsFilter{
+		Types: []TxType{TxTypeSendSelf},
+		Query: "consolidation",
+	}))
+}
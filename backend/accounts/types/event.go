@@ -31,4 +31,34 @@ const (
 
 	// EventHeadersSynced is fired when the headers finished syncing.
 	EventHeadersSynced Event = "headersSynced"
+
+	// EventNewTransaction is fired once when a new transaction is seen, and once more when it gets
+	// its first confirmation. The transaction is passed along in the event.
+	EventNewTransaction Event = "newTransaction"
+
+	// EventFirstTransaction is fired once when the very first transaction of an account arrives. It
+	// is not fired for the transaction history discovered when adding or restoring an account. It is
+	// fired before the EventNewTransaction of the transaction.
+	EventFirstTransaction Event = "firstTransaction"
+
+	// EventSyncProgress is fired while syncing when the sync progress changed, at most every few
+	// hundred milliseconds. The progress is passed along in the event. EventSyncStarted and
+	// EventSyncDone are still fired around it.
+	EventSyncProgress Event = "syncProgress"
 )
+
+// SyncProgress counts the work done in the current sync, which starts with EventSyncStarted. The
+// totals grow while syncing, as more addresses are discovered.
+type SyncProgress struct {
+	// AddressesSubscribed is the number of addresses whose status was received from the backend.
+	AddressesSubscribed int `json:"addressesSubscribed"`
+	// AddressesTotal is the number of addresses subscribed to.
+	AddressesTotal int `json:"addressesTotal"`
+	// HistoriesFetched is the number of address histories which were downloaded and processed.
+	HistoriesFetched int `json:"historiesFetched"`
+	// HistoriesTotal is the number of address histories which need to be downloaded, because the
+	// status of the address changed.
+	HistoriesTotal int `json:"historiesTotal"`
+	// TransactionsIndexed is the number of transactions downloaded and indexed.
+	TransactionsIndexed int `json:"transactionsIndexed"`
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// SyncMetricsVersion is the version of the SyncMetrics structure. It is increased when a field is
+// removed or changes its meaning, so that external tooling can parse the metrics of different
+// releases. Adding fields or phases does not change the version.
+const SyncMetricsVersion = 1
+
+// SyncPhase is a phase of syncing an account.
+type SyncPhase string
+
+const (
+	// SyncPhaseSubscribe is the time from subscribing to an address until its status is received.
+	SyncPhaseSubscribe SyncPhase = "subscribe"
+	// SyncPhaseHistoryFetch is the time to download the history of an address.
+	SyncPhaseHistoryFetch SyncPhase = "historyFetch"
+	// SyncPhaseTxFetch is the time to download the transactions of an address history which are not
+	// cached yet. Their outputs are the previous outputs of the spending transactions.
+	SyncPhaseTxFetch SyncPhase = "txFetch"
+	// SyncPhaseProcessing is the time to index the transactions of an address history, excluding
+	// the downloads.
+	SyncPhaseProcessing SyncPhase = "processing"
+)
+
+// SyncPhaseMetrics are the timings of one phase of a sync. A phase runs for many addresses
+// concurrently, so the total duration of a phase can exceed the duration of the sync.
+type SyncPhaseMetrics struct {
+	// Count is the number of times the phase ran, e.g. the number of downloaded histories.
+	Count int `json:"count"`
+	// TotalMillis is the sum of the durations of all runs of the phase.
+	TotalMillis int64 `json:"totalMillis"`
+	// MaxMillis is the duration of the longest single run of the phase.
+	MaxMillis int64 `json:"maxMillis"`
+}
+
+// SyncMetrics are the timings of one sync of an account, which starts with EventSyncStarted and
+// ends with EventSyncDone.
+type SyncMetrics struct {
+	// Version is SyncMetricsVersion.
+	Version int `json:"version"`
+	// Initial is true for the first sync after the account was initialized or rescanned.
+	Initial bool `json:"initial"`
+	// Started is when the sync started.
+	Started time.Time `json:"started"`
+	// DurationMillis is the duration of the whole sync.
+	DurationMillis int64 `json:"durationMillis"`
+	// Phases contains the phases which ran during the sync.
+	Phases map[SyncPhase]SyncPhaseMetrics `json:"phases"`
+}
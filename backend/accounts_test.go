@@ -18,11 +18,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -263,7 +266,7 @@ func TestNextAccountNumber(t *testing.T) {
 					signing.NewBitcoinConfiguration(
 						signing.ScriptTypeP2WPKH,
 						rootFingerprint2,
-						mustKeypath("m/84'/0'/4'"),
+						mustKeypath("m/84'/0'/99'"),
 						xpub,
 					),
 				},
@@ -292,6 +295,25 @@ func TestNextAccountNumber(t *testing.T) {
 
 	_, err = nextAccountNumber(coinpkg.CodeTBTC, ks(rootFingerprint2, true), accountsConfig)
 	require.Equal(t, errAccountLimitReached, errp.Cause(err))
+
+	// Beyond the accounts which are always scanned, an account can only be added after a used one.
+	accountsConfig.Accounts = append(accountsConfig.Accounts, &config.Account{
+		CoinCode: coinpkg.CodeTBTC,
+		SigningConfigurations: signing.Configurations{
+			signing.NewBitcoinConfiguration(
+				signing.ScriptTypeP2WPKH,
+				rootFingerprint1,
+				mustKeypath("m/84'/0'/4'"),
+				xpub,
+			),
+		},
+	})
+	_, err = nextAccountNumber(coinpkg.CodeTBTC, ks(rootFingerprint1, true), accountsConfig)
+	require.Equal(t, errAccountPreviousUnused, errp.Cause(err))
+	accountsConfig.Accounts[len(accountsConfig.Accounts)-1].Used = true
+	num, err = nextAccountNumber(coinpkg.CodeTBTC, ks(rootFingerprint1, true), accountsConfig)
+	require.NoError(t, err)
+	require.Equal(t, uint16(5), num)
 }
 
 func TestSupportedCoins(t *testing.T) {
@@ -312,7 +334,7 @@ func TestSupportedCoins(t *testing.T) {
 		b := newBackend(t, testnetEnabled, regtestDisabled)
 		defer b.Close()
 		require.Equal(t,
-			[]coinpkg.Code{coinpkg.CodeTBTC, coinpkg.CodeTLTC, coinpkg.CodeGOETH, coinpkg.CodeSEPETH},
+			[]coinpkg.Code{coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeTLTC, coinpkg.CodeGOETH, coinpkg.CodeSEPETH},
 			b.SupportedCoins(&keystoremock.KeystoreMock{
 				SupportsCoinFunc: func(coin coinpkg.Coin) bool {
 					return true
@@ -1056,6 +1078,145 @@ func TestRenameAccount(t *testing.T) {
 	require.NoError(t, b.RenameAccount("v0-55555555-btc-0", "renamed"))
 	require.Equal(t, "renamed", b.Accounts().lookup("v0-55555555-btc-0").Config().Config.Name)
 	require.Equal(t, "renamed", b.config.AccountsConfig().Lookup("v0-55555555-btc-0").Name)
+
+	// The name is trimmed.
+	require.NoError(t, b.RenameAccount("v0-55555555-btc-0", "  savings\t"))
+	require.Equal(t, "savings", b.config.AccountsConfig().Lookup("v0-55555555-btc-0").Name)
+
+	// 64 characters are allowed, regardless of their encoded length.
+	longName := strings.Repeat("ä", 64)
+	require.NoError(t, b.RenameAccount("v0-55555555-btc-0", longName))
+	require.Equal(t, longName, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").Name)
+
+	err := b.RenameAccount("v0-55555555-btc-0", longName+"ä")
+	require.Equal(t, errAccountNameTooLong, errp.Cause(err))
+	err = b.RenameAccount("v0-55555555-btc-0", " ")
+	require.Equal(t, errAccountNameEmpty, errp.Cause(err))
+	require.Equal(t, longName, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").Name)
+	require.Error(t, b.RenameAccount("unknown", "name"))
+}
+
+func TestSetAccountShowUsedAddresses(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+
+	require.False(t, b.Accounts().lookup("v0-55555555-btc-0").Config().Config.ShowUsedAddresses)
+	require.NoError(t, b.SetAccountShowUsedAddresses("v0-55555555-btc-0", true))
+	require.True(t, b.Accounts().lookup("v0-55555555-btc-0").Config().Config.ShowUsedAddresses)
+	require.True(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").ShowUsedAddresses)
+	require.NoError(t, b.SetAccountShowUsedAddresses("v0-55555555-btc-0", false))
+	require.False(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").ShowUsedAddresses)
+
+	require.Error(t, b.SetAccountShowUsedAddresses("unknown", true))
+}
+
+func TestSetAccountMinConfirmations(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+
+	require.NoError(t, b.SetAccountMinConfirmations("v0-55555555-btc-0", 3, true))
+	accountConfig := b.config.AccountsConfig().Lookup("v0-55555555-btc-0")
+	require.Equal(t, 3, accountConfig.MinConfirmations)
+	require.True(t, accountConfig.SpendUnconfirmedChange)
+	require.Equal(t, 3, b.Accounts().lookup("v0-55555555-btc-0").Config().Config.MinConfirmations)
+
+	require.Error(t, b.SetAccountMinConfirmations("v0-55555555-btc-0", -1, false))
+	require.Error(t, b.SetAccountMinConfirmations("v0-55555555-btc-0", maxMinConfirmations+1, false))
+	require.Equal(t, 3, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").MinConfirmations)
+
+	require.Error(t, b.SetAccountMinConfirmations("v0-55555555-eth-0", 1, false))
+	require.Error(t, b.SetAccountMinConfirmations("unknown", 1, false))
+}
+
+func TestSetAccountDustThreshold(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+
+	require.NoError(t, b.SetAccountDustThreshold("v0-55555555-btc-0", 1000, true))
+	accountConfig := b.config.AccountsConfig().Lookup("v0-55555555-btc-0")
+	require.Equal(t, int64(1000), accountConfig.DustThreshold)
+	require.True(t, accountConfig.ShowOwnDust)
+	require.Equal(t, int64(1000), b.Accounts().lookup("v0-55555555-btc-0").Config().Config.DustThreshold)
+
+	require.Error(t, b.SetAccountDustThreshold("v0-55555555-btc-0", -1, false))
+	require.Error(t, b.SetAccountDustThreshold("v0-55555555-btc-0", maxDustThreshold+1, false))
+	require.Equal(t, int64(1000), b.config.AccountsConfig().Lookup("v0-55555555-btc-0").DustThreshold)
+
+	require.Error(t, b.SetAccountDustThreshold("v0-55555555-eth-0", 1000, false))
+	require.Error(t, b.SetAccountDustThreshold("unknown", 1000, false))
+}
+
+func TestSetAccountRequireVerifiedTxs(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+
+	require.NoError(t, b.SetAccountRequireVerifiedTxs("v0-55555555-btc-0", true))
+	require.True(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").RequireVerifiedTxs)
+	require.True(t, b.Accounts().lookup("v0-55555555-btc-0").Config().Config.RequireVerifiedTxs)
+	require.NoError(t, b.SetAccountRequireVerifiedTxs("v0-55555555-btc-0", false))
+	require.False(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").RequireVerifiedTxs)
+
+	require.Error(t, b.SetAccountRequireVerifiedTxs("v0-55555555-eth-0", true))
+	require.Error(t, b.SetAccountRequireVerifiedTxs("unknown", true))
+}
+
+func TestSetAccountPreferredScriptType(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+
+	require.NoError(t, b.SetAccountPreferredScriptType("v0-55555555-btc-0", signing.ScriptTypeP2TR))
+	require.Equal(t, signing.ScriptTypeP2TR,
+		b.config.AccountsConfig().Lookup("v0-55555555-btc-0").PreferredScriptType)
+	require.Equal(t, signing.ScriptTypeP2TR,
+		b.Accounts().lookup("v0-55555555-btc-0").Config().Config.PreferredScriptType)
+
+	// Reset to the default order.
+	require.NoError(t, b.SetAccountPreferredScriptType("v0-55555555-btc-0", ""))
+	require.Empty(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-0").PreferredScriptType)
+
+	// Insured accounts only receive on native segwit.
+	require.NoError(t, b.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		accountsConfig.Lookup("v0-55555555-btc-0").InsuranceStatus = string(bitsurance.ActiveStatus)
+		return nil
+	}))
+	require.Error(t, b.SetAccountPreferredScriptType("v0-55555555-btc-0", signing.ScriptTypeP2TR))
+	require.NoError(t, b.SetAccountPreferredScriptType("v0-55555555-btc-0", signing.ScriptTypeP2WPKH))
+
+	// Litecoin accounts have no taproot configuration.
+	require.Error(t, b.SetAccountPreferredScriptType("v0-55555555-ltc-0", signing.ScriptTypeP2TR))
+	require.Empty(t, b.config.AccountsConfig().Lookup("v0-55555555-ltc-0").PreferredScriptType)
+	require.Error(t, b.SetAccountPreferredScriptType("v0-55555555-eth-0", signing.ScriptTypeP2WPKH))
+	require.Error(t, b.SetAccountPreferredScriptType("unknown", signing.ScriptTypeP2WPKH))
 }
 
 func TestMaybeAddHiddenUnusedAccounts(t *testing.T) {
@@ -1111,6 +1272,64 @@ func TestMaybeAddHiddenUnusedAccounts(t *testing.T) {
 	require.NotNil(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-6"))
 }
 
+func TestMaybeAddHiddenUnusedAccountsWalletBirthday(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	// registering a keystore calls `go maybeAddHiddenunusedAccounts()` - we need wait for it to
+	// complete before setting the birthday.
+	hiddenAccountsAdded := make(chan struct{})
+	var once sync.Once
+	b.tstMaybeAddHiddenUnusedAccounts = func() {
+		once.Do(func() { close(hiddenAccountsAdded) })
+	}
+
+	bitbox02LikeKeystore := makeBitBox02Multi()
+	bitbox02LikeKeystore.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(bitbox02LikeKeystore)
+	select {
+	case <-hiddenAccountsAdded:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "expected hidden accounts to be added")
+	}
+	require.NoError(t, b.SetWalletBirthday(rootFingerprint1, &config.WalletBirthday{Height: 800000}))
+	require.Error(t, b.SetWalletBirthday(rootFingerprint1, &config.WalletBirthday{}))
+	// The headers sync can skip the headers below the birthday.
+	require.Equal(t, 800000, b.headersBirthday())
+
+	// The birthday does not skip the accounts which are always scanned.
+	for i := 1; i <= 10; i++ {
+		b.maybeAddHiddenUnusedAccounts()
+	}
+	require.Len(t, b.config.AccountsConfig().Accounts, 3+2*5)
+
+	// Transactions after the birthday keep the birthday.
+	btcAccount := b.Accounts().lookup("v0-55555555-btc-0")
+	require.NotNil(t, btcAccount)
+	b.checkWalletBirthday(btcAccount, accounts.OrderedTransactions{
+		{TxID: "after", Height: 800001},
+	})
+	ks, err := b.config.AccountsConfig().LookupKeystore(rootFingerprint1)
+	require.NoError(t, err)
+	require.NotNil(t, ks.Birthday)
+
+	// A transaction before the birthday discards the birthday, so the headers are synced fully.
+	b.checkWalletBirthday(btcAccount, accounts.OrderedTransactions{
+		{TxID: "after", Height: 800001},
+		{TxID: "before", Height: 799999},
+	})
+	ks, err = b.config.AccountsConfig().LookupKeystore(rootFingerprint1)
+	require.NoError(t, err)
+	require.Nil(t, ks.Birthday)
+	require.Equal(t, 0, b.headersBirthday())
+	for i := 1; i <= 10; i++ {
+		b.maybeAddHiddenUnusedAccounts()
+	}
+	require.Len(t, b.config.AccountsConfig().Accounts, 3+2*5)
+}
+
 func TestWatchonly(t *testing.T) {
 	filterAcct := func(code accountsTypes.Code) func(acct *config.Account) bool {
 		return func(acct *config.Account) bool {
@@ -0,0 +1,367 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	// ErrAccountsDiscoveryInitialize means that an account could not be initialized during accounts
+	// discovery, e.g. because the server could not be reached.
+	ErrAccountsDiscoveryInitialize errp.ErrorCode = "accountsDiscoveryInitialize"
+	// ErrAccountsDiscoveryTransactions means that the transaction history of an account could not
+	// be retrieved during accounts discovery.
+	ErrAccountsDiscoveryTransactions errp.ErrorCode = "accountsDiscoveryTransactions"
+	// ErrAccountsDiscoveryUnsupported is returned when retrying or canceling the accounts discovery
+	// of a coin which is not discovered automatically.
+	ErrAccountsDiscoveryUnsupported errp.ErrorCode = "accountsDiscoveryUnsupported"
+)
+
+// AccountsDiscoveryStatus is the status of the accounts discovery of a coin.
+type AccountsDiscoveryStatus string
+
+const (
+	// AccountsDiscoveryStatusRunning means that there are accounts which were not checked yet.
+	AccountsDiscoveryStatusRunning AccountsDiscoveryStatus = "running"
+	// AccountsDiscoveryStatusDone means that all accounts were checked and no more accounts need
+	// to be scanned.
+	AccountsDiscoveryStatusDone AccountsDiscoveryStatus = "done"
+	// AccountsDiscoveryStatusFailed means that checking an account failed. The discovery can be
+	// resumed using RetryAccountsDiscovery().
+	AccountsDiscoveryStatusFailed AccountsDiscoveryStatus = "failed"
+	// AccountsDiscoveryStatusCanceled means that the user canceled the discovery. It can be resumed
+	// using RetryAccountsDiscovery().
+	AccountsDiscoveryStatusCanceled AccountsDiscoveryStatus = "canceled"
+)
+
+// AccountsDiscovery is the state of the accounts discovery of a coin of the connected keystore.
+// It is also the payload of the `accounts-discovery` event, which is emitted whenever the
+// discovery of a coin progresses.
+type AccountsDiscovery struct {
+	CoinCode coinpkg.Code            `json:"coinCode"`
+	Status   AccountsDiscoveryStatus `json:"status"`
+	// LastCheckedAccountNumber is the highest account number which was fully checked. -1 if no
+	// account was checked yet.
+	LastCheckedAccountNumber int `json:"lastCheckedAccountNumber"`
+	// UsedAccounts is the number of used accounts found so far.
+	UsedAccounts int `json:"usedAccounts"`
+	// FailedAccountCode is the account which could not be checked if the status is failed.
+	FailedAccountCode accountsTypes.Code `json:"failedAccountCode,omitempty"`
+	// ErrorCode is the reason of the failure if the status is failed, e.g.
+	// ErrAccountsDiscoveryInitialize.
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// accountsDiscoveryCoinCodes returns the coins for which accounts are discovered automatically, see
+// maybeAddHiddenUnusedAccounts().
+func (backend *Backend) accountsDiscoveryCoinCodes() []coinpkg.Code {
+	switch {
+	case backend.arguments.Regtest():
+		return []coinpkg.Code{coinpkg.CodeRBTC}
+	case backend.arguments.Testing():
+		return []coinpkg.Code{coinpkg.CodeTBTC, coinpkg.CodeTLTC}
+	default:
+		return []coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeLTC}
+	}
+}
+
+func (backend *Backend) checkAccountsDiscoveryCoin(coinCode coinpkg.Code) error {
+	for _, code := range backend.accountsDiscoveryCoinCodes() {
+		if code == coinCode {
+			return nil
+		}
+	}
+	return errp.WithStack(ErrAccountsDiscoveryUnsupported)
+}
+
+// accountsDiscovery computes the discovery state of a coin of the keystore with the given root
+// fingerprint.
+func (backend *Backend) accountsDiscovery(
+	rootFingerprint []byte, coinCode coinpkg.Code) *AccountsDiscovery {
+	accountsConfig := backend.config.AccountsConfig()
+	result := &AccountsDiscovery{
+		CoinCode:                 coinCode,
+		LastCheckedAccountNumber: -1,
+	}
+	canceled := false
+	if ks, err := accountsConfig.LookupKeystore(rootFingerprint); err == nil {
+		if progress, ok := ks.AccountsDiscovery[coinCode]; ok {
+			result.LastCheckedAccountNumber = progress.LastCheckedAccountNumber
+			canceled = progress.Canceled
+		}
+	}
+
+	maxAccountNumber := -1
+	defer backend.accountsDiscoveryLock.RLock()()
+	for _, acct := range accountsConfig.Accounts {
+		if acct.CoinCode != coinCode || !acct.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+			continue
+		}
+		accountNumber, err := acct.SigningConfigurations[0].AccountNumber()
+		if err != nil {
+			continue
+		}
+		if int(accountNumber) > maxAccountNumber {
+			maxAccountNumber = int(accountNumber)
+		}
+		if acct.Used {
+			result.UsedAccounts++
+		}
+		if errorCode, ok := backend.accountsDiscoveryFailures[acct.Code]; ok {
+			result.FailedAccountCode = acct.Code
+			result.ErrorCode = string(errorCode)
+		}
+	}
+	switch {
+	case canceled:
+		result.Status = AccountsDiscoveryStatusCanceled
+	case result.ErrorCode != "":
+		result.Status = AccountsDiscoveryStatusFailed
+	case result.LastCheckedAccountNumber >= maxAccountNumber:
+		result.Status = AccountsDiscoveryStatusDone
+	default:
+		result.Status = AccountsDiscoveryStatusRunning
+	}
+	return result
+}
+
+// AccountsDiscovery returns the state of the accounts discovery of the connected keystore for all
+// coins for which accounts are discovered automatically.
+func (backend *Backend) AccountsDiscovery() ([]*AccountsDiscovery, error) {
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return nil, errp.New("Keystore not found")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	result := []*AccountsDiscovery{}
+	for _, coinCode := range backend.accountsDiscoveryCoinCodes() {
+		result = append(result, backend.accountsDiscovery(rootFingerprint, coinCode))
+	}
+	return result, nil
+}
+
+// emitAccountsDiscovery emits the discovery state of the coin if the accounts of the coin belong to
+// the connected keystore.
+func (backend *Backend) emitAccountsDiscovery(rootFingerprint []byte, coinCode coinpkg.Code) {
+	if backend.checkAccountsDiscoveryCoin(coinCode) != nil {
+		return
+	}
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return
+	}
+	keystoreRootFingerprint, err := keystore.RootFingerprint()
+	if err != nil || string(keystoreRootFingerprint) != string(rootFingerprint) {
+		return
+	}
+	backend.Notify(observable.Event{
+		Subject: "accounts-discovery",
+		Action:  action.Replace,
+		Object:  backend.accountsDiscovery(rootFingerprint, coinCode),
+	})
+}
+
+// modifyAccountsDiscoveryProgress persists a change to the discovery progress of a coin of the
+// keystore with the given root fingerprint. Nothing is persisted if the keystore is unknown.
+func (backend *Backend) modifyAccountsDiscoveryProgress(
+	rootFingerprint []byte,
+	coinCode coinpkg.Code,
+	f func(progress *config.AccountsDiscoveryProgress),
+) error {
+	return backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		ks, err := accountsConfig.LookupKeystore(rootFingerprint)
+		if err != nil {
+			return nil
+		}
+		if ks.AccountsDiscovery == nil {
+			ks.AccountsDiscovery = map[coinpkg.Code]*config.AccountsDiscoveryProgress{}
+		}
+		progress, ok := ks.AccountsDiscovery[coinCode]
+		if !ok {
+			progress = &config.AccountsDiscoveryProgress{LastCheckedAccountNumber: -1}
+			ks.AccountsDiscovery[coinCode] = progress
+		}
+		f(progress)
+		return nil
+	})
+}
+
+// setAccountDiscoveryFailure records that the account could not be checked during accounts
+// discovery.
+func (backend *Backend) setAccountDiscoveryFailure(account accounts.Interface, errorCode errp.ErrorCode) {
+	accountConfig := account.Config().Config
+	func() {
+		defer backend.accountsDiscoveryLock.Lock()()
+		backend.accountsDiscoveryFailures[accountConfig.Code] = errorCode
+	}()
+	rootFingerprint, err := accountConfig.SigningConfigurations.RootFingerprint()
+	if err != nil {
+		return
+	}
+	backend.emitAccountsDiscovery(rootFingerprint, accountConfig.CoinCode)
+}
+
+// setAccountDiscoveryChecked records that the transaction history of the account was fully
+// checked, advancing the discovery progress of its coin.
+func (backend *Backend) setAccountDiscoveryChecked(account accounts.Interface) {
+	accountConfig := account.Config().Config
+	log := backend.log.WithField("accountCode", accountConfig.Code)
+	func() {
+		defer backend.accountsDiscoveryLock.Lock()()
+		delete(backend.accountsDiscoveryFailures, accountConfig.Code)
+	}()
+	if len(accountConfig.SigningConfigurations) == 0 {
+		return
+	}
+	accountNumber, err := accountConfig.SigningConfigurations[0].AccountNumber()
+	if err != nil {
+		return
+	}
+	rootFingerprint, err := accountConfig.SigningConfigurations.RootFingerprint()
+	if err != nil {
+		log.WithError(err).Error("setAccountDiscoveryChecked")
+		return
+	}
+	err = backend.modifyAccountsDiscoveryProgress(
+		rootFingerprint, accountConfig.CoinCode,
+		func(progress *config.AccountsDiscoveryProgress) {
+			if int(accountNumber) > progress.LastCheckedAccountNumber {
+				progress.LastCheckedAccountNumber = int(accountNumber)
+			}
+		})
+	if err != nil {
+		log.WithError(err).Error("setAccountDiscoveryChecked")
+	}
+}
+
+// CancelAccountsDiscovery stops adding accounts of the given coin of the connected keystore for
+// scanning. The discovery of other coins continues. Accounts which were already added are kept.
+func (backend *Backend) CancelAccountsDiscovery(coinCode coinpkg.Code) error {
+	if err := backend.checkAccountsDiscoveryCoin(coinCode); err != nil {
+		return err
+	}
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return errp.New("Keystore not found")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return err
+	}
+	err = backend.modifyAccountsDiscoveryProgress(
+		rootFingerprint, coinCode,
+		func(progress *config.AccountsDiscoveryProgress) {
+			progress.Canceled = true
+		})
+	if err != nil {
+		return err
+	}
+	backend.log.WithField("coinCode", coinCode).Info("accounts discovery canceled")
+	backend.emitAccountsDiscovery(rootFingerprint, coinCode)
+	return nil
+}
+
+// RetryAccountsDiscovery resumes the accounts discovery of the given coin of the connected
+// keystore after it failed or was canceled. Accounts which failed to be checked are reloaded. Other
+// accounts up to the last checked account are not checked again.
+func (backend *Backend) RetryAccountsDiscovery(coinCode coinpkg.Code) error {
+	if err := backend.checkAccountsDiscoveryCoin(coinCode); err != nil {
+		return err
+	}
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return errp.New("Keystore not found")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return err
+	}
+	err = backend.modifyAccountsDiscoveryProgress(
+		rootFingerprint, coinCode,
+		func(progress *config.AccountsDiscoveryProgress) {
+			progress.Canceled = false
+		})
+	if err != nil {
+		return err
+	}
+	lastChecked := backend.accountsDiscovery(rootFingerprint, coinCode).LastCheckedAccountNumber
+	backend.log.
+		WithField("coinCode", coinCode).
+		WithField("lastCheckedAccountNumber", lastChecked).
+		Info("retrying accounts discovery")
+
+	var unchecked []accounts.Interface
+	func() {
+		defer backend.accountsAndKeystoreLock.Lock()()
+		defer backend.accountsDiscoveryLock.Lock()()
+		var keep AccountsList
+		var reload []accountsTypes.Code
+		for _, account := range backend.accounts {
+			accountConfig := account.Config().Config
+			accountNumber, err := accountConfig.SigningConfigurations[0].AccountNumber()
+			if err != nil ||
+				accountConfig.CoinCode != coinCode ||
+				!accountConfig.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+				keep = append(keep, account)
+				continue
+			}
+			if _, failed := backend.accountsDiscoveryFailures[accountConfig.Code]; !failed {
+				keep = append(keep, account)
+				if int(accountNumber) > lastChecked {
+					unchecked = append(unchecked, account)
+				}
+				continue
+			}
+			// A failed account can't be initialized again, so it is closed and loaded anew, which
+			// checks it again.
+			delete(backend.accountsDiscoveryFailures, accountConfig.Code)
+			backend.closeAccount(account)
+			reload = append(reload, accountConfig.Code)
+		}
+		backend.accounts = keep
+		if len(reload) == 0 {
+			return
+		}
+		coin, err := backend.Coin(coinCode)
+		if err != nil {
+			backend.log.WithError(err).Error("RetryAccountsDiscovery")
+			return
+		}
+		accountsConfig := backend.config.AccountsConfig()
+		for _, code := range reload {
+			if accountConfig := accountsConfig.Lookup(code); accountConfig != nil {
+				backend.createAndAddAccount(coin, accountConfig)
+			}
+		}
+	}()
+	for _, account := range unchecked {
+		go backend.checkAccountUsed(account)
+	}
+	// Adds the next account to be scanned if the discovery stopped before adding it.
+	backend.maybeAddHiddenUnusedAccounts()
+	backend.emitAccountsDiscovery(rootFingerprint, coinCode)
+	backend.emitAccountsStatusChanged()
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsDiscovery(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	// Only accounts listed here are checked, so that the accounts checked in the background when
+	// they are added do not interfere.
+	var checkable sync.Map
+	b.tstCheckAccountUsed = func(account accounts.Interface) bool {
+		_, ok := checkable.Load(account.Config().Config.Code)
+		return ok
+	}
+	check := func(code accountsTypes.Code) {
+		checkable.Store(code, struct{}{})
+		defer checkable.Delete(code)
+		b.checkAccountUsed(b.Accounts().lookup(code))
+	}
+
+	hiddenAccountsAdded := make(chan struct{})
+	var once sync.Once
+	b.tstMaybeAddHiddenUnusedAccounts = func() {
+		once.Do(func() { close(hiddenAccountsAdded) })
+	}
+
+	var eventsLock sync.Mutex
+	var events []AccountsDiscovery
+	b.Observe(func(event observable.Event) {
+		if event.Subject == "accounts-discovery" {
+			eventsLock.Lock()
+			defer eventsLock.Unlock()
+			events = append(events, *event.Object.(*AccountsDiscovery))
+		}
+	})
+	lastEvent := func() AccountsDiscovery {
+		eventsLock.Lock()
+		defer eventsLock.Unlock()
+		require.NotEmpty(t, events)
+		return events[len(events)-1]
+	}
+
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(ks)
+	select {
+	case <-hiddenAccountsAdded:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "expected hidden accounts to be added")
+	}
+
+	discovery, err := b.AccountsDiscovery()
+	require.NoError(t, err)
+	require.Len(t, discovery, 2)
+	require.Equal(t, coinpkg.CodeBTC, discovery[0].CoinCode)
+	require.Equal(t, AccountsDiscoveryStatusRunning, discovery[0].Status)
+	require.Equal(t, -1, discovery[0].LastCheckedAccountNumber)
+	require.Equal(t, coinpkg.CodeLTC, discovery[1].CoinCode)
+
+	// A failure is reported per coin.
+	btcAccount := b.Accounts().lookup("v0-55555555-btc-0").(*accountsMocks.InterfaceMock)
+	btcAccount.TransactionsFunc = func() (accounts.OrderedTransactions, error) {
+		return nil, errors.New("server unreachable")
+	}
+	check("v0-55555555-btc-0")
+	require.Equal(t, AccountsDiscovery{
+		CoinCode:                 coinpkg.CodeBTC,
+		Status:                   AccountsDiscoveryStatusFailed,
+		LastCheckedAccountNumber: -1,
+		FailedAccountCode:        "v0-55555555-btc-0",
+		ErrorCode:                string(ErrAccountsDiscoveryTransactions),
+	}, lastEvent())
+	discovery, err = b.AccountsDiscovery()
+	require.NoError(t, err)
+	require.Equal(t, AccountsDiscoveryStatusFailed, discovery[0].Status)
+	require.Equal(t, AccountsDiscoveryStatusRunning, discovery[1].Status)
+
+	// Canceling Litecoin does not add more Litecoin accounts, but Bitcoin continues.
+	require.NoError(t, b.CancelAccountsDiscovery(coinpkg.CodeLTC))
+	require.Equal(t, coinpkg.CodeLTC, lastEvent().CoinCode)
+	require.Equal(t, AccountsDiscoveryStatusCanceled, lastEvent().Status)
+	check("v0-55555555-ltc-1")
+	require.Nil(t, b.config.AccountsConfig().Lookup("v0-55555555-ltc-2"))
+
+	// Checking the next account advances the progress, but the discovery remains failed.
+	check("v0-55555555-btc-1")
+	require.Equal(t, coinpkg.CodeBTC, lastEvent().CoinCode)
+	require.Equal(t, AccountsDiscoveryStatusFailed, lastEvent().Status)
+	require.Equal(t, 1, lastEvent().LastCheckedAccountNumber)
+	require.NotNil(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-2"))
+	require.Nil(t, b.config.AccountsConfig().Lookup("v0-55555555-ltc-2"))
+	check("v0-55555555-btc-2")
+	require.NotNil(t, b.config.AccountsConfig().Lookup("v0-55555555-btc-3"))
+
+	// The progress is persisted.
+	keystoreConfig, err := b.config.AccountsConfig().LookupKeystore(rootFingerprint1)
+	require.NoError(t, err)
+	require.Equal(t, 2, keystoreConfig.AccountsDiscovery[coinpkg.CodeBTC].LastCheckedAccountNumber)
+	require.True(t, keystoreConfig.AccountsDiscovery[coinpkg.CodeLTC].Canceled)
+
+	// Retrying reloads the failed account.
+	require.NoError(t, b.RetryAccountsDiscovery(coinpkg.CodeBTC))
+	reloaded := b.Accounts().lookup("v0-55555555-btc-0")
+	require.NotNil(t, reloaded)
+	require.NotSame(t, btcAccount, reloaded)
+	discovery, err = b.AccountsDiscovery()
+	require.NoError(t, err)
+	require.Equal(t, AccountsDiscoveryStatusRunning, discovery[0].Status)
+	require.Equal(t, 2, discovery[0].LastCheckedAccountNumber)
+	require.Empty(t, discovery[0].ErrorCode)
+	require.Equal(t, AccountsDiscoveryStatusCanceled, discovery[1].Status)
+
+	// Retrying Litecoin resumes adding accounts after the last checked one.
+	require.NoError(t, b.RetryAccountsDiscovery(coinpkg.CodeLTC))
+	require.NotNil(t, b.config.AccountsConfig().Lookup("v0-55555555-ltc-2"))
+	discovery, err = b.AccountsDiscovery()
+	require.NoError(t, err)
+	require.Equal(t, AccountsDiscoveryStatusRunning, discovery[1].Status)
+	require.Equal(t, 1, discovery[1].LastCheckedAccountNumber)
+
+	// Accounts of other coins are not discovered.
+	err = b.CancelAccountsDiscovery(coinpkg.CodeETH)
+	require.Equal(t, ErrAccountsDiscoveryUnsupported, errp.Cause(err))
+	err = b.RetryAccountsDiscovery(coinpkg.CodeETH)
+	require.Equal(t, ErrAccountsDiscoveryUnsupported, errp.Cause(err))
+}
+
+// Accounts known to be used are not initialized during accounts discovery, so that they don't all
+// sync at startup.
+func TestAccountsDiscoveryUsedAccountNotInitialized(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	var checkable sync.Map
+	b.tstCheckAccountUsed = func(account accounts.Interface) bool {
+		_, ok := checkable.Load(account.Config().Config.Code)
+		return ok
+	}
+
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+	b.registerKeystore(ks)
+
+	account := b.Accounts().lookup("v0-55555555-btc-0").(*accountsMocks.InterfaceMock)
+	account.Config().Config.Used = true
+	account.InitializeFunc = func() error {
+		require.Fail(t, "used account must not be initialized")
+		return nil
+	}
+	checkable.Store(account.Config().Config.Code, struct{}{})
+	b.checkAccountUsed(account)
+
+	discovery, err := b.AccountsDiscovery()
+	require.NoError(t, err)
+	require.Equal(t, coinpkg.CodeBTC, discovery[0].CoinCode)
+	require.Equal(t, 0, discovery[0].LastCheckedAccountNumber)
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// setDefaultFiats sets the active fiat currencies of the given config to the single currency
+// matching the system locale.
+func (backend *Backend) setDefaultFiats(appConfig *config.AppConfig) {
+	fiat := rates.FiatFromLocale(backend.environment.NativeLocale()).String()
+	appConfig.Backend.FiatList = []string{fiat}
+	appConfig.Backend.MainFiat = fiat
+}
+
+// initDefaultFiats sets the default active fiat currencies on the first start of the app.
+func (backend *Backend) initDefaultFiats() {
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		backend.setDefaultFiats(appConfig)
+		return nil
+	})
+	if err != nil {
+		backend.log.WithError(err).Error("could not set the default fiat currencies")
+	}
+}
+
+// activeFiats returns the fiat currencies the rates are fetched for and the amounts are converted
+// to: the active fiat currencies of the config, and the main fiat currency in case it is not part
+// of them.
+func (backend *Backend) activeFiats() []string {
+	backendConfig := backend.config.AppConfig().Backend
+	fiats := append([]string(nil), backendConfig.FiatList...)
+	for _, fiat := range fiats {
+		if fiat == backendConfig.MainFiat {
+			return fiats
+		}
+	}
+	if backendConfig.MainFiat != "" {
+		fiats = append(fiats, backendConfig.MainFiat)
+	}
+	return fiats
+}
+
+// SetActiveFiats sets the fiat currencies the user selected. Only the rates of these currencies
+// are fetched. If the main fiat currency is not among them, the first one becomes the main fiat
+// currency.
+func (backend *Backend) SetActiveFiats(fiats []string) error {
+	if len(fiats) == 0 {
+		return errp.New("At least one fiat currency must be active")
+	}
+	for _, fiat := range fiats {
+		if !rates.IsSupportedFiat(fiat) {
+			return errp.Newf("Unsupported fiat currency %s", fiat)
+		}
+	}
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.FiatList = fiats
+		for _, fiat := range fiats {
+			if fiat == appConfig.Backend.MainFiat {
+				return nil
+			}
+		}
+		appConfig.Backend.MainFiat = fiats[0]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.UpdateActiveFiats()
+	defer backend.accountsAndKeystoreLock.RLock()()
+	backend.configureHistoryExchangeRates()
+	return nil
+}
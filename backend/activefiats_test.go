@@ -0,0 +1,49 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetActiveFiats(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	// Default inferred from the (empty) system locale.
+	require.Equal(t, []string{"USD"}, b.Config().AppConfig().Backend.FiatList)
+	require.Equal(t, "USD", b.Config().AppConfig().Backend.MainFiat)
+
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	require.Equal(t, []string{"USD"}, btcCoin.ActiveFiats())
+
+	require.Error(t, b.SetActiveFiats(nil))
+	require.Error(t, b.SetActiveFiats([]string{"EUR", "XYZ"}))
+
+	require.NoError(t, b.SetActiveFiats([]string{"USD", "EUR"}))
+	require.Equal(t, []string{"USD", "EUR"}, b.Config().AppConfig().Backend.FiatList)
+	require.Equal(t, "USD", b.Config().AppConfig().Backend.MainFiat)
+
+	// The main fiat currency is replaced if it is not active anymore.
+	require.NoError(t, b.SetActiveFiats([]string{"CHF", "EUR"}))
+	require.Equal(t, "CHF", b.Config().AppConfig().Backend.MainFiat)
+	require.Equal(t, []string{"CHF", "EUR"}, b.activeFiats())
+	// Amounts are converted to all active fiat currencies, not only the main one.
+	require.Equal(t, []string{"CHF", "EUR"}, btcCoin.ActiveFiats())
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// AddressPreview is the first receive address of an account for one script type.
+type AddressPreview struct {
+	ScriptType signing.ScriptType `json:"scriptType"`
+	Keypath    string             `json:"keypath"`
+	Address    string             `json:"address"`
+}
+
+// PreviewAccountAddresses returns the first receive address of the first account of the given
+// coin, for each script type supported by the connected keystore. The account-level xpubs are
+// fetched from the keystore once. Nothing is persisted, and no account, database or subscription
+// is created, so the user can see what the addresses look like before adding the account, e.g. to
+// decide whether to use taproot.
+func (backend *Backend) PreviewAccountAddresses(coinCode coinpkg.Code) ([]AddressPreview, error) {
+	keystore := backend.Keystore()
+	if keystore == nil {
+		return nil, errp.New("No keystore connected")
+	}
+	configs := btcScriptTypesWithKeypaths(coinCode, 0)
+	if configs == nil {
+		return nil, errp.Newf("Address preview not supported for coin %s", coinCode)
+	}
+	coin, err := backend.Coin(coinCode)
+	if err != nil {
+		return nil, err
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	log := backend.log.WithField("coinCode", coinCode)
+	// The first address of the receive chain, see addresses.NewAddressChain().
+	firstReceiveKeypath := signing.NewEmptyRelativeKeypath().
+		Child(0, signing.NonHardened).
+		Child(0, signing.NonHardened)
+
+	result := []AddressPreview{}
+	for _, cfg := range configs {
+		if !keystore.SupportsAccount(coin, cfg.scriptType) {
+			continue
+		}
+		extendedPublicKey, err := keystore.ExtendedPublicKey(coin, cfg.keypath)
+		if err != nil {
+			return nil, err
+		}
+		address := addresses.NewAccountAddress(
+			signing.NewBitcoinConfiguration(cfg.scriptType, rootFingerprint, cfg.keypath, extendedPublicKey),
+			firstReceiveKeypath,
+			coin.(*btc.Coin).Net(),
+			log,
+		)
+		result = append(result, AddressPreview{
+			ScriptType: cfg.scriptType,
+			Keypath:    address.AbsoluteKeypath().Encode(),
+			Address:    address.EncodeForHumans(),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/software"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewAccountAddresses(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	_, err := b.PreviewAccountAddresses(coinpkg.CodeBTC)
+	require.Error(t, err)
+
+	// From mnemonic: abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon
+	// abandon about
+	rootKey := test.TstMustXKey("xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu")
+	keystoreHelper := software.NewKeystore(rootKey)
+	xpubCalls := 0
+	ks := &keystoremock.KeystoreMock{
+		RootFingerprintFunc: func() ([]byte, error) {
+			return []byte{0x73, 0xc5, 0xda, 0x0a}, nil
+		},
+		SupportsAccountFunc: func(coin coinpkg.Coin, meta interface{}) bool {
+			switch coin.(type) {
+			case *btc.Coin:
+				scriptType := meta.(signing.ScriptType)
+				return scriptType != signing.ScriptTypeP2PKH
+			default:
+				return true
+			}
+		},
+		ExtendedPublicKeyFunc: func(coin coinpkg.Coin, keypath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+			xpubCalls++
+			return keystoreHelper.ExtendedPublicKey(coin, keypath)
+		},
+	}
+	b.keystore = ks
+
+	previews, err := b.PreviewAccountAddresses(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	// Test vectors of BIP84, BIP86 and BIP49.
+	require.Equal(t, []AddressPreview{
+		{
+			ScriptType: signing.ScriptTypeP2WPKH,
+			Keypath:    "m/84'/0'/0'/0/0",
+			Address:    "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		},
+		{
+			ScriptType: signing.ScriptTypeP2TR,
+			Keypath:    "m/86'/0'/0'/0/0",
+			Address:    "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+		{
+			ScriptType: signing.ScriptTypeP2WPKHP2SH,
+			Keypath:    "m/49'/0'/0'/0/0",
+			Address:    "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+		},
+	}, previews)
+	// One xpub per script type, and nothing was persisted.
+	require.Equal(t, 3, xpubCalls)
+	require.Empty(t, b.Config().AccountsConfig().Accounts)
+	require.Empty(t, b.Accounts())
+
+	_, err = b.PreviewAccountAddresses(coinpkg.CodeETH)
+	require.Error(t, err)
+}
@@ -54,6 +54,8 @@ const (
 	errAOPPSigningAborted errp.ErrorCode = "aoppSigningAborted"
 	// errAOPPCallback is returned when there was an error calling the callback in the AOPP request.
 	errAOPPCallback errp.ErrorCode = "aoppCallback"
+	// errAOPPViewOnly is returned when the app is in view-only mode, in which no message is signed.
+	errAOPPViewOnly errp.ErrorCode = "aoppViewOnly"
 )
 
 // aoppCoinMap maps from the asset codes specified by AOPP to our own coin codes.
@@ -291,6 +293,10 @@ func (backend *Backend) AOPPApprove() {
 	if backend.aopp.State != aoppStateUserApproval {
 		return
 	}
+	if backend.checkViewOnly() != nil {
+		backend.aoppSetError(errAOPPViewOnly)
+		return
+	}
 	backend.aopp.State = aoppStateAwaitingKeystore
 	if backend.keystore == nil {
 		backend.notifyAOPP()
@@ -305,6 +311,11 @@ func (backend *Backend) aoppChooseAccount(code accountsTypes.Code) {
 	if backend.aopp.State != aoppStateChoosingAccount {
 		return
 	}
+	// The view-only mode could have been enabled after the request was approved.
+	if backend.checkViewOnly() != nil {
+		backend.aoppSetError(errAOPPViewOnly)
+		return
+	}
 
 	backend.aopp.AccountCode = code
 	backend.aopp.State = aoppStateSyncing
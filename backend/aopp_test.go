@@ -460,4 +460,31 @@ func TestAOPPFailures(t *testing.T) {
 		require.Equal(t, aoppStateError, b.AOPP().State)
 		require.Equal(t, errAOPPCallback, b.AOPP().ErrorCode)
 	})
+	t.Run("view_only", func(t *testing.T) {
+		b := newBackend(t, testnetDisabled, regtestDisabled)
+		defer b.Close()
+		require.NoError(t, b.SetViewOnly(true))
+		params := defaultParams()
+		b.HandleURI(uriPrefix + params.Encode())
+		b.AOPPApprove()
+		require.Equal(t, aoppStateError, b.AOPP().State)
+		require.Equal(t, errAOPPViewOnly, b.AOPP().ErrorCode)
+	})
+	t.Run("view_only_after_approval", func(t *testing.T) {
+		b := newBackend(t, testnetDisabled, regtestDisabled)
+		defer b.Close()
+		params := defaultParams()
+		b.HandleURI(uriPrefix + params.Encode())
+		b.AOPPApprove()
+		require.NoError(t, b.SetViewOnly(true))
+		ks2 := makeKeystore(t, scriptTypeRef(signing.ScriptTypeP2WPKH), keystoreHelper.ExtendedPublicKey)
+		ks2.SignBTCMessageFunc = func([]byte, signing.AbsoluteKeypath, signing.ScriptType) ([]byte, error) {
+			require.Fail(t, "no message must be signed in view-only mode")
+			return nil, nil
+		}
+		b.registerKeystore(ks2)
+		b.AOPPChooseAccount("v0-55555555-btc-0")
+		require.Equal(t, aoppStateError, b.AOPP().State)
+		require.Equal(t, errAOPPViewOnly, b.AOPP().ErrorCode)
+	})
 }
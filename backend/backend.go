@@ -31,7 +31,10 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/banners"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/esplora"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
@@ -62,8 +65,8 @@ func init() {
 	electrum.SetClientSoftwareVersion(Version)
 }
 
-// fixedURLWhitelist is always allowed by SystemOpen, in addition to some
-// adhoc URLs. See SystemOpen for details.
+// fixedURLWhitelist is always allowed by SystemOpen, in addition to the block
+// explorers and some adhoc URLs. See SystemOpen for details.
 var fixedURLWhitelist = []string{
 	// Shift Crypto owned domains.
 	"https://bitbox.swiss/",
@@ -71,14 +74,6 @@ var fixedURLWhitelist = []string{
 	"https://shiftcrypto.support/",
 	// Exchange rates.
 	"https://www.coingecko.com/",
-	// Block explorers.
-	"https://blockstream.info/tx/",
-	"https://blockstream.info/testnet/tx/",
-	"https://sochain.com/tx/LTCTEST/",
-	"https://blockchair.com/litecoin/transaction/",
-	"https://etherscan.io/tx/",
-	"https://goerli.etherscan.io/tx/",
-	"https://sepolia.etherscan.io/tx/",
 	// Moonpay onramp
 	"https://www.moonpay.com/",
 	"https://support.moonpay.com/",
@@ -123,6 +118,30 @@ type AccountEvent struct {
 	Type string             `json:"type"`
 	Code accountsTypes.Code `json:"code"`
 	Data string             `json:"data"`
+	Meta interface{}        `json:"meta,omitempty"`
+}
+
+// NewTransactionEventMeta is the meta data of the EventNewTransaction account event.
+type NewTransactionEventMeta struct {
+	TxID       string                  `json:"txID"`
+	InternalID string                  `json:"internalID"`
+	Direction  accounts.TxType         `json:"direction"`
+	Amount     coinpkg.FormattedAmount `json:"amount"`
+	// Address is the first address the tx sends to, i.e. our receive address for incoming
+	// transactions.
+	Address          string `json:"address"`
+	NumConfirmations int    `json:"numConfirmations"`
+	// Index is the position of the tx in the transaction history, newest first, and Total the
+	// length of the history, so the frontend can insert the tx without fetching the whole history.
+	// Index is -1 if the tx is not shown in the history.
+	Index int `json:"index"`
+	Total int `json:"total"`
+}
+
+// FirstTransactionEventMeta is the meta data of the EventFirstTransaction account event.
+type FirstTransactionEventMeta struct {
+	// FirstForKeystore is true if no other account of the same keystore had a transaction before.
+	FirstForKeystore bool `json:"firstForKeystore"`
 }
 
 type authEventType string
@@ -194,11 +213,25 @@ type Backend struct {
 
 	accountsAndKeystoreLock locker.Locker
 	accounts                AccountsList
+	// accountObservations tracks which accounts are observed by the frontend.
+	accountObservations *accountObservations
 	// keystore is nil if no keystore is connected.
 	keystore keystore.Keystore
 
 	connectKeystore connectKeystore
 
+	// accountRotationLock serializes the steps of account rotations, see StartAccountRotation().
+	accountRotationLock locker.Locker
+	// accountRotationProposals holds the args of the last AccountRotationProposal() call by
+	// account code, so that SendAccountRotation() can rebuild exactly that transaction. Guarded by
+	// accountRotationLock.
+	accountRotationProposals map[accountsTypes.Code]*accounts.TxProposalArgs
+
+	// accountsDiscoveryFailures holds the reason why checking an account during accounts discovery
+	// failed, by account code, until the account is checked successfully.
+	accountsDiscoveryFailures map[accountsTypes.Code]errp.ErrorCode
+	accountsDiscoveryLock     locker.Locker
+
 	aopp AOPP
 
 	// makeBtcAccount creates a BTC account. In production this is `btc.NewAccount`, but can be
@@ -234,12 +267,16 @@ type Backend struct {
 // NewBackend creates a new backend with the given arguments.
 func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backend, error) {
 	log := logging.Get().WithGroup("backend")
+	_, err := os.Stat(arguments.AppConfigFilename())
+	firstStart := os.IsNotExist(err)
 	config, err := config.NewConfig(arguments.AppConfigFilename(), arguments.AccountsConfigFilename())
 	if err != nil {
 		return nil, errp.WithStack(err)
 	}
 	log.Infof("backend config: %+v", config.AppConfig().Backend)
 	log.Infof("frontend config: %+v", config.AppConfig().Frontend)
+	// Applied before any coin connects to an Electrum server.
+	electrum.SetMaxMessageSize(config.AppConfig().Backend.ElectrumMaxMessageSize)
 	backendProxy := socksproxy.NewSocksProxy(
 		config.AppConfig().Backend.Proxy.UseProxy,
 		config.AppConfig().Backend.Proxy.ProxyAddress,
@@ -255,11 +292,16 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 		config:      config,
 		events:      make(chan interface{}, 1000),
 
+		accountObservations: newAccountObservations(),
+
 		devices:  map[string]device.Interface{},
 		coins:    map[coinpkg.Code]coinpkg.Coin{},
 		accounts: []accounts.Interface{},
 		aopp:     AOPP{State: aoppStateInactive},
 
+		accountsDiscoveryFailures: map[accountsTypes.Code]errp.ErrorCode{},
+		accountRotationProposals:  map[accountsTypes.Code]*accounts.TxProposalArgs{},
+
 		makeBtcAccount: func(config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, log *logrus.Entry) accounts.Interface {
 			return btc.NewAccount(config, coin, gapLimits, log, hclient)
 		},
@@ -282,7 +324,11 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 	if err := os.MkdirAll(ratesCache, 0700); err != nil {
 		log.Errorf("RateUpdater DB cache dir: %v", err)
 	}
+	if firstStart {
+		backend.initDefaultFiats()
+	}
 	backend.ratesUpdater = rates.NewRateUpdater(hclient, ratesCache)
+	backend.ratesUpdater.SetActiveFiats(backend.activeFiats())
 	backend.ratesUpdater.Observe(backend.Notify)
 
 	backend.banners = banners.NewBanners()
@@ -300,8 +346,7 @@ func (backend *Backend) configureHistoryExchangeRates() {
 	for _, acct := range backend.accounts {
 		coins = append(coins, string(acct.Coin().Code()))
 	}
-	fiats := backend.config.AppConfig().Backend.FiatList
-	backend.ratesUpdater.ReconfigureHistory(coins, fiats)
+	backend.ratesUpdater.ReconfigureHistory(coins, backend.activeFiats())
 }
 
 func (backend *Backend) notifyNewTxs(account accounts.Interface) {
@@ -327,6 +372,80 @@ func (backend *Backend) notifyNewTxs(account accounts.Interface) {
 	}
 }
 
+// emitNewTransactionEvents emits EventNewTransaction for the new and newly confirmed transactions
+// of the account. The events are not subject to account observation, as they are meant to be shown
+// regardless of the currently viewed account.
+func (backend *Backend) emitNewTransactionEvents(account accounts.Interface) {
+	if !account.Synced() {
+		return
+	}
+	txs, err := account.Transactions()
+	if err != nil {
+		backend.log.WithError(err).Error("error getting the transactions")
+		return
+	}
+	code := account.Config().Config.Code
+	newTxs, err := backend.notifier.newTransactionEvents(code, txs)
+	if err != nil {
+		backend.log.WithError(err).Error("error recording the new transaction events")
+		return
+	}
+	rootFingerprint, err := account.Config().Config.SigningConfigurations.RootFingerprint()
+	if err != nil {
+		backend.log.WithError(err).Error("could not get the root fingerprint of the account")
+	}
+	first, err := backend.notifier.recordHasTransactions(code, rootFingerprint, txs, newTxs)
+	if err != nil {
+		backend.log.WithError(err).Error("error recording the first transaction")
+		return
+	}
+	if first.account {
+		backend.events <- AccountEvent{
+			Type: "account",
+			Code: code,
+			Data: string(accountsTypes.EventFirstTransaction),
+			Meta: FirstTransactionEventMeta{FirstForKeystore: first.keystore},
+		}
+	}
+	listed := txs.Listed()
+	indices := make(map[string]int, len(listed))
+	for index, tx := range listed {
+		indices[tx.InternalID] = index
+	}
+	for _, tx := range newTxs {
+		index, ok := indices[tx.InternalID]
+		if !ok {
+			index = -1
+		}
+		var address string
+		if len(tx.Addresses) > 0 {
+			address = tx.Addresses[0].Address
+		}
+		backend.events <- AccountEvent{
+			Type: "account",
+			Code: code,
+			Data: string(accountsTypes.EventNewTransaction),
+			Meta: NewTransactionEventMeta{
+				TxID:       tx.TxID,
+				InternalID: tx.InternalID,
+				Direction:  tx.Type,
+				Amount: coinpkg.FormatAmountAsJSON(
+					tx.Amount,
+					account.Coin(),
+					false,
+					backend.ratesUpdater,
+					util.FormatBtcAsSat(backend.config.AppConfig().Backend.BtcUnit),
+					false,
+				),
+				Address:          address,
+				NumConfirmations: tx.NumConfirmations,
+				Index:            index,
+				Total:            len(listed),
+			},
+		}
+	}
+}
+
 // Config returns the app config.
 func (backend *Backend) Config() *config.Config {
 	return backend.config
@@ -407,7 +526,9 @@ func (backend *Backend) AuthResult(ok bool) {
 
 // DefaultAppConfig returns the default app config.
 func (backend *Backend) DefaultAppConfig() config.AppConfig {
-	return config.NewDefaultAppConfig()
+	appConfig := config.NewDefaultAppConfig()
+	backend.setDefaultFiats(&appConfig)
+	return appConfig
 }
 
 func (backend *Backend) defaultProdServers(code coinpkg.Code) []*config.ServerInfo {
@@ -416,6 +537,10 @@ func (backend *Backend) defaultProdServers(code coinpkg.Code) []*config.ServerIn
 		return backend.config.AppConfig().Backend.BTC.ElectrumServers
 	case coinpkg.CodeTBTC:
 		return backend.config.AppConfig().Backend.TBTC.ElectrumServers
+	case coinpkg.CodeTBTC4:
+		return backend.config.AppConfig().Backend.TBTC4.ElectrumServers
+	case coinpkg.CodeSBTC:
+		return backend.config.AppConfig().Backend.SBTC.ElectrumServers
 	case coinpkg.CodeRBTC:
 		return backend.config.AppConfig().Backend.RBTC.ElectrumServers
 	case coinpkg.CodeLTC:
@@ -447,6 +572,11 @@ mCMuGBNHsbrs6rI1hbI4Qq6GYazLaDRqdCufTA==
 		return []*config.ServerInfo{{Server: "btc1.shiftcrypto.dev:50001", TLS: true, PEMCert: devShiftCA}}
 	case coinpkg.CodeTBTC:
 		return []*config.ServerInfo{{Server: "tbtc1.shiftcrypto.dev:51001", TLS: true, PEMCert: devShiftCA}}
+	case coinpkg.CodeTBTC4:
+		// There are no dev servers for testnet4 and signet.
+		return config.NewDefaultAppConfig().Backend.TBTC4.ElectrumServers
+	case coinpkg.CodeSBTC:
+		return config.NewDefaultAppConfig().Backend.SBTC.ElectrumServers
 	case coinpkg.CodeRBTC:
 		return []*config.ServerInfo{
 			{Server: "127.0.0.1:52001", TLS: false, PEMCert: ""},
@@ -485,59 +615,138 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 
 	erc20Token := erc20TokenByCode(code)
 	btcFormatUnit := backend.config.AppConfig().Backend.BtcUnit
+	txFetchThrottle := backend.config.AppConfig().Backend.TxFetchThrottle
 	switch {
 	case code == coinpkg.CodeRBTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeRBTC, "Bitcoin Regtest", "RBTC", coinpkg.BtcUnitDefault, &chaincfg.RegressionNetParams, dbFolder, servers, "", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeRBTC, "Bitcoin Regtest", "RBTC", coinpkg.BtcUnitDefault, &chaincfg.RegressionNetParams, dbFolder, servers, txFetchThrottle, backend.BlockExplorer(code), backend.socksProxy)
 	case code == coinpkg.CodeTBTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeTBTC, "Bitcoin Testnet", "TBTC", btcFormatUnit, &chaincfg.TestNet3Params, dbFolder, servers,
-			"https://blockstream.info/testnet/tx/", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeTBTC, "Bitcoin Testnet", "TBTC", btcFormatUnit, &chaincfg.TestNet3Params, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
+	case code == coinpkg.CodeTBTC4:
+		servers := backend.defaultElectrumXServers(code)
+		coin = btc.NewCoin(coinpkg.CodeTBTC4, "Bitcoin Testnet4", "TBTC", btcFormatUnit, &netparams.TestNet4Params, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
+	case code == coinpkg.CodeSBTC:
+		servers := backend.defaultElectrumXServers(code)
+		coin = btc.NewCoin(coinpkg.CodeSBTC, "Bitcoin Signet", "TBTC", btcFormatUnit, &netparams.SigNetParams, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
 	case code == coinpkg.CodeBTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeBTC, "Bitcoin", "BTC", btcFormatUnit, &chaincfg.MainNetParams, dbFolder, servers,
-			"https://blockstream.info/tx/", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeBTC, "Bitcoin", "BTC", btcFormatUnit, &chaincfg.MainNetParams, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
 	case code == coinpkg.CodeTLTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeTLTC, "Litecoin Testnet", "TLTC", coinpkg.BtcUnitDefault, &ltc.TestNet4Params, dbFolder, servers,
-			"https://sochain.com/tx/LTCTEST/", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeTLTC, "Litecoin Testnet", "TLTC", coinpkg.BtcUnitDefault, &ltc.TestNet4Params, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
 	case code == coinpkg.CodeLTC:
 		servers := backend.defaultElectrumXServers(code)
-		coin = btc.NewCoin(coinpkg.CodeLTC, "Litecoin", "LTC", coinpkg.BtcUnitDefault, &ltc.MainNetParams, dbFolder, servers,
-			"https://blockchair.com/litecoin/transaction/", backend.socksProxy)
+		coin = btc.NewCoin(coinpkg.CodeLTC, "Litecoin", "LTC", coinpkg.BtcUnitDefault, &ltc.MainNetParams, dbFolder, servers, txFetchThrottle,
+			backend.BlockExplorer(code), backend.socksProxy)
 	case code == coinpkg.CodeETH:
 		etherScan := etherscan.NewEtherScan("https://api.etherscan.io/api", backend.etherScanHTTPClient)
 		coin = eth.NewCoin(etherScan, code, "Ethereum", "ETH", "ETH", params.MainnetChainConfig,
-			"https://etherscan.io/tx/",
+			backend.BlockExplorer(code),
 			etherScan,
 			nil)
 	case code == coinpkg.CodeGOETH:
 		etherScan := etherscan.NewEtherScan("https://api-goerli.etherscan.io/api", backend.etherScanHTTPClient)
 		coin = eth.NewCoin(etherScan, code, "Ethereum Goerli", "GOETH", "GOETH", params.GoerliChainConfig,
-			"https://goerli.etherscan.io/tx/",
+			backend.BlockExplorer(code),
 			etherScan,
 			nil)
 	case code == coinpkg.CodeSEPETH:
 		etherScan := etherscan.NewEtherScan("https://api-sepolia.etherscan.io/api", backend.etherScanHTTPClient)
 		coin = eth.NewCoin(etherScan, code, "Ethereum Sepolia", "SEPETH", "SEPETH", params.SepoliaChainConfig,
-			"https://sepolia.etherscan.io/tx/",
+			backend.BlockExplorer(code),
 			etherScan,
 			nil)
 	case erc20Token != nil:
 		etherScan := etherscan.NewEtherScan("https://api.etherscan.io/api", backend.etherScanHTTPClient)
 		coin = eth.NewCoin(etherScan, erc20Token.code, erc20Token.name, erc20Token.unit, "ETH", params.MainnetChainConfig,
-			"https://etherscan.io/tx/",
+			backend.BlockExplorer(code),
 			etherScan,
 			erc20Token.token,
 		)
 	default:
 		return nil, errp.Newf("unknown coin code %s", code)
 	}
+	if btcCoin, ok := coin.(*btc.Coin); ok {
+		btcCoin.SetRateUpdater(backend.ratesUpdater)
+		btcCoin.SetHeadersPruneDepth(backend.config.AppConfig().Backend.HeadersPruneDepth)
+		if code == coinpkg.CodeBTC || code == coinpkg.CodeTBTC {
+			btcCoin.SetHeadersBirthday(backend.headersBirthday())
+		}
+		btcCoin.SetRequestTimeout(
+			time.Duration(backend.config.AppConfig().Backend.BlockchainRequestTimeoutSeconds) * time.Second)
+	}
+	coin.SetActiveFiats(backend.activeFiats())
 	backend.coins[code] = coin
 	coin.Observe(backend.Notify)
 	return coin, nil
 }
 
+// UpdateLocale sets the locale fiat amounts are formatted in, see coinpkg.FormatAsCurrency. The
+// locale of the native app layer is used, as it also determines the number format of the
+// frontend. If it reports none, the UI language configured by the user is used.
+func (backend *Backend) UpdateLocale() {
+	locale := backend.environment.NativeLocale()
+	if locale == "" {
+		locale = backend.config.AppConfig().Backend.UserLanguage
+	}
+	coinpkg.SetLocale(locale)
+}
+
+// UpdateActiveFiats applies the active fiat currencies of the config: the rates are fetched for
+// them and the amounts of all coins are converted to them. See coinpkg.Coin.ActiveFiats().
+func (backend *Backend) UpdateActiveFiats() {
+	fiats := backend.activeFiats()
+	backend.ratesUpdater.SetActiveFiats(fiats)
+	defer backend.coinsLock.Lock()()
+	for _, coin := range backend.coins {
+		coin.SetActiveFiats(fiats)
+	}
+}
+
+// headersBirthday returns the height below which no wallet has transactions according to the wallet
+// birthdays, see headers.Headers.SetBirthday(). It is 0 if a wallet has no birthday height. The
+// birthday heights refer to the Bitcoin blockchain, so they only apply to the BTC and TBTC headers.
+func (backend *Backend) headersBirthday() int {
+	birthday := 0
+	for _, ks := range backend.config.AccountsConfig().Keystores {
+		if ks.Birthday == nil || ks.Birthday.Height <= 0 {
+			return 0
+		}
+		if birthday == 0 || ks.Birthday.Height < birthday {
+			birthday = ks.Birthday.Height
+		}
+	}
+	return birthday
+}
+
+// updateHeadersBirthday applies the wallet birthdays of the config to the headers of the coins.
+func (backend *Backend) updateHeadersBirthday() {
+	birthday := backend.headersBirthday()
+	defer backend.coinsLock.Lock()()
+	for _, code := range []coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeTBTC} {
+		if btcCoin, ok := backend.coins[code].(*btc.Coin); ok {
+			btcCoin.SetHeadersBirthday(birthday)
+		}
+	}
+}
+
+// RetryConnections makes the blockchain backends of all coins which wait to retry after all their
+// servers failed connect immediately, e.g. when the network connectivity was restored.
+func (backend *Backend) RetryConnections() {
+	defer backend.coinsLock.Lock()()
+	for _, coin := range backend.coins {
+		if btcCoin, ok := coin.(*btc.Coin); ok {
+			btcCoin.RetryConnection()
+		}
+	}
+}
+
 // Testing returns whether this backend is for testing only.
 func (backend *Backend) Testing() bool {
 	return backend.arguments.Testing()
@@ -595,6 +804,11 @@ func (backend *Backend) Start() <-chan interface{} {
 		go backend.banners.Init(httpClient)
 	}
 
+	backend.UpdateLocale()
+
+	// Deferred before locking below, so that it runs after the lock is released.
+	defer backend.registerEnvironmentKeystore()
+
 	defer backend.accountsAndKeystoreLock.Lock()()
 	backend.initPersistedAccounts()
 	backend.emitAccountsStatusChanged()
@@ -674,6 +888,7 @@ func (backend *Backend) registerKeystore(keystore keystore.Keystore) {
 	}
 
 	backend.initAccounts(false)
+	go backend.verifyAccountCaches(keystore)
 
 	backend.aoppKeystoreRegistered()
 
@@ -703,6 +918,7 @@ func (backend *Backend) DeregisterKeystore() {
 	// TODO: classify accounts by keystore, remove only the ones belonging to the deregistered
 	// keystore. For now we just remove all, then re-add the rest.
 	backend.initPersistedAccounts()
+	backend.closeUnusedCoins()
 	backend.emitAccountsStatusChanged()
 	backend.connectKeystore.onDisconnect()
 }
@@ -795,8 +1011,15 @@ func (backend *Backend) DownloadCert(server string) (string, error) {
 }
 
 // CheckElectrumServer checks if a connection can be established with the electrum server, and
-// whether the server is an electrum server.
+// whether the server is an electrum server. Esplora servers are checked via their HTTP API.
 func (backend *Backend) CheckElectrumServer(serverInfo *config.ServerInfo) error {
+	if serverInfo.ServerType() == config.ServerTypeEsplora {
+		httpClient, err := backend.socksProxy.GetHTTPClient()
+		if err != nil {
+			return err
+		}
+		return esplora.CheckServer(serverInfo, backend.log, httpClient)
+	}
 	return electrum.CheckElectrumServer(
 		serverInfo, backend.log, backend.socksProxy.GetTCPProxyDialer())
 }
@@ -808,13 +1031,57 @@ func (backend *Backend) RegisterTestKeystore(pin string) {
 	backend.registerKeystore(softwareBasedKeystore)
 }
 
+// RegisterTestMnemonicKeystore adds a software keystore derived from a BIP39 mnemonic, so that the
+// backend can be used without a device, e.g. in integration tests. It is refused on mainnet unless
+// forceMainnet is true.
+func (backend *Backend) RegisterTestMnemonicKeystore(mnemonic string, passphrase string, forceMainnet bool) error {
+	net := &chaincfg.TestNet3Params
+	if !backend.Testing() {
+		if !forceMainnet {
+			return errp.New("The mnemonic keystore is only available on testnet and regtest")
+		}
+		backend.log.Warning("Using a mnemonic keystore on mainnet")
+		net = &chaincfg.MainNetParams
+	}
+	softwareBasedKeystore, err := software.NewKeystoreFromMnemonic(mnemonic, passphrase, net)
+	if err != nil {
+		return err
+	}
+	backend.registerKeystore(softwareBasedKeystore)
+	return nil
+}
+
+// registerEnvironmentKeystore registers a mnemonic keystore if the user set the mnemonic as an
+// environment variable, see RegisterTestMnemonicKeystore(). Like the /test/register endpoint, it is
+// only available in testing mode, and never on mainnet.
+func (backend *Backend) registerEnvironmentKeystore() {
+	mnemonic := os.Getenv("BITBOX_TEST_MNEMONIC")
+	if mnemonic == "" {
+		return
+	}
+	if !backend.Testing() {
+		backend.log.Error("Ignoring the mnemonic keystore from the environment outside of testing mode")
+		return
+	}
+	err := backend.RegisterTestMnemonicKeystore(
+		mnemonic,
+		os.Getenv("BITBOX_TEST_MNEMONIC_PASSPHRASE"),
+		false,
+	)
+	if err != nil {
+		backend.log.WithError(err).Error("Could not register the mnemonic keystore from the environment")
+		return
+	}
+	backend.log.Info("Registered the mnemonic keystore from the environment")
+}
+
 // NotifyUser creates a desktop notification.
 func (backend *Backend) NotifyUser(text string) {
 	backend.environment.NotifyUser(text)
 }
 
 // SystemOpen opens the given URL using backend.environment.
-// It consults fixedURLWhitelist, matching the URL with each whitelist item.
+// It consults fixedURLWhitelist and the block explorers, matching the URL with each item.
 // If an item is a prefix of url, it is allowed to be openend.
 //
 // If none matched, an ad-hoc URL construction failed or opening a URL failed,
@@ -826,6 +1093,13 @@ func (backend *Backend) SystemOpen(url string) error {
 			return backend.environment.SystemOpen(url)
 		}
 	}
+	for _, explorers := range blockExplorers {
+		for _, explorer := range explorers {
+			if strings.HasPrefix(url, explorer.URL) {
+				return backend.environment.SystemOpen(url)
+			}
+		}
+	}
 
 	return errp.Newf("Blocked /open with url: %s", url)
 }
@@ -951,6 +1225,27 @@ func (backend *Backend) SetWatchonly(rootFingerprint []byte, watchonly bool) err
 	)
 }
 
+// SetWalletBirthday sets or clears (if birthday is nil) the wallet birthday of the keystore with
+// the given root fingerprint. See config.WalletBirthday.
+func (backend *Backend) SetWalletBirthday(rootFingerprint []byte, birthday *config.WalletBirthday) error {
+	if birthday != nil && birthday.Height <= 0 && birthday.Date.IsZero() {
+		return errp.New("either the date or the height of the wallet birthday must be set")
+	}
+	err := backend.config.ModifyAccountsConfig(func(config *config.AccountsConfig) error {
+		ks, err := config.LookupKeystore(rootFingerprint)
+		if err != nil {
+			return err
+		}
+		ks.Birthday = birthday
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.updateHeadersBirthday()
+	return nil
+}
+
 // ExportLogs function copy and save log.txt file to help users provide it to support while troubleshooting.
 func (backend *Backend) ExportLogs() error {
 	name := fmt.Sprintf("%s-log.txt", time.Now().Format("2006-01-02-at-15-04-05"))
@@ -122,7 +122,7 @@ func makeBitBox02BTCOnly() *keystoremock.KeystoreMock {
 	return ks
 }
 
-func MockBtcAccount(t *testing.T, config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, log *logrus.Entry) *accountsMocks.InterfaceMock {
+func MockBtcAccount(t testing.TB, config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, log *logrus.Entry) *accountsMocks.InterfaceMock {
 	t.Helper()
 	return &accountsMocks.InterfaceMock{
 		ObserveFunc: func(func(observable.Event)) func() {
@@ -146,12 +146,19 @@ func MockBtcAccount(t *testing.T, config *accounts.AccountConfig, coin *btc.Coin
 		FatalErrorFunc: func() bool {
 			return false
 		},
+		NotifierFunc: func() accounts.Notifier {
+			return nil
+		},
+		SyncedFunc: func() bool {
+			return false
+		},
 		GetUnusedReceiveAddressesFunc: func() []accounts.AddressList {
 			result := []accounts.AddressList{}
 			for _, signingConfig := range config.Config.SigningConfigurations {
 				addressChain := addresses.NewAddressChain(
 					signingConfig,
 					coin.Net(), 20, 0,
+					addresses.NewDerivationCache(coin.Net(), log),
 					func(*addresses.AccountAddress) (bool, error) {
 						return false, nil
 					},
@@ -254,7 +261,7 @@ func (m *mockTransactionsSource) Transactions(
 	return []*accounts.TransactionData{}, nil
 }
 
-func newBackend(t *testing.T, testing, regtest bool) *Backend {
+func newBackend(t testing.TB, testing, regtest bool) *Backend {
 	t.Helper()
 	b, err := NewBackend(
 		arguments.NewArguments(
@@ -319,6 +326,47 @@ func newBackend(t *testing.T, testing, regtest bool) *Backend {
 	return b
 }
 
+func TestRegisterTestMnemonicKeystore(t *testing.T) {
+	const mnemonic = "wisdom minute home employ west tail liquid mad deal catalog narrow mistake"
+	expectedKeystore := software.NewKeystore(test.TstMustXKey("xprv9s21ZrQH143K3gie3VFLgx8JcmqZNsBcBc6vAdJrsf4bPRhx69U8qZe3EYAyvRWyQdEfz7ZpyYtL8jW2d2Lfkfh6g2zivq8JdZPQqxoxLwB"))
+	expectedFingerprint, err := expectedKeystore.RootFingerprint()
+	require.NoError(t, err)
+
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	require.Error(t, b.RegisterTestMnemonicKeystore(mnemonic, "", false))
+	require.Nil(t, b.Keystore())
+	require.NoError(t, b.RegisterTestMnemonicKeystore(mnemonic, "", true))
+	fingerprint, err := b.Keystore().RootFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, expectedFingerprint, fingerprint)
+
+	b = newBackend(t, testnetEnabled, regtestDisabled)
+	defer b.Close()
+	require.Error(t, b.RegisterTestMnemonicKeystore("wisdom minute", "", false))
+	require.NoError(t, b.RegisterTestMnemonicKeystore(mnemonic, "", false))
+	fingerprint, err = b.Keystore().RootFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, expectedFingerprint, fingerprint)
+	require.NotEmpty(t, b.Accounts())
+}
+
+func TestRegisterEnvironmentKeystore(t *testing.T) {
+	t.Setenv("BITBOX_TEST_MNEMONIC",
+		"wisdom minute home employ west tail liquid mad deal catalog narrow mistake")
+
+	// Ignored outside of testing mode.
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	b.registerEnvironmentKeystore()
+	require.Nil(t, b.Keystore())
+
+	b = newBackend(t, testnetEnabled, regtestDisabled)
+	defer b.Close()
+	b.registerEnvironmentKeystore()
+	require.NotNil(t, b.Keystore())
+}
+
 func TestRegisterKeystore(t *testing.T) {
 	// From mnemonic: wisdom minute home employ west tail liquid mad deal catalog narrow mistake
 	rootKey1 := test.TstMustXKey("xprv9s21ZrQH143K3gie3VFLgx8JcmqZNsBcBc6vAdJrsf4bPRhx69U8qZe3EYAyvRWyQdEfz7ZpyYtL8jW2d2Lfkfh6g2zivq8JdZPQqxoxLwB")
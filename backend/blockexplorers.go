@@ -0,0 +1,130 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// blockExplorers are the block explorers the user can choose from, by coin. The first one is the
+// default. All URLs must be https URLs, as they are also allowed by SystemOpen.
+var blockExplorers = map[coin.Code][]coin.BlockExplorer{
+	coin.CodeBTC: {
+		{Name: "blockstream.info", URL: "https://blockstream.info/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block-height/"},
+		{Name: "mempool.space", URL: "https://mempool.space/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeTBTC: {
+		{Name: "blockstream.info", URL: "https://blockstream.info/testnet/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block-height/"},
+		{Name: "mempool.space", URL: "https://mempool.space/testnet/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeTBTC4: {
+		{Name: "mempool.space", URL: "https://mempool.space/testnet4/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeSBTC: {
+		{Name: "mempool.space", URL: "https://mempool.space/signet/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeLTC: {
+		{Name: "blockchair.com", URL: "https://blockchair.com/litecoin/", TxPath: "transaction/", AddressPath: "address/", BlockPath: "block/"},
+		{Name: "litecoinspace.org", URL: "https://litecoinspace.org/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeTLTC: {
+		{Name: "sochain.com", URL: "https://sochain.com/", TxPath: "tx/LTCTEST/", AddressPath: "address/LTCTEST/", BlockPath: "block/LTCTEST/"},
+		{Name: "litecoinspace.org", URL: "https://litecoinspace.org/testnet/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeETH: {
+		{Name: "etherscan.io", URL: "https://etherscan.io/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+		{Name: "blockchair.com", URL: "https://blockchair.com/ethereum/", TxPath: "transaction/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeGOETH: {
+		{Name: "etherscan.io", URL: "https://goerli.etherscan.io/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+	coin.CodeSEPETH: {
+		{Name: "etherscan.io", URL: "https://sepolia.etherscan.io/", TxPath: "tx/", AddressPath: "address/", BlockPath: "block/"},
+	},
+}
+
+// blockExplorerCode returns the code of the coin the block explorer is configured for. ERC20
+// tokens use the block explorer of Ethereum.
+func blockExplorerCode(code coin.Code) coin.Code {
+	if erc20TokenByCode(code) != nil {
+		return coin.CodeETH
+	}
+	return code
+}
+
+// AvailableBlockExplorers returns the block explorers the user can choose from for the given coin.
+// Empty if there are none, e.g. for regtest.
+func (backend *Backend) AvailableBlockExplorers(code coin.Code) []coin.BlockExplorer {
+	return blockExplorers[blockExplorerCode(code)]
+}
+
+// BlockExplorer returns the block explorer selected by the user for the given coin, or the default
+// if none was selected or the selected one is not available anymore.
+func (backend *Backend) BlockExplorer(code coin.Code) coin.BlockExplorer {
+	available := backend.AvailableBlockExplorers(code)
+	if len(available) == 0 {
+		return coin.BlockExplorer{}
+	}
+	selectedURL := backend.config.AppConfig().Backend.BlockExplorers[blockExplorerCode(code)]
+	for _, explorer := range available {
+		if explorer.URL == selectedURL {
+			return explorer
+		}
+	}
+	return available[0]
+}
+
+// SetBlockExplorer selects the block explorer of the given coin, identified by its URL, which must
+// be one of AvailableBlockExplorers(). For Ethereum, this also applies to all ERC20 tokens.
+func (backend *Backend) SetBlockExplorer(code coin.Code, explorerURL string) error {
+	if err := coin.ValidateBlockExplorerURL(explorerURL); err != nil {
+		return err
+	}
+	var selected *coin.BlockExplorer
+	for _, explorer := range backend.AvailableBlockExplorers(code) {
+		explorer := explorer
+		if explorer.URL == explorerURL {
+			selected = &explorer
+			break
+		}
+	}
+	if selected == nil {
+		return errp.Newf("Block explorer %s is not available for %s", explorerURL, code)
+	}
+	explorerCode := blockExplorerCode(code)
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		if appConfig.Backend.BlockExplorers == nil {
+			appConfig.Backend.BlockExplorers = map[coin.Code]string{}
+		}
+		appConfig.Backend.BlockExplorers[explorerCode] = explorerURL
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	func() {
+		defer backend.coinsLock.Lock()()
+		for coinCode, coin := range backend.coins {
+			if blockExplorerCode(coinCode) == explorerCode {
+				coin.SetBlockExplorer(*selected)
+			}
+		}
+	}()
+	// The accounts contain the block explorer URL prefix.
+	backend.emitAccountsStatusChanged()
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockExplorersAreHTTPS(t *testing.T) {
+	for code, explorers := range blockExplorers {
+		require.NotEmpty(t, explorers, code)
+		for _, explorer := range explorers {
+			require.NoError(t, coinpkg.ValidateBlockExplorerURL(explorer.URL), code)
+		}
+	}
+}
+
+func TestSetBlockExplorer(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	btcCoin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	require.Equal(t, "https://blockstream.info/tx/", btcCoin.BlockExplorerTransactionURLPrefix())
+
+	var events []observable.Event
+	b.Observe(func(event observable.Event) { events = append(events, event) })
+
+	require.Error(t, b.SetBlockExplorer(coinpkg.CodeBTC, "javascript:alert(1)//https://mempool.space/"))
+	require.Error(t, b.SetBlockExplorer(coinpkg.CodeBTC, "http://mempool.space/"))
+	require.Error(t, b.SetBlockExplorer(coinpkg.CodeBTC, "https://evil.example.com/"))
+	// Explorer of another coin.
+	require.Error(t, b.SetBlockExplorer(coinpkg.CodeBTC, "https://mempool.space/testnet4/"))
+	require.Empty(t, events)
+
+	require.NoError(t, b.SetBlockExplorer(coinpkg.CodeBTC, "https://mempool.space/"))
+	require.Equal(t, "https://mempool.space/",
+		b.Config().AppConfig().Backend.BlockExplorers[coinpkg.CodeBTC])
+	require.Equal(t, "https://mempool.space/tx/", btcCoin.BlockExplorerTransactionURLPrefix())
+	addressURL, err := btcCoin.BlockExplorerURL(coinpkg.BlockExplorerAddress, "bc1qaddress")
+	require.NoError(t, err)
+	require.Equal(t, "https://mempool.space/address/bc1qaddress", addressURL)
+	blockURL, err := btcCoin.BlockExplorerURL(coinpkg.BlockExplorerBlock, "840000")
+	require.NoError(t, err)
+	require.Equal(t, "https://mempool.space/block/840000", blockURL)
+	subjects := []string{}
+	for _, event := range events {
+		subjects = append(subjects, event.Subject)
+	}
+	require.Equal(t, []string{"coins/btc/block-explorer", "accounts"}, subjects)
+
+	// Coins created later use the selected explorer too.
+	require.NoError(t, b.SetBlockExplorer(coinpkg.CodeETH, "https://blockchair.com/ethereum/"))
+	tokenCoin, err := b.Coin("eth-erc20-usdt")
+	require.NoError(t, err)
+	require.Equal(t, "https://blockchair.com/ethereum/transaction/", tokenCoin.BlockExplorerTransactionURLPrefix())
+
+	// Regtest has no block explorer.
+	require.Equal(t, coinpkg.BlockExplorer{}, b.BlockExplorer(coinpkg.CodeRBTC))
+}
+
+func TestSystemOpenBlockExplorer(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	require.NoError(t, b.SystemOpen("https://mempool.space/testnet4/tx/abcd"))
+	require.NoError(t, b.SystemOpen("https://etherscan.io/address/0x01"))
+	require.Error(t, b.SystemOpen("javascript:alert(1)"))
+	require.Error(t, b.SystemOpen("https://evil.example.com/tx/abcd"))
+}
@@ -310,7 +310,7 @@ func Serve(
 	globalHandlers = handlers.NewHandlers(globalBackend,
 		handlers.NewConnectionData(-1, globalToken))
 
-	events := globalHandlers.Events()
+	events := globalHandlers.Events(quitChan)
 	go func() {
 		for {
 			select {
@@ -0,0 +1,100 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+// cacheVerifier is implemented by accounts whose cached data can be verified against the keystore,
+// see btc.Account.VerifyCache().
+type cacheVerifier interface {
+	VerifyCache(keystore keystore.Keystore, full bool) error
+	QuarantineCache() error
+}
+
+// AccountCacheQuarantined is the payload of the "accounts/cache-quarantined" event, emitted when the
+// cached data of an account did not match the keystore and was discarded.
+type AccountCacheQuarantined struct {
+	Code accountsTypes.Code `json:"code"`
+	Name string             `json:"name"`
+}
+
+// verifyAccountCaches verifies the cached data of all accounts of the given keystore against it.
+// In testing mode, all addresses and transactions are verified instead of a sample. If the cache
+// of an account does not match, it is quarantined, the accounts are reloaded and synced from
+// scratch, and the frontend is warned.
+func (backend *Backend) verifyAccountCaches(ks keystore.Keystore) {
+	rootFingerprint, err := ks.RootFingerprint()
+	if err != nil {
+		backend.log.WithError(err).Error("Could not retrieve root fingerprint")
+		return
+	}
+	var toVerify []accounts.Interface
+	func() {
+		defer backend.accountsAndKeystoreLock.RLock()()
+		for _, account := range backend.accounts {
+			if _, ok := account.(cacheVerifier); !ok {
+				continue
+			}
+			if account.Config().Config.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
+				toVerify = append(toVerify, account)
+			}
+		}
+	}()
+
+	quarantined := false
+	for _, account := range toVerify {
+		log := backend.log.WithField("accountCode", account.Config().Config.Code)
+		if account.FatalError() {
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			log.WithError(err).Error("Could not initialize the account to verify its cache")
+			continue
+		}
+		verifier := account.(cacheVerifier)
+		err := verifier.VerifyCache(ks, backend.Testing())
+		if errp.Cause(err) != btc.ErrCacheMismatch {
+			if err != nil {
+				log.WithError(err).Error("Could not verify the account cache")
+			}
+			continue
+		}
+		log.WithError(err).Error("The account cache does not match the keystore, quarantining it")
+		if err := verifier.QuarantineCache(); err != nil {
+			log.WithError(err).Error("Could not quarantine the account cache")
+			continue
+		}
+		quarantined = true
+		backend.Notify(observable.Event{
+			Subject: "accounts/cache-quarantined",
+			Action:  action.Replace,
+			Object: AccountCacheQuarantined{
+				Code: account.Config().Config.Code,
+				Name: account.Config().Config.Name,
+			},
+		})
+	}
+	if quarantined {
+		backend.ReinitializeAccounts()
+	}
+}
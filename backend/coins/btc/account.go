@@ -16,13 +16,16 @@
 package btc
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"sort"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
@@ -31,7 +34,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/transactionsdb"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -46,6 +49,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
 )
@@ -59,6 +63,9 @@ const (
 	// goal that the scanning will stop in a reasonable amount of time.
 	maxGapLimit = 2000
 
+	// rescanWorkers is the number of address histories fetched concurrently by Rescan().
+	rescanWorkers = 10
+
 	// mempoolSpaceMirror is Shift server that mirrors "https://mempool.space/api/v1/fees/recommended"
 	// rest call.
 	mempoolSpaceMirror = "https://fees1.shiftcrypto.io"
@@ -103,10 +110,23 @@ type Account struct {
 
 	transactions *transactions.Transactions
 
+	// derivationCache caches the addresses derived from the signing configurations, so that each
+	// address is derived only once.
+	derivationCache *addresses.DerivationCache
+
+	// addressesByScriptHash contains all addresses watched by the account, i.e. the addresses of
+	// all subaccounts, so that they can be looked up by the script hash notifications and outputs
+	// refer to. Addresses are added in subscribeAddress().
+	addressesByScriptHash     map[blockchain.ScriptHashHex]*addresses.AccountAddress
+	addressesByScriptHashLock locker.Locker
+
 	// if not nil, SendTx() will sign and send this transaction. Set by TxProposal().
-	activeTxProposal     *maketx.TxProposal
+	activeTxProposal     *TransactionProposal
 	activeTxProposalLock locker.Locker
 
+	// receiveAddressHandoutLock serializes handing out receive addresses, see NextReceiveAddress().
+	receiveAddressHandoutLock locker.Locker
+
 	// Access this only via getMinRelayFeeRate(). sat/kB.
 	minRelayFeeRate     *btcutil.Amount
 	minRelayFeeRateLock locker.Locker
@@ -117,6 +137,25 @@ type Account struct {
 
 	fatalError atomic.Bool
 
+	subscriptionsHealth     SubscriptionsHealth
+	subscriptionsHealthLock locker.Locker
+	// lastBalance is the balance as of the last balance check, see scheduleBalanceCheck().
+	lastBalance     *accounts.Balance
+	lastBalanceLock locker.Locker
+	// balanceCheckPending is true while a balance check is scheduled.
+	balanceCheckPending atomic.Bool
+	// reconnected triggers a subscriptions check shortly after a reconnect. Set in Initialize().
+	reconnected chan struct{}
+	// reconnectedRebroadcast triggers a rebroadcast of the unconfirmed outgoing transactions shortly
+	// after a reconnect. Set in Initialize().
+	reconnectedRebroadcast chan struct{}
+	// quitChan is closed when the account is closed. Set in Initialize().
+	quitChan chan struct{}
+	// ctx is cancelled when the account is closed, aborting its pending blockchain requests. Set in
+	// Initialize().
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	closed bool
 
 	log *logrus.Entry
@@ -135,7 +174,7 @@ func NewAccount(
 	httpClient *http.Client,
 ) *Account {
 	log = log.WithField("group", "btc").
-		WithFields(logrus.Fields{"coin": coin.String(), "code": config.Config.Code, "name": config.Config.Name})
+		WithFields(logrus.Fields{"coin": coin.String(), "code": config.Config.Code, "name": accounts.NameLogField(config.Config)})
 	log.Debug("Creating new account")
 
 	account := &Account{
@@ -144,6 +183,8 @@ func NewAccount(
 		dbSubfolder:    "", // set in Initialize()
 		forceGapLimits: forceGapLimits,
 
+		derivationCache: addresses.NewDerivationCache(coin.Net(), log),
+
 		log:        log,
 		httpClient: httpClient,
 	}
@@ -214,6 +255,9 @@ func (account *Account) gapLimits(
 		if err != nil {
 			return types.GapLimits{}, err
 		}
+		if configured := account.Config().Config.ReceiveGapLimit; limits.Receive < configured {
+			limits.Receive = configured
+		}
 		if limits.Receive < defaultLimits.Receive {
 			if account.forceGapLimits != nil { // log only when it's interesting
 				account.log.Infof("receive gap limit increased to minimum of %d", defaultLimits.Receive)
@@ -255,7 +299,7 @@ func (account *Account) getMinRelayFeeRate() (btcutil.Amount, error) {
 		return *cached, nil
 	}
 
-	feeRate, err := account.coin.Blockchain().RelayFee()
+	feeRate, err := account.coin.Blockchain().RelayFee(account.ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -264,6 +308,11 @@ func (account *Account) getMinRelayFeeRate() (btcutil.Amount, error) {
 	return feeRate, nil
 }
 
+// dbFilename returns the path of the database the transactions of the account are persisted in.
+func (account *Account) dbFilename() string {
+	return path.Join(account.Config().DBFolder, fmt.Sprintf("account-%s.db", account.Config().Config.Code))
+}
+
 func (account *Account) isInitialized() bool {
 	defer account.initializedLock.RLock()()
 	return account.initialized
@@ -302,7 +351,7 @@ func (account *Account) Initialize() error {
 
 	dbName := fmt.Sprintf("%s.db", accountIdentifier)
 	account.log.Debugf("Opening the database '%s' to persist the transactions.", dbName)
-	db, err := transactionsdb.NewDB(path.Join(account.Config().DBFolder, dbName))
+	db, err := transactionsdb.NewDB(account.dbFilename())
 	if err != nil {
 		return err
 	}
@@ -320,9 +369,24 @@ func (account *Account) Initialize() error {
 			account.SetOffline(nil)
 			account.minRelayFeeRate = nil
 			account.log.Debug("Connection to blockchain backend established")
+			select {
+			case account.reconnected <- struct{}{}:
+			default:
+			}
+			select {
+			case account.reconnectedRebroadcast <- struct{}{}:
+			default:
+			}
 		}
 	}
-	account.coin.Initialize()
+	account.reconnected = make(chan struct{}, 1)
+	account.reconnectedRebroadcast = make(chan struct{}, 1)
+	account.quitChan = make(chan struct{})
+	account.ctx, account.cancel = context.WithCancel(context.Background())
+	account.addressesByScriptHash = map[blockchain.ScriptHashHex]*addresses.AccountAddress{}
+	if err := account.coin.Initialize(); err != nil {
+		return err
+	}
 	account.SetOffline(account.coin.Blockchain().ConnectionError())
 	account.coin.Blockchain().RegisterOnConnectionErrorChangedEvent(onConnectionStatusChanged)
 	theHeaders := account.coin.Headers()
@@ -333,11 +397,16 @@ func (account *Account) Initialize() error {
 	})
 	account.transactions = transactions.NewTransactions(
 		account.coin.Net(), account.db, theHeaders, account.Synchronizer,
-		account.coin.Blockchain(), account.notifier, account.log)
+		account.coin.Blockchain(), account.coin.TxFetchThrottle(), account.notifier,
+		account.onTxConfirmationsChanged, account.onTxVerificationChanged, account.log)
 
 	for _, signingConfiguration := range signingConfigurations {
 		signingConfiguration := signingConfiguration
 
+		// Reject configurations addresses can't be created from before building any address.
+		if _, err := signingConfiguration.AddressType(account.coin.Net()); err != nil {
+			return err
+		}
 		var subacc subaccount
 		subacc.signingConfiguration = signingConfiguration
 		gapLimits, err := account.gapLimits(signingConfiguration)
@@ -347,14 +416,18 @@ func (account *Account) Initialize() error {
 		account.log.Infof("gap limits: receive=%d, change=%d", gapLimits.Receive, gapLimits.Change)
 
 		subacc.receiveAddresses = addresses.NewAddressChain(
-			signingConfiguration, account.coin.Net(), int(gapLimits.Receive), 0, account.isAddressUsed, account.log)
+			signingConfiguration, account.coin.Net(), int(gapLimits.Receive), 0,
+			account.derivationCache, account.isAddressUsed, account.log)
 		subacc.changeAddresses = addresses.NewAddressChain(
-			signingConfiguration, account.coin.Net(), int(gapLimits.Change), 1, account.isAddressUsed, account.log)
+			signingConfiguration, account.coin.Net(), int(gapLimits.Change), 1,
+			account.derivationCache, account.isAddressUsed, account.log)
 
 		account.subaccounts = append(account.subaccounts, subacc)
 	}
 	account.ensureAddresses()
 	account.coin.Blockchain().HeadersSubscribe(account.onNewHeader)
+	go account.subscriptionsHealthLoop(account.reconnected, account.quitChan)
+	go account.rebroadcastLoop(account.reconnectedRebroadcast, account.quitChan)
 
 	return account.BaseAccount.Initialize(accountIdentifier)
 }
@@ -373,7 +446,7 @@ func XPubVersionForScriptType(coin *Coin, scriptType signing.ScriptType) [4]byte
 			return versions[signing.ScriptTypeP2PKH]
 		}
 		return version
-	case chaincfg.TestNet3Params.Net:
+	case chaincfg.TestNet3Params.Net, netparams.TestNet4, netparams.SigNetParams.Net:
 		return chaincfg.TestNet3Params.HDPublicKeyID
 	case ltc.TestNet4Params.Net:
 		return ltc.TestNet4Params.HDPublicKeyID
@@ -392,6 +465,7 @@ func (account *Account) Info() *accounts.Info {
 	// convert it here to the account-specific version (zpub, ypub, tpub, ...).
 	isInsuredAccount := account.Config().Config.InsuranceStatus == string(bitsurance.ActiveStatus)
 	var signingConfigurations []*signing.Configuration
+	var descriptors []*signing.Descriptors
 	for _, subacc := range account.subaccounts {
 		isNativeSegwit := subacc.signingConfiguration.ScriptType() == signing.ScriptTypeP2WPKH
 		// hiding legacy/taproot xpubs as an insured account should only receive on native segwit.
@@ -419,9 +493,15 @@ func (account *Account) Info() *accounts.Info {
 			xpubCopy,
 		)
 		signingConfigurations = append(signingConfigurations, signingConfiguration)
+		subaccDescriptors, err := subacc.signingConfiguration.Descriptors(account.coin.Net())
+		if err != nil {
+			panic(err)
+		}
+		descriptors = append(descriptors, subaccDescriptors)
 	}
 	return &accounts.Info{
 		SigningConfigurations: signingConfigurations,
+		Descriptors:           descriptors,
 	}
 }
 
@@ -453,6 +533,12 @@ func (account *Account) Close() {
 	if account.transactions != nil {
 		account.transactions.Close()
 	}
+	if account.quitChan != nil {
+		close(account.quitChan)
+	}
+	if account.cancel != nil {
+		account.cancel()
+	}
 
 	if account.db != nil {
 		if err := account.db.Close(); err != nil {
@@ -524,7 +610,7 @@ func (account *Account) feeTargets() []*FeeTarget {
 		} else {
 			// If mempool.space fees are not available, we fallback on Bitcoin Core estimation.
 			// If even that one is not available, we just offer the min relay fee.
-			feeRatePerKb, err = account.coin.Blockchain().EstimateFee(feeTarget.blocks)
+			feeRatePerKb, err = account.coin.Blockchain().EstimateFee(account.ctx, feeTarget.blocks)
 			if err != nil {
 				if account.coin.Code() != coin.CodeTLTC {
 					account.log.WithField("fee-target", feeTarget.blocks).
@@ -589,15 +675,40 @@ func (account *Account) Balance() (*accounts.Balance, error) {
 	}
 	balance, err := account.transactions.Balance()
 	if err != nil {
-		// TODO
-		panic(err)
+		return nil, err
+	}
+	frozen, err := account.frozenBalance()
+	if err != nil {
+		return nil, err
+	}
+	pendingChange, err := account.pendingChangeBalance()
+	if err != nil {
+		return nil, err
+	}
+	unverified, err := account.unverifiedBalance()
+	if err != nil {
+		return nil, err
 	}
-	return balance, nil
+	hiddenAvailable, hiddenIncoming, err := account.hiddenDustBalance()
+	if err != nil {
+		return nil, err
+	}
+	return balance.WithoutHidden(hiddenAvailable, hiddenIncoming).
+		WithFrozen(frozen).WithPendingChange(pendingChange).WithUnverified(unverified), nil
+}
+
+// TxFetchRate returns the current rate limit of the transaction downloads in transactions per
+// second. It is shared by all accounts of the coin and adapts to the health of the server.
+func (account *Account) TxFetchRate() float64 {
+	return account.coin.TxFetchThrottle().Rate()
 }
 
 func (account *Account) incAndEmitSyncCounter() {
 	if !account.Synced() {
 		synced := atomic.AddUint32(&account.syncedAddressesCount, 1)
+		if !account.Observed() {
+			return
+		}
 		account.Notify(observable.Event{
 			Subject: fmt.Sprintf("account/%s/synced-addresses-count", account.Config().Config.Code),
 			Action:  action.Replace,
@@ -606,6 +717,27 @@ func (account *Account) incAndEmitSyncCounter() {
 	}
 }
 
+// TxConfirmations is the payload of the `account/<code>/tx-confirmations` event, which is emitted
+// when a tx confirms, is complete, or goes back below one of these thresholds after a reorg.
+type TxConfirmations struct {
+	TxID             string `json:"txID"`
+	NumConfirmations int    `json:"numConfirmations"`
+}
+
+func (account *Account) onTxConfirmationsChanged(txHash chainhash.Hash, numConfirmations int) {
+	if !account.Observed() {
+		return
+	}
+	account.Notify(observable.Event{
+		Subject: fmt.Sprintf("account/%s/tx-confirmations", account.Config().Config.Code),
+		Action:  action.Replace,
+		Object: TxConfirmations{
+			TxID:             txHash.String(),
+			NumConfirmations: numConfirmations,
+		},
+	})
+}
+
 func (account *Account) getAddressHistory(address *addresses.AccountAddress) (blockchain.TxHistory, error) {
 	return transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (blockchain.TxHistory, error) {
 		return dbTx.AddressHistory(address.PubkeyScriptHashHex())
@@ -623,6 +755,10 @@ func (account *Account) isAddressUsed(address *addresses.AccountAddress) (bool,
 // onAddressStatus is called when the status (tx history) of an address might have changed. It is
 // called when the address is initialized, and when the backend notifies us of changes to it. If
 // there was indeed change, the tx history is downloaded and processed.
+//
+// The address histories are persisted in the transactions DB and the status is derived from them,
+// so on startup and after a reconnect only the histories of addresses whose status differs from the
+// server are downloaded again.
 func (account *Account) onAddressStatus(address *addresses.AccountAddress, status string) {
 	if account.isClosed() {
 		account.log.Debug("Ignoring result of ScriptHashSubscribe after the account was closed")
@@ -646,10 +782,26 @@ func (account *Account) onAddressStatus(address *addresses.AccountAddress, statu
 	}
 
 	account.log.Debug("Address status changed, fetching history.")
+	account.syncAddressHistory(address)
+}
 
+// syncAddressHistory downloads and processes the tx history of the address. Only the txs which
+// changed in the history are processed, and only the address chain of the address is extended, so
+// a single new payment does not cause work proportional to the size of the account. The work is
+// reported as a sync of its own, with EventSyncStarted and EventSyncDone, unless another sync is
+// running already.
+func (account *Account) syncAddressHistory(address *addresses.AccountAddress) {
 	defer account.Synchronizer.IncRequestsCounter()()
-	history, err := account.coin.Blockchain().ScriptHashGetHistory(address.PubkeyScriptHashHex())
+	account.AddSyncProgress(accountsTypes.SyncProgress{HistoriesTotal: 1})
+	started := time.Now()
+	history, err := account.coin.Blockchain().ScriptHashGetHistory(
+		account.ctx, address.PubkeyScriptHashHex())
+	account.RecordSyncPhase(accountsTypes.SyncPhaseHistoryFetch, 1, time.Since(started))
 	if err != nil {
+		if account.isClosed() {
+			account.log.WithError(err).Debug("ScriptHashGetHistory aborted because the account was closed")
+			return
+		}
 		// We are not closing client.blockchain here, as it is reused per coin with
 		// different accounts.
 		account.fatalError.Store(true)
@@ -662,48 +814,158 @@ func (account *Account) onAddressStatus(address *addresses.AccountAddress, statu
 		return
 	}
 
-	account.transactions.UpdateAddressHistory(address.PubkeyScriptHashHex(), history)
+	started = time.Now()
+	numDownloaded, downloadDuration := account.transactions.UpdateAddressHistory(
+		address.PubkeyScriptHashHex(), history)
+	if numDownloaded > 0 {
+		account.RecordSyncPhase(accountsTypes.SyncPhaseTxFetch, numDownloaded, downloadDuration)
+	}
+	account.RecordSyncPhase(accountsTypes.SyncPhaseProcessing, 1, time.Since(started)-downloadDuration)
+	account.AddSyncProgress(accountsTypes.SyncProgress{
+		HistoriesFetched:    1,
+		TransactionsIndexed: numDownloaded,
+	})
 	account.incAndEmitSyncCounter()
-	account.ensureAddresses()
+	account.scheduleBalanceCheck()
+	// Only the chain of this address can have fewer unused addresses than the gap limit now.
+	if chain := account.addressChainOf(address); chain != nil {
+		account.ensureAddressChain(chain)
+	}
+}
+
+// Rescan discards the transactions confirmed at or above `fromHeight`, as well as the unconfirmed
+// ones, and downloads the history of all addresses again. Use fromHeight 0 to rebuild the whole
+// transaction set, e.g. after switching to a server whose index was incomplete before. It is safe
+// to call on a synced account. Transaction notes are not affected, as they are stored separately.
+//
+// The existing address subscriptions are kept, as the backend keeps notifying us about them. The
+// histories are fetched explicitly instead, which fires EventSyncStarted and EventSyncDone.
+func (account *Account) Rescan(fromHeight int) error {
+	if fromHeight < 0 {
+		return errp.Newf("invalid height: %d", fromHeight)
+	}
+	if !account.isInitialized() {
+		return errp.New("account not initialized")
+	}
+	if account.isClosed() {
+		return errp.New("account was closed")
+	}
+	if account.fatalError.Load() {
+		return errp.New("can't rescan after a fatal error")
+	}
+	account.log.WithField("from-height", fromHeight).Info("Rescanning the account")
+
+	// Held until all histories are processed, so that the sync is not reported done in between.
+	done := account.Synchronizer.IncRequestsCounter()
+	account.ResetSynced()
+	atomic.StoreUint32(&account.syncedAddressesCount, 0)
+	account.Config().OnEvent(accountsTypes.EventStatusChanged)
+
+	allAddresses := []*addresses.AccountAddress{}
+	for _, subacc := range account.subaccounts {
+		allAddresses = append(allAddresses, subacc.receiveAddresses.Addresses()...)
+		allAddresses = append(allAddresses, subacc.changeAddresses.Addresses()...)
+	}
+	for _, address := range allAddresses {
+		account.transactions.RewindAddressHistory(address.PubkeyScriptHashHex(), fromHeight)
+	}
+	// The histories are fetched by a bounded number of workers, as an account can have thousands
+	// of addresses.
+	queue := make(chan *addresses.AccountAddress)
+	var wg sync.WaitGroup
+	for i := 0; i < min(rescanWorkers, len(allAddresses)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range queue {
+				account.syncAddressHistory(address)
+			}
+		}()
+	}
+	go func() {
+		defer done()
+		defer wg.Wait()
+		defer close(queue)
+		for _, address := range allAddresses {
+			if account.isClosed() {
+				return
+			}
+			queue <- address
+		}
+	}()
+	return nil
 }
 
 // ensureAddresses is the entry point of syncing up the account. It extends the receive and change
 // address chains to discover all funds, with respect to the gap limit. In the end, there are
 // `gapLimit` unused addresses in the tail. It is also called whenever the status (tx history) of
-// changes, to keep the gapLimit tail.
+// changes, to keep the gapLimit tail. When the status of a single address changes, only its chain
+// is extended, see ensureAddressChain().
 func (account *Account) ensureAddresses() {
 	defer account.Synchronizer.IncRequestsCounter()()
+	for _, subacc := range account.subaccounts {
+		account.ensureAddressChain(subacc.receiveAddresses)
+		account.ensureAddressChain(subacc.changeAddresses)
+	}
+}
 
-	syncSequence := func(addressChain *addresses.AddressChain) {
-		for {
-			newAddresses, err := addressChain.EnsureAddresses()
-			if err != nil {
-				if account.isClosed() {
-					account.log.WithError(err).Error("stopping sync because account was closed")
-					return
-				}
-				// TODO
-				account.log.WithError(err).Panic("EnsureAddresses failed")
-			}
-			if len(newAddresses) == 0 {
-				break
-			}
-			for _, address := range newAddresses {
-				account.subscribeAddress(address)
+// ensureAddressChain extends the address chain until it has `gapLimit` unused addresses in the
+// tail, and subscribes to the new addresses.
+func (account *Account) ensureAddressChain(addressChain *addresses.AddressChain) {
+	defer account.Synchronizer.IncRequestsCounter()()
+	for {
+		newAddresses, err := addressChain.EnsureAddresses()
+		if err != nil {
+			if account.isClosed() {
+				account.log.WithError(err).Error("stopping sync because account was closed")
+				return
 			}
+			// TODO
+			account.log.WithError(err).Panic("EnsureAddresses failed")
+		}
+		if len(newAddresses) == 0 {
+			return
+		}
+		account.AddSyncProgress(accountsTypes.SyncProgress{AddressesTotal: len(newAddresses)})
+		for _, address := range newAddresses {
+			account.subscribeAddress(address)
 		}
 	}
+}
+
+// addressChainOf returns the receive or change address chain the address belongs to, or nil if it
+// is not part of a chain.
+func (account *Account) addressChainOf(address *addresses.AccountAddress) *addresses.AddressChain {
+	scriptHashHex := address.PubkeyScriptHashHex()
 	for _, subacc := range account.subaccounts {
-		syncSequence(subacc.receiveAddresses)
-		syncSequence(subacc.changeAddresses)
+		if subacc.receiveAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
+			return subacc.receiveAddresses
+		}
+		if subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
+			return subacc.changeAddresses
+		}
 	}
+	return nil
 }
 
 func (account *Account) subscribeAddress(address *addresses.AccountAddress) {
+	func() {
+		defer account.addressesByScriptHashLock.Lock()()
+		account.addressesByScriptHash[address.PubkeyScriptHashHex()] = address
+	}()
+	// The callback is called again for every status change, but the address counts as subscribed
+	// once.
+	var subscribed sync.Once
+	started := time.Now()
 	account.coin.Blockchain().ScriptHashSubscribe(
+		account.ctx,
 		account.Synchronizer.IncRequestsCounter,
 		address.PubkeyScriptHashHex(),
 		func(status string) {
+			subscribed.Do(func() {
+				account.RecordSyncPhase(accountsTypes.SyncPhaseSubscribe, 1, time.Since(started))
+				account.AddSyncProgress(accountsTypes.SyncProgress{AddressesSubscribed: 1})
+			})
 			go account.onAddressStatus(address, status)
 		},
 	)
@@ -717,18 +979,54 @@ func (account *Account) Transactions() (accounts.OrderedTransactions, error) {
 	if account.fatalError.Load() {
 		return nil, errp.New("can't call Transactions() after a fatal error")
 	}
-	return account.transactions.Transactions(
-		func(scriptHashHex blockchain.ScriptHashHex) bool {
-			for _, subacc := range account.subaccounts {
-				if subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
-					return true
-				}
-			}
-			return false
-		})
+	txs, err := account.transactions.Transactions(account.isChange)
+	if err != nil {
+		return nil, err
+	}
+	if account.Config().Config.DustThreshold == 0 {
+		return txs, nil
+	}
+	listed := make([]*accounts.TransactionData, 0, len(txs))
+	for _, tx := range txs {
+		if !account.isHiddenDustTx(tx) {
+			// Copied, as the balance after the tx is recomputed below and the transactions are shared.
+			txCopy := *tx
+			listed = append(listed, &txCopy)
+		}
+	}
+	// Ordered again, so that the balances after each tx do not include the hidden dust.
+	return accounts.NewOrderedTransactions(listed), nil
+}
+
+// isChange returns true if the script hash belongs to a change address of the account.
+func (account *Account) isChange(scriptHashHex blockchain.ScriptHashHex) bool {
+	for _, subacc := range account.subaccounts {
+		if subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// TransactionDetails returns the decoded inputs and outputs of the transaction with the given ID.
+// nil is returned if the transaction is not part of the account.
+func (account *Account) TransactionDetails(txID string) (*transactions.TxDetails, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	if account.fatalError.Load() {
+		return nil, errp.New("can't call TransactionDetails() after a fatal error")
+	}
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return account.transactions.TxDetails(*txHash, account.isChange)
 }
 
-// GetUnusedReceiveAddresses returns a number of unused addresses. Returns nil if the account is not initialized.
+// GetUnusedReceiveAddresses returns a number of unused addresses per script type. The addresses of
+// the preferred script type of the account, see config.Account.PreferredScriptType, come first.
+// Returns nil if the account is not initialized.
 func (account *Account) GetUnusedReceiveAddresses() []accounts.AddressList {
 	if !account.isInitialized() {
 		return nil
@@ -738,8 +1036,7 @@ func (account *Account) GetUnusedReceiveAddresses() []accounts.AddressList {
 	var addresses []accounts.AddressList
 	for _, subacc := range account.subaccounts {
 		scriptType := subacc.signingConfiguration.ScriptType()
-		if account.Config().Config.InsuranceStatus == string(bitsurance.ActiveStatus) && scriptType != signing.ScriptTypeP2WPKH {
-			// Insured accounts can only receive on native segwit
+		if !account.canReceiveOn(scriptType) {
 			continue
 		}
 
@@ -758,11 +1055,73 @@ func (account *Account) GetUnusedReceiveAddresses() []accounts.AddressList {
 			}
 			addressList.Addresses = append(addressList.Addresses, address)
 		}
-		addresses = append(addresses, addressList)
+		if scriptType == account.Config().Config.PreferredScriptType {
+			addresses = append([]accounts.AddressList{addressList}, addresses...)
+		} else {
+			addresses = append(addresses, addressList)
+		}
 	}
 	return addresses
 }
 
+// canReceiveOn returns false if funds must not be received on addresses of the given script type.
+func (account *Account) canReceiveOn(scriptType signing.ScriptType) bool {
+	// Insured accounts can only receive on native segwit
+	return account.Config().Config.InsuranceStatus != string(bitsurance.ActiveStatus) ||
+		scriptType == signing.ScriptTypeP2WPKH
+}
+
+// GetUsedReceiveAddresses returns the receive addresses which already have a transaction history,
+// per script type. Returns nil if the account is not initialized.
+func (account *Account) GetUsedReceiveAddresses() ([]accounts.AddressList, error) {
+	if !account.isInitialized() {
+		return nil, nil
+	}
+	account.Synchronizer.WaitSynchronized()
+	var addressLists []accounts.AddressList
+	for _, subacc := range account.subaccounts {
+		scriptType := subacc.signingConfiguration.ScriptType()
+		if !account.canReceiveOn(scriptType) {
+			continue
+		}
+		usedAddresses, err := subacc.receiveAddresses.GetUsed()
+		if err != nil {
+			return nil, err
+		}
+		addressList := accounts.AddressList{ScriptType: &scriptType}
+		for _, address := range usedAddresses {
+			addressList.Addresses = append(addressList.Addresses, address)
+		}
+		addressLists = append(addressLists, addressList)
+	}
+	return addressLists, nil
+}
+
+// LookupReceiveAddress returns the receive address with the given ID, and whether it already has
+// a transaction history.
+func (account *Account) LookupReceiveAddress(addressID string) (*addresses.AccountAddress, bool, error) {
+	if !account.isInitialized() {
+		return nil, false, errp.New("account must be initialized")
+	}
+	account.Synchronizer.WaitSynchronized()
+	scriptHashHex, err := blockchain.ParseScriptHashHex(addressID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, subacc := range account.subaccounts {
+		address := subacc.receiveAddresses.LookupByScriptHashHex(scriptHashHex)
+		if address == nil {
+			continue
+		}
+		used, err := subacc.receiveAddresses.IsUsed(address)
+		if err != nil {
+			return nil, false, err
+		}
+		return address, used, nil
+	}
+	return nil, false, errp.New("unknown address not found")
+}
+
 // VerifyAddress verifies a receive address on a keystore. Returns false, nil if no secure output
 // exists.
 func (account *Account) VerifyAddress(addressID string) (bool, error) {
@@ -776,7 +1135,10 @@ func (account *Account) VerifyAddress(addressID string) (bool, error) {
 		return false, err
 	}
 
-	scriptHashHex := blockchain.ScriptHashHex(addressID)
+	scriptHashHex, err := blockchain.ParseScriptHashHex(addressID)
+	if err != nil {
+		return false, err
+	}
 	var address *addresses.AccountAddress
 	for _, subacc := range account.subaccounts {
 		if addr := subacc.receiveAddresses.LookupByScriptHashHex(scriptHashHex); addr != nil {
@@ -863,7 +1225,8 @@ type SpendableOutput struct {
 	Address  *addresses.AccountAddress
 }
 
-// SpendableOutputs returns the utxo set, sorted by the value descending.
+// SpendableOutputs returns the utxo set, sorted by the value descending. Hidden dust is not
+// included, see isHiddenDust().
 func (account *Account) SpendableOutputs() []*SpendableOutput {
 	account.Synchronizer.WaitSynchronized()
 	result := []*SpendableOutput{}
@@ -873,6 +1236,9 @@ func (account *Account) SpendableOutputs() []*SpendableOutput {
 		panic(err)
 	}
 	for outPoint, txOut := range utxos {
+		if account.isHiddenDust(txOut) {
+			continue
+		}
 		result = append(
 			result,
 			&SpendableOutput{
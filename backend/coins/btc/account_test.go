@@ -20,9 +20,13 @@ import (
 	"encoding/base64"
 	"math/big"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -32,11 +36,14 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,6 +57,14 @@ func mockKeystore() *keystoremock.KeystoreMock {
 }
 
 func mockAccount(t *testing.T, accountConfig *config.Account) *btc.Account {
+	t.Helper()
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	return mockAccountWithBlockchain(t, accountConfig, blockchainMock)
+}
+
+func mockAccountWithBlockchain(
+	t *testing.T, accountConfig *config.Account, blockchainMock blockchain.Interface) *btc.Account {
 	t.Helper()
 	code := coin.CodeTBTC
 	unit := "TBTC"
@@ -59,10 +74,7 @@ func mockAccount(t *testing.T, accountConfig *config.Account) *btc.Account {
 	defer func() { _ = os.RemoveAll(dbFolder) }()
 
 	coin := btc.NewCoin(
-		code, "Bitcoin Testnet", unit, coin.BtcUnitDefault, net, dbFolder, nil, explorer, socksproxy.NewSocksProxy(false, ""))
-
-	blockchainMock := &blockchainMock.BlockchainMock{}
-	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+		code, "Bitcoin Testnet", unit, coin.BtcUnitDefault, net, dbFolder, nil, config.TxFetchThrottle{}, explorer, socksproxy.NewSocksProxy(false, ""))
 
 	coin.TstSetMakeBlockchain(func() blockchain.Interface { return blockchainMock })
 
@@ -93,6 +105,7 @@ func mockAccount(t *testing.T, accountConfig *config.Account) *btc.Account {
 		&accounts.AccountConfig{
 			Config:          accountConfig,
 			DBFolder:        dbFolder,
+			NotesFolder:     test.TstTempDir("btc-notesfolder"),
 			OnEvent:         func(accountsTypes.Event) {},
 			RateUpdater:     nil,
 			GetNotifier:     func(signing.Configurations) accounts.Notifier { return nil },
@@ -100,6 +113,9 @@ func mockAccount(t *testing.T, accountConfig *config.Account) *btc.Account {
 			ConnectKeystore: func() (keystore.Keystore, error) {
 				return mockKeystore(), nil
 			},
+			ModifyConfig: func(f func(*config.Account) error) error {
+				return f(accountConfig)
+			},
 		},
 		coin, nil,
 		logging.Get().WithGroup("account_test"),
@@ -125,6 +141,228 @@ func TestAccount(t *testing.T) {
 	require.Equal(t, []*btc.SpendableOutput{}, account.SpendableOutputs())
 }
 
+func TestPersistedTransactions(t *testing.T) {
+	account := mockAccount(t, nil)
+	// Never synced.
+	_, _, err := account.PersistedTransactions()
+	require.Error(t, err)
+
+	require.NoError(t, account.Initialize())
+	_, err = account.Notes().SetTxNote("txid", "note")
+	require.NoError(t, err)
+	transactions, txNotes, err := account.PersistedTransactions()
+	require.NoError(t, err)
+	require.Equal(t, accounts.OrderedTransactions{}, transactions)
+	require.Equal(t, "note", txNotes.TxNote("txid"))
+	account.Close()
+
+	// Read from the persisted files without initializing the account.
+	archived := btc.NewAccount(
+		account.Config(), account.Coin().(*btc.Coin), nil,
+		logging.Get().WithGroup("account_test"), nil)
+	transactions, txNotes, err = archived.PersistedTransactions()
+	require.NoError(t, err)
+	require.Equal(t, accounts.OrderedTransactions{}, transactions)
+	require.Equal(t, "note", txNotes.TxNote("txid"))
+	require.False(t, archived.Synced())
+	require.NoError(t, archived.Initialize())
+	archived.Close()
+}
+
+func TestCheckSubscriptions(t *testing.T) {
+	var lock sync.Mutex
+	subscribed := []blockchain.ScriptHashHex{}
+	resubscribed := []blockchain.ScriptHashHex{}
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchainMock.MockConnectionError = func() error { return nil }
+	blockchainMock.MockScriptHashSubscribe = func(
+		setupAndTeardown func() func(), scriptHashHex blockchain.ScriptHashHex, success func(string)) {
+		lock.Lock()
+		defer lock.Unlock()
+		subscribed = append(subscribed, scriptHashHex)
+	}
+	blockchainMock.MockScriptHashResubscribe = func(
+		setupAndTeardown func() func(), scriptHashHex blockchain.ScriptHashHex, success func(string)) {
+		lock.Lock()
+		defer lock.Unlock()
+		resubscribed = append(resubscribed, scriptHashHex)
+	}
+	account := mockAccountWithBlockchain(t, nil, blockchainMock)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	lock.Lock()
+	initial := append([]blockchain.ScriptHashHex{}, subscribed...)
+	subscribed = subscribed[:0]
+	lock.Unlock()
+	require.NotEmpty(t, initial)
+	require.True(t, account.SubscriptionsHealth().LastCheck.IsZero())
+
+	// All subscriptions active: nothing to repair.
+	blockchainMock.MockScriptHashSubscriptions = func() (
+		[]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+		return initial, nil
+	}
+	account.TstCheckSubscriptions()
+	require.Empty(t, resubscribed)
+	require.False(t, account.SubscriptionsHealth().LastCheck.IsZero())
+	require.Equal(t, 0, account.SubscriptionsHealth().Repaired)
+
+	// Subscriptions whose request did not complete yet are not missing.
+	blockchainMock.MockScriptHashSubscriptions = func() (
+		[]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+		return initial[3:], initial[:3]
+	}
+	account.TstCheckSubscriptions()
+	require.Empty(t, resubscribed)
+	require.Equal(t, 0, account.SubscriptionsHealth().Repaired)
+
+	// Three subscriptions were dropped and are resubscribed without registering them again.
+	blockchainMock.MockScriptHashSubscriptions = func() (
+		[]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+		return initial[3:], nil
+	}
+	account.TstCheckSubscriptions()
+	require.ElementsMatch(t, initial[:3], resubscribed)
+	require.Empty(t, subscribed)
+	require.Equal(t, 3, account.SubscriptionsHealth().Repaired)
+}
+
+func TestRebroadcast(t *testing.T) {
+	var lock sync.Mutex
+	broadcast := []chainhash.Hash{}
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchainMock.MockConnectionError = func() error { return nil }
+	blockchainMock.MockScriptHashSubscribe = func(func() func(), blockchain.ScriptHashHex, func(string)) {}
+	blockchainMock.MockTransactionBroadcast = func(tx *wire.MsgTx) error {
+		lock.Lock()
+		defer lock.Unlock()
+		broadcast = append(broadcast, tx.TxHash())
+		// Counts as a successful broadcast.
+		return errp.New("the transaction was rejected by network rules.\n\ntxn-already-in-mempool")
+	}
+	account := mockAccountWithBlockchain(t, nil, blockchainMock)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH(nil), Index: 0}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	sentTime := time.Now().Add(-time.Hour)
+	require.NoError(t, account.TstTransactions().PutOutgoingTransaction(tx, sentTime))
+
+	account.TstRebroadcast()
+	lock.Lock()
+	require.Equal(t, []chainhash.Hash{tx.TxHash()}, broadcast)
+	lock.Unlock()
+
+	outgoingTxs, err := account.TstTransactions().OutgoingTransactionsToBroadcast()
+	require.NoError(t, err)
+	require.Len(t, outgoingTxs, 1)
+	require.True(t, outgoingTxs[0].LastBroadcast.After(sentTime))
+}
+
+func TestGetAddress(t *testing.T) {
+	var lock sync.Mutex
+	subscribed := []blockchain.ScriptHashHex{}
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchainMock.MockConnectionError = func() error { return nil }
+	blockchainMock.MockScriptHashSubscribe = func(
+		setupAndTeardown func() func(), scriptHashHex blockchain.ScriptHashHex, success func(string)) {
+		lock.Lock()
+		defer lock.Unlock()
+		subscribed = append(subscribed, scriptHashHex)
+	}
+	account := mockAccountWithBlockchain(t, nil, blockchainMock)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+	// 20 receive and 6 change addresses.
+	require.Len(t, subscribed, 26)
+	for _, scriptHashHex := range subscribed {
+		address := account.TstGetAddress(scriptHashHex)
+		require.NotNil(t, address)
+		require.Equal(t, scriptHashHex, address.PubkeyScriptHashHex())
+	}
+	require.Nil(t, account.TstGetAddress(blockchain.NewScriptHashHex([]byte("unknown"))))
+}
+
+func TestRescan(t *testing.T) {
+	var lock sync.Mutex
+	fetched := map[blockchain.ScriptHashHex]bool{}
+	running, maxRunning := 0, 0
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchainMock.MockConnectionError = func() error { return nil }
+	blockchainMock.MockScriptHashSubscribe = func(func() func(), blockchain.ScriptHashHex, func(string)) {}
+	blockchainMock.MockScriptHashGetHistory = func(
+		scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+		lock.Lock()
+		fetched[scriptHashHex] = true
+		running++
+		maxRunning = max(maxRunning, running)
+		lock.Unlock()
+		time.Sleep(time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return blockchain.TxHistory{}, nil
+	}
+	account := mockAccountWithBlockchain(t, nil, blockchainMock)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	require.NoError(t, account.Rescan(0))
+	require.Eventually(t, account.Synced, time.Second, time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	// 20 receive and 6 change addresses, fetched by a bounded number of workers.
+	require.Len(t, fetched, 26)
+	require.LessOrEqual(t, maxRunning, btc.TstRescanWorkers)
+	require.Greater(t, maxRunning, 1)
+}
+
+func TestVerifyCache(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+
+	xpub := func(seed byte) *hdkeychain.ExtendedKey {
+		key, err := hdkeychain.NewMaster(append(make([]byte, 31), seed), &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		key, err = key.Neuter()
+		require.NoError(t, err)
+		return key
+	}
+	ks := &keystoremock.KeystoreMock{
+		ExtendedPublicKeyFunc: func(coin.Coin, signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+			// Same xpub as in mockAccount().
+			return xpub(0), nil
+		},
+	}
+	require.NoError(t, account.VerifyCache(ks, false))
+	require.NoError(t, account.VerifyCache(ks, true))
+
+	// The persisted xpub does not belong to the keystore.
+	ks.ExtendedPublicKeyFunc = func(coin.Coin, signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+		return xpub(1), nil
+	}
+	require.Equal(t, btc.ErrCacheMismatch, errp.Cause(account.VerifyCache(ks, false)))
+
+	dbFolder := account.Config().DBFolder
+	require.NoError(t, account.QuarantineCache())
+	quarantined, err := filepath.Glob(filepath.Join(dbFolder, "account-accountcode.db.quarantined-*"))
+	require.NoError(t, err)
+	require.Len(t, quarantined, 1)
+	_, err = os.Stat(filepath.Join(dbFolder, "account-accountcode.db"))
+	require.True(t, os.IsNotExist(err))
+	require.Error(t, account.VerifyCache(ks, false))
+}
+
 func TestInsuredAccountAddresses(t *testing.T) {
 	net := &chaincfg.TestNet3Params
 
@@ -169,6 +407,18 @@ func TestInsuredAccountAddresses(t *testing.T) {
 	require.Len(t, account.GetUnusedReceiveAddresses()[1].Addresses, 20)
 	require.Equal(t, signing.ScriptTypeP2WPKH, *account.GetUnusedReceiveAddresses()[1].ScriptType)
 
+	// The addresses of the preferred script type come first.
+	accountPreferred := mockAccount(t, &config.Account{
+		Code:                  "accountcode3",
+		Name:                  "accountname3",
+		SigningConfigurations: signingConfigurations,
+		PreferredScriptType:   signing.ScriptTypeP2WPKH,
+	})
+	require.NoError(t, accountPreferred.Initialize())
+	require.Len(t, accountPreferred.GetUnusedReceiveAddresses(), 2)
+	require.Equal(t, signing.ScriptTypeP2WPKH, *accountPreferred.GetUnusedReceiveAddresses()[0].ScriptType)
+	require.Equal(t, signing.ScriptTypeP2WPKHP2SH, *accountPreferred.GetUnusedReceiveAddresses()[1].ScriptType)
+
 	// Create a new insured account.
 	account2 := mockAccount(t, &config.Account{
 		Code:                  "accountcode2",
@@ -186,6 +436,30 @@ func TestInsuredAccountAddresses(t *testing.T) {
 
 }
 
+func TestPaymentURI(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+	address := account.GetUnusedReceiveAddresses()[0].Addresses[0]
+
+	uri, err := account.PaymentURI(address.ID(), "0.001", "Invoice 42")
+	require.NoError(t, err)
+	require.Equal(t, "bitcoin:"+address.EncodeForHumans()+"?amount=0.001&label=Invoice%2042", uri)
+
+	// The amount is given in the unit selected for display.
+	account.Coin().(*btc.Coin).SetFormatUnit(coin.BtcUnitSats)
+	uri, err = account.PaymentURI(address.ID(), "100000", "")
+	require.NoError(t, err)
+	require.Equal(t, "bitcoin:"+address.EncodeForHumans()+"?amount=0.001", uri)
+
+	for _, invalidAmount := range []string{"abc", "0", "-1", "0.5"} {
+		_, err = account.PaymentURI(address.ID(), invalidAmount, "")
+		require.Equal(t, errors.ErrInvalidAmount, errp.Cause(err), invalidAmount)
+	}
+
+	_, err = account.PaymentURI("unknown", "", "")
+	require.Error(t, err)
+}
+
 func TestSignAddress(t *testing.T) {
 	account := mockAccount(t, nil)
 	require.NoError(t, account.Initialize())
@@ -39,13 +39,17 @@ type AccountAddress struct {
 	// Configuration contains the absolute keypath and the extended public keys of the address.
 	Configuration *signing.Configuration
 
+	// addressType is the validated address type of Configuration.
+	addressType signing.AddressType
 	// redeemScript stores the redeem script of a BIP16 P2SH output or nil if address type is P2PKH.
 	redeemScript []byte
 
 	log *logrus.Entry
 }
 
-// NewAccountAddress creates a new account address.
+// NewAccountAddress creates a new account address at the keypath relative to the account, e.g.
+// 0/5 for a receive and 1/5 for a change address. For taproot, the BIP86 output key is computed from
+// the key derived at the keypath.
 func NewAccountAddress(
 	accountConfiguration *signing.Configuration,
 	keyPath signing.RelativeKeypath,
@@ -65,14 +69,18 @@ func NewAccountAddress(
 	})
 	log.Debug("Creating new account address")
 
+	addressType, err := configuration.AddressType(net)
+	if err != nil {
+		log.WithError(err).Panic("Invalid signing configuration.")
+	}
 	publicKeyHash := btcutil.Hash160(configuration.PublicKey().SerializeCompressed())
-	switch configuration.ScriptType() {
-	case signing.ScriptTypeP2PKH:
+	switch addressType {
+	case signing.AddressTypeP2PKH:
 		address, err = btcutil.NewAddressPubKeyHash(publicKeyHash, net)
 		if err != nil {
 			log.WithError(err).Panic("Failed to get P2PKH addr. from public key hash.")
 		}
-	case signing.ScriptTypeP2WPKHP2SH:
+	case signing.AddressTypeP2WPKHP2SH:
 		var segwitAddress *btcutil.AddressWitnessPubKeyHash
 		segwitAddress, err = btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, net)
 		if err != nil {
@@ -86,25 +94,26 @@ func NewAccountAddress(
 		if err != nil {
 			log.WithError(err).Panic("Failed to get a P2SH address for segwit.")
 		}
-	case signing.ScriptTypeP2WPKH:
+	case signing.AddressTypeP2WPKH:
 		address, err = btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, net)
 		if err != nil {
 			log.WithError(err).Panic("Failed to get p2wpkh addr. from publ. key hash.")
 		}
-	case signing.ScriptTypeP2TR:
+	case signing.AddressTypeP2TR:
 		outputKey := txscript.ComputeTaprootKeyNoScript(configuration.PublicKey())
 		address, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), net)
 		if err != nil {
 			log.WithError(err).Panic("Failed to get p2tr addr")
 		}
 	default:
-		log.Panic(fmt.Sprintf("Unrecognized script type: %s", configuration.ScriptType()))
+		log.Panic(fmt.Sprintf("Unsupported address type: %s", addressType))
 	}
 
 	return &AccountAddress{
 		Address:              address,
 		AccountConfiguration: accountConfiguration,
 		Configuration:        configuration,
+		addressType:          addressType,
 		redeemScript:         redeemScript,
 		log:                  log,
 	}
@@ -112,7 +121,7 @@ func NewAccountAddress(
 
 // ID implements accounts.Address.
 func (address *AccountAddress) ID() string {
-	return string(address.PubkeyScriptHashHex())
+	return address.PubkeyScriptHashHex().String()
 }
 
 // EncodeForHumans implements accounts.Address.
@@ -144,36 +153,49 @@ func (address *AccountAddress) PubkeyScriptHashHex() blockchain.ScriptHashHex {
 // calculating the hash to be signed in a transaction. This info is needed when trying to spend
 // from this address.
 func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
-	switch address.Configuration.ScriptType() {
-	case signing.ScriptTypeP2PKH:
+	switch address.addressType {
+	case signing.AddressTypeP2PKH:
 		return false, address.PubkeyScript()
-	case signing.ScriptTypeP2WPKHP2SH:
+	case signing.AddressTypeP2WPKHP2SH:
 		return true, address.redeemScript
-	case signing.ScriptTypeP2WPKH:
+	case signing.AddressTypeP2WPKH:
 		return true, address.PubkeyScript()
 	default:
-		address.log.Panic("Unrecognized address type.")
+		address.log.Panic(fmt.Sprintf("Unsupported address type: %s", address.addressType))
 	}
 	panic("The end of the function cannot be reached.")
 }
 
+// SigHashType returns the sighash type used to sign an input spending from this address.
+// txscript.SigHashDefault only exists for taproot and is replaced by txscript.SigHashAll for the
+// other script types.
+func (address *AccountAddress) SigHashType(sigHashType txscript.SigHashType) txscript.SigHashType {
+	if sigHashType == txscript.SigHashDefault && address.addressType != signing.AddressTypeP2TR {
+		return txscript.SigHashAll
+	}
+	return sigHashType
+}
+
 // SignatureScript returns the signature script (and witness) needed to spend from this address.
 // The signatures have to be provided in the order of the configuration (and some can be nil).
+// sigHashType must be the sighash type the signature was created with, see SigHashType().
 func (address *AccountAddress) SignatureScript(
 	signature types.Signature,
+	sigHashType txscript.SigHashType,
 ) ([]byte, wire.TxWitness) {
 	publicKey := address.Configuration.PublicKey()
-	switch address.Configuration.ScriptType() {
-	case signing.ScriptTypeP2PKH:
+	sigHashFlag := byte(address.SigHashType(sigHashType))
+	switch address.addressType {
+	case signing.AddressTypeP2PKH:
 		signatureScript, err := txscript.NewScriptBuilder().
-			AddData(append(signature.SerializeDER(), byte(txscript.SigHashAll))).
+			AddData(append(signature.SerializeDER(), sigHashFlag)).
 			AddData(publicKey.SerializeCompressed()).
 			Script()
 		if err != nil {
 			address.log.WithError(err).Panic("Failed to build signature script for P2PKH.")
 		}
 		return signatureScript, nil
-	case signing.ScriptTypeP2WPKHP2SH:
+	case signing.AddressTypeP2WPKHP2SH:
 		signatureScript, err := txscript.NewScriptBuilder().
 			AddData(address.redeemScript).
 			Script()
@@ -181,26 +203,28 @@ func (address *AccountAddress) SignatureScript(
 			address.log.WithError(err).Panic("Failed to build segwit signature script.")
 		}
 		txWitness := wire.TxWitness{
-			append(signature.SerializeDER(), byte(txscript.SigHashAll)),
+			append(signature.SerializeDER(), sigHashFlag),
 			publicKey.SerializeCompressed(),
 		}
 		return signatureScript, txWitness
-	case signing.ScriptTypeP2WPKH:
+	case signing.AddressTypeP2WPKH:
 		txWitness := wire.TxWitness{
-			append(signature.SerializeDER(), byte(txscript.SigHashAll)),
+			append(signature.SerializeDER(), sigHashFlag),
 			publicKey.SerializeCompressed(),
 		}
 		return []byte{}, txWitness
-	case signing.ScriptTypeP2TR:
-		// We assume SIGHASH_DEFAULT, which defaults to SIGHASH_ALL without needing to explicitly
-		// append it to the signature. See:
+	case signing.AddressTypeP2TR:
+		// SIGHASH_DEFAULT is SIGHASH_ALL without explicitly appending it to the signature. Other
+		// sighash types are appended. See:
 		// https://github.com/bitcoin/bips/blob/97e02b2223b21753acefa813a4e59dbb6e849e77/bip-0341.mediawiki#taproot-key-path-spending-signature-validation
-		txWitness := wire.TxWitness{
-			signature.SerializeCompact(),
+		taprootSignature := signature.SerializeCompact()
+		if sigHashType != txscript.SigHashDefault {
+			taprootSignature = append(taprootSignature, sigHashFlag)
 		}
+		txWitness := wire.TxWitness{taprootSignature}
 		return []byte{}, txWitness
 	default:
-		address.log.Panic("Unrecognized address type.")
+		address.log.Panic(fmt.Sprintf("Unsupported address type: %s", address.addressType))
 	}
 	panic("The end of the function cannot be reached.")
 }
@@ -20,12 +20,13 @@ import (
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	testlog "github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -66,8 +67,8 @@ func (s *addressTestSuite) TestPubkeyScript() {
 
 func (s *addressTestSuite) TestScriptHashHex() {
 	s.Require().Equal(
-		blockchain.ScriptHashHex("0466d0029406f583feadaccb91c7b5b855eb5d6782316cafa4f390b7c784436b"),
-		s.address.PubkeyScriptHashHex())
+		"0466d0029406f583feadaccb91c7b5b855eb5d6782316cafa4f390b7c784436b",
+		s.address.PubkeyScriptHashHex().String())
 }
 
 func TestAddressP2TR(t *testing.T) {
@@ -110,3 +111,85 @@ func TestAddressP2TR(t *testing.T) {
 		require.Equal(t, test.expectedAddress, addr.EncodeForHumans())
 	}
 }
+
+// TestAddressP2TRChange checks that taproot change and receive addresses of the same index are
+// derived from different keys (BIP86 change path .../1/*), and that both are spendable with the
+// tweaked private key of their keypath.
+func TestAddressP2TRChange(t *testing.T) {
+	// Test vectors from https://github.com/bitcoin/bips/blob/a3a397c82384220fc871852c809f73898a4d547c/bip-0086.mediawiki#Test_vectors
+	accountXprv, err := hdkeychain.NewKeyFromString("xprv9xgqHN7yz9MwCkxsBPN5qetuNdQSUttZNKw1dcYTV4mkaAFiBVGQziHs3NRSWMkCzvgjEe3n9xV8oYywvM8at9yRqyaZVz6TYYhX98VjsUk")
+	require.NoError(t, err)
+	accountXpub, err := accountXprv.Neuter()
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/86'/0'/0'")
+	require.NoError(t, err)
+	configuration := signing.NewBitcoinConfiguration(
+		signing.ScriptTypeP2TR, []byte{1, 2, 3, 4}, keypath, accountXpub)
+	log := logging.Get().WithGroup("addresses_test")
+	cache := addresses.NewDerivationCache(&chaincfg.MainNetParams, log)
+	used := map[*addresses.AccountAddress]bool{}
+	isAddressUsed := func(address *addresses.AccountAddress) (bool, error) { return used[address], nil }
+	receive := addresses.NewAddressChain(
+		configuration, &chaincfg.MainNetParams, 1, 0, cache, isAddressUsed, log)
+	change := addresses.NewAddressChain(
+		configuration, &chaincfg.MainNetParams, 1, 1, cache, isAddressUsed, log)
+	receiveAddresses, err := receive.EnsureAddresses()
+	require.NoError(t, err)
+	changeAddresses, err := change.EnsureAddresses()
+	require.NoError(t, err)
+	require.Len(t, receiveAddresses, 1)
+	require.Len(t, changeAddresses, 1)
+	receiveAddress, changeAddress := receiveAddresses[0], changeAddresses[0]
+
+	require.Equal(t, "m/86'/0'/0'/0/0", receiveAddress.AbsoluteKeypath().Encode())
+	require.Equal(t, "m/86'/0'/0'/1/0", changeAddress.AbsoluteKeypath().Encode())
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		receiveAddress.EncodeForHumans())
+	require.Equal(t, "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+		changeAddress.EncodeForHumans())
+	require.NotEqual(t, receiveAddress.PubkeyScript(), changeAddress.PubkeyScript())
+
+	// The change chain has its own gap scan: using a receive address does not extend it.
+	used[receiveAddress] = true
+	moreReceiveAddresses, err := receive.EnsureAddresses()
+	require.NoError(t, err)
+	require.Len(t, moreReceiveAddresses, 1)
+	require.Equal(t, "m/86'/0'/0'/0/1", moreReceiveAddresses[0].AbsoluteKeypath().Encode())
+	moreChangeAddresses, err := change.EnsureAddresses()
+	require.NoError(t, err)
+	require.Empty(t, moreChangeAddresses)
+	used[changeAddress] = true
+	moreChangeAddresses, err = change.EnsureAddresses()
+	require.NoError(t, err)
+	require.Len(t, moreChangeAddresses, 1)
+	require.Equal(t, "m/86'/0'/0'/1/1", moreChangeAddresses[0].AbsoluteKeypath().Encode())
+
+	for _, address := range []*addresses.AccountAddress{receiveAddress, changeAddress} {
+		xprv := accountXprv
+		for _, index := range address.AbsoluteKeypath().ToUInt32()[len(keypath.ToUInt32()):] {
+			xprv, err = xprv.Derive(index)
+			require.NoError(t, err)
+		}
+		privateKey, err := xprv.ECPrivKey()
+		require.NoError(t, err)
+
+		const value = 100000
+		prevOut := wire.NewTxOut(value, address.PubkeyScript())
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(value-1000, address.PubkeyScript()))
+		prevOutFetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+		sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+		signature, err := txscript.RawTxInTaprootSignature(
+			tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, nil,
+			address.SigHashType(txscript.SigHashDefault), privateKey)
+		require.NoError(t, err)
+		tx.TxIn[0].Witness = wire.TxWitness{signature}
+
+		engine, err := txscript.NewEngine(
+			prevOut.PkScript, tx, 0, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value,
+			prevOutFetcher)
+		require.NoError(t, err)
+		require.NoError(t, engine.Execute(), address.AbsoluteKeypath().Encode())
+	}
+}
@@ -32,16 +32,19 @@ type AddressChain struct {
 	addresses            []*AccountAddress
 	addressesLookup      map[blockchain.ScriptHashHex]*AccountAddress
 	addressesLock        locker.Locker
+	derivationCache      *DerivationCache
 	isAddressUsed        func(*AccountAddress) (bool, error)
 	log                  *logrus.Entry
 }
 
 // NewAddressChain creates an address chain starting at m/<chainIndex> from the given configuration.
+// The addresses are derived using the given cache, which can be shared by all chains of an account.
 func NewAddressChain(
 	accountConfiguration *signing.Configuration,
 	net *chaincfg.Params,
 	gapLimit int,
 	chainIndex uint32,
+	derivationCache *DerivationCache,
 	isAddressUsed func(*AccountAddress) (bool, error),
 	log *logrus.Entry,
 ) *AddressChain {
@@ -52,6 +55,7 @@ func NewAddressChain(
 		chainIndex:           chainIndex,
 		addresses:            []*AccountAddress{},
 		addressesLookup:      map[blockchain.ScriptHashHex]*AccountAddress{},
+		derivationCache:      derivationCache,
 		isAddressUsed:        isAddressUsed,
 		log: log.WithFields(logrus.Fields{"group": "addresses", "net": net.Name,
 			"gap-limit": gapLimit, "chain-index": chainIndex,
@@ -59,6 +63,20 @@ func NewAddressChain(
 	}
 }
 
+// GapLimit returns the number of unused addresses kept at the end of the chain.
+func (addresses *AddressChain) GapLimit() int {
+	defer addresses.addressesLock.RLock()()
+	return addresses.gapLimit
+}
+
+// SetGapLimit changes the number of unused addresses kept at the end of the chain.
+// EnsureAddresses() must be called afterwards to derive the additional addresses.
+func (addresses *AddressChain) SetGapLimit(gapLimit int) {
+	defer addresses.addressesLock.Lock()()
+	addresses.log.WithField("gap-limit", gapLimit).Info("Gap limit changed")
+	addresses.gapLimit = gapLimit
+}
+
 // GetUnused returns the last `gapLimit` unused addresses. EnsureAddresses() must be called
 // beforehand.
 func (addresses *AddressChain) GetUnused() ([]*AccountAddress, error) {
@@ -73,15 +91,35 @@ func (addresses *AddressChain) GetUnused() ([]*AccountAddress, error) {
 	return addresses.addresses[len(addresses.addresses)-unusedTailCount:], nil
 }
 
+// GetUsed returns the addresses which have a transaction history, in the order of the chain.
+// Handing them out again to receive funds hurts privacy.
+func (addresses *AddressChain) GetUsed() ([]*AccountAddress, error) {
+	defer addresses.addressesLock.RLock()()
+	used := []*AccountAddress{}
+	for _, address := range addresses.addresses {
+		isUsed, err := addresses.isAddressUsed(address)
+		if err != nil {
+			return nil, err
+		}
+		if isUsed {
+			used = append(used, address)
+		}
+	}
+	return used, nil
+}
+
+// IsUsed returns true if the address has a transaction history.
+func (addresses *AddressChain) IsUsed(address *AccountAddress) (bool, error) {
+	return addresses.isAddressUsed(address)
+}
+
 // addAddress appends a new address at the end of the chain.
 func (addresses *AddressChain) addAddress() *AccountAddress {
 	addresses.log.Debug("Add new address to chain")
 	index := uint32(len(addresses.addresses))
-	address := NewAccountAddress(
+	address := addresses.derivationCache.Get(
 		addresses.accountConfiguration,
 		signing.NewEmptyRelativeKeypath().Child(addresses.chainIndex, signing.NonHardened).Child(index, signing.NonHardened),
-		addresses.net,
-		addresses.log,
 	)
 	addresses.addresses = append(addresses.addresses, address)
 	addresses.addressesLookup[address.PubkeyScriptHashHex()] = address
@@ -105,6 +143,12 @@ func (addresses *AddressChain) unusedTailCount() (int, error) {
 	return count, nil
 }
 
+// Addresses returns all addresses of the chain.
+func (addresses *AddressChain) Addresses() []*AccountAddress {
+	defer addresses.addressesLock.RLock()()
+	return append([]*AccountAddress{}, addresses.addresses...)
+}
+
 // LookupByScriptHashHex returns the address which matches the provided scriptHashHex. Returns nil
 // if not found.
 func (addresses *AddressChain) LookupByScriptHashHex(hashHex blockchain.ScriptHashHex) *AccountAddress {
@@ -54,6 +54,7 @@ func (s *addressChainTestSuite) SetupTest() {
 		net,
 		s.gapLimit,
 		s.chainIndex,
+		addresses.NewDerivationCache(net, s.log),
 		func(address *addresses.AccountAddress) (bool, error) {
 			return s.isAddressUsed(address), nil
 		},
@@ -89,6 +90,62 @@ func (s *addressChainTestSuite) TestGetUnused() {
 	s.Require().Equal(newAddresses[1], unusedAddresses[0])
 }
 
+func (s *addressChainTestSuite) TestGetUsed() {
+	s.isAddressUsed = func(*addresses.AccountAddress) bool { return false }
+	newAddresses, err := s.addresses.EnsureAddresses()
+	s.Require().NoError(err)
+	usedAddresses, err := s.addresses.GetUsed()
+	s.Require().NoError(err)
+	s.Require().Empty(usedAddresses)
+
+	secondAddress := newAddresses[1]
+	s.isAddressUsed = func(addr *addresses.AccountAddress) bool {
+		return addr == secondAddress
+	}
+	usedAddresses, err = s.addresses.GetUsed()
+	s.Require().NoError(err)
+	s.Require().Equal([]*addresses.AccountAddress{secondAddress}, usedAddresses)
+	used, err := s.addresses.IsUsed(secondAddress)
+	s.Require().NoError(err)
+	s.Require().True(used)
+	used, err = s.addresses.IsUsed(newAddresses[0])
+	s.Require().NoError(err)
+	s.Require().False(used)
+}
+
+func (s *addressChainTestSuite) TestAllAddressesUsed() {
+	s.isAddressUsed = func(*addresses.AccountAddress) bool { return false }
+	newAddresses, err := s.addresses.EnsureAddresses()
+	s.Require().NoError(err)
+
+	// All derived addresses are used: there is no unused address until new ones are derived.
+	s.isAddressUsed = func(*addresses.AccountAddress) bool { return true }
+	_, err = s.addresses.GetUnused()
+	s.Require().Error(err)
+	usedAddresses, err := s.addresses.GetUsed()
+	s.Require().NoError(err)
+	s.Require().Equal(newAddresses, usedAddresses)
+
+	s.isAddressUsed = func(addr *addresses.AccountAddress) bool {
+		for _, usedAddress := range usedAddresses {
+			if addr == usedAddress {
+				return true
+			}
+		}
+		return false
+	}
+	moreAddresses, err := s.addresses.EnsureAddresses()
+	s.Require().NoError(err)
+	s.Require().Len(moreAddresses, s.gapLimit)
+	unusedAddresses, err := s.addresses.GetUnused()
+	s.Require().NoError(err)
+	s.Require().Equal(moreAddresses, unusedAddresses)
+	s.Require().Equal(uint32(s.gapLimit), unusedAddresses[0].Configuration.AbsoluteKeypath().ToUInt32()[1])
+	usedAddresses, err = s.addresses.GetUsed()
+	s.Require().NoError(err)
+	s.Require().Equal(newAddresses, usedAddresses)
+}
+
 func (s *addressChainTestSuite) TestLookupByScriptHashHex() {
 	s.isAddressUsed = func(*addresses.AccountAddress) bool { return false }
 	newAddresses, err := s.addresses.EnsureAddresses()
@@ -160,3 +217,22 @@ func (s *addressChainTestSuite) TestEnsureAddresses() {
 	s.Require().NoError(err)
 	s.Require().Empty(addrs)
 }
+
+func (s *addressChainTestSuite) TestSetGapLimit() {
+	s.isAddressUsed = func(*addresses.AccountAddress) bool { return false }
+	newAddresses, err := s.addresses.EnsureAddresses()
+	s.Require().NoError(err)
+	s.Require().Equal(s.gapLimit, s.addresses.GapLimit())
+
+	s.addresses.SetGapLimit(s.gapLimit + 4)
+	s.Require().Equal(s.gapLimit+4, s.addresses.GapLimit())
+	// Not synced until the additional addresses are derived.
+	_, err = s.addresses.GetUnused()
+	s.Require().Error(err)
+	moreAddresses, err := s.addresses.EnsureAddresses()
+	s.Require().NoError(err)
+	s.Require().Len(moreAddresses, 4)
+	unusedAddresses, err := s.addresses.GetUnused()
+	s.Require().NoError(err)
+	s.Require().Equal(append(newAddresses, moreAddresses...), unusedAddresses)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addresses
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/sirupsen/logrus"
+)
+
+// derivationCacheEntry holds the addresses derived from one account configuration.
+type derivationCacheEntry struct {
+	// xpub is the extended public key of the account configuration the addresses were derived
+	// from.
+	xpub string
+	// addresses are keyed by the encoded keypath relative to the account configuration.
+	addresses map[string]*AccountAddress
+}
+
+// DerivationCache caches the addresses derived from the account configurations of an account, so
+// that the BIP32 derivation and, for taproot addresses, the computation of the output key is done
+// only once per address.
+type DerivationCache struct {
+	net *chaincfg.Params
+	log *logrus.Entry
+
+	// entries are keyed by the script type and keypath of the account configuration, see
+	// signing.Configuration.String().
+	entries map[string]*derivationCacheEntry
+	lock    locker.Locker
+}
+
+// NewDerivationCache creates an empty derivation cache for addresses of the given network.
+func NewDerivationCache(net *chaincfg.Params, log *logrus.Entry) *DerivationCache {
+	return &DerivationCache{
+		net:     net,
+		log:     log,
+		entries: map[string]*derivationCacheEntry{},
+	}
+}
+
+// Get returns the address at the keypath relative to the account configuration, see
+// NewAccountAddress(). The address is derived on the first call and returned from the cache
+// afterwards. If the extended public key of the account configuration changed, the addresses
+// derived from the previous one are dropped.
+func (cache *DerivationCache) Get(
+	accountConfiguration *signing.Configuration,
+	keyPath signing.RelativeKeypath,
+) *AccountAddress {
+	configurationKey := accountConfiguration.String()
+	xpub := accountConfiguration.ExtendedPublicKey().String()
+	encodedKeyPath := keyPath.Encode()
+
+	defer cache.lock.Lock()()
+	entry, ok := cache.entries[configurationKey]
+	if !ok || entry.xpub != xpub {
+		if ok {
+			cache.log.WithField("configuration", configurationKey).
+				Info("Account configuration changed, dropping the derived addresses")
+		}
+		entry = &derivationCacheEntry{
+			xpub:      xpub,
+			addresses: map[string]*AccountAddress{},
+		}
+		cache.entries[configurationKey] = entry
+	}
+	if address, ok := entry.addresses[encodedKeyPath]; ok {
+		return address
+	}
+	address := NewAccountAddress(accountConfiguration, keyPath, cache.net, cache.log)
+	entry.addresses[encodedKeyPath] = address
+	return address
+}
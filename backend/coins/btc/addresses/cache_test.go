@@ -0,0 +1,82 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package addresses_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/stretchr/testify/require"
+)
+
+func newTaprootConfiguration(t testing.TB, seed byte) *signing.Configuration {
+	t.Helper()
+	xprv, err := hdkeychain.NewMaster(
+		append(make([]byte, hdkeychain.RecommendedSeedLen-1), seed), net)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/86'/1'/0'")
+	require.NoError(t, err)
+	return signing.NewBitcoinConfiguration(signing.ScriptTypeP2TR, []byte{1, 2, 3, 4}, keypath, xpub)
+}
+
+func TestDerivationCache(t *testing.T) {
+	log := logging.Get().WithGroup("addresses_test")
+	cache := addresses.NewDerivationCache(net, log)
+	configuration := newTaprootConfiguration(t, 1)
+	keyPath := signing.NewEmptyRelativeKeypath().Child(0, signing.NonHardened).Child(5, signing.NonHardened)
+
+	address := cache.Get(configuration, keyPath)
+	require.Equal(t,
+		addresses.NewAccountAddress(configuration, keyPath, net, log).EncodeAddress(),
+		address.EncodeAddress())
+	require.Same(t, address, cache.Get(configuration, keyPath))
+
+	otherKeyPath := signing.NewEmptyRelativeKeypath().Child(1, signing.NonHardened).Child(5, signing.NonHardened)
+	require.NotEqual(t, address.EncodeAddress(), cache.Get(configuration, otherKeyPath).EncodeAddress())
+
+	// A configuration with the same keypath but a different xpub invalidates the cached addresses.
+	changedConfiguration := newTaprootConfiguration(t, 2)
+	changedAddress := cache.Get(changedConfiguration, keyPath)
+	require.Equal(t,
+		addresses.NewAccountAddress(changedConfiguration, keyPath, net, log).EncodeAddress(),
+		changedAddress.EncodeAddress())
+	require.NotEqual(t, address.EncodeAddress(), changedAddress.EncodeAddress())
+	require.NotSame(t, address, cache.Get(configuration, keyPath))
+}
+
+func BenchmarkNewAccountAddressTaproot(b *testing.B) {
+	log := logging.Get().WithGroup("addresses_test")
+	configuration := newTaprootConfiguration(b, 1)
+	keyPath := signing.NewEmptyRelativeKeypath().Child(0, signing.NonHardened).Child(0, signing.NonHardened)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		addresses.NewAccountAddress(configuration, keyPath, net, log)
+	}
+}
+
+func BenchmarkDerivationCacheTaproot(b *testing.B) {
+	cache := addresses.NewDerivationCache(net, logging.Get().WithGroup("addresses_test"))
+	configuration := newTaprootConfiguration(b, 1)
+	keyPath := signing.NewEmptyRelativeKeypath().Child(0, signing.NonHardened).Child(0, signing.NonHardened)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Get(configuration, keyPath)
+	}
+}
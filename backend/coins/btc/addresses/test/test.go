@@ -48,7 +48,8 @@ func NewAddressChain(
 	}
 	configuration := signing.NewBitcoinConfiguration(
 		signing.ScriptTypeP2PKH, []byte{1, 2, 3, 4}, derivationPath, xpub)
-	return configuration, addresses.NewAddressChain(configuration, net, 20, 0, isAddressUsed, log)
+	return configuration, addresses.NewAddressChain(
+		configuration, net, 20, 0, addresses.NewDerivationCache(net, log), isAddressUsed, log)
 }
 
 // GetAddress returns a dummy address for a given address type.
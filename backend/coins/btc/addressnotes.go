@@ -0,0 +1,110 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Address notes are labels the user gives to the receive addresses of the account, e.g. the name of
+// the person the address was handed out to. They are stored locally with the notes of the account,
+// keyed by the address ID, see addresses.AccountAddress.ID().
+
+// SetAddressNote sets the note of the receive address with the given ID. An empty note removes the
+// note.
+func (account *Account) SetAddressNote(addressID string, note string) error {
+	address, _, err := account.LookupReceiveAddress(addressID)
+	if err != nil {
+		return err
+	}
+	changed, err := account.Notes().SetAddressNote(address.ID(), note)
+	if err != nil {
+		return err
+	}
+	if changed {
+		// Prompt refresh.
+		account.Config().OnEvent(accountsTypes.EventStatusChanged)
+	}
+	return nil
+}
+
+// AddressNote returns the note of the address with the given ID, or the empty string if there is
+// none.
+func (account *Account) AddressNote(addressID string) string {
+	return account.Notes().AddressNote(addressID)
+}
+
+// lookupReceiveAddressByScriptHashHex returns the receive address with the given script hash, or
+// nil if it is not a receive address of the account.
+func (account *Account) lookupReceiveAddressByScriptHashHex(
+	scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+	for _, subacc := range account.subaccounts {
+		if address := subacc.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+			return address
+		}
+	}
+	return nil
+}
+
+// ExportAddressNotes returns the address notes of the account, keyed by the encoded address, for
+// the BIP-329 export. Notes of addresses which are not known to the account are skipped.
+func (account *Account) ExportAddressNotes() (map[string]string, error) {
+	if err := account.Initialize(); err != nil {
+		return nil, err
+	}
+	account.Synchronizer.WaitSynchronized()
+	result := map[string]string{}
+	for addressID, note := range account.Notes().AddressNotes() {
+		scriptHashHex, err := blockchain.ParseScriptHashHex(addressID)
+		if err != nil {
+			continue
+		}
+		address := account.lookupReceiveAddressByScriptHashHex(scriptHashHex)
+		if address == nil {
+			continue
+		}
+		result[address.EncodeForHumans()] = note
+	}
+	return result, nil
+}
+
+// ImportAddressNote sets the note of a receive address given in its encoded form, as it appears in
+// a BIP-329 import. Returns whether the address belongs to the account, and whether its note
+// changed. See WaitSynchronizedTimeout().
+func (account *Account) ImportAddressNote(encodedAddress string, note string) (bool, bool, error) {
+	if err := account.Initialize(); err != nil {
+		return false, false, err
+	}
+	address, err := account.coin.DecodeAddress(encodedAddress)
+	if err != nil {
+		return false, false, nil
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return false, false, nil
+	}
+	accountAddress := account.lookupReceiveAddressByScriptHashHex(blockchain.NewScriptHashHex(pkScript))
+	if accountAddress == nil {
+		return false, false, nil
+	}
+	changed, err := account.Notes().SetAddressNote(accountAddress.ID(), note)
+	if err != nil {
+		return true, false, err
+	}
+	return true, changed, nil
+}
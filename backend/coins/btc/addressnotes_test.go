@@ -0,0 +1,72 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressNotes(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	receiveAddresses := account.GetUnusedReceiveAddresses()[0].Addresses
+	address1, address2 := receiveAddresses[0], receiveAddresses[1]
+	require.Equal(t, "", account.AddressNote(address1.ID()))
+
+	require.NoError(t, account.SetAddressNote(address1.ID(), "from exchange"))
+	require.Equal(t, "from exchange", account.AddressNote(address1.ID()))
+
+	// Only receive addresses of the account can be labeled.
+	require.Error(t, account.SetAddressNote("not-an-address-id", "note"))
+	require.Error(t, account.SetAddressNote(
+		"0000000000000000000000000000000000000000000000000000000000000000", "note"))
+
+	exported, err := account.ExportAddressNotes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{address1.EncodeForHumans(): "from exchange"}, exported)
+
+	found, changed, err := account.ImportAddressNote(address2.EncodeForHumans(), "donations")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, changed)
+	require.Equal(t, "donations", account.AddressNote(address2.ID()))
+
+	found, changed, err = account.ImportAddressNote(address2.EncodeForHumans(), "donations")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, changed)
+
+	// Addresses which do not belong to the account are skipped.
+	for _, address := range []string{
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		// Mainnet address in a testnet account.
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"not-an-address",
+	} {
+		found, changed, err := account.ImportAddressNote(address, "note")
+		require.NoError(t, err)
+		require.False(t, found, address)
+		require.False(t, changed, address)
+	}
+
+	require.NoError(t, account.SetAddressNote(address1.ID(), ""))
+	exported, err = account.ExportAddressNotes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{address2.EncodeForHumans(): "donations"}, exported)
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// canSpendUnconfirmedChange returns true if the outputs of our own unconfirmed transactions, e.g.
+// change, can be spent according to the account settings, see hasMinConfirmations().
+func (account *Account) canSpendUnconfirmedChange() bool {
+	accountConfig := account.Config().Config
+	return accountConfig.MinConfirmations == 0 || accountConfig.SpendUnconfirmedChange
+}
+
+// pendingChangeBalance returns the sum of the unconfirmed outputs of our own transactions which
+// can't be spent until they confirm. Frozen outputs are not included, as they are part of the
+// frozen balance, nor is hidden dust.
+func (account *Account) pendingChangeBalance() (coin.Amount, error) {
+	if account.canSpendUnconfirmedChange() {
+		return coin.NewAmountFromInt64(0), nil
+	}
+	utxos, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return coin.Amount{}, err
+	}
+	var pendingChange int64
+	for outPoint, txOut := range utxos {
+		if txOut.OwnInputs && txOut.Confirmations == 0 && !account.isFrozen(outPoint) &&
+			!account.isHiddenDust(txOut) {
+			pendingChange += txOut.Value
+		}
+	}
+	return coin.NewAmountFromInt64(pendingChange), nil
+}
+
+// isUnverified returns true if the output is from a confirmed transaction paying to the account
+// which was not verified against the block headers yet, and the account requires verification, see
+// config.Account.RequireVerifiedTxs. Outputs of our own transactions can't be faked by the server
+// and are never unverified. The other spendable outputs are all confirmed, even if the headers are
+// not synced to their height yet.
+func (account *Account) isUnverified(output *transactions.SpendableOutput) bool {
+	return account.Config().Config.RequireVerifiedTxs && !output.Verified && !output.OwnInputs
+}
+
+// unverifiedBalance returns the sum of the unverified outputs, see isUnverified(). Frozen outputs
+// are not included, as they are part of the frozen balance, nor is hidden dust.
+func (account *Account) unverifiedBalance() (coin.Amount, error) {
+	if !account.Config().Config.RequireVerifiedTxs {
+		return coin.NewAmountFromInt64(0), nil
+	}
+	utxos, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return coin.Amount{}, err
+	}
+	var unverified int64
+	for outPoint, txOut := range utxos {
+		if account.isUnverified(txOut) && !account.isFrozen(outPoint) && !account.isHiddenDust(txOut) {
+			unverified += txOut.Value
+		}
+	}
+	return coin.NewAmountFromInt64(unverified), nil
+}
+
+// onTxVerificationChanged is called when a tx was verified against the block headers or marked
+// unverified after a reorg. The balance is checked again, as it depends on it if the account
+// requires verified transactions.
+func (account *Account) onTxVerificationChanged(chainhash.Hash) {
+	if account.Config().Config.RequireVerifiedTxs {
+		account.scheduleBalanceCheck()
+	}
+}
+
+// scheduleBalanceCheck checks the balance once the account is synced, and fires
+// EventStatusChanged if any of its components changed since the last check, so that the balance is
+// refreshed. Calls made while a check is pending are merged into it.
+func (account *Account) scheduleBalanceCheck() {
+	if !account.balanceCheckPending.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		account.Synchronizer.WaitSynchronized()
+		account.balanceCheckPending.Store(false)
+		if account.isClosed() || account.fatalError.Load() {
+			return
+		}
+		balance, err := account.Balance()
+		if err != nil {
+			account.log.WithError(err).Error("Could not check the balance")
+			return
+		}
+		if account.setLastBalance(balance) {
+			account.Config().OnEvent(accountsTypes.EventStatusChanged)
+		}
+	}()
+}
+
+// setLastBalance stores the balance of the last check and returns true if it differs from the
+// previous one.
+func (account *Account) setLastBalance(balance *accounts.Balance) bool {
+	defer account.lastBalanceLock.Lock()()
+	changed := account.lastBalance == nil || !account.lastBalance.Equal(balance)
+	account.lastBalance = balance
+	return changed
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fundedAccount returns an initialized account funded with a confirmed payment, which is spent
+// with change to the account by the returned unconfirmed tx, another confirmed payment and an
+// unconfirmed incoming payment. configure is called before the account is initialized. The
+// confirmed payments are not verified, as the headers are not synced.
+func fundedAccount(t *testing.T, configure func(*btc.Account)) (*btc.Account, *wire.MsgTx) {
+	t.Helper()
+	var lock sync.Mutex
+	subscribed := []blockchain.ScriptHashHex{}
+	onStatus := map[blockchain.ScriptHashHex]func(string){}
+	histories := map[blockchain.ScriptHashHex]blockchain.TxHistory{}
+	txs := map[chainhash.Hash]*wire.MsgTx{}
+	mock := &blockchainMock.BlockchainMock{
+		MockRegisterOnConnectionErrorChangedEvent: func(func(error)) {},
+		MockConnectionError:                       func() error { return nil },
+		MockRelayFee:                              func() (btcutil.Amount, error) { return 1000, nil },
+		MockScriptHashSubscribe: func(
+			setupAndTeardown func() func(), scriptHashHex blockchain.ScriptHashHex, success func(string)) {
+			lock.Lock()
+			defer lock.Unlock()
+			subscribed = append(subscribed, scriptHashHex)
+			onStatus[scriptHashHex] = success
+		},
+		MockScriptHashGetHistory: func(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return histories[scriptHashHex], nil
+		},
+		MockTransactionGet: func(txHash chainhash.Hash) (*wire.MsgTx, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return txs[txHash], nil
+		},
+	}
+	account := mockAccountWithBlockchain(t, nil, mock)
+	notifier := &accountsMocks.Notifier{}
+	notifier.On("Put", testifyMock.Anything).Return(nil)
+	account.Config().GetNotifier = func(signing.Configurations) accounts.Notifier { return notifier }
+	configure(account)
+	require.NoError(t, account.Initialize())
+	t.Cleanup(account.Close)
+
+	lock.Lock()
+	defer lock.Unlock()
+	// 20 receive and 6 change addresses.
+	require.Len(t, subscribed, 26)
+	receiveAddress := account.TstGetAddress(subscribed[0])
+	receiveAddress2 := account.TstGetAddress(subscribed[1])
+	receiveAddress3 := account.TstGetAddress(subscribed[2])
+	changeAddress := account.TstGetAddress(subscribed[20])
+
+	received := wire.NewMsgTx(wire.TxVersion)
+	received.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("funding"))}, nil, nil))
+	received.AddTxOut(wire.NewTxOut(100000, receiveAddress.PubkeyScript()))
+	receivedHash := received.TxHash()
+	// Pays a fee of 1000 sat.
+	sent := wire.NewMsgTx(wire.TxVersion)
+	sent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&receivedHash, 0), nil, nil))
+	sent.AddTxOut(wire.NewTxOut(30000, []byte{0x51}))
+	sent.AddTxOut(wire.NewTxOut(69000, changeAddress.PubkeyScript()))
+	incomingTx := wire.NewMsgTx(wire.TxVersion)
+	incomingTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("incoming"))}, nil, nil))
+	incomingTx.AddTxOut(wire.NewTxOut(5000, receiveAddress2.PubkeyScript()))
+	received2 := wire.NewMsgTx(wire.TxVersion)
+	received2.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("funding2"))}, nil, nil))
+	received2.AddTxOut(wire.NewTxOut(2000, receiveAddress3.PubkeyScript()))
+	for _, tx := range []*wire.MsgTx{received, sent, incomingTx, received2} {
+		txs[tx.TxHash()] = tx
+	}
+	histories[receiveAddress.PubkeyScriptHashHex()] = blockchain.TxHistory{
+		{Height: 10, TXHash: blockchain.TXHash(received.TxHash())},
+		{Height: 0, TXHash: blockchain.TXHash(sent.TxHash())},
+	}
+	histories[changeAddress.PubkeyScriptHashHex()] = blockchain.TxHistory{
+		{Height: 0, TXHash: blockchain.TXHash(sent.TxHash())},
+	}
+	histories[receiveAddress2.PubkeyScriptHashHex()] = blockchain.TxHistory{
+		{Height: 0, TXHash: blockchain.TXHash(incomingTx.TxHash())},
+	}
+	histories[receiveAddress3.PubkeyScriptHashHex()] = blockchain.TxHistory{
+		{Height: 11, TXHash: blockchain.TXHash(received2.TxHash())},
+	}
+	for scriptHashHex, history := range histories {
+		onStatus[scriptHashHex](history.Status())
+	}
+	return account, sent
+}
+
+// requireBalance checks the balance of a funded account, see fundedAccount().
+func requireBalance(
+	t *testing.T, minConfirmations int, spendUnconfirmedChange bool, requireVerifiedTxs bool,
+	available, incoming, pendingChange int64) {
+	t.Helper()
+	var statusChanged atomic.Int32
+	account, _ := fundedAccount(t, func(account *btc.Account) {
+		account.Config().Config.MinConfirmations = minConfirmations
+		account.Config().Config.SpendUnconfirmedChange = spendUnconfirmedChange
+		account.Config().Config.RequireVerifiedTxs = requireVerifiedTxs
+		account.Config().OnEvent = func(event accountsTypes.Event) {
+			if event == accountsTypes.EventStatusChanged {
+				statusChanged.Add(1)
+			}
+		}
+	})
+
+	require.Eventually(t, func() bool {
+		balance, err := account.Balance()
+		require.NoError(t, err)
+		return balance.Available().BigInt().Int64() == available &&
+			balance.Incoming().BigInt().Int64() == incoming &&
+			balance.PendingChange().BigInt().Int64() == pendingChange &&
+			(requireVerifiedTxs || balance.Unverified().BigInt().Sign() == 0) &&
+			balance.Owned().BigInt().Int64() == available+pendingChange
+	}, 5*time.Second, 10*time.Millisecond)
+	// Fired once after the initial sync, and again when the balance changed.
+	require.Eventually(t, func() bool { return statusChanged.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestBalancePendingChange(t *testing.T) {
+	// By default, our own unconfirmed change can be spent.
+	requireBalance(t, 0, false, false, 71000, 5000, 0)
+	// It is pending if outputs need confirmations to be spent.
+	requireBalance(t, 1, false, false, 2000, 5000, 69000)
+	// Unless spending unconfirmed change is allowed explicitly.
+	requireBalance(t, 1, true, false, 71000, 5000, 0)
+}
+
+func TestBalanceUnverified(t *testing.T) {
+	// The unverified confirmed payment is incoming, our own change is not affected.
+	requireBalance(t, 0, false, true, 69000, 7000, 0)
+	requireBalance(t, 1, false, true, 0, 7000, 69000)
+}
@@ -16,9 +16,12 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
@@ -54,17 +57,68 @@ func (txHash *TXHash) UnmarshalJSON(jsonBytes []byte) error {
 	return nil
 }
 
+// TxStatus is the confirmation status of a transaction.
+type TxStatus int
+
+const (
+	// TxStatusConfirmed means the transaction is included in a block.
+	TxStatusConfirmed TxStatus = iota
+	// TxStatusMempool means the transaction is unconfirmed and all its parents are confirmed.
+	TxStatusMempool
+	// TxStatusMempoolUnconfirmedParents means the transaction is unconfirmed and spends an output of
+	// an unconfirmed transaction.
+	TxStatusMempoolUnconfirmedParents
+)
+
+// The heights of unconfirmed transactions, as encoded by the Electrum protocol.
+const (
+	heightMempool                   = 0
+	heightMempoolUnconfirmedParents = -1
+)
+
+// NormalizeTxHeight converts a tx height as encoded by the Electrum protocol to the confirmation
+// status of the tx and the height of the block containing it, which is 0 if the tx is unconfirmed.
+// The height is expected to be valid, see TxHistory.Validate().
+func NormalizeTxHeight(height int) (TxStatus, int) {
+	switch {
+	case height > 0:
+		return TxStatusConfirmed, height
+	case height == heightMempoolUnconfirmedParents:
+		return TxStatusMempoolUnconfirmedParents, 0
+	default:
+		return TxStatusMempool, 0
+	}
+}
+
 // TxInfo is returned by ScriptHashGetHistory.
 type TxInfo struct {
 	// >0 for a confirmed transaction. 0 for an unconfirmed transaction. -1 for an unconfirmed
-	// transaction with an unconfirmed parent transaction.
+	// transaction with an unconfirmed parent transaction. The raw value is kept, as it is part of
+	// the history status. Use Status() to get the normalized status.
 	Height int    `json:"height"`
 	TXHash TXHash `json:"tx_hash"`
 }
 
+// Status returns the confirmation status of the tx and the height of the block containing it,
+// which is 0 if the tx is unconfirmed.
+func (txInfo *TxInfo) Status() (TxStatus, int) {
+	return NormalizeTxHeight(txInfo.Height)
+}
+
 // TxHistory is returned by ScriptHashGetHistory.
 type TxHistory []*TxInfo
 
+// Validate returns an error if the history contains a height which is not allowed by the Electrum
+// protocol. It should be called on all histories returned by a server.
+func (history TxHistory) Validate() error {
+	for _, tx := range history {
+		if tx.Height < heightMempoolUnconfirmedParents {
+			return errp.Newf("invalid height %d of tx %s", tx.Height, tx.TXHash.Hash())
+		}
+	}
+	return nil
+}
+
 // Status encodes the status of the address history as a hash, according to the Electrum
 // specification.
 // https://github.com/kyuupichan/electrumx/blob/b01139bb93a7b0cfbd45b64e170223f4871a4a87/docs/PROTOCOL.rst#blockchainaddresssubscribe
@@ -79,12 +133,66 @@ func (history TxHistory) Status() string {
 	return hex.EncodeToString(chainhash.HashB(status.Bytes()))
 }
 
-// ScriptHashHex is the hash of a pkScript in reverse hex format. Always 64 chars.
-type ScriptHashHex string
+// ScriptHashHex is the hash of a pkScript, as used by the Electrum protocol to identify addresses.
+// It is encoded as 64 chars of byte-reversed hex, see String(). It can only be created with
+// NewScriptHashHex() or ParseScriptHashHex(), so two values referring to the same pkScript always
+// compare equal and can be used as map keys.
+type ScriptHashHex struct {
+	hash chainhash.Hash
+}
 
 // NewScriptHashHex creates the hash of a pubkeyScript.
 func NewScriptHashHex(pkScript []byte) ScriptHashHex {
-	return ScriptHashHex(chainhash.HashH(pkScript).String())
+	return ScriptHashHex{hash: chainhash.HashH(pkScript)}
+}
+
+// ParseScriptHashHex parses a script hash in the format returned by String(), i.e. 64 chars of
+// lowercase, byte-reversed hex. It is also used to convert the script hashes persisted as strings.
+func ParseScriptHashHex(scriptHashHex string) (ScriptHashHex, error) {
+	if len(scriptHashHex) != 2*chainhash.HashSize {
+		return ScriptHashHex{}, errp.Newf("invalid script hash length %d", len(scriptHashHex))
+	}
+	for _, char := range scriptHashHex {
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') {
+			return ScriptHashHex{}, errp.Newf("invalid script hash char %q", char)
+		}
+	}
+	hash, err := chainhash.NewHashFromStr(scriptHashHex)
+	if err != nil {
+		return ScriptHashHex{}, errp.WithStack(err)
+	}
+	return ScriptHashHex{hash: *hash}, nil
+}
+
+// Hash returns the sha256 hash of the pkScript.
+func (scriptHashHex ScriptHashHex) Hash() chainhash.Hash {
+	return scriptHashHex.hash
+}
+
+// String returns the byte-reversed hex encoding of the script hash.
+func (scriptHashHex ScriptHashHex) String() string {
+	return scriptHashHex.hash.String()
+}
+
+// IsZero returns true if the script hash was not created with NewScriptHashHex() or
+// ParseScriptHashHex().
+func (scriptHashHex ScriptHashHex) IsZero() bool {
+	return scriptHashHex == ScriptHashHex{}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (scriptHashHex ScriptHashHex) MarshalText() ([]byte, error) {
+	return []byte(scriptHashHex.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (scriptHashHex *ScriptHashHex) UnmarshalText(text []byte) error {
+	parsed, err := ParseScriptHashHex(string(text))
+	if err != nil {
+		return err
+	}
+	*scriptHashHex = parsed
+	return nil
 }
 
 // HeadersResult is returned by Headers().
@@ -93,6 +201,9 @@ type HeadersResult struct {
 	Headers []*wire.BlockHeader
 	// Max is the maximum number of headers the server will return in a single request.
 	Max int
+	// ReportInvalid, if not nil, marks the server which returned these headers as bad and fails
+	// over to another server. It is called if the headers fail validation.
+	ReportInvalid func(error)
 }
 
 // GetMerkleResult is returned by GetMerkle().
@@ -101,20 +212,123 @@ type GetMerkleResult struct {
 	Pos    int
 }
 
+// ServerInfo describes the server a blockchain backend is connected to.
+type ServerInfo struct {
+	// Server is the address of the server.
+	Server string `json:"server"`
+	// Software is the server software and version as reported by the server.
+	Software string `json:"software"`
+	// ProtocolVersion is the protocol version agreed with the server.
+	ProtocolVersion string `json:"protocolVersion"`
+	// AddressFamily is "ipv4" or "ipv6", depending on the address of the server which was
+	// connected to. Empty if unknown, e.g. when connected via a proxy.
+	AddressFamily string `json:"addressFamily"`
+	// Banner is the message of the server operator, which may be empty.
+	Banner string `json:"banner"`
+}
+
+// ServerInfoProvider is implemented by blockchain backends which can describe the server they are
+// connected to.
+type ServerInfoProvider interface {
+	ServerInfo() (*ServerInfo, error)
+}
+
+// ErrRequestTimeout is wrapped by the errors of requests which timed out.
+var ErrRequestTimeout = errors.New("request timed out")
+
+// ConnectionState is the state of the connection to the blockchain backend.
+type ConnectionState string
+
+const (
+	// ConnectionStateConnecting means that a connection is being established, initially or after
+	// waiting to retry.
+	ConnectionStateConnecting ConnectionState = "connecting"
+	// ConnectionStateConnected means that a server is connected.
+	ConnectionStateConnected ConnectionState = "connected"
+	// ConnectionStateFailover means that the previous server failed or disconnected and the next
+	// server is being tried.
+	ConnectionStateFailover ConnectionState = "failover"
+	// ConnectionStateDisconnected means that all servers failed and the backend is waiting to retry,
+	// or that a backend without failover could not be reached.
+	ConnectionStateDisconnected ConnectionState = "disconnected"
+)
+
+// ConnectionStatus describes the connection to the blockchain backend.
+type ConnectionStatus struct {
+	State ConnectionState `json:"state"`
+	// Server is the server which is connected or being connected to. Empty if unknown.
+	Server string `json:"server"`
+	// Error is the error of the last failed connection attempt, if any.
+	Error *string `json:"error"`
+	// NextAttempt is the time of the next connection attempt if disconnected and waiting to retry.
+	NextAttempt *time.Time `json:"nextAttempt"`
+}
+
+// ConnectionStatusProvider is implemented by blockchain backends which report the state of their
+// connection in more detail than ConnectionError().
+type ConnectionStatusProvider interface {
+	ConnectionStatus() ConnectionStatus
+	// RegisterOnConnectionStatusChangedEvent registers a callback which is called on every change
+	// of the connection status.
+	RegisterOnConnectionStatusChangedEvent(func(ConnectionStatus))
+}
+
+// RetryNower is implemented by blockchain backends which wait between reconnection attempts, and
+// which can be told to stop waiting, e.g. when the network connectivity was restored.
+type RetryNower interface {
+	RetryNow()
+}
+
+// RetryError is the connection error while all servers are unreachable and the backend waits
+// before the next connection attempt.
+type RetryError struct {
+	// Err is the error of the last failed connection attempt.
+	Err error
+	// NextAttempt is the time of the next connection attempt.
+	NextAttempt time.Time
+}
+
+func (err *RetryError) Error() string {
+	return fmt.Sprintf("%v (waiting to retry, next attempt in %ds)",
+		err.Err, int(time.Until(err.NextAttempt).Round(time.Second).Seconds()))
+}
+
+// Unwrap returns the error of the last failed connection attempt.
+func (err *RetryError) Unwrap() error {
+	return err.Err
+}
+
 // Interface is the interface to a blockchain index backend. Currently geared to Electrum, though
 // other backends can implement the same interface.
 //
+// The requests take a context which cancels them, e.g. when the account which made them is closed.
+// Independently of the context, requests time out after the request timeout of the backend and
+// fail with an error wrapping ErrRequestTimeout.
+//
 //go:generate mockery --name Interface
 type Interface interface {
-	ScriptHashGetHistory(ScriptHashHex) (TxHistory, error)
-	TransactionGet(chainhash.Hash) (*wire.MsgTx, error)
-	ScriptHashSubscribe(func() func(), ScriptHashHex, func(string))
+	ScriptHashGetHistory(context.Context, ScriptHashHex) (TxHistory, error)
+	TransactionGet(context.Context, chainhash.Hash) (*wire.MsgTx, error)
+	// ScriptHashSubscribe subscribes to the status of the script hash. The subscription is not
+	// subject to the request timeout. It ends when the context is done, after which the callback
+	// is not called anymore and the script hash is not subscribed to on new connections.
+	ScriptHashSubscribe(context.Context, func() func(), ScriptHashHex, func(string))
+	// ScriptHashSubscriptions returns the state of the subscriptions on the active connection: the
+	// script hashes for which the server acknowledged the subscription, and the script hashes whose
+	// subscription request did not complete yet.
+	ScriptHashSubscriptions() (subscribed []ScriptHashHex, pending []ScriptHashHex)
+	// ScriptHashResubscribe sends the subscription request for a script hash subscribed to with
+	// ScriptHashSubscribe again on the active connection, e.g. if the server dropped it. No new
+	// subscription is registered: notifications keep going to the callback of
+	// ScriptHashSubscribe, and the callback passed here is called at most once with the status.
+	ScriptHashResubscribe(context.Context, func() func(), ScriptHashHex, func(string))
+	// HeadersSubscribe subscribes to new block headers for the lifetime of the backend.
 	HeadersSubscribe(func(*types.Header))
-	TransactionBroadcast(*wire.MsgTx) error
-	RelayFee() (btcutil.Amount, error)
-	EstimateFee(int) (btcutil.Amount, error)
-	Headers(int, int) (*HeadersResult, error)
-	GetMerkle(chainhash.Hash, int) (*GetMerkleResult, error)
+	TransactionBroadcast(context.Context, *wire.MsgTx) error
+	RelayFee(context.Context) (btcutil.Amount, error)
+	EstimateFee(context.Context, int) (btcutil.Amount, error)
+	Headers(context.Context, int, int) (*HeadersResult, error)
+	GetMerkle(context.Context, chainhash.Hash, int) (*GetMerkleResult, error)
 	Close()
 	ConnectionError() error
 	RegisterOnConnectionErrorChangedEvent(func(error))
@@ -15,7 +15,11 @@
 package blockchain
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/stretchr/testify/require"
@@ -44,3 +48,73 @@ func TestStatus(t *testing.T) {
 		"9783fa8a2f1c89652022e0bb435f302ee8b856961dd979ee083435c65384f314",
 		history.Status())
 }
+
+func TestTxInfoStatus(t *testing.T) {
+	tests := []struct {
+		height         int
+		expectedStatus TxStatus
+		expectedHeight int
+	}{
+		{height: 10, expectedStatus: TxStatusConfirmed, expectedHeight: 10},
+		{height: 0, expectedStatus: TxStatusMempool, expectedHeight: 0},
+		{height: -1, expectedStatus: TxStatusMempoolUnconfirmedParents, expectedHeight: 0},
+	}
+	for _, test := range tests {
+		status, height := (&TxInfo{Height: test.height}).Status()
+		require.Equal(t, test.expectedStatus, status, "height %d", test.height)
+		require.Equal(t, test.expectedHeight, height, "height %d", test.height)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	history := TxHistory{
+		{Height: 10, TXHash: TXHash(chainhash.HashH([]byte("tx1")))},
+		{Height: 0, TXHash: TXHash(chainhash.HashH([]byte("tx2")))},
+		{Height: -1, TXHash: TXHash(chainhash.HashH([]byte("tx3")))},
+	}
+	require.NoError(t, history.Validate())
+	history = append(history, &TxInfo{Height: -2, TXHash: TXHash(chainhash.HashH([]byte("tx4")))})
+	require.Error(t, history.Validate())
+}
+
+func TestScriptHashHex(t *testing.T) {
+	pkScript := []byte{0x51}
+	scriptHashHex := NewScriptHashHex(pkScript)
+	require.False(t, scriptHashHex.IsZero())
+	require.True(t, ScriptHashHex{}.IsZero())
+	require.Equal(t, chainhash.HashH(pkScript), scriptHashHex.Hash())
+	require.Equal(t, chainhash.HashH(pkScript).String(), scriptHashHex.String())
+
+	parsed, err := ParseScriptHashHex(scriptHashHex.String())
+	require.NoError(t, err)
+	require.Equal(t, scriptHashHex, parsed)
+
+	for _, invalid := range []string{
+		"",
+		"abc",
+		// Uppercase.
+		strings.ToUpper(scriptHashHex.String()),
+		// Too long.
+		scriptHashHex.String() + "00",
+		// Not hex.
+		strings.Repeat("g", 64),
+	} {
+		_, err := ParseScriptHashHex(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	jsonBytes, err := json.Marshal(map[ScriptHashHex]int{scriptHashHex: 1})
+	require.NoError(t, err)
+	require.Equal(t, `{"`+scriptHashHex.String()+`":1}`, string(jsonBytes))
+	var decoded []ScriptHashHex
+	require.NoError(t, json.Unmarshal([]byte(`["`+scriptHashHex.String()+`"]`), &decoded))
+	require.Equal(t, []ScriptHashHex{scriptHashHex}, decoded)
+	require.Error(t, json.Unmarshal([]byte(`["invalid"]`), &decoded))
+}
+
+func TestRetryError(t *testing.T) {
+	connErr := errors.New("connection refused")
+	err := &RetryError{Err: connErr, NextAttempt: time.Now().Add(8*time.Second + 100*time.Millisecond)}
+	require.Equal(t, "connection refused (waiting to retry, next attempt in 8s)", err.Error())
+	require.ErrorIs(t, err, connErr)
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	btcutil "github.com/btcsuite/btcd/btcutil"
 	blockchain "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 
@@ -41,20 +43,20 @@ func (_m *Interface) ConnectionError() error {
 	return r0
 }
 
-// EstimateFee provides a mock function with given fields: _a0
-func (_m *Interface) EstimateFee(_a0 int) (btcutil.Amount, error) {
-	ret := _m.Called(_a0)
+// EstimateFee provides a mock function with given fields: _a0, _a1
+func (_m *Interface) EstimateFee(_a0 context.Context, _a1 int) (btcutil.Amount, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 btcutil.Amount
-	if rf, ok := ret.Get(0).(func(int) btcutil.Amount); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) btcutil.Amount); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Get(0).(btcutil.Amount)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -62,13 +64,13 @@ func (_m *Interface) EstimateFee(_a0 int) (btcutil.Amount, error) {
 	return r0, r1
 }
 
-// GetMerkle provides a mock function with given fields: _a0, _a1
-func (_m *Interface) GetMerkle(_a0 chainhash.Hash, _a1 int) (*blockchain.GetMerkleResult, error) {
-	ret := _m.Called(_a0, _a1)
+// GetMerkle provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Interface) GetMerkle(_a0 context.Context, _a1 chainhash.Hash, _a2 int) (*blockchain.GetMerkleResult, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 *blockchain.GetMerkleResult
-	if rf, ok := ret.Get(0).(func(chainhash.Hash, int) *blockchain.GetMerkleResult); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, chainhash.Hash, int) *blockchain.GetMerkleResult); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*blockchain.GetMerkleResult)
@@ -76,8 +78,8 @@ func (_m *Interface) GetMerkle(_a0 chainhash.Hash, _a1 int) (*blockchain.GetMerk
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(chainhash.Hash, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, chainhash.Hash, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -85,13 +87,13 @@ func (_m *Interface) GetMerkle(_a0 chainhash.Hash, _a1 int) (*blockchain.GetMerk
 	return r0, r1
 }
 
-// Headers provides a mock function with given fields: _a0, _a1
-func (_m *Interface) Headers(_a0 int, _a1 int) (*blockchain.HeadersResult, error) {
-	ret := _m.Called(_a0, _a1)
+// Headers provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Interface) Headers(_a0 context.Context, _a1 int, _a2 int) (*blockchain.HeadersResult, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 *blockchain.HeadersResult
-	if rf, ok := ret.Get(0).(func(int, int) *blockchain.HeadersResult); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *blockchain.HeadersResult); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*blockchain.HeadersResult)
@@ -99,8 +101,8 @@ func (_m *Interface) Headers(_a0 int, _a1 int) (*blockchain.HeadersResult, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,20 +120,20 @@ func (_m *Interface) RegisterOnConnectionErrorChangedEvent(_a0 func(error)) {
 	_m.Called(_a0)
 }
 
-// RelayFee provides a mock function with given fields:
-func (_m *Interface) RelayFee() (btcutil.Amount, error) {
-	ret := _m.Called()
+// RelayFee provides a mock function with given fields: _a0
+func (_m *Interface) RelayFee(_a0 context.Context) (btcutil.Amount, error) {
+	ret := _m.Called(_a0)
 
 	var r0 btcutil.Amount
-	if rf, ok := ret.Get(0).(func() btcutil.Amount); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) btcutil.Amount); ok {
+		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(btcutil.Amount)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -139,13 +141,13 @@ func (_m *Interface) RelayFee() (btcutil.Amount, error) {
 	return r0, r1
 }
 
-// ScriptHashGetHistory provides a mock function with given fields: _a0
-func (_m *Interface) ScriptHashGetHistory(_a0 blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
-	ret := _m.Called(_a0)
+// ScriptHashGetHistory provides a mock function with given fields: _a0, _a1
+func (_m *Interface) ScriptHashGetHistory(_a0 context.Context, _a1 blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 blockchain.TxHistory
-	if rf, ok := ret.Get(0).(func(blockchain.ScriptHashHex) blockchain.TxHistory); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, blockchain.ScriptHashHex) blockchain.TxHistory); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(blockchain.TxHistory)
@@ -153,8 +155,8 @@ func (_m *Interface) ScriptHashGetHistory(_a0 blockchain.ScriptHashHex) (blockch
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(blockchain.ScriptHashHex) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, blockchain.ScriptHashHex) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -162,18 +164,48 @@ func (_m *Interface) ScriptHashGetHistory(_a0 blockchain.ScriptHashHex) (blockch
 	return r0, r1
 }
 
-// ScriptHashSubscribe provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Interface) ScriptHashSubscribe(_a0 func() func(), _a1 blockchain.ScriptHashHex, _a2 func(string)) {
-	_m.Called(_a0, _a1, _a2)
+// ScriptHashSubscribe provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Interface) ScriptHashSubscribe(_a0 context.Context, _a1 func() func(), _a2 blockchain.ScriptHashHex, _a3 func(string)) {
+	_m.Called(_a0, _a1, _a2, _a3)
 }
 
-// TransactionBroadcast provides a mock function with given fields: _a0
-func (_m *Interface) TransactionBroadcast(_a0 *wire.MsgTx) error {
-	ret := _m.Called(_a0)
+// ScriptHashResubscribe provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Interface) ScriptHashResubscribe(_a0 context.Context, _a1 func() func(), _a2 blockchain.ScriptHashHex, _a3 func(string)) {
+	_m.Called(_a0, _a1, _a2, _a3)
+}
+
+// ScriptHashSubscriptions provides a mock function with given fields:
+func (_m *Interface) ScriptHashSubscriptions() ([]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+	ret := _m.Called()
+
+	var r0 []blockchain.ScriptHashHex
+	if rf, ok := ret.Get(0).(func() []blockchain.ScriptHashHex); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]blockchain.ScriptHashHex)
+		}
+	}
+
+	var r1 []blockchain.ScriptHashHex
+	if rf, ok := ret.Get(1).(func() []blockchain.ScriptHashHex); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]blockchain.ScriptHashHex)
+		}
+	}
+
+	return r0, r1
+}
+
+// TransactionBroadcast provides a mock function with given fields: _a0, _a1
+func (_m *Interface) TransactionBroadcast(_a0 context.Context, _a1 *wire.MsgTx) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*wire.MsgTx) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *wire.MsgTx) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -181,13 +213,13 @@ func (_m *Interface) TransactionBroadcast(_a0 *wire.MsgTx) error {
 	return r0
 }
 
-// TransactionGet provides a mock function with given fields: _a0
-func (_m *Interface) TransactionGet(_a0 chainhash.Hash) (*wire.MsgTx, error) {
-	ret := _m.Called(_a0)
+// TransactionGet provides a mock function with given fields: _a0, _a1
+func (_m *Interface) TransactionGet(_a0 context.Context, _a1 chainhash.Hash) (*wire.MsgTx, error) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 *wire.MsgTx
-	if rf, ok := ret.Get(0).(func(chainhash.Hash) *wire.MsgTx); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, chainhash.Hash) *wire.MsgTx); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*wire.MsgTx)
@@ -195,8 +227,8 @@ func (_m *Interface) TransactionGet(_a0 chainhash.Hash) (*wire.MsgTx, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(chainhash.Hash) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, chainhash.Hash) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
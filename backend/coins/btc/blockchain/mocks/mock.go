@@ -15,6 +15,7 @@
 package mocks
 
 import (
+	"context"
 	"errors"
 
 	blockchain "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -39,10 +40,12 @@ type BlockchainMock struct {
 	MockConnectionError      func() error
 
 	MockRegisterOnConnectionErrorChangedEvent func(func(error))
+	MockScriptHashSubscriptions               func() ([]blockchain.ScriptHashHex, []blockchain.ScriptHashHex)
+	MockScriptHashResubscribe                 func(func() func(), blockchain.ScriptHashHex, func(string))
 }
 
 // ScriptHashGetHistory implements Interface.
-func (b *BlockchainMock) ScriptHashGetHistory(s blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+func (b *BlockchainMock) ScriptHashGetHistory(_ context.Context, s blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
 	if b.MockScriptHashGetHistory != nil {
 		return b.MockScriptHashGetHistory(s)
 	}
@@ -50,7 +53,7 @@ func (b *BlockchainMock) ScriptHashGetHistory(s blockchain.ScriptHashHex) (block
 }
 
 // TransactionGet implements Interface.
-func (b *BlockchainMock) TransactionGet(h chainhash.Hash) (*wire.MsgTx, error) {
+func (b *BlockchainMock) TransactionGet(_ context.Context, h chainhash.Hash) (*wire.MsgTx, error) {
 	if b.MockTransactionGet != nil {
 		return b.MockTransactionGet(h)
 	}
@@ -58,12 +61,29 @@ func (b *BlockchainMock) TransactionGet(h chainhash.Hash) (*wire.MsgTx, error) {
 }
 
 // ScriptHashSubscribe implements Interface.
-func (b *BlockchainMock) ScriptHashSubscribe(setupAndTeardown func() func(), s blockchain.ScriptHashHex, success func(string)) {
+func (b *BlockchainMock) ScriptHashSubscribe(
+	_ context.Context, setupAndTeardown func() func(), s blockchain.ScriptHashHex, success func(string)) {
 	if b.MockScriptHashSubscribe != nil {
 		b.MockScriptHashSubscribe(setupAndTeardown, s, success)
 	}
 }
 
+// ScriptHashSubscriptions implements Interface.
+func (b *BlockchainMock) ScriptHashSubscriptions() ([]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+	if b.MockScriptHashSubscriptions != nil {
+		return b.MockScriptHashSubscriptions()
+	}
+	return nil, nil
+}
+
+// ScriptHashResubscribe implements Interface.
+func (b *BlockchainMock) ScriptHashResubscribe(
+	_ context.Context, setupAndTeardown func() func(), s blockchain.ScriptHashHex, success func(string)) {
+	if b.MockScriptHashResubscribe != nil {
+		b.MockScriptHashResubscribe(setupAndTeardown, s, success)
+	}
+}
+
 // HeadersSubscribe implements Interface.
 func (b *BlockchainMock) HeadersSubscribe(success func(*types.Header)) {
 	if b.MockHeadersSubscribe != nil {
@@ -72,7 +92,7 @@ func (b *BlockchainMock) HeadersSubscribe(success func(*types.Header)) {
 }
 
 // TransactionBroadcast implements Interface.
-func (b *BlockchainMock) TransactionBroadcast(msgTx *wire.MsgTx) error {
+func (b *BlockchainMock) TransactionBroadcast(_ context.Context, msgTx *wire.MsgTx) error {
 	if b.MockTransactionBroadcast != nil {
 		return b.MockTransactionBroadcast(msgTx)
 	}
@@ -80,7 +100,7 @@ func (b *BlockchainMock) TransactionBroadcast(msgTx *wire.MsgTx) error {
 }
 
 // RelayFee implements Interface.
-func (b *BlockchainMock) RelayFee() (btcutil.Amount, error) {
+func (b *BlockchainMock) RelayFee(context.Context) (btcutil.Amount, error) {
 	if b.MockRelayFee != nil {
 		return b.MockRelayFee()
 	}
@@ -88,7 +108,7 @@ func (b *BlockchainMock) RelayFee() (btcutil.Amount, error) {
 }
 
 // EstimateFee implements Interface.
-func (b *BlockchainMock) EstimateFee(i int) (btcutil.Amount, error) {
+func (b *BlockchainMock) EstimateFee(_ context.Context, i int) (btcutil.Amount, error) {
 	if b.MockEstimateFee != nil {
 		return b.MockEstimateFee(i)
 	}
@@ -96,7 +116,7 @@ func (b *BlockchainMock) EstimateFee(i int) (btcutil.Amount, error) {
 }
 
 // Headers implements Interface.
-func (b *BlockchainMock) Headers(i1 int, i2 int) (*blockchain.HeadersResult, error) {
+func (b *BlockchainMock) Headers(_ context.Context, i1 int, i2 int) (*blockchain.HeadersResult, error) {
 	if b.MockHeaders != nil {
 		return b.MockHeaders(i1, i2)
 	}
@@ -107,7 +127,7 @@ func (b *BlockchainMock) Headers(i1 int, i2 int) (*blockchain.HeadersResult, err
 }
 
 // GetMerkle implements Interface.
-func (b *BlockchainMock) GetMerkle(h chainhash.Hash, i int) (*blockchain.GetMerkleResult, error) {
+func (b *BlockchainMock) GetMerkle(_ context.Context, h chainhash.Hash, i int) (*blockchain.GetMerkleResult, error) {
 	if b.MockGetMerkle != nil {
 		return b.MockGetMerkle(h, i)
 	}
@@ -0,0 +1,189 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// ErrCacheMismatch is returned by VerifyCache() if the cached account data does not match the
+// keystore, e.g. because the database was tampered with.
+const ErrCacheMismatch errp.ErrorCode = "cacheMismatch"
+
+// cacheVerificationSampleSize is the number of addresses per address chain and the number of
+// transactions checked by VerifyCache(), so that the check does not noticeably delay the start of
+// the account.
+const cacheVerificationSampleSize = 10
+
+// sampleIndices returns up to `size` random distinct indices in [0, n), or all of them if `full`
+// is true.
+func sampleIndices(n int, size int, full bool) []int {
+	indices := rand.Perm(n)
+	if !full && len(indices) > size {
+		indices = indices[:size]
+	}
+	return indices
+}
+
+// verifyAddressChain checks that the addresses of the chain derived from the persisted signing
+// configuration match the ones derived from the configuration of the keystore.
+func (account *Account) verifyAddressChain(
+	chain *addresses.AddressChain,
+	chainIndex uint32,
+	liveConfiguration *signing.Configuration,
+	full bool,
+) error {
+	cached := chain.Addresses()
+	for _, index := range sampleIndices(len(cached), cacheVerificationSampleSize, full) {
+		derived := addresses.NewAccountAddress(
+			liveConfiguration,
+			signing.NewEmptyRelativeKeypath().
+				Child(chainIndex, signing.NonHardened).
+				Child(uint32(index), signing.NonHardened),
+			account.coin.Net(),
+			account.log,
+		)
+		if derived.PubkeyScriptHashHex() != cached[index].PubkeyScriptHashHex() {
+			return errp.WithMessage(ErrCacheMismatch,
+				fmt.Sprintf("address %d/%d does not match the keystore", chainIndex, index))
+		}
+	}
+	return nil
+}
+
+// isTxRelevant returns true if the transaction pays to or spends from an address of the account.
+func (account *Account) isTxRelevant(dbTx transactions.DBTxInterface, txInfo *transactions.DBTxInfo) (bool, error) {
+	for _, txOut := range txInfo.Tx.TxOut {
+		if account.getAddress(blockchain.NewScriptHashHex(txOut.PkScript)) != nil {
+			return true, nil
+		}
+	}
+	for _, txIn := range txInfo.Tx.TxIn {
+		spent, err := dbTx.Output(txIn.PreviousOutPoint)
+		if err != nil {
+			return false, err
+		}
+		if spent != nil && account.getAddress(blockchain.NewScriptHashHex(spent.PkScript)) != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyTransactions checks that the stored transactions only refer to addresses of the account
+// and actually pay to or spend from one of them.
+func (account *Account) verifyTransactions(full bool) error {
+	_, err := transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (struct{}, error) {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return struct{}{}, err
+		}
+		for _, index := range sampleIndices(len(txHashes), cacheVerificationSampleSize, full) {
+			txHash := txHashes[index]
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return struct{}{}, err
+			}
+			if txInfo == nil {
+				continue
+			}
+			if err := account.verifyTransaction(dbTx, txHash, txInfo); err != nil {
+				return struct{}{}, err
+			}
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+func (account *Account) verifyTransaction(
+	dbTx transactions.DBTxInterface, txHash chainhash.Hash, txInfo *transactions.DBTxInfo) error {
+	for storedScriptHashHex := range txInfo.Addresses {
+		scriptHashHex, err := blockchain.ParseScriptHashHex(storedScriptHashHex)
+		if err != nil {
+			return errp.WithMessage(ErrCacheMismatch,
+				fmt.Sprintf("transaction %s refers to an invalid address: %v", txHash, err))
+		}
+		if account.getAddress(scriptHashHex) == nil {
+			return errp.WithMessage(ErrCacheMismatch,
+				fmt.Sprintf("transaction %s refers to an unknown address", txHash))
+		}
+	}
+	relevant, err := account.isTxRelevant(dbTx, txInfo)
+	if err != nil {
+		return err
+	}
+	if !relevant {
+		return errp.WithMessage(ErrCacheMismatch,
+			fmt.Sprintf("transaction %s does not involve the account", txHash))
+	}
+	return nil
+}
+
+// VerifyCache re-derives a random sample of the addresses of the account from the extended public
+// keys of the keystore and checks a random sample of the stored transactions against them, so that
+// tampering with the persisted account data is detected. All addresses and transactions are checked
+// if `full` is true. ErrCacheMismatch is returned if there is a mismatch, in which case the cache
+// should be discarded with QuarantineCache(). The account must be initialized.
+func (account *Account) VerifyCache(keystore keystore.Keystore, full bool) error {
+	if !account.isInitialized() || account.isClosed() {
+		return errp.New("account not initialized")
+	}
+	for _, subacc := range account.subaccounts {
+		configuration := subacc.signingConfiguration
+		keypath := configuration.AbsoluteKeypath()
+		xpub, err := keystore.ExtendedPublicKey(account.coin, keypath)
+		if err != nil {
+			return err
+		}
+		liveConfiguration := signing.NewBitcoinConfiguration(
+			configuration.ScriptType(),
+			configuration.BitcoinSimple.KeyInfo.RootFingerprint,
+			keypath,
+			xpub,
+		)
+		if err := account.verifyAddressChain(subacc.receiveAddresses, 0, liveConfiguration, full); err != nil {
+			return err
+		}
+		if err := account.verifyAddressChain(subacc.changeAddresses, 1, liveConfiguration, full); err != nil {
+			return err
+		}
+	}
+	return account.verifyTransactions(full)
+}
+
+// QuarantineCache closes the account and moves its database aside, so that the account is synced
+// from scratch when it is loaded again. The database is kept for inspection.
+func (account *Account) QuarantineCache() error {
+	account.Close()
+	filename := account.dbFilename()
+	quarantined := fmt.Sprintf("%s.quarantined-%d", filename, time.Now().Unix())
+	if err := os.Rename(filename, quarantined); err != nil {
+		return errp.WithStack(err)
+	}
+	account.log.WithField("filename", quarantined).Warn("Quarantined the account database")
+	return nil
+}
@@ -20,43 +20,91 @@ import (
 	"math/big"
 	"os"
 	"path"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/headersdb"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/esplora"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/throttle"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/sirupsen/logrus"
 )
 
+// headersStatusNotifyInterval is the minimum interval between two headers status notifications.
+const headersStatusNotifyInterval = 300 * time.Millisecond
+
 // Coin models a Bitcoin-related coin.
 type Coin struct {
-	initOnce sync.Once
-	code     coinpkg.Code
-	name     string
+	// initMu guards initialized, and serializes Initialize() and Close().
+	initMu      sync.Mutex
+	initialized bool
+
+	code coinpkg.Code
+	name string
 	// unit is the main unit of the coin, e.g. 'BTC'
 	unit string
 	// formatUnit keeps track of the unit used, e.g. 'BTC' or 'sat' depening on if sat mode is enabled
-	formatUnit            coinpkg.BtcUnit
-	net                   *chaincfg.Params
-	dbFolder              string
-	makeBlockchain        func() blockchain.Interface
-	blockExplorerTxPrefix string
+	formatUnit     coinpkg.BtcUnit
+	net            *chaincfg.Params
+	dbFolder       string
+	makeBlockchain func() (blockchain.Interface, error)
+	// blockExplorerMu guards blockExplorer, which can be changed by the user at any time.
+	blockExplorerMu sync.RWMutex
+	blockExplorer   coinpkg.BlockExplorer
+	// activeFiatsMu guards activeFiats, which can be changed by the user at any time.
+	activeFiatsMu sync.RWMutex
+	// activeFiats are the fiat currencies selected by the user, e.g. 'USD' and 'EUR'.
+	activeFiats []string
 
 	observable.Implementation
 
+	// mu guards blockchain and headers, which are assigned in Initialize().
+	mu         sync.RWMutex
 	blockchain blockchain.Interface
 	headers    *headers.Headers
+	// headersPruneDepth is passed to headers.Headers.SetPruneDepth().
+	headersPruneDepth int
+	// headersBirthday is passed to headers.Headers.SetBirthday(). Guarded by mu.
+	headersBirthday int
+	// requestTimeout is the timeout of the blockchain requests, see SetRequestTimeout().
+	requestTimeout time.Duration
+
+	// rateUpdater is observed while the coin is initialized, see ObserveRates().
+	rateUpdater *rates.RateUpdater
+	// unobserveRates stops observing the rate updater. Set in Initialize() if there is a rate
+	// updater, and called in Close().
+	unobserveRates func()
+	// ratesObservers are notified of rate updates, see ObserveRates().
+	ratesObservers observable.Implementation
+
+	// txFetchThrottle limits the rate of transaction downloads of all accounts of the coin, as
+	// they share the connection to the server.
+	txFetchThrottle *throttle.Throttle
+
+	// headersStatusMu guards headersStatusNotified, headersStatusPending and headersStatusTimer,
+	// which are used to throttle the headers status notifications.
+	headersStatusMu       sync.Mutex
+	headersStatusNotified time.Time
+	headersStatusPending  bool
+	// headersStatusTimer delivers the pending notification. Stopped in Close().
+	headersStatusTimer *time.Timer
 
 	log *logrus.Entry
 }
@@ -70,76 +118,260 @@ func NewCoin(
 	net *chaincfg.Params,
 	dbFolder string,
 	servers []*config.ServerInfo,
-	blockExplorerTxPrefix string,
+	txFetchThrottle config.TxFetchThrottle,
+	blockExplorer coinpkg.BlockExplorer,
 	socksProxy socksproxy.SocksProxy,
 ) *Coin {
 	log := logging.Get().WithGroup("coin").WithField("code", code)
 	coin := &Coin{
-		code:                  code,
-		name:                  name,
-		unit:                  unit,
-		formatUnit:            formatUnit,
-		net:                   net,
-		dbFolder:              dbFolder,
-		blockExplorerTxPrefix: blockExplorerTxPrefix,
-		makeBlockchain: func() blockchain.Interface {
-			return electrum.NewElectrumConnection(
-				servers,
-				log,
-				socksProxy.GetTCPProxyDialer(),
-			)
-		},
-		log: log,
+		code:            code,
+		name:            name,
+		unit:            unit,
+		formatUnit:      formatUnit,
+		net:             net,
+		dbFolder:        dbFolder,
+		blockExplorer:   blockExplorer,
+		txFetchThrottle: throttle.New(txFetchThrottle, log),
+		log:             log,
+	}
+	coin.makeBlockchain = func() (blockchain.Interface, error) {
+		return newBlockchain(servers, log, socksProxy, coin.requestTimeout)
 	}
 	return coin
 }
 
+// newBlockchain connects to the configured backend servers. The backend type is selected by the
+// first server: Esplora servers are queried via their HTTP API, all other servers are Electrum
+// servers.
+func newBlockchain(
+	servers []*config.ServerInfo,
+	log *logrus.Entry,
+	socksProxy socksproxy.SocksProxy,
+	requestTimeout time.Duration,
+) (blockchain.Interface, error) {
+	if len(servers) > 0 && servers[0].ServerType() == config.ServerTypeEsplora {
+		// The Esplora requests must go through the proxy if one is configured, so we never fall
+		// back to a client which does not use it.
+		httpClient, err := socksProxy.GetHTTPClient()
+		if err != nil {
+			return nil, errp.WithMessage(err, "Could not create the HTTP client for Esplora")
+		}
+		return esplora.NewClient(
+			servers, log, httpClient, requestTimeout, electrum.MaxMessageSize()), nil
+	}
+	return electrum.NewElectrumConnection(
+		servers,
+		log,
+		socksProxy.GetTCPProxyDialer(),
+		requestTimeout,
+	), nil
+}
+
+// SetHeadersPruneDepth enables pruning the headers more than `depth` blocks below the tip, see
+// headers.Headers.SetPruneDepth(). 0 disables pruning. Must be called before Initialize().
+func (coin *Coin) SetHeadersPruneDepth(depth int) {
+	coin.headersPruneDepth = depth
+}
+
+// SetHeadersBirthday sets the height below which the wallets of this coin have no transactions, see
+// headers.Headers.SetBirthday(). 0 means the birthday is unknown.
+func (coin *Coin) SetHeadersBirthday(height int) {
+	coin.mu.Lock()
+	defer coin.mu.Unlock()
+	coin.headersBirthday = height
+	if coin.headers != nil {
+		coin.headers.SetBirthday(height)
+	}
+}
+
+// SetRequestTimeout sets the timeout of the requests to the Electrum and Esplora servers. 0 uses
+// the default timeout of the backend, e.g. electrum.DefaultRequestTimeout. Must be called before Initialize().
+func (coin *Coin) SetRequestTimeout(timeout time.Duration) {
+	coin.requestTimeout = timeout
+}
+
+// SetRateUpdater sets the rate updater which is observed on behalf of the accounts of the coin, see
+// ObserveRates(). Must be called before Initialize().
+func (coin *Coin) SetRateUpdater(rateUpdater *rates.RateUpdater) {
+	coin.rateUpdater = rateUpdater
+}
+
+// ObserveRates implements coinpkg.RatesObserver.
+func (coin *Coin) ObserveRates(f func()) func() {
+	return coin.ratesObservers.Observe(func(observable.Event) { f() })
+}
+
 // TstSetMakeBlockchain must only be used in unit tests to provide a mock instance for the
 // blockchain interface.
 func (coin *Coin) TstSetMakeBlockchain(f func() blockchain.Interface) {
-	coin.makeBlockchain = f
+	coin.makeBlockchain = func() (blockchain.Interface, error) { return f(), nil }
 }
 
 // Initialize implements coinpkg.Coin.
-func (coin *Coin) Initialize() {
-	coin.initOnce.Do(func() {
-		// Init blockchain
-		coin.blockchain = coin.makeBlockchain()
+//
+// The coin can be initialized again after Close(), which connects to the servers and opens the
+// headers DB again. If connecting to the servers fails, an error is returned and the coin stays
+// uninitialized.
+func (coin *Coin) Initialize() error {
+	coin.initMu.Lock()
+	defer coin.initMu.Unlock()
+	if coin.initialized {
+		return nil
+	}
+	// Init blockchain
+	theBlockchain, err := coin.makeBlockchain()
+	if err != nil {
+		return err
+	}
+	coin.initialized = true
+	coin.mu.Lock()
+	coin.blockchain = theBlockchain
+	coin.mu.Unlock()
+	if provider, ok := theBlockchain.(blockchain.ConnectionStatusProvider); ok {
+		provider.RegisterOnConnectionStatusChangedEvent(func(blockchain.ConnectionStatus) {
+			coin.notifyConnectionStatus()
+		})
+	} else {
+		theBlockchain.RegisterOnConnectionErrorChangedEvent(func(error) {
+			coin.notifyConnectionStatus()
+		})
+	}
+
+	// Init Headers
 
-		// Init Headers
+	// delete old db version (up to v4.10.0, bbolt was used):
+	oldDBFilename := path.Join(coin.dbFolder, fmt.Sprintf("headers-%s.db", coin.code))
+	if _, err := os.Stat(oldDBFilename); err == nil {
+		_ = os.Remove(oldDBFilename)
+	}
 
-		// delete old db version (up to v4.10.0, bbolt was used):
-		oldDBFilename := path.Join(coin.dbFolder, fmt.Sprintf("headers-%s.db", coin.code))
-		if _, err := os.Stat(oldDBFilename); err == nil {
-			_ = os.Remove(oldDBFilename)
+	db, err := headersdb.NewDB(
+		path.Join(coin.dbFolder, fmt.Sprintf("headers-%s.bin", coin.code)),
+		coin.log)
+	if err != nil {
+		coin.log.WithError(err).Panic("Could not open headers DB")
+	}
+	theHeaders := headers.NewHeaders(
+		coin.net,
+		db,
+		theBlockchain,
+		coin.log)
+	theHeaders.SetPruneDepth(coin.headersPruneDepth)
+	coin.mu.Lock()
+	theHeaders.SetBirthday(coin.headersBirthday)
+	coin.headers = theHeaders
+	coin.mu.Unlock()
+	theHeaders.Initialize()
+	theHeaders.SubscribeEvent(func(event headers.Event) {
+		switch event {
+		case headers.EventSyncing, headers.EventSynced, headers.EventLaggingServer:
+			coin.notifyHeadersStatus()
+		case headers.EventInvalidHeaders:
+			coin.Notify(observable.Event{
+				Subject: fmt.Sprintf("coins/%s/headers/invalid", coin.code),
+				Action:  action.Reload,
+			})
 		}
+	})
+
+	if coin.rateUpdater != nil {
+		coin.unobserveRates = coin.rateUpdater.Observe(func(event observable.Event) {
+			if event.Subject == rates.RatesEventSubject ||
+				event.Subject == rates.HistoricalRatesEventSubject {
+				coin.ratesObservers.Notify(event)
+			}
+		})
+	}
+	return nil
+}
 
-		db, err := headersdb.NewDB(
-			path.Join(coin.dbFolder, fmt.Sprintf("headers-%s.bin", coin.code)),
-			coin.log)
+// ConnectionStatus returns the status of the connection to the blockchain backend, independent of
+// the headers sync. Backends which don't report it in detail are considered connected unless they
+// report a connection error.
+func (coin *Coin) ConnectionStatus() blockchain.ConnectionStatus {
+	theBlockchain := coin.Blockchain()
+	if theBlockchain == nil {
+		return blockchain.ConnectionStatus{State: blockchain.ConnectionStateConnecting}
+	}
+	if provider, ok := theBlockchain.(blockchain.ConnectionStatusProvider); ok {
+		return provider.ConnectionStatus()
+	}
+	if err := theBlockchain.ConnectionError(); err != nil {
+		errMsg := err.Error()
+		return blockchain.ConnectionStatus{
+			State: blockchain.ConnectionStateDisconnected,
+			Error: &errMsg,
+		}
+	}
+	return blockchain.ConnectionStatus{State: blockchain.ConnectionStateConnected}
+}
+
+// notifyConnectionStatus pushes the connection status to the frontend. The status is fetched
+// when notifying, so the last notification carries the latest status even if the change callbacks
+// run out of order.
+func (coin *Coin) notifyConnectionStatus() {
+	coin.Notify(observable.Event{
+		Subject: fmt.Sprintf("coins/%s/connection/status", coin.code),
+		Action:  action.Replace,
+		Object:  coin.ConnectionStatus(),
+	})
+}
+
+// notifyHeadersStatus pushes the headers status to the frontend. During the initial sync, the
+// status changes with every batch of headers, so the notifications are throttled to at most one
+// per headersStatusNotifyInterval. The last status is always delivered.
+func (coin *Coin) notifyHeadersStatus() {
+	notify := func() {
+		status, err := coin.Headers().Status()
 		if err != nil {
-			coin.log.WithError(err).Panic("Could not open headers DB")
+			coin.log.WithError(err).Error("Could not get headers status")
 		}
-		coin.headers = headers.NewHeaders(
-			coin.net,
-			db,
-			coin.blockchain,
-			coin.log)
-		coin.headers.Initialize()
-		coin.headers.SubscribeEvent(func(event headers.Event) {
-			if event == headers.EventSyncing || event == headers.EventSynced {
-				status, err := coin.headers.Status()
-				if err != nil {
-					coin.log.WithError(err).Error("Could not get headers status")
-				}
-				coin.Notify(observable.Event{
-					Subject: fmt.Sprintf("coins/%s/headers/status", coin.code),
-					Action:  action.Replace,
-					Object:  status,
-				})
-			}
+		coin.Notify(observable.Event{
+			Subject: fmt.Sprintf("coins/%s/headers/status", coin.code),
+			Action:  action.Replace,
+			Object:  status,
 		})
+	}
+
+	coin.headersStatusMu.Lock()
+	if coin.headersStatusPending {
+		// A notification is already scheduled and will fetch the latest status.
+		coin.headersStatusMu.Unlock()
+		return
+	}
+	elapsed := time.Since(coin.headersStatusNotified)
+	if elapsed >= headersStatusNotifyInterval {
+		coin.headersStatusNotified = time.Now()
+		coin.headersStatusMu.Unlock()
+		notify()
+		return
+	}
+	coin.headersStatusPending = true
+	coin.headersStatusTimer = time.AfterFunc(headersStatusNotifyInterval-elapsed, func() {
+		coin.headersStatusMu.Lock()
+		if !coin.headersStatusPending {
+			// Cancelled by Close().
+			coin.headersStatusMu.Unlock()
+			return
+		}
+		coin.headersStatusPending = false
+		coin.headersStatusTimer = nil
+		coin.headersStatusNotified = time.Now()
+		coin.headersStatusMu.Unlock()
+		notify()
 	})
+	coin.headersStatusMu.Unlock()
+}
+
+// stopHeadersStatusTimer cancels the pending headers status notification, if any.
+func (coin *Coin) stopHeadersStatusTimer() {
+	coin.headersStatusMu.Lock()
+	defer coin.headersStatusMu.Unlock()
+	if coin.headersStatusTimer != nil {
+		coin.headersStatusTimer.Stop()
+		coin.headersStatusTimer = nil
+	}
+	coin.headersStatusPending = false
 }
 
 // Name implements coinpkg.Coin.
@@ -169,18 +401,40 @@ func (coin *Coin) SetFormatUnit(unit coinpkg.BtcUnit) {
 
 // GetFormatUnit implements coin.Coin.
 func (coin *Coin) GetFormatUnit(bool) string {
-	if coin.formatUnit == coinpkg.BtcUnitSats {
+	return coin.unitName(coin.formatUnit)
+}
+
+// unitName returns the name of the unit as displayed to the user.
+func (coin *Coin) unitName(unit coinpkg.BtcUnit) string {
+	switch unit {
+	case coinpkg.BtcUnitSats:
 		switch coin.code {
 		case coinpkg.CodeBTC:
 			return "sat"
-		case coinpkg.CodeTBTC:
+		case coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC:
 			return "tsat"
 		}
+	case coinpkg.BtcUnitMilliBTC:
+		return "m" + coin.unit
 	}
 
 	return coin.unit
 }
 
+// ActiveFiats implements coinpkg.Coin.
+func (coin *Coin) ActiveFiats() []string {
+	coin.activeFiatsMu.RLock()
+	defer coin.activeFiatsMu.RUnlock()
+	return coin.activeFiats
+}
+
+// SetActiveFiats implements coinpkg.Coin.
+func (coin *Coin) SetActiveFiats(fiats []string) {
+	coin.activeFiatsMu.Lock()
+	defer coin.activeFiatsMu.Unlock()
+	coin.activeFiats = append([]string(nil), fiats...)
+}
+
 // Decimals implements coinpkg.Coin.
 func (coin *Coin) Decimals(isFee bool) uint {
 	return 8
@@ -188,10 +442,7 @@ func (coin *Coin) Decimals(isFee bool) uint {
 
 // FormatAmount implements coinpkg.Coin.
 func (coin *Coin) FormatAmount(amount coinpkg.Amount, isFee bool) string {
-	if coin.formatUnit == coinpkg.BtcUnitSats {
-		return amount.BigInt().String()
-	}
-	return new(big.Rat).SetFrac(amount.BigInt(), big.NewInt(unitSatoshi)).FloatString(8)
+	return coinpkg.FormatBtcAmount(amount.BigInt(), coin.formatUnit)
 }
 
 // ToUnit implements coinpkg.Coin.
@@ -207,26 +458,89 @@ func (coin *Coin) SetAmount(amount *big.Rat, isFee bool) coinpkg.Amount {
 	return coinpkg.NewAmount(intSatsAmount)
 }
 
-// ParseAmount implements coinpkg.Coin.
+// ParseAmount implements coinpkg.Coin. It is the inverse of FormatAmount(): the amount is a decimal
+// in the format unit of the coin, optionally followed by the name of a unit of the coin which is
+// then used instead, e.g. "0.001 BTC" or "100'000 sat". Negative amounts, amounts with more decimals
+// than the unit has and amounts exceeding the total supply of the coin are rejected.
 func (coin *Coin) ParseAmount(amount string) (coinpkg.Amount, error) {
-	amountRat, valid := new(big.Rat).SetString(amount)
-	if !valid {
-		return coinpkg.Amount{}, errp.New("Invalid amount")
+	amount = strings.TrimSpace(amount)
+	unit := coin.formatUnit
+	if index := strings.IndexFunc(amount, unicode.IsLetter); index >= 0 {
+		var ok bool
+		unit, ok = coin.unitByName(strings.TrimSpace(amount[index:]))
+		if !ok {
+			return coinpkg.Amount{}, errp.Newf("Invalid unit in amount %q", amount)
+		}
+		amount = strings.TrimSpace(amount[:index])
+	}
+	parsed, err := coinpkg.ParseBtcAmount(amount, unit)
+	if err != nil {
+		return coinpkg.Amount{}, errp.WithMessage(err, "Invalid amount")
+	}
+	if parsed.BigInt().Sign() < 0 || parsed.BigInt().Cmp(big.NewInt(int64(coin.maxSupply()))) > 0 {
+		return coinpkg.Amount{}, errp.Newf("Invalid amount %q", amount)
 	}
+	return parsed, nil
+}
 
-	if coin.formatUnit == coinpkg.BtcUnitSats {
-		amountRat = coinpkg.Sat2Btc(amountRat)
+// unitByName returns the unit with the given name, see unitName(). If several units have the same
+// name, the first one of BTC, mBTC and sat is returned.
+func (coin *Coin) unitByName(name string) (coinpkg.BtcUnit, bool) {
+	for _, unit := range []coinpkg.BtcUnit{
+		coinpkg.BtcUnitDefault, coinpkg.BtcUnitMilliBTC, coinpkg.BtcUnitSats,
+	} {
+		if coin.unitName(unit) == name {
+			return unit, true
+		}
+	}
+	return "", false
+}
+
+// maxSupply returns the total supply of the coin, which no amount can exceed.
+func (coin *Coin) maxSupply() btcutil.Amount {
+	switch coin.code {
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		return 84e6 * btcutil.SatoshiPerBitcoin
+	default:
+		return btcutil.MaxSatoshi
 	}
-	return coin.SetAmount(amountRat, false), nil
 }
 
 // Blockchain connects to a blockchain backend.
 func (coin *Coin) Blockchain() blockchain.Interface {
+	coin.mu.RLock()
+	defer coin.mu.RUnlock()
 	return coin.blockchain
 }
 
+// ServerInfo describes the blockchain server the coin is connected to, including the banner of the
+// server operator, so that it can be displayed to the user.
+func (coin *Coin) ServerInfo() (*blockchain.ServerInfo, error) {
+	provider, ok := coin.Blockchain().(blockchain.ServerInfoProvider)
+	if !ok {
+		return nil, errp.New("The blockchain backend does not provide server info")
+	}
+	return provider.ServerInfo()
+}
+
+// RetryConnection makes the blockchain backend try to connect immediately if it is waiting to
+// retry after all servers failed, e.g. when the network connectivity was restored. It does nothing
+// if the blockchain backend is not initialized or does not wait between connection attempts.
+func (coin *Coin) RetryConnection() {
+	if retryNower, ok := coin.Blockchain().(blockchain.RetryNower); ok {
+		retryNower.RetryNow()
+	}
+}
+
+// TxFetchThrottle returns the rate limit of the transaction downloads of the coin.
+func (coin *Coin) TxFetchThrottle() *throttle.Throttle {
+	return coin.txFetchThrottle
+}
+
 // Headers returns the coin headers.
 func (coin *Coin) Headers() *headers.Headers {
+	coin.mu.RLock()
+	defer coin.mu.RUnlock()
 	return coin.headers
 }
 
@@ -236,7 +550,35 @@ func (coin *Coin) String() string {
 
 // BlockExplorerTransactionURLPrefix implements coinpkg.Coin.
 func (coin *Coin) BlockExplorerTransactionURLPrefix() string {
-	return coin.blockExplorerTxPrefix
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.TxURLPrefix()
+}
+
+// BlockExplorerURL implements coinpkg.Coin.
+func (coin *Coin) BlockExplorerURL(kind coinpkg.BlockExplorerURLKind, id string) (string, error) {
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.BuildURL(kind, id)
+}
+
+// BlockExplorerURLPrefix implements coinpkg.Coin.
+func (coin *Coin) BlockExplorerURLPrefix(kind coinpkg.BlockExplorerURLKind) string {
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.URLPrefix(kind)
+}
+
+// SetBlockExplorer implements coinpkg.Coin.
+func (coin *Coin) SetBlockExplorer(explorer coinpkg.BlockExplorer) {
+	coin.blockExplorerMu.Lock()
+	coin.blockExplorer = explorer
+	coin.blockExplorerMu.Unlock()
+	coin.Notify(observable.Event{
+		Subject: fmt.Sprintf("coins/%s/block-explorer", coin.code),
+		Action:  action.Replace,
+		Object:  explorer,
+	})
 }
 
 // SmallestUnit implements coinpkg.Coin.
@@ -249,37 +591,103 @@ func (coin *Coin) SmallestUnit() string {
 	}
 }
 
+// decodeAddressError turns the error of btcutil.DecodeAddress into the matching
+// errors.TxValidationError.
+func (coin *Coin) decodeAddressError(address string, err error) error {
+	if _, ok := err.(btcutil.UnsupportedWitnessVerError); ok {
+		return errors.ErrAddressUnsupportedType
+	}
+	if _, ok := err.(bech32.ErrInvalidChecksum); ok || err == btcutil.ErrChecksumMismatch {
+		return errors.ErrAddressInvalidChecksum
+	}
+	if hrp, data, version, err := bech32.DecodeGeneric(address); err == nil {
+		// Bech32 addresses with a valid checksum but the prefix of an unknown network.
+		if hrp != coin.Net().Bech32HRPSegwit {
+			return errors.ErrAddressWrongNetwork
+		}
+		// Segwit v0 addresses must use bech32, later witness versions bech32m (BIP350).
+		if len(data) > 0 && (data[0] == 0) != (version == bech32.Version0) {
+			return errors.ErrAddressInvalidChecksum
+		}
+	}
+	// Base58 addresses with a valid checksum but the version byte of another network.
+	if decoded, netID, err := base58.CheckDecode(address); err == nil && len(decoded) == 20 &&
+		(chaincfg.IsPubKeyHashAddrID(netID) || chaincfg.IsScriptHashAddrID(netID)) {
+		return errors.ErrAddressWrongNetwork
+	}
+	return errors.ErrInvalidAddress
+}
+
 // DecodeAddress decodes a btc/ltc address, checking that the format matches the account coin
-// type.
+// type. The returned error is an errors.TxValidationError describing why the address is invalid.
 func (coin *Coin) DecodeAddress(address string) (btcutil.Address, error) {
 	btcAddress, err := btcutil.DecodeAddress(address, coin.Net())
 	if err != nil {
-		return nil, errp.WithStack(errors.ErrInvalidAddress)
+		return nil, errp.WithStack(coin.decodeAddressError(address, err))
 	}
 	if !btcAddress.IsForNet(coin.Net()) {
-		return nil, errp.WithStack(errors.ErrInvalidAddress)
+		return nil, errp.WithStack(errors.ErrAddressWrongNetwork)
 	}
 	if _, ok := btcAddress.(*btcutil.AddressTaproot); ok {
 		switch coin.code {
-		case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+		case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC:
 			// Taproot activated on Bitcoin.
 		default:
 			// Taproot not activated on other coins.
-			return nil, errp.WithStack(errors.ErrInvalidAddress)
+			return nil, errp.WithStack(errors.ErrAddressUnsupportedType)
 		}
 	}
 	return btcAddress, nil
 }
 
+// ValidateAddress implements coinpkg.Coin. The address type is the script type of the output paid
+// to: "p2pkh", "p2sh", "p2wpkh", "p2wsh" or "p2tr".
+func (coin *Coin) ValidateAddress(address string) (string, error) {
+	btcAddress, err := coin.DecodeAddress(address)
+	if err != nil {
+		return "", err
+	}
+	switch btcAddress.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return "p2pkh", nil
+	case *btcutil.AddressScriptHash:
+		return "p2sh", nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		return "p2wpkh", nil
+	case *btcutil.AddressWitnessScriptHash:
+		return "p2wsh", nil
+	case *btcutil.AddressTaproot:
+		return "p2tr", nil
+	default:
+		// E.g. raw public keys, which are not addresses that can be entered as a recipient.
+		return "", errp.WithStack(errors.ErrAddressUnsupportedType)
+	}
+}
+
 // Close implements coinpkg.Coin.
+//
+// It stops observing the rate updater, the headers sync and the pending headers status
+// notification, and closes the headers DB and the
+// connections to the servers. It is safe to call Close() more than once, and the coin can be
+// initialized again afterwards.
 func (coin *Coin) Close() error {
+	coin.initMu.Lock()
+	defer coin.initMu.Unlock()
+	if !coin.initialized {
+		return nil
+	}
+	coin.initialized = false
 	coin.log.Info("closing coin")
-	if coin.headers != nil {
-		coin.log.Info("closing headers")
-		if err := coin.headers.Close(); err != nil {
-			return err
-		}
+	if coin.unobserveRates != nil {
+		coin.unobserveRates()
+		coin.unobserveRates = nil
 	}
-	// TODO: shutdown Electrum connection.
-	return nil
+	coin.stopHeadersStatusTimer()
+	// The blockchain is closed even if closing the headers fails, so no connection is leaked.
+	defer func() {
+		coin.log.Info("closing blockchain connection")
+		coin.blockchain.Close()
+	}()
+	coin.log.Info("closing headers")
+	return coin.headers.Close()
 }
@@ -17,6 +17,7 @@ package btc_test
 
 import (
 	"math/big"
+	"net/url"
 	"os"
 	"testing"
 
@@ -24,9 +25,13 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
@@ -34,9 +39,12 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
-const (
-	explorer = "https://some-explorer.com"
-)
+var explorer = coin.BlockExplorer{
+	Name:        "Some explorer",
+	URL:         "https://some-explorer.com/",
+	TxPath:      "tx/",
+	AddressPath: "address/",
+}
 
 func TestMain(m *testing.M) {
 	test.TstSetupLogging()
@@ -57,7 +65,7 @@ type testSuite struct {
 func (s *testSuite) SetupTest() {
 	s.dbFolder = test.TstTempDir("btc-dbfolder")
 
-	s.coin = btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, s.dbFolder, nil,
+	s.coin = btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, s.dbFolder, nil, config.TxFetchThrottle{},
 		explorer, socksproxy.NewSocksProxy(false, ""))
 	blockchainMock := &blockchainMock.BlockchainMock{}
 	blockchainMock.MockHeadersSubscribe = func(
@@ -65,15 +73,18 @@ func (s *testSuite) SetupTest() {
 
 	}
 	s.coin.TstSetMakeBlockchain(func() blockchain.Interface { return blockchainMock })
-	s.coin.Initialize()
+	s.Require().NoError(s.coin.Initialize())
 }
 
 func (s *testSuite) TearDownTest() {
+	s.Require().NoError(s.coin.Close())
 	_ = os.RemoveAll(s.dbFolder)
 }
 
 func TestSuite(t *testing.T) {
 	suite.Run(t, &testSuite{code: coin.CodeTBTC, unit: "TBTC", net: &chaincfg.TestNet3Params})
+	suite.Run(t, &testSuite{code: coin.CodeTBTC4, unit: "TBTC", net: &netparams.TestNet4Params})
+	suite.Run(t, &testSuite{code: coin.CodeSBTC, unit: "TBTC", net: &netparams.SigNetParams})
 	suite.Run(t, &testSuite{code: coin.CodeBTC, unit: "BTC", net: &chaincfg.MainNetParams})
 	suite.Run(t, &testSuite{code: coin.CodeTLTC, unit: "TLTC", net: &ltc.TestNet4Params})
 	suite.Run(t, &testSuite{code: coin.CodeLTC, unit: "LTC", net: &ltc.MainNetParams})
@@ -87,7 +98,148 @@ func (s *testSuite) TestCoin() {
 	s.Require().Equal(s.unit, s.coin.Unit(true))
 	s.Require().Equal(uint(8), s.coin.Decimals(false))
 	s.Require().Equal(uint(8), s.coin.Decimals(true))
-	s.Require().Equal(explorer, s.coin.BlockExplorerTransactionURLPrefix())
+	s.Require().Equal("https://some-explorer.com/tx/", s.coin.BlockExplorerTransactionURLPrefix())
+}
+
+func (s *testSuite) TestSetBlockExplorer() {
+	var events []observable.Event
+	s.coin.Observe(func(event observable.Event) { events = append(events, event) })
+
+	newExplorer := coin.BlockExplorer{
+		Name:        "Other explorer",
+		URL:         "https://other-explorer.com/",
+		TxPath:      "transaction/",
+		AddressPath: "address/",
+	}
+	s.coin.SetBlockExplorer(newExplorer)
+	s.Require().Equal("https://other-explorer.com/transaction/", s.coin.BlockExplorerTransactionURLPrefix())
+	addressURL, err := s.coin.BlockExplorerURL(coin.BlockExplorerAddress, "some address")
+	s.Require().NoError(err)
+	s.Require().Equal("https://other-explorer.com/address/some%20address", addressURL)
+
+	s.Require().Len(events, 1)
+	s.Require().Equal("coins/"+string(s.code)+"/block-explorer", events[0].Subject)
+	s.Require().Equal(newExplorer, events[0].Object)
+}
+
+func (s *testSuite) TestConnectionStatus() {
+	var connectionErr error
+	var onConnectionErrorChanged []func(error)
+	blockchainMock := &blockchainMock.BlockchainMock{}
+	blockchainMock.MockConnectionError = func() error { return connectionErr }
+	blockchainMock.MockRegisterOnConnectionErrorChangedEvent = func(callback func(error)) {
+		onConnectionErrorChanged = append(onConnectionErrorChanged, callback)
+	}
+	coin := btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, test.TstTempDir("btc-dbfolder"),
+		nil, config.TxFetchThrottle{}, explorer, socksproxy.NewSocksProxy(false, ""))
+	coin.TstSetMakeBlockchain(func() blockchain.Interface { return blockchainMock })
+	s.Require().Equal(blockchain.ConnectionStateConnecting, coin.ConnectionStatus().State)
+	s.Require().NoError(coin.Initialize())
+	defer func() { s.Require().NoError(coin.Close()) }()
+	setConnectionErr := func(err error) {
+		connectionErr = err
+		for _, callback := range onConnectionErrorChanged {
+			callback(err)
+		}
+	}
+	s.Require().Equal(blockchain.ConnectionStatus{State: blockchain.ConnectionStateConnected}, coin.ConnectionStatus())
+
+	var events []observable.Event
+	coin.Observe(func(event observable.Event) {
+		if event.Subject == "coins/"+string(s.code)+"/connection/status" {
+			events = append(events, event)
+		}
+	})
+	setConnectionErr(errp.New("unreachable"))
+	s.Require().Len(events, 1)
+	status := events[0].Object.(blockchain.ConnectionStatus)
+	s.Require().Equal(blockchain.ConnectionStateDisconnected, status.State)
+	s.Require().Equal("unreachable", *status.Error)
+
+	setConnectionErr(nil)
+	s.Require().Len(events, 2)
+	s.Require().Equal(blockchain.ConnectionStatus{State: blockchain.ConnectionStateConnected}, events[1].Object)
+}
+
+func (s *testSuite) TestCloseAndReinitialize() {
+	made, closed := 0, 0
+	coin := btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, test.TstTempDir("btc-dbfolder"),
+		nil, config.TxFetchThrottle{}, explorer, socksproxy.NewSocksProxy(false, ""))
+	coin.TstSetMakeBlockchain(func() blockchain.Interface {
+		made++
+		return &blockchainMock.BlockchainMock{
+			MockHeadersSubscribe: func(func(*types.Header)) {},
+			MockClose:            func() { closed++ },
+		}
+	})
+
+	// Closing a coin that was never initialized is a no-op.
+	s.Require().NoError(coin.Close())
+	s.Require().Equal(0, closed)
+
+	s.Require().NoError(coin.Initialize())
+	s.Require().NoError(coin.Initialize())
+	s.Require().Equal(1, made)
+	firstBlockchain, firstHeaders := coin.Blockchain(), coin.Headers()
+
+	s.Require().NoError(coin.Close())
+	s.Require().Equal(1, closed)
+	// Closing again is safe.
+	s.Require().NoError(coin.Close())
+	s.Require().Equal(1, closed)
+
+	// The coin connects again and reopens the headers DB.
+	s.Require().NoError(coin.Initialize())
+	s.Require().Equal(2, made)
+	s.Require().NotSame(firstBlockchain, coin.Blockchain())
+	s.Require().NotSame(firstHeaders, coin.Headers())
+
+	s.Require().NoError(coin.Close())
+	s.Require().Equal(2, closed)
+}
+
+func (s *testSuite) TestObserveRates() {
+	rateUpdater := rates.MockRateUpdater()
+	defer rateUpdater.Stop()
+	coin := btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, test.TstTempDir("btc-dbfolder"),
+		nil, config.TxFetchThrottle{}, explorer, socksproxy.NewSocksProxy(false, ""))
+	coin.TstSetMakeBlockchain(func() blockchain.Interface {
+		return &blockchainMock.BlockchainMock{
+			MockHeadersSubscribe: func(func(*types.Header)) {},
+			MockClose:            func() {},
+		}
+	})
+	coin.SetRateUpdater(rateUpdater)
+	updates := 0
+	unobserve := coin.ObserveRates(func() { updates++ })
+	defer unobserve()
+
+	s.Require().NoError(coin.Initialize())
+	rateUpdater.Notify(observable.Event{Subject: rates.RatesEventSubject})
+	rateUpdater.Notify(observable.Event{Subject: rates.HistoricalRatesEventSubject})
+	rateUpdater.Notify(observable.Event{Subject: "other"})
+	s.Require().Equal(2, updates)
+
+	// The closed coin does not observe the rate updater anymore.
+	s.Require().NoError(coin.Close())
+	rateUpdater.Notify(observable.Event{Subject: rates.RatesEventSubject})
+	s.Require().Equal(2, updates)
+
+	s.Require().NoError(coin.Initialize())
+	rateUpdater.Notify(observable.Event{Subject: rates.RatesEventSubject})
+	s.Require().Equal(3, updates)
+	s.Require().NoError(coin.Close())
+}
+
+func (s *testSuite) TestInitializeEsploraProxyError() {
+	// The proxy address can't be parsed, so no proxied HTTP client can be created.
+	coin := btc.NewCoin(s.code, "Some coin", s.unit, coin.BtcUnitDefault, s.net, test.TstTempDir("btc-dbfolder"),
+		[]*config.ServerInfo{{Server: "https://esplora.example.com/api"}},
+		config.TxFetchThrottle{}, explorer, socksproxy.NewSocksProxy(true, "%invalid"))
+	s.Require().Error(coin.Initialize())
+	// The coin stays uninitialized.
+	s.Require().Nil(coin.Blockchain())
+	s.Require().NoError(coin.Close())
 }
 
 func (s *testSuite) TestFormatAmount() {
@@ -145,6 +297,95 @@ func (s *testSuite) TestParseAmount() {
 	intAmount, err = coinAmount.Int64()
 	s.Require().NoError(err)
 	s.Require().Equal(intSatAmount, intAmount)
+
+	s.coin.SetFormatUnit(coin.BtcUnitDefault)
+	_, err = s.coin.ParseAmount("21000000")
+	s.Require().NoError(err)
+	_, err = s.coin.ParseAmount("84000000.00000001")
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount("-0.00000001")
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount("92233720368.54775808") // int64 overflow in satoshis
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount("21000000.00000001")
+	if s.code != coin.CodeLTC && s.code != coin.CodeTLTC {
+		s.Require().Error(err)
+	} else {
+		s.Require().NoError(err)
+	}
+
+	s.coin.SetFormatUnit(coin.BtcUnitMilliBTC)
+	coinAmount, err = s.coin.ParseAmount("123123.45678")
+	s.Require().NoError(err)
+	intAmount, err = coinAmount.Int64()
+	s.Require().NoError(err)
+	s.Require().Equal(intSatAmount, intAmount)
+	s.coin.SetFormatUnit(coin.BtcUnitDefault)
+
+	// Only plain decimals with at most 8 decimals are accepted.
+	for _, invalid := range []string{"", "1e-8", "0x10", "1/2", "0.000000001", ".5", "1.", "1,5"} {
+		_, err = s.coin.ParseAmount(invalid)
+		s.Require().Error(err, invalid)
+	}
+}
+
+func (s *testSuite) TestParseAmountUnit() {
+	defer s.coin.SetFormatUnit(coin.BtcUnitDefault)
+	parse := func(amount string) int64 {
+		parsed, err := s.coin.ParseAmount(amount)
+		s.Require().NoError(err, amount)
+		sats, err := parsed.Int64()
+		s.Require().NoError(err)
+		return sats
+	}
+	unit := s.coin.Unit(false)
+
+	// The unit overrides the format unit.
+	s.coin.SetFormatUnit(coin.BtcUnitSats)
+	s.Require().Equal(int64(150000000), parse("1.5 "+unit))
+	s.Require().Equal(int64(150000000), parse(" 1.5"+unit+" "))
+	s.Require().Equal(int64(150000), parse("1.5 m"+unit))
+	_, err := s.coin.ParseAmount("1.5 EUR")
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount(unit)
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount("-1 " + unit)
+	s.Require().Error(err)
+	_, err = s.coin.ParseAmount("0.000000001 " + unit)
+	s.Require().Error(err)
+
+	// Parsing the formatted amount with or without unit returns the original amount.
+	for _, formatUnit := range []coin.BtcUnit{coin.BtcUnitDefault, coin.BtcUnitMilliBTC, coin.BtcUnitSats} {
+		if formatUnit == coin.BtcUnitSats && (s.code == coin.CodeLTC || s.code == coin.CodeTLTC) {
+			// Litecoin has no named sat unit, its format unit is always the default one.
+			continue
+		}
+		s.coin.SetFormatUnit(formatUnit)
+		for _, sats := range []int64{0, 1, 10, 99999999, 100000000, 123456789012345, 2100000000000000} {
+			formatted := s.coin.FormatAmount(coin.NewAmountFromInt64(sats), false)
+			s.Require().Equal(sats, parse(formatted), formatted)
+			withUnit := formatted + " " + s.coin.GetFormatUnit(false)
+			s.Require().Equal(sats, parse(withUnit), withUnit)
+		}
+	}
+}
+
+func (s *testSuite) TestFormatUnit() {
+	defer s.coin.SetFormatUnit(coin.BtcUnitDefault)
+	amount := coin.NewAmountFromInt64(1234568910)
+
+	s.coin.SetFormatUnit(coin.BtcUnitSats)
+	s.Require().Equal("1'234'568'910", s.coin.FormatAmount(amount, false))
+	switch s.code {
+	case coin.CodeBTC:
+		s.Require().Equal("sat", s.coin.GetFormatUnit(false))
+	case coin.CodeTBTC, coin.CodeTBTC4, coin.CodeSBTC:
+		s.Require().Equal("tsat", s.coin.GetFormatUnit(false))
+	}
+
+	s.coin.SetFormatUnit(coin.BtcUnitMilliBTC)
+	s.Require().Equal("12345.68910", s.coin.FormatAmount(amount, false))
+	s.Require().Equal("m"+s.unit, s.coin.GetFormatUnit(false))
 }
 
 func (s *testSuite) TestDecodeAddress() {
@@ -174,7 +415,8 @@ func (s *testSuite) TestDecodeAddress() {
 	var validAddresses []string
 	var invalidAddresses []string
 	switch s.code {
-	case coin.CodeTBTC:
+	case coin.CodeTBTC, coin.CodeTBTC4, coin.CodeSBTC:
+		// Testnet, testnet4 and signet share the same address format.
 		validAddresses = tbtcValidAddresses
 		invalidAddresses = append([]string{}, btcValidAddresses...)
 		invalidAddresses = append(invalidAddresses, ltcValidAddresses...)
@@ -204,7 +446,131 @@ func (s *testSuite) TestDecodeAddress() {
 	}
 	for _, invalidAddress := range invalidAddresses {
 		_, err := s.coin.DecodeAddress(invalidAddress)
-		s.Require().Equal(errors.ErrInvalidAddress, errp.Cause(err), invalidAddress)
+		s.Require().Equal(errors.ErrAddressWrongNetwork, errp.Cause(err), invalidAddress)
+	}
+}
+
+func (s *testSuite) TestValidateAddress() {
+	var addresses map[string]string
+	var otherNetwork string
+	switch s.code {
+	case coin.CodeTBTC, coin.CodeTBTC4, coin.CodeSBTC:
+		addresses = map[string]string{
+			"myY3Bbvj5mjwqqvubtu5Hfy2nuCeBfvNXL":                             "p2pkh",
+			"2NBecb6J3HmBBC8RDB9PC2h7EgT9iyza1N3":                            "p2sh",
+			"tb1qp4p8rtxsg3ddz62pntl64s2ddctgtjudkdsg27":                     "p2wpkh",
+			"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7": "p2wsh",
+			"tb1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqp3mvzv": "p2tr",
+		}
+		otherNetwork = "1GM1Wp6t3hJf6U5aq6dG62Pg3c9ePbiUQ9"
+	case coin.CodeBTC:
+		addresses = map[string]string{
+			"1GM1Wp6t3hJf6U5aq6dG62Pg3c9ePbiUQ9":                             "p2pkh",
+			"3GZFjFASPoYh3zuLoJLapYpKHw7ikiH63z":                             "p2sh",
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     "p2wpkh",
+			"bc1qwqdg6squsna38e46795at95yu9atm8azzmyvckulcc7kytlcckxswvvzej": "p2wsh",
+			"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr": "p2tr",
+		}
+		otherNetwork = "tb1qp4p8rtxsg3ddz62pntl64s2ddctgtjudkdsg27"
+	case coin.CodeTLTC:
+		addresses = map[string]string{
+			"mjWrpYaAg7jg5fSXo7Mjt7xwbzRzuEBA39":           "p2pkh",
+			"tltc1q2n65aaawmc94xsyznyr5939uztwjdz3rhvveq0": "p2wpkh",
+		}
+		otherNetwork = "Lc88gfaqBup8k9588fwaP1o73esVsUADoZ"
+	case coin.CodeLTC:
+		addresses = map[string]string{
+			"Lc88gfaqBup8k9588fwaP1o73esVsUADoZ":          "p2pkh",
+			"ltc1qzr0n0a4xs0404fy5l7pl7pj8yj8q34ml27rlcs": "p2wpkh",
+		}
+		otherNetwork = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	default:
+		s.Require().Fail("not all cases tested")
 	}
+	for address, expectedType := range addresses {
+		addressType, err := s.coin.ValidateAddress(address)
+		s.Require().NoError(err, address)
+		s.Require().Equal(expectedType, addressType, address)
+
+		// Changing the last character breaks the checksum.
+		last := address[len(address)-1]
+		typo := address[:len(address)-1] + map[bool]string{true: "q", false: "p"}[last != 'q']
+		_, err = s.coin.ValidateAddress(typo)
+		s.Require().Equal(errors.ErrAddressInvalidChecksum, errp.Cause(err), typo)
+	}
+
+	_, err := s.coin.ValidateAddress(otherNetwork)
+	s.Require().Equal(errors.ErrAddressWrongNetwork, errp.Cause(err))
+
+	_, err = s.coin.ValidateAddress("not an address")
+	s.Require().Equal(errors.ErrInvalidAddress, errp.Cause(err))
+
+	if s.code == coin.CodeBTC {
+		// Witness version 2, valid bech32m address from BIP350.
+		_, err = s.coin.ValidateAddress(
+			"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs")
+		s.Require().Equal(errors.ErrAddressUnsupportedType, errp.Cause(err))
+		// Witness version 16 with a bech32 instead of a bech32m checksum, from BIP350.
+		_, err = s.coin.ValidateAddress(
+			"bc1s0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq54well")
+		s.Require().Error(err)
+		// Taproot with a bech32 instead of a bech32m checksum, from BIP350.
+		_, err = s.coin.ValidateAddress(
+			"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd")
+		s.Require().Equal(errors.ErrAddressInvalidChecksum, errp.Cause(err))
+		// Segwit v0 with a bech32m instead of a bech32 checksum, from BIP350.
+		_, err = s.coin.ValidateAddress(
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh")
+		s.Require().Equal(errors.ErrAddressInvalidChecksum, errp.Cause(err))
+	}
+	if s.code == coin.CodeTBTC {
+		// Segwit v0 with a bech32m instead of a bech32 checksum, from BIP350.
+		_, err = s.coin.ValidateAddress(
+			"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47")
+		s.Require().Equal(errors.ErrAddressInvalidChecksum, errp.Cause(err))
+	}
+	if s.code == coin.CodeLTC {
+		// Taproot is not activated on Litecoin.
+		_, err = s.coin.ValidateAddress(
+			"ltc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqwyej7p")
+		s.Require().Error(err)
+	}
+}
+
+func (s *testSuite) TestPaymentURI() {
+	scheme := "bitcoin"
+	address := "tb1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqp3mvzv"
+	switch s.code {
+	case coin.CodeBTC:
+		address = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	case coin.CodeTLTC:
+		scheme = "litecoin"
+		address = "tltc1q2n65aaawmc94xsyznyr5939uztwjdz3rhvveq0"
+	case coin.CodeLTC:
+		scheme = "litecoin"
+		address = "ltc1qzr0n0a4xs0404fy5l7pl7pj8yj8q34ml27rlcs"
+	}
+
+	s.Require().Equal(scheme+":"+address, s.coin.PaymentURI(address, nil, ""))
+
+	amount := coin.NewAmountFromInt64(123450000)
+	uri := s.coin.PaymentURI(address, &amount, "Rent & utilities #3")
+	s.Require().Equal(
+		scheme+":"+address+"?amount=1.2345&label=Rent%20%26%20utilities%20%233", uri)
+
+	// The address round-trips, including bech32m taproot addresses.
+	parsed, err := url.Parse(uri)
+	s.Require().NoError(err)
+	s.Require().Equal(scheme, parsed.Scheme)
+	s.Require().Equal(address, parsed.Opaque)
+	s.Require().Equal("Rent & utilities #3", parsed.Query().Get("label"))
+	s.Require().Equal("1.2345", parsed.Query().Get("amount"))
+	decoded, err := s.coin.DecodeAddress(parsed.Opaque)
+	s.Require().NoError(err)
+	s.Require().Equal(address, decoded.EncodeAddress())
 
+	oneSat := coin.NewAmountFromInt64(1)
+	s.Require().Equal(scheme+":"+address+"?amount=0.00000001", s.coin.PaymentURI(address, &oneSat, ""))
+	oneCoin := coin.NewAmountFromInt64(1e8)
+	s.Require().Equal(scheme+":"+address+"?amount=1", s.coin.PaymentURI(address, &oneCoin, ""))
 }
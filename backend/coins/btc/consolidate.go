@@ -0,0 +1,176 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ConsolidationArgs are the arguments needed to consolidate many small outputs of the account into
+// a single output.
+type ConsolidationArgs struct {
+	FeeTargetCode accounts.FeeTargetCode
+	// Only applies if FeeTargetCode == Custom. It is provided in sat/vB.
+	CustomFee string
+	// FutureFee is the fee rate in sat/vB expected when the coins are spent later. If empty, the fee
+	// rate of the normal fee target is used.
+	FutureFee string
+	// MaxInputs caps the number of consolidated outputs. If 0, maketx.MaxConsolidationInputs is used.
+	MaxInputs int
+}
+
+// Consolidation is a transaction spending many small outputs of the account to a single output of
+// the account.
+type Consolidation struct {
+	*TransactionProposal
+	// Saving is the fee expected to be saved when spending the consolidated coins later, see
+	// maketx.ConsolidationSaving(). It is higher than the fee of the consolidation.
+	Saving btcutil.Amount
+}
+
+// consolidationCandidates returns the outputs which can be consolidated at the given fee rate,
+// sorted by value ascending. Outputs which cost more to spend than they are worth, which do not
+// have enough confirmations, which are flagged or frozen, or which are hidden dust are skipped.
+func (account *Account) consolidationCandidates(
+	feeRatePerKb btcutil.Amount) (map[wire.OutPoint]maketx.UTXO, []wire.OutPoint, error) {
+	utxos, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates := map[wire.OutPoint]maketx.UTXO{}
+	outPoints := []wire.OutPoint{}
+	for outPoint, txOut := range utxos {
+		address := account.getAddress(blockchain.NewScriptHashHex(txOut.TxOut.PkScript))
+		if address == nil {
+			continue
+		}
+		if !account.hasMinConfirmations(txOut) || account.flagLabel(outPoint, txOut.TxOut) != "" ||
+			account.isFrozen(outPoint) || account.isHiddenDust(txOut) {
+			continue
+		}
+		if btcutil.Amount(txOut.Value) <= maketx.InputFee(address.Configuration, feeRatePerKb, account.log) {
+			continue
+		}
+		candidates[outPoint] = maketx.UTXO{TxOut: txOut.TxOut, Configuration: address.Configuration}
+		outPoints = append(outPoints, outPoint)
+	}
+	sort.Slice(outPoints, func(i, j int) bool {
+		valueI, valueJ := candidates[outPoints[i]].TxOut.Value, candidates[outPoints[j]].TxOut.Value
+		if valueI != valueJ {
+			return valueI < valueJ
+		}
+		return outPoints[i].String() < outPoints[j].String()
+	})
+	return candidates, outPoints, nil
+}
+
+// ConsolidationProposal creates a transaction spending the smallest outputs of the account, at most
+// args.MaxInputs, to a single change address of the account. It is refused if the fee of the
+// transaction is not lower than the fee it is expected to save when the coins are spent later.
+//
+// Like with TxProposal(), the transaction is stored as the active tx proposal and can be signed
+// and sent with SendTx().
+func (account *Account) ConsolidationProposal(args *ConsolidationArgs) (*Consolidation, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	maxInputs := args.MaxInputs
+	if maxInputs == 0 {
+		maxInputs = maketx.MaxConsolidationInputs
+	}
+	if maxInputs < 2 || maxInputs > maketx.MaxConsolidationInputs {
+		return nil, errp.Newf("The number of inputs must be between 2 and %d", maketx.MaxConsolidationInputs)
+	}
+	feeRatePerKb, err := account.getFeePerKb(&accounts.TxProposalArgs{
+		FeeTargetCode: args.FeeTargetCode,
+		CustomFee:     args.CustomFee,
+	})
+	if err != nil {
+		return nil, err
+	}
+	futureFeeArgs := &accounts.TxProposalArgs{FeeTargetCode: accounts.FeeTargetCodeNormal}
+	if args.FutureFee != "" {
+		futureFeeArgs = &accounts.TxProposalArgs{
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     args.FutureFee,
+		}
+	}
+	futureFeeRatePerKb, err := account.getFeePerKb(futureFeeArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Synchronizer.WaitSynchronized()
+	candidates, outPoints, err := account.consolidationCandidates(feeRatePerKb)
+	if err != nil {
+		return nil, err
+	}
+	if len(outPoints) > maxInputs {
+		outPoints = outPoints[:maxInputs]
+	}
+	if len(outPoints) < 2 {
+		return nil, errp.WithStack(errors.ErrNothingToConsolidate)
+	}
+	selected := make(map[wire.OutPoint]maketx.UTXO, len(outPoints))
+	inputConfigurations := make([]*signing.Configuration, len(outPoints))
+	for index, outPoint := range outPoints {
+		selected[outPoint] = candidates[outPoint]
+		inputConfigurations[index] = candidates[outPoint].Configuration
+	}
+
+	address, err := account.pickChangeAddress(selected)
+	if err != nil {
+		return nil, err
+	}
+	txProposal, err := maketx.NewTxSpendAll(
+		account.coin,
+		selected,
+		address.PubkeyScript(),
+		nil,
+		feeRatePerKb,
+		nil,
+		account.log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	saving := maketx.ConsolidationSaving(
+		inputConfigurations, address.Configuration, futureFeeRatePerKb, account.log)
+	account.log.Infof("Consolidating %d outputs, fee: %s, expected saving: %s",
+		len(outPoints), txProposal.Fee, saving)
+	if txProposal.Fee >= saving {
+		return nil, errp.WithStack(errors.ErrConsolidationUneconomical)
+	}
+	// The output is sent to the account, which lets the keystore verify it like a change output.
+	txProposal.ChangeAddress = address
+	transactionProposal, err := account.newTransactionProposal(txProposal)
+	if err != nil {
+		return nil, err
+	}
+
+	defer account.activeTxProposalLock.Lock()()
+	account.activeTxProposal = transactionProposal
+	account.BaseAccount.PinRatesSnapshot()
+	return &Consolidation{TransactionProposal: transactionProposal, Saving: saving}, nil
+}
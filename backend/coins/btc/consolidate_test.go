@@ -0,0 +1,60 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidationProposal(t *testing.T) {
+	blockchain := &blockchainMock.BlockchainMock{}
+	blockchain.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchain.MockRelayFee = func() (btcutil.Amount, error) { return 1000, nil }
+	account := mockAccountWithBlockchain(t, nil, blockchain)
+
+	args := &btc.ConsolidationArgs{
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "1",
+		FutureFee:     "20",
+	}
+	_, err := account.ConsolidationProposal(args)
+	require.Error(t, err)
+
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	_, err = account.ConsolidationProposal(args)
+	require.Equal(t, errors.ErrNothingToConsolidate, errp.Cause(err))
+
+	for _, maxInputs := range []int{-1, 1, 601} {
+		args.MaxInputs = maxInputs
+		_, err = account.ConsolidationProposal(args)
+		require.Error(t, err)
+		require.NotEqual(t, errors.ErrNothingToConsolidate, errp.Cause(err))
+	}
+
+	args.MaxInputs = 0
+	args.CustomFee = "0.5"
+	_, err = account.ConsolidationProposal(args)
+	require.Equal(t, errors.ErrFeeTooLow, errp.Cause(err))
+}
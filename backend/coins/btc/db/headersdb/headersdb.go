@@ -16,6 +16,7 @@ package headersdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"os"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -26,12 +27,23 @@ import (
 
 const headerSize = 80
 
+// prunedMagic starts the record written in place of the first header once the database was
+// pruned, see PruneBelow(). It can't be confused with the genesis header, which starts with the
+// block version 1.
+var prunedMagic = []byte("prunedDB")
+
 // DB is a database for storing headers. The database is simply a file where headers are appended
 // to. Loolup is quick as each header is 80 bytes.
+//
+// If the database was pruned, the file starts with a record containing the height of the first
+// stored header instead of the genesis header, followed by the headers from that height on.
 type DB struct {
-	file *os.File
-	log  *logrus.Entry
-	lock locker.Locker
+	filename string
+	file     *os.File
+	// prunedBelow is the height of the first stored header, 0 if the database was not pruned.
+	prunedBelow int
+	log         *logrus.Entry
+	lock        locker.Locker
 }
 
 // NewDB creates/opens a new db.
@@ -41,8 +53,13 @@ func NewDB(filename string, log *logrus.Entry) (*DB, error) {
 		return nil, errp.WithStack(err)
 	}
 	db := &DB{
-		file: file,
-		log:  log,
+		filename: filename,
+		file:     file,
+		log:      log,
+	}
+	if err := db.readPrunedRecord(); err != nil {
+		_ = file.Close()
+		return nil, err
 	}
 	if err := db.fixTrailingZeroesHeaders(); err != nil {
 		return nil, err
@@ -50,6 +67,38 @@ func NewDB(filename string, log *logrus.Entry) (*DB, error) {
 	return db, nil
 }
 
+// readPrunedRecord loads the height below which the database was pruned from the first record of
+// the file, if it is a pruned record.
+func (db *DB) readPrunedRecord() error {
+	record := make([]byte, headerSize)
+	n, err := db.file.ReadAt(record, 0)
+	if n < headerSize {
+		// Empty file, or an interrupted first write which is fixed by fixTrailingZeroesHeaders().
+		return nil
+	}
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if !bytes.HasPrefix(record, prunedMagic) {
+		return nil
+	}
+	prunedBelow := binary.LittleEndian.Uint64(record[len(prunedMagic):])
+	if prunedBelow == 0 || prunedBelow > 1<<31 {
+		return errp.Newf("invalid pruned height %d", prunedBelow)
+	}
+	db.prunedBelow = int(prunedBelow)
+	return nil
+}
+
+// offset returns the position of the header at the given height in the file.
+func (db *DB) offset(height int) int64 {
+	if db.prunedBelow == 0 {
+		return headerSize * int64(height)
+	}
+	// The first record is the pruned record.
+	return headerSize * int64(height-db.prunedBelow+1)
+}
+
 // fixTrailingZeroesHeaders deletes trailing headers that are stored as zero bytes. Zero headers
 // don't exist in reality and could end up in the database file as a result of an interrupted
 // `file.WriteAt()` call.
@@ -81,10 +130,16 @@ func (db *DB) tip() (int, error) {
 	if err != nil {
 		return 0, errp.WithStack(err)
 	}
-	return int(fileInfo.Size()/headerSize) - 1, nil
+	numRecords := int(fileInfo.Size() / headerSize)
+	if db.prunedBelow == 0 {
+		return numRecords - 1, nil
+	}
+	return db.prunedBelow + numRecords - 2, nil
 }
 
-// RevertTo implements headers.DBInterface.
+// RevertTo implements headers.DBInterface. If the database was pruned and the new tip is below the
+// first stored header, all headers are deleted, so they are downloaded again from the genesis
+// block.
 func (db *DB) RevertTo(tip int) error {
 	defer db.lock.Lock()()
 	if tip < -1 {
@@ -97,12 +152,136 @@ func (db *DB) RevertTo(tip int) error {
 	if tip > currentTip {
 		panic("revert must go backwards")
 	}
-	if err := db.file.Truncate(headerSize * int64(tip+1)); err != nil {
+	if db.prunedBelow != 0 && tip < db.prunedBelow {
+		db.log.Warningf("Reverting to %d, below the pruned headers at %d. Deleting all headers.",
+			tip, db.prunedBelow)
+		if err := db.file.Truncate(0); err != nil {
+			return errp.WithStack(err)
+		}
+		db.prunedBelow = 0
+		return nil
+	}
+	if err := db.file.Truncate(db.offset(tip + 1)); err != nil {
 		return err
 	}
 	return nil
 }
 
+// PruneBelow implements headers.DBInterface. The headers are copied to a new file which replaces
+// the database file, so an interruption leaves either the old or the pruned database.
+func (db *DB) PruneBelow(height int) error {
+	defer db.lock.Lock()()
+	if height <= db.prunedBelow {
+		return nil
+	}
+	tip, err := db.tip()
+	if err != nil {
+		return err
+	}
+	if height > tip {
+		return errp.Newf("can't prune below %d, the tip is %d", height, tip)
+	}
+	headersBytes := make([]byte, headerSize*int64(tip-height+1))
+	if _, err := db.file.ReadAt(headersBytes, db.offset(height)); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := db.replaceFile(height, headersBytes); err != nil {
+		return err
+	}
+	db.log.Infof("Pruned the headers below %d", height)
+	return nil
+}
+
+// RestoreBelow implements headers.DBInterface. Like PruneBelow(), the database file is replaced
+// with a new file, so an interruption leaves either the old or the restored database.
+func (db *DB) RestoreBelow(height int, headers []*wire.BlockHeader) error {
+	defer db.lock.Lock()()
+	tip, err := db.tip()
+	if err != nil {
+		return err
+	}
+	empty := tip == -1 && db.prunedBelow == 0
+	if height < 0 || (!empty && height+len(headers) != db.prunedBelow) {
+		return errp.Newf("can't restore %d headers from %d, the headers are pruned below %d",
+			len(headers), height, db.prunedBelow)
+	}
+	var data bytes.Buffer
+	for _, header := range headers {
+		if err := header.Serialize(&data); err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	if !empty {
+		storedBytes := make([]byte, headerSize*int64(tip-db.prunedBelow+1))
+		if _, err := db.file.ReadAt(storedBytes, db.offset(db.prunedBelow)); err != nil {
+			return errp.WithStack(err)
+		}
+		data.Write(storedBytes)
+	}
+	if err := db.replaceFile(height, data.Bytes()); err != nil {
+		return err
+	}
+	db.log.Infof("Restored the headers from %d", height)
+	return nil
+}
+
+// replaceFile replaces the database file with the given headers starting at the given height,
+// preceded by the pruned record if the height is not 0. Must be called with the write lock held.
+func (db *DB) replaceFile(height int, headersBytes []byte) error {
+	var data []byte
+	if height != 0 {
+		record := make([]byte, headerSize)
+		copy(record, prunedMagic)
+		binary.LittleEndian.PutUint64(record[len(prunedMagic):], uint64(height))
+		data = append(record, headersBytes...)
+	} else {
+		data = headersBytes
+	}
+
+	tmpFilename := db.filename + ".tmp"
+	if err := writeFileSynced(tmpFilename, data); err != nil {
+		return err
+	}
+	// The database file is closed before it is replaced, which is required on Windows.
+	if err := db.file.Close(); err != nil {
+		db.log.WithError(err).Error("Could not close the headers DB before replacing it")
+	}
+	renameErr := os.Rename(tmpFilename, db.filename)
+	file, err := os.OpenFile(db.filename, os.O_RDWR, 0600)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	db.file = file
+	if renameErr != nil {
+		return errp.WithStack(renameErr)
+	}
+	db.prunedBelow = height
+	return nil
+}
+
+// writeFileSynced writes the file and flushes it to the filesystem.
+func writeFileSynced(filename string, data []byte) error {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return errp.WithStack(err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(file.Close())
+}
+
+// PrunedBelow returns the height of the first stored header, 0 if the database was not pruned.
+func (db *DB) PrunedBelow() int {
+	defer db.lock.RLock()()
+	return db.prunedBelow
+}
+
 // Tip implements headers.DBInterface.
 func (db *DB) Tip() (int, error) {
 	defer db.lock.RLock()()
@@ -115,6 +294,9 @@ func (db *DB) PutHeader(height int, header *wire.BlockHeader) error {
 		panic("invalid height")
 	}
 	defer db.lock.Lock()()
+	if height < db.prunedBelow {
+		return errp.Newf("can't put header %d below the pruned headers at %d", height, db.prunedBelow)
+	}
 	var headerSer bytes.Buffer
 	if err := header.Serialize(&headerSer); err != nil {
 		return errp.WithStack(err)
@@ -122,7 +304,7 @@ func (db *DB) PutHeader(height int, header *wire.BlockHeader) error {
 	// This call, if interrupted, can leave zero bytes at the end of the file without writing the
 	// data. We can't fix it here as the process may have ended. It is fixed at DB loading time, see
 	// `fixTrailingZeroesHeaders()`.
-	if _, err := db.file.WriteAt(headerSer.Bytes(), headerSize*int64(height)); err != nil {
+	if _, err := db.file.WriteAt(headerSer.Bytes(), db.offset(height)); err != nil {
 		return errp.WithStack(err)
 	}
 	return nil
@@ -135,11 +317,11 @@ func (db *DB) HeaderByHeight(height int) (*wire.BlockHeader, error) {
 	if err != nil {
 		return nil, err
 	}
-	if tip < height {
+	if tip < height || height < db.prunedBelow {
 		return nil, nil
 	}
 	headerBytes := make([]byte, headerSize)
-	if _, err := db.file.ReadAt(headerBytes, headerSize*int64(height)); err != nil {
+	if _, err := db.file.ReadAt(headerBytes, db.offset(height)); err != nil {
 		return nil, errp.WithStack(err)
 	}
 	if bytes.Equal(headerBytes, bytes.Repeat([]byte{0}, headerSize)) {
@@ -77,3 +77,141 @@ func TestFixTrailingZeroes(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, tip)
 }
+
+func TestPruneBelow(t *testing.T) {
+	filename := test.TstTempFile("headersdb")
+	db, err := NewDB(filename, log)
+	require.NoError(t, err)
+
+	for height := 0; height < 30; height++ {
+		require.NoError(t, db.PutHeader(height, &wire.BlockHeader{Nonce: uint32(height)}))
+	}
+	require.Error(t, db.PruneBelow(30))
+	require.NoError(t, db.PruneBelow(10))
+	require.Equal(t, 10, db.PrunedBelow())
+	// Pruning below a lower height does nothing.
+	require.NoError(t, db.PruneBelow(5))
+	require.Equal(t, 10, db.PrunedBelow())
+
+	requireHeaders := func(db *DB, tip int) {
+		t.Helper()
+		actualTip, err := db.Tip()
+		require.NoError(t, err)
+		require.Equal(t, tip, actualTip)
+		for height := 0; height <= tip; height++ {
+			header, err := db.HeaderByHeight(height)
+			require.NoError(t, err)
+			if height < db.PrunedBelow() {
+				require.Nil(t, header, height)
+				continue
+			}
+			require.NotNil(t, header, height)
+			require.Equal(t, uint32(height), header.Nonce)
+		}
+	}
+	requireHeaders(db, 29)
+	require.Error(t, db.PutHeader(9, &wire.BlockHeader{}))
+	require.NoError(t, db.PutHeader(30, &wire.BlockHeader{Nonce: 30}))
+	requireHeaders(db, 30)
+
+	// The pruned height is persisted.
+	require.NoError(t, db.Close())
+	db, err = NewDB(filename, log)
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 10, db.PrunedBelow())
+	requireHeaders(db, 30)
+
+	// Pruning again.
+	require.NoError(t, db.PruneBelow(20))
+	requireHeaders(db, 30)
+
+	// Reverting within the stored headers.
+	require.NoError(t, db.RevertTo(25))
+	requireHeaders(db, 25)
+	require.Equal(t, 20, db.PrunedBelow())
+
+	// Reverting below the pruned headers deletes all headers.
+	require.NoError(t, db.RevertTo(15))
+	requireHeaders(db, -1)
+	require.Equal(t, 0, db.PrunedBelow())
+	require.NoError(t, db.PutHeader(0, &wire.BlockHeader{Nonce: 0}))
+	requireHeaders(db, 0)
+}
+
+func TestRestoreBelow(t *testing.T) {
+	filename := test.TstTempFile("headersdb")
+	db, err := NewDB(filename, log)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for height := 0; height < 30; height++ {
+		require.NoError(t, db.PutHeader(height, &wire.BlockHeader{Nonce: uint32(height)}))
+	}
+	require.NoError(t, db.PruneBelow(20))
+	headersRange := func(from, to int) []*wire.BlockHeader {
+		result := []*wire.BlockHeader{}
+		for height := from; height < to; height++ {
+			result = append(result, &wire.BlockHeader{Nonce: uint32(height)})
+		}
+		return result
+	}
+	requireHeaders := func(prunedBelow int) {
+		t.Helper()
+		require.Equal(t, prunedBelow, db.PrunedBelow())
+		tip, err := db.Tip()
+		require.NoError(t, err)
+		require.Equal(t, 29, tip)
+		for height := prunedBelow; height <= tip; height++ {
+			header, err := db.HeaderByHeight(height)
+			require.NoError(t, err)
+			require.NotNil(t, header, height)
+			require.Equal(t, uint32(height), header.Nonce)
+		}
+	}
+
+	// The headers must end right below the first stored header.
+	require.Error(t, db.RestoreBelow(10, headersRange(10, 19)))
+	require.Error(t, db.RestoreBelow(10, headersRange(10, 21)))
+	requireHeaders(20)
+
+	require.NoError(t, db.RestoreBelow(10, headersRange(10, 20)))
+	requireHeaders(10)
+
+	// Restoring down to the genesis header removes the pruned record.
+	require.NoError(t, db.RestoreBelow(0, headersRange(0, 10)))
+	requireHeaders(0)
+	require.NoError(t, db.Close())
+	db, err = NewDB(filename, log)
+	require.NoError(t, err)
+	requireHeaders(0)
+}
+
+func TestRestoreBelowEmpty(t *testing.T) {
+	filename := test.TstTempFile("headersdb")
+	db, err := NewDB(filename, log)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The headers become the first stored headers, the headers below are treated as pruned.
+	require.NoError(t, db.RestoreBelow(10, []*wire.BlockHeader{{Nonce: 10}, {Nonce: 11}}))
+	require.Equal(t, 10, db.PrunedBelow())
+	tip, err := db.Tip()
+	require.NoError(t, err)
+	require.Equal(t, 11, tip)
+	header, err := db.HeaderByHeight(9)
+	require.NoError(t, err)
+	require.Nil(t, header)
+	header, err = db.HeaderByHeight(11)
+	require.NoError(t, err)
+	require.Equal(t, uint32(11), header.Nonce)
+	require.NoError(t, db.PutHeader(12, &wire.BlockHeader{Nonce: 12}))
+
+	require.NoError(t, db.Close())
+	db, err = NewDB(filename, log)
+	require.NoError(t, err)
+	require.Equal(t, 10, db.PrunedBelow())
+	tip, err = db.Tip()
+	require.NoError(t, err)
+	require.Equal(t, 12, tip)
+}
@@ -37,6 +37,8 @@ const (
 	bucketOutputsKey                = "outputs"
 	bucketAddressHistoriesKey       = "addressHistories"
 	bucketConfigKey                 = "config"
+	bucketOutgoingTransactionsKey   = "outgoingTransactions"
+	bucketHandedOutAddressesKey     = "handedOutAddresses"
 )
 
 // DB is a bbolt key/value database.
@@ -53,6 +55,16 @@ func NewDB(filename string) (*DB, error) {
 	return &DB{db: db}, nil
 }
 
+// NewReadOnlyDB opens an existing db for reading. It fails if the db is opened for writing elsewhere
+// for longer than a second.
+func NewReadOnlyDB(filename string) (*DB, error) {
+	db, err := bbolt.Open(filename, 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &DB{db: db}, nil
+}
+
 // Begin implements transactions.Begin.
 func (db *DB) Begin(writable bool) (transactions.DBTxInterface, error) {
 	tx, err := db.db.Begin(writable)
@@ -179,7 +191,7 @@ func (tx *Tx) DeleteTx(txHash chainhash.Hash) {
 // AddAddressToTx implements transactions.DBTxInterface.
 func (tx *Tx) AddAddressToTx(txHash chainhash.Hash, scriptHashHex blockchain.ScriptHashHex) error {
 	return tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
-		walletTx.Addresses[string(scriptHashHex)] = true
+		walletTx.Addresses[scriptHashHex.String()] = true
 	})
 }
 
@@ -187,7 +199,7 @@ func (tx *Tx) AddAddressToTx(txHash chainhash.Hash, scriptHashHex blockchain.Scr
 func (tx *Tx) RemoveAddressFromTx(txHash chainhash.Hash, scriptHashHex blockchain.ScriptHashHex) (bool, error) {
 	var empty bool
 	err := tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
-		delete(walletTx.Addresses, string(scriptHashHex))
+		delete(walletTx.Addresses, scriptHashHex.String())
 		empty = len(walletTx.Addresses) == 0
 	})
 	return empty, err
@@ -221,7 +233,8 @@ func (tx *Tx) UnverifiedTransactions() ([]chainhash.Hash, error) {
 }
 
 // MarkTxVerified implements transactions.DBTxInterface.
-func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) error {
+func (tx *Tx) MarkTxVerified(
+	txHash chainhash.Hash, headerTimestamp time.Time, merkleProof *transactions.MerkleProof) error {
 	bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
 	if err != nil {
 		panic(errp.WithStack(err))
@@ -233,6 +246,23 @@ func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) e
 		truth := true
 		walletTx.Verified = &truth
 		walletTx.HeaderTimestamp = &headerTimestamp
+		walletTx.MerkleProof = merkleProof
+	})
+}
+
+// MarkTxUnverified implements transactions.DBTxInterface.
+func (tx *Tx) MarkTxUnverified(txHash chainhash.Hash) error {
+	bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := bucketUnverifiedTransactions.Put(txHash[:], nil); err != nil {
+		return errp.WithStack(err)
+	}
+	return tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
+		walletTx.Verified = nil
+		walletTx.HeaderTimestamp = nil
+		walletTx.MerkleProof = nil
 	})
 }
 
@@ -335,17 +365,87 @@ func (tx *Tx) PutAddressHistory(scriptHashHex blockchain.ScriptHashHex, history
 	if err != nil {
 		return errp.WithStack(err)
 	}
-	return writeJSON(bucketAddressHistories, []byte(string(scriptHashHex)), history)
+	return writeJSON(bucketAddressHistories, []byte(scriptHashHex.String()), history)
 }
 
 // AddressHistory implements transactions.DBTxInterface.
 func (tx *Tx) AddressHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
 	history := blockchain.TxHistory{}
 	bucketAddressHistories := tx.tx.Bucket([]byte(bucketAddressHistoriesKey))
-	_, err := readJSON(bucketAddressHistories, []byte(string(scriptHashHex)), &history)
+	_, err := readJSON(bucketAddressHistories, []byte(scriptHashHex.String()), &history)
 	return history, err
 }
 
+// PutOutgoingTransaction implements transactions.DBTxInterface.
+func (tx *Tx) PutOutgoingTransaction(outgoingTx *transactions.OutgoingTransaction) error {
+	bucketOutgoingTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketOutgoingTransactionsKey))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	txHash := outgoingTx.Tx.TxHash()
+	return writeJSON(bucketOutgoingTransactions, txHash[:], outgoingTx)
+}
+
+// OutgoingTransaction implements transactions.DBTxInterface.
+func (tx *Tx) OutgoingTransaction(txHash chainhash.Hash) (*transactions.OutgoingTransaction, error) {
+	outgoingTx := &transactions.OutgoingTransaction{}
+	found, err := readJSON(tx.tx.Bucket([]byte(bucketOutgoingTransactionsKey)), txHash[:], outgoingTx)
+	if err != nil || !found {
+		return nil, err
+	}
+	return outgoingTx, nil
+}
+
+// OutgoingTransactions implements transactions.DBTxInterface.
+func (tx *Tx) OutgoingTransactions() ([]*transactions.OutgoingTransaction, error) {
+	result := []*transactions.OutgoingTransaction{}
+	bucketOutgoingTransactions := tx.tx.Bucket([]byte(bucketOutgoingTransactionsKey))
+	if bucketOutgoingTransactions == nil {
+		return result, nil
+	}
+	cursor := bucketOutgoingTransactions.Cursor()
+	for txHash, jsonBytes := cursor.First(); txHash != nil; txHash, jsonBytes = cursor.Next() {
+		outgoingTx := &transactions.OutgoingTransaction{}
+		if err := json.Unmarshal(jsonBytes, outgoingTx); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if hash := outgoingTx.Tx.TxHash(); !bytes.Equal(hash[:], txHash) {
+			return nil, errp.New("deserialized tx hash does not match the key")
+		}
+		result = append(result, outgoingTx)
+	}
+	return result, nil
+}
+
+// DeleteOutgoingTransaction implements transactions.DBTxInterface.
+func (tx *Tx) DeleteOutgoingTransaction(txHash chainhash.Hash) {
+	bucketOutgoingTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketOutgoingTransactionsKey))
+	if err != nil {
+		panic(errp.WithStack(err))
+	}
+	if err := bucketOutgoingTransactions.Delete(txHash[:]); err != nil {
+		panic(errp.WithStack(err))
+	}
+}
+
+// PutHandedOutAddress implements transactions.DBTxInterface.
+func (tx *Tx) PutHandedOutAddress(scriptHashHex blockchain.ScriptHashHex) error {
+	bucketHandedOutAddresses, err := tx.tx.CreateBucketIfNotExists([]byte(bucketHandedOutAddressesKey))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(bucketHandedOutAddresses.Put([]byte(scriptHashHex.String()), []byte{1}))
+}
+
+// IsHandedOutAddress implements transactions.DBTxInterface.
+func (tx *Tx) IsHandedOutAddress(scriptHashHex blockchain.ScriptHashHex) (bool, error) {
+	bucketHandedOutAddresses := tx.tx.Bucket([]byte(bucketHandedOutAddressesKey))
+	if bucketHandedOutAddresses == nil {
+		return false, nil
+	}
+	return bucketHandedOutAddresses.Get([]byte(scriptHashHex.String())) != nil, nil
+}
+
 // PutGapLimits implements transactions.DBTxInterface.
 func (tx *Tx) PutGapLimits(limits types.GapLimits) error {
 	bucketConfig, err := tx.tx.CreateBucketIfNotExists([]byte(bucketConfigKey))
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil"
@@ -251,17 +252,35 @@ func TestTxQuick(t *testing.T) {
 			txHash := txHash
 			t.Run("", func(t *testing.T) {
 				expectedHeaderTimestamp := time.Unix(time.Now().Unix(), 123)
-				require.NoError(t, tx.MarkTxVerified(txHash, expectedHeaderTimestamp))
+				expectedMerkleProof := &transactions.MerkleProof{
+					BlockHash: chainhash.HashH([]byte("block")),
+					Pos:       3,
+					Merkle:    []blockchain.TXHash{blockchain.TXHash(chainhash.HashH([]byte("sibling")))},
+				}
+				require.NoError(t, tx.MarkTxVerified(txHash, expectedHeaderTimestamp, expectedMerkleProof))
 				delete(allUnverifiedTxHashes, txHash)
 				require.True(t, checkTxHashes())
 				txInfo, err := tx.TxInfo(txHash)
 				require.NoError(t, err)
 				require.Equal(t, expectedHeaderTimestamp.String(), txInfo.HeaderTimestamp.String())
+				require.Equal(t, expectedMerkleProof, txInfo.MerkleProof)
 				now := time.Now()
 				require.NotNil(t, txInfo.CreatedTimestamp)
 				require.True(t,
 					!txInfo.CreatedTimestamp.After(now) || *txInfo.CreatedTimestamp == now)
 
+				require.NoError(t, tx.MarkTxUnverified(txHash))
+				allUnverifiedTxHashes[txHash] = struct{}{}
+				require.True(t, checkTxHashes())
+				txInfo, err = tx.TxInfo(txHash)
+				require.NoError(t, err)
+				require.Nil(t, txInfo.Verified)
+				require.Nil(t, txInfo.HeaderTimestamp)
+				require.Nil(t, txInfo.MerkleProof)
+				require.NoError(t, tx.MarkTxVerified(txHash, expectedHeaderTimestamp, expectedMerkleProof))
+				delete(allUnverifiedTxHashes, txHash)
+				require.True(t, checkTxHashes())
+
 				tx.DeleteTx(txHash)
 				delete(allTxHashes, txHash)
 				require.True(t, checkTxHashes())
@@ -484,13 +503,18 @@ func TestAddressHistory(t *testing.T) {
 			{Height: 15, TXHash: blockchain.TXHash(hash2)},
 		}
 
+		scriptHashHex1, err := blockchain.ParseScriptHashHex(key1)
+		require.NoError(t, err)
+		scriptHashHex2, err := blockchain.ParseScriptHashHex(key2)
+		require.NoError(t, err)
+
 		// Does not exist yet.
-		history, err := tx.AddressHistory(key1)
+		history, err := tx.AddressHistory(scriptHashHex1)
 		require.NoError(t, err)
 		require.Equal(t, blockchain.TxHistory{}, history)
 
-		require.NoError(t, tx.PutAddressHistory(key1, txHistory1))
-		require.NoError(t, tx.PutAddressHistory(key2, txHistory2))
+		require.NoError(t, tx.PutAddressHistory(scriptHashHex1, txHistory1))
+		require.NoError(t, tx.PutAddressHistory(scriptHashHex2, txHistory2))
 
 		// Test actual db store against fixtures to ensure compatibility does not break
 		require.Equal(t,
@@ -502,11 +526,11 @@ func TestAddressHistory(t *testing.T) {
 			string(getRawValue(tx, "addressHistories", []byte(key2))),
 		)
 
-		history, err = tx.AddressHistory(key1)
+		history, err = tx.AddressHistory(scriptHashHex1)
 		require.NoError(t, err)
 		require.Equal(t, txHistory1, history)
 
-		history, err = tx.AddressHistory(key2)
+		history, err = tx.AddressHistory(scriptHashHex2)
 		require.NoError(t, err)
 		require.Equal(t, txHistory2, history)
 
@@ -517,7 +541,10 @@ func TestAddressHistory(t *testing.T) {
 func TestAddressHistoryQuick(t *testing.T) {
 	testTx(func(tx *Tx) {
 		f := func(scriptHash chainhash.Hash, expectedHistory blockchain.TxHistory) bool {
-			scriptHashHex := blockchain.ScriptHashHex(hex.EncodeToString(scriptHash[:]))
+			scriptHashHex, err := blockchain.ParseScriptHashHex(hex.EncodeToString(scriptHash[:]))
+			if err != nil {
+				return false
+			}
 			if err := tx.PutAddressHistory(scriptHashHex, expectedHistory); err != nil {
 				return false
 			}
@@ -553,3 +580,78 @@ func TestGapLimits(t *testing.T) {
 		require.Equal(t, uint16(123), limits.Change)
 	})
 }
+
+func TestOutgoingTransactions(t *testing.T) {
+	testTx(func(tx *Tx) {
+		outgoingTxs, err := tx.OutgoingTransactions()
+		require.NoError(t, err)
+		require.Empty(t, outgoingTxs)
+
+		msgTx1 := wire.NewMsgTx(2)
+		msgTx1.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+		msgTx1.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+		msgTx2 := wire.NewMsgTx(2)
+		msgTx2.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 2}, nil, nil))
+		msgTx2.AddTxOut(wire.NewTxOut(2000, []byte{0x51}))
+
+		// no-op, does not exist yet
+		tx.DeleteOutgoingTransaction(msgTx1.TxHash())
+		outgoingTx, err := tx.OutgoingTransaction(msgTx1.TxHash())
+		require.NoError(t, err)
+		require.Nil(t, outgoingTx)
+
+		lastBroadcast := time.Unix(1700000000, 0).UTC()
+		require.NoError(t, tx.PutOutgoingTransaction(
+			&transactions.OutgoingTransaction{Tx: msgTx1, LastBroadcast: lastBroadcast}))
+		require.NoError(t, tx.PutOutgoingTransaction(
+			&transactions.OutgoingTransaction{Tx: msgTx2, LastBroadcast: lastBroadcast}))
+
+		outgoingTx, err = tx.OutgoingTransaction(msgTx1.TxHash())
+		require.NoError(t, err)
+		require.Equal(t, msgTx1.TxHash(), outgoingTx.Tx.TxHash())
+		require.Equal(t, lastBroadcast, outgoingTx.LastBroadcast)
+
+		// Storing it again updates the last broadcast.
+		require.NoError(t, tx.PutOutgoingTransaction(
+			&transactions.OutgoingTransaction{Tx: msgTx1, LastBroadcast: lastBroadcast.Add(time.Hour)}))
+		outgoingTxs, err = tx.OutgoingTransactions()
+		require.NoError(t, err)
+		require.Len(t, outgoingTxs, 2)
+		lastBroadcasts := map[chainhash.Hash]time.Time{}
+		for _, outgoingTx := range outgoingTxs {
+			lastBroadcasts[outgoingTx.Tx.TxHash()] = outgoingTx.LastBroadcast
+		}
+		require.Equal(t, map[chainhash.Hash]time.Time{
+			msgTx1.TxHash(): lastBroadcast.Add(time.Hour),
+			msgTx2.TxHash(): lastBroadcast,
+		}, lastBroadcasts)
+
+		tx.DeleteOutgoingTransaction(msgTx1.TxHash())
+		outgoingTx, err = tx.OutgoingTransaction(msgTx1.TxHash())
+		require.NoError(t, err)
+		require.Nil(t, outgoingTx)
+		outgoingTxs, err = tx.OutgoingTransactions()
+		require.NoError(t, err)
+		require.Len(t, outgoingTxs, 1)
+	})
+}
+
+func TestHandedOutAddresses(t *testing.T) {
+	testTx(func(tx *Tx) {
+		scriptHashHex, err := blockchain.ParseScriptHashHex(
+			"a1c0e5d2b2e8e8d8b3a3cb9d5e8b2a3c9e6f0d1e2f3a4b5c6d7e8f9a0b1c2d3e")
+		require.NoError(t, err)
+		handedOut, err := tx.IsHandedOutAddress(scriptHashHex)
+		require.NoError(t, err)
+		require.False(t, handedOut)
+
+		require.NoError(t, tx.PutHandedOutAddress(scriptHashHex))
+		handedOut, err = tx.IsHandedOutAddress(scriptHashHex)
+		require.NoError(t, err)
+		require.True(t, handedOut)
+
+		handedOut, err = tx.IsHandedOutAddress(blockchain.NewScriptHashHex([]byte("other")))
+		require.NoError(t, err)
+		require.False(t, handedOut)
+	})
+}
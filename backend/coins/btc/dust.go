@@ -0,0 +1,85 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// Dust is received in dusting attacks to clutter the wallet and to link addresses when it is spent
+// together with other coins. Outputs below the DustThreshold setting of the account are hidden:
+// they are not counted, not spent, and receive transactions paying only such outputs are not
+// listed. They are still stored, e.g. for the UTXO export.
+
+// isDust returns true if the value of an output is below the DustThreshold setting of the account.
+func (account *Account) isDust(value int64) bool {
+	return value < account.Config().Config.DustThreshold
+}
+
+// isHiddenDust returns true if the output is dust which is hidden. Outputs of our own transactions
+// are only hidden if the account does not show them, see config.Account.ShowOwnDust.
+func (account *Account) isHiddenDust(output *transactions.SpendableOutput) bool {
+	if output.OwnInputs && account.Config().Config.ShowOwnDust {
+		return false
+	}
+	return account.isDust(output.Value)
+}
+
+// hiddenDustBalance returns the sum of the hidden dust outputs which would be part of the available
+// and the incoming balance.
+func (account *Account) hiddenDustBalance() (coin.Amount, coin.Amount, error) {
+	if account.Config().Config.DustThreshold == 0 {
+		return coin.NewAmountFromInt64(0), coin.NewAmountFromInt64(0), nil
+	}
+	spendable, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	var available int64
+	for _, txOut := range spendable {
+		if account.isHiddenDust(txOut) {
+			available += txOut.Value
+		}
+	}
+	// The unspent outputs which are not spendable are the incoming ones, which are never ours.
+	unspent, err := account.transactions.UnspentOutputs()
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	var incoming int64
+	for outPoint, txOut := range unspent {
+		if _, ok := spendable[outPoint]; !ok && account.isDust(txOut.Value) {
+			incoming += txOut.Value
+		}
+	}
+	return coin.NewAmountFromInt64(available), coin.NewAmountFromInt64(incoming), nil
+}
+
+// isHiddenDustTx returns true if the transaction receives only dust, see isDust(). Our own
+// transactions, e.g. self-transfers, are not receive transactions and are always listed.
+func (account *Account) isHiddenDustTx(tx *accounts.TransactionData) bool {
+	if account.Config().Config.DustThreshold == 0 || tx.Type != accounts.TxTypeReceive {
+		return false
+	}
+	for _, address := range tx.Addresses {
+		amount, err := address.Amount.Int64()
+		if err != nil || !account.isDust(amount) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+// requireDustHidden checks the balance, the number of listed transactions and spendable outputs of
+// a funded account, see fundedAccount(), with the given dust settings.
+func requireDustHidden(
+	t *testing.T, dustThreshold int64, showOwnDust bool,
+	available, incoming int64, numTransactions, numOutputs int) *btc.Account {
+	t.Helper()
+	account, _ := fundedAccount(t, func(account *btc.Account) {
+		account.Config().Config.DustThreshold = dustThreshold
+		account.Config().Config.ShowOwnDust = showOwnDust
+	})
+	// The balance can match before all transactions were processed, e.g. if it is 0.
+	require.Eventually(t, func() bool {
+		balance, err := account.Balance()
+		require.NoError(t, err)
+		txs, err := account.Transactions()
+		require.NoError(t, err)
+		return balance.Available().BigInt().Int64() == available &&
+			balance.Incoming().BigInt().Int64() == incoming &&
+			len(txs) == numTransactions &&
+			len(account.SpendableOutputs()) == numOutputs
+	}, 5*time.Second, 10*time.Millisecond)
+	return account
+}
+
+func TestDust(t *testing.T) {
+	requireDustHidden(t, 0, false, 71000, 5000, 4, 2)
+
+	// The confirmed payment of 2000 and the incoming payment of 5000 are hidden.
+	account := requireDustHidden(t, 6000, false, 69000, 0, 2, 1)
+	txs, err := account.Transactions()
+	require.NoError(t, err)
+	// The running balance does not include the hidden dust.
+	require.Equal(t, int64(69000), txs[0].Balance.BigInt().Int64())
+	// Hidden dust is not spent.
+	txProposal, err := account.NewTransactionProposal(&accounts.TxProposalArgs{
+		RecipientAddress: addressesTest.GetAddress(signing.ScriptTypeP2WPKH).EncodeForHumans(),
+		Amount:           coin.NewSendAmountAll(),
+		FeeTargetCode:    accounts.FeeTargetCodeCustom,
+		CustomFee:        "1",
+	})
+	require.NoError(t, err)
+	require.Len(t, txProposal.Transaction.TxIn, 1)
+
+	// Our own change is hidden too, unless own outputs are shown.
+	requireDustHidden(t, 70000, false, 0, 0, 2, 0)
+	requireDustHidden(t, 70000, true, 69000, 0, 2, 1)
+}
@@ -18,11 +18,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/block-client-go/electrum"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/BitBoxSwiss/block-client-go/failover"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
@@ -32,20 +38,73 @@ import (
 // also implements blockchain.Interface.
 type client struct {
 	client *electrum.Client
+	// onError is installed by the failover client and triggers a failover away from this client.
+	onError func(error)
+	// closed is set when the connection is closed, e.g. because of a failover.
+	closed atomic.Bool
+
+	// server is the address of the server.
+	server string
+	// software and protocolVersion are the server software and the protocol version agreed when
+	// connecting.
+	software        string
+	protocolVersion string
+	// addressFamily is the address family the connection was established with, see
+	// remoteAddressFamily().
+	addressFamily string
+	// dial opens another connection to the same server, used to fetch the banner.
+	dial func() (net.Conn, error)
+	// banner is the fetched banner of the server, nil if not fetched yet.
+	banner   *string
+	bannerMu sync.Mutex
 }
 
-func (c *client) EstimateFee(number int) (btcutil.Amount, error) {
-	fee, err := c.client.EstimateFee(context.Background(), number)
+// ServerInfo implements blockchain.ServerInfoProvider. The banner is fetched once per connection.
+func (c *client) ServerInfo() (*blockchain.ServerInfo, error) {
+	c.bannerMu.Lock()
+	defer c.bannerMu.Unlock()
+	if c.banner == nil {
+		banner, err := fetchBanner(c.dial)
+		if err != nil {
+			return nil, err
+		}
+		c.banner = &banner
+	}
+	return &blockchain.ServerInfo{
+		Server:          c.server,
+		Software:        c.software,
+		ProtocolVersion: c.protocolVersion,
+		AddressFamily:   c.addressFamily,
+		Banner:          *c.banner,
+	}, nil
+}
+
+// requestError converts the error of a request. Requests which timed out fail with an error wrapping
+// blockchain.ErrRequestTimeout. Requests which were cancelled because the connection was closed,
+// e.g. on a failover, fail with a failover error, so that they are made again on the next server.
+func (c *client) requestError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %v", blockchain.ErrRequestTimeout, err)
+	case errors.Is(err, context.Canceled) && c.closed.Load():
+		return failover.NewFailoverError(err)
+	}
+	return err
+}
+
+func (c *client) EstimateFee(ctx context.Context, number int) (btcutil.Amount, error) {
+	fee, err := c.client.EstimateFee(ctx, number)
 	if err != nil {
-		return 0, err
+		return 0, c.requestError(err)
 	}
 	return btcutil.NewAmount(fee)
 }
 
-func (c *client) GetMerkle(txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
-	result, err := c.client.GetMerkle(context.Background(), txHash.String(), height)
+func (c *client) GetMerkle(
+	ctx context.Context, txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
+	result, err := c.client.GetMerkle(ctx, txHash.String(), height)
 	if err != nil {
-		return nil, err
+		return nil, c.requestError(err)
 	}
 	merkle := make([]blockchain.TXHash, len(result.Merkle))
 	for i, s := range result.Merkle {
@@ -58,10 +117,11 @@ func (c *client) GetMerkle(txHash chainhash.Hash, height int) (*blockchain.GetMe
 	return &blockchain.GetMerkleResult{Merkle: merkle, Pos: result.Pos}, nil
 }
 
-func (c *client) Headers(startHeight int, count int) (*blockchain.HeadersResult, error) {
-	headersResult, err := c.client.Headers(context.Background(), startHeight, count)
+func (c *client) Headers(
+	ctx context.Context, startHeight int, count int) (*blockchain.HeadersResult, error) {
+	headersResult, err := c.client.Headers(ctx, startHeight, count)
 	if err != nil {
-		return nil, err
+		return nil, c.requestError(err)
 	}
 	headers := make([]*wire.BlockHeader, len(headersResult.Headers))
 	for i, h := range headersResult.Headers {
@@ -72,26 +132,30 @@ func (c *client) Headers(startHeight int, count int) (*blockchain.HeadersResult,
 		}
 		headers[i] = header
 	}
-	return &blockchain.HeadersResult{Headers: headers, Max: headersResult.Max}, nil
+	return &blockchain.HeadersResult{
+		Headers:       headers,
+		Max:           headersResult.Max,
+		ReportInvalid: c.onError,
+	}, nil
 }
 
 func (c *client) HeadersSubscribe(result func(*types.Header, error)) {
 	c.client.HeadersSubscribe(context.Background(), result)
 }
 
-func (c *client) RelayFee() (btcutil.Amount, error) {
-	fee, err := c.client.RelayFee(context.Background())
+func (c *client) RelayFee(ctx context.Context) (btcutil.Amount, error) {
+	fee, err := c.client.RelayFee(ctx)
 	if err != nil {
-		return 0, err
+		return 0, c.requestError(err)
 	}
 	return btcutil.NewAmount(fee)
 }
 
-func (c *client) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (
+func (c *client) ScriptHashGetHistory(ctx context.Context, scriptHashHex blockchain.ScriptHashHex) (
 	blockchain.TxHistory, error) {
-	historyA, err := c.client.ScriptHashGetHistory(context.Background(), string(scriptHashHex))
+	historyA, err := c.client.ScriptHashGetHistory(ctx, scriptHashHex.String())
 	if err != nil {
-		return nil, err
+		return nil, c.requestError(err)
 	}
 	history := blockchain.TxHistory{}
 	for _, t := range historyA {
@@ -104,23 +168,32 @@ func (c *client) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (
 			TXHash: blockchain.TXHash(*txHash),
 		})
 	}
+	if err := history.Validate(); err != nil {
+		return nil, err
+	}
 	return history, nil
 }
 
 func (c *client) ScriptHashSubscribe(
+	ctx context.Context,
 	scriptHashHex blockchain.ScriptHashHex,
 	success func(string, error),
 ) {
-	c.client.ScriptHashSubscribe(context.Background(), string(scriptHashHex), success)
+	c.client.ScriptHashSubscribe(ctx, scriptHashHex.String(), func(status string, err error) {
+		if err != nil {
+			err = c.requestError(err)
+		}
+		success(status, err)
+	})
 }
 
-func (c *client) TransactionBroadcast(transaction *wire.MsgTx) error {
+func (c *client) TransactionBroadcast(ctx context.Context, transaction *wire.MsgTx) error {
 	rawTx := &bytes.Buffer{}
 	_ = transaction.BtcEncode(rawTx, 0, wire.WitnessEncoding)
 	rawTxHex := hex.EncodeToString(rawTx.Bytes())
-	txID, err := c.client.TransactionBroadcast(context.Background(), rawTxHex)
+	txID, err := c.client.TransactionBroadcast(ctx, rawTxHex)
 	if err != nil {
-		return err
+		return c.requestError(err)
 	}
 	if txID != transaction.TxHash().String() {
 		return errp.New("Response is unexpected (transaction hash mismatch)")
@@ -128,10 +201,10 @@ func (c *client) TransactionBroadcast(transaction *wire.MsgTx) error {
 	return nil
 }
 
-func (c *client) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
-	rawTx, err := c.client.TransactionGet(context.Background(), txHash.String())
+func (c *client) TransactionGet(ctx context.Context, txHash chainhash.Hash) (*wire.MsgTx, error) {
+	rawTx, err := c.client.TransactionGet(ctx, txHash.String())
 	if err != nil {
-		return nil, err
+		return nil, c.requestError(err)
 	}
 
 	tx := &wire.MsgTx{}
@@ -142,9 +215,11 @@ func (c *client) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
 }
 
 func (c *client) SetOnError(f func(error)) {
+	c.onError = f
 	c.client.SetOnError(f)
 }
 
 func (c *client) Close() {
+	c.closed.Store(true)
 	c.client.Close()
 }
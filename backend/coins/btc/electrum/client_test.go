@@ -0,0 +1,112 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	electrumTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeoutAndCancellation(t *testing.T) {
+	log := logging.Get().WithGroup("electrum_test")
+	chain := electrumTest.NewChain(&chaincfg.RegressionNetParams)
+	server, err := chain.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+	server.SetUnresponsive("blockchain.transaction.get", true)
+
+	connection := NewElectrumConnection(
+		[]*config.ServerInfo{server.ServerInfo()}, log, &net.Dialer{}, 200*time.Millisecond)
+	defer connection.Close()
+
+	_, err = connection.TransactionGet(context.Background(), chainhash.Hash{})
+	require.True(t, errors.Is(err, blockchain.ErrRequestTimeout), err)
+
+	// Other requests are not affected.
+	_, err = connection.RelayFee(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err = connection.TransactionGet(ctx, chainhash.Hash{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// No request is made with a context that is done already.
+	_, err = connection.RelayFee(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// No subscription is made with a context that is done already.
+	connection.ScriptHashSubscribe(
+		ctx,
+		func() func() { return func() {} },
+		blockchain.NewScriptHashHex([]byte{0x51}),
+		func(string) { require.Fail(t, "unexpected callback") },
+	)
+	time.Sleep(50 * time.Millisecond)
+	subscribed, pending := connection.ScriptHashSubscriptions()
+	require.Empty(t, subscribed)
+	require.Empty(t, pending)
+}
+
+func TestScriptHashResubscribe(t *testing.T) {
+	log := logging.Get().WithGroup("electrum_test")
+	chain := electrumTest.NewChain(&chaincfg.RegressionNetParams)
+	server, err := chain.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+	server.SetUnresponsive("blockchain.scripthash.subscribe", true)
+
+	connection := NewElectrumConnection(
+		[]*config.ServerInfo{server.ServerInfo()}, log, &net.Dialer{}, 10*time.Second)
+	defer connection.Close()
+
+	scriptHashHex := blockchain.NewScriptHashHex([]byte{0x51})
+	noop := func() func() { return func() {} }
+	connection.ScriptHashSubscribe(context.Background(), noop, scriptHashHex, func(string) {})
+
+	// The subscription is pending until the server responds.
+	time.Sleep(100 * time.Millisecond)
+	subscribed, pending := connection.ScriptHashSubscriptions()
+	require.Empty(t, subscribed)
+	require.Equal(t, []blockchain.ScriptHashHex{scriptHashHex}, pending)
+
+	server.SetUnresponsive("blockchain.scripthash.subscribe", false)
+	statuses := make(chan string, 2)
+	connection.ScriptHashResubscribe(context.Background(), noop, scriptHashHex, func(status string) {
+		statuses <- status
+	})
+	select {
+	case <-statuses:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "resubscribing timed out")
+	}
+	subscribed, pending = connection.ScriptHashSubscriptions()
+	require.Equal(t, []blockchain.ScriptHashHex{scriptHashHex}, subscribed)
+	require.Empty(t, pending)
+}
@@ -16,12 +16,15 @@ package electrum
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -48,6 +51,23 @@ func SetClientSoftwareVersion(v *semver.SemVer) {
 	softwareVersion = fmt.Sprintf("BitBoxApp/%s", v)
 }
 
+// handshakeTimeout is the maximum duration of the TLS handshake.
+const handshakeTimeout = 30 * time.Second
+
+// ErrCertificatePinMismatch is returned if the certificate presented by the server does not match
+// the pinned certificate fingerprint of the server, see config.ServerInfo.CertFingerprint.
+var ErrCertificatePinMismatch = errors.New("server certificate does not match the pinned fingerprint")
+
+// parseCertFingerprint decodes a hex encoded SHA-256 certificate fingerprint, optionally
+// containing colons between the bytes.
+func parseCertFingerprint(fingerprint string) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, errp.Newf("Invalid certificate fingerprint %q", fingerprint)
+	}
+	return decoded, nil
+}
+
 // establishConnection connects to a backend and returns an rpc client
 // or an error if the connection could not be established.
 func establishConnection(
@@ -55,7 +75,8 @@ func establishConnection(
 	var conn net.Conn
 	if serverInfo.TLS {
 		var err error
-		conn, err = newTLSConnection(serverInfo.Server, serverInfo.PEMCert, dialer)
+		conn, err = newTLSConnection(
+			serverInfo.Server, serverInfo.PEMCert, serverInfo.CertFingerprint, dialer)
 		if err != nil {
 			return nil, err
 		}
@@ -69,7 +90,11 @@ func establishConnection(
 	return conn, nil
 }
 
-func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tls.Conn, error) {
+// newTLSConnection connects to the server, verifying the server certificate against rootCert. If
+// certFingerprint is not empty, the server certificate must also match the fingerprint. In this
+// case, rootCert can be empty to skip the verification against it.
+func newTLSConnection(
+	address string, rootCert string, certFingerprint string, dialer proxy.Dialer) (*tls.Conn, error) {
 	// hostname is used as server name in SNI client hello during the handshake.
 	// It is set to empty string by tls.Client if address is an IP address.
 	hostname, _, err := net.SplitHostPort(address)
@@ -77,8 +102,15 @@ func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tl
 		return nil, errp.WithMessage(err, fmt.Sprintf("Invalid server address %q", address))
 	}
 
+	var pinnedFingerprint []byte
+	if certFingerprint != "" {
+		pinnedFingerprint, err = parseCertFingerprint(certFingerprint)
+		if err != nil {
+			return nil, err
+		}
+	}
 	caCertPool := x509.NewCertPool()
-	if ok := caCertPool.AppendCertsFromPEM([]byte(rootCert)); !ok {
+	if ok := caCertPool.AppendCertsFromPEM([]byte(rootCert)); !ok && (pinnedFingerprint == nil || rootCert != "") {
 		return nil, errp.New("Failed to append CA cert as trusted cert")
 	}
 	conn, err := dialer.Dial("tcp", address)
@@ -92,6 +124,20 @@ func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tl
 		// See custom verification against a rootCert in VerifyPeerCertificate.
 		InsecureSkipVerify: true,
 		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errp.New("no remote certs")
+			}
+			if pinnedFingerprint != nil {
+				fingerprint := sha256.Sum256(rawCerts[0])
+				if !bytes.Equal(fingerprint[:], pinnedFingerprint) {
+					return errp.WithMessage(ErrCertificatePinMismatch,
+						fmt.Sprintf("got %x", fingerprint))
+				}
+				if rootCert == "" {
+					return nil
+				}
+			}
+
 			// Code copy/pasted and adapted from
 			// https://github.com/golang/go/blob/81555cb4f3521b53f9de4ce15f64b77cc9df61b9/src/crypto/tls/handshake_client.go#L327-L344, but adapted to skip the hostname verification.
 			// See https://github.com/golang/go/issues/21971#issuecomment-412836078.
@@ -124,6 +170,19 @@ func newTLSConnection(address string, rootCert string, dialer proxy.Dialer) (*tl
 			return err
 		},
 	})
+	// Perform the handshake right away so certificate errors are reported when connecting.
+	if err := tlsConn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, errp.WithStack(err)
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, errp.WithStack(err)
+	}
+	if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, errp.WithStack(err)
+	}
 	return tlsConn, nil
 }
 
@@ -135,9 +194,33 @@ func newTCPConnection(address string, dialer proxy.Dialer) (net.Conn, error) {
 	return conn, nil
 }
 
+// DefaultRequestTimeout is the request timeout used if none is configured.
+const DefaultRequestTimeout = 50 * time.Second
+
+// remoteAddressFamily returns "ipv4" or "ipv6" depending on the address the connection was
+// established to, or an empty string if it is not an IP address, e.g. when connected via a proxy.
+func remoteAddressFamily(conn net.Conn) string {
+	tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+	if tcpAddr.IP.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
 // NewElectrumConnection connects to an Electrum server and returns a ElectrumClient instance to
-// communicate with it.
-func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry, dialer proxy.Dialer) blockchain.Interface {
+// communicate with it. Requests time out after requestTimeout, or DefaultRequestTimeout if 0.
+func NewElectrumConnection(
+	serverInfos []*config.ServerInfo,
+	log *logrus.Entry,
+	dialer proxy.Dialer,
+	requestTimeout time.Duration,
+) blockchain.Interface {
+	if requestTimeout <= 0 {
+		requestTimeout = DefaultRequestTimeout
+	}
 	var serverList string
 	for _, serverInfo := range serverInfos {
 		if serverList != "" {
@@ -149,37 +232,114 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 	log.Debug("Connecting to Electrum server")
 
 	servers := []*failover.Server[*client]{}
-	retryTimeout := 30 * time.Second
+	oversized := newOversizedServers()
+	var fclient *failoverClient
+	reconnect := newReconnectBackoff(len(serverInfos), func(err error, nextAttempt time.Time) {
+		log.WithError(err).Errorf("All backends failed, retrying after %v",
+			time.Until(nextAttempt).Round(time.Millisecond))
+		if err == nil {
+			// Shouldn't happen, a fallback just in case.
+			err = errors.New("Servers unreachable")
+		}
+		fclient.setWaitingToRetry(&blockchain.RetryError{Err: err, NextAttempt: nextAttempt})
+	})
 
 	for _, serverInfo := range serverInfos {
 		serverInfo := serverInfo
+		serverDialer := dialer
+		// Without a proxy, the connections to dual-stack servers start with the address family
+		// which worked last, see serverDialer.
+		if netDialer, ok := dialer.(*net.Dialer); ok {
+			serverDialer = newServerDialer(netDialer)
+		}
+		connect := func() (*client, error) {
+			log := log.WithField("server", serverInfo.String())
+			log.Info("Trying to connect to backend")
+			if serverInfo.TLS && serverInfo.CertFingerprint == "" {
+				log.Warn("No certificate fingerprint pinned for this server")
+			}
+			if oversized.isFlagged(serverInfo.Server) {
+				log.Warn("Skipping server which recently sent an oversized response")
+				return nil, errp.WithMessage(ErrOversizedResponse, "server skipped")
+			}
+			dial := func() (net.Conn, error) {
+				conn, err := establishConnection(serverInfo, serverDialer)
+				if err != nil {
+					return nil, err
+				}
+				return newLimitedConn(conn, maxMessageSize, func() {
+					log.WithField("max-message-size", maxMessageSize).
+						Error("Server sent an oversized response, disconnecting")
+					oversized.flag(serverInfo.Server)
+				}), nil
+			}
+			var addressFamily string
+			c, err := electrum.Connect(&electrum.Options{
+				SoftwareVersion: softwareVersion,
+				// Slightly less than PingInterval according to the `electrum.Options` docs - a
+				// ping is a method call by itself.
+				MethodTimeout: requestTimeout,
+				PingInterval:  requestTimeout + 10*time.Second,
+				Dial: func() (net.Conn, error) {
+					conn, err := dial()
+					if err != nil {
+						return nil, err
+					}
+					addressFamily = remoteAddressFamily(conn)
+					return conn, nil
+				},
+			})
+			if err != nil {
+				if isUnsupportedProtocolError(err) {
+					err = errp.WithMessage(ErrUnsupportedProtocolVersion, err.Error())
+				}
+				log.WithError(err).Error("Failover: backend is down")
+				return nil, err
+			}
+			software, protocolVersion, err := negotiatedVersion(c.ServerVersion())
+			if err != nil {
+				c.Close()
+				log.WithError(err).Error("Failover: backend protocol version not supported")
+				return nil, err
+			}
+			log.
+				WithField("server-version", c.ServerVersion().String()).
+				WithField("address-family", addressFamily).
+				Infof("Successfully connected to backend %s", serverInfo.Server)
+			return &client{
+				client:          c,
+				server:          serverInfo.Server,
+				software:        software,
+				protocolVersion: protocolVersion,
+				addressFamily:   addressFamily,
+				dial:            dial,
+			}, nil
+		}
 		servers = append(servers, &failover.Server[*client]{
 			Name: serverInfo.Server,
 			Connect: func() (*client, error) {
-				log := log.WithField("server", serverInfo.String())
-				log.Info("Trying to connect to backend")
-				c, err := electrum.Connect(&electrum.Options{
-					SoftwareVersion: softwareVersion,
-					// Slightly less than PingInterval according to the `electrum.Options` docs - a
-					// ping is a method call by itself.
-					MethodTimeout: 50 * time.Second,
-					PingInterval:  time.Minute,
-					Dial: func() (net.Conn, error) {
-						return establishConnection(serverInfo, dialer)
-					},
-				})
+				waited, err := reconnect.beforeConnect()
 				if err != nil {
-					log.WithError(err).Error("Failover: backend is down")
 					return nil, err
 				}
-				log.
-					WithField("server-version", c.ServerVersion().String()).
-					Infof("Successfully connected to backend %s", serverInfo.Server)
-				return &client{client: c}, nil
+				fclient.setConnecting(serverInfo.Server, waited)
+				c, err := connect()
+				if err != nil {
+					reconnect.connectFailed(err)
+					return nil, err
+				}
+				reconnect.connected()
+				fclient.setConnected(serverInfo.Server)
+				return c, nil
 			},
 		})
 	}
-	var fclient *failoverClient
+	// The delay between the rounds of connection attempts is applied by reconnect. Without
+	// servers, there are no connection attempts, so the failover client has to wait itself.
+	retryTimeout := failoverRetryTimeout
+	if len(servers) == 0 {
+		retryTimeout = maxReconnectDelay
+	}
 	fclient = newFailoverClient(&failover.Options[*client]{
 		Servers:      servers,
 		RetryTimeout: retryTimeout,
@@ -193,15 +353,11 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 				Errorf("backend disconnected")
 		},
 		OnRetry: func(err error) {
-			log.WithError(err).Errorf("All backends failed, retrying after %v", retryTimeout)
-			if err != nil {
+			if errors.Is(err, failover.ErrNoServers) {
 				fclient.setConnectionError(err)
-			} else {
-				// Shouldn't happen, a fallback just in case.
-				fclient.setConnectionError(errors.New("Servers unreachable"))
 			}
 		},
-	})
+	}, reconnect)
 	return fclient
 }
 
@@ -248,19 +404,27 @@ func DownloadCert(server string, dialer proxy.Dialer) (string, error) {
 }
 
 // CheckElectrumServer checks if a tls connection can be established with the electrum server, and
-// whether the server is an electrum server.
+// whether the server is an electrum server supporting a compatible protocol version.
 func CheckElectrumServer(serverInfo *config.ServerInfo, log *logrus.Entry, dialer proxy.Dialer) error {
 	client, err := electrum.Connect(&electrum.Options{
 		SoftwareVersion: softwareVersion,
 		MethodTimeout:   30 * time.Second,
 		PingInterval:    -1,
 		Dial: func() (net.Conn, error) {
-			return establishConnection(serverInfo, dialer)
+			conn, err := establishConnection(serverInfo, dialer)
+			if err != nil {
+				return nil, err
+			}
+			return newLimitedConn(conn, maxMessageSize, nil), nil
 		},
 	})
 	if err != nil {
+		if isUnsupportedProtocolError(err) {
+			return errp.WithMessage(ErrUnsupportedProtocolVersion, err.Error())
+		}
 		return err
 	}
-	client.Close()
-	return nil
+	defer client.Close()
+	_, _, err = negotiatedVersion(client.ServerVersion())
+	return err
 }
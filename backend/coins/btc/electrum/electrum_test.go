@@ -15,7 +15,9 @@
 package electrum
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"io"
 	"net"
 	"strings"
@@ -23,6 +25,7 @@ import (
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -135,3 +138,93 @@ func TestEstablishConnectionTLS(t *testing.T) {
 		})
 	}
 }
+
+func TestEstablishConnectionCertFingerprint(t *testing.T) {
+	fakeNode := &test.TCPServer{}
+	fakeNode.StartTLS(func(conn net.Conn) {
+		io.Copy(conn, conn) // echo back all incoming data
+		conn.Close()
+	})
+	defer fakeNode.Close()
+
+	fingerprint := sha256.Sum256(test.TCPServerCert.Certificate[0])
+	fingerprintHex := hex.EncodeToString(fingerprint[:])
+	otherFingerprintHex := strings.Repeat("ab", sha256.Size)
+
+	tt := []struct {
+		name        string
+		pemCert     string
+		fingerprint string
+		wantErr     error
+	}{
+		{"pin", "", fingerprintHex, nil},
+		{"pin uppercase with colons", "", colonSeparated(strings.ToUpper(fingerprintHex)), nil},
+		{"pin and root cert", test.TCPServerCertPub, fingerprintHex, nil},
+		{"pin mismatch", "", otherFingerprintHex, ErrCertificatePinMismatch},
+		{"pin mismatch with root cert", test.TCPServerCertPub, otherFingerprintHex, ErrCertificatePinMismatch},
+	}
+	for _, testcase := range tt {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			info := &config.ServerInfo{
+				Server:          "node.example.org:123",
+				TLS:             true,
+				PEMCert:         testcase.pemCert,
+				CertFingerprint: testcase.fingerprint,
+			}
+			conn, err := establishConnection(info, fakeNode.Dialer())
+			if testcase.wantErr != nil {
+				require.Error(t, err)
+				require.Equal(t, testcase.wantErr, errp.Cause(err))
+				return
+			}
+			require.NoError(t, err)
+			_, err = conn.Write([]byte("hello"))
+			require.NoError(t, err)
+			buf := make([]byte, 5)
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+			require.Equal(t, "hello", string(buf))
+			require.NoError(t, conn.Close())
+		})
+	}
+
+	_, err := establishConnection(&config.ServerInfo{
+		Server:          "node.example.org:123",
+		TLS:             true,
+		CertFingerprint: "invalid",
+	}, fakeNode.Dialer())
+	require.Error(t, err)
+}
+
+// colonSeparated formats a hex string as pairs of characters separated by colons.
+func colonSeparated(hexString string) string {
+	pairs := []string{}
+	for i := 0; i < len(hexString); i += 2 {
+		pairs = append(pairs, hexString[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+func TestRemoteAddressFamily(t *testing.T) {
+	for _, testcase := range []struct{ network, address, family string }{
+		{"tcp4", "127.0.0.1:0", "ipv4"},
+		{"tcp6", "[::1]:0", "ipv6"},
+	} {
+		listener, err := net.Listen(testcase.network, testcase.address)
+		if err != nil {
+			t.Logf("skipping %s: %v", testcase.family, err)
+			continue
+		}
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		require.Equal(t, testcase.family, remoteAddressFamily(conn))
+		require.NoError(t, conn.Close())
+		require.NoError(t, listener.Close())
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	require.Equal(t, "", remoteAddressFamily(client))
+}
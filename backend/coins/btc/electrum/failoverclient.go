@@ -15,6 +15,7 @@
 package electrum
 
 import (
+	"context"
 	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
@@ -29,22 +30,62 @@ import (
 // is an automatic failover to another server. If all servers fail, there is a retry timeout and all
 // servers are tried again. Subscriptions are automatically re-subscribed on new servers.
 type failoverClient struct {
-	failover *failover.Failover[*client]
+	failover  *failover.Failover[*client]
+	reconnect *reconnectBackoff
 
 	connectionError                   error
 	onConnectionErrorChangedCallbacks []func(error)
-	// covers connectionError and onConnectionErrorChangedCallbacks.
+	connectionStatus                  blockchain.ConnectionStatus
+	// connectAttempted is true after the first connection attempt, to tell the initial connection
+	// from a failover.
+	connectAttempted                   bool
+	onConnectionStatusChangedCallbacks []func(blockchain.ConnectionStatus)
+	// covers connectionError, onConnectionErrorChangedCallbacks, connectionStatus, connectAttempted
+	// and onConnectionStatusChangedCallbacks.
 	mu sync.RWMutex
+
+	// subscriptionsClient is the connection on which the script hashes in subscriptions and
+	// pendingSubscriptions were subscribed to. A subscription request on another connection starts
+	// a new set.
+	subscriptionsClient *client
+	// subscriptions contains the script hashes whose subscription the server acknowledged.
+	subscriptions map[blockchain.ScriptHashHex]struct{}
+	// pendingSubscriptions counts the subscription requests per script hash which did not complete
+	// yet.
+	pendingSubscriptions map[blockchain.ScriptHashHex]int
+	// covers subscriptionsClient, subscriptions and pendingSubscriptions.
+	subscriptionsMu sync.Mutex
 }
 
-// newFailoverClient creates a new failover client.
-func newFailoverClient(opts *failover.Options[*client]) *failoverClient {
+// newFailoverClient creates a new failover client. reconnect must be the backoff used in the
+// Connect functions of the servers.
+func newFailoverClient(
+	opts *failover.Options[*client], reconnect *reconnectBackoff) *failoverClient {
 	return &failoverClient{
 		failover:                          failover.New[*client](opts),
+		reconnect:                         reconnect,
 		onConnectionErrorChangedCallbacks: []func(error){},
+		connectionStatus: blockchain.ConnectionStatus{
+			State: blockchain.ConnectionStateConnecting,
+		},
+		onConnectionStatusChangedCallbacks: []func(blockchain.ConnectionStatus){},
 	}
 }
 
+// call is like failover.Call, resetting the reconnection backoff if the call succeeded. If the
+// context is already done, the call is not made.
+func call[R any](ctx context.Context, f *failoverClient, fn func(c *client) (R, error)) (R, error) {
+	if err := ctx.Err(); err != nil {
+		var empty R
+		return empty, err
+	}
+	result, err := failover.Call(f.failover, fn)
+	if err == nil {
+		f.reconnect.requestSucceeded()
+	}
+	return result, err
+}
+
 func (f *failoverClient) setConnectionError(err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -68,21 +109,84 @@ func (f *failoverClient) RegisterOnConnectionErrorChangedEvent(callback func(err
 	f.onConnectionErrorChangedCallbacks = append(f.onConnectionErrorChangedCallbacks, callback)
 }
 
-func (f *failoverClient) EstimateFee(number int) (btcutil.Amount, error) {
-	return failover.Call(f.failover, func(c *client) (btcutil.Amount, error) {
-		return c.EstimateFee(number)
+// setConnectionStatusLocked must be called with mu locked.
+func (f *failoverClient) setConnectionStatusLocked(status blockchain.ConnectionStatus) {
+	f.connectionStatus = status
+	for _, callback := range f.onConnectionStatusChangedCallbacks {
+		go callback(status)
+	}
+}
+
+// setConnecting sets the connection status before a connection attempt to the given server. It is
+// a failover if it follows a failed attempt or a lost connection without waiting to retry in
+// between.
+func (f *failoverClient) setConnecting(server string, waited bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state := blockchain.ConnectionStateFailover
+	if !f.connectAttempted || waited {
+		state = blockchain.ConnectionStateConnecting
+	}
+	f.connectAttempted = true
+	f.setConnectionStatusLocked(blockchain.ConnectionStatus{State: state, Server: server})
+}
+
+// setConnected sets the connection status after a successful connection attempt.
+func (f *failoverClient) setConnected(server string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setConnectionStatusLocked(blockchain.ConnectionStatus{
+		State:  blockchain.ConnectionStateConnected,
+		Server: server,
 	})
 }
 
-func (f *failoverClient) GetMerkle(txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
-	return failover.Call(f.failover, func(c *client) (*blockchain.GetMerkleResult, error) {
-		return c.GetMerkle(txHash, height)
+// setWaitingToRetry sets the connection status and error when all servers failed.
+func (f *failoverClient) setWaitingToRetry(err *blockchain.RetryError) {
+	f.setConnectionError(err)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	errMsg := err.Err.Error()
+	nextAttempt := err.NextAttempt
+	f.setConnectionStatusLocked(blockchain.ConnectionStatus{
+		State:       blockchain.ConnectionStateDisconnected,
+		Error:       &errMsg,
+		NextAttempt: &nextAttempt,
 	})
 }
 
-func (f *failoverClient) Headers(startHeight int, count int) (*blockchain.HeadersResult, error) {
-	return failover.Call(f.failover, func(c *client) (*blockchain.HeadersResult, error) {
-		return c.Headers(startHeight, count)
+// ConnectionStatus implements blockchain.ConnectionStatusProvider.
+func (f *failoverClient) ConnectionStatus() blockchain.ConnectionStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.connectionStatus
+}
+
+// RegisterOnConnectionStatusChangedEvent implements blockchain.ConnectionStatusProvider.
+func (f *failoverClient) RegisterOnConnectionStatusChangedEvent(
+	callback func(blockchain.ConnectionStatus)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onConnectionStatusChangedCallbacks = append(f.onConnectionStatusChangedCallbacks, callback)
+}
+
+func (f *failoverClient) EstimateFee(ctx context.Context, number int) (btcutil.Amount, error) {
+	return call(ctx, f, func(c *client) (btcutil.Amount, error) {
+		return c.EstimateFee(ctx, number)
+	})
+}
+
+func (f *failoverClient) GetMerkle(
+	ctx context.Context, txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
+	return call(ctx, f, func(c *client) (*blockchain.GetMerkleResult, error) {
+		return c.GetMerkle(ctx, txHash, height)
+	})
+}
+
+func (f *failoverClient) Headers(
+	ctx context.Context, startHeight int, count int) (*blockchain.HeadersResult, error) {
+	return call(ctx, f, func(c *client) (*blockchain.HeadersResult, error) {
+		return c.Headers(ctx, startHeight, count)
 	})
 }
 
@@ -103,59 +207,193 @@ func (f *failoverClient) HeadersSubscribe(result func(header *types.Header)) {
 		})
 }
 
-func (f *failoverClient) RelayFee() (btcutil.Amount, error) {
-	return failover.Call(f.failover, func(c *client) (btcutil.Amount, error) {
-		return c.RelayFee()
+func (f *failoverClient) RelayFee(ctx context.Context) (btcutil.Amount, error) {
+	return call(ctx, f, func(c *client) (btcutil.Amount, error) {
+		return c.RelayFee(ctx)
 	})
 }
 
-func (f *failoverClient) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
-	return failover.Call(f.failover, func(c *client) (blockchain.TxHistory, error) {
-		return c.ScriptHashGetHistory(scriptHashHex)
+func (f *failoverClient) ScriptHashGetHistory(
+	ctx context.Context, scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+	return call(ctx, f, func(c *client) (blockchain.TxHistory, error) {
+		return c.ScriptHashGetHistory(ctx, scriptHashHex)
 	})
 }
 
+// ScriptHashSubscribe implements blockchain.Interface. Once the context is done, the script hash is
+// not subscribed to on new servers anymore and notifications are dropped.
 func (f *failoverClient) ScriptHashSubscribe(
+	ctx context.Context,
 	setupAndTeardown func() func(),
 	scriptHashHex blockchain.ScriptHashHex,
 	result func(status string)) {
+	if ctx.Err() != nil {
+		return
+	}
 	failover.Subscribe(
 		f.failover,
 		// This is called the first time `ScriptHashSubscribe()` is called for the current server,
 		// and again everytime a new server is connected (failover).
 		func(c *client, result func(string, error)) {
+			if ctx.Err() != nil {
+				return
+			}
 			// Do something before and after subscribing on a server.
 			teardown := setupAndTeardown()
+			f.startSubscription(c, scriptHashHex)
 			// The callback will be called once after subscribing and then more times when the server pushes
 			// notifications. We teardown the subscription setup once.
 			once := sync.Once{}
-			c.ScriptHashSubscribe(scriptHashHex, func(status string, err error) {
-				defer once.Do(teardown)
+			c.ScriptHashSubscribe(ctx, scriptHashHex, func(status string, err error) {
+				defer once.Do(func() {
+					f.finishSubscription(c, scriptHashHex, err)
+					teardown()
+				})
 				result(status, err)
 			})
 		},
 		func(status string, err error) {
 			if err != nil {
-				// Can only happen if the failover client is closed.
+				// Happens if the failover client is closed, or if subscribing timed out or was
+				// cancelled. A subscription missing on the server is detected by the subscriptions
+				// health check of the account.
+				return
+			}
+			if ctx.Err() != nil {
 				return
 			}
 			result(status)
 		})
 }
 
-func (f *failoverClient) TransactionBroadcast(transaction *wire.MsgTx) error {
-	_, err := failover.Call(f.failover, func(c *client) (struct{}, error) {
-		return struct{}{}, c.TransactionBroadcast(transaction)
+// ScriptHashResubscribe implements blockchain.Interface. The request is sent on the current
+// connection only. The electrum client keeps the extra callback until the connection is closed, but
+// it only forwards the response to the request.
+func (f *failoverClient) ScriptHashResubscribe(
+	ctx context.Context,
+	setupAndTeardown func() func(),
+	scriptHashHex blockchain.ScriptHashHex,
+	result func(status string)) {
+	if ctx.Err() != nil {
+		return
+	}
+	go func() {
+		_, _ = call(ctx, f, func(c *client) (struct{}, error) {
+			teardown := setupAndTeardown()
+			f.startSubscription(c, scriptHashHex)
+			once := sync.Once{}
+			c.ScriptHashSubscribe(ctx, scriptHashHex, func(status string, err error) {
+				once.Do(func() {
+					f.finishSubscription(c, scriptHashHex, err)
+					teardown()
+					if err != nil {
+						// Not failing over: the next subscriptions health check tries again.
+						return
+					}
+					if ctx.Err() != nil {
+						return
+					}
+					result(status)
+				})
+			})
+			return struct{}{}, nil
+		})
+	}()
+}
+
+// resetSubscriptionsLocked starts a new set of subscriptions if c is not the connection of the
+// current set. subscriptionsMu must be locked.
+func (f *failoverClient) resetSubscriptionsLocked(c *client) {
+	if f.subscriptionsClient != c {
+		f.subscriptionsClient = c
+		f.subscriptions = map[blockchain.ScriptHashHex]struct{}{}
+		f.pendingSubscriptions = map[blockchain.ScriptHashHex]int{}
+	}
+}
+
+// startSubscription records that the subscription to the script hash was requested on the given
+// connection.
+func (f *failoverClient) startSubscription(c *client, scriptHashHex blockchain.ScriptHashHex) {
+	if c.closed.Load() {
+		return
+	}
+	f.subscriptionsMu.Lock()
+	defer f.subscriptionsMu.Unlock()
+	f.resetSubscriptionsLocked(c)
+	f.pendingSubscriptions[scriptHashHex]++
+}
+
+// finishSubscription records that the subscription request to the script hash on the given
+// connection completed, and whether the server acknowledged it. Requests on an older connection
+// are ignored.
+func (f *failoverClient) finishSubscription(
+	c *client, scriptHashHex blockchain.ScriptHashHex, err error) {
+	f.subscriptionsMu.Lock()
+	defer f.subscriptionsMu.Unlock()
+	if f.subscriptionsClient != c {
+		return
+	}
+	if f.pendingSubscriptions[scriptHashHex] <= 1 {
+		delete(f.pendingSubscriptions, scriptHashHex)
+	} else {
+		f.pendingSubscriptions[scriptHashHex]--
+	}
+	if err == nil {
+		f.subscriptions[scriptHashHex] = struct{}{}
+	}
+}
+
+// ScriptHashSubscriptions implements blockchain.Interface. Subscriptions on a closed connection
+// don't count, so the result is empty while disconnected.
+func (f *failoverClient) ScriptHashSubscriptions() (
+	[]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+	f.subscriptionsMu.Lock()
+	defer f.subscriptionsMu.Unlock()
+	subscribed := []blockchain.ScriptHashHex{}
+	pending := []blockchain.ScriptHashHex{}
+	if f.subscriptionsClient == nil || f.subscriptionsClient.closed.Load() {
+		return subscribed, pending
+	}
+	for scriptHashHex := range f.subscriptions {
+		subscribed = append(subscribed, scriptHashHex)
+	}
+	for scriptHashHex := range f.pendingSubscriptions {
+		if _, ok := f.subscriptions[scriptHashHex]; !ok {
+			pending = append(pending, scriptHashHex)
+		}
+	}
+	return subscribed, pending
+}
+
+func (f *failoverClient) TransactionBroadcast(ctx context.Context, transaction *wire.MsgTx) error {
+	_, err := call(ctx, f, func(c *client) (struct{}, error) {
+		return struct{}{}, c.TransactionBroadcast(ctx, transaction)
 	})
 	return err
 }
 
-func (f *failoverClient) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
-	return failover.Call(f.failover, func(c *client) (*wire.MsgTx, error) {
-		return c.TransactionGet(txHash)
+func (f *failoverClient) TransactionGet(ctx context.Context, txHash chainhash.Hash) (*wire.MsgTx, error) {
+	return call(ctx, f, func(c *client) (*wire.MsgTx, error) {
+		return c.TransactionGet(ctx, txHash)
+	})
+}
+
+// ServerInfo implements blockchain.ServerInfoProvider and describes the active connection.
+func (f *failoverClient) ServerInfo() (*blockchain.ServerInfo, error) {
+	return call(context.Background(), f, func(c *client) (*blockchain.ServerInfo, error) {
+		return c.ServerInfo()
 	})
 }
 
+// RetryNow implements blockchain.RetryNower. If all servers failed, the next round of connection
+// attempts is started immediately instead of after the backoff delay.
+func (f *failoverClient) RetryNow() {
+	f.reconnect.retryNow()
+}
+
 func (f *failoverClient) Close() {
+	// Stop waiting for the next connection attempt first, as the failover client can only close
+	// once the pending connection attempt returned.
+	f.reconnect.close()
 	f.failover.Close()
 }
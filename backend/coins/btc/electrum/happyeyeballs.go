@@ -0,0 +1,146 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// fallbackDelay is how long the connection attempt to the preferred address family may take
+// before the other address family is tried in parallel. Same as the default of
+// net.Dialer.FallbackDelay.
+const fallbackDelay = 300 * time.Millisecond
+
+// familyNetworks maps the address families returned by remoteAddressFamily() to the networks
+// restricting net.Dialer to them.
+var familyNetworks = map[string]string{
+	"ipv4": "tcp4",
+	"ipv6": "tcp6",
+}
+
+// serverDialer connects to one server without a proxy. Until a connection succeeded, the happy
+// eyeballs dialing of net.Dialer is used, which tries the address family of the first resolved
+// address first. Afterwards, the address family of the last successful connection is tried first,
+// so that a family which is broken on the current network does not delay every reconnect.
+type serverDialer struct {
+	dialContext   func(ctx context.Context, network, address string) (net.Conn, error)
+	fallbackDelay time.Duration
+
+	// lastFamily is the address family of the last successful connection, see
+	// remoteAddressFamily(). Empty if no connection succeeded yet.
+	lastFamily string
+	lock       locker.Locker
+}
+
+func newServerDialer(dialer *net.Dialer) *serverDialer {
+	return &serverDialer{
+		dialContext:   dialer.DialContext,
+		fallbackDelay: fallbackDelay,
+	}
+}
+
+// Dial implements proxy.Dialer.
+func (d *serverDialer) Dial(network, address string) (net.Conn, error) {
+	unlock := d.lock.RLock()
+	preferred := d.lastFamily
+	unlock()
+
+	var conn net.Conn
+	var err error
+	if network == "tcp" && preferred != "" {
+		conn, err = d.dialPreferring(address, preferred)
+	} else {
+		conn, err = d.dialContext(context.Background(), network, address)
+	}
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if family := remoteAddressFamily(conn); family != "" {
+		defer d.lock.Lock()()
+		d.lastFamily = family
+	}
+	return conn, nil
+}
+
+// dialPreferring connects to the preferred address family first. The other address family is tried
+// in parallel after the fallback delay, or right away if the first attempt failed. The first
+// established connection is returned and the other attempt is canceled.
+func (d *serverDialer) dialPreferring(address string, preferred string) (net.Conn, error) {
+	other := "ipv4"
+	if preferred == "ipv4" {
+		other = "ipv6"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	// Buffered so that the attempt which lost the race never blocks.
+	results := make(chan result, 2)
+	dial := func(family string) {
+		go func() {
+			conn, err := d.dialContext(ctx, familyNetworks[family], address)
+			results <- result{conn: conn, err: err}
+		}()
+	}
+	dial(preferred)
+	pending := 1
+	fallback := time.NewTimer(d.fallbackDelay)
+	defer fallback.Stop()
+	fallbackStarted := false
+	startFallback := func() {
+		if !fallbackStarted {
+			fallbackStarted = true
+			pending++
+			dial(other)
+		}
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-fallback.C:
+			startFallback()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if pending > 0 {
+					// Close the connection of the other attempt if it succeeded despite being
+					// canceled.
+					go func() {
+						if loser := <-results; loser.conn != nil {
+							_ = loser.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			startFallback()
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
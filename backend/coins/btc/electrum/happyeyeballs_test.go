@@ -0,0 +1,174 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listen starts a local listener accepting and immediately closing connections. It returns the
+// address, or an empty string if the address family is not available.
+func listen(t *testing.T, network, address string) string {
+	t.Helper()
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return ""
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// testNetwork simulates a dual-stack server with one local listener per address family, one of
+// which can be blackholed: connection attempts to it hang until they are canceled.
+type testNetwork struct {
+	listeners map[string]string
+
+	lock       sync.Mutex
+	blackholed string
+	// dials are the networks passed to dialContext, in order.
+	dials []string
+}
+
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+	ipv4 := listen(t, "tcp4", "127.0.0.1:0")
+	ipv6 := listen(t, "tcp6", "[::1]:0")
+	if ipv4 == "" || ipv6 == "" {
+		t.Skip("IPv4 and IPv6 loopback addresses are required")
+	}
+	return &testNetwork{listeners: map[string]string{"tcp4": ipv4, "tcp6": ipv6}}
+}
+
+func (n *testNetwork) blackhole(family string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.blackholed = familyNetworks[family]
+	n.dials = nil
+}
+
+func (n *testNetwork) dialed() []string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]string{}, n.dials...)
+}
+
+// dialContext ignores the address. With the network "tcp", it connects to the listener which is
+// not blackholed, like the happy eyeballs dialing of net.Dialer eventually would.
+func (n *testNetwork) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	n.lock.Lock()
+	n.dials = append(n.dials, network)
+	blackholed := n.blackholed
+	n.lock.Unlock()
+	if network == "tcp" {
+		network = "tcp4"
+		if blackholed == "tcp4" {
+			network = "tcp6"
+		}
+	}
+	if network == blackholed {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return (&net.Dialer{}).DialContext(ctx, network, n.listeners[network])
+}
+
+func TestServerDialer(t *testing.T) {
+	for _, broken := range []string{"ipv4", "ipv6"} {
+		broken := broken
+		working := "ipv4"
+		if broken == "ipv4" {
+			working = "ipv6"
+		}
+		t.Run("blackholed "+broken, func(t *testing.T) {
+			network := newTestNetwork(t)
+			dialer := newServerDialer(&net.Dialer{})
+			dialer.dialContext = network.dialContext
+			dialer.fallbackDelay = 200 * time.Millisecond
+
+			// The first connection is established by net.Dialer.
+			network.blackhole(broken)
+			conn, err := dialer.Dial("tcp", "electrum.example.com:50002")
+			require.NoError(t, err)
+			require.Equal(t, working, remoteAddressFamily(conn))
+			require.NoError(t, conn.Close())
+			require.Equal(t, []string{"tcp"}, network.dialed())
+
+			// The working family is tried first and connects without waiting for the fallback.
+			network.blackhole(broken)
+			start := time.Now()
+			conn, err = dialer.Dial("tcp", "electrum.example.com:50002")
+			require.NoError(t, err)
+			require.Less(t, time.Since(start), dialer.fallbackDelay)
+			require.Equal(t, working, remoteAddressFamily(conn))
+			require.NoError(t, conn.Close())
+			require.Equal(t, []string{familyNetworks[working]}, network.dialed())
+
+			// The family which worked last becomes blackholed, e.g. after switching networks. The
+			// other family connects after the fallback delay and is preferred from then on.
+			network.blackhole(working)
+			start = time.Now()
+			conn, err = dialer.Dial("tcp", "electrum.example.com:50002")
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, time.Since(start), dialer.fallbackDelay)
+			require.Equal(t, broken, remoteAddressFamily(conn))
+			require.NoError(t, conn.Close())
+			require.Equal(t,
+				[]string{familyNetworks[working], familyNetworks[broken]}, network.dialed())
+
+			network.blackhole(working)
+			conn, err = dialer.Dial("tcp", "electrum.example.com:50002")
+			require.NoError(t, err)
+			require.Equal(t, broken, remoteAddressFamily(conn))
+			require.NoError(t, conn.Close())
+			require.Equal(t, []string{familyNetworks[broken]}, network.dialed())
+		})
+	}
+}
+
+func TestServerDialerFailure(t *testing.T) {
+	dialer := newServerDialer(&net.Dialer{})
+	dialer.lastFamily = "ipv6"
+	dialer.fallbackDelay = time.Hour
+	var lock sync.Mutex
+	dials := []string{}
+	dialer.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		dials = append(dials, network)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: &net.AddrError{Err: "unreachable"}}
+	}
+	// The other family is tried right away if the preferred family fails, without waiting for the
+	// fallback delay.
+	_, err := dialer.Dial("tcp", "electrum.example.com:50002")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "tcp6")
+	require.Equal(t, []string{"tcp6", "tcp4"}, dials)
+	require.Equal(t, "ipv6", dialer.lastFamily)
+}
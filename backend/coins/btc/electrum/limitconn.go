@@ -0,0 +1,144 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultMaxMessageSize is the default maximum size of a single JSON-RPC message received from a
+// server, see SetMaxMessageSize.
+const DefaultMaxMessageSize = 32 * 1024 * 1024
+
+// oversizedServerCooldown is how long a server which sent an oversized response is not connected
+// to again.
+const oversizedServerCooldown = 10 * time.Minute
+
+// ErrOversizedResponse is returned when a server sends a message larger than the maximum message
+// size. The connection is closed in this case.
+var ErrOversizedResponse = errors.New("oversized response")
+
+// maxMessageSize is the maximum size in bytes of a newline delimited message received from a
+// server. It is set at the app startup and never changes during the runtime.
+var maxMessageSize = DefaultMaxMessageSize
+
+// SetMaxMessageSize sets the maximum size of a single JSON-RPC message received from a server.
+// Servers sending larger messages are disconnected. A size of 0 or less restores the default.
+// SetMaxMessageSize is unsafe for concurrent use.
+func SetMaxMessageSize(size int) {
+	if size <= 0 {
+		size = DefaultMaxMessageSize
+	}
+	maxMessageSize = size
+}
+
+// MaxMessageSize returns the maximum size of a single message received from a server, see
+// SetMaxMessageSize. It also bounds the responses of Esplora servers.
+func MaxMessageSize() int {
+	return maxMessageSize
+}
+
+// limitedConn fails reading with ErrOversizedResponse as soon as a newline delimited message
+// exceeds maxMessageSize, so that the line based JSON-RPC reader buffers at most maxMessageSize
+// plus one read buffer per message.
+type limitedConn struct {
+	net.Conn
+	maxMessageSize int
+	// onOversized is called once when an oversized message is detected.
+	onOversized func()
+
+	// messageSize is the number of bytes read since the last newline.
+	messageSize int
+	oversized   bool
+	mu          sync.Mutex
+}
+
+func newLimitedConn(conn net.Conn, maxMessageSize int, onOversized func()) *limitedConn {
+	return &limitedConn{
+		Conn:           conn,
+		maxMessageSize: maxMessageSize,
+		onOversized:    onOversized,
+	}
+}
+
+// Read implements net.Conn.
+func (conn *limitedConn) Read(p []byte) (int, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.oversized {
+		return 0, ErrOversizedResponse
+	}
+	n, err := conn.Conn.Read(p)
+	data := p[:n]
+	for len(data) > 0 {
+		newline := bytes.IndexByte(data, '\n')
+		if newline == -1 {
+			conn.messageSize += len(data)
+			break
+		}
+		conn.messageSize += newline
+		if conn.messageSize > conn.maxMessageSize {
+			break
+		}
+		conn.messageSize = 0
+		data = data[newline+1:]
+	}
+	if conn.messageSize > conn.maxMessageSize {
+		conn.oversized = true
+		_ = conn.Conn.Close()
+		if conn.onOversized != nil {
+			go conn.onOversized()
+		}
+		return 0, ErrOversizedResponse
+	}
+	return n, err
+}
+
+// oversizedServers keeps track of the servers which sent an oversized response, so they are
+// skipped for a while.
+type oversizedServers struct {
+	flaggedUntil map[string]time.Time
+	mu           sync.Mutex
+}
+
+func newOversizedServers() *oversizedServers {
+	return &oversizedServers{flaggedUntil: map[string]time.Time{}}
+}
+
+// flag marks the server as misbehaving for oversizedServerCooldown.
+func (servers *oversizedServers) flag(server string) {
+	servers.mu.Lock()
+	defer servers.mu.Unlock()
+	servers.flaggedUntil[server] = time.Now().Add(oversizedServerCooldown)
+}
+
+// isFlagged returns true if the server sent an oversized response recently.
+func (servers *oversizedServers) isFlagged(server string) bool {
+	servers.mu.Lock()
+	defer servers.mu.Unlock()
+	until, ok := servers.flaggedUntil[server]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(servers.flaggedUntil, server)
+		return false
+	}
+	return true
+}
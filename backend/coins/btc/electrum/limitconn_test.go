@@ -0,0 +1,128 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitedConn(t *testing.T) {
+	const maxSize = 64 * 1024
+	// bufio.Reader's default buffer size, the most that is read beyond the limit.
+	const readBufferSize = 4096
+
+	tt := []struct {
+		name string
+		// frames are written by the server, in order, after which it keeps sending garbage without
+		// newline until the connection is closed.
+		frames    []string
+		wantLines []string
+	}{
+		{
+			name:      "oversized first message",
+			wantLines: nil,
+		},
+		{
+			name:      "valid messages before oversized message",
+			frames:    []string{"{}\n", string(bytes.Repeat([]byte("a"), maxSize)) + "\n"},
+			wantLines: []string{"{}\n", string(bytes.Repeat([]byte("a"), maxSize)) + "\n"},
+		},
+		{
+			name:      "messages split across reads",
+			frames:    []string{"{\"a\":", "1}\n{", "}\n"},
+			wantLines: []string{"{\"a\":1}\n", "{}\n"},
+		},
+	}
+	for _, testcase := range tt {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			fakeNode := &test.TCPServer{
+				GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+					return test.TCPServerCert, nil
+				},
+			}
+			fakeNode.StartTLS(func(conn net.Conn) {
+				defer conn.Close() //nolint:errcheck
+				for _, frame := range testcase.frames {
+					if _, err := conn.Write([]byte(frame)); err != nil {
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				garbage := bytes.Repeat([]byte("x"), readBufferSize)
+				for {
+					if _, err := conn.Write(garbage); err != nil {
+						return
+					}
+				}
+			})
+			defer fakeNode.Close()
+
+			conn, err := establishConnection(&config.ServerInfo{
+				Server:  "node.example.org:123",
+				TLS:     true,
+				PEMCert: test.TCPServerCertPub,
+			}, fakeNode.Dialer())
+			require.NoError(t, err)
+
+			oversized := make(chan struct{})
+			reader := bufio.NewReader(newLimitedConn(conn, maxSize, func() { close(oversized) }))
+			for _, wantLine := range testcase.wantLines {
+				line, err := reader.ReadBytes('\n')
+				require.NoError(t, err)
+				require.Equal(t, wantLine, string(line))
+			}
+			line, err := reader.ReadBytes('\n')
+			require.True(t, errors.Is(err, ErrOversizedResponse))
+			require.LessOrEqual(t, len(line), maxSize+readBufferSize)
+			select {
+			case <-oversized:
+			case <-time.After(3 * time.Second):
+				t.Fatal("onOversized was not called")
+			}
+			// The connection stays failed.
+			_, err = reader.ReadBytes('\n')
+			require.True(t, errors.Is(err, ErrOversizedResponse))
+		})
+	}
+}
+
+func TestOversizedServers(t *testing.T) {
+	servers := newOversizedServers()
+	require.False(t, servers.isFlagged("a"))
+	servers.flag("a")
+	require.True(t, servers.isFlagged("a"))
+	require.False(t, servers.isFlagged("b"))
+	servers.flaggedUntil["a"] = time.Now().Add(-time.Second)
+	require.False(t, servers.isFlagged("a"))
+}
+
+func TestSetMaxMessageSize(t *testing.T) {
+	defer SetMaxMessageSize(0)
+	SetMaxMessageSize(1024)
+	require.Equal(t, 1024, maxMessageSize)
+	SetMaxMessageSize(0)
+	require.Equal(t, DefaultMaxMessageSize, maxMessageSize)
+}
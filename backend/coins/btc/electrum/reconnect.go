@@ -0,0 +1,119 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/backoff"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+)
+
+const (
+	// minReconnectDelay is the delay after all servers failed for the first time.
+	minReconnectDelay = time.Second
+	// maxReconnectDelay caps the delay after repeated failures of all servers.
+	maxReconnectDelay = 2 * time.Minute
+	// failoverRetryTimeout is the retry timeout of the failover client after all servers failed.
+	// The actual delay is applied by reconnectBackoff, so this is just the minimum allowed value.
+	failoverRetryTimeout = time.Millisecond
+)
+
+// reconnectBackoff spaces out the connection attempts once all servers failed, with an
+// exponential backoff and jitter. The failover client would otherwise retry after a fixed timeout,
+// hammering the servers the moment the connectivity returns.
+type reconnectBackoff struct {
+	backoff    *backoff.Backoff
+	numServers int
+	// onWait is called before waiting for the next round of connection attempts with the error of
+	// the last failed attempt and the time of the next attempt.
+	onWait   func(err error, nextAttempt time.Time)
+	quitChan chan struct{}
+	quitOnce sync.Once
+
+	// failedAttempts counts the failed connection attempts since the last successful one.
+	failedAttempts int
+	lastErr        error
+	// covers failedAttempts and lastErr.
+	mu sync.Mutex
+}
+
+func newReconnectBackoff(
+	numServers int, onWait func(err error, nextAttempt time.Time)) *reconnectBackoff {
+	return &reconnectBackoff{
+		backoff:    backoff.New(minReconnectDelay, maxReconnectDelay),
+		numServers: numServers,
+		onWait:     onWait,
+		quitChan:   make(chan struct{}),
+	}
+}
+
+// beforeConnect waits for the backoff delay if all servers failed since the last successful
+// connection attempt. It must be called before each connection attempt. It returns true if it
+// waited, and failover.ErrClosed if closed while waiting.
+func (r *reconnectBackoff) beforeConnect() (bool, error) {
+	select {
+	case <-r.quitChan:
+		return false, failover.ErrClosed
+	default:
+	}
+	r.mu.Lock()
+	allFailed := r.failedAttempts > 0 && r.failedAttempts%max(r.numServers, 1) == 0
+	lastErr := r.lastErr
+	r.mu.Unlock()
+	if !allFailed {
+		return false, nil
+	}
+	delay := r.backoff.Next()
+	r.onWait(lastErr, time.Now().Add(delay))
+	if !r.backoff.Wait(delay, r.quitChan) {
+		return true, failover.ErrClosed
+	}
+	return true, nil
+}
+
+// connectFailed records a failed connection attempt.
+func (r *reconnectBackoff) connectFailed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedAttempts++
+	r.lastErr = err
+}
+
+// connected records a successful connection attempt. The backoff delay is only reset once a
+// request succeeded, see requestSucceeded(), as a server might accept connections without
+// serving requests.
+func (r *reconnectBackoff) connected() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedAttempts = 0
+	r.lastErr = nil
+}
+
+// requestSucceeded resets the backoff delay.
+func (r *reconnectBackoff) requestSucceeded() {
+	r.backoff.Reset()
+}
+
+// retryNow stops waiting for the next round of connection attempts.
+func (r *reconnectBackoff) retryNow() {
+	r.backoff.RetryNow()
+}
+
+// close stops waiting for good, so that the failover client can be closed.
+func (r *reconnectBackoff) close() {
+	r.quitOnce.Do(func() { close(r.quitChan) })
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/backoff"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	type wait struct {
+		err         error
+		nextAttempt time.Time
+	}
+	waits := make(chan wait, 10)
+	r := newReconnectBackoff(2, func(err error, nextAttempt time.Time) {
+		waits <- wait{err: err, nextAttempt: nextAttempt}
+	})
+	r.backoff = backoff.New(time.Hour, time.Hour)
+
+	// No delay before the first attempt and while not all servers failed.
+	waited, err := r.beforeConnect()
+	require.NoError(t, err)
+	require.False(t, waited)
+	r.connectFailed(errors.New("first"))
+	waited, err = r.beforeConnect()
+	require.NoError(t, err)
+	require.False(t, waited)
+	require.Empty(t, waits)
+
+	// All servers failed, waiting until asked to retry.
+	lastErr := errors.New("second")
+	r.connectFailed(lastErr)
+	done := make(chan error)
+	go func() {
+		var err error
+		waited, err = r.beforeConnect()
+		done <- err
+	}()
+	w := <-waits
+	require.Equal(t, lastErr, w.err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), w.nextAttempt, time.Hour/2+time.Minute)
+	select {
+	case <-done:
+		require.Fail(t, "should wait")
+	case <-time.After(10 * time.Millisecond):
+	}
+	for {
+		r.retryNow()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+			require.True(t, waited)
+		case <-time.After(10 * time.Millisecond):
+			continue
+		}
+		break
+	}
+
+	// A successful connection starts counting the failed attempts anew.
+	r.connected()
+	waited, err = r.beforeConnect()
+	require.NoError(t, err)
+	require.False(t, waited)
+	r.connectFailed(errors.New("first"))
+	r.connectFailed(errors.New("second"))
+
+	// Closing ends the wait.
+	go func() {
+		_, err := r.beforeConnect()
+		done <- err
+	}()
+	<-waits
+	r.close()
+	require.Equal(t, failover.ErrClosed, <-done)
+	_, err = r.beforeConnect()
+	require.Equal(t, failover.ErrClosed, err)
+	require.Empty(t, waits)
+}
+
+func TestFailoverClientConnectionStatus(t *testing.T) {
+	reconnect := newReconnectBackoff(2, func(error, time.Time) {})
+	f := newFailoverClient(&failover.Options[*client]{}, reconnect)
+	defer f.Close()
+	statuses := make(chan blockchain.ConnectionStatus, 10)
+	f.RegisterOnConnectionStatusChangedEvent(func(status blockchain.ConnectionStatus) {
+		statuses <- status
+	})
+	requireStatus := func(expected blockchain.ConnectionStatus) {
+		t.Helper()
+		require.Equal(t, expected, f.ConnectionStatus())
+		require.Equal(t, expected, <-statuses)
+	}
+
+	require.Equal(t,
+		blockchain.ConnectionStatus{State: blockchain.ConnectionStateConnecting},
+		f.ConnectionStatus())
+
+	f.setConnecting("server1", false)
+	requireStatus(blockchain.ConnectionStatus{
+		State: blockchain.ConnectionStateConnecting, Server: "server1"})
+	// The first server failed.
+	f.setConnecting("server2", false)
+	requireStatus(blockchain.ConnectionStatus{
+		State: blockchain.ConnectionStateFailover, Server: "server2"})
+	f.setConnected("server2")
+	requireStatus(blockchain.ConnectionStatus{
+		State: blockchain.ConnectionStateConnected, Server: "server2"})
+	// The connection was lost.
+	f.setConnecting("server1", false)
+	requireStatus(blockchain.ConnectionStatus{
+		State: blockchain.ConnectionStateFailover, Server: "server1"})
+
+	// All servers failed.
+	connErr := errors.New("unreachable")
+	nextAttempt := time.Now().Add(time.Minute)
+	f.setWaitingToRetry(&blockchain.RetryError{Err: connErr, NextAttempt: nextAttempt})
+	errMsg := "unreachable"
+	requireStatus(blockchain.ConnectionStatus{
+		State:       blockchain.ConnectionStateDisconnected,
+		Error:       &errMsg,
+		NextAttempt: &nextAttempt,
+	})
+	require.ErrorIs(t, f.ConnectionError(), connErr)
+	f.setConnecting("server2", true)
+	requireStatus(blockchain.ConnectionStatus{
+		State: blockchain.ConnectionStateConnecting, Server: "server2"})
+}
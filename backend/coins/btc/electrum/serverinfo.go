@@ -0,0 +1,143 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/block-client-go/electrum"
+	"github.com/BitBoxSwiss/block-client-go/jsonrpc"
+)
+
+const (
+	// minProtocolVersion and maxProtocolVersion are the range of Electrum protocol versions the
+	// app supports. The version is negotiated with `server.version` when connecting.
+	minProtocolVersion = "1.4"
+	maxProtocolVersion = "1.4.2"
+
+	// bannerTimeout is the maximum duration of fetching the banner of a server.
+	bannerTimeout = 30 * time.Second
+)
+
+// ErrUnsupportedProtocolVersion is returned when connecting to a server which does not support any
+// Electrum protocol version in the range supported by the app.
+var ErrUnsupportedProtocolVersion = errors.New(
+	"the server does not support Electrum protocol version " +
+		minProtocolVersion + " to " + maxProtocolVersion)
+
+// parseProtocolVersion parses a protocol version like "1.4.2" into its numeric components.
+func parseProtocolVersion(version string) ([]int, error) {
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for index, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, errp.Newf("invalid protocol version %q", version)
+		}
+		numbers[index] = number
+	}
+	return numbers, nil
+}
+
+// compareProtocolVersions returns -1, 0 or 1 if a is lower than, equal to or higher than b.
+// Missing components count as zero, so "1.4" equals "1.4.0".
+func compareProtocolVersions(a, b []int) int {
+	for index := 0; index < len(a) || index < len(b); index++ {
+		var numberA, numberB int
+		if index < len(a) {
+			numberA = a[index]
+		}
+		if index < len(b) {
+			numberB = b[index]
+		}
+		if numberA != numberB {
+			if numberA < numberB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkProtocolVersion returns ErrUnsupportedProtocolVersion if the given protocol version is not
+// in the supported range.
+func checkProtocolVersion(version string) error {
+	parsed, err := parseProtocolVersion(version)
+	if err != nil {
+		return errp.WithMessage(ErrUnsupportedProtocolVersion, err.Error())
+	}
+	minVersion, err := parseProtocolVersion(minProtocolVersion)
+	if err != nil {
+		panic(err)
+	}
+	maxVersion, err := parseProtocolVersion(maxProtocolVersion)
+	if err != nil {
+		panic(err)
+	}
+	if compareProtocolVersions(parsed, minVersion) < 0 || compareProtocolVersions(parsed, maxVersion) > 0 {
+		return errp.WithMessage(ErrUnsupportedProtocolVersion, "server agreed to "+version)
+	}
+	return nil
+}
+
+// negotiatedVersion returns the server software and the protocol version agreed in the
+// `server.version` negotiation, and checks that the protocol version is supported.
+func negotiatedVersion(serverVersion electrum.ServerVersion) (string, string, error) {
+	// The version is formatted as "software;protocol". The software may contain a semicolon
+	// itself, the protocol version never does.
+	formatted := serverVersion.String()
+	separator := strings.LastIndex(formatted, ";")
+	software, protocolVersion := formatted[:separator], formatted[separator+1:]
+	if err := checkProtocolVersion(protocolVersion); err != nil {
+		return "", "", err
+	}
+	return software, protocolVersion, nil
+}
+
+// isUnsupportedProtocolError returns true if the server rejected the `server.version` negotiation
+// because it does not support the protocol versions offered.
+func isUnsupportedProtocolError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "unsupported protocol version")
+}
+
+// fetchBanner fetches the banner of the server using a new, short-lived connection, as the
+// `server.banner` call is not exposed by the Electrum client.
+func fetchBanner(dial func() (net.Conn, error)) (string, error) {
+	rpc, err := jsonrpc.Connect(&jsonrpc.Options{Dial: dial})
+	if err != nil {
+		return "", err
+	}
+	defer rpc.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), bannerTimeout)
+	defer cancel()
+	var version [2]string
+	if err := rpc.MethodBlocking(
+		ctx, &version, "server.version",
+		softwareVersion, []string{minProtocolVersion, maxProtocolVersion}); err != nil {
+		return "", errp.WithStack(err)
+	}
+	var banner string
+	if err := rpc.MethodBlocking(ctx, &banner, "server.banner"); err != nil {
+		return "", errp.WithStack(err)
+	}
+	return banner, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package electrum
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	electrumTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProtocolVersion(t *testing.T) {
+	for _, version := range []string{"1.4", "1.4.0", "1.4.1", "1.4.2"} {
+		require.NoError(t, checkProtocolVersion(version), version)
+	}
+	for _, version := range []string{"1.3", "1.3.9", "1.4.3", "1.5", "2.0", "", "1.x", "1.-4"} {
+		err := checkProtocolVersion(version)
+		require.Equal(t, ErrUnsupportedProtocolVersion, errp.Cause(err), version)
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	log := logging.Get().WithGroup("electrum_test")
+	chain := electrumTest.NewChain(&chaincfg.RegressionNetParams)
+	server, err := chain.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	require.NoError(t, CheckElectrumServer(server.ServerInfo(), log, &net.Dialer{}))
+
+	connection := NewElectrumConnection(
+		[]*config.ServerInfo{server.ServerInfo()}, log, &net.Dialer{}, 0).(*failoverClient)
+	defer connection.Close()
+	serverInfo, err := connection.ServerInfo()
+	require.NoError(t, err)
+	require.Equal(t, &blockchain.ServerInfo{
+		Server:          server.ServerInfo().Server,
+		Software:        electrumTest.ServerSoftware,
+		ProtocolVersion: "1.4",
+		AddressFamily:   "ipv4",
+		Banner:          electrumTest.ServerBanner,
+	}, serverInfo)
+}
+
+func TestUnsupportedProtocolVersion(t *testing.T) {
+	log := logging.Get().WithGroup("electrum_test")
+	chain := electrumTest.NewChain(&chaincfg.RegressionNetParams)
+	server, err := chain.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// The server refuses the negotiation.
+	server.SetProtocolVersion("")
+	err = CheckElectrumServer(server.ServerInfo(), log, &net.Dialer{})
+	require.Equal(t, ErrUnsupportedProtocolVersion, errp.Cause(err))
+
+	// The server agrees to a version outside of the supported range.
+	server.SetProtocolVersion("1.2")
+	err = CheckElectrumServer(server.ServerInfo(), log, &net.Dialer{})
+	require.Equal(t, ErrUnsupportedProtocolVersion, errp.Cause(err))
+}
@@ -0,0 +1,713 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package test provides a fake Electrum server for tests. It serves a chain of blocks kept in
+// memory, which the test extends, reorgs and broadcasts to, over the Electrum protocol. The blocks
+// have a valid proof of work on regtest, so that the app can verify the headers and transactions
+// like on a real chain.
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	btcdBlockchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// maxHeaders is the maximum number of headers returned by blockchain.block.headers.
+	maxHeaders = 2016
+	// estimatedFee is the fee rate returned by blockchain.estimatefee, in BTC/kB.
+	estimatedFee = 0.0001
+	// relayFee is the fee rate returned by blockchain.relayfee, in BTC/kB.
+	relayFee = 0.00001
+
+	// ServerSoftware is the server software returned by server.version.
+	ServerSoftware = "FakeElectrum 1.0"
+	// ServerBanner is the banner returned by server.banner.
+	ServerBanner = "Welcome to FakeElectrum"
+)
+
+type block struct {
+	header wire.BlockHeader
+	// txs are the hashes of the transactions of the block, the coinbase first.
+	txs []chainhash.Hash
+}
+
+// Chain is a blockchain kept in memory, served by one or more fake Electrum servers. All servers
+// of a chain see the same blocks and mempool and notify their clients of changes.
+type Chain struct {
+	net *chaincfg.Params
+
+	// blocks are indexed by height. The first block is the genesis block of the network.
+	blocks  []*block
+	mempool []chainhash.Hash
+	txs     map[chainhash.Hash]*wire.MsgTx
+	// broadcasts are the hashes of the transactions broadcast through the servers of the chain.
+	broadcasts []chainhash.Hash
+	// extraNonce is included in the coinbase of each block, so that no two blocks are the same.
+	extraNonce uint64
+	servers    []*Server
+	mu         sync.Mutex
+}
+
+// NewChain returns a chain consisting of the genesis block of the given network. The proof of
+// work of new blocks is only cheap enough to compute on regtest.
+func NewChain(net *chaincfg.Params) *Chain {
+	return &Chain{
+		net:    net,
+		blocks: []*block{{header: net.GenesisBlock.Header}},
+		txs:    map[chainhash.Hash]*wire.MsgTx{},
+	}
+}
+
+// TipHeight returns the height of the last block.
+func (chain *Chain) TipHeight() int {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	return len(chain.blocks) - 1
+}
+
+// Height returns the height of the block the transaction is included in, 0 if it is in the mempool
+// and -1 if it is unknown.
+func (chain *Chain) Height(txHash chainhash.Hash) int {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	return chain.height(txHash)
+}
+
+func (chain *Chain) height(txHash chainhash.Hash) int {
+	for height, block := range chain.blocks {
+		for _, blockTxHash := range block.txs {
+			if blockTxHash == txHash {
+				return height
+			}
+		}
+	}
+	for _, mempoolTxHash := range chain.mempool {
+		if mempoolTxHash == txHash {
+			return 0
+		}
+	}
+	return -1
+}
+
+// Tx returns the transaction with the given hash, nil if it is unknown.
+func (chain *Chain) Tx(txHash chainhash.Hash) *wire.MsgTx {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	return chain.txs[txHash]
+}
+
+// Broadcasts returns the hashes of the transactions broadcast through the servers of the chain.
+func (chain *Chain) Broadcasts() []chainhash.Hash {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	return append([]chainhash.Hash(nil), chain.broadcasts...)
+}
+
+// AddToMempool adds the transactions to the mempool.
+func (chain *Chain) AddToMempool(txs ...*wire.MsgTx) {
+	func() {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		for _, tx := range txs {
+			chain.addToMempool(tx)
+		}
+	}()
+	chain.notify()
+}
+
+func (chain *Chain) addToMempool(tx *wire.MsgTx) {
+	txHash := tx.TxHash()
+	if chain.height(txHash) != -1 {
+		return
+	}
+	chain.txs[txHash] = tx
+	chain.mempool = append(chain.mempool, txHash)
+}
+
+// Mine adds a block containing the given transactions, which are removed from the mempool if they
+// were in it. Transactions of the mempool which are not given stay in the mempool.
+func (chain *Chain) Mine(txs ...*wire.MsgTx) {
+	func() {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		chain.mine(txs)
+	}()
+	chain.notify()
+}
+
+// Reorg replaces the last depth blocks by the given blocks, each a list of transactions. The
+// transactions of the replaced blocks which are not included in the new blocks are moved to the
+// mempool. There must be more new blocks than replaced blocks for the clients to follow the reorg.
+func (chain *Chain) Reorg(depth int, blocks ...[]*wire.MsgTx) {
+	func() {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		if depth >= len(chain.blocks) {
+			panic("cannot reorg the genesis block")
+		}
+		disconnected := chain.blocks[len(chain.blocks)-depth:]
+		chain.blocks = chain.blocks[:len(chain.blocks)-depth]
+		for _, block := range disconnected {
+			// Skip the coinbase.
+			chain.mempool = append(chain.mempool, block.txs[1:]...)
+		}
+		for _, txs := range blocks {
+			chain.mine(txs)
+		}
+	}()
+	chain.notify()
+}
+
+// mine adds a block with the given transactions. The lock must be held.
+func (chain *Chain) mine(txs []*wire.MsgTx) {
+	height := len(chain.blocks)
+	previous := chain.blocks[height-1].header
+
+	chain.extraNonce++
+	extraNonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(extraNonce, chain.extraNonce)
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  extraNonce,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+
+	block := &block{txs: []chainhash.Hash{coinbase.TxHash()}}
+	chain.txs[coinbase.TxHash()] = coinbase
+	for _, tx := range txs {
+		txHash := tx.TxHash()
+		chain.txs[txHash] = tx
+		block.txs = append(block.txs, txHash)
+		for index, mempoolTxHash := range chain.mempool {
+			if mempoolTxHash == txHash {
+				chain.mempool = append(chain.mempool[:index:index], chain.mempool[index+1:]...)
+				break
+			}
+		}
+	}
+
+	merkleRoot, _ := merkleBranch(block.txs, 0)
+	block.header = wire.BlockHeader{
+		Version:    0x20000000,
+		PrevBlock:  previous.BlockHash(),
+		MerkleRoot: merkleRoot,
+		Timestamp:  chain.net.GenesisBlock.Header.Timestamp.Add(time.Duration(height) * 10 * time.Minute),
+		Bits:       previous.Bits,
+	}
+	target := btcdBlockchain.CompactToBig(block.header.Bits)
+	for {
+		blockHash := block.header.BlockHash()
+		if btcdBlockchain.HashToBig(&blockHash).Cmp(target) <= 0 {
+			break
+		}
+		block.header.Nonce++
+	}
+	chain.blocks = append(chain.blocks, block)
+}
+
+// merkleBranch returns the merkle root of the given hashes and the merkle branch of the hash at the
+// given position.
+func merkleBranch(hashes []chainhash.Hash, pos int) (chainhash.Hash, []chainhash.Hash) {
+	level := append([]chainhash.Hash(nil), hashes...)
+	branch := []chainhash.Hash{}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		branch = append(branch, level[pos^1])
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			next[i] = chainhash.DoubleHashH(append(level[2*i][:], level[2*i+1][:]...))
+		}
+		level = next
+		pos /= 2
+	}
+	return level[0], branch
+}
+
+// history returns the history of the script hash according to the Electrum protocol. The lock must
+// be held.
+func (chain *Chain) history(scriptHashHex blockchain.ScriptHashHex) blockchain.TxHistory {
+	involves := func(tx *wire.MsgTx) bool {
+		for _, txOut := range tx.TxOut {
+			if blockchain.NewScriptHashHex(txOut.PkScript) == scriptHashHex {
+				return true
+			}
+		}
+		for _, txIn := range tx.TxIn {
+			prevTx, ok := chain.txs[txIn.PreviousOutPoint.Hash]
+			if !ok || int(txIn.PreviousOutPoint.Index) >= len(prevTx.TxOut) {
+				continue
+			}
+			if blockchain.NewScriptHashHex(prevTx.TxOut[txIn.PreviousOutPoint.Index].PkScript) == scriptHashHex {
+				return true
+			}
+		}
+		return false
+	}
+	history := blockchain.TxHistory{}
+	for height, block := range chain.blocks {
+		for _, txHash := range block.txs {
+			if tx, ok := chain.txs[txHash]; ok && involves(tx) {
+				history = append(history, &blockchain.TxInfo{Height: height, TXHash: blockchain.TXHash(txHash)})
+			}
+		}
+	}
+	for _, txHash := range chain.mempool {
+		tx := chain.txs[txHash]
+		if !involves(tx) {
+			continue
+		}
+		height := 0
+		for _, txIn := range tx.TxIn {
+			if chain.height(txIn.PreviousOutPoint.Hash) == 0 {
+				height = -1
+			}
+		}
+		history = append(history, &blockchain.TxInfo{Height: height, TXHash: blockchain.TXHash(txHash)})
+	}
+	return history
+}
+
+// status returns the Electrum status of the script hash, nil if the history is empty.
+func (chain *Chain) status(scriptHashHex blockchain.ScriptHashHex) *string {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	history := chain.history(scriptHashHex)
+	if len(history) == 0 {
+		return nil
+	}
+	status := history.Status()
+	return &status
+}
+
+// tipHeader returns the height and the serialized header of the last block.
+func (chain *Chain) tipHeader() (int, string) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	height := len(chain.blocks) - 1
+	return height, serializeHeader(&chain.blocks[height].header)
+}
+
+func serializeHeader(header *wire.BlockHeader) string {
+	serialized := &bytes.Buffer{}
+	if err := header.Serialize(serialized); err != nil {
+		panic(errp.WithStack(err))
+	}
+	return hex.EncodeToString(serialized.Bytes())
+}
+
+func (chain *Chain) notify() {
+	chain.mu.Lock()
+	servers := append([]*Server(nil), chain.servers...)
+	chain.mu.Unlock()
+	for _, server := range servers {
+		server.notify()
+	}
+}
+
+// Server is a fake Electrum server listening on localhost, serving a Chain.
+type Server struct {
+	chain    *Chain
+	listener net.Listener
+
+	down        bool
+	connections map[*connection]struct{}
+	broadcasts  []chainhash.Hash
+	// protocolVersion is the protocol version agreed in `server.version`. If empty, the
+	// negotiation is refused.
+	protocolVersion string
+	// unresponsive are the methods which are not answered.
+	unresponsive map[string]bool
+	mu           sync.Mutex
+}
+
+// connection is a client connection of a server.
+type connection struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	// notifiedHeight is the tip height the client was last notified of, -1 if the client is not
+	// subscribed to headers.
+	notifiedHeight int
+	// scriptHashes are the statuses the client was last notified of, by the subscribed script
+	// hashes.
+	scriptHashes map[blockchain.ScriptHashHex]*string
+	mu           sync.Mutex
+}
+
+// NewServer starts a server serving the chain on a random port of localhost.
+func (chain *Chain) NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	server := &Server{
+		chain:           chain,
+		listener:        listener,
+		connections:     map[*connection]struct{}{},
+		protocolVersion: "1.4",
+		unresponsive:    map[string]bool{},
+	}
+	chain.mu.Lock()
+	chain.servers = append(chain.servers, server)
+	chain.mu.Unlock()
+	go server.accept()
+	return server, nil
+}
+
+// ServerInfo returns the configuration to connect to the server.
+func (server *Server) ServerInfo() *config.ServerInfo {
+	return &config.ServerInfo{Server: server.listener.Addr().String(), TLS: false, PEMCert: ""}
+}
+
+// SetProtocolVersion sets the protocol version the server agrees to in `server.version`. If empty,
+// the server refuses the negotiation like a server not supporting the offered versions.
+func (server *Server) SetProtocolVersion(version string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.protocolVersion = version
+}
+
+// SetUnresponsive makes the server not answer requests of the given method, like a hung server.
+func (server *Server) SetUnresponsive(method string, unresponsive bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.unresponsive[method] = unresponsive
+}
+
+// Drop closes all client connections and refuses new ones until Restore is called.
+func (server *Server) Drop() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.down = true
+	for connection := range server.connections {
+		_ = connection.conn.Close()
+		delete(server.connections, connection)
+	}
+}
+
+// Restore accepts connections again after Drop.
+func (server *Server) Restore() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.down = false
+}
+
+// Connections returns the number of connected clients.
+func (server *Server) Connections() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return len(server.connections)
+}
+
+// Broadcasts returns the hashes of the transactions broadcast through this server.
+func (server *Server) Broadcasts() []chainhash.Hash {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]chainhash.Hash(nil), server.broadcasts...)
+}
+
+// Close stops the server and closes all client connections.
+func (server *Server) Close() {
+	_ = server.listener.Close()
+	server.Drop()
+}
+
+func (server *Server) accept() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		connection := &connection{
+			conn:           conn,
+			notifiedHeight: -1,
+			scriptHashes:   map[blockchain.ScriptHashHex]*string{},
+		}
+		server.mu.Lock()
+		if server.down {
+			_ = conn.Close()
+		} else {
+			server.connections[connection] = struct{}{}
+		}
+		server.mu.Unlock()
+		go server.serve(connection)
+	}
+}
+
+type request struct {
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func (server *Server) serve(connection *connection) {
+	defer func() {
+		server.mu.Lock()
+		delete(server.connections, connection)
+		server.mu.Unlock()
+		_ = connection.conn.Close()
+	}()
+	reader := bufio.NewReader(connection.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			return
+		}
+		server.mu.Lock()
+		unresponsive := server.unresponsive[req.Method]
+		server.mu.Unlock()
+		if unresponsive {
+			continue
+		}
+		result, err := server.handle(connection, &req)
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if err != nil {
+			response["error"] = map[string]interface{}{"code": 1, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		if err := connection.write(response); err != nil {
+			return
+		}
+	}
+}
+
+func (connection *connection) write(message interface{}) error {
+	msg, err := json.Marshal(message)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	connection.writeMu.Lock()
+	defer connection.writeMu.Unlock()
+	_, err = connection.conn.Write(append(msg, '\n'))
+	return err
+}
+
+func (server *Server) handle(connection *connection, req *request) (interface{}, error) {
+	param := func(index int, value interface{}) error {
+		if index >= len(req.Params) {
+			return errp.Newf("%s: missing parameter %d", req.Method, index)
+		}
+		return errp.WithStack(json.Unmarshal(req.Params[index], value))
+	}
+	chain := server.chain
+	switch req.Method {
+	case "server.version":
+		server.mu.Lock()
+		protocolVersion := server.protocolVersion
+		server.mu.Unlock()
+		if protocolVersion == "" {
+			return nil, errp.New("unsupported protocol version: 1.4")
+		}
+		return []string{ServerSoftware, protocolVersion}, nil
+	case "server.banner":
+		return ServerBanner, nil
+	case "server.ping":
+		return nil, nil
+	case "blockchain.estimatefee":
+		return estimatedFee, nil
+	case "blockchain.relayfee":
+		return relayFee, nil
+	case "blockchain.headers.subscribe":
+		height, header := chain.tipHeader()
+		connection.mu.Lock()
+		connection.notifiedHeight = height
+		connection.mu.Unlock()
+		return map[string]interface{}{"height": height, "hex": header}, nil
+	case "blockchain.block.headers":
+		var startHeight, count int
+		if err := param(0, &startHeight); err != nil {
+			return nil, err
+		}
+		if err := param(1, &count); err != nil {
+			return nil, err
+		}
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		headers := ""
+		returned := 0
+		for height := startHeight; height < len(chain.blocks) && returned < count && returned < maxHeaders; height++ {
+			headers += serializeHeader(&chain.blocks[height].header)
+			returned++
+		}
+		return map[string]interface{}{"hex": headers, "count": returned, "max": maxHeaders}, nil
+	case "blockchain.scripthash.subscribe":
+		var scriptHashHex blockchain.ScriptHashHex
+		if err := param(0, &scriptHashHex); err != nil {
+			return nil, err
+		}
+		status := chain.status(scriptHashHex)
+		connection.mu.Lock()
+		connection.scriptHashes[scriptHashHex] = status
+		connection.mu.Unlock()
+		return status, nil
+	case "blockchain.scripthash.get_history":
+		var scriptHashHex blockchain.ScriptHashHex
+		if err := param(0, &scriptHashHex); err != nil {
+			return nil, err
+		}
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		result := []map[string]interface{}{}
+		for _, txInfo := range chain.history(scriptHashHex) {
+			result = append(result, map[string]interface{}{
+				"height":  txInfo.Height,
+				"tx_hash": txInfo.TXHash.Hash().String(),
+			})
+		}
+		return result, nil
+	case "blockchain.transaction.get":
+		var txID string
+		if err := param(0, &txID); err != nil {
+			return nil, err
+		}
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		chain.mu.Lock()
+		tx, ok := chain.txs[*txHash]
+		chain.mu.Unlock()
+		if !ok {
+			return nil, errp.Newf("transaction %s not found", txID)
+		}
+		serialized := &bytes.Buffer{}
+		if err := tx.Serialize(serialized); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		return hex.EncodeToString(serialized.Bytes()), nil
+	case "blockchain.transaction.get_merkle":
+		var txID string
+		var height int
+		if err := param(0, &txID); err != nil {
+			return nil, err
+		}
+		if err := param(1, &height); err != nil {
+			return nil, err
+		}
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		if height <= 0 || height >= len(chain.blocks) {
+			return nil, errp.Newf("no block at height %d", height)
+		}
+		block := chain.blocks[height]
+		for pos, blockTxHash := range block.txs {
+			if blockTxHash != *txHash {
+				continue
+			}
+			_, branch := merkleBranch(block.txs, pos)
+			merkle := make([]string, len(branch))
+			for index, hash := range branch {
+				merkle[index] = hash.String()
+			}
+			return map[string]interface{}{"merkle": merkle, "pos": pos, "block_height": height}, nil
+		}
+		return nil, errp.Newf("transaction %s not in block %d", txID, height)
+	case "blockchain.transaction.broadcast":
+		var rawTxHex string
+		if err := param(0, &rawTxHex); err != nil {
+			return nil, err
+		}
+		rawTx, err := hex.DecodeString(rawTxHex)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		tx := &wire.MsgTx{}
+		if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		server.mu.Lock()
+		server.broadcasts = append(server.broadcasts, tx.TxHash())
+		server.mu.Unlock()
+		chain.mu.Lock()
+		chain.broadcasts = append(chain.broadcasts, tx.TxHash())
+		chain.addToMempool(tx)
+		chain.mu.Unlock()
+		// Notify after responding, like a real server.
+		defer func() { go chain.notify() }()
+		return tx.TxHash().String(), nil
+	default:
+		return nil, errp.Newf("unknown method %s", req.Method)
+	}
+}
+
+// notify sends the new tip and the changed statuses of the subscribed script hashes to the
+// clients.
+func (server *Server) notify() {
+	server.mu.Lock()
+	connections := make([]*connection, 0, len(server.connections))
+	for connection := range server.connections {
+		connections = append(connections, connection)
+	}
+	server.mu.Unlock()
+
+	height, header := server.chain.tipHeader()
+	for _, connection := range connections {
+		notifications := []interface{}{}
+		connection.mu.Lock()
+		if connection.notifiedHeight != -1 && connection.notifiedHeight != height {
+			connection.notifiedHeight = height
+			notifications = append(notifications, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "blockchain.headers.subscribe",
+				"params":  []interface{}{map[string]interface{}{"height": height, "hex": header}},
+			})
+		}
+		for scriptHashHex, notifiedStatus := range connection.scriptHashes {
+			status := server.chain.status(scriptHashHex)
+			if (status == nil) == (notifiedStatus == nil) && (status == nil || *status == *notifiedStatus) {
+				continue
+			}
+			connection.scriptHashes[scriptHashHex] = status
+			notifications = append(notifications, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "blockchain.scripthash.subscribe",
+				"params":  []interface{}{scriptHashHex, status},
+			})
+		}
+		connection.mu.Unlock()
+		for _, notification := range notifications {
+			if err := connection.write(notification); err != nil {
+				break
+			}
+		}
+	}
+}
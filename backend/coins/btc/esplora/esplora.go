@@ -0,0 +1,823 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package esplora implements blockchain.Interface on top of the HTTP REST API of an
+// Esplora/Electrs server, see https://github.com/Blockstream/esplora/blob/master/API.md.
+//
+// Esplora has no push notifications, so subscriptions are implemented by polling.
+package esplora
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultRequestTimeout is the maximum duration of a single HTTP request if none is
+	// configured.
+	defaultRequestTimeout = 30 * time.Second
+	// defaultMaxResponseSize is the maximum size of a response body if none is configured.
+	defaultMaxResponseSize = 32 * 1024 * 1024
+	// defaultPollInterval is how often subscribed script hashes and the chain tip are polled.
+	defaultPollInterval = 30 * time.Second
+	// initialPollRetryInterval is the first delay before retrying the initial poll of a new
+	// subscription. It doubles with every failed attempt, up to the poll interval.
+	initialPollRetryInterval = time.Second
+	// blocksPerPage is the number of blocks returned by `GET /blocks/:start_height`.
+	blocksPerPage = 10
+	// maxHeadersPerCall is the maximum number of headers returned by Headers(), fetched in pages of
+	// blocksPerPage.
+	maxHeadersPerCall = 10 * blocksPerPage
+	// confirmedTxsPerPage is the number of confirmed transactions returned per page by
+	// `GET /scripthash/:hash/txs/chain/:last_seen_txid`.
+	confirmedTxsPerPage = 25
+	// relayFeePerKb is the default minimum relay fee of Bitcoin Core. Esplora does not expose the
+	// relay fee of its node.
+	relayFeePerKb = btcutil.Amount(1000)
+)
+
+// apiError is returned when the server was reachable but rejected the request, e.g. when
+// broadcasting an invalid transaction. These errors do not trigger a failover to the next server.
+type apiError struct {
+	statusCode int
+	message    string
+}
+
+func (err *apiError) Error() string {
+	return fmt.Sprintf("esplora: status %d: %s", err.statusCode, err.message)
+}
+
+type scriptHashSubscription struct {
+	// ctx ends the subscription when it is done.
+	ctx           context.Context
+	scriptHashHex blockchain.ScriptHashHex
+	callback      func(string)
+
+	// notified is true after the callback was called at least once.
+	notified bool
+	status   string
+	// covers notified and status.
+	mu sync.Mutex
+}
+
+// Client is an Esplora client that is backed by one or more servers. Requests are sent to the
+// last server that worked, and fail over to the next server if a server is unreachable.
+type Client struct {
+	servers    []string
+	httpClient *http.Client
+	log        *logrus.Entry
+	// requestTimeout is the maximum duration of a single HTTP request.
+	requestTimeout time.Duration
+	// maxResponseSize is the maximum size of a response body in bytes. Servers sending larger
+	// responses are failed over from.
+	maxResponseSize int
+
+	pollInterval time.Duration
+	quitChan     chan struct{}
+	closeOnce    sync.Once
+	// ctx is used for the requests made by the client itself, e.g. when polling. It is cancelled
+	// when the client is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// currentServer is the index of the server in `servers` that is used for the next request.
+	currentServer int
+
+	subscriptions    []*scriptHashSubscription
+	headersCallbacks []func(*types.Header)
+	tipHeight        int
+	// covers currentServer, subscriptions, headersCallbacks and tipHeight.
+	subscriptionsMu sync.Mutex
+
+	connectionError                   error
+	onConnectionErrorChangedCallbacks []func(error)
+	// covers connectionError and onConnectionErrorChangedCallbacks.
+	mu sync.RWMutex
+}
+
+// NewClient creates a client talking to the given Esplora servers. Servers which are not Esplora
+// servers are ignored. Requests time out after requestTimeout and responses may be at most
+// maxResponseSize bytes large. Defaults are used for values of 0.
+func NewClient(
+	serverInfos []*config.ServerInfo,
+	log *logrus.Entry,
+	httpClient *http.Client,
+	requestTimeout time.Duration,
+	maxResponseSize int,
+) *Client {
+	if requestTimeout <= 0 {
+		requestTimeout = defaultRequestTimeout
+	}
+	if maxResponseSize <= 0 {
+		maxResponseSize = defaultMaxResponseSize
+	}
+	return newClient(serverInfos, log, httpClient, requestTimeout, maxResponseSize, defaultPollInterval)
+}
+
+func newClient(
+	serverInfos []*config.ServerInfo,
+	log *logrus.Entry,
+	httpClient *http.Client,
+	requestTimeout time.Duration,
+	maxResponseSize int,
+	pollInterval time.Duration) *Client {
+	servers := []string{}
+	for _, serverInfo := range serverInfos {
+		if serverInfo.ServerType() != config.ServerTypeEsplora {
+			continue
+		}
+		servers = append(servers, strings.TrimSuffix(serverInfo.Server, "/"))
+	}
+	client := &Client{
+		servers:                           servers,
+		httpClient:                        httpClient,
+		log:                               log.WithFields(logrus.Fields{"group": "esplora", "servers": strings.Join(servers, ", ")}),
+		requestTimeout:                    requestTimeout,
+		maxResponseSize:                   maxResponseSize,
+		pollInterval:                      pollInterval,
+		quitChan:                          make(chan struct{}),
+		onConnectionErrorChangedCallbacks: []func(error){},
+	}
+	client.ctx, client.cancel = context.WithCancel(context.Background())
+	go client.poll()
+	return client
+}
+
+func (c *Client) setConnectionError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != c.connectionError {
+		c.connectionError = err
+		for _, callback := range c.onConnectionErrorChangedCallbacks {
+			go callback(err)
+		}
+	}
+}
+
+// ConnectionError implements blockchain.Interface.
+func (c *Client) ConnectionError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connectionError
+}
+
+// RegisterOnConnectionErrorChangedEvent implements blockchain.Interface.
+func (c *Client) RegisterOnConnectionErrorChangedEvent(callback func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnectionErrorChangedCallbacks = append(c.onConnectionErrorChangedCallbacks, callback)
+}
+
+func (c *Client) requestServer(
+	ctx context.Context, server string, method string, path string, body []byte) ([]byte, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(requestCtx, method, server+path, bodyReader)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %v", blockchain.ErrRequestTimeout, err)
+		}
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	// One more byte than allowed is read to detect oversized responses.
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, int64(c.maxResponseSize)+1))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if len(responseBody) > c.maxResponseSize {
+		return nil, errp.Newf("esplora: response larger than %d bytes", c.maxResponseSize)
+	}
+	switch {
+	case response.StatusCode >= 500:
+		return nil, errp.Newf("esplora: status %d: %s", response.StatusCode, responseBody)
+	case response.StatusCode != http.StatusOK:
+		return nil, &apiError{statusCode: response.StatusCode, message: string(responseBody)}
+	}
+	return responseBody, nil
+}
+
+// request performs the request on the current server, failing over to the other servers if the
+// server is unreachable. If ctx is done, the request is aborted and ctx.Err() is returned.
+func (c *Client) request(ctx context.Context, method string, path string, body []byte) ([]byte, error) {
+	if len(c.servers) == 0 {
+		err := errp.New("no Esplora servers configured")
+		c.setConnectionError(err)
+		return nil, err
+	}
+	c.subscriptionsMu.Lock()
+	first := c.currentServer
+	c.subscriptionsMu.Unlock()
+
+	var err error
+	for i := range c.servers {
+		index := (first + i) % len(c.servers)
+		var response []byte
+		response, err = c.requestServer(ctx, c.servers[index], method, path, body)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var apiErr *apiError
+		if err == nil || errors.As(err, &apiErr) {
+			c.subscriptionsMu.Lock()
+			c.currentServer = index
+			c.subscriptionsMu.Unlock()
+			c.setConnectionError(nil)
+			return response, err
+		}
+		c.log.WithError(err).WithField("server", c.servers[index]).Error("Failover: backend is down")
+	}
+	c.setConnectionError(err)
+	return nil, err
+}
+
+// reportInvalid moves on to the next server if the server at the given index is still the current
+// one.
+func (c *Client) reportInvalid(server int, err error) {
+	c.log.WithError(err).WithField("server", c.servers[server]).Error("Invalid response, failing over")
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	if c.currentServer == server {
+		c.currentServer = (server + 1) % len(c.servers)
+	}
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, result interface{}) error {
+	response, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	return errp.WithStack(json.Unmarshal(response, result))
+}
+
+// esploraScriptHash converts the Electrum script hash format (byte-reversed hex) to the Esplora
+// script hash format (hex of the sha256 hash of the output script).
+func esploraScriptHash(scriptHashHex blockchain.ScriptHashHex) string {
+	hash := scriptHashHex.Hash()
+	return hex.EncodeToString(hash[:])
+}
+
+type txStatus struct {
+	Confirmed   bool `json:"confirmed"`
+	BlockHeight int  `json:"block_height"`
+}
+
+type tx struct {
+	TXID   string   `json:"txid"`
+	Status txStatus `json:"status"`
+}
+
+// ScriptHashGetHistory implements blockchain.Interface. The history is ordered like in the
+// Electrum protocol: confirmed transactions in chain order, followed by unconfirmed transactions.
+// Esplora does not report if an unconfirmed transaction has unconfirmed parents, so their height
+// is always 0.
+func (c *Client) ScriptHashGetHistory(
+	ctx context.Context, scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+	scriptHash := esploraScriptHash(scriptHashHex)
+	// The first page contains all unconfirmed transactions and the first page of confirmed
+	// transactions, newest first.
+	var page []*tx
+	if err := c.getJSON(ctx, fmt.Sprintf("/scripthash/%s/txs", scriptHash), &page); err != nil {
+		return nil, err
+	}
+	unconfirmed := []*tx{}
+	confirmed := []*tx{}
+	for {
+		confirmedInPage := 0
+		for _, t := range page {
+			if t.Status.Confirmed {
+				confirmed = append(confirmed, t)
+				confirmedInPage++
+			} else {
+				unconfirmed = append(unconfirmed, t)
+			}
+		}
+		if confirmedInPage < confirmedTxsPerPage {
+			break
+		}
+		page = nil
+		lastSeen := confirmed[len(confirmed)-1].TXID
+		if err := c.getJSON(ctx,
+			fmt.Sprintf("/scripthash/%s/txs/chain/%s", scriptHash, lastSeen), &page); err != nil {
+			return nil, err
+		}
+	}
+	// Sort for a stable history status.
+	sort.Slice(unconfirmed, func(i, j int) bool { return unconfirmed[i].TXID < unconfirmed[j].TXID })
+
+	history := blockchain.TxHistory{}
+	appendTx := func(t *tx, height int) error {
+		txHash, err := chainhash.NewHashFromStr(t.TXID)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		history = append(history, &blockchain.TxInfo{Height: height, TXHash: blockchain.TXHash(*txHash)})
+		return nil
+	}
+	for i := len(confirmed) - 1; i >= 0; i-- {
+		if err := appendTx(confirmed[i], confirmed[i].Status.BlockHeight); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range unconfirmed {
+		if err := appendTx(t, 0); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// UTXO is an unspent output as returned by ScriptHashListUnspent().
+type UTXO struct {
+	OutPoint wire.OutPoint
+	Value    btcutil.Amount
+	// Height is the height of the block containing the output, or 0 if it is unconfirmed.
+	Height int
+}
+
+// ScriptHashListUnspent returns the unspent outputs paying to the given script hash.
+func (c *Client) ScriptHashListUnspent(
+	ctx context.Context, scriptHashHex blockchain.ScriptHashHex) ([]*UTXO, error) {
+	scriptHash := esploraScriptHash(scriptHashHex)
+	var response []struct {
+		TXID   string   `json:"txid"`
+		Vout   uint32   `json:"vout"`
+		Value  int64    `json:"value"`
+		Status txStatus `json:"status"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("/scripthash/%s/utxo", scriptHash), &response); err != nil {
+		return nil, err
+	}
+	utxos := make([]*UTXO, len(response))
+	for i, utxo := range response {
+		txHash, err := chainhash.NewHashFromStr(utxo.TXID)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		height := 0
+		if utxo.Status.Confirmed {
+			height = utxo.Status.BlockHeight
+		}
+		utxos[i] = &UTXO{
+			OutPoint: *wire.NewOutPoint(txHash, utxo.Vout),
+			Value:    btcutil.Amount(utxo.Value),
+			Height:   height,
+		}
+	}
+	return utxos, nil
+}
+
+// TransactionGet implements blockchain.Interface.
+func (c *Client) TransactionGet(ctx context.Context, txHash chainhash.Hash) (*wire.MsgTx, error) {
+	response, err := c.request(ctx, http.MethodGet, fmt.Sprintf("/tx/%s/hex", txHash), nil)
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := hex.DecodeString(strings.TrimSpace(string(response)))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	tx := &wire.MsgTx{}
+	if err := tx.BtcDecode(bytes.NewReader(rawTx), 0, wire.WitnessEncoding); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if tx.TxHash() != txHash {
+		return nil, errp.New("Response is unexpected (transaction hash mismatch)")
+	}
+	return tx, nil
+}
+
+// TransactionBroadcast implements blockchain.Interface.
+func (c *Client) TransactionBroadcast(ctx context.Context, transaction *wire.MsgTx) error {
+	rawTx := &bytes.Buffer{}
+	_ = transaction.BtcEncode(rawTx, 0, wire.WitnessEncoding)
+	response, err := c.request(
+		ctx, http.MethodPost, "/tx", []byte(hex.EncodeToString(rawTx.Bytes())))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(response)) != transaction.TxHash().String() {
+		return errp.New("Response is unexpected (transaction hash mismatch)")
+	}
+	return nil
+}
+
+// RelayFee implements blockchain.Interface. Esplora does not expose the relay fee of its node, so
+// the Bitcoin Core default is returned.
+func (c *Client) RelayFee(ctx context.Context) (btcutil.Amount, error) {
+	return relayFeePerKb, nil
+}
+
+// EstimateFee implements blockchain.Interface. It returns the estimate for the largest available
+// confirmation target not exceeding `number` blocks, in sat/kB.
+func (c *Client) EstimateFee(ctx context.Context, number int) (btcutil.Amount, error) {
+	var estimates map[string]float64
+	if err := c.getJSON(ctx, "/fee-estimates", &estimates); err != nil {
+		return 0, err
+	}
+	feeRates := map[int]float64{}
+	targets := []int{}
+	for targetStr, feeRate := range estimates {
+		target, err := strconv.Atoi(targetStr)
+		if err != nil {
+			continue
+		}
+		feeRates[target] = feeRate
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return 0, errp.New("esplora: no fee estimates available")
+	}
+	sort.Ints(targets)
+	bestTarget := targets[0]
+	for _, target := range targets {
+		if target <= number {
+			bestTarget = target
+		}
+	}
+	// Esplora estimates are in sat/vB.
+	return btcutil.Amount(math.Round(feeRates[bestTarget] * 1000)), nil
+}
+
+func (c *Client) tip(ctx context.Context) (int, error) {
+	response, err := c.request(ctx, http.MethodGet, "/blocks/tip/height", nil)
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(string(response)))
+	if err != nil {
+		return 0, errp.WithStack(err)
+	}
+	return height, nil
+}
+
+type block struct {
+	ID                string `json:"id"`
+	Height            int    `json:"height"`
+	Version           int32  `json:"version"`
+	Timestamp         int64  `json:"timestamp"`
+	Bits              uint32 `json:"bits"`
+	Nonce             uint32 `json:"nonce"`
+	MerkleRoot        string `json:"merkle_root"`
+	PreviousBlockHash string `json:"previousblockhash"`
+}
+
+func (b *block) header() (*wire.BlockHeader, error) {
+	header := &wire.BlockHeader{
+		Version:   b.Version,
+		Timestamp: time.Unix(b.Timestamp, 0),
+		Bits:      b.Bits,
+		Nonce:     b.Nonce,
+	}
+	// The genesis block has no previous block.
+	if b.PreviousBlockHash != "" {
+		prevBlock, err := chainhash.NewHashFromStr(b.PreviousBlockHash)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		header.PrevBlock = *prevBlock
+	}
+	merkleRoot, err := chainhash.NewHashFromStr(b.MerkleRoot)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	header.MerkleRoot = *merkleRoot
+	if header.BlockHash().String() != b.ID {
+		return nil, errp.Newf("esplora: header of block %d does not match its hash", b.Height)
+	}
+	return header, nil
+}
+
+// Headers implements blockchain.Interface. At most maxHeadersPerCall headers are returned per
+// call. They are fetched in pages of blocksPerPage, the page size of the Esplora blocks endpoint,
+// and the tip is fetched once per call.
+func (c *Client) Headers(
+	ctx context.Context, startHeight int, count int) (*blockchain.HeadersResult, error) {
+	c.subscriptionsMu.Lock()
+	server := c.currentServer
+	c.subscriptionsMu.Unlock()
+	result := &blockchain.HeadersResult{
+		Headers: []*wire.BlockHeader{},
+		Max:     maxHeadersPerCall,
+		ReportInvalid: func(err error) {
+			c.reportInvalid(server, err)
+		},
+	}
+	tip, err := c.tip(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxHeadersPerCall {
+		count = maxHeadersPerCall
+	}
+	endHeight := startHeight + count - 1
+	if endHeight > tip {
+		endHeight = tip
+	}
+	for pageStart := startHeight; pageStart <= endHeight; pageStart += blocksPerPage {
+		pageEnd := pageStart + blocksPerPage - 1
+		if pageEnd > endHeight {
+			pageEnd = endHeight
+		}
+		// Blocks are returned in descending order, starting at the given height.
+		var blocks []*block
+		if err := c.getJSON(ctx, fmt.Sprintf("/blocks/%d", pageEnd), &blocks); err != nil {
+			return nil, err
+		}
+		for i := len(blocks) - 1; i >= 0; i-- {
+			b := blocks[i]
+			if b.Height < pageStart || b.Height > pageEnd {
+				continue
+			}
+			if b.Height != startHeight+len(result.Headers) {
+				return nil, errp.Newf("esplora: unexpected block height %d", b.Height)
+			}
+			header, err := b.header()
+			if err != nil {
+				return nil, err
+			}
+			result.Headers = append(result.Headers, header)
+		}
+		if len(result.Headers) != pageEnd-startHeight+1 {
+			return nil, errp.Newf("esplora: missing blocks up to height %d", pageEnd)
+		}
+	}
+	return result, nil
+}
+
+// GetMerkle implements blockchain.Interface.
+func (c *Client) GetMerkle(
+	ctx context.Context, txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
+	var response struct {
+		BlockHeight int      `json:"block_height"`
+		Merkle      []string `json:"merkle"`
+		Pos         int      `json:"pos"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("/tx/%s/merkle-proof", txHash), &response); err != nil {
+		return nil, err
+	}
+	merkle := make([]blockchain.TXHash, len(response.Merkle))
+	for i, s := range response.Merkle {
+		t, err := chainhash.NewHashFromStr(s)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		merkle[i] = blockchain.TXHash(*t)
+	}
+	return &blockchain.GetMerkleResult{Merkle: merkle, Pos: response.Pos}, nil
+}
+
+// ScriptHashSubscribe implements blockchain.Interface. The callback is called once with the
+// current status and then every time a poll detects a status change, until ctx is done. The initial
+// poll is retried until it succeeds, so the subscription is only torn down once its status was
+// reported.
+func (c *Client) ScriptHashSubscribe(
+	ctx context.Context,
+	setupAndTeardown func() func(),
+	scriptHashHex blockchain.ScriptHashHex,
+	callback func(string)) {
+	if ctx.Err() != nil {
+		return
+	}
+	subscription := &scriptHashSubscription{
+		ctx:           ctx,
+		scriptHashHex: scriptHashHex,
+		callback:      callback,
+	}
+	c.subscriptionsMu.Lock()
+	c.subscriptions = append(c.subscriptions, subscription)
+	c.subscriptionsMu.Unlock()
+
+	teardown := setupAndTeardown()
+	go func() {
+		defer teardown()
+		retryInterval := initialPollRetryInterval
+		for !c.pollScriptHash(subscription) {
+			if retryInterval > c.pollInterval {
+				retryInterval = c.pollInterval
+			}
+			select {
+			case <-c.quitChan:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			retryInterval *= 2
+		}
+	}()
+}
+
+// ScriptHashSubscriptions implements blockchain.Interface. All subscriptions are polled, so they
+// count as active once their initial poll reported the status.
+func (c *Client) ScriptHashSubscriptions() ([]blockchain.ScriptHashHex, []blockchain.ScriptHashHex) {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	notified := map[blockchain.ScriptHashHex]bool{}
+	for _, subscription := range c.subscriptions {
+		if subscription.ctx.Err() != nil {
+			continue
+		}
+		subscription.mu.Lock()
+		notified[subscription.scriptHashHex] = notified[subscription.scriptHashHex] ||
+			subscription.notified
+		subscription.mu.Unlock()
+	}
+	subscribed := []blockchain.ScriptHashHex{}
+	pending := []blockchain.ScriptHashHex{}
+	for scriptHashHex, ok := range notified {
+		if ok {
+			subscribed = append(subscribed, scriptHashHex)
+		} else {
+			pending = append(pending, scriptHashHex)
+		}
+	}
+	return subscribed, pending
+}
+
+// ScriptHashResubscribe implements blockchain.Interface. There is no connection which could drop
+// the subscription, so this only fetches the current status.
+func (c *Client) ScriptHashResubscribe(
+	ctx context.Context,
+	setupAndTeardown func() func(),
+	scriptHashHex blockchain.ScriptHashHex,
+	callback func(string)) {
+	if ctx.Err() != nil {
+		return
+	}
+	teardown := setupAndTeardown()
+	go func() {
+		defer teardown()
+		history, err := c.ScriptHashGetHistory(ctx, scriptHashHex)
+		if err != nil {
+			c.log.WithError(err).Error("Could not fetch the script hash history")
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		callback(history.Status())
+	}()
+}
+
+// HeadersSubscribe implements blockchain.Interface. The callback is called once with the current
+// tip and then every time a poll detects a new tip.
+func (c *Client) HeadersSubscribe(callback func(*types.Header)) {
+	c.subscriptionsMu.Lock()
+	c.headersCallbacks = append(c.headersCallbacks, callback)
+	c.subscriptionsMu.Unlock()
+	go func() {
+		tip, err := c.tip(c.ctx)
+		if err != nil {
+			c.log.WithError(err).Error("Could not fetch the chain tip")
+			return
+		}
+		c.subscriptionsMu.Lock()
+		c.tipHeight = tip
+		c.subscriptionsMu.Unlock()
+		callback(&types.Header{Height: tip})
+	}()
+}
+
+// pollScriptHash fetches the status of the subscribed script hash and calls the callback if it
+// changed. Returns false if the status could not be fetched.
+func (c *Client) pollScriptHash(subscription *scriptHashSubscription) bool {
+	history, err := c.ScriptHashGetHistory(subscription.ctx, subscription.scriptHashHex)
+	if subscription.ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		c.log.WithError(err).Error("Could not poll the script hash history")
+		return false
+	}
+	status := history.Status()
+	subscription.mu.Lock()
+	changed := !subscription.notified || subscription.status != status
+	subscription.notified = true
+	subscription.status = status
+	subscription.mu.Unlock()
+	if changed {
+		subscription.callback(status)
+	}
+	return true
+}
+
+func (c *Client) pollHeaders() {
+	c.subscriptionsMu.Lock()
+	hasCallbacks := len(c.headersCallbacks) > 0
+	c.subscriptionsMu.Unlock()
+	if !hasCallbacks {
+		return
+	}
+	tip, err := c.tip(c.ctx)
+	if err != nil {
+		c.log.WithError(err).Error("Could not fetch the chain tip")
+		return
+	}
+	c.subscriptionsMu.Lock()
+	changed := tip != c.tipHeight
+	c.tipHeight = tip
+	callbacks := append([]func(*types.Header){}, c.headersCallbacks...)
+	c.subscriptionsMu.Unlock()
+	if changed {
+		for _, callback := range callbacks {
+			callback(&types.Header{Height: tip})
+		}
+	}
+}
+
+func (c *Client) poll() {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.quitChan:
+			return
+		case <-ticker.C:
+			c.pollHeaders()
+			subscriptions := c.activeSubscriptions()
+			for _, subscription := range subscriptions {
+				select {
+				case <-c.quitChan:
+					return
+				default:
+				}
+				c.pollScriptHash(subscription)
+			}
+		}
+	}
+}
+
+// activeSubscriptions returns the subscriptions to poll, dropping the ones whose context is done.
+func (c *Client) activeSubscriptions() []*scriptHashSubscription {
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+	active := []*scriptHashSubscription{}
+	for _, subscription := range c.subscriptions {
+		if subscription.ctx.Err() == nil {
+			active = append(active, subscription)
+		}
+	}
+	c.subscriptions = active
+	return append([]*scriptHashSubscription{}, active...)
+}
+
+// Close implements blockchain.Interface.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.quitChan)
+		c.cancel()
+	})
+}
+
+// CheckServer checks if the server is reachable and responds like an Esplora server.
+func CheckServer(serverInfo *config.ServerInfo, log *logrus.Entry, httpClient *http.Client) error {
+	client := &Client{
+		servers:                           []string{strings.TrimSuffix(serverInfo.Server, "/")},
+		httpClient:                        httpClient,
+		log:                               log.WithField("group", "esplora"),
+		onConnectionErrorChangedCallbacks: []func(error){},
+	}
+	_, err := client.tip(context.Background())
+	return err
+}
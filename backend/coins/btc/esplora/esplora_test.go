@@ -0,0 +1,407 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package esplora
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.Handler, pollInterval time.Duration) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := newClient(
+		[]*config.ServerInfo{{Server: server.URL}},
+		logging.Get().WithGroup("esplora_test"),
+		server.Client(),
+		defaultRequestTimeout,
+		defaultMaxResponseSize,
+		pollInterval,
+	)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	t.Helper()
+	require.NoError(t, json.NewEncoder(w).Encode(value))
+}
+
+func TestScriptHashGetHistory(t *testing.T) {
+	pkScript := []byte{0x00, 0x14, 0x01, 0x02}
+	scriptHash := chainhash.HashB(pkScript)
+	hashPath := "/scripthash/" + hex.EncodeToString(scriptHash)
+
+	txid := func(i int) string {
+		return chainhash.HashH([]byte{byte(i)}).String()
+	}
+	makeTxs := func(from, to int) []map[string]interface{} {
+		txs := []map[string]interface{}{}
+		for i := from; i > to; i-- {
+			txs = append(txs, map[string]interface{}{
+				"txid":   txid(i),
+				"status": map[string]interface{}{"confirmed": true, "block_height": 100 + i},
+			})
+		}
+		return txs
+	}
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case hashPath + "/txs":
+			unconfirmed := []map[string]interface{}{
+				{"txid": txid(50), "status": map[string]interface{}{"confirmed": false}},
+			}
+			writeJSON(t, w, append(unconfirmed, makeTxs(30, 5)...))
+		case hashPath + "/txs/chain/" + txid(6):
+			writeJSON(t, w, makeTxs(5, 0))
+		default:
+			http.NotFound(w, r)
+		}
+	}), time.Hour)
+
+	history, err := client.ScriptHashGetHistory(context.Background(), blockchain.NewScriptHashHex(pkScript))
+	require.NoError(t, err)
+	require.Len(t, history, 31)
+	for i := 0; i < 30; i++ {
+		require.Equal(t, 101+i, history[i].Height)
+		require.Equal(t, txid(i+1), history[i].TXHash.Hash().String())
+	}
+	require.Equal(t, 0, history[30].Height)
+	require.Equal(t, txid(50), history[30].TXHash.Hash().String())
+	require.NoError(t, client.ConnectionError())
+}
+
+func TestTransactionGetAndBroadcast(t *testing.T) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	rawTx := &bytes.Buffer{}
+	require.NoError(t, tx.BtcEncode(rawTx, 0, wire.WitnessEncoding))
+	rawTxHex := hex.EncodeToString(rawTx.Bytes())
+
+	var broadcast string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == fmt.Sprintf("/tx/%s/hex", tx.TxHash()):
+			_, _ = w.Write([]byte(rawTxHex))
+		case r.Method == http.MethodPost && r.URL.Path == "/tx":
+			body, _ := io.ReadAll(r.Body)
+			broadcast = string(body)
+			if broadcast != rawTxHex {
+				http.Error(w, "sendrawtransaction RPC error: bad-txns", http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(tx.TxHash().String()))
+		default:
+			http.NotFound(w, r)
+		}
+	}), time.Hour)
+
+	fetchedTx, err := client.TransactionGet(context.Background(), tx.TxHash())
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash(), fetchedTx.TxHash())
+
+	require.NoError(t, client.TransactionBroadcast(context.Background(), tx))
+	require.Equal(t, rawTxHex, broadcast)
+
+	// A rejected transaction is an error, but the server is still considered connected.
+	otherTx := tx.Copy()
+	otherTx.TxOut[0].Value = 2000
+	require.Error(t, client.TransactionBroadcast(context.Background(), otherTx))
+	require.NoError(t, client.ConnectionError())
+}
+
+func TestHeaders(t *testing.T) {
+	genesis := chaincfg.RegressionNetParams.GenesisBlock.Header
+	headers := []*wire.BlockHeader{&genesis}
+	for i := 1; i < 15; i++ {
+		headers = append(headers, &wire.BlockHeader{
+			Version:    1,
+			PrevBlock:  headers[i-1].BlockHash(),
+			MerkleRoot: chainhash.HashH([]byte{byte(i)}),
+			Timestamp:  genesis.Timestamp.Add(time.Duration(i) * 10 * time.Minute),
+			Bits:       genesis.Bits,
+			Nonce:      uint32(i),
+		})
+	}
+	tip := len(headers) - 1
+	var tipRequests int
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocks/tip/height" {
+			tipRequests++
+			_, _ = w.Write([]byte(fmt.Sprint(tip)))
+			return
+		}
+		var height int
+		if _, err := fmt.Sscanf(r.URL.Path, "/blocks/%d", &height); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		blocks := []map[string]interface{}{}
+		for i := height; i >= 0 && i > height-blocksPerPage; i-- {
+			b := map[string]interface{}{
+				"id":          headers[i].BlockHash().String(),
+				"height":      i,
+				"version":     headers[i].Version,
+				"timestamp":   headers[i].Timestamp.Unix(),
+				"bits":        headers[i].Bits,
+				"nonce":       headers[i].Nonce,
+				"merkle_root": headers[i].MerkleRoot.String(),
+			}
+			if i > 0 {
+				b["previousblockhash"] = headers[i].PrevBlock.String()
+			}
+			blocks = append(blocks, b)
+		}
+		writeJSON(t, w, blocks)
+	}), time.Hour)
+
+	// Two pages, but only one tip request.
+	result, err := client.Headers(context.Background(), 0, 1000)
+	require.NoError(t, err)
+	require.Equal(t, maxHeadersPerCall, result.Max)
+	require.Len(t, result.Headers, len(headers))
+	for i, header := range result.Headers {
+		require.Equal(t, headers[i].BlockHash(), header.BlockHash())
+	}
+	require.Equal(t, 1, tipRequests)
+
+	result, err = client.Headers(context.Background(), 3, 5)
+	require.NoError(t, err)
+	require.Len(t, result.Headers, 5)
+	require.Equal(t, headers[3].BlockHash(), result.Headers[0].BlockHash())
+
+	// Capped at the tip.
+	result, err = client.Headers(context.Background(), 12, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Headers, 3)
+	require.Equal(t, headers[12].BlockHash(), result.Headers[0].BlockHash())
+
+	// Beyond the tip.
+	result, err = client.Headers(context.Background(), tip+1, 10)
+	require.NoError(t, err)
+	require.Empty(t, result.Headers)
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("12345"))
+	}))
+	defer server.Close()
+	newSizeLimitedClient := func(maxResponseSize int) *Client {
+		client := NewClient(
+			[]*config.ServerInfo{{Server: server.URL}},
+			logging.Get().WithGroup("esplora_test"),
+			server.Client(),
+			0,
+			maxResponseSize,
+		)
+		t.Cleanup(client.Close)
+		return client
+	}
+
+	height, err := newSizeLimitedClient(5).tip(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 12345, height)
+
+	_, err = newSizeLimitedClient(4).tip(context.Background())
+	require.Error(t, err)
+}
+
+func TestEstimateFee(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]float64{"2": 20.5, "6": 10, "144": 1.234})
+	}), time.Hour)
+
+	for number, expected := range map[int]btcutil.Amount{
+		1:   20500,
+		2:   20500,
+		5:   20500,
+		6:   10000,
+		24:  10000,
+		500: 1234,
+	} {
+		fee, err := client.EstimateFee(context.Background(), number)
+		require.NoError(t, err)
+		require.Equal(t, expected, fee, number)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer down.Close()
+	var upRequests int
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upRequests++
+		_, _ = w.Write([]byte("123"))
+	}))
+	defer up.Close()
+
+	client := newClient(
+		[]*config.ServerInfo{
+			{Server: down.URL},
+			{Server: "electrum.example.com:50002", TLS: true},
+			{Server: up.URL + "/"},
+		},
+		logging.Get().WithGroup("esplora_test"),
+		http.DefaultClient,
+		defaultRequestTimeout,
+		defaultMaxResponseSize,
+		time.Hour,
+	)
+	defer client.Close()
+	require.Len(t, client.servers, 2)
+
+	height, err := client.tip(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 123, height)
+	// The working server is remembered.
+	_, err = client.tip(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, upRequests)
+	require.Equal(t, 1, client.currentServer)
+
+	up.Close()
+	_, err = client.tip(context.Background())
+	require.Error(t, err)
+	require.Error(t, client.ConnectionError())
+}
+
+func TestScriptHashSubscribe(t *testing.T) {
+	pkScript := []byte{0x51}
+	hashPath := "/scripthash/" + hex.EncodeToString(chainhash.HashB(pkScript)) + "/txs"
+	var mu sync.Mutex
+	txs := []map[string]interface{}{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, hashPath, r.URL.Path)
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(t, w, txs)
+	}), 10*time.Millisecond)
+
+	statuses := make(chan string, 10)
+	tornDown := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.ScriptHashSubscribe(
+		ctx,
+		func() func() { return func() { close(tornDown) } },
+		blockchain.NewScriptHashHex(pkScript),
+		func(status string) { statuses <- status },
+	)
+	require.Equal(t, "", <-statuses)
+	<-tornDown
+
+	mu.Lock()
+	txs = append(txs, map[string]interface{}{
+		"txid":   chainhash.HashH([]byte("tx")).String(),
+		"status": map[string]interface{}{"confirmed": false},
+	})
+	mu.Unlock()
+	status := <-statuses
+	require.NotEqual(t, "", status)
+
+	// No notification without a change.
+	select {
+	case status := <-statuses:
+		require.Fail(t, "unexpected notification", status)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// No notification after the subscription was cancelled.
+	cancel()
+	mu.Lock()
+	txs = append(txs, map[string]interface{}{
+		"txid":   chainhash.HashH([]byte("tx2")).String(),
+		"status": map[string]interface{}{"confirmed": false},
+	})
+	mu.Unlock()
+	select {
+	case status := <-statuses:
+		require.Fail(t, "unexpected notification", status)
+	case <-time.After(100 * time.Millisecond):
+	}
+	subscribed, pending := client.ScriptHashSubscriptions()
+	require.Empty(t, subscribed)
+	require.Empty(t, pending)
+}
+
+func TestScriptHashSubscribeRetriesInitialPoll(t *testing.T) {
+	var mu sync.Mutex
+	failures := 2
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(t, w, []map[string]interface{}{})
+	}), 10*time.Millisecond)
+
+	statuses := make(chan string, 10)
+	tornDown := make(chan struct{})
+	client.ScriptHashSubscribe(
+		context.Background(),
+		func() func() { return func() { close(tornDown) } },
+		blockchain.NewScriptHashHex([]byte{0x51}),
+		func(status string) { statuses <- status },
+	)
+	// The subscription is only torn down after the status was reported.
+	select {
+	case <-tornDown:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "subscription was not torn down")
+	}
+	select {
+	case status := <-statuses:
+		require.Equal(t, "", status)
+	default:
+		require.Fail(t, "status was not reported")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 0, failures)
+}
+
+func TestEsploraScriptHash(t *testing.T) {
+	pkScript := []byte{0x51}
+	require.Equal(t,
+		hex.EncodeToString(chainhash.HashB(pkScript)),
+		esploraScriptHash(blockchain.NewScriptHashHex(pkScript)))
+}
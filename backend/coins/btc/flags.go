@@ -0,0 +1,143 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"sort"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The flag list is a list of addresses and outpoints maintained by the user, each with a label
+// describing the origin of the coins, e.g. an address the refund of a hack was received on. Coins
+// received on a flagged address or outpoint are not spent unless selected explicitly using coin
+// control, and then never together with coins that are not flagged with the same label. The list is
+// stored locally with the notes of the account.
+
+// FlaggedOutput is an output whose address or outpoint is in the flag list of the account.
+type FlaggedOutput struct {
+	OutPoint string `json:"outPoint"`
+	Label    string `json:"label"`
+}
+
+// normalizeFlagKey returns the canonical form of a flagged address or outpoint, so that it can be
+// matched with the outputs of the account.
+func (account *Account) normalizeFlagKey(key string) (string, error) {
+	if outPoint, err := wire.NewOutPointFromString(key); err == nil {
+		return outPoint.String(), nil
+	}
+	address, err := account.coin.DecodeAddress(key)
+	if err != nil {
+		return "", errp.Newf("%s is neither an outpoint nor a valid address", key)
+	}
+	return address.EncodeAddress(), nil
+}
+
+// SetFlags replaces the flag list of the account, a map of address or outpoint to the label of the
+// origin of the coins. Nothing is changed if an entry is invalid.
+func (account *Account) SetFlags(flags map[string]string) error {
+	normalized := make(map[string]string, len(flags))
+	for key, label := range flags {
+		normalizedKey, err := account.normalizeFlagKey(key)
+		if err != nil {
+			return err
+		}
+		normalized[normalizedKey] = label
+	}
+	if err := account.Notes().SetFlags(normalized); err != nil {
+		return err
+	}
+	// Prompt refresh.
+	account.Config().OnEvent(accountsTypes.EventStatusChanged)
+	return nil
+}
+
+// Flags returns the flag list of the account, see SetFlags().
+func (account *Account) Flags() map[string]string {
+	return account.Notes().Flags()
+}
+
+// flagLabel returns the label of the output if its outpoint or the address it was received on is
+// flagged, and the empty string otherwise.
+func (account *Account) flagLabel(outPoint wire.OutPoint, txOut *wire.TxOut) string {
+	notes := account.Notes()
+	if label := notes.Flag(outPoint.String()); label != "" {
+		return label
+	}
+	address := account.getAddress(blockchain.NewScriptHashHex(txOut.PkScript))
+	if address == nil {
+		return ""
+	}
+	return notes.Flag(address.EncodeForHumans())
+}
+
+// FlagLabel returns the label of the output if it is flagged, and the empty string otherwise.
+func (account *Account) FlagLabel(output *SpendableOutput) string {
+	return account.flagLabel(output.OutPoint, output.TxOut)
+}
+
+// ActiveFlaggedInputs returns the inputs of the active tx proposal which are flagged, sorted by
+// outpoint. Returns nil if there is no active tx proposal.
+func (account *Account) ActiveFlaggedInputs() []FlaggedOutput {
+	unlock := account.activeTxProposalLock.RLock()
+	txProposal := account.activeTxProposal
+	unlock()
+	if txProposal == nil {
+		return nil
+	}
+	return account.flaggedInputs(txProposal.TxProposal)
+}
+
+func (account *Account) flaggedInputs(txProposal *maketx.TxProposal) []FlaggedOutput {
+	result := []FlaggedOutput{}
+	for _, txIn := range txProposal.Transaction.TxIn {
+		prevOut, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			continue
+		}
+		if label := account.flagLabel(txIn.PreviousOutPoint, prevOut.TxOut); label != "" {
+			result = append(result, FlaggedOutput{
+				OutPoint: txIn.PreviousOutPoint.String(),
+				Label:    label,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].OutPoint < result[j].OutPoint })
+	return result
+}
+
+// flagChange adds the change of a transaction spending flagged coins to the flag list, so that it
+// is not merged with other coins later.
+func (account *Account) flagChange(txProposal *maketx.TxProposal) error {
+	flagged := account.flaggedInputs(txProposal)
+	if len(flagged) == 0 || txProposal.ChangeAddress == nil {
+		return nil
+	}
+	changeScript := txProposal.ChangeAddress.PubkeyScript()
+	for index, txOut := range txProposal.Transaction.TxOut {
+		if !bytes.Equal(txOut.PkScript, changeScript) {
+			continue
+		}
+		txHash := txProposal.Transaction.TxHash()
+		outPoint := wire.NewOutPoint(&txHash, uint32(index))
+		return account.Notes().AddFlag(outPoint.String(), flagged[0].Label)
+	}
+	return nil
+}
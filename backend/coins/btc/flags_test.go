@@ -0,0 +1,85 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+	require.Empty(t, account.Flags())
+
+	const outPoint = "0d5f28d6a6b0c8d0a8d3e8b6f6b5a7e1b3e8f0a7c9b1d2e3f4a5b6c7d8e9f001:1"
+	receiveAddress := account.GetUnusedReceiveAddresses()[0].Addresses[0].(*addresses.AccountAddress)
+
+	// Addresses are normalized, so they match the addresses of the account.
+	require.NoError(t, account.SetFlags(map[string]string{
+		"TB1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KXPJZSX": "hack refund",
+		outPoint:                         "hack refund",
+		receiveAddress.EncodeForHumans(): "exchange",
+	}))
+	require.Equal(t, map[string]string{
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx": "hack refund",
+		outPoint:                         "hack refund",
+		receiveAddress.EncodeForHumans(): "exchange",
+	}, account.Flags())
+
+	// Invalid entries are rejected and the list is left unchanged.
+	for _, invalid := range []string{
+		"",
+		"not-an-address",
+		// Mainnet address in a testnet account.
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+	} {
+		require.Error(t, account.SetFlags(map[string]string{invalid: "label"}), invalid)
+	}
+	require.Error(t, account.SetFlags(map[string]string{outPoint: ""}))
+	require.Len(t, account.Flags(), 3)
+
+	// An output is flagged by its outpoint or the address it was received on.
+	parsedOutPoint, err := wire.NewOutPointFromString(outPoint)
+	require.NoError(t, err)
+	otherOutPoint := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	otherTxOut := wire.NewTxOut(1000, []byte{0x51})
+	require.Equal(t, "hack refund", account.FlagLabel(&btc.SpendableOutput{
+		SpendableOutput: &transactions.SpendableOutput{TxOut: otherTxOut},
+		OutPoint:        *parsedOutPoint,
+	}))
+	require.Equal(t, "exchange", account.FlagLabel(&btc.SpendableOutput{
+		SpendableOutput: &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(1000, receiveAddress.PubkeyScript()),
+		},
+		OutPoint: otherOutPoint,
+	}))
+	require.Equal(t, "", account.FlagLabel(&btc.SpendableOutput{
+		SpendableOutput: &transactions.SpendableOutput{TxOut: otherTxOut},
+		OutPoint:        otherOutPoint,
+	}))
+
+	require.Nil(t, account.ActiveFlaggedInputs())
+
+	require.NoError(t, account.SetFlags(nil))
+	require.Empty(t, account.Flags())
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Frozen outputs are outputs the user excluded from spending, e.g. dust received in a dusting
+// attack, so that it is never linked with the other coins of the account. Unlike flagged coins, they
+// are not spent even if selected using coin control, unless spending frozen coins is allowed
+// explicitly. The frozen outpoints are stored locally with the notes of the account.
+
+// SetOutputFrozen freezes or unfreezes an output of the account, given as "txid:index".
+func (account *Account) SetOutputFrozen(outPoint string, frozen bool) error {
+	parsedOutPoint, err := wire.NewOutPointFromString(outPoint)
+	if err != nil {
+		return errp.Newf("%s is not a valid outpoint", outPoint)
+	}
+	changed, err := account.Notes().SetOutputFrozen(parsedOutPoint.String(), frozen)
+	if err != nil {
+		return err
+	}
+	if changed {
+		// Prompt refresh of the balance and the outputs.
+		account.Config().OnEvent(accountsTypes.EventStatusChanged)
+	}
+	return nil
+}
+
+// FrozenOutputs returns the frozen outpoints of the account, sorted.
+func (account *Account) FrozenOutputs() []string {
+	return account.Notes().FrozenOutputs()
+}
+
+func (account *Account) isFrozen(outPoint wire.OutPoint) bool {
+	return account.Notes().OutputFrozen(outPoint.String())
+}
+
+// IsOutputFrozen returns true if the output is frozen.
+func (account *Account) IsOutputFrozen(output *SpendableOutput) bool {
+	return account.isFrozen(output.OutPoint)
+}
+
+// frozenBalance returns the sum of the frozen spendable outputs. Hidden dust is not included, see
+// isHiddenDust().
+func (account *Account) frozenBalance() (coin.Amount, error) {
+	if len(account.FrozenOutputs()) == 0 {
+		return coin.NewAmountFromInt64(0), nil
+	}
+	utxos, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return coin.Amount{}, err
+	}
+	var frozen int64
+	for outPoint, txOut := range utxos {
+		if account.isFrozen(outPoint) && !account.isHiddenDust(txOut) {
+			frozen += txOut.Value
+		}
+	}
+	return coin.NewAmountFromInt64(frozen), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrozenOutputs(t *testing.T) {
+	blockchain := &blockchainMock.BlockchainMock{}
+	blockchain.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchain.MockRelayFee = func() (btcutil.Amount, error) { return 1000, nil }
+	account := mockAccountWithBlockchain(t, nil, blockchain)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+	require.Empty(t, account.FrozenOutputs())
+
+	const outPoint = "0d5f28d6a6b0c8d0a8d3e8b6f6b5a7e1b3e8f0a7c9b1d2e3f4a5b6c7d8e9f001:1"
+	parsedOutPoint, err := wire.NewOutPointFromString(outPoint)
+	require.NoError(t, err)
+
+	require.NoError(t, account.SetOutputFrozen(outPoint, true))
+	require.Equal(t, []string{outPoint}, account.FrozenOutputs())
+	require.True(t, account.IsOutputFrozen(&btc.SpendableOutput{
+		SpendableOutput: &transactions.SpendableOutput{TxOut: wire.NewTxOut(1000, []byte{0x51})},
+		OutPoint:        *parsedOutPoint,
+	}))
+
+	for _, invalid := range []string{"", "not-an-outpoint", outPoint[:64] + ":x"} {
+		require.Error(t, account.SetOutputFrozen(invalid, true), invalid)
+	}
+	require.Len(t, account.FrozenOutputs(), 1)
+
+	// The frozen output does not exist, so nothing is frozen.
+	balance, err := account.Balance()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), balance.Frozen().BigInt().Int64())
+
+	// Frozen outputs are only spent if allowed explicitly.
+	args := &accounts.TxProposalArgs{
+		RecipientAddress: addressesTest.GetAddress(signing.ScriptTypeP2WPKH).EncodeForHumans(),
+		Amount:           coin.NewSendAmount("0.001"),
+		FeeTargetCode:    accounts.FeeTargetCodeCustom,
+		CustomFee:        "1",
+		SelectedUTXOs:    map[wire.OutPoint]struct{}{*parsedOutPoint: {}},
+	}
+	_, err = account.NewTransactionProposal(args)
+	require.Equal(t, errors.ErrFrozenOutputSelected, errp.Cause(err))
+	args.SpendFrozen = true
+	_, err = account.NewTransactionProposal(args)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+
+	require.NoError(t, account.SetOutputFrozen(outPoint, false))
+	require.Empty(t, account.FrozenOutputs())
+	args.SpendFrozen = false
+	_, err = account.NewTransactionProposal(args)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
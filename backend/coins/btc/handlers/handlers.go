@@ -17,12 +17,14 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +38,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -44,41 +47,64 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	qrcode "github.com/skip2/go-qrcode"
 )
 
 // Handlers provides a web api to the account.
 type Handlers struct {
 	account accounts.Interface
-	log     *logrus.Entry
+	// viewOnly returns true if the app is in view-only mode, in which nothing can be sent or signed.
+	viewOnly func() bool
+	log      *logrus.Entry
 }
 
 // NewHandlers creates a new Handlers instance.
 func NewHandlers(
-	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route, log *logrus.Entry) *Handlers {
-	handlers := &Handlers{log: log}
+	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route,
+	viewOnly func() bool,
+	log *logrus.Entry) *Handlers {
+	handlers := &Handlers{viewOnly: viewOnly, log: log}
 
 	handleFunc("/init", handlers.postInit).Methods("POST")
 	handleFunc("/status", handlers.getAccountStatus).Methods("GET")
 	handleFunc("/transactions", handlers.ensureAccountInitialized(handlers.getAccountTransactions)).Methods("GET")
+	handleFunc("/transactions/summary", handlers.ensureAccountInitialized(handlers.getTransactionsSummary)).Methods("GET")
 	handleFunc("/transaction", handlers.ensureAccountInitialized(handlers.getAccountTransaction)).Methods("GET")
+	handleFunc("/transaction-details", handlers.ensureAccountInitialized(handlers.getTransactionDetails)).Methods("GET")
 	handleFunc("/export", handlers.ensureAccountInitialized(handlers.postExportTransactions)).Methods("POST")
+	handleFunc("/export-utxos", handlers.ensureAccountInitialized(handlers.postExportUTXOs)).Methods("POST")
 	handleFunc("/info", handlers.ensureAccountInitialized(handlers.getAccountInfo)).Methods("GET")
 	handleFunc("/utxos", handlers.ensureAccountInitialized(handlers.getUTXOs)).Methods("GET")
+	handleFunc("/flags", handlers.ensureAccountInitialized(handlers.getFlags)).Methods("GET")
+	handleFunc("/flags", handlers.ensureAccountInitialized(handlers.postFlags)).Methods("POST")
+	handleFunc("/frozen-outputs", handlers.ensureAccountInitialized(handlers.getFrozenOutputs)).Methods("GET")
+	handleFunc("/frozen-outputs", handlers.ensureAccountInitialized(handlers.postFrozenOutput)).Methods("POST")
+	handleFunc("/diagnostics", handlers.ensureAccountInitialized(handlers.getDiagnostics)).Methods("GET")
 	handleFunc("/balance", handlers.ensureAccountInitialized(handlers.getAccountBalance)).Methods("GET")
-	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.postAccountSendTx)).Methods("POST")
+	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postAccountSendTx))).Methods("POST")
 	handleFunc("/fee-targets", handlers.ensureAccountInitialized(handlers.getAccountFeeTargets)).Methods("GET")
-	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.postAccountTxProposal)).Methods("POST")
+	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postAccountTxProposal))).Methods("POST")
+	handleFunc("/sweep-proposal", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postSweepProposal))).Methods("POST")
+	handleFunc("/sweep", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postSweep))).Methods("POST")
+	handleFunc("/consolidation-proposal", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postConsolidationProposal))).Methods("POST")
 	handleFunc("/receive-addresses", handlers.ensureAccountInitialized(handlers.getReceiveAddresses)).Methods("GET")
+	handleFunc("/receive-address", handlers.ensureAccountInitialized(handlers.getReceiveAddress)).Methods("GET")
+	handleFunc("/receive-address/next", handlers.ensureAccountInitialized(handlers.postNextReceiveAddress)).Methods("POST")
+	handleFunc("/receive-gap-limit/extend", handlers.ensureAccountInitialized(handlers.postExtendReceiveGapLimit)).Methods("POST")
+	handleFunc("/payment-uri", handlers.ensureAccountInitialized(handlers.getPaymentURI)).Methods("GET")
+	handleFunc("/validate-address", handlers.ensureAccountInitialized(handlers.postValidateAddress)).Methods("POST")
 	handleFunc("/verify-address", handlers.ensureAccountInitialized(handlers.postVerifyAddress)).Methods("POST")
 	handleFunc("/verify-extended-public-key", handlers.ensureAccountInitialized(handlers.postVerifyExtendedPublicKey)).Methods("POST")
-	handleFunc("/sign-address", handlers.ensureAccountInitialized(handlers.postSignBTCAddress)).Methods("POST")
+	handleFunc("/sign-address", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postSignBTCAddress))).Methods("POST")
 	handleFunc("/has-secure-output", handlers.ensureAccountInitialized(handlers.getHasSecureOutput)).Methods("GET")
 	handleFunc("/propose-tx-note", handlers.ensureAccountInitialized(handlers.postProposeTxNote)).Methods("POST")
 	handleFunc("/notes/tx", handlers.ensureAccountInitialized(handlers.postSetTxNote)).Methods("POST")
+	handleFunc("/notes/address", handlers.ensureAccountInitialized(handlers.postSetAddressNote)).Methods("POST")
+	handleFunc("/rescan", handlers.ensureAccountInitialized(handlers.postRescan)).Methods("POST")
 	handleFunc("/connect-keystore", handlers.ensureAccountInitialized(handlers.postConnectKeystore)).Methods("POST")
-	handleFunc("/eth-sign-msg", handlers.ensureAccountInitialized(handlers.postEthSignMsg)).Methods("POST")
-	handleFunc("/eth-sign-typed-msg", handlers.ensureAccountInitialized(handlers.postEthSignTypedMsg)).Methods("POST")
-	handleFunc("/eth-sign-wallet-connect-tx", handlers.ensureAccountInitialized(handlers.postEthSignWalletConnectTx)).Methods("POST")
+	handleFunc("/eth-sign-msg", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postEthSignMsg))).Methods("POST")
+	handleFunc("/eth-sign-typed-msg", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postEthSignTypedMsg))).Methods("POST")
+	handleFunc("/eth-sign-wallet-connect-tx", handlers.ensureAccountInitialized(handlers.ensureNotViewOnly(handlers.postEthSignWalletConnectTx))).Methods("POST")
 	return handlers
 }
 
@@ -94,45 +120,62 @@ func (handlers *Handlers) Uninit() {
 }
 
 // FormattedAmount with unit and conversions.
-type FormattedAmount struct {
-	Amount      string            `json:"amount"`
-	Unit        string            `json:"unit"`
-	Conversions map[string]string `json:"conversions"`
+type FormattedAmount = coin.FormattedAmount
+
+// formatAmountAsJSON formats the amount including its fiat conversion to the active fiat currency
+// of the coin. If allConversions is true, the conversions to all available currencies are included.
+func (handlers *Handlers) formatAmountAsJSON(amount coin.Amount, isFee bool, allConversions bool) FormattedAmount {
+	return coin.FormatAmountAsJSON(
+		amount,
+		handlers.account.Coin(),
+		isFee,
+		handlers.account.Config().RateUpdater,
+		util.FormatBtcAsSat(handlers.account.Config().BtcCurrencyUnit),
+		allConversions,
+	)
 }
 
-func (handlers *Handlers) formatAmountAsJSON(amount coin.Amount, isFee bool) FormattedAmount {
+// formatAmountWithSnapshotAsJSON is like formatAmountAsJSON, but the conversions are computed
+// using the given rates snapshot instead of the latest rates.
+func (handlers *Handlers) formatAmountWithSnapshotAsJSON(
+	amount coin.Amount, isFee bool, snapshot *rates.Snapshot) FormattedAmount {
 	accountCoin := handlers.account.Coin()
 	return FormattedAmount{
 		Amount: accountCoin.FormatAmount(amount, isFee),
 		Unit:   accountCoin.GetFormatUnit(isFee),
-		Conversions: coin.Conversions(
+		Conversions: coin.ConversionsFromSnapshot(
 			amount,
 			accountCoin,
 			isFee,
-			handlers.account.Config().RateUpdater,
+			snapshot,
 			util.FormatBtcAsSat(handlers.account.Config().BtcCurrencyUnit),
+			false,
 		),
 	}
 }
 
-func (handlers *Handlers) formatAmountAtTimeAsJSON(amount coin.Amount, timeStamp *time.Time) *FormattedAmount {
+// formatAmountAtTimeAsJSON formats the amount including its fiat conversion at the given time. The
+// second result is true if no historical rate was available and the latest rate was used instead.
+func (handlers *Handlers) formatAmountAtTimeAsJSON(amount coin.Amount, timeStamp *time.Time) (*FormattedAmount, bool) {
 	accountCoin := handlers.account.Coin()
+	conversions, atLatestRate := coin.ConversionsAtTime(
+		amount,
+		handlers.account.Coin(),
+		false,
+		handlers.account.Config().RateUpdater,
+		util.FormatBtcAsSat(handlers.account.Config().BtcCurrencyUnit),
+		false,
+		timeStamp,
+	)
 	return &FormattedAmount{
-		Amount: accountCoin.FormatAmount(amount, false),
-		Unit:   accountCoin.GetFormatUnit(false),
-		Conversions: coin.ConversionsAtTime(
-			amount,
-			handlers.account.Coin(),
-			false,
-			handlers.account.Config().RateUpdater,
-			util.FormatBtcAsSat(handlers.account.Config().BtcCurrencyUnit),
-			timeStamp,
-		),
-	}
+		Amount:      accountCoin.FormatAmount(amount, false),
+		Unit:        accountCoin.GetFormatUnit(false),
+		Conversions: conversions,
+	}, atLatestRate
 }
 
 func (handlers *Handlers) formatBTCAmountAsJSON(amount btcutil.Amount, isFee bool) FormattedAmount {
-	return handlers.formatAmountAsJSON(coin.NewAmountFromInt64(int64(amount)), isFee)
+	return handlers.formatAmountAsJSON(coin.NewAmountFromInt64(int64(amount)), isFee, false)
 }
 
 // Transaction is the info returned per transaction by the /transactions and /transaction endpoint.
@@ -145,16 +188,32 @@ type Transaction struct {
 	Status                   accounts.TxStatus `json:"status"`
 	Amount                   FormattedAmount   `json:"amount"`
 	AmountAtTime             *FormattedAmount  `json:"amountAtTime"`
-	Fee                      FormattedAmount   `json:"fee"`
-	Time                     *string           `json:"time"`
-	Addresses                []string          `json:"addresses"`
-	Note                     string            `json:"note"`
+	// AmountAtTimeIsLatest is true if no historical rate was available for the time of the
+	// transaction and AmountAtTime was converted using the latest rate.
+	AmountAtTimeIsLatest bool            `json:"amountAtTimeIsLatest"`
+	Fee                  FormattedAmount `json:"fee"`
+	Time                 *string         `json:"time"`
+	Addresses            []string        `json:"addresses"`
+	Note                 string          `json:"note"`
+	// AmountAtSend and FeeAtSend are converted with the rates that were shown when the transaction
+	// was sent with the app. Nil for other transactions.
+	AmountAtSend *FormattedAmount `json:"amountAtSend,omitempty"`
+	FeeAtSend    *FormattedAmount `json:"feeAtSend,omitempty"`
 
 	// BTC specific fields.
 	VSize        int64           `json:"vsize"`
 	Size         int64           `json:"size"`
 	Weight       int64           `json:"weight"`
 	FeeRatePerKb FormattedAmount `json:"feeRatePerKb"`
+	// GrossIn and GrossOut are our gross in- and outflows of complex transactions, e.g. coinjoins.
+	GrossIn  *FormattedAmount `json:"grossIn,omitempty"`
+	GrossOut *FormattedAmount `json:"grossOut,omitempty"`
+	// Verified is true if the tx was verified to be included in a block (SPV), nil if not
+	// applicable to the coin.
+	Verified *bool `json:"verified"`
+	// LastBroadcast is the time the unconfirmed outgoing tx was last broadcast, if it is still being
+	// broadcast again.
+	LastBroadcast *string `json:"lastBroadcast,omitempty"`
 
 	// ETH specific fields
 	Gas   uint64  `json:"gas"`
@@ -170,19 +229,40 @@ func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interf
 	}
 }
 
+// ensureNotViewOnly rejects requests which propose, sign or broadcast transactions or sign messages
+// while the app is in view-only mode, or if the account is watch-only.
+func (handlers *Handlers) ensureNotViewOnly(h func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
+	return func(request *http.Request) (interface{}, error) {
+		if handlers.viewOnly() {
+			return map[string]interface{}{
+				"success":   false,
+				"errorCode": string(backend.ErrViewOnly),
+			}, nil
+		}
+		if handlers.account.Config().Config.WatchOnly {
+			return map[string]interface{}{
+				"success":   false,
+				"errorCode": errors.ErrWatchOnly.Error(),
+			}, nil
+		}
+		return h(request)
+	}
+}
+
 // getTxInfoJSON encodes a given transaction in JSON.
-// If `detail` is false, Coin related details, fees and historical fiat amount won't be included.
+// If `detail` is false, Coin related details and fees won't be included.
 func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail bool) Transaction {
 	var feeString FormattedAmount
 	if txInfo.Fee != nil {
-		feeString = handlers.formatAmountAsJSON(*txInfo.Fee, true)
+		feeString = handlers.formatAmountAsJSON(*txInfo.Fee, true, false)
 	}
 	var formattedTime *string
 	var amountAtTime *FormattedAmount
+	var amountAtTimeIsLatest bool
 	if txInfo.Timestamp != nil {
 		t := txInfo.Timestamp.Format(time.RFC3339)
 		formattedTime = &t
-		amountAtTime = handlers.formatAmountAtTimeAsJSON(txInfo.Amount, txInfo.Timestamp)
+		amountAtTime, amountAtTimeIsLatest = handlers.formatAmountAtTimeAsJSON(txInfo.Amount, txInfo.Timestamp)
 	} else if txInfo.CreatedTimestamp != nil {
 		t := txInfo.CreatedTimestamp.Format(time.RFC3339)
 		formattedTime = &t
@@ -201,17 +281,30 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 			accounts.TxTypeReceive:  "receive",
 			accounts.TxTypeSend:     "send",
 			accounts.TxTypeSendSelf: "send_to_self",
+			accounts.TxTypeComplex:  "complex",
 		}[txInfo.Type],
-		Status:    txInfo.Status,
-		Amount:    handlers.formatAmountAsJSON(txInfo.Amount, false),
-		Time:      formattedTime,
-		Addresses: addresses,
-		Note:      handlers.account.TxNote(txInfo.InternalID),
+		Status:               txInfo.Status,
+		Amount:               handlers.formatAmountAsJSON(txInfo.Amount, false, false),
+		AmountAtTime:         amountAtTime,
+		AmountAtTimeIsLatest: amountAtTimeIsLatest,
+		Time:                 formattedTime,
+		Addresses:            addresses,
+		Note:                 handlers.account.TxNote(txInfo.InternalID),
+		Verified:             txInfo.Verified,
 	}
 
 	if detail {
 		txInfoJSON.Fee = feeString
-		txInfoJSON.AmountAtTime = amountAtTime
+		if sentConversions := handlers.account.SentConversions(txInfo.InternalID); sentConversions != nil {
+			amountAtSend := handlers.formatAmountAsJSON(txInfo.Amount, false, false)
+			amountAtSend.Conversions = sentConversions.Amount
+			txInfoJSON.AmountAtSend = &amountAtSend
+			if txInfo.Fee != nil {
+				feeAtSend := feeString
+				feeAtSend.Conversions = sentConversions.Fee
+				txInfoJSON.FeeAtSend = &feeAtSend
+			}
+		}
 		switch handlers.account.Coin().(type) {
 		case *btc.Coin:
 			txInfoJSON.VSize = txInfo.VSize
@@ -221,6 +314,16 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 			if feeRatePerKb != nil {
 				txInfoJSON.FeeRatePerKb = handlers.formatBTCAmountAsJSON(*feeRatePerKb, true)
 			}
+			if txInfo.LastBroadcast != nil {
+				t := txInfo.LastBroadcast.Format(time.RFC3339)
+				txInfoJSON.LastBroadcast = &t
+			}
+			if txInfo.GrossIn != nil && txInfo.GrossOut != nil {
+				grossIn := handlers.formatAmountAsJSON(*txInfo.GrossIn, false, false)
+				grossOut := handlers.formatAmountAsJSON(*txInfo.GrossOut, false, false)
+				txInfoJSON.GrossIn = &grossIn
+				txInfoJSON.GrossOut = &grossOut
+			}
 		case *eth.Coin:
 			txInfoJSON.Gas = txInfo.Gas
 			txInfoJSON.Nonce = txInfo.Nonce
@@ -229,22 +332,156 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 	return txInfoJSON
 }
 
-func (handlers *Handlers) getAccountTransactions(*http.Request) (interface{}, error) {
+// queryInt parses the non-negative integer query parameter `key`. defaultValue is returned if the
+// parameter is missing.
+func queryInt(r *http.Request, key string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 0 {
+		return 0, errp.Newf("invalid %s: %q", key, value)
+	}
+	return result, nil
+}
+
+// queryTime parses the RFC3339 time query parameter `key`. nil is returned if the parameter is
+// missing.
+func queryTime(r *http.Request, key string) (*time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errp.Newf("invalid %s: %q", key, value)
+	}
+	return &result, nil
+}
+
+// queryAmount parses the amount query parameter `key`, given in the unit of the coin of the
+// account. nil is returned if the parameter is missing.
+func (handlers *Handlers) queryAmount(r *http.Request, key string) (*big.Int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := handlers.account.Coin().ParseAmount(value)
+	if err != nil {
+		return nil, errp.Newf("invalid %s: %q", key, value)
+	}
+	return amount.BigInt(), nil
+}
+
+// transactionsFilter parses the filter query parameters of getAccountTransactions():
+//   - `direction`: comma separated transaction types, e.g. "send,sendSelf"
+//   - `from`, `to`: time range in RFC3339 format
+//   - `minAmount`, `maxAmount`: amount range in the unit of the coin, e.g. "0.001" for BTC
+//   - `q`: text contained in the note or in one of the addresses
+func (handlers *Handlers) transactionsFilter(r *http.Request) (*accounts.TransactionsFilter, error) {
+	query := r.URL.Query()
+	filter := &accounts.TransactionsFilter{Query: query.Get("q")}
+	if direction := query.Get("direction"); direction != "" {
+		for _, txType := range strings.Split(direction, ",") {
+			switch accounts.TxType(txType) {
+			case accounts.TxTypeReceive, accounts.TxTypeSend, accounts.TxTypeSendSelf, accounts.TxTypeComplex:
+				filter.Types = append(filter.Types, accounts.TxType(txType))
+			default:
+				return nil, errp.Newf("invalid direction: %q", txType)
+			}
+		}
+	}
+	var err error
+	if filter.From, err = queryTime(r, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = queryTime(r, "to"); err != nil {
+		return nil, err
+	}
+	if filter.MinAmount, err = handlers.queryAmount(r, "minAmount"); err != nil {
+		return nil, err
+	}
+	if filter.MaxAmount, err = handlers.queryAmount(r, "maxAmount"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// getAccountTransactions returns the transactions of the account, newest first. The optional query
+// parameters `offset` and `limit` select a page of the list. All transactions from the offset on are
+// returned if no limit is given. The list can be filtered, see transactionsFilter(). `total` is the
+// number of transactions of the whole list and `matched` the number of transactions matching the
+// filter.
+func (handlers *Handlers) getAccountTransactions(r *http.Request) (interface{}, error) {
 	var result struct {
 		Success      bool          `json:"success"`
 		Transactions []Transaction `json:"list"`
+		Total        int           `json:"total"`
+		Matched      int           `json:"matched"`
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		handlers.log.WithError(err).Error("getAccountTransactions")
+		return result, nil
+	}
+	limit, err := queryInt(r, "limit", -1)
+	if err != nil {
+		handlers.log.WithError(err).Error("getAccountTransactions")
+		return result, nil
+	}
+	filter, err := handlers.transactionsFilter(r)
+	if err != nil {
+		handlers.log.WithError(err).Error("getAccountTransactions")
+		return result, nil
 	}
 	txs, err := handlers.account.Transactions()
 	if err != nil {
 		return result, nil
 	}
-	result.Transactions = []Transaction{}
-	for _, txInfo := range txs {
-		if txInfo.IsErc20 && big.NewInt(0).Cmp(txInfo.Amount.BigInt()) == 0 {
-			// skipping 0 amount erc20 txs to mitigate Address Poisoning attack
-			continue
-		}
-		result.Transactions = append(result.Transactions, handlers.getTxInfoJSON(txInfo, false))
+	txs = txs.Listed()
+	result.Total = len(txs)
+	txs = txs.Filter(filter, handlers.account.TxNote)
+	result.Matched = len(txs)
+	page := txs[min(offset, len(txs)):]
+	if limit >= 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	result.Transactions = make([]Transaction, len(page))
+	for index, txInfo := range page {
+		result.Transactions[index] = handlers.getTxInfoJSON(txInfo, false)
+	}
+	result.Success = true
+	return result, nil
+}
+
+// defaultNewestTransactions is the number of transactions returned by getTransactionsSummary() if
+// not specified.
+const defaultNewestTransactions = 10
+
+// getTransactionsSummary returns the number of transactions of the account and the newest ones,
+// as many as given by the `newest` query parameter (default 10). It is cheaper than
+// getAccountTransactions() for showing an overview of a long history.
+func (handlers *Handlers) getTransactionsSummary(r *http.Request) (interface{}, error) {
+	var result struct {
+		Success bool          `json:"success"`
+		Total   int           `json:"total"`
+		Newest  []Transaction `json:"newest"`
+	}
+	newest, err := queryInt(r, "newest", defaultNewestTransactions)
+	if err != nil {
+		handlers.log.WithError(err).Error("getTransactionsSummary")
+		return result, nil
+	}
+	txs, err := handlers.account.Transactions()
+	if err != nil {
+		return result, nil
+	}
+	txs = txs.Listed()
+	result.Total = len(txs)
+	result.Newest = make([]Transaction, min(newest, len(txs)))
+	for index := range result.Newest {
+		result.Newest[index] = handlers.getTxInfoJSON(txs[index], false)
 	}
 	result.Success = true
 	return result, nil
@@ -266,12 +503,95 @@ func (handlers *Handlers) getAccountTransaction(r *http.Request) (interface{}, e
 	return nil, nil
 }
 
+// TransactionInput is an input of a transaction returned by the /transaction-details endpoint.
+type TransactionInput struct {
+	OutPoint string `json:"outPoint"`
+	// Address is empty if the spent output is unknown.
+	Address string `json:"address"`
+	// Value is nil if the spent output is unknown.
+	Value *FormattedAmount `json:"value"`
+	Ours  bool             `json:"ours"`
+}
+
+// TransactionOutput is an output of a transaction returned by the /transaction-details endpoint.
+type TransactionOutput struct {
+	Index   uint32          `json:"index"`
+	Address string          `json:"address"`
+	Value   FormattedAmount `json:"value"`
+	Ours    bool            `json:"ours"`
+	Change  bool            `json:"change"`
+}
+
+// TransactionDetails is the decoded transaction returned by the /transaction-details endpoint.
+type TransactionDetails struct {
+	TxID    string              `json:"txID"`
+	Inputs  []TransactionInput  `json:"inputs"`
+	Outputs []TransactionOutput `json:"outputs"`
+	Size    int64               `json:"size"`
+	VSize   int64               `json:"vsize"`
+	Weight  int64               `json:"weight"`
+	// Fee and FeeRate are nil unless the values of all inputs are known. FeeRate is in sat/vB.
+	Fee     *FormattedAmount `json:"fee"`
+	FeeRate *string          `json:"feeRate"`
+}
+
+// getTransactionDetails returns the decoded inputs and outputs of a single transaction. They are
+// not part of the /transactions endpoint to keep the list small.
+func (handlers *Handlers) getTransactionDetails(r *http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	details, err := btcAccount.TransactionDetails(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, nil
+	}
+	result := TransactionDetails{
+		TxID:    details.TxHash.String(),
+		Inputs:  make([]TransactionInput, len(details.Inputs)),
+		Outputs: make([]TransactionOutput, len(details.Outputs)),
+		Size:    details.Size,
+		VSize:   details.VSize,
+		Weight:  details.Weight,
+	}
+	for index, input := range details.Inputs {
+		result.Inputs[index] = TransactionInput{
+			OutPoint: input.PreviousOutPoint.String(),
+			Address:  input.Address,
+			Ours:     input.Ours,
+		}
+		if input.Value != nil {
+			value := handlers.formatBTCAmountAsJSON(*input.Value, false)
+			result.Inputs[index].Value = &value
+		}
+	}
+	for index, output := range details.Outputs {
+		result.Outputs[index] = TransactionOutput{
+			Index:   output.Index,
+			Address: output.Address,
+			Value:   handlers.formatBTCAmountAsJSON(output.Value, false),
+			Ours:    output.Ours,
+			Change:  output.Change,
+		}
+	}
+	if details.Fee != nil {
+		fee := handlers.formatBTCAmountAsJSON(*details.Fee, true)
+		feeRate := strconv.FormatFloat(float64(*details.FeeRatePerKb)/1000, 'f', 1, 64)
+		result.Fee = &fee
+		result.FeeRate = &feeRate
+	}
+	return result, nil
+}
+
 func (handlers *Handlers) postExportTransactions(*http.Request) (interface{}, error) {
 	type result struct {
 		Success      bool   `json:"success"`
 		ErrorMessage string `json:"errorMessage"`
 	}
-	name := fmt.Sprintf("%s-%s-export.csv", time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code)
+	name := accounts.ExportFilename(handlers.account.Config().Config, time.Now(), "export.csv")
 	exportsDir, err := config.ExportsDir()
 	if err != nil {
 		handlers.log.WithError(err).Error("error exporting account")
@@ -311,10 +631,74 @@ func (handlers *Handlers) postExportTransactions(*http.Request) (interface{}, er
 	return result{Success: true}, nil
 }
 
+func (handlers *Handlers) postExportUTXOs(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	var jsonBody struct {
+		ProofOfReservesMessage string `json:"proofOfReservesMessage"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	export, err := btcAccount.ExportUTXOs(jsonBody.ProofOfReservesMessage)
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting the UTXOs")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	exportJSON, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+
+	name := accounts.ExportFilename(handlers.account.Config().Config, time.Now(), "utxos.json")
+	exportsDir, err := config.ExportsDir()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting the UTXOs")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := handlers.account.Config().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return nil, nil
+	}
+	handlers.log.Infof("Export UTXOs to %s.", path)
+	if err := os.WriteFile(path, exportJSON, 0600); err != nil {
+		handlers.log.WithError(err).Error("error writing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
 func (handlers *Handlers) getAccountInfo(*http.Request) (interface{}, error) {
 	return handlers.account.Info(), nil
 }
 
+func (handlers *Handlers) getDiagnostics(*http.Request) (interface{}, error) {
+	t, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	health := t.SubscriptionsHealth()
+	var lastCheck *time.Time
+	if !health.LastCheck.IsZero() {
+		lastCheck = &health.LastCheck
+	}
+	return map[string]interface{}{
+		"subscriptions": map[string]interface{}{
+			"lastCheck": lastCheck,
+			"repaired":  health.Repaired,
+		},
+		"txFetchRate": t.TxFetchRate(),
+		"syncMetrics": t.SyncMetricsHistory(),
+	}, nil
+}
+
 func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 	result := []map[string]interface{}{}
 
@@ -345,27 +729,99 @@ func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 				"scriptType":    output.Address.Configuration.ScriptType(),
 				"note":          handlers.account.TxNote(output.OutPoint.Hash.String()),
 				"addressReused": addressReused,
+				"confirmations": output.Confirmations,
+				"flag":          t.FlagLabel(output),
+				"frozen":        t.IsOutputFrozen(output),
 			})
 	}
 
 	return result, nil
 }
 
-func (handlers *Handlers) getAccountBalance(*http.Request) (interface{}, error) {
+func (handlers *Handlers) getFlags(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	return btcAccount.Flags(), nil
+}
+
+func (handlers *Handlers) postFlags(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	var flags map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&flags); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := btcAccount.SetFlags(flags); err != nil {
+		handlers.log.WithError(err).Error("Could not import the flag list")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+func (handlers *Handlers) getFrozenOutputs(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	return btcAccount.FrozenOutputs(), nil
+}
+
+// postFrozenOutput freezes or unfreezes an output of the account.
+func (handlers *Handlers) postFrozenOutput(r *http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	var input struct {
+		OutPoint string `json:"outPoint"`
+		Frozen   bool   `json:"frozen"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := btcAccount.SetOutputFrozen(input.OutPoint, input.Frozen); err != nil {
+		handlers.log.WithError(err).Error("Could not freeze or unfreeze the output")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+func (handlers *Handlers) getAccountBalance(r *http.Request) (interface{}, error) {
 	balance, err := handlers.account.Balance()
 	if err != nil {
 		return nil, err
 	}
+	allConversions := r.URL.Query().Get("allConversions") == "true"
 	return map[string]interface{}{
-		"hasAvailable": balance.Available().BigInt().Sign() > 0,
-		"available":    handlers.formatAmountAsJSON(balance.Available(), false),
-		"hasIncoming":  balance.Incoming().BigInt().Sign() > 0,
-		"incoming":     handlers.formatAmountAsJSON(balance.Incoming(), false),
+		"hasAvailable":     balance.Available().BigInt().Sign() > 0,
+		"available":        handlers.formatAmountAsJSON(balance.Available(), false, allConversions),
+		"hasIncoming":      balance.Incoming().BigInt().Sign() > 0,
+		"incoming":         handlers.formatAmountAsJSON(balance.Incoming(), false, allConversions),
+		"hasFrozen":        balance.Frozen().BigInt().Sign() > 0,
+		"frozen":           handlers.formatAmountAsJSON(balance.Frozen(), false, allConversions),
+		"hasPendingChange": balance.PendingChange().BigInt().Sign() > 0,
+		"pendingChange":    handlers.formatAmountAsJSON(balance.PendingChange(), false, allConversions),
+		"hasUnverified":    balance.Unverified().BigInt().Sign() > 0,
+		"unverified":       handlers.formatAmountAsJSON(balance.Unverified(), false, allConversions),
 	}, nil
 }
 
 type sendTxInput struct {
 	accounts.TxProposalArgs
+	// Debug requests the selection trace of the proposal in the response. BTC/LTC only.
+	Debug bool
 }
 
 func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
@@ -379,6 +835,22 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 		SelectedUTXOS []string `json:"selectedUTXOS"`
 		Note          string   `json:"note"`
 		Counter       int      `json:"counter"`
+		// Hex-encoded data to be embedded in an OP_RETURN output, BTC/LTC only.
+		OpReturnData string `json:"opReturnData"`
+		// Decimal seed of the order of the inputs and outputs, BTC/LTC only. A string, as JSON
+		// numbers can't represent all 64 bit integers.
+		SelectionSeed string `json:"selectionSeed"`
+		Debug         bool   `json:"debug"`
+		// Replaces address, amount and sendAll to pay multiple recipients, BTC/LTC only.
+		Recipients []struct {
+			Address string `json:"address"`
+			Amount  string `json:"amount"`
+			SendAll string `json:"sendAll"`
+		} `json:"recipients"`
+		// Allows spending frozen outputs selected in selectedUTXOS, BTC/LTC only.
+		SpendFrozen bool `json:"spendFrozen"`
+		// Raises the fee to pay for unconfirmed spent transactions, BTC/LTC only.
+		CoverUnconfirmedParents bool `json:"coverUnconfirmedParents"`
 	}{}
 	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
 		return errp.WithStack(err)
@@ -397,6 +869,16 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 	} else {
 		input.Amount = coin.NewSendAmount(jsonBody.Amount)
 	}
+	for _, recipient := range jsonBody.Recipients {
+		amount := coin.NewSendAmount(recipient.Amount)
+		if recipient.SendAll == "yes" {
+			amount = coin.NewSendAmountAll()
+		}
+		input.Recipients = append(input.Recipients, accounts.Recipient{
+			Address: recipient.Address,
+			Amount:  amount,
+		})
+	}
 	input.SelectedUTXOs = map[wire.OutPoint]struct{}{}
 	for _, outPointString := range jsonBody.SelectedUTXOS {
 		outPoint, err := util.ParseOutPoint([]byte(outPointString))
@@ -405,11 +887,29 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 		}
 		input.SelectedUTXOs[*outPoint] = struct{}{}
 	}
+	input.SpendFrozen = jsonBody.SpendFrozen
+	input.CoverUnconfirmedParents = jsonBody.CoverUnconfirmedParents
 	input.Note = jsonBody.Note
+	if jsonBody.OpReturnData != "" {
+		input.OpReturnData, err = hex.DecodeString(jsonBody.OpReturnData)
+		if err != nil {
+			return errp.WithMessage(err, "Invalid OP_RETURN data")
+		}
+	}
+	if jsonBody.SelectionSeed != "" {
+		seed, err := strconv.ParseInt(jsonBody.SelectionSeed, 10, 64)
+		if err != nil {
+			return errp.WithMessage(err, "Invalid selection seed")
+		}
+		input.SelectionSeed = &seed
+	}
+	input.Debug = jsonBody.Debug
 	return nil
 }
 
 func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error) {
+	// The pin is cleared by a successful SendTx(), so it is fetched before.
+	ratesSnapshot := handlers.account.PinnedRatesSnapshot()
 	err := handlers.account.SendTx()
 	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
 		return map[string]interface{}{"success": false, "aborted": true}, nil
@@ -420,12 +920,33 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 		if strings.Contains(err.Error(), etherscan.ERC20GasErr) {
 			result["errorCode"] = errors.ERC20InsufficientGasFunds.Error()
 		}
+		if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			result["errorCode"] = validationErr.Error()
+		}
 		return result, nil
 	}
-	return map[string]interface{}{"success": true}, nil
+	return map[string]interface{}{
+		"success":         true,
+		"ratesSnapshotID": ratesSnapshotID(ratesSnapshot),
+	}, nil
+}
+
+// ratesSnapshotID returns the ID of the given snapshot, or nil if there is none.
+func ratesSnapshotID(snapshot *rates.Snapshot) interface{} {
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.ID
 }
 
 func txProposalError(err error) (interface{}, error) {
+	if recipientErr, ok := errp.Cause(err).(*errors.RecipientError); ok {
+		return map[string]interface{}{
+			"success":        false,
+			"errorCode":      recipientErr.Err.Error(),
+			"recipientIndex": recipientErr.Index,
+		}, nil
+	}
 	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
 		return map[string]interface{}{
 			"success":   false,
@@ -435,6 +956,30 @@ func txProposalError(err error) (interface{}, error) {
 	return nil, errp.WithMessage(err, "Failed to create transaction proposal")
 }
 
+// postValidateAddress validates a recipient address as the user types it, returning the address
+// type if it is valid, or the reason why it is not.
+func (handlers *Handlers) postValidateAddress(r *http.Request) (interface{}, error) {
+	var address string
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	addressType, err := handlers.account.Coin().ValidateAddress(address)
+	if err != nil {
+		validationErr, ok := errp.Cause(err).(errors.TxValidationError)
+		if !ok {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"success":   false,
+			"errorCode": validationErr.Error(),
+		}, nil
+	}
+	return map[string]interface{}{
+		"success":     true,
+		"addressType": addressType,
+	}, nil
+}
+
 func (handlers *Handlers) postAccountTxProposal(r *http.Request) (interface{}, error) {
 	var input sendTxInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -444,11 +989,144 @@ func (handlers *Handlers) postAccountTxProposal(r *http.Request) (interface{}, e
 	if err != nil {
 		return txProposalError(err)
 	}
+	// All fiat values shown until the proposal is sent use the rates pinned with the proposal.
+	ratesSnapshot := handlers.account.PinnedRatesSnapshot()
+	result := map[string]interface{}{
+		"success":         true,
+		"amount":          handlers.formatAmountWithSnapshotAsJSON(outputAmount, false, ratesSnapshot),
+		"fee":             handlers.formatAmountWithSnapshotAsJSON(fee, true, ratesSnapshot),
+		"total":           handlers.formatAmountWithSnapshotAsJSON(total, false, ratesSnapshot),
+		"ratesSnapshotID": ratesSnapshotID(ratesSnapshot),
+	}
+	if btcAccount, ok := handlers.account.(*btc.Account); ok {
+		// Lets the user confirm spending coins of an origin they flagged.
+		result["flaggedInputs"] = btcAccount.ActiveFlaggedInputs()
+		// Lets the user know that the tx depends on unconfirmed txs to confirm.
+		result["unconfirmedInputs"] = btcAccount.ActiveUnconfirmedInputs()
+		if input.Debug {
+			result["debug"] = map[string]interface{}{
+				"selection": btcAccount.ActiveSelectionTrace(),
+			}
+		}
+	}
+	return result, nil
+}
+
+type sweepInput struct {
+	btc.SweepArgs
+}
+
+func (input *sweepInput) UnmarshalJSON(jsonBytes []byte) error {
+	jsonBody := struct {
+		WIF       string `json:"wif"`
+		FeeTarget string `json:"feeTarget"`
+		// Provided in Sat/vByte.
+		CustomFee string `json:"customFee"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
+		return errp.WithStack(err)
+	}
+	input.WIF = jsonBody.WIF
+	var err error
+	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to retrieve fee target code")
+	}
+	if input.FeeTargetCode == accounts.FeeTargetCodeCustom {
+		input.CustomFee = jsonBody.CustomFee
+	}
+	return nil
+}
+
+// sweep handles /sweep-proposal and /sweep. If broadcast is false, the signed sweep transaction is
+// only returned for display, otherwise it is also broadcast.
+func (handlers *Handlers) sweep(r *http.Request, broadcast bool) (interface{}, error) {
+	var input sweepInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	sweepFunc := btcAccount.SweepProposal
+	if broadcast {
+		sweepFunc = btcAccount.Sweep
+	}
+	txProposal, err := sweepFunc(&input.SweepArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
 	return map[string]interface{}{
 		"success": true,
-		"amount":  handlers.formatAmountAsJSON(outputAmount, false),
-		"fee":     handlers.formatAmountAsJSON(fee, true),
-		"total":   handlers.formatAmountAsJSON(total, false),
+		"txID":    txProposal.Transaction.TxHash().String(),
+		"amount":  handlers.formatBTCAmountAsJSON(txProposal.Amount, false),
+		"fee":     handlers.formatBTCAmountAsJSON(txProposal.Fee, true),
+		"total":   handlers.formatBTCAmountAsJSON(txProposal.Total(), false),
+	}, nil
+}
+
+func (handlers *Handlers) postSweepProposal(r *http.Request) (interface{}, error) {
+	return handlers.sweep(r, false)
+}
+
+func (handlers *Handlers) postSweep(r *http.Request) (interface{}, error) {
+	return handlers.sweep(r, true)
+}
+
+type consolidationInput struct {
+	btc.ConsolidationArgs
+}
+
+func (input *consolidationInput) UnmarshalJSON(jsonBytes []byte) error {
+	jsonBody := struct {
+		FeeTarget string `json:"feeTarget"`
+		// Provided in Sat/vByte.
+		CustomFee string `json:"customFee"`
+		// Provided in Sat/vByte. Optional.
+		FutureFee string `json:"futureFee"`
+		MaxInputs int    `json:"maxInputs"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
+		return errp.WithStack(err)
+	}
+	var err error
+	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to retrieve fee target code")
+	}
+	if input.FeeTargetCode == accounts.FeeTargetCodeCustom {
+		input.CustomFee = jsonBody.CustomFee
+	}
+	input.FutureFee = jsonBody.FutureFee
+	input.MaxInputs = jsonBody.MaxInputs
+	return nil
+}
+
+// postConsolidationProposal proposes a transaction consolidating small outputs of the account. It
+// is signed and sent with /sendtx like any other tx proposal.
+func (handlers *Handlers) postConsolidationProposal(r *http.Request) (interface{}, error) {
+	var input consolidationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	consolidation, err := btcAccount.ConsolidationProposal(&input.ConsolidationArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	ratesSnapshot := handlers.account.PinnedRatesSnapshot()
+	return map[string]interface{}{
+		"success":         true,
+		"inputs":          len(consolidation.Transaction.TxIn),
+		"amount":          handlers.formatAmountWithSnapshotAsJSON(coin.NewAmountFromInt64(int64(consolidation.Amount)), false, ratesSnapshot),
+		"fee":             handlers.formatAmountWithSnapshotAsJSON(coin.NewAmountFromInt64(int64(consolidation.Fee)), true, ratesSnapshot),
+		"saving":          handlers.formatAmountWithSnapshotAsJSON(coin.NewAmountFromInt64(int64(consolidation.Saving)), true, ratesSnapshot),
+		"total":           handlers.formatAmountWithSnapshotAsJSON(coin.NewAmountFromInt64(int64(consolidation.Total())), false, ratesSnapshot),
+		"ratesSnapshotID": ratesSnapshotID(ratesSnapshot),
 	}, nil
 }
 
@@ -489,11 +1167,19 @@ type statusResponse struct {
 	// FatalError indicates that there was a fatal error in handling the account. When this happens,
 	// an error is shown to the user and the account is made unusable.
 	FatalError bool `json:"fatalError"`
+	// HasTransactions indicates that a transaction was ever seen in the account.
+	HasTransactions bool `json:"hasTransactions"`
+	// KeystoreHasTransactions indicates that a transaction was ever seen in any account of the
+	// keystore of the account.
+	KeystoreHasTransactions bool `json:"keystoreHasTransactions"`
+	// ViewOnly indicates that the app is in view-only mode, in which nothing can be sent from the
+	// account.
+	ViewOnly bool `json:"viewOnly"`
 }
 
 func (handlers *Handlers) getAccountStatus(*http.Request) (interface{}, error) {
 	if handlers.account == nil {
-		return statusResponse{Disabled: true}, nil
+		return statusResponse{Disabled: true, ViewOnly: handlers.viewOnly()}, nil
 	}
 	offlineErr := handlers.account.Offline()
 	var offlineError *string
@@ -501,40 +1187,266 @@ func (handlers *Handlers) getAccountStatus(*http.Request) (interface{}, error) {
 		s := offlineErr.Error()
 		offlineError = &s
 	}
+	var hasTransactions, keystoreHasTransactions bool
+	if notifier := handlers.account.Notifier(); notifier != nil {
+		var err error
+		hasTransactions, keystoreHasTransactions, err = notifier.HasTransactions()
+		if err != nil {
+			handlers.log.WithError(err).Error("Could not check if the account has transactions")
+		}
+	}
 	return statusResponse{
-		Synced:       handlers.account.Synced(),
-		OfflineError: offlineError,
-		FatalError:   handlers.account.FatalError(),
+		Synced:                  handlers.account.Synced(),
+		OfflineError:            offlineError,
+		FatalError:              handlers.account.FatalError(),
+		HasTransactions:         hasTransactions,
+		KeystoreHasTransactions: keystoreHasTransactions,
+		ViewOnly:                handlers.viewOnly(),
 	}, nil
 }
 
-func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error) {
+// receiveAddressReusedWarning is the warning returned when an address which already has a
+// transaction history is requested for receiving.
+const receiveAddressReusedWarning = "addressReused"
 
-	type jsonAddress struct {
-		Address   string `json:"address"`
-		AddressID string `json:"addressID"`
+// receiveGapLimitLowWarning is the warning returned when fewer than
+// receiveGapLimitLowThreshold receive addresses can be handed out before the gap limit is reached.
+const (
+	receiveGapLimitLowWarning   = "gapLimitLow"
+	receiveGapLimitLowThreshold = 5
+)
+
+// receiveHeadroomWarning returns the warning to show for the given receive address headroom, if
+// any.
+func receiveHeadroomWarning(headroom int) string {
+	if headroom < receiveGapLimitLowThreshold {
+		return receiveGapLimitLowWarning
 	}
+	return ""
+}
+
+type jsonReceiveAddress struct {
+	Address   string `json:"address"`
+	AddressID string `json:"addressID"`
+	// Reused is true if the address already has a transaction history.
+	Reused bool `json:"reused,omitempty"`
+	// Note is the note the user gave the address. Only set for BTC based accounts.
+	Note string `json:"note,omitempty"`
+}
+
+// addressNote returns the note of the address, or the empty string if there is none or the account
+// does not support address notes.
+func (handlers *Handlers) addressNote(addressID string) string {
+	if btcAccount, ok := handlers.account.(*btc.Account); ok {
+		return btcAccount.AddressNote(addressID)
+	}
+	return ""
+}
+
+func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error) {
 	type jsonAddressList struct {
-		ScriptType *signing.ScriptType `json:"scriptType"`
-		Addresses  []jsonAddress       `json:"addresses"`
+		ScriptType *signing.ScriptType  `json:"scriptType"`
+		Addresses  []jsonReceiveAddress `json:"addresses"`
+		// UsedAddresses lists the addresses that were used before. Only set if the account is
+		// configured to show used addresses.
+		UsedAddresses []jsonReceiveAddress `json:"usedAddresses,omitempty"`
+		// Headroom is the number of addresses that can still be handed out before the gap limit
+		// is reached. Only set for BTC based accounts.
+		Headroom *int   `json:"headroom,omitempty"`
+		Warning  string `json:"warning,omitempty"`
+	}
+	// Only BTC based accounts have more than one receive address.
+	var usedAddressLists []accounts.AddressList
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if ok && handlers.account.Config().Config.ShowUsedAddresses {
+		var err error
+		usedAddressLists, err = btcAccount.GetUsedReceiveAddresses()
+		if err != nil {
+			return nil, err
+		}
 	}
 	addressList := []jsonAddressList{}
-	for _, addresses := range handlers.account.GetUnusedReceiveAddresses() {
-		addrs := []jsonAddress{}
+	for idx, addresses := range handlers.account.GetUnusedReceiveAddresses() {
+		addrs := []jsonReceiveAddress{}
 		for _, address := range addresses.Addresses {
-			addrs = append(addrs, jsonAddress{
+			addrs = append(addrs, jsonReceiveAddress{
 				Address:   address.EncodeForHumans(),
 				AddressID: address.ID(),
+				Note:      handlers.addressNote(address.ID()),
 			})
 		}
-		addressList = append(addressList, jsonAddressList{
-			ScriptType: addresses.ScriptType,
-			Addresses:  addrs,
-		})
+		var usedAddrs []jsonReceiveAddress
+		// Both lists contain the same script types in the same order.
+		if idx < len(usedAddressLists) {
+			for _, address := range usedAddressLists[idx].Addresses {
+				usedAddrs = append(usedAddrs, jsonReceiveAddress{
+					Address:   address.EncodeForHumans(),
+					AddressID: address.ID(),
+					Reused:    true,
+					Note:      handlers.addressNote(address.ID()),
+				})
+			}
+		}
+		list := jsonAddressList{
+			ScriptType:    addresses.ScriptType,
+			Addresses:     addrs,
+			UsedAddresses: usedAddrs,
+		}
+		if ok {
+			headroom, err := btcAccount.ReceiveAddressHeadroom(addresses.ScriptType)
+			if err != nil {
+				return nil, err
+			}
+			list.Headroom = &headroom
+			list.Warning = receiveHeadroomWarning(headroom)
+		}
+		addressList = append(addressList, list)
 	}
 	return addressList, nil
 }
 
+// getReceiveAddress returns the receive address with the ID given in the `addressID` query
+// parameter. If the address was used before, a warning is included, as reusing addresses hurts
+// privacy.
+func (handlers *Handlers) getReceiveAddress(r *http.Request) (interface{}, error) {
+	type response struct {
+		jsonReceiveAddress
+		Warning string `json:"warning,omitempty"`
+	}
+	addressID := r.URL.Query().Get("addressID")
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		// Account based coins have only one receive address, which is reused by design.
+		for _, addresses := range handlers.account.GetUnusedReceiveAddresses() {
+			for _, address := range addresses.Addresses {
+				if address.ID() == addressID {
+					return response{jsonReceiveAddress: jsonReceiveAddress{
+						Address:   address.EncodeForHumans(),
+						AddressID: address.ID(),
+					}}, nil
+				}
+			}
+		}
+		return nil, errp.New("unknown address not found")
+	}
+	address, used, err := btcAccount.LookupReceiveAddress(addressID)
+	if err != nil {
+		return nil, err
+	}
+	result := response{jsonReceiveAddress: jsonReceiveAddress{
+		Address:   address.EncodeForHumans(),
+		AddressID: address.ID(),
+		Reused:    used,
+		Note:      btcAccount.AddressNote(address.ID()),
+	}}
+	if used {
+		result.Warning = receiveAddressReusedWarning
+	}
+	return result, nil
+}
+
+// postNextReceiveAddress hands out the next receive address of the script type given in the body
+// (the preferred one if null), along with the number of addresses that can still be handed out
+// before the gap limit is reached. If none can, the request is refused with the
+// `gapLimitExhausted` error code and the gap limit that postExtendReceiveGapLimit() would set is
+// offered, unless the maximum has been reached.
+func (handlers *Handlers) postNextReceiveAddress(r *http.Request) (interface{}, error) {
+	type response struct {
+		Success bool `json:"success"`
+		*jsonReceiveAddress
+		Headroom  int    `json:"headroom"`
+		Warning   string `json:"warning,omitempty"`
+		ErrorCode string `json:"errorCode,omitempty"`
+		// GapLimitExtension is the receive gap limit offered when the gap limit is exhausted.
+		GapLimitExtension *uint16 `json:"gapLimitExtension,omitempty"`
+	}
+	var input struct {
+		ScriptType *signing.ScriptType `json:"scriptType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("receive address handouts are only supported for BTC based accounts")
+	}
+	handout, err := btcAccount.NextReceiveAddress(input.ScriptType)
+	if errp.Cause(err) == errors.ErrGapLimitExhausted {
+		result := response{Success: false, ErrorCode: errors.ErrGapLimitExhausted.Error()}
+		if gapLimit, ok := btcAccount.ReceiveGapLimitExtension(); ok {
+			result.GapLimitExtension = &gapLimit
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response{
+		Success: true,
+		jsonReceiveAddress: &jsonReceiveAddress{
+			Address:   handout.Address.EncodeForHumans(),
+			AddressID: handout.Address.ID(),
+			Note:      btcAccount.AddressNote(handout.Address.ID()),
+		},
+		Headroom: handout.Headroom,
+		Warning:  receiveHeadroomWarning(handout.Headroom),
+	}, nil
+}
+
+// postExtendReceiveGapLimit increases the receive gap limit so that more receive addresses can be
+// handed out, and returns the new gap limit.
+func (handlers *Handlers) postExtendReceiveGapLimit(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("gap limits are only supported for BTC based accounts")
+	}
+	gapLimit, err := btcAccount.ExtendReceiveGapLimit()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"gapLimit": gapLimit,
+	}, nil
+}
+
+// getPaymentURI returns the BIP21 payment URI of the receive address with the ID given in the
+// `addressID` query parameter, including the optional `amount` (in the unit selected for display)
+// and `label` query parameters, and its QR code as a PNG data URI.
+func (handlers *Handlers) getPaymentURI(r *http.Request) (interface{}, error) {
+	type response struct {
+		Success   bool   `json:"success"`
+		URI       string `json:"uri,omitempty"`
+		QRCode    string `json:"qrCode,omitempty"`
+		ErrorCode string `json:"errorCode,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("payment URIs are only supported for BTC based accounts")
+	}
+	query := r.URL.Query()
+	uri, err := btcAccount.PaymentURI(query.Get("addressID"), query.Get("amount"), query.Get("label"))
+	if err != nil {
+		if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return response{Success: false, ErrorCode: validationErr.Error()}, nil
+		}
+		return nil, err
+	}
+	qr, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	png, err := qr.PNG(256)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return response{
+		Success: true,
+		URI:     uri,
+		QRCode:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
 func (handlers *Handlers) postVerifyAddress(r *http.Request) (interface{}, error) {
 	var addressID string
 	if err := json.NewDecoder(r.Body).Decode(&addressID); err != nil {
@@ -610,6 +1522,48 @@ func (handlers *Handlers) postSetTxNote(r *http.Request) (interface{}, error) {
 	return nil, handlers.account.SetTxNote(args.InternalTxID, args.Note)
 }
 
+// postSetAddressNote sets the note of the receive address with the given ID. Only supported by BTC
+// based accounts.
+func (handlers *Handlers) postSetAddressNote(r *http.Request) (interface{}, error) {
+	var args struct {
+		AddressID string `json:"addressID"`
+		Note      string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("address notes are only supported for BTC based accounts")
+	}
+	return nil, btcAccount.SetAddressNote(args.AddressID, args.Note)
+}
+
+func (handlers *Handlers) postRescan(r *http.Request) (interface{}, error) {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var args struct {
+		FromHeight int `json:"fromHeight"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return response{
+			Success:      false,
+			ErrorMessage: "An account must be BTC based to support rescanning.",
+		}, nil
+	}
+	if err := btcAccount.Rescan(args.FromHeight); err != nil {
+		handlers.log.WithError(err).Error("Rescan failed")
+		return response{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return response{Success: true}, nil
+}
+
 func (handlers *Handlers) postConnectKeystore(r *http.Request) (interface{}, error) {
 	type response struct {
 		Success bool `json:"success"`
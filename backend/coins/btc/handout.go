@@ -0,0 +1,188 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// receiveGapLimitExtension is the number of receive addresses by which ExtendReceiveGapLimit()
+// increases the receive gap limit.
+const receiveGapLimitExtension = 20
+
+// ReceiveAddressHandout is a receive address handed out by NextReceiveAddress().
+type ReceiveAddressHandout struct {
+	Address *addresses.AccountAddress
+	// Headroom is the number of receive addresses which can still be handed out after this one
+	// before the gap limit is reached.
+	Headroom int
+}
+
+// receiveAddressChain returns the receive address chain of the given script type, or of the
+// preferred script type if scriptType is nil.
+func (account *Account) receiveAddressChain(scriptType *signing.ScriptType) (*addresses.AddressChain, error) {
+	var chain *addresses.AddressChain
+	for _, subacc := range account.subaccounts {
+		subaccScriptType := subacc.signingConfiguration.ScriptType()
+		if !account.canReceiveOn(subaccScriptType) {
+			continue
+		}
+		if scriptType != nil {
+			if subaccScriptType == *scriptType {
+				return subacc.receiveAddresses, nil
+			}
+			continue
+		}
+		if chain == nil || subaccScriptType == account.Config().Config.PreferredScriptType {
+			chain = subacc.receiveAddresses
+		}
+	}
+	if chain == nil {
+		return nil, errp.New("no receive addresses for this script type")
+	}
+	return chain, nil
+}
+
+// nextHandoutIndex returns the index of the next address to be handed out among the given unused
+// receive addresses, which is the one following the last handed out address. If it is equal to
+// len(unused), all addresses up to the gap limit have been handed out.
+func nextHandoutIndex(dbTx transactions.DBTxInterface, unused []*addresses.AccountAddress) (int, error) {
+	for index := len(unused) - 1; index >= 0; index-- {
+		handedOut, err := dbTx.IsHandedOutAddress(unused[index].PubkeyScriptHashHex())
+		if err != nil {
+			return 0, err
+		}
+		if handedOut {
+			return index + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// ReceiveAddressHeadroom returns the number of receive addresses of the given script type (the
+// preferred one if nil) that can still be handed out by NextReceiveAddress() before the gap limit
+// is reached.
+func (account *Account) ReceiveAddressHeadroom(scriptType *signing.ScriptType) (int, error) {
+	if !account.isInitialized() {
+		return 0, errp.New("account must be initialized")
+	}
+	account.Synchronizer.WaitSynchronized()
+	chain, err := account.receiveAddressChain(scriptType)
+	if err != nil {
+		return 0, err
+	}
+	unused, err := chain.GetUnused()
+	if err != nil {
+		return 0, err
+	}
+	index, err := transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (int, error) {
+		return nextHandoutIndex(dbTx, unused)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(unused) - index, nil
+}
+
+// NextReceiveAddress hands out the next unused receive address of the given script type (the
+// preferred one if nil) and remembers it, so it is not handed out again.
+//
+// Funds sent to addresses beyond the gap limit are not found when scanning the chain. If all
+// addresses up to the gap limit have been handed out, errors.ErrGapLimitExhausted is returned until
+// one of them receives a payment or the gap limit is extended with ExtendReceiveGapLimit().
+func (account *Account) NextReceiveAddress(scriptType *signing.ScriptType) (*ReceiveAddressHandout, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account must be initialized")
+	}
+	account.Synchronizer.WaitSynchronized()
+	defer account.receiveAddressHandoutLock.Lock()()
+	chain, err := account.receiveAddressChain(scriptType)
+	if err != nil {
+		return nil, err
+	}
+	unused, err := chain.GetUnused()
+	if err != nil {
+		return nil, err
+	}
+	var handout *ReceiveAddressHandout
+	err = transactions.DBUpdate(account.db, func(dbTx transactions.DBTxInterface) error {
+		index, err := nextHandoutIndex(dbTx, unused)
+		if err != nil {
+			return err
+		}
+		if index == len(unused) {
+			return errp.WithStack(errors.ErrGapLimitExhausted)
+		}
+		address := unused[index]
+		if err := dbTx.PutHandedOutAddress(address.PubkeyScriptHashHex()); err != nil {
+			return err
+		}
+		handout = &ReceiveAddressHandout{Address: address, Headroom: len(unused) - index - 1}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handout, nil
+}
+
+// ReceiveGapLimitExtension returns the receive gap limit ExtendReceiveGapLimit() would set, and
+// false if the gap limit cannot be increased any further.
+func (account *Account) ReceiveGapLimitExtension() (uint16, bool) {
+	current := 0
+	for _, subacc := range account.subaccounts {
+		if gapLimit := subacc.receiveAddresses.GapLimit(); gapLimit > current {
+			current = gapLimit
+		}
+	}
+	extended := current + receiveGapLimitExtension
+	if extended > maxGapLimit {
+		extended = maxGapLimit
+	}
+	return uint16(extended), extended > current
+}
+
+// ExtendReceiveGapLimit increases the receive gap limit of all subaccounts by
+// receiveGapLimitExtension addresses, so that more receive addresses can be handed out. The new
+// limit is stored in the account config, see config.Account.ReceiveGapLimit, so it is kept if the
+// account database is rebuilt. Returns the new receive gap limit.
+func (account *Account) ExtendReceiveGapLimit() (uint16, error) {
+	if !account.isInitialized() {
+		return 0, errp.New("account must be initialized")
+	}
+	defer account.receiveAddressHandoutLock.Lock()()
+	gapLimit, ok := account.ReceiveGapLimitExtension()
+	if !ok {
+		return 0, errp.Newf("The receive gap limit cannot be higher than %d", maxGapLimit)
+	}
+	err := account.Config().ModifyConfig(func(accountConfig *config.Account) error {
+		accountConfig.ReceiveGapLimit = gapLimit
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	account.log.Infof("receive gap limit extended to %d", gapLimit)
+	for _, subacc := range account.subaccounts {
+		subacc.receiveAddresses.SetGapLimit(int(gapLimit))
+	}
+	account.ensureAddresses()
+	return gapLimit, nil
+}
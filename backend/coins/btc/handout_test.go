@@ -0,0 +1,78 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextReceiveAddress(t *testing.T) {
+	account := mockAccount(t, nil)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	headroom, err := account.ReceiveAddressHeadroom(nil)
+	require.NoError(t, err)
+	require.Equal(t, 20, headroom)
+
+	unused := account.GetUnusedReceiveAddresses()[0].Addresses
+	for i := 0; i < 20; i++ {
+		handout, err := account.NextReceiveAddress(nil)
+		require.NoError(t, err)
+		require.Equal(t, unused[i].ID(), handout.Address.ID())
+		require.Equal(t, 19-i, handout.Headroom)
+	}
+	headroom, err = account.ReceiveAddressHeadroom(nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, headroom)
+
+	// No payment was received on any of the addresses.
+	_, err = account.NextReceiveAddress(nil)
+	require.Equal(t, errors.ErrGapLimitExhausted, errp.Cause(err))
+
+	gapLimit, ok := account.ReceiveGapLimitExtension()
+	require.True(t, ok)
+	require.Equal(t, uint16(40), gapLimit)
+	gapLimit, err = account.ExtendReceiveGapLimit()
+	require.NoError(t, err)
+	require.Equal(t, uint16(40), gapLimit)
+	require.Equal(t, uint16(40), account.Config().Config.ReceiveGapLimit)
+
+	headroom, err = account.ReceiveAddressHeadroom(nil)
+	require.NoError(t, err)
+	require.Equal(t, 20, headroom)
+	handout, err := account.NextReceiveAddress(nil)
+	require.NoError(t, err)
+	require.Equal(t, 19, handout.Headroom)
+	require.Equal(t, uint32(20), handout.Address.Configuration.AbsoluteKeypath().ToUInt32()[4])
+
+	// The account has no receive addresses of other script types.
+	scriptType := signing.ScriptTypeP2TR
+	_, err = account.NextReceiveAddress(&scriptType)
+	require.Error(t, err)
+
+	// The extended gap limit is kept in the account config if the account database is rebuilt.
+	rebuilt := mockAccount(t, account.Config().Config)
+	require.NoError(t, rebuilt.Initialize())
+	defer rebuilt.Close()
+	headroom, err = rebuilt.ReceiveAddressHeadroom(nil)
+	require.NoError(t, err)
+	require.Equal(t, 40, headroom)
+}
@@ -23,8 +23,20 @@ type DBInterface interface {
 	// HeaderByHeight retrieves a header stored at the specified height. If no header was found, nil
 	// is returned.
 	HeaderByHeight(height int) (*wire.BlockHeader, error)
-	// RevertTo deletes all headers after tip.
+	// RevertTo deletes all headers after tip. If the headers below the new tip were pruned, all
+	// headers are deleted.
 	RevertTo(tip int) error
+	// PruneBelow deletes the headers below the given height, which must not be above the tip.
+	// HeaderByHeight() returns nil for the pruned heights. Nothing happens if the headers are
+	// already pruned below this height.
+	PruneBelow(height int) error
+	// PrunedBelow returns the height of the first stored header, 0 if the headers were not pruned.
+	PrunedBelow() int
+	// RestoreBelow stores the given headers, which were pruned, in front of the stored headers. The
+	// first of them is the header at `height`, and the last one the header right below the first
+	// stored header. If there are no headers yet, the given headers become the first stored
+	// headers, and the headers below `height` are treated as pruned.
+	RestoreBelow(height int, headers []*wire.BlockHeader) error
 	// Tip retrieves the current max. height.
 	Tip() (int, error)
 	// Flush forces the db changes to the filesystem.
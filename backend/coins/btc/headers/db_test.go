@@ -20,6 +20,9 @@ type dbMock struct {
 	putHeader      func(height int, header *wire.BlockHeader) error
 	headerByHeight func(height int) (*wire.BlockHeader, error)
 	revertTo       func(tip int) error
+	pruneBelow     func(height int) error
+	prunedBelow    func() int
+	restoreBelow   func(height int, headers []*wire.BlockHeader) error
 	tip            func() (int, error)
 	flush          func() error
 	close          func() error
@@ -43,6 +46,24 @@ func (db *dbMock) RevertTo(tip int) error {
 	}
 	return nil
 }
+func (db *dbMock) PruneBelow(height int) error {
+	if db.pruneBelow != nil {
+		return db.pruneBelow(height)
+	}
+	return nil
+}
+func (db *dbMock) PrunedBelow() int {
+	if db.prunedBelow != nil {
+		return db.prunedBelow()
+	}
+	return 0
+}
+func (db *dbMock) RestoreBelow(height int, headers []*wire.BlockHeader) error {
+	if db.restoreBelow != nil {
+		return db.restoreBelow(height, headers)
+	}
+	return nil
+}
 func (db *dbMock) Tip() (int, error) {
 	if db.tip != nil {
 		return db.tip()
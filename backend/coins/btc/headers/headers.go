@@ -16,12 +16,16 @@ package headers
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
@@ -34,7 +38,39 @@ import (
 	"golang.org/x/crypto/scrypt"
 )
 
-const reorgLimit = 100
+// ReorgLimit is the number of headers which are reverted and downloaded again when a reorg is
+// detected.
+const ReorgLimit = 100
+
+// restoreChunkSize is the maximum number of pruned headers which are restored at once, see
+// restorePrunedHeaders().
+const restoreChunkSize = 50 * 2016
+
+// minPruneDepth is the minimum number of headers kept below the tip when pruning, in addition to
+// one difficulty period, which is needed to validate the next difficulty adjustment. It allows
+// reverting and downloading the headers again after a reorg without a full re-download.
+const minPruneDepth = ReorgLimit
+
+// maxTimewarp is the maximum number of seconds the first block of a difficulty period can be
+// timestamped before the last block of the previous period, see BIP94.
+const maxTimewarp = 600 * time.Second
+
+// maxClaimedTipGap is the number of blocks the verified tip may stay behind the tip claimed by the
+// server once the server stopped delivering headers.
+const maxClaimedTipGap = 2
+
+// maxStalledBatches is the number of header requests in a row after which the server is considered
+// lagging if it does not deliver the headers up to the tip it claimed, e.g. because it claimed a
+// bogus future height.
+const maxStalledBatches = 3
+
+// stalledRetryInterval is the time after which headers are requested again if the server did not
+// deliver the headers up to the tip it claimed.
+const stalledRetryInterval = 10 * time.Second
+
+// syncRateSmoothing is the weight of the most recent measurement in the rolling estimate of the
+// sync rate.
+const syncRateSmoothing = 0.3
 
 // Event instances are sent to the onEvent callback.
 type Event string
@@ -45,8 +81,18 @@ const (
 	EventSyncing Event = "syncing"
 	// EventSynced is fired when the headers finished syncing.
 	EventSynced Event = "synced"
-	// EventNewTip is fired when a new tip is known.
+	// EventNewTip is fired when the verified tip advanced, i.e. when the headers up to the new tip
+	// were downloaded and validated. A tip merely claimed by the server does not fire this event.
 	EventNewTip Event = "newTip"
+	// EventInvalidHeaders is fired when the server sent headers violating the consensus rules,
+	// e.g. with insufficient proof of work. The server is marked as bad.
+	EventInvalidHeaders Event = "invalidHeaders"
+	// EventReorg is fired when a reorg was detected. The last `ReorgLimit` headers were reverted and
+	// are downloaded again, followed by EventSynced.
+	EventReorg Event = "reorg"
+	// EventLaggingServer is fired when the server persistently did not deliver the headers up to the
+	// tip it claimed. The claimed tip is discarded and the server is marked as bad.
+	EventLaggingServer Event = "laggingServer"
 )
 
 // Interface represents the public API of this package.
@@ -68,14 +114,38 @@ type Headers struct {
 	db              DBInterface
 	blockchain      blockchain.Interface
 	headersPerBatch int
-	lock            locker.Locker
-	// targetHeight is the potential tip height we are syncing up to.
+	// pruneDepth is the number of headers kept below the tip, see SetPruneDepth(). 0 means the
+	// headers are not pruned.
+	pruneDepth int
+	// birthday is the height below which the wallets have no transactions, see SetBirthday(). 0 if
+	// unknown.
+	birthday int
+	// restoreLock serializes restoring pruned headers, see restorePrunedHeaders(). It is not held
+	// together with `lock` while downloading.
+	restoreLock sync.Mutex
+	lock        locker.Locker
+	// targetHeight is the tip height claimed by the server, which we are syncing up to. It is not
+	// trusted until the headers up to it are verified, see TipHeight().
 	targetHeight int
+	// stalledBatches counts the header requests in a row which did not close the gap to the claimed
+	// tip, see maxStalledBatches.
+	stalledBatches       int
+	stalledRetryInterval time.Duration
 	// tipAtInitTime is the tip at init time, i.e. the last tip known, loaded from the DB. It is
 	// used to show the sync progress since the last time (catch up).
 	tipAtInitTime int
 	kickChan      chan struct{}
 	quitChan      chan struct{}
+	// ctx is cancelled on Close(), cancelling a pending headers request.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// headersPerSecond is a rolling estimate of the download speed, used to estimate the time
+	// until the headers are synced. It is reset when the connection to the server is restored.
+	headersPerSecond float64
+	// syncRateTip and syncRateTime are the tip and time of the last sync rate measurement.
+	syncRateTip  int
+	syncRateTime time.Time
 
 	eventCallbacks []func(Event)
 
@@ -83,6 +153,8 @@ type Headers struct {
 
 	// Only for testing, must be nil in production.
 	testDownloadFinished func()
+	// Only for testing, must be nil in production. Replaces the checkpoint of the network.
+	testCheckpoint *chaincfg.Checkpoint
 }
 
 // Status represents the syncing status.
@@ -92,6 +164,15 @@ type Status struct {
 	// Only well defined if Tip >= 0
 	TipHashHex   blockchain.TXHash `json:"tipHashHex"`
 	TargetHeight int               `json:"targetHeight"`
+	// Percentage is the sync progress of Tip towards TargetHeight, in the range [0, 100].
+	Percentage float64 `json:"percentage"`
+	// HeadersPerSecond is a rolling estimate of the download speed. 0 if unknown.
+	HeadersPerSecond float64 `json:"headersPerSecond"`
+	// ETASeconds is the estimated number of seconds until the headers are synced. nil if unknown.
+	ETASeconds *int `json:"etaSeconds"`
+	// ClaimedTipGap is the number of blocks the verified tip is behind the tip claimed by the
+	// server. A large gap after syncing means that the server claims blocks it does not deliver.
+	ClaimedTipGap int `json:"claimedTipGap"`
 }
 
 // NewHeaders creates a new Headers instance.
@@ -100,6 +181,7 @@ func NewHeaders(
 	db DBInterface,
 	blockchain blockchain.Interface,
 	log *logrus.Entry) *Headers {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Headers{
 		log: log,
 
@@ -108,22 +190,203 @@ func NewHeaders(
 		blockchain: blockchain,
 		// We start with a small batch size and increase to the maximum allowed one with the first
 		// response.
-		headersPerBatch: 10,
-		targetHeight:    0,
-		tipAtInitTime:   0,
-		kickChan:        make(chan struct{}, 1),
-		quitChan:        make(chan struct{}),
+		headersPerBatch:      10,
+		targetHeight:         0,
+		tipAtInitTime:        0,
+		stalledRetryInterval: stalledRetryInterval,
+		kickChan:             make(chan struct{}, 1),
+		quitChan:             make(chan struct{}),
+		ctx:                  ctx,
+		cancel:               cancel,
 
 		eventCallbacks: []func(Event){},
 	}
 }
 
+// SetPruneDepth enables pruning the headers more than `depth` blocks below the tip once the
+// headers are synced. The depth is raised to keep at least one difficulty period plus ReorgLimit
+// headers. 0 disables pruning. Must be called before Initialize().
+//
+// The headers below the pruned height are downloaded again when they are needed, e.g. to verify
+// the transactions of an account added after pruning, see VerifiedHeaderByHeight(). If a reorg
+// reverts below the pruned height, all headers are downloaded again.
+func (headers *Headers) SetPruneDepth(depth int) {
+	headers.pruneDepth = depth
+}
+
+// SetBirthday sets the height below which the wallets using these headers have no transactions. If
+// it is not below the latest checkpoint, the sync starts at the difficulty period of the checkpoint
+// instead of the genesis block, so the headers below are neither downloaded nor verified. 0 means
+// the birthday is unknown. It has no effect once the headers are synced up to the difficulty period
+// of the checkpoint.
+//
+// The birthday is only an optimization hint: the skipped headers are treated like pruned headers
+// and are downloaded when they are needed, e.g. to verify a transaction before the birthday, see
+// VerifiedHeaderByHeight().
+func (headers *Headers) SetBirthday(height int) {
+	defer headers.lock.Lock()()
+	headers.birthday = height
+}
+
+// checkpointPeriodStart returns the height of the first header stored when starting the sync at
+// the checkpoint. One header before the difficulty period of the checkpoint is included, as
+// Litecoin uses it to compute the difficulty adjustment.
+func (headers *Headers) checkpointPeriodStart(checkpoint *chaincfg.Checkpoint) int {
+	blocksPerRetarget := headers.blocksPerRetarget()
+	return int(checkpoint.Height)/blocksPerRetarget*blocksPerRetarget - 1
+}
+
+// startsAtCheckpoint returns the checkpoint if the sync at the given tip can continue at it
+// because of the birthday, see SetBirthday(), or nil otherwise. Must be called with the lock held.
+func (headers *Headers) startsAtCheckpoint(tip int) *chaincfg.Checkpoint {
+	checkpoint := headers.checkpoint()
+	if checkpoint == nil || headers.birthday <= 0 || headers.birthday < int(checkpoint.Height) ||
+		tip >= headers.checkpointPeriodStart(checkpoint) || headers.db.PrunedBelow() != 0 {
+		return nil
+	}
+	return checkpoint
+}
+
+// downloadCheckpointPeriod replaces the headers synced up to `tip` with the headers of the
+// difficulty period of the checkpoint up to the checkpoint, so that the sync continues after the
+// checkpoint. The downloaded headers are valid if they are linked by their hashes to the
+// checkpoint. The headers below are treated as pruned.
+func (headers *Headers) downloadCheckpointPeriod(tip int, checkpoint *chaincfg.Checkpoint) error {
+	from := headers.checkpointPeriodStart(checkpoint)
+	downloaded, reportInvalid, err := headers.downloadHeaders(from, int(checkpoint.Height)-from+1)
+	if err != nil {
+		return err
+	}
+	for i := range downloaded {
+		valid := i == len(downloaded)-1 && downloaded[i].BlockHash() == *checkpoint.Hash ||
+			i < len(downloaded)-1 && downloaded[i].BlockHash() == downloaded[i+1].PrevBlock
+		if !valid {
+			if reportInvalid != nil {
+				go reportInvalid(errInvalidHeader)
+			}
+			return errp.WithMessage(errInvalidHeader,
+				fmt.Sprintf("Header %d does not link to the checkpoint", from+i))
+		}
+	}
+	defer headers.lock.Lock()()
+	if headers.closed {
+		return nil
+	}
+	if currentTip, err := headers.db.Tip(); err != nil || currentTip != tip {
+		return err
+	}
+	if tip != -1 {
+		if err := headers.db.RevertTo(-1); err != nil {
+			return err
+		}
+	}
+	if err := headers.db.RestoreBelow(from, downloaded); err != nil {
+		return err
+	}
+	headers.log.Infof("Starting the sync at the checkpoint at %d", checkpoint.Height)
+	headers.kick()
+	return nil
+}
+
+// maybePrune prunes the headers according to the prune depth. The pruned height is rounded down
+// to the start of a difficulty period, so the headers are not rewritten after every block. Must be
+// called with the lock held.
+func (headers *Headers) maybePrune(db DBInterface, tip int) {
+	if headers.pruneDepth == 0 {
+		return
+	}
+	blocksPerRetarget := headers.blocksPerRetarget()
+	depth := max(headers.pruneDepth, blocksPerRetarget+minPruneDepth)
+	// One header before the difficulty period is kept, as Litecoin uses it to compute the
+	// difficulty adjustment.
+	pruneBelow := (tip-depth)/blocksPerRetarget*blocksPerRetarget - 1
+	if pruneBelow <= 0 {
+		return
+	}
+	if err := db.PruneBelow(pruneBelow); err != nil {
+		headers.log.WithError(err).Error("Could not prune the headers")
+	}
+}
+
+// restorePrunedHeaders downloads the pruned headers from the given height up to the first stored
+// header and stores them again. The downloaded headers are valid if they are linked by their hashes
+// to the first stored header, which was validated before. They are restored in chunks from the top
+// down, so every chunk can be checked against the headers restored before it.
+func (headers *Headers) restorePrunedHeaders(height int) error {
+	headers.restoreLock.Lock()
+	defer headers.restoreLock.Unlock()
+	for {
+		prunedBelow := headers.db.PrunedBelow()
+		if height >= prunedBelow {
+			return nil
+		}
+		from := max(height, prunedBelow-restoreChunkSize)
+		restored, reportInvalid, err := headers.downloadHeaders(from, prunedBelow-from)
+		if err != nil {
+			return err
+		}
+		err = func() error {
+			defer headers.lock.Lock()()
+			if headers.db.PrunedBelow() != prunedBelow {
+				return errp.New("The headers changed while restoring pruned headers")
+			}
+			firstStored, err := headers.db.HeaderByHeight(prunedBelow)
+			if err != nil {
+				return err
+			}
+			if firstStored == nil {
+				return errp.Newf("Missing the header at %d", prunedBelow)
+			}
+			for i := range restored {
+				nextPrevBlock := firstStored.PrevBlock
+				if i < len(restored)-1 {
+					nextPrevBlock = restored[i+1].PrevBlock
+				}
+				if restored[i].BlockHash() != nextPrevBlock {
+					if reportInvalid != nil {
+						go reportInvalid(errInvalidHeader)
+					}
+					return errp.WithMessage(errInvalidHeader,
+						fmt.Sprintf("Restored header %d does not link to the stored headers", from+i))
+				}
+			}
+			return headers.db.RestoreBelow(from, restored)
+		}()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// downloadHeaders downloads `count` headers starting at `from`, in as many requests as needed.
+// Returns the function to report the server as invalid, see blockchain.HeadersResult.
+func (headers *Headers) downloadHeaders(from int, count int) ([]*wire.BlockHeader, func(error), error) {
+	result := make([]*wire.BlockHeader, 0, count)
+	var reportInvalid func(error)
+	for len(result) < count {
+		headersResult, err := headers.blockchain.Headers(
+			headers.ctx, from+len(result), count-len(result))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(headersResult.Headers) == 0 {
+			return nil, nil, errp.Newf("The server returned no headers at %d", from+len(result))
+		}
+		reportInvalid = headersResult.ReportInvalid
+		result = append(result, headersResult.Headers...)
+	}
+	return result[:count], reportInvalid, nil
+}
+
 // checkpoint returns the latest checkpoint for the current chain. It panics if the network is
 // unknown.
 func (headers *Headers) checkpoint() *chaincfg.Checkpoint {
 	// We define our own checkpoints over using headers.net.Checkpoints, because they are defined in
 	// the vendored btcd dep, and we want to control it. Furthermore, the chaincfg.Params are evil
 	// globals registered in the lib's `init()`, so we can't replicate the instances ourselves.
+	if headers.testCheckpoint != nil {
+		return headers.testCheckpoint
+	}
 
 	mustUnhex := func(s string) *chainhash.Hash {
 		hash, err := chainhash.NewHashFromStr(s)
@@ -166,15 +429,28 @@ func (headers *Headers) SubscribeEvent(f func(event Event)) func() {
 	}
 }
 
-// TipHeight returns the height of the tip.
+// TipHeight returns the height of the verified tip, i.e. of the last header which was downloaded and
+// validated. The tip claimed by the server is not used, so that a lying server can't inflate the
+// number of confirmations. Returns 0 if the tip could not be read.
 func (headers *Headers) TipHeight() int {
-	return headers.targetHeight
+	defer headers.lock.RLock()()
+	tip, err := headers.db.Tip()
+	if err != nil {
+		headers.log.WithError(err).Error("Could not read the tip")
+		return 0
+	}
+	return tip
 }
 
 // Initialize starts the syncing process.
 func (headers *Headers) Initialize() {
 	headers.tipAtInitTime = headers.tip()
 	headers.log.Infof("last tip loaded: %d", headers.tipAtInitTime)
+	headers.blockchain.RegisterOnConnectionErrorChangedEvent(func(err error) {
+		if err == nil {
+			headers.resetSyncRate()
+		}
+	})
 	go headers.download()
 	go headers.blockchain.HeadersSubscribe(
 		func(header *types.Header) {
@@ -195,27 +471,65 @@ func (headers *Headers) download() {
 	defer headers.log.Debug("stopped downloading")
 
 	downloadAndProcessBatch := func() {
-		defer headers.lock.Lock()()
+		// The lock is not held during the request, so that the verified tip, the status and the
+		// headers can be read while waiting for the server.
+		unlock := headers.lock.RLock()
 		if headers.closed {
+			unlock()
 			return
 		}
-		db := headers.db
-		tip, err := db.Tip()
+		tip, err := headers.db.Tip()
+		headersPerBatch := headers.headersPerBatch
+		var checkpoint *chaincfg.Checkpoint
+		if err == nil {
+			checkpoint = headers.startsAtCheckpoint(tip)
+		}
+		unlock()
 		if err != nil {
 			// TODO
 			return
 		}
-		headersResult, err := headers.blockchain.Headers(tip+1, headers.headersPerBatch)
+		if checkpoint != nil {
+			if err := headers.downloadCheckpointPeriod(tip, checkpoint); err != nil {
+				headers.log.WithError(err).Error("Could not download the headers of the checkpoint")
+				if errp.Cause(err) == errInvalidHeader {
+					headers.notifyEvent(EventInvalidHeaders)
+				}
+			}
+			return
+		}
+		headersResult, err := headers.blockchain.Headers(headers.ctx, tip+1, headersPerBatch)
 		if err != nil {
 			// TODO
 			headers.log.WithError(err).Error("blockchain.Headers")
 			return
 		}
+
+		defer headers.lock.Lock()()
+		if headers.closed {
+			return
+		}
+		db := headers.db
+		if currentTip, err := db.Tip(); err != nil || currentTip != tip {
+			// Only this goroutine changes the tip, but never connect a batch to a stale tip.
+			headers.kick()
+			return
+		}
+		moreComing := len(headersResult.Headers) == min(headersResult.Max, headersPerBatch)
 		if err := headers.processBatch(db, tip, headersResult.Headers, headersResult.Max); err != nil {
+			if errp.Cause(err) == errInvalidHeader {
+				headers.log.WithError(err).Error("The server sent invalid headers")
+				if headersResult.ReportInvalid != nil {
+					go headersResult.ReportInvalid(err)
+				}
+				headers.notifyEvent(EventInvalidHeaders)
+				return
+			}
 			// TODO
 			headers.log.WithError(err).Error("processBatch")
 			return
 		}
+		headers.checkClaimedTip(db, moreComing, headersResult.ReportInvalid)
 	}
 
 	for {
@@ -235,18 +549,98 @@ func (headers *Headers) download() {
 
 var errPrevHash = errors.New("header prevhash does not match")
 
+// errInvalidHeader is returned if a header violates the consensus rules, i.e. the server sent us
+// an invalid chain.
+var errInvalidHeader = errors.New("invalid header")
+
+// errCustomSignet is returned when connecting headers of a signet other than the default public
+// signet. The blocks of a signet are signed according to its challenge. The signature is part of
+// the coinbase transaction, so it can't be verified using block headers only. Only the challenge of
+// the default signet is known to be trustworthy, see netparams.SigNetParams.
+var errCustomSignet = errors.New("custom signets are not supported")
+
+// blocksPerRetarget returns the number of blocks between two difficulty adjustments.
+func (headers *Headers) blocksPerRetarget() int {
+	return int(headers.net.TargetTimespan / headers.net.TargetTimePerBlock)
+}
+
+// requiredBits returns the difficulty (nBits) the header at the given height must have according
+// to the difficulty adjustment rules of the network.
+func (headers *Headers) requiredBits(db DBInterface, height int, header *wire.BlockHeader) (uint32, error) {
+	headerByHeight := func(height int) (*wire.BlockHeader, error) {
+		header, err := db.HeaderByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errp.Newf("header at %d not found", height)
+		}
+		return header, nil
+	}
+	previous, err := headerByHeight(height - 1)
+	if err != nil {
+		return 0, err
+	}
+	if headers.net.PoWNoRetargeting {
+		return previous.Bits, nil
+	}
+	blocksPerRetarget := headers.blocksPerRetarget()
+	if height%blocksPerRetarget == 0 {
+		newTarget, err := headers.getTarget(db, height)
+		if err != nil {
+			return 0, err
+		}
+		return btcdBlockchain.BigToCompact(newTarget), nil
+	}
+	if headers.net.ReduceMinDifficulty {
+		// Testnet rule: if no block was found for twice the target block time, a block can be
+		// mined with the minimum difficulty.
+		if header.Timestamp.After(previous.Timestamp.Add(headers.net.MinDiffReductionTime)) {
+			return headers.net.PowLimitBits, nil
+		}
+		// Otherwise, the difficulty is the one of the last block which was not mined using the
+		// above rule.
+		for h := height - 1; h%blocksPerRetarget != 0 && previous.Bits == headers.net.PowLimitBits; {
+			h--
+			previous, err = headerByHeight(h)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return previous.Bits, nil
+}
+
+// checkProofOfWork checks that the header hash satisfies the target encoded in its nBits.
+func (headers *Headers) checkProofOfWork(height int, header *wire.BlockHeader) error {
+	target := btcdBlockchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 || target.Cmp(headers.net.PowLimit) > 0 {
+		return errp.Wrap(errInvalidHeader,
+			fmt.Sprintf("header %d has a target out of range", height))
+	}
+	headerSerialized := &bytes.Buffer{}
+	if err := header.BtcEncode(headerSerialized, 0, wire.BaseEncoding); err != nil {
+		panic(errp.WithStack(err))
+	}
+	powHash := headers.powHash(headerSerialized.Bytes())
+	if btcdBlockchain.HashToBig(&powHash).Cmp(target) > 0 {
+		return errp.Wrap(errInvalidHeader,
+			fmt.Sprintf("header %d, %s has insufficient proof of work", height, powHash))
+	}
+	return nil
+}
+
 func (headers *Headers) getTarget(db DBInterface, index int) (*big.Int, error) {
 	targetTimespan := int64(headers.net.TargetTimespan / time.Second)
-	targetTimePerBlock := int64(headers.net.TargetTimePerBlock / time.Second)
-	blocksPerRetarget := int(targetTimespan / targetTimePerBlock)
+	blocksPerRetarget := headers.blocksPerRetarget()
 	chunkIndex := (index / blocksPerRetarget) - 1
 	if chunkIndex == -1 {
 		return btcdBlockchain.CompactToBig(headers.net.GenesisBlock.Header.Bits), nil
 	}
 
 	firstIndex := chunkIndex * blocksPerRetarget
-	if headers.net.Net == ltc.MainNetParams.Net && chunkIndex > 0 {
-		// Litecoin includes the last block of the previous window to fix a time warp attack:
+	if headers.isScrypt() && chunkIndex > 0 {
+		// Litecoin (mainnet and testnet) includes the last block of the previous window to fix a time warp attack:
 		// https://litecoin.info/index.php/Time_warp_attack#cite_note-2
 		firstIndex--
 	}
@@ -266,6 +660,11 @@ func (headers *Headers) getTarget(db DBInterface, index int) (*big.Int, error) {
 		return nil, errp.Newf("header at %d not found", lastIndex)
 	}
 	lastTarget := btcdBlockchain.CompactToBig(last.Bits)
+	if headers.enforceBIP94() {
+		// The last block of the period could be mined with the minimum difficulty, so the
+		// difficulty of the first block is used instead.
+		lastTarget = btcdBlockchain.CompactToBig(first.Bits)
+	}
 	timespan := last.Timestamp.Unix() - first.Timestamp.Unix()
 
 	minRetargetTimespan := targetTimespan / headers.net.RetargetAdjustmentFactor
@@ -283,31 +682,48 @@ func (headers *Headers) getTarget(db DBInterface, index int) (*big.Int, error) {
 	return newTarget, nil
 }
 
+// enforceBIP94 returns true if the network enforces the testnet4 consensus rules of BIP94.
+func (headers *Headers) enforceBIP94() bool {
+	return headers.net.Net == netparams.TestNet4
+}
+
+// isCustomSignet returns true if the network is a signet with a challenge other than the one of the
+// default signet. Custom signets have the name of the default signet, but their network magic is
+// derived from their challenge.
+func (headers *Headers) isCustomSignet() bool {
+	return headers.net.Name == netparams.SigNetParams.Name && headers.net.Net != netparams.SigNetParams.Net
+}
+
+// isScrypt returns true if the proof of work of the network is scrypt based (Litecoin) instead of
+// double SHA256 based (Bitcoin).
+func (headers *Headers) isScrypt() bool {
+	return headers.net.Net == ltc.MainNetParams.Net || headers.net.Net == ltc.TestNet4Params.Net
+}
+
 func (headers *Headers) powHash(msg []byte) chainhash.Hash {
-	switch headers.net.Net {
-	case chaincfg.MainNetParams.Net:
+	if !headers.isScrypt() {
 		return chainhash.DoubleHashH(msg)
-	case ltc.MainNetParams.Net:
-		const (
-			N = 1024
-			r = 1
-			p = 1
-		)
-		hashBytes, err := scrypt.Key(msg, msg, N, r, p, 32)
-		if err != nil {
-			panic(errp.WithStack(err))
-		}
-		hash := chainhash.Hash{}
-		if err := hash.SetBytes(hashBytes); err != nil {
-			panic(errp.WithStack(err))
-		}
-		return hash
-	default:
-		panic("unsupported coin")
 	}
+	const (
+		N = 1024
+		r = 1
+		p = 1
+	)
+	hashBytes, err := scrypt.Key(msg, msg, N, r, p, 32)
+	if err != nil {
+		panic(errp.WithStack(err))
+	}
+	hash := chainhash.Hash{}
+	if err := hash.SetBytes(hashBytes); err != nil {
+		panic(errp.WithStack(err))
+	}
+	return hash
 }
 
 func (headers *Headers) canConnect(db DBInterface, tip int, header *wire.BlockHeader) error {
+	if headers.isCustomSignet() {
+		return errp.WithStack(errCustomSignet)
+	}
 	if tip == 0 {
 		if header.BlockHash() != *headers.net.GenesisHash {
 			return errp.Newf("wrong genesis hash, got %s, expected %s",
@@ -327,36 +743,35 @@ func (headers *Headers) canConnect(db DBInterface, tip int, header *wire.BlockHe
 				fmt.Sprintf("%s (%d) does not connect to %s (%d)",
 					header.PrevBlock, tip, prevBlock, tip-1))
 		}
+		if headers.enforceBIP94() && tip%headers.blocksPerRetarget() == 0 &&
+			header.Timestamp.Before(previousHeader.Timestamp.Add(-maxTimewarp)) {
+			return errp.Wrap(errInvalidHeader,
+				fmt.Sprintf("header %d is timestamped too far before the previous header", tip))
+		}
 
 		lastCheckpoint := headers.checkpoint()
 		if lastCheckpoint != nil && tip == int(lastCheckpoint.Height) {
 			if *lastCheckpoint.Hash != header.BlockHash() {
-				return errp.Newf("checkpoint mismatch at %d. Expected %s, got %s",
-					tip, lastCheckpoint.Hash, header.BlockHash())
+				return errp.Wrap(errInvalidHeader,
+					fmt.Sprintf("checkpoint mismatch at %d. Expected %s, got %s",
+						tip, lastCheckpoint.Hash, header.BlockHash()))
 			}
 			headers.log.Infof("checkpoint at %d matches", tip)
 		}
-		// Check Difficulty, PoW.
-		if headers.net.Net == chaincfg.MainNetParams.Net || headers.net.Net == ltc.MainNetParams.Net {
-			newTarget, err := headers.getTarget(db, tip)
-			if err != nil {
+		requiredBits, err := headers.requiredBits(db, tip, header)
+		if err != nil {
+			return err
+		}
+		if header.Bits != requiredBits {
+			return errp.Wrap(errInvalidHeader,
+				fmt.Sprintf("header %d has an unexpected difficulty", tip))
+		}
+		// Skip the PoW check of scrypt based coins before the checkpoint for performance. These
+		// headers are anchored by the checkpoint.
+		if !headers.isScrypt() || (lastCheckpoint != nil && tip > int(lastCheckpoint.Height)) {
+			if err := headers.checkProofOfWork(tip, header); err != nil {
 				return err
 			}
-			if header.Bits != btcdBlockchain.BigToCompact(newTarget) {
-				return errp.Newf("header %d has an unexpected difficulty", tip)
-			}
-			headerSerialized := &bytes.Buffer{}
-			if err := header.BtcEncode(headerSerialized, 0, wire.BaseEncoding); err != nil {
-				panic(errp.WithStack(err))
-			}
-			// Skip PoW check before the checkpoint for performance.
-			if lastCheckpoint != nil && tip > int(lastCheckpoint.Height) {
-				powHash := headers.powHash(headerSerialized.Bytes())
-				proofOfWork := btcdBlockchain.HashToBig(&powHash)
-				if proofOfWork.Cmp(newTarget) > 0 {
-					return errp.Newf("header %d, %s has insufficient proof of work.", tip, powHash)
-				}
-			}
 		}
 	}
 	return nil
@@ -371,15 +786,16 @@ func min(a, b int) int {
 
 func (headers *Headers) reorg(db DBInterface, tip int) {
 	// Simple reorg method: re-fetch headers up to the maximum reorg limit. The server can shorten
-	// our chain by sending a fake header and set us back by `reorgLimit` blocks, but it needs to
+	// our chain by sending a fake header and set us back by `ReorgLimit` blocks, but it needs to
 	// contain the correct PoW to do so.
-	newTip := tip - reorgLimit
+	newTip := tip - ReorgLimit
 	if newTip < -1 {
 		newTip = -1
 	}
 	if err := db.RevertTo(newTip); err != nil {
 		panic(err)
 	}
+	headers.notifyEvent(EventReorg)
 	headers.kick()
 }
 
@@ -408,6 +824,7 @@ func (headers *Headers) processBatch(
 			return err
 		}
 	}
+	headers.updateSyncRate(tip, time.Now())
 	if err := db.Flush(); err != nil {
 		// Ignore error, not critical.
 		headers.log.WithError(err).Error("Failed to flush")
@@ -419,28 +836,79 @@ func (headers *Headers) processBatch(
 		headers.notifyEvent(EventSyncing)
 	} else if len(blockHeaders) != 0 {
 		headers.log.Debugf("Synced headers; tip: %d", tip)
+		headers.maybePrune(db, tip)
 		headers.notifyEvent(EventSynced)
+		headers.notifyEvent(EventNewTip)
 	}
 	headers.headersPerBatch = max
 	return nil
 }
 
 // VerifiedHeaderByHeight returns the header at the given height. Returns nil if the headers are not synced
-// up to this height yet OR if the headers are not synced up to the latest checkpoint yet.
+// up to this height yet OR if the headers are not synced up to the latest checkpoint yet. If the
+// header was pruned, it is downloaded again, see restorePrunedHeaders().
 func (headers *Headers) VerifiedHeaderByHeight(height int) (*wire.BlockHeader, error) {
+	header, pruned, err := headers.verifiedHeaderByHeight(height)
+	if err != nil || !pruned {
+		return header, err
+	}
+	if err := headers.restorePrunedHeaders(height); err != nil {
+		return nil, err
+	}
+	header, _, err = headers.verifiedHeaderByHeight(height)
+	return header, err
+}
+
+// verifiedHeaderByHeight is like VerifiedHeaderByHeight(), but returns true instead of
+// restoring the header if it was pruned.
+func (headers *Headers) verifiedHeaderByHeight(height int) (*wire.BlockHeader, bool, error) {
 	defer headers.lock.RLock()()
 
 	tip, err := headers.db.Tip()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	checkpoint := headers.checkpoint()
 	if checkpoint != nil && tip < int(checkpoint.Height) {
-		return nil, nil
+		return nil, false, nil
+	}
+	if height >= 0 && height <= tip && height < headers.db.PrunedBelow() {
+		return nil, true, nil
 	}
 
-	return headers.db.HeaderByHeight(height)
+	header, err := headers.db.HeaderByHeight(height)
+	return header, false, err
+}
+
+// updateSyncRate updates the rolling estimate of the download speed with the tip reached at the
+// given time. Must be called with the lock held.
+func (headers *Headers) updateSyncRate(tip int, now time.Time) {
+	defer func() {
+		headers.syncRateTip = tip
+		headers.syncRateTime = now
+	}()
+	if headers.syncRateTime.IsZero() || tip <= headers.syncRateTip {
+		return
+	}
+	elapsed := now.Sub(headers.syncRateTime).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(tip-headers.syncRateTip) / elapsed
+	if headers.headersPerSecond == 0 {
+		headers.headersPerSecond = rate
+		return
+	}
+	headers.headersPerSecond = syncRateSmoothing*rate + (1-syncRateSmoothing)*headers.headersPerSecond
+}
+
+// resetSyncRate discards the sync rate estimate, e.g. after a reconnect, as the previous
+// measurements don't apply anymore.
+func (headers *Headers) resetSyncRate() {
+	defer headers.lock.Lock()()
+	headers.headersPerSecond = 0
+	headers.syncRateTime = time.Time{}
 }
 
 func (headers *Headers) kick() {
@@ -450,12 +918,44 @@ func (headers *Headers) kick() {
 	}
 }
 
-// update should be called when there is a new header.
+// update should be called when the server claims a new tip. The claimed tip only becomes the sync
+// target. EventNewTip is fired once the headers up to it are verified.
 func (headers *Headers) update(blockHeight int) {
 	headers.log.Debugf("new target %d", blockHeight)
+	func() {
+		defer headers.lock.Lock()()
+		headers.targetHeight = blockHeight
+	}()
 	headers.kick()
-	headers.targetHeight = blockHeight
-	headers.notifyEvent(EventNewTip)
+}
+
+// checkClaimedTip checks that the server delivers the headers up to the tip it claimed, once it
+// stopped delivering headers. If it does not after a few attempts, the claimed tip is discarded and
+// the server is reported as bad, so that another server is used. Must be called with the lock held.
+func (headers *Headers) checkClaimedTip(db DBInterface, moreComing bool, reportInvalid func(error)) {
+	tip, err := db.Tip()
+	if err != nil {
+		headers.log.WithError(err).Error("Could not read the tip")
+		return
+	}
+	if moreComing || headers.targetHeight-tip <= maxClaimedTipGap {
+		headers.stalledBatches = 0
+		return
+	}
+	headers.stalledBatches++
+	if headers.stalledBatches < maxStalledBatches {
+		time.AfterFunc(headers.stalledRetryInterval, headers.kick)
+		return
+	}
+	err = errp.Newf("the server claimed tip %d, but only delivered headers up to %d",
+		headers.targetHeight, tip)
+	headers.log.WithError(err).Error("Lagging server")
+	headers.stalledBatches = 0
+	headers.targetHeight = tip
+	if reportInvalid != nil {
+		go reportInvalid(err)
+	}
+	headers.notifyEvent(EventLaggingServer)
 }
 
 func (headers *Headers) tip() int {
@@ -485,16 +985,35 @@ func (headers *Headers) Status() (*Status, error) {
 	if header != nil {
 		tipHashHex = blockchain.TXHash(header.BlockHash())
 	}
-	return &Status{
-		TipAtInitTime: headers.tipAtInitTime,
-		Tip:           tip,
-		TargetHeight:  headers.targetHeight,
-		TipHashHex:    tipHashHex,
-	}, nil
+	status := &Status{
+		TipAtInitTime:    headers.tipAtInitTime,
+		Tip:              tip,
+		TargetHeight:     headers.targetHeight,
+		TipHashHex:       tipHashHex,
+		HeadersPerSecond: headers.headersPerSecond,
+	}
+	if headers.targetHeight > tip {
+		status.ClaimedTipGap = headers.targetHeight - tip
+	}
+	if headers.targetHeight > 0 {
+		status.Percentage = 100 * math.Max(0, math.Min(1, float64(tip)/float64(headers.targetHeight)))
+		remaining := headers.targetHeight - tip
+		switch {
+		case remaining <= 0:
+			eta := 0
+			status.ETASeconds = &eta
+		case headers.headersPerSecond > 0:
+			eta := int(math.Ceil(float64(remaining) / headers.headersPerSecond))
+			status.ETASeconds = &eta
+		}
+	}
+	return status, nil
 }
 
 // Close shuts down the downloading goroutine and closes the database.
 func (headers *Headers) Close() error {
+	// Cancel a pending request first, so the download goroutine does not wait for it.
+	headers.cancel()
 	defer headers.lock.Lock()()
 	close(headers.quitChan)
 	headers.closed = true
@@ -15,11 +15,24 @@
 package headers
 
 import (
+	"bytes"
+	"math/big"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/headersdb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	btcdBlockchain "github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
@@ -45,3 +58,553 @@ func TestClose(t *testing.T) {
 	}
 
 }
+
+func TestStatusProgress(t *testing.T) {
+	headers := NewHeaders(
+		&chaincfg.TestNet3Params,
+		&dbMock{
+			tip:            func() (int, error) { return 1000, nil },
+			headerByHeight: func(int) (*wire.BlockHeader, error) { return &wire.BlockHeader{}, nil },
+		},
+		&mocks.BlockchainMock{},
+		(&logrus.Logger{}).WithField("group", "headers_test"),
+	)
+
+	// Target unknown.
+	status, err := headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, float64(0), status.Percentage)
+	require.Nil(t, status.ETASeconds)
+
+	// Target known, but no rate yet.
+	headers.targetHeight = 4000
+	status, err = headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, float64(25), status.Percentage)
+	require.Nil(t, status.ETASeconds)
+
+	// 500 headers per second.
+	start := time.Now()
+	headers.updateSyncRate(0, start)
+	headers.updateSyncRate(1000, start.Add(2*time.Second))
+	status, err = headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, float64(500), status.HeadersPerSecond)
+	require.Equal(t, 6, *status.ETASeconds)
+
+	// Rolling estimate: a batch at 1000 headers per second only partially moves the estimate.
+	headers.updateSyncRate(2000, start.Add(3*time.Second))
+	require.InDelta(t, 650, headers.headersPerSecond, 1e-9)
+
+	headers.resetSyncRate()
+	status, err = headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, float64(0), status.HeadersPerSecond)
+	require.Nil(t, status.ETASeconds)
+
+	// Synced.
+	headers.targetHeight = 1000
+	status, err = headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, float64(100), status.Percentage)
+	require.Equal(t, 0, *status.ETASeconds)
+}
+
+// TestReadDuringRequest checks that the verified tip and the status can be read while a headers
+// request is pending.
+func TestReadDuringRequest(t *testing.T) {
+	const verifiedTip = 100000
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	headers := NewHeaders(
+		&chaincfg.TestNet3Params,
+		&dbMock{
+			tip:            func() (int, error) { return verifiedTip, nil },
+			headerByHeight: func(int) (*wire.BlockHeader, error) { return &wire.BlockHeader{}, nil },
+		},
+		&mocks.BlockchainMock{
+			MockHeaders: func(int, int) (*blockchain.HeadersResult, error) {
+				close(requested)
+				<-release
+				return &blockchain.HeadersResult{Headers: []*wire.BlockHeader{}, Max: 10}, nil
+			},
+		},
+		(&logrus.Logger{}).WithField("group", "headers_test"),
+	)
+	headers.Initialize()
+	defer func() { require.NoError(t, headers.Close()) }()
+	defer close(release)
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "headers were not requested")
+	}
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		require.Equal(t, verifiedTip, headers.TipHeight())
+		status, err := headers.Status()
+		require.NoError(t, err)
+		require.Equal(t, verifiedTip, status.Tip)
+	}()
+	select {
+	case <-read:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "reading the tip blocked on the pending request")
+	}
+}
+
+func TestLaggingServer(t *testing.T) {
+	const verifiedTip = 100000
+	reportedInvalid := make(chan error, 1)
+	headers := NewHeaders(
+		&chaincfg.TestNet3Params,
+		&dbMock{
+			headerByHeight: func(int) (*wire.BlockHeader, error) { return &wire.BlockHeader{}, nil },
+		},
+		&mocks.BlockchainMock{
+			// The server claims a tip far in the future, but does not deliver the headers.
+			MockHeadersSubscribe: func(success func(*types.Header)) {
+				success(&types.Header{Height: verifiedTip + 1000})
+			},
+			MockHeaders: func(int, int) (*blockchain.HeadersResult, error) {
+				return &blockchain.HeadersResult{
+					Headers: []*wire.BlockHeader{},
+					Max:     10,
+					ReportInvalid: func(err error) {
+						reportedInvalid <- err
+					},
+				}, nil
+			},
+		},
+		(&logrus.Logger{}).WithField("group", "headers_test"),
+	)
+	headers.stalledRetryInterval = time.Millisecond
+	events := make(chan Event, 100)
+	headers.SubscribeEvent(func(event Event) { events <- event })
+	headers.Initialize()
+	defer func() { require.NoError(t, headers.Close()) }()
+
+	select {
+	case err := <-reportedInvalid:
+		require.Error(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the lagging server was not reported")
+	}
+	require.Equal(t, verifiedTip, headers.TipHeight())
+	status, err := headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, verifiedTip, status.TargetHeight)
+	require.Equal(t, 0, status.ClaimedTipGap)
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			require.NotEqual(t, EventNewTip, event)
+			if event == EventLaggingServer {
+				return
+			}
+		case <-timeout:
+			require.Fail(t, "expected EventLaggingServer")
+			return
+		}
+	}
+}
+
+// testChain builds a header chain on custom network params with cheap proof of work and a
+// difficulty retarget every 4 blocks.
+type testChain struct {
+	net     *chaincfg.Params
+	headers []*wire.BlockHeader
+}
+
+func newTestChain(reduceMinDifficulty bool, genesisBits uint32) *testChain {
+	net := chaincfg.RegressionNetParams
+	net.PoWNoRetargeting = false
+	net.TargetTimePerBlock = 10 * time.Minute
+	net.TargetTimespan = 4 * net.TargetTimePerBlock
+	net.ReduceMinDifficulty = reduceMinDifficulty
+	net.MinDiffReductionTime = 2 * net.TargetTimePerBlock
+	chain := &testChain{net: &net}
+	genesis := chain.mine(&wire.BlockHeader{
+		Version:   1,
+		Timestamp: time.Unix(1600000000, 0),
+		Bits:      genesisBits,
+	})
+	genesisHash := genesis.BlockHash()
+	net.GenesisHash = &genesisHash
+	net.GenesisBlock = &wire.MsgBlock{Header: *genesis}
+	chain.headers = []*wire.BlockHeader{genesis}
+	return chain
+}
+
+// mine finds a nonce satisfying the target of the header.
+func (chain *testChain) mine(header *wire.BlockHeader) *wire.BlockHeader {
+	target := btcdBlockchain.CompactToBig(header.Bits)
+	powHeaders := &Headers{net: chain.net}
+	for {
+		serialized := &bytes.Buffer{}
+		if err := header.BtcEncode(serialized, 0, wire.BaseEncoding); err != nil {
+			panic(err)
+		}
+		hash := powHeaders.powHash(serialized.Bytes())
+		if btcdBlockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return header
+		}
+		header.Nonce++
+	}
+}
+
+// next returns a new header on top of the chain, `spacing` after the previous one.
+func (chain *testChain) next(bits uint32, spacing time.Duration) *wire.BlockHeader {
+	previous := chain.headers[len(chain.headers)-1]
+	return chain.mine(&wire.BlockHeader{
+		Version:   1,
+		PrevBlock: previous.BlockHash(),
+		Timestamp: previous.Timestamp.Add(spacing),
+		Bits:      bits,
+	})
+}
+
+func (chain *testChain) connect(t *testing.T, header *wire.BlockHeader) error {
+	t.Helper()
+	headers := NewHeaders(
+		chain.net,
+		&dbMock{
+			headerByHeight: func(height int) (*wire.BlockHeader, error) {
+				if height >= len(chain.headers) {
+					return nil, nil
+				}
+				return chain.headers[height], nil
+			},
+		},
+		&mocks.BlockchainMock{},
+		(&logrus.Logger{}).WithField("group", "headers_test"),
+	)
+	err := headers.canConnect(headers.db, len(chain.headers), header)
+	if err == nil {
+		chain.headers = append(chain.headers, header)
+	}
+	return err
+}
+
+func TestDifficultyRetarget(t *testing.T) {
+	chain := newTestChain(false, 0x207fffff)
+	bits := chain.headers[0].Bits
+	for i := 1; i < 4; i++ {
+		require.NoError(t, chain.connect(t, chain.next(bits, 5*time.Minute)))
+	}
+
+	// The first period took 15 minutes (timestamp of first to last block) instead of 40 minutes.
+	target := btcdBlockchain.CompactToBig(bits)
+	target.Mul(target, big.NewInt(15))
+	target.Div(target, big.NewInt(40))
+	retargetBits := btcdBlockchain.BigToCompact(target)
+	require.NotEqual(t, bits, retargetBits)
+
+	// Not adjusting the difficulty at the retarget boundary is invalid.
+	err := chain.connect(t, chain.next(bits, 5*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(retargetBits, 5*time.Minute)))
+
+	// Changing the difficulty in the middle of a period is invalid.
+	err = chain.connect(t, chain.next(bits, 5*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(retargetBits, 5*time.Minute)))
+
+	// Insufficient proof of work.
+	header := chain.next(retargetBits, 5*time.Minute)
+	for {
+		header.Nonce++
+		hash := header.BlockHash()
+		if btcdBlockchain.HashToBig(&hash).Cmp(btcdBlockchain.CompactToBig(retargetBits)) > 0 {
+			break
+		}
+	}
+	err = chain.connect(t, header)
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+}
+
+func TestDifficultyRetargetLitecoinTestnet(t *testing.T) {
+	chain := newTestChain(false, 0x207fffff)
+	chain.net.Net = ltc.TestNet4Params.Net
+	bits := chain.headers[0].Bits
+	for i := 1; i < 4; i++ {
+		require.NoError(t, chain.connect(t, chain.next(bits, 5*time.Minute)))
+	}
+	// The first period took 15 minutes (timestamp of first to last block) instead of 40 minutes.
+	target := btcdBlockchain.CompactToBig(bits)
+	target.Mul(target, big.NewInt(15))
+	target.Div(target, big.NewInt(40))
+	retargetBits := btcdBlockchain.BigToCompact(target)
+	require.NoError(t, chain.connect(t, chain.next(retargetBits, 20*time.Minute)))
+	for i := 1; i < 4; i++ {
+		require.NoError(t, chain.connect(t, chain.next(retargetBits, 5*time.Minute)))
+	}
+
+	// Litecoin includes the last block of the previous period, so the second period took 35
+	// minutes instead of 15 minutes.
+	btcTarget := btcdBlockchain.CompactToBig(retargetBits)
+	btcTarget.Mul(btcTarget, big.NewInt(15))
+	btcTarget.Div(btcTarget, big.NewInt(40))
+	err := chain.connect(t, chain.next(btcdBlockchain.BigToCompact(btcTarget), 5*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	target = btcdBlockchain.CompactToBig(retargetBits)
+	target.Mul(target, big.NewInt(35))
+	target.Div(target, big.NewInt(40))
+	require.NoError(t, chain.connect(t, chain.next(btcdBlockchain.BigToCompact(target), 5*time.Minute)))
+}
+
+func TestDifficultyTestnetMinDifficulty(t *testing.T) {
+	// The regular difficulty is higher than the minimum difficulty.
+	const bits = 0x2000ffff
+	chain := newTestChain(true, bits)
+	powLimitBits := chain.net.PowLimitBits
+	require.NotEqual(t, uint32(bits), powLimitBits)
+
+	require.NoError(t, chain.connect(t, chain.next(bits, 10*time.Minute)))
+	// A min difficulty block is only allowed after twice the target block time.
+	err := chain.connect(t, chain.next(powLimitBits, 20*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(powLimitBits, 21*time.Minute)))
+	// Then the difficulty goes back to the one of the last regular block.
+	err = chain.connect(t, chain.next(powLimitBits, 10*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(bits, 10*time.Minute)))
+}
+
+func TestCustomSignet(t *testing.T) {
+	chain := newTestChain(false, 0x207fffff)
+	bits := chain.headers[0].Bits
+	chain.net.Name = netparams.SigNetParams.Name
+	chain.net.Net = netparams.SigNetParams.Net
+	require.NoError(t, chain.connect(t, chain.next(bits, 5*time.Minute)))
+
+	customSignet := chaincfg.CustomSignetParams([]byte{txscript.OP_TRUE}, nil)
+	chain.net.Net = customSignet.Net
+	err := chain.connect(t, chain.next(bits, 5*time.Minute))
+	require.Equal(t, errCustomSignet, errp.Cause(err))
+}
+
+func TestDifficultyBIP94(t *testing.T) {
+	const bits = 0x2000ffff
+	chain := newTestChain(true, bits)
+	chain.net.Net = netparams.TestNet4
+	powLimitBits := chain.net.PowLimitBits
+
+	require.NoError(t, chain.connect(t, chain.next(bits, 10*time.Minute)))
+	require.NoError(t, chain.connect(t, chain.next(powLimitBits, 21*time.Minute)))
+	require.NoError(t, chain.connect(t, chain.next(powLimitBits, 21*time.Minute)))
+
+	// The retarget is based on the difficulty of the first block of the period, not on the
+	// minimum difficulty of the last one. The period took 52 minutes instead of 40 minutes.
+	target := btcdBlockchain.CompactToBig(bits)
+	target.Mul(target, big.NewInt(52))
+	target.Div(target, big.NewInt(40))
+	retargetBits := btcdBlockchain.BigToCompact(target)
+	err := chain.connect(t, chain.next(powLimitBits, 10*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(retargetBits, 10*time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, chain.connect(t, chain.next(retargetBits, 10*time.Minute)))
+	}
+	// The first block of a period can't be timestamped more than 10 minutes before the previous
+	// block.
+	target = btcdBlockchain.CompactToBig(retargetBits)
+	target.Mul(target, big.NewInt(30))
+	target.Div(target, big.NewInt(40))
+	retargetBits = btcdBlockchain.BigToCompact(target)
+	err = chain.connect(t, chain.next(retargetBits, -11*time.Minute))
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.NoError(t, chain.connect(t, chain.next(retargetBits, -10*time.Minute)))
+}
+
+func TestPrune(t *testing.T) {
+	chain := newTestChain(false, 0x207fffff)
+	for len(chain.headers) < 250 {
+		chain.headers = append(chain.headers, chain.next(chain.headers[0].Bits, 10*time.Minute))
+	}
+	log := (&logrus.Logger{}).WithField("group", "headers_test")
+	db, err := headersdb.NewDB(test.TstTempFile("headers"), log)
+	require.NoError(t, err)
+	for height, header := range chain.headers {
+		require.NoError(t, db.PutHeader(height, header))
+	}
+	// The server returns at most 30 headers per request.
+	requestedHeaders := 0
+	tamperedHeight := -1
+	blockchainMock := &mocks.BlockchainMock{
+		MockHeaders: func(startHeight int, count int) (*blockchain.HeadersResult, error) {
+			result := []*wire.BlockHeader{}
+			for height := startHeight; height < min(startHeight+min(count, 30), len(chain.headers)); height++ {
+				header := *chain.headers[height]
+				if height == tamperedHeight {
+					header.Nonce++
+				}
+				result = append(result, &header)
+			}
+			requestedHeaders += len(result)
+			return &blockchain.HeadersResult{Headers: result, Max: 30}, nil
+		},
+	}
+	headers := NewHeaders(chain.net, db, blockchainMock, log)
+	defer func() { require.NoError(t, headers.Close()) }()
+	const tip = 249
+
+	// Disabled by default.
+	headers.maybePrune(db, tip)
+	require.Equal(t, 0, db.PrunedBelow())
+
+	// At least one difficulty period of 4 blocks plus ReorgLimit blocks are kept, rounded down to
+	// the start of a difficulty period, minus one.
+	headers.SetPruneDepth(1)
+	headers.maybePrune(db, tip)
+	require.Equal(t, 143, db.PrunedBelow())
+
+	status, err := headers.Status()
+	require.NoError(t, err)
+	require.Equal(t, tip, status.Tip)
+	require.Equal(t, blockchain.TXHash(chain.headers[tip].BlockHash()), status.TipHashHex)
+	header, err := headers.VerifiedHeaderByHeight(143)
+	require.NoError(t, err)
+	require.Equal(t, chain.headers[143], header)
+	require.Equal(t, 0, requestedHeaders)
+
+	// A pruned header is downloaded again, so transactions below the pruned height can still be
+	// verified. Headers which do not link to the stored headers are rejected.
+	tamperedHeight = 120
+	_, err = headers.VerifiedHeaderByHeight(100)
+	require.Equal(t, errInvalidHeader, errp.Cause(err))
+	require.Equal(t, 143, db.PrunedBelow())
+	tamperedHeight = -1
+	requestedHeaders = 0
+	header, err = headers.VerifiedHeaderByHeight(100)
+	require.NoError(t, err)
+	require.Equal(t, chain.headers[100], header)
+	require.Equal(t, 43, requestedHeaders)
+	require.Equal(t, 100, db.PrunedBelow())
+	// The restored headers are kept until the next pruning.
+	requestedHeaders = 0
+	header, err = headers.VerifiedHeaderByHeight(120)
+	require.NoError(t, err)
+	require.Equal(t, chain.headers[120], header)
+	require.Equal(t, 0, requestedHeaders)
+	headers.maybePrune(db, tip)
+	require.Equal(t, 143, db.PrunedBelow())
+
+	// A reorg within the kept headers.
+	headers.reorg(db, tip)
+	require.Equal(t, tip-ReorgLimit, headers.TipHeight())
+	require.Equal(t, 143, db.PrunedBelow())
+
+	// A reorg below the pruned headers downloads all headers again.
+	headers.reorg(db, tip-ReorgLimit)
+	require.Equal(t, -1, headers.TipHeight())
+	require.Equal(t, 0, db.PrunedBelow())
+}
+
+func TestBirthday(t *testing.T) {
+	chain := newTestChain(false, 0x207fffff)
+	// Each difficulty period takes exactly the target timespan, so the difficulty stays the same.
+	for len(chain.headers) < 245 {
+		require.NoError(t, chain.connect(t, chain.next(chain.headers[0].Bits, 800*time.Second)))
+	}
+	const checkpointHeight = 201
+	checkpointHash := chain.headers[checkpointHeight].BlockHash()
+	log := (&logrus.Logger{}).WithField("group", "headers_test")
+
+	// run syncs the headers with the given birthday, `synced` headers being stored already. The
+	// server returns at most 30 headers per request, and a wrong header at `tamperedHeight`. It
+	// waits for the event and returns the lowest requested height.
+	run := func(
+		t *testing.T, birthday int, synced int, tamperedHeight int, waitFor Event,
+	) (*Headers, *headersdb.DB, int) {
+		t.Helper()
+		db, err := headersdb.NewDB(test.TstTempFile("headers"), log)
+		require.NoError(t, err)
+		for height := 0; height < synced; height++ {
+			require.NoError(t, db.PutHeader(height, chain.headers[height]))
+		}
+		var lock sync.Mutex
+		lowestRequested := len(chain.headers)
+		blockchainMock := &mocks.BlockchainMock{
+			MockHeaders: func(startHeight int, count int) (*blockchain.HeadersResult, error) {
+				lock.Lock()
+				lowestRequested = min(lowestRequested, startHeight)
+				lock.Unlock()
+				result := []*wire.BlockHeader{}
+				for height := startHeight; height < min(startHeight+min(count, 30), len(chain.headers)); height++ {
+					header := *chain.headers[height]
+					if height == tamperedHeight {
+						header.Nonce++
+					}
+					result = append(result, &header)
+				}
+				return &blockchain.HeadersResult{Headers: result, Max: 30}, nil
+			},
+		}
+		headers := NewHeaders(chain.net, db, blockchainMock, log)
+		headers.testCheckpoint = &chaincfg.Checkpoint{Height: checkpointHeight, Hash: &checkpointHash}
+		headers.SetBirthday(birthday)
+		events := make(chan Event, 100)
+		headers.SubscribeEvent(func(event Event) { events <- event })
+		headers.Initialize()
+		timeout := time.After(5 * time.Second)
+	wait:
+		for {
+			select {
+			case event := <-events:
+				if event == waitFor {
+					break wait
+				}
+			case <-timeout:
+				require.Fail(t, "expected event", waitFor)
+				break wait
+			}
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		return headers, db, lowestRequested
+	}
+
+	t.Run("after the checkpoint", func(t *testing.T) {
+		headers, db, lowestRequested := run(t, 210, 0, -1, EventSynced)
+		defer func() { require.NoError(t, headers.Close()) }()
+		require.Equal(t, 244, headers.TipHeight())
+		// The sync started at the difficulty period of the checkpoint.
+		require.Equal(t, 199, db.PrunedBelow())
+		require.Equal(t, 199, lowestRequested)
+		// Headers before the birthday are still downloaded if needed.
+		header, err := headers.VerifiedHeaderByHeight(100)
+		require.NoError(t, err)
+		require.Equal(t, chain.headers[100], header)
+	})
+
+	t.Run("set after the sync started", func(t *testing.T) {
+		headers, db, lowestRequested := run(t, 210, 50, -1, EventSynced)
+		defer func() { require.NoError(t, headers.Close()) }()
+		require.Equal(t, 244, headers.TipHeight())
+		require.Equal(t, 199, db.PrunedBelow())
+		require.Equal(t, 199, lowestRequested)
+	})
+
+	t.Run("before the checkpoint", func(t *testing.T) {
+		headers, db, lowestRequested := run(t, 150, 0, -1, EventSynced)
+		defer func() { require.NoError(t, headers.Close()) }()
+		require.Equal(t, 244, headers.TipHeight())
+		require.Equal(t, 0, db.PrunedBelow())
+		require.Equal(t, 0, lowestRequested)
+	})
+
+	t.Run("not linked to the checkpoint", func(t *testing.T) {
+		headers, db, _ := run(t, 210, 0, 200, EventInvalidHeaders)
+		defer func() { require.NoError(t, headers.Close()) }()
+		tip, err := db.Tip()
+		require.NoError(t, err)
+		require.Equal(t, -1, tip)
+		require.Equal(t, 0, db.PrunedBelow())
+	})
+}
@@ -14,7 +14,12 @@ import (
 // feeForSerializeSize calculates the required fee for a transaction of some
 // arbitrary size given a mempool's relay fee policy.
 func feeForSerializeSize(relayFeePerKb btcutil.Amount, txSerializeSize int, log *logrus.Entry) btcutil.Amount {
-	fee := relayFeePerKb * btcutil.Amount(txSerializeSize) / 1000
+	fee, err := mulAmount(relayFeePerKb, int64(txSerializeSize))
+	if err != nil {
+		fee = btcutil.MaxSatoshi
+	} else {
+		fee /= 1000
+	}
 
 	if fee == 0 && relayFeePerKb > 0 {
 		fee = relayFeePerKb
@@ -45,5 +50,10 @@ func isDustAmount(
 
 	// Dust is defined as an output value where the total cost to the network
 	// (output size + input size) is greater than 1/3 of the relay fee.
-	return int64(amount)*1000/(3*int64(totalSize)) < int64(relayFeePerKb)
+	scaledAmount, err := mulAmount(amount, 1000)
+	if err != nil {
+		// Way too large to be dust.
+		return false
+	}
+	return int64(scaledAmount)/(3*int64(totalSize)) < int64(relayFeePerKb)
 }
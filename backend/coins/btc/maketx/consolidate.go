@@ -0,0 +1,57 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxConsolidationInputs caps the number of inputs of a consolidation transaction. With the largest
+// supported inputs (P2PKH, 148 vbytes), the transaction stays below the standard size limit of
+// 100000 vbytes.
+const MaxConsolidationInputs = 600
+
+// InputFee returns the fee needed to spend an output of the given configuration as an input, at
+// the given fee rate.
+func InputFee(configuration *signing.Configuration, feePerKb btcutil.Amount, log *logrus.Entry) btcutil.Amount {
+	sigScriptSize, witnessSize := sigScriptWitnessSize(configuration)
+	const nonWitness = 4
+	weight := nonWitness*calcInputSize(sigScriptSize) + witnessSize
+	return feeForSerializeSize(feePerKb, (weight+3)/4, log)
+}
+
+// ConsolidationSaving estimates the fee saved when spending the output of a consolidation
+// transaction in a future transaction instead of the outputs it consolidated, at the fee rate
+// expected for the future transaction. Consolidating is only worth it if the saving exceeds the
+// fee of the consolidation transaction, which is the case only if the future fee rate is higher
+// than the fee rate of the consolidation.
+//
+// inputConfigurations are the configurations of the consolidated outputs, outputConfiguration the
+// configuration of the consolidation output.
+func ConsolidationSaving(
+	inputConfigurations []*signing.Configuration,
+	outputConfiguration *signing.Configuration,
+	futureFeePerKb btcutil.Amount,
+	log *logrus.Entry,
+) btcutil.Amount {
+	// The outputs of the future transaction are the same in both cases and do not matter.
+	withoutConsolidation := feeForSerializeSize(
+		futureFeePerKb, estimateTxSize(inputConfigurations, 0, 0), log)
+	withConsolidation := feeForSerializeSize(
+		futureFeePerKb, estimateTxSize([]*signing.Configuration{outputConfiguration}, 0, 0), log)
+	return withoutConsolidation - withConsolidation
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"testing"
+
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputFee(t *testing.T) {
+	log := logging.Get().WithGroup("maketx_test")
+	const feePerKb = btcutil.Amount(10000) // 10 sat/vB
+	for scriptType, vsize := range map[signing.ScriptType]btcutil.Amount{
+		signing.ScriptTypeP2PKH:      148,
+		signing.ScriptTypeP2WPKHP2SH: 91,
+		signing.ScriptTypeP2WPKH:     68,
+		signing.ScriptTypeP2TR:       58,
+	} {
+		configuration := addressesTest.GetAddress(scriptType).Configuration
+		require.Equal(t, vsize*10, maketx.InputFee(configuration, feePerKb, log), scriptType)
+	}
+}
+
+func TestConsolidationSaving(t *testing.T) {
+	log := logging.Get().WithGroup("maketx_test")
+	p2wpkh := addressesTest.GetAddress(signing.ScriptTypeP2WPKH).Configuration
+	inputConfigurations := make([]*signing.Configuration, 10)
+	for i := range inputConfigurations {
+		inputConfigurations[i] = p2wpkh
+	}
+	// 691 vbytes for 10 P2WPKH inputs vs. 79 vbytes for one, at 10 sat/vB.
+	require.Equal(t,
+		btcutil.Amount(6120),
+		maketx.ConsolidationSaving(inputConfigurations, p2wpkh, 10000, log))
+	// Nothing to save when there is nothing to consolidate.
+	require.Equal(t,
+		btcutil.Amount(0),
+		maketx.ConsolidationSaving(inputConfigurations[:1], p2wpkh, 10000, log))
+}
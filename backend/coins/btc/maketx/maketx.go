@@ -29,6 +29,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
 )
@@ -53,6 +54,13 @@ type TxProposal struct {
 	// ChangeAddress is the address of the wallet to which the change of the transaction is sent.
 	ChangeAddress   *addresses.AccountAddress
 	PreviousOutputs PreviousOutputs
+	// SigHashTypes optionally overrides the sighash type of the inputs spending the given outputs,
+	// e.g. txscript.SigHashAll | txscript.SigHashAnyOneCanPay for an input contributed to a
+	// collaborative transaction. The other inputs are signed with
+	// btc.ProposedTransaction.SigHashType.
+	SigHashTypes map[wire.OutPoint]txscript.SigHashType
+	// SelectionTrace records how the inputs were selected, see SelectionTrace.
+	SelectionTrace *SelectionTrace
 }
 
 // Total is amount+fee.
@@ -75,21 +83,34 @@ func (p *byValue) Len() int { return len(p.outPoints) }
 func (p *byValue) Less(i, j int) bool {
 	if p.outputs[p.outPoints[i]].TxOut.Value == p.outputs[p.outPoints[j]].TxOut.Value {
 		// Secondary sort to make coin selection deterministic.
-		return chainhash.HashH(p.outputs[p.outPoints[i]].TxOut.PkScript).String() < chainhash.HashH(p.outputs[p.outPoints[j]].TxOut.PkScript).String()
+		scriptHashI := chainhash.HashH(p.outputs[p.outPoints[i]].TxOut.PkScript).String()
+		scriptHashJ := chainhash.HashH(p.outputs[p.outPoints[j]].TxOut.PkScript).String()
+		if scriptHashI == scriptHashJ {
+			// Outputs of the same value to the same address.
+			return p.outPoints[i].String() < p.outPoints[j].String()
+		}
+		return scriptHashI < scriptHashJ
 	}
 	return p.outputs[p.outPoints[i]].TxOut.Value < p.outputs[p.outPoints[j]].TxOut.Value
 }
 func (p *byValue) Swap(i, j int) { p.outPoints[i], p.outPoints[j] = p.outPoints[j], p.outPoints[i] }
 
-func coinSelection(
-	minAmount btcutil.Amount,
-	outputs map[wire.OutPoint]UTXO,
-) (btcutil.Amount, []wire.OutPoint, error) {
-	outPoints := []wire.OutPoint{}
+// sortedByValue returns the outpoints of the outputs, the largest output first. Outputs of the same
+// value are sorted deterministically.
+func sortedByValue(outputs map[wire.OutPoint]UTXO) []wire.OutPoint {
+	outPoints := make([]wire.OutPoint, 0, len(outputs))
 	for outPoint := range outputs {
 		outPoints = append(outPoints, outPoint)
 	}
 	sort.Sort(sort.Reverse(&byValue{outPoints, outputs}))
+	return outPoints
+}
+
+func coinSelection(
+	minAmount btcutil.Amount,
+	outputs map[wire.OutPoint]UTXO,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	outPoints := sortedByValue(outputs)
 	selectedOutPoints := []wire.OutPoint{}
 	outputsSum := btcutil.Amount(0)
 
@@ -98,7 +119,11 @@ func coinSelection(
 			break
 		}
 		selectedOutPoints = append(selectedOutPoints, outPoint)
-		outputsSum += btcutil.Amount(outputs[outPoint].TxOut.Value)
+		var err error
+		outputsSum, err = addAmounts(outputsSum, btcutil.Amount(outputs[outPoint].TxOut.Value))
+		if err != nil {
+			return 0, nil, err
+		}
 	}
 	if outputsSum < minAmount {
 		return 0, nil, errp.WithStack(errors.ErrInsufficientFunds)
@@ -125,6 +150,8 @@ func setRBF(coin coinpkg.Coin, tx *wire.MsgTx) {
 	for _, txIn := range tx.TxIn {
 		if coin.Code() == coinpkg.CodeBTC ||
 			coin.Code() == coinpkg.CodeTBTC ||
+			coin.Code() == coinpkg.CodeTBTC4 ||
+			coin.Code() == coinpkg.CodeSBTC ||
 			coin.Code() == coinpkg.CodeRBTC {
 			// Enable RBF
 			// https://github.com/bitcoin/bips/blob/master/bip-0125.mediawiki#summary
@@ -135,81 +162,166 @@ func setRBF(coin coinpkg.Coin, tx *wire.MsgTx) {
 	}
 }
 
-// NewTxSpendAll creates a transaction which spends all available unspent outputs.
+// dataOutputSize returns the size of the given OP_RETURN output, or 0 if it is nil.
+func dataOutputSize(dataOutput *wire.TxOut) int {
+	if dataOutput == nil {
+		return 0
+	}
+	return outputSize(len(dataOutput.PkScript))
+}
+
+// SpendAllAmount computes the maximum amount that can be sent to a single output when spending all
+// the given unspent outputs at the given fee rate, so that the sum of the inputs equals the amount
+// plus the fee and there is no change output. dataOutput is an optional (nil) OP_RETURN output, see
+// NewDataOutput().
+//
+// The fee is computed once for the transaction without a change output. The amount does not
+// affect the size of the transaction, as output values are always 8 bytes, and an amount below the
+// dust limit is rejected instead of dropping the output, see NewTxSpendAll().
+func SpendAllAmount(
+	spendableOutputs map[wire.OutPoint]UTXO,
+	outputPkScriptSize int,
+	dataOutput *wire.TxOut,
+	feePerKb btcutil.Amount,
+	log *logrus.Entry,
+) (btcutil.Amount, btcutil.Amount, error) {
+	outPoints := make([]wire.OutPoint, 0, len(spendableOutputs))
+	outputsSum := btcutil.Amount(0)
+	for outPoint, output := range spendableOutputs {
+		outPoints = append(outPoints, outPoint)
+		var err error
+		outputsSum, err = addAmounts(outputsSum, btcutil.Amount(output.TxOut.Value))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	inputConfigurations := toInputConfigurations(spendableOutputs, outPoints)
+
+	txSize := estimateTxSize(inputConfigurations, outputPkScriptSize, 0) + dataOutputSize(dataOutput)
+	fee := feeForSerializeSize(feePerKb, txSize, log)
+	if outputsSum < fee {
+		return 0, 0, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	return outputsSum - fee, fee, nil
+}
+
+// NewTxSpendAll creates a transaction which spends all available unspent outputs to a single
+// output, without change. See SpendAllAmount().
+//
+// seed: seeds the shuffling of the inputs and outputs, see NewTx().
 func NewTxSpendAll(
 	coin coinpkg.Coin,
 	spendableOutputs map[wire.OutPoint]UTXO,
 	outputPkScript []byte,
+	dataOutput *wire.TxOut,
 	feePerKb btcutil.Amount,
+	seed *int64,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
-	selectedOutPoints := []wire.OutPoint{}
+	amount, fee, err := SpendAllAmount(spendableOutputs, len(outputPkScript), dataOutput, feePerKb, log)
+	if err != nil {
+		return nil, err
+	}
+	trace := &SelectionTrace{Seed: selectionSeed(seed), Change: ChangeDecisionNone}
 	inputs := []*wire.TxIn{}
 	previousOutputs := make(PreviousOutputs, len(spendableOutputs))
-	outputsSum := btcutil.Amount(0)
-	for outPoint, output := range spendableOutputs {
+	// Sorted so that the shuffled order only depends on the seed.
+	for _, outPoint := range sortedByValue(spendableOutputs) {
 		outPoint := outPoint // avoid reference reuse due to range loop
-		selectedOutPoints = append(selectedOutPoints, outPoint)
-		outputsSum += btcutil.Amount(output.TxOut.Value)
 		inputs = append(inputs, wire.NewTxIn(&outPoint, nil, nil))
 		previousOutputs[outPoint] = &transactions.SpendableOutput{
 			TxOut: spendableOutputs[outPoint].TxOut,
 		}
+		trace.Candidates = append(trace.Candidates,
+			newSelectionCandidate(outPoint, spendableOutputs[outPoint], true, ""))
 	}
-	txSize := estimateTxSize(
-		toInputConfigurations(spendableOutputs, selectedOutPoints),
-		len(outputPkScript),
-		0)
-	maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
-	if outputsSum < maxRequiredFee {
-		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	output := wire.NewTxOut(int64(amount), outputPkScript)
+	// E.g. if all that is left after the fee is a few sats.
+	if err := ValidateOutput(output, amount+fee); err != nil {
+		return nil, err
 	}
-	output := wire.NewTxOut(int64(outputsSum-maxRequiredFee), outputPkScript)
 	unsignedTransaction := &wire.MsgTx{
 		Version:  wire.TxVersion,
 		TxIn:     inputs,
 		TxOut:    []*wire.TxOut{output},
 		LockTime: 0,
 	}
+	if dataOutput != nil {
+		unsignedTransaction.TxOut = append(unsignedTransaction.TxOut, dataOutput)
+	}
+	if err := checkDataOutputs(unsignedTransaction); err != nil {
+		return nil, err
+	}
 
-	secureRand := mrand.New(mrand.NewSource(secureSeed()))
-	shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+	shuffleTxInputsAndOutputs(unsignedTransaction, mrand.New(mrand.NewSource(trace.Seed)))
 
-	log.WithField("fee", maxRequiredFee).Debug("Preparing transaction to spend all outputs")
+	log.WithField("fee", fee).Debug("Preparing transaction to spend all outputs")
 
 	setRBF(coin, unsignedTransaction)
 	return &TxProposal{
 		Coin:            coin,
-		Amount:          btcutil.Amount(output.Value),
-		Fee:             maxRequiredFee,
+		Amount:          amount,
+		Fee:             fee,
 		Transaction:     unsignedTransaction,
 		PreviousOutputs: previousOutputs,
+		SelectionTrace:  trace,
 	}, nil
 }
 
-// NewTx creates a transaction from a set of unspent outputs, targeting an output value. A subset of
-// the unspent outputs is selected to cover the needed amount.
+// NewTx creates a transaction from a set of unspent outputs, targeting the sum of the values of the
+// outputs. A subset of the unspent outputs is selected to cover the needed amount. The outputs
+// should be validated using ValidateOutput first.
 //
+// outputs: the outputs paying the recipients, at least one.
+// dataOutput: an optional (nil) OP_RETURN output, see NewDataOutput().
 // changeAddress: a change output to this address is added if needed.
+// seed: seeds the shuffling of the inputs and outputs. If nil, a secure random seed is used. Pass a
+// seed only to reproduce a transaction, e.g. in tests, as a known order hurts privacy.
 func NewTx(
 	coin coinpkg.Coin,
 	spendableOutputs map[wire.OutPoint]UTXO,
-	output *wire.TxOut,
+	outputs []*wire.TxOut,
+	dataOutput *wire.TxOut,
 	feePerKb btcutil.Amount,
 	changeAddress *addresses.AccountAddress,
+	seed *int64,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
-	targetAmount := btcutil.Amount(output.Value)
-	if targetAmount <= 0 {
-		panic("amount must be positive")
+	if len(outputs) == 0 {
+		return nil, errp.New("no outputs")
+	}
+	var targetAmount btcutil.Amount
+	for _, output := range outputs {
+		amount := btcutil.Amount(output.Value)
+		if amount < 0 || (amount == 0 && !txscript.IsNullData(output.PkScript)) {
+			return nil, errp.WithStack(errors.ErrInvalidAmount)
+		}
+		var err error
+		targetAmount, err = addAmounts(targetAmount, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The size of the outputs except the first one, which is accounted for by estimateTxSize().
+	additionalOutputsSize := dataOutputSize(dataOutput)
+	for _, output := range outputs[1:] {
+		additionalOutputsSize += outputSize(len(output.PkScript))
+	}
+	txOutputs := append([]*wire.TxOut{}, outputs...)
+	if dataOutput != nil {
+		txOutputs = append(txOutputs, dataOutput)
 	}
-	outputs := []*wire.TxOut{output}
 	changePKScript := changeAddress.PubkeyScript()
 
+	trace := &SelectionTrace{Seed: selectionSeed(seed)}
 	targetFee := btcutil.Amount(0)
 	for {
+		minAmount, err := addAmounts(targetAmount, targetFee)
+		if err != nil {
+			return nil, err
+		}
 		selectedOutputsSum, selectedOutPoints, err := coinSelection(
-			targetAmount+targetFee,
+			minAmount,
 			spendableOutputs,
 		)
 		if err != nil {
@@ -218,13 +330,23 @@ func NewTx(
 
 		txSize := estimateTxSize(
 			toInputConfigurations(spendableOutputs, selectedOutPoints),
-			len(output.PkScript),
-			len(changePKScript))
+			len(outputs[0].PkScript),
+			len(changePKScript)) + additionalOutputsSize
 		maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
 		if selectedOutputsSum-targetAmount < maxRequiredFee {
 			targetFee = maxRequiredFee
+			trace.FeeRounds++
 			continue
 		}
+		for i, outPoint := range sortedByValue(spendableOutputs) {
+			if i < len(selectedOutPoints) {
+				trace.Candidates = append(trace.Candidates,
+					newSelectionCandidate(outPoint, spendableOutputs[outPoint], true, ""))
+			} else {
+				trace.Candidates = append(trace.Candidates,
+					newSelectionCandidate(outPoint, spendableOutputs[outPoint], false, SelectionReasonTargetReached))
+			}
+		}
 
 		inputs := make([]*wire.TxIn, len(selectedOutPoints))
 		previousOutputs := make(PreviousOutputs, len(selectedOutPoints))
@@ -238,26 +360,41 @@ func NewTx(
 		unsignedTransaction := &wire.MsgTx{
 			Version:  wire.TxVersion,
 			TxIn:     inputs,
-			TxOut:    outputs,
+			TxOut:    txOutputs,
 			LockTime: 0,
 		}
 		changeAmount := selectedOutputsSum - targetAmount - maxRequiredFee
+		// Change which costs more to spend than it is worth at the current fee rate, or which is
+		// below the dust threshold of the relay policy, is added to the fee instead.
 		changeIsDust := isDustAmount(
-			changeAmount, len(changePKScript), changeAddress.Configuration, feePerKb)
+			changeAmount, len(changePKScript), changeAddress.Configuration, feePerKb) ||
+			changeAmount < DustThreshold(changePKScript)
 		finalFee := maxRequiredFee
 		if changeIsDust {
 			log.Info("change is dust")
 			finalFee = selectedOutputsSum - targetAmount
 		}
-		if changeAmount != 0 && !changeIsDust {
+		switch {
+		case changeAmount != 0 && !changeIsDust:
 			unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
 				wire.NewTxOut(int64(changeAmount), changePKScript))
-		} else {
+			trace.Change = ChangeDecisionAdded
+		case changeIsDust:
 			changeAddress = nil
+			trace.Change = ChangeDecisionDust
+		default:
+			changeAddress = nil
+			trace.Change = ChangeDecisionNone
 		}
 
-		secureRand := mrand.New(mrand.NewSource(secureSeed()))
-		shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+		if err := checkDust(unsignedTransaction); err != nil {
+			return nil, err
+		}
+		if err := checkDataOutputs(unsignedTransaction); err != nil {
+			return nil, err
+		}
+
+		shuffleTxInputsAndOutputs(unsignedTransaction, mrand.New(mrand.NewSource(trace.Seed)))
 
 		log.WithField("fee", finalFee).Debug("Preparing transaction")
 
@@ -269,6 +406,7 @@ func NewTx(
 			Transaction:     unsignedTransaction,
 			ChangeAddress:   changeAddress,
 			PreviousOutputs: previousOutputs,
+			SelectionTrace:  trace,
 		}, nil
 	}
 }
@@ -286,6 +424,14 @@ func shuffleTxInputsAndOutputs(tx *wire.MsgTx, secureRand *mrand.Rand) {
 	})
 }
 
+// selectionSeed returns the given seed, or a secure seed if it is nil.
+func selectionSeed(seed *int64) int64 {
+	if seed != nil {
+		return *seed
+	}
+	return secureSeed()
+}
+
 // secureSeed generates a secure seed value.
 func secureSeed() int64 {
 	var b [8]byte
@@ -15,9 +15,11 @@
 package maketx
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 )
@@ -58,3 +60,29 @@ func TestShuffleTxInputsAndOutputs(t *testing.T) {
 	require.Equal(t, expectedSortedIns, tx.TxIn, "The transaction inputs were not successfully shuffled.")
 	require.Equal(t, expectedSortedOuts, tx.TxOut, "The transaction outputs were not successfully shuffled.")
 }
+
+func TestCheckedAmounts(t *testing.T) {
+	sum, err := addAmounts(1, 2)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(3), sum)
+	_, err = addAmounts(math.MaxInt64, 1)
+	require.Error(t, err)
+	_, err = addAmounts(math.MinInt64, -1)
+	require.Error(t, err)
+	sum, err = addAmounts(math.MaxInt64, -1)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(math.MaxInt64-1), sum)
+
+	product, err := mulAmount(btcutil.MaxSatoshi, 1000)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(btcutil.MaxSatoshi*1000), product)
+	product, err = mulAmount(0, math.MaxInt64)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(0), product)
+	_, err = mulAmount(math.MaxInt64, 2)
+	require.Error(t, err)
+	_, err = mulAmount(math.MinInt64, -1)
+	require.Error(t, err)
+	_, err = mulAmount(-1, math.MinInt64)
+	require.Error(t, err)
+}
@@ -17,6 +17,7 @@ package maketx_test
 
 import (
 	"bytes"
+	"math"
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
@@ -33,15 +34,17 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 var noDust = btcutil.Amount(0)
 
-var tltc = btc.NewCoin(coin.CodeTLTC, "Litecoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "", socksproxy.NewSocksProxy(false, ""))
-var tbtc = btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, "https://blockstream.info/testnet/tx/", socksproxy.NewSocksProxy(false, ""))
+var tltc = btc.NewCoin(coin.CodeTLTC, "Litecoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, config.TxFetchThrottle{}, coin.BlockExplorer{}, socksproxy.NewSocksProxy(false, ""))
+var tbtc = btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, &chaincfg.TestNet3Params, ".", []*config.ServerInfo{}, config.TxFetchThrottle{}, coin.BlockExplorer{}, socksproxy.NewSocksProxy(false, ""))
 
 // For reference, tx vsizes assuming two outputs (normal + change), for N inputs:
 // 1 inputs: 226
@@ -104,9 +107,11 @@ func (s *newTxSuite) newTx(
 	return maketx.NewTx(
 		s.coin,
 		utxo,
-		s.output(amount),
+		[]*wire.TxOut{s.output(amount)},
+		nil,
 		feePerKb,
 		s.changeAddress,
+		nil,
 		s.log,
 	)
 }
@@ -241,10 +246,12 @@ func (s *newTxSuite) TestNewTxNoFee() {
 	_, err := s.newTx(1, feePerKb, s.buildUTXO())
 	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
 
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1), s.change(0), noDust, s.selectCoins(0))
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2), s.change(1), noDust, s.selectCoins(1))
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2, 3), s.change(2), noDust, s.selectCoins(2))
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(2), s.change(1), noDust, s.selectCoins(0))
+	s.check(btcutil.Amount(1000), feePerKb, s.buildUTXO(1000), s.change(0), noDust, s.selectCoins(0))
+	s.check(btcutil.Amount(1000), feePerKb, s.buildUTXO(1000, 2000), s.change(1000), noDust, s.selectCoins(1))
+	s.check(btcutil.Amount(1000), feePerKb, s.buildUTXO(1000, 2000, 3000), s.change(2000), noDust, s.selectCoins(2))
+	s.check(btcutil.Amount(1000), feePerKb, s.buildUTXO(2000), s.change(1000), noDust, s.selectCoins(0))
+	// Change below the dust threshold is added to the fee, even if the fee rate is zero.
+	s.check(btcutil.Amount(1000), feePerKb, s.buildUTXO(1100), s.change(0), 100, s.selectCoins(0))
 }
 
 func (s *newTxSuite) TestNewTxDust() {
@@ -254,7 +261,7 @@ func (s *newTxSuite) TestNewTxDust() {
 	// spent.
 	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
 	const maxDust = 545              // dust threshold for a p2pkh change output.
-	for baseAmount := int64(500); baseAmount <= 5000000000; baseAmount += 5000000000 / 10 {
+	for baseAmount := int64(1000); baseAmount <= 5000000000; baseAmount += 5000000000 / 10 {
 		for dust := int64(0); dust <= maxDust; dust++ {
 			s.check(btcutil.Amount(baseAmount), feePerKb, s.buildUTXO(400, baseAmount+txSizeOneInput+dust, 450), s.change(0), btcutil.Amount(dust), s.selectCoins(1))
 		}
@@ -262,6 +269,14 @@ func (s *newTxSuite) TestNewTxDust() {
 	}
 }
 
+func (s *newTxSuite) TestNewTxDustOutput() {
+	threshold := maketx.DustThreshold(s.outputPkScript)
+	_, err := s.newTx(threshold-1, 0, s.buildUTXO(1e8))
+	s.Require().Equal(errors.ErrDustAmount, errp.Cause(err))
+	_, err = s.newTx(threshold, 0, s.buildUTXO(1e8))
+	s.Require().NoError(err)
+}
+
 func (s *newTxSuite) TestNewTxInsufficientFunds() {
 	const mBTC = 100000
 	amount := btcutil.Amount(1000 * mBTC) // 1 BTC
@@ -311,3 +326,217 @@ func (s *newTxSuite) TestNewTxCoinSelection() {
 	// coins: .5, .3, .1, .1, .9, .8, .6. select .5+.3+.1+.1 to get 1BTC, take .9 to cover the fees.
 	s.check(amount, feePerKb, s.buildUTXO(500*mBTC, 300*mBTC, 100*mBTC, 100*mBTC, 90*mBTC, 80*mBTC, 70*mBTC), s.change(90*mBTC-txSizeFiveInputs), noDust, s.selectCoins(0, 1, 2, 3, 4))
 }
+
+func (s *newTxSuite) TestNewTxSelectionSeed() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000)
+	utxo := s.buildUTXO(300*mBTC, 100*mBTC, 100*mBTC, 200*mBTC)
+	seed := int64(42)
+	txProposal, err := maketx.NewTx(
+		s.coin, utxo, []*wire.TxOut{s.output(350 * mBTC)}, nil, feePerKb, s.changeAddress, &seed, s.log)
+	s.Require().NoError(err)
+	trace := txProposal.SelectionTrace
+	s.Require().Equal(seed, trace.Seed)
+	s.Require().Equal(maketx.ChangeDecisionAdded, trace.Change)
+	s.Require().Equal(0, trace.FeeRounds)
+	s.Require().Equal([]maketx.SelectionCandidate{
+		{Outpoint: s.outpoint(0).String(), Value: 300 * mBTC, ScriptType: s.inputConfiguration.ScriptType(), Selected: true},
+		{Outpoint: s.outpoint(3).String(), Value: 200 * mBTC, ScriptType: s.inputConfiguration.ScriptType(), Selected: true},
+		{Outpoint: s.outpoint(2).String(), Value: 100 * mBTC, ScriptType: s.inputConfiguration.ScriptType(),
+			Reason: maketx.SelectionReasonTargetReached},
+		{Outpoint: s.outpoint(1).String(), Value: 100 * mBTC, ScriptType: s.inputConfiguration.ScriptType(),
+			Reason: maketx.SelectionReasonTargetReached},
+	}, trace.Candidates)
+
+	// The same seed results in the same transaction.
+	for i := 0; i < 10; i++ {
+		again, err := maketx.NewTx(
+			s.coin, utxo, []*wire.TxOut{s.output(350 * mBTC)}, nil, feePerKb, s.changeAddress, &seed, s.log)
+		s.Require().NoError(err)
+		s.Require().Equal(txProposal.Transaction, again.Transaction)
+		s.Require().Equal(trace, again.SelectionTrace)
+
+		spendAll, err := maketx.NewTxSpendAll(s.coin, utxo, s.outputPkScript, nil, feePerKb, &seed, s.log)
+		s.Require().NoError(err)
+		spendAllAgain, err := maketx.NewTxSpendAll(s.coin, utxo, s.outputPkScript, nil, feePerKb, &seed, s.log)
+		s.Require().NoError(err)
+		s.Require().Equal(spendAll.Transaction, spendAllAgain.Transaction)
+		s.Require().Equal(maketx.ChangeDecisionNone, spendAll.SelectionTrace.Change)
+		s.Require().Len(spendAll.SelectionTrace.Candidates, 4)
+	}
+}
+
+func (s *newTxSuite) TestNewTxZeroAmount() {
+	_, err := s.newTx(0, 0, s.buildUTXO(1e8))
+	s.Require().Equal(errors.ErrInvalidAmount, errp.Cause(err))
+	_, err = s.newTx(-1, 0, s.buildUTXO(1e8))
+	s.Require().Equal(errors.ErrInvalidAmount, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxOverflow() {
+	// The sum of the selected UTXOs overflows int64.
+	_, err := s.newTx(math.MaxInt64-5, 0, s.buildUTXO(math.MaxInt64-10, 100))
+	s.Require().Equal(errors.ErrInvalidAmount, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxSpendAllOneSat() {
+	_, err := maketx.NewTxSpendAll(s.coin, s.buildUTXO(1), s.outputPkScript, nil, 1000, nil, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+	// Without fee, the single sat remaining is dust.
+	_, err = maketx.NewTxSpendAll(s.coin, s.buildUTXO(1), s.outputPkScript, nil, 0, nil, s.log)
+	s.Require().Equal(errors.ErrDustAmount, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxSpendAll() {
+	const feePerKb = 1000
+	utxo := s.buildUTXO(1e8, 2e8)
+	// Dropping the change output of 34 bytes.
+	const txSizeTwoInputsNoChange = txSizeTwoInputs - 34
+
+	amount, fee, err := maketx.SpendAllAmount(utxo, len(s.outputPkScript), nil, feePerKb, s.log)
+	s.Require().NoError(err)
+	s.Require().Equal(btcutil.Amount(txSizeTwoInputsNoChange), fee)
+	s.Require().Equal(btcutil.Amount(3e8-txSizeTwoInputsNoChange), amount)
+
+	txProposal, err := maketx.NewTxSpendAll(s.coin, utxo, s.outputPkScript, nil, feePerKb, nil, s.log)
+	s.Require().NoError(err)
+	s.Require().Equal(amount, txProposal.Amount)
+	s.Require().Equal(fee, txProposal.Fee)
+	s.Require().Nil(txProposal.ChangeAddress)
+	s.Require().Len(txProposal.Transaction.TxIn, 2)
+	s.Require().Equal([]*wire.TxOut{s.output(amount)}, txProposal.Transaction.TxOut)
+	// The inputs are spent entirely by the output and the fee.
+	s.Require().Equal(btcutil.Amount(3e8), txProposal.Amount+txProposal.Fee)
+}
+
+func (s *newTxSuite) TestNewTxDataOutput() {
+	const feePerKb = 1000
+	utxo := s.buildUTXO(1e8, 2e8)
+	dataOutput, err := maketx.NewDataOutput([]byte("data"))
+	s.Require().NoError(err)
+	// 8 bytes value, 1 byte script length, OP_RETURN, push opcode and 4 bytes of data.
+	const dataOutputSize = 15
+
+	_, feeWithoutData, err := maketx.SpendAllAmount(utxo, len(s.outputPkScript), nil, feePerKb, s.log)
+	s.Require().NoError(err)
+	txProposal, err := maketx.NewTxSpendAll(s.coin, utxo, s.outputPkScript, dataOutput, feePerKb, nil, s.log)
+	s.Require().NoError(err)
+	s.Require().Equal(feeWithoutData+dataOutputSize, txProposal.Fee)
+	s.Require().Contains(txProposal.Transaction.TxOut, dataOutput)
+
+	txProposal, err = maketx.NewTx(
+		s.coin, utxo, []*wire.TxOut{s.output(1e8)}, dataOutput, feePerKb, s.changeAddress, nil, s.log)
+	s.Require().NoError(err)
+	s.Require().Len(txProposal.Transaction.TxOut, 3)
+	s.Require().Contains(txProposal.Transaction.TxOut, dataOutput)
+	s.Require().Equal(btcutil.Amount(1e8), txProposal.Amount)
+
+	// A second OP_RETURN output is non-standard.
+	_, err = maketx.NewTx(
+		s.coin, utxo, []*wire.TxOut{dataOutput}, dataOutput, feePerKb, s.changeAddress, nil, s.log)
+	s.Require().Equal(errors.ErrTooManyOpReturnOutputs, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxMultipleOutputs() {
+	const feePerKb = 1000
+	utxo := s.buildUTXO(1e8, 2e8)
+	secondPkScript := s.someAddresses[1].PubkeyScript()
+	outputs := []*wire.TxOut{s.output(5e7), wire.NewTxOut(7e7, secondPkScript)}
+
+	single, err := s.newTx(12e7, feePerKb, utxo)
+	s.Require().NoError(err)
+	txProposal, err := maketx.NewTx(
+		s.coin, utxo, outputs, nil, feePerKb, s.changeAddress, nil, s.log)
+	s.Require().NoError(err)
+	s.Require().Len(txProposal.Transaction.TxOut, 3)
+	for _, output := range outputs {
+		s.Require().Contains(txProposal.Transaction.TxOut, output)
+	}
+	s.Require().Equal(btcutil.Amount(12e7), txProposal.Amount)
+	// The additional output adds its size to the fee: 8 bytes value, 1 byte script length and the
+	// script.
+	s.Require().Equal(single.Fee+btcutil.Amount(9+len(secondPkScript)), txProposal.Fee)
+	var inputSum, outputSum int64
+	for _, txIn := range txProposal.Transaction.TxIn {
+		inputSum += utxo[txIn.PreviousOutPoint].TxOut.Value
+	}
+	for _, txOut := range txProposal.Transaction.TxOut {
+		outputSum += txOut.Value
+	}
+	s.Require().Equal(txProposal.Fee, btcutil.Amount(inputSum-outputSum))
+}
+
+func TestNewDataOutput(t *testing.T) {
+	output, err := maketx.NewDataOutput(make([]byte, txscript.MaxDataCarrierSize))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), output.Value)
+	require.True(t, txscript.IsNullData(output.PkScript))
+	pushes, err := txscript.PushedData(output.PkScript)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{make([]byte, txscript.MaxDataCarrierSize)}, pushes)
+
+	_, err = maketx.NewDataOutput(make([]byte, txscript.MaxDataCarrierSize+1))
+	require.Equal(t, errors.ErrOpReturnDataTooLarge, errp.Cause(err))
+}
+
+func TestValidateOutput(t *testing.T) {
+	// P2WPKH output, with a dust threshold of 294 sat.
+	pkScript := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x01}, 20)...)
+	opReturn := []byte{txscript.OP_RETURN, 0x01, 0x01}
+
+	for _, test := range []struct {
+		output      *wire.TxOut
+		maxAmount   btcutil.Amount
+		expectedErr error
+	}{
+		{wire.NewTxOut(0, opReturn), btcutil.MaxSatoshi, nil},
+		{wire.NewTxOut(1, opReturn), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+		{wire.NewTxOut(0, pkScript), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+		{wire.NewTxOut(-1, pkScript), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+		{wire.NewTxOut(math.MinInt64, pkScript), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+		{wire.NewTxOut(1, pkScript), btcutil.MaxSatoshi, errors.ErrDustAmount},
+		{wire.NewTxOut(293, pkScript), btcutil.MaxSatoshi, errors.ErrDustAmount},
+		{wire.NewTxOut(294, pkScript), btcutil.MaxSatoshi, nil},
+		{wire.NewTxOut(int64(btcutil.MaxSatoshi), pkScript), btcutil.MaxSatoshi, nil},
+		{wire.NewTxOut(int64(btcutil.MaxSatoshi)+1, pkScript), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+		{wire.NewTxOut(math.MaxInt64, pkScript), btcutil.MaxSatoshi, errors.ErrInvalidAmount},
+	} {
+		err := maketx.ValidateOutput(test.output, test.maxAmount)
+		if test.expectedErr == nil {
+			require.NoError(t, err, test.output.Value)
+		} else {
+			require.Equal(t, test.expectedErr, errp.Cause(err), test.output.Value)
+		}
+	}
+}
+
+func TestDustThreshold(t *testing.T) {
+	hash20 := bytes.Repeat([]byte{0x01}, 20)
+	hash32 := bytes.Repeat([]byte{0x01}, 32)
+	p2pkh := append(append([]byte{txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20}, hash20...),
+		txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	p2sh := append(append([]byte{txscript.OP_HASH160, txscript.OP_DATA_20}, hash20...), txscript.OP_EQUAL)
+	p2wpkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, hash20...)
+	p2wsh := append([]byte{txscript.OP_0, txscript.OP_DATA_32}, hash32...)
+	p2tr := append([]byte{txscript.OP_1, txscript.OP_DATA_32}, hash32...)
+
+	for _, test := range []struct {
+		name      string
+		pkScript  []byte
+		threshold btcutil.Amount
+	}{
+		{"p2pkh", p2pkh, 546},
+		{"p2sh", p2sh, 540},
+		{"p2wpkh", p2wpkh, 294},
+		{"p2wsh", p2wsh, 330},
+		{"p2tr", p2tr, 330},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.threshold, maketx.DustThreshold(test.pkScript))
+			require.Equal(t, errors.ErrDustAmount, errp.Cause(
+				maketx.ValidateOutput(wire.NewTxOut(int64(test.threshold)-1, test.pkScript), btcutil.MaxSatoshi)))
+			require.NoError(t,
+				maketx.ValidateOutput(wire.NewTxOut(int64(test.threshold), test.pkScript), btcutil.MaxSatoshi))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SelectionReason is the reason why a spendable output was not selected as an input.
+type SelectionReason string
+
+const (
+	// SelectionReasonTargetReached means the outputs selected before already covered the amount and
+	// fee.
+	SelectionReasonTargetReached SelectionReason = "targetReached"
+	// SelectionReasonUnconfirmed means the output does not have enough confirmations to be spent.
+	SelectionReasonUnconfirmed SelectionReason = "unconfirmed"
+	// SelectionReasonCoinControl means the output was not among the outputs selected by the user.
+	SelectionReasonCoinControl SelectionReason = "coinControl"
+	// SelectionReasonFlagged means the output is in the flag list of the account and is only spent if
+	// selected explicitly using coin control.
+	SelectionReasonFlagged SelectionReason = "flagged"
+	// SelectionReasonFrozen means the output was frozen by the user and is not spent.
+	SelectionReasonFrozen SelectionReason = "frozen"
+	// SelectionReasonDust means the output is below the dust threshold of the account and is hidden.
+	SelectionReasonDust SelectionReason = "dust"
+)
+
+// ChangeDecision describes what happened to the change of a transaction.
+type ChangeDecision string
+
+const (
+	// ChangeDecisionAdded means a change output was added.
+	ChangeDecisionAdded ChangeDecision = "added"
+	// ChangeDecisionDust means the change was too small to be worth an output and was added to the
+	// fee.
+	ChangeDecisionDust ChangeDecision = "dust"
+	// ChangeDecisionNone means there was no change, e.g. when spending all outputs.
+	ChangeDecisionNone ChangeDecision = "none"
+)
+
+// SelectionCandidate is a spendable output considered by the coin selection.
+type SelectionCandidate struct {
+	Outpoint   string             `json:"outpoint"`
+	Value      int64              `json:"value"`
+	ScriptType signing.ScriptType `json:"scriptType"`
+	Selected   bool               `json:"selected"`
+	// Reason is set if the output was not selected.
+	Reason SelectionReason `json:"reason,omitempty"`
+}
+
+// SelectionTrace is a compact record of the decisions made when building a transaction, so that
+// the choice of the inputs can be explained, and the transaction reproduced using the seed. It
+// contains no extended public keys.
+type SelectionTrace struct {
+	// Seed seeded the shuffling of the inputs and outputs. Building the transaction again with the
+	// same seed, UTXO set and parameters results in the same transaction.
+	Seed int64 `json:"seed,string"`
+	// FeeRounds is the number of times the coin selection was repeated with a higher fee target.
+	FeeRounds int `json:"feeRounds"`
+	// Candidates are the outputs in the order they were considered.
+	Candidates []SelectionCandidate `json:"candidates"`
+	Change     ChangeDecision       `json:"change"`
+}
+
+// AddRejected records a spendable output which was not passed to the coin selection.
+func (trace *SelectionTrace) AddRejected(outPoint wire.OutPoint, utxo UTXO, reason SelectionReason) {
+	trace.Candidates = append(trace.Candidates, newSelectionCandidate(outPoint, utxo, false, reason))
+}
+
+func newSelectionCandidate(
+	outPoint wire.OutPoint,
+	utxo UTXO,
+	selected bool,
+	reason SelectionReason,
+) SelectionCandidate {
+	return SelectionCandidate{
+		Outpoint:   outPoint.String(),
+		Value:      utxo.TxOut.Value,
+		ScriptType: utxo.Configuration.ScriptType(),
+		Selected:   selected,
+		Reason:     reason,
+	}
+}
@@ -129,3 +129,38 @@ func estimateTxSize(
 	}
 	return txWeight/4 + 1
 }
+
+// EstimateSignedTxSize gives the worst case size of the given unsigned tx once it is signed, in
+// vbytes. Unlike estimateTxSize, the outputs are taken from the tx itself. inputConfigurations
+// are the configurations of the addresses spent by the inputs, in the order of the inputs.
+func EstimateSignedTxSize(tx *wire.MsgTx, inputConfigurations []*signing.Configuration) int {
+	const nonWitness = 4
+	baseSize := tx.SerializeSizeStripped()
+	witnessWeight := 0
+	isSegwitTx := false
+	for _, inputConfiguration := range inputConfigurations {
+		_, witnessSize := sigScriptWitnessSize(inputConfiguration)
+		if witnessSize > 0 {
+			isSegwitTx = true
+			break
+		}
+	}
+	for index, txIn := range tx.TxIn {
+		sigScriptSize, witnessSize := sigScriptWitnessSize(inputConfigurations[index])
+		// Replace the current (empty) sigScript by the one of the signed input.
+		baseSize += calcInputSize(sigScriptSize) - calcInputSize(len(txIn.SignatureScript))
+		witnessWeight += witnessSize
+		if isSegwitTx && witnessSize == 0 {
+			witnessWeight += wire.VarIntSerializeSize(0)
+		}
+	}
+	txWeight := nonWitness*baseSize + witnessWeight
+	if isSegwitTx {
+		txWeight += 2 // segwit marker + segwit flag
+	}
+	// return txWeight/4 rounded up.
+	if txWeight%4 == 0 {
+		return txWeight / 4
+	}
+	return txWeight/4 + 1
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 )
@@ -87,7 +88,7 @@ func testEstimateTxSize(
 	for counter := 0; counter < 10; counter++ {
 		for _, inputScriptType := range inputScriptTypes {
 			inputAddress := addressesTest.GetAddress(inputScriptType)
-			sigScript, witness := inputAddress.SignatureScript(sig)
+			sigScript, witness := inputAddress.SignatureScript(sig, txscript.SigHashDefault)
 			tx.TxIn = append(tx.TxIn, &wire.TxIn{
 				SignatureScript: sigScript,
 				Witness:         witness,
@@ -112,6 +113,13 @@ func testEstimateTxSize(
 		len(outputPkScript), changePkScriptSize)
 	require.Equal(t, mempool.GetTxVirtualSize(btcutil.NewTx(tx)), int64(estimatedSize))
 
+	// The same estimate from the unsigned tx.
+	unsignedTx := tx.Copy()
+	for _, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	require.Equal(t, estimatedSize, EstimateSignedTxSize(unsignedTx, inputConfigurations))
 }
 
 func TestSigScriptWitnessSize(t *testing.T) {
@@ -122,7 +130,7 @@ func TestSigScriptWitnessSize(t *testing.T) {
 		address := test.GetAddress(scriptType)
 		t.Run(address.Configuration.String(), func(t *testing.T) {
 			sigScriptSize, witnessSize := sigScriptWitnessSize(address.Configuration)
-			sigScript, witness := address.SignatureScript(sig)
+			sigScript, witness := address.SignatureScript(sig, txscript.SigHashDefault)
 			require.Equal(t, len(sigScript), sigScriptSize)
 			if witness != nil {
 				require.Equal(t, witness.SerializeSize(), witnessSize)
@@ -0,0 +1,121 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"math"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ValidateOutput checks the amount of an output to be created. maxAmount is the total supply of the
+// coin. The amount must be positive and at most maxAmount, except for OP_RETURN outputs, which
+// must have a zero amount. Outputs which are dust according to the default relay policy are
+// rejected with errors.ErrDustAmount, as the transaction would not be relayed.
+func ValidateOutput(output *wire.TxOut, maxAmount btcutil.Amount) error {
+	if output.Value < 0 || output.Value > int64(maxAmount) {
+		return errp.WithStack(errors.ErrInvalidAmount)
+	}
+	if txscript.IsNullData(output.PkScript) {
+		if output.Value != 0 {
+			return errp.WithStack(errors.ErrInvalidAmount)
+		}
+		return nil
+	}
+	if output.Value == 0 {
+		return errp.WithStack(errors.ErrInvalidAmount)
+	}
+	if btcutil.Amount(output.Value) < DustThreshold(output.PkScript) {
+		return errp.WithStack(errors.ErrDustAmount)
+	}
+	return nil
+}
+
+// DustThreshold returns the smallest amount of an output paying to pkScript which is not dust
+// according to the default relay policy. It depends on the size of the output and of the input
+// spending it later: 546 sat for P2PKH, 540 sat for P2SH, 294 sat for P2WPKH and 330 sat for P2WSH
+// and P2TR.
+func DustThreshold(pkScript []byte) btcutil.Amount {
+	// mempool.IsDust() with the default relay fee of 1 sat/vbyte is equivalent to comparing the
+	// value to this threshold.
+	return btcutil.Amount(mempool.GetDustThreshold(wire.NewTxOut(0, pkScript)))
+}
+
+// checkDust returns errors.ErrDustAmount if the transaction has a dust output, which would make
+// nodes reject the transaction. OP_RETURN outputs are not checked.
+func checkDust(tx *wire.MsgTx) error {
+	for _, output := range tx.TxOut {
+		if txscript.IsNullData(output.PkScript) {
+			continue
+		}
+		if btcutil.Amount(output.Value) < DustThreshold(output.PkScript) {
+			return errp.WithStack(errors.ErrDustAmount)
+		}
+	}
+	return nil
+}
+
+// NewDataOutput returns an OP_RETURN output with a zero value embedding the given data. At most
+// txscript.MaxDataCarrierSize (80) bytes can be embedded, as larger outputs are not relayed.
+func NewDataOutput(data []byte) (*wire.TxOut, error) {
+	if len(data) > txscript.MaxDataCarrierSize {
+		return nil, errp.WithStack(errors.ErrOpReturnDataTooLarge)
+	}
+	pkScript, err := txscript.NullDataScript(data)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return wire.NewTxOut(0, pkScript), nil
+}
+
+// checkDataOutputs returns errors.ErrTooManyOpReturnOutputs if the transaction has more than one
+// OP_RETURN output, which would make nodes reject the transaction.
+func checkDataOutputs(tx *wire.MsgTx) error {
+	count := 0
+	for _, output := range tx.TxOut {
+		if txscript.IsNullData(output.PkScript) {
+			count++
+		}
+	}
+	if count > 1 {
+		return errp.WithStack(errors.ErrTooManyOpReturnOutputs)
+	}
+	return nil
+}
+
+// addAmounts returns a+b, or an error if the sum overflows.
+func addAmounts(a, b btcutil.Amount) (btcutil.Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, errp.WithStack(errors.ErrInvalidAmount)
+	}
+	return a + b, nil
+}
+
+// mulAmount returns a*b, or an error if the product overflows.
+func mulAmount(a btcutil.Amount, b int64) (btcutil.Amount, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := int64(a) * b
+	if product/b != int64(a) || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, errp.WithStack(errors.ErrInvalidAmount)
+	}
+	return btcutil.Amount(product), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netparams
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestNet4Genesis(t *testing.T) {
+	require.Equal(t,
+		"7aa0a7ae1e223414cb807e40cd57e667b718e42aaf9306db9102fe28912b7b4e",
+		testNet4GenesisCoinbaseTx.TxHash().String())
+	require.Equal(t, testNet4GenesisCoinbaseTx.TxHash(), testNet4GenesisBlock.Header.MerkleRoot)
+	require.Equal(t,
+		"00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043",
+		testNet4GenesisBlock.BlockHash().String())
+	require.Equal(t, *TestNet4Params.GenesisHash, testNet4GenesisBlock.BlockHash())
+}
+
+func TestRegistered(t *testing.T) {
+	require.True(t, chaincfg.IsBech32SegwitPrefix("tb1"))
+	require.Equal(t, chaincfg.ErrDuplicateNet, chaincfg.Register(&TestNet4Params))
+	require.Equal(t, chaincfg.ErrDuplicateNet, chaincfg.Register(&SigNetParams))
+
+	// Testnet addresses are valid on all test networks.
+	address := "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	for _, net := range []*chaincfg.Params{&TestNet4Params, &SigNetParams} {
+		decoded, err := btcutil.DecodeAddress(address, net)
+		require.NoError(t, err)
+		require.True(t, decoded.IsForNet(net))
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netparams
+
+import "github.com/btcsuite/btcd/wire"
+
+const (
+	// TestNet4 represents the Bitcoin test network (version 4), see BIP94.
+	TestNet4 wire.BitcoinNet = 0x283f161c
+)
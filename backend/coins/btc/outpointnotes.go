@@ -0,0 +1,148 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Input and output notes label the inputs and outputs of the transactions of the account, as in
+// BIP-329. Both are keyed by an outpoint ("txid:index"): an output by its own outpoint, an input by
+// the outpoint it spends. They are stored locally with the notes of the account.
+
+// SetInputNote sets the note of the input of one of the account's transactions which spends the
+// given outpoint. An empty note removes the note.
+func (account *Account) SetInputNote(outPoint string, note string) error {
+	_, err := account.setOutPointNote(outPoint, note, true)
+	return err
+}
+
+// InputNote returns the note of the input spending the given outpoint, or the empty string if there
+// is none.
+func (account *Account) InputNote(outPoint string) string {
+	return account.Notes().InputNote(outPoint)
+}
+
+// SetOutputNote sets the note of an output of one of the account's transactions, given as
+// "txid:index". An empty note removes the note.
+func (account *Account) SetOutputNote(outPoint string, note string) error {
+	_, err := account.setOutPointNote(outPoint, note, false)
+	return err
+}
+
+// OutputNote returns the note of the given output, or the empty string if there is none.
+func (account *Account) OutputNote(outPoint string) string {
+	return account.Notes().OutputNote(outPoint)
+}
+
+// hasOutPoint returns true if the outpoint is spent by an input of the account's transactions if
+// `input` is true, or if it is an output of the account's transactions otherwise.
+func (account *Account) hasOutPoint(outPoint wire.OutPoint, input bool) (bool, error) {
+	if input {
+		return account.transactions.HasInput(outPoint)
+	}
+	return account.transactions.HasOutput(outPoint)
+}
+
+// setOutPointNote sets an input note if `input` is true, an output note otherwise. Returns an error
+// if the outpoint is not an input or output of the account's transactions. Returns whether the note
+// changed.
+func (account *Account) setOutPointNote(outPoint string, note string, input bool) (bool, error) {
+	parsedOutPoint, err := wire.NewOutPointFromString(outPoint)
+	if err != nil {
+		return false, errp.Newf("%s is not a valid outpoint", outPoint)
+	}
+	known, err := account.hasOutPoint(*parsedOutPoint, input)
+	if err != nil {
+		return false, err
+	}
+	if !known {
+		return false, errp.Newf("%s is not part of a transaction of the account", outPoint)
+	}
+	setNote := account.Notes().SetOutputNote
+	if input {
+		setNote = account.Notes().SetInputNote
+	}
+	changed, err := setNote(parsedOutPoint.String(), note)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		// Prompt refresh.
+		account.Config().OnEvent(accountsTypes.EventStatusChanged)
+	}
+	return changed, nil
+}
+
+// ExportInputNotes returns the input notes of the account keyed by the spent outpoint, for the
+// BIP-329 export.
+func (account *Account) ExportInputNotes() (map[string]string, error) {
+	if err := account.Initialize(); err != nil {
+		return nil, err
+	}
+	return account.Notes().InputNotes(), nil
+}
+
+// ExportOutputNotes returns the output notes of the account keyed by outpoint, for the BIP-329
+// export.
+func (account *Account) ExportOutputNotes() (map[string]string, error) {
+	if err := account.Initialize(); err != nil {
+		return nil, err
+	}
+	return account.Notes().OutputNotes(), nil
+}
+
+// WaitSynchronizedTimeout waits until the account is synced, but at most for the given duration, so
+// that the notes of a BIP-329 import can be matched against its transactions and addresses.
+// ImportInputNote(), ImportOutputNote() and ImportAddressNote() don't wait themselves. Returns false
+// if the account is not synced in time, e.g. while offline.
+func (account *Account) WaitSynchronizedTimeout(timeout time.Duration) bool {
+	return account.Synchronizer.WaitSynchronizedTimeout(timeout)
+}
+
+// ImportInputNote sets the note of an input as it appears in a BIP-329 import. Returns whether the
+// input belongs to the account's transactions, and whether its note changed.
+func (account *Account) ImportInputNote(outPoint string, note string) (bool, bool, error) {
+	return account.importOutPointNote(outPoint, note, true)
+}
+
+// ImportOutputNote sets the note of an output as it appears in a BIP-329 import. Returns whether the
+// output belongs to the account's transactions, and whether its note changed.
+func (account *Account) ImportOutputNote(outPoint string, note string) (bool, bool, error) {
+	return account.importOutPointNote(outPoint, note, false)
+}
+
+func (account *Account) importOutPointNote(outPoint string, note string, input bool) (bool, bool, error) {
+	if err := account.Initialize(); err != nil {
+		return false, false, err
+	}
+	parsedOutPoint, err := wire.NewOutPointFromString(outPoint)
+	if err != nil {
+		return false, false, nil
+	}
+	known, err := account.hasOutPoint(*parsedOutPoint, input)
+	if err != nil || !known {
+		return false, false, err
+	}
+	changed, err := account.setOutPointNote(parsedOutPoint.String(), note, input)
+	if err != nil {
+		return true, false, err
+	}
+	return true, changed, nil
+}
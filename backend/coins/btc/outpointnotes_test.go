@@ -0,0 +1,79 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutPointNotes(t *testing.T) {
+	account, sent := fundedAccount(t, func(*btc.Account) {})
+	require.Eventually(t, func() bool {
+		balance, err := account.Balance()
+		require.NoError(t, err)
+		return balance.Available().BigInt().Int64() == 71000
+	}, 5*time.Second, 10*time.Millisecond)
+	sentHash := sent.TxHash()
+	spentOutPoint := sent.TxIn[0].PreviousOutPoint.String()
+	recipientOutPoint := wire.NewOutPoint(&sentHash, 0).String()
+	unknownOutPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("unknown"))}.String()
+
+	found, changed, err := account.ImportInputNote(spentOutPoint, "paid rent")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, changed)
+	require.Equal(t, "paid rent", account.InputNote(spentOutPoint))
+
+	found, changed, err = account.ImportOutputNote(recipientOutPoint, "landlord")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, changed)
+	found, changed, err = account.ImportOutputNote(recipientOutPoint, "landlord")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, changed)
+
+	// The spent outpoint is also an output of the account, but input and output notes are separate.
+	require.Equal(t, "", account.OutputNote(spentOutPoint))
+	// The recipient output is not spent by the account.
+	found, _, err = account.ImportInputNote(recipientOutPoint, "note")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// Outpoints which do not belong to the account's transactions are skipped.
+	for _, outPoint := range []string{unknownOutPoint, sentHash.String() + ":2", "not-an-outpoint"} {
+		found, changed, err := account.ImportOutputNote(outPoint, "note")
+		require.NoError(t, err)
+		require.False(t, found, outPoint)
+		require.False(t, changed, outPoint)
+	}
+	require.Error(t, account.SetOutputNote(unknownOutPoint, "note"))
+	require.Error(t, account.SetInputNote("not-an-outpoint", "note"))
+
+	exported, err := account.ExportInputNotes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{spentOutPoint: "paid rent"}, exported)
+
+	require.NoError(t, account.SetOutputNote(recipientOutPoint, ""))
+	exported, err = account.ExportOutputNotes()
+	require.NoError(t, err)
+	require.Empty(t, exported)
+}
@@ -0,0 +1,406 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package payjoin implements the sender side of payjoin transactions as specified in
+// https://github.com/bitcoin/bips/blob/master/bip-0078.mediawiki.
+package payjoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// psbtMagic is the prefix of serialized PSBTs, "psbt" followed by 0xff.
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// maxPSBTEntrySize limits the size of the keys and values of a PSBT, so that a malicious receiver
+// cannot make us allocate arbitrary amounts of memory.
+const maxPSBTEntrySize = 1 << 22
+
+// Key types of BIP174 used by payjoin.
+const (
+	psbtGlobalUnsignedTx = 0x00
+	psbtGlobalVersion    = 0xfb
+
+	psbtInNonWitnessUTXO     = 0x00
+	psbtInWitnessUTXO        = 0x01
+	psbtInPartialSig         = 0x02
+	psbtInBIP32Derivation    = 0x06
+	psbtInFinalScriptSig     = 0x07
+	psbtInFinalScriptWitness = 0x08
+	psbtInTapKeySig          = 0x13
+	psbtInTapScriptSig       = 0x14
+	psbtInTapBIP32Derivation = 0x16
+
+	psbtOutBIP32Derivation    = 0x02
+	psbtOutTapBIP32Derivation = 0x07
+)
+
+// PSBTInput contains the fields of a PSBT input relevant to payjoin. Other fields are skipped when
+// decoding.
+type PSBTInput struct {
+	NonWitnessUTXO     *wire.MsgTx
+	WitnessUTXO        *wire.TxOut
+	FinalScriptSig     []byte
+	FinalScriptWitness wire.TxWitness
+	// HasKeypaths is true if the decoded input contains BIP32 derivations. Not encoded.
+	HasKeypaths bool
+	// HasPartialSigs is true if the decoded input contains signatures which are not finalized. Not
+	// encoded.
+	HasPartialSigs bool
+}
+
+// Finalized returns true if the input contains its final scriptSig or witness.
+func (input *PSBTInput) Finalized() bool {
+	return len(input.FinalScriptSig) != 0 || len(input.FinalScriptWitness) != 0
+}
+
+// SpentOutput returns the output spent by the input at the given outpoint, or nil if the input
+// contains no UTXO information.
+func (input *PSBTInput) SpentOutput(outPoint wire.OutPoint) (*wire.TxOut, error) {
+	if input.WitnessUTXO != nil {
+		return input.WitnessUTXO, nil
+	}
+	if input.NonWitnessUTXO != nil {
+		if input.NonWitnessUTXO.TxHash() != outPoint.Hash ||
+			int(outPoint.Index) >= len(input.NonWitnessUTXO.TxOut) {
+			return nil, errp.New("The previous transaction does not match the input")
+		}
+		return input.NonWitnessUTXO.TxOut[outPoint.Index], nil
+	}
+	return nil, nil
+}
+
+// PSBTOutput contains the fields of a PSBT output relevant to payjoin.
+type PSBTOutput struct {
+	// HasKeypaths is true if the decoded output contains BIP32 derivations. Not encoded.
+	HasKeypaths bool
+}
+
+// PSBT is a partially signed Bitcoin transaction (BIP174), restricted to what is exchanged in a
+// payjoin.
+type PSBT struct {
+	UnsignedTx *wire.MsgTx
+	Inputs     []*PSBTInput
+	Outputs    []*PSBTOutput
+}
+
+// NewPSBT returns the PSBT of a signed transaction, with finalized inputs and the outputs they
+// spend, as sent by the sender of a payjoin as the original PSBT. Only segwit inputs are supported,
+// as the previous transactions would be needed otherwise.
+func NewPSBT(signedTx *wire.MsgTx, prevOutputs txscript.PrevOutputFetcher) (*PSBT, error) {
+	unsignedTx := signedTx.Copy()
+	inputs := make([]*PSBTInput, len(signedTx.TxIn))
+	for index, txIn := range unsignedTx.TxIn {
+		spentOutput := prevOutputs.FetchPrevOutput(txIn.PreviousOutPoint)
+		if spentOutput == nil {
+			return nil, errp.Newf("Output spent by input %d missing", index)
+		}
+		if len(txIn.Witness) == 0 {
+			return nil, errp.Newf("Input %d is not a segwit input", index)
+		}
+		inputs[index] = &PSBTInput{
+			WitnessUTXO:        spentOutput,
+			FinalScriptSig:     txIn.SignatureScript,
+			FinalScriptWitness: txIn.Witness,
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	outputs := make([]*PSBTOutput, len(signedTx.TxOut))
+	for index := range outputs {
+		outputs[index] = &PSBTOutput{}
+	}
+	return &PSBT{UnsignedTx: unsignedTx, Inputs: inputs, Outputs: outputs}, nil
+}
+
+// Extract returns the transaction with the final scripts and witnesses of the inputs.
+func (psbt *PSBT) Extract() *wire.MsgTx {
+	tx := psbt.UnsignedTx.Copy()
+	for index, txIn := range tx.TxIn {
+		txIn.SignatureScript = psbt.Inputs[index].FinalScriptSig
+		txIn.Witness = psbt.Inputs[index].FinalScriptWitness
+	}
+	return tx
+}
+
+func writeKeyValue(w io.Writer, keyType byte, value []byte) error {
+	if err := wire.WriteVarBytes(w, 0, []byte{keyType}); err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(wire.WriteVarBytes(w, 0, value))
+}
+
+// readKeyValue reads a key-value pair of a PSBT map. The key is nil at the separator ending the map.
+func readKeyValue(r io.Reader) ([]byte, []byte, error) {
+	key, err := wire.ReadVarBytes(r, 0, maxPSBTEntrySize, "key")
+	if err != nil {
+		return nil, nil, errp.WithStack(err)
+	}
+	if len(key) == 0 {
+		return nil, nil, nil
+	}
+	value, err := wire.ReadVarBytes(r, 0, maxPSBTEntrySize, "value")
+	if err != nil {
+		return nil, nil, errp.WithStack(err)
+	}
+	return key, value, nil
+}
+
+// readMap calls `handle` with each key-value pair of a PSBT map, rejecting duplicate keys.
+func readMap(r io.Reader, handle func(key []byte, value []byte) error) error {
+	seen := map[string]struct{}{}
+	for {
+		key, value, err := readKeyValue(r)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return nil
+		}
+		if _, ok := seen[string(key)]; ok {
+			return errp.New("Duplicate PSBT key")
+		}
+		seen[string(key)] = struct{}{}
+		if err := handle(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+func encodeWitness(witness wire.TxWitness) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeWitness(value []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(value)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if count > uint64(len(value)) {
+		return nil, errp.New("Invalid witness")
+	}
+	witness := make(wire.TxWitness, count)
+	for index := range witness {
+		witness[index], err = wire.ReadVarBytes(r, 0, maxPSBTEntrySize, "witness item")
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	if r.Len() != 0 {
+		return nil, errp.New("Invalid witness")
+	}
+	return witness, nil
+}
+
+// Encode serializes the PSBT.
+func (psbt *PSBT) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	var unsignedTx bytes.Buffer
+	if err := psbt.UnsignedTx.SerializeNoWitness(&unsignedTx); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := writeKeyValue(&buf, psbtGlobalUnsignedTx, unsignedTx.Bytes()); err != nil {
+		return nil, err
+	}
+	buf.WriteByte(0x00)
+	for _, input := range psbt.Inputs {
+		if input.NonWitnessUTXO != nil {
+			var tx bytes.Buffer
+			if err := input.NonWitnessUTXO.Serialize(&tx); err != nil {
+				return nil, errp.WithStack(err)
+			}
+			if err := writeKeyValue(&buf, psbtInNonWitnessUTXO, tx.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+		if input.WitnessUTXO != nil {
+			var txOut bytes.Buffer
+			if err := wire.WriteTxOut(&txOut, 0, 0, input.WitnessUTXO); err != nil {
+				return nil, errp.WithStack(err)
+			}
+			if err := writeKeyValue(&buf, psbtInWitnessUTXO, txOut.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+		if len(input.FinalScriptSig) != 0 {
+			if err := writeKeyValue(&buf, psbtInFinalScriptSig, input.FinalScriptSig); err != nil {
+				return nil, err
+			}
+		}
+		if len(input.FinalScriptWitness) != 0 {
+			witness, err := encodeWitness(input.FinalScriptWitness)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeKeyValue(&buf, psbtInFinalScriptWitness, witness); err != nil {
+				return nil, err
+			}
+		}
+		buf.WriteByte(0x00)
+	}
+	for range psbt.Outputs {
+		buf.WriteByte(0x00)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeBase64 serializes the PSBT in base64, as exchanged in a payjoin.
+func (psbt *PSBT) EncodeBase64() (string, error) {
+	encoded, err := psbt.Encode()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+func decodeInput(r io.Reader) (*PSBTInput, error) {
+	input := &PSBTInput{}
+	err := readMap(r, func(key []byte, value []byte) error {
+		switch key[0] {
+		case psbtInNonWitnessUTXO:
+			tx := &wire.MsgTx{}
+			if err := tx.Deserialize(bytes.NewReader(value)); err != nil {
+				return errp.WithStack(err)
+			}
+			input.NonWitnessUTXO = tx
+		case psbtInWitnessUTXO:
+			txOut := &wire.TxOut{}
+			if err := wire.ReadTxOut(bytes.NewReader(value), 0, 0, txOut); err != nil {
+				return errp.WithStack(err)
+			}
+			input.WitnessUTXO = txOut
+		case psbtInPartialSig, psbtInTapKeySig, psbtInTapScriptSig:
+			input.HasPartialSigs = true
+		case psbtInBIP32Derivation, psbtInTapBIP32Derivation:
+			input.HasKeypaths = true
+		case psbtInFinalScriptSig:
+			input.FinalScriptSig = value
+		case psbtInFinalScriptWitness:
+			witness, err := decodeWitness(value)
+			if err != nil {
+				return err
+			}
+			input.FinalScriptWitness = witness
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func decodeOutput(r io.Reader) (*PSBTOutput, error) {
+	output := &PSBTOutput{}
+	err := readMap(r, func(key []byte, value []byte) error {
+		switch key[0] {
+		case psbtOutBIP32Derivation, psbtOutTapBIP32Derivation:
+			output.HasKeypaths = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// DecodePSBT parses a serialized PSBT.
+func DecodePSBT(data []byte) (*PSBT, error) {
+	if !bytes.HasPrefix(data, psbtMagic) {
+		return nil, errp.New("Invalid PSBT magic")
+	}
+	r := bytes.NewReader(data[len(psbtMagic):])
+	var unsignedTx *wire.MsgTx
+	err := readMap(r, func(key []byte, value []byte) error {
+		switch key[0] {
+		case psbtGlobalUnsignedTx:
+			if len(key) != 1 {
+				return errp.New("Invalid PSBT key")
+			}
+			tx := &wire.MsgTx{}
+			txReader := bytes.NewReader(value)
+			if err := tx.DeserializeNoWitness(txReader); err != nil {
+				return errp.WithStack(err)
+			}
+			if txReader.Len() != 0 {
+				return errp.New("Invalid unsigned transaction")
+			}
+			unsignedTx = tx
+		case psbtGlobalVersion:
+			if len(value) != 4 || binary.LittleEndian.Uint32(value) != 0 {
+				return errp.New("Unsupported PSBT version")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unsignedTx == nil {
+		return nil, errp.New("The PSBT contains no unsigned transaction")
+	}
+	for _, txIn := range unsignedTx.TxIn {
+		if len(txIn.SignatureScript) != 0 {
+			return nil, errp.New("The unsigned transaction of the PSBT is signed")
+		}
+		txIn.SignatureScript = nil
+	}
+	psbt := &PSBT{
+		UnsignedTx: unsignedTx,
+		Inputs:     make([]*PSBTInput, len(unsignedTx.TxIn)),
+		Outputs:    make([]*PSBTOutput, len(unsignedTx.TxOut)),
+	}
+	for index := range psbt.Inputs {
+		if psbt.Inputs[index], err = decodeInput(r); err != nil {
+			return nil, err
+		}
+	}
+	for index := range psbt.Outputs {
+		if psbt.Outputs[index], err = decodeOutput(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, errp.New("Trailing data after the PSBT")
+	}
+	return psbt, nil
+}
+
+// DecodePSBTBase64 parses a PSBT serialized in base64.
+func DecodePSBTBase64(encoded string) (*PSBT, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return DecodePSBT(data)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payjoin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/payjoin"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPSBTRoundtrip(t *testing.T) {
+	f := newFixture()
+	encoded, err := f.original.EncodeBase64()
+	require.NoError(t, err)
+	decoded, err := payjoin.DecodePSBTBase64(encoded)
+	require.NoError(t, err)
+	require.Equal(t, f.original, decoded)
+	require.Equal(t, f.signedOriginal, decoded.Extract())
+
+	proposal := f.proposal()
+	proposal.Inputs[1].WitnessUTXO = nil
+	proposal.Inputs[1].NonWitnessUTXO = f.receiverPrevTx
+	encoded, err = proposal.EncodeBase64()
+	require.NoError(t, err)
+	decoded, err = payjoin.DecodePSBTBase64(encoded)
+	require.NoError(t, err)
+	require.Equal(t, proposal, decoded)
+	spentOutput, err := decoded.Inputs[1].SpentOutput(f.receiverOutPoint)
+	require.NoError(t, err)
+	require.Equal(t, f.receiverPrevTx.TxOut[0], spentOutput)
+	_, err = decoded.Inputs[1].SpentOutput(f.senderOutPoint)
+	require.Error(t, err)
+}
+
+func TestNewPSBTNonSegwit(t *testing.T) {
+	f := newFixture()
+	tx := f.signedOriginal.Copy()
+	tx.TxIn[0].Witness = nil
+	tx.TxIn[0].SignatureScript = []byte{txscript.OP_TRUE}
+	_, err := payjoin.NewPSBT(tx, f.prevOutputs())
+	require.Error(t, err)
+}
+
+// psbtBytes serializes a PSBT with the given unsigned transaction and raw input and output maps,
+// each a list of key-value pairs.
+func psbtBytes(t *testing.T, tx *wire.MsgTx, inputs [][][2][]byte, outputs [][][2][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.Write([]byte("psbt\xff"))
+	var txBuf bytes.Buffer
+	require.NoError(t, tx.SerializeNoWitness(&txBuf))
+	writeMap := func(entries [][2][]byte) {
+		for _, entry := range entries {
+			require.NoError(t, wire.WriteVarBytes(&buf, 0, entry[0]))
+			require.NoError(t, wire.WriteVarBytes(&buf, 0, entry[1]))
+		}
+		buf.WriteByte(0x00)
+	}
+	writeMap([][2][]byte{{{0x00}, txBuf.Bytes()}})
+	for _, entries := range inputs {
+		writeMap(entries)
+	}
+	for _, entries := range outputs {
+		writeMap(entries)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodePSBT(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("a"))}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, p2wpkhScript("out")))
+
+	keypath := [2][]byte{append([]byte{0x06}, make([]byte, 33)...), make([]byte, 8)}
+	partialSig := [2][]byte{append([]byte{0x02}, make([]byte, 33)...), make([]byte, 72)}
+	tapKeySig := [2][]byte{{0x13}, make([]byte, 64)}
+	unknown := [2][]byte{{0xfc, 0x01}, {0x01}}
+
+	psbt, err := payjoin.DecodePSBT(psbtBytes(t, tx,
+		[][][2][]byte{{keypath, partialSig, unknown}},
+		[][][2][]byte{{{append([]byte{0x02}, make([]byte, 33)...), make([]byte, 8)}}}))
+	require.NoError(t, err)
+	require.True(t, psbt.Inputs[0].HasKeypaths)
+	require.True(t, psbt.Inputs[0].HasPartialSigs)
+	require.True(t, psbt.Outputs[0].HasKeypaths)
+
+	psbt, err = payjoin.DecodePSBT(psbtBytes(t, tx, [][][2][]byte{{tapKeySig}}, [][][2][]byte{{}}))
+	require.NoError(t, err)
+	require.False(t, psbt.Inputs[0].HasKeypaths)
+	require.True(t, psbt.Inputs[0].HasPartialSigs)
+	require.False(t, psbt.Outputs[0].HasKeypaths)
+
+	// Duplicate key.
+	_, err = payjoin.DecodePSBT(psbtBytes(t, tx, [][][2][]byte{{unknown, unknown}}, [][][2][]byte{{}}))
+	require.Error(t, err)
+	// Missing output map.
+	_, err = payjoin.DecodePSBT(psbtBytes(t, tx, [][][2][]byte{{}}, nil))
+	require.Error(t, err)
+	// Trailing data.
+	_, err = payjoin.DecodePSBT(append(psbtBytes(t, tx, [][][2][]byte{{}}, [][][2][]byte{{}}), 0x00))
+	require.Error(t, err)
+	// Invalid magic.
+	_, err = payjoin.DecodePSBT(psbtBytes(t, tx, [][][2][]byte{{}}, [][][2][]byte{{}})[1:])
+	require.Error(t, err)
+	_, err = payjoin.DecodePSBTBase64("not base64")
+	require.Error(t, err)
+}
@@ -0,0 +1,426 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payjoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// ErrInvalidEndpoint is returned if the payjoin endpoint of a payment request is malformed or
+	// neither uses https nor a Tor onion service.
+	ErrInvalidEndpoint errp.ErrorCode = "payjoinInvalidEndpoint"
+	// ErrReceiverRejected is returned if the receiver responded with an error, e.g. because it has
+	// no coins to contribute. The original transaction can be broadcast instead.
+	ErrReceiverRejected errp.ErrorCode = "payjoinRejected"
+	// ErrInvalidProposal is returned if the proposal of the receiver fails the checks of BIP78. It
+	// must not be signed, but the original transaction can be broadcast instead.
+	ErrInvalidProposal errp.ErrorCode = "payjoinInvalidProposal"
+)
+
+// maxResponseSize limits the size of the response of the receiver.
+const maxResponseSize = 1 << 20
+
+// Endpoint is the payjoin endpoint of a BIP21 payment request.
+type Endpoint struct {
+	URL string
+	// DisableOutputSubstitution is true if the receiver does not allow the payment output to be
+	// substituted (`pjos=0`).
+	DisableOutputSubstitution bool
+}
+
+// EndpointFromURI returns the payjoin endpoint of the given BIP21 payment request URI (`pj=`), or
+// nil if the payment request does not support payjoin.
+func EndpointFromURI(uri string) (*Endpoint, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	query := parsed.Query()
+	endpoint := query.Get("pj")
+	if endpoint == "" {
+		return nil, nil
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errp.WithMessage(ErrInvalidEndpoint, err.Error())
+	}
+	isOnion := strings.HasSuffix(endpointURL.Hostname(), ".onion")
+	if endpointURL.Scheme != "https" && !(endpointURL.Scheme == "http" && isOnion) {
+		return nil, errp.WithStack(ErrInvalidEndpoint)
+	}
+	return &Endpoint{
+		URL:                       endpoint,
+		DisableOutputSubstitution: query.Get("pjos") == "0",
+	}, nil
+}
+
+// Params are the optional parameters of the sender, see BIP78.
+type Params struct {
+	// MaxAdditionalFeeContribution is the amount the receiver may subtract from the output at
+	// AdditionalFeeOutputIndex, usually our change, to pay for the fee of its inputs. 0 if the
+	// receiver has to pay for the fee of its inputs.
+	MaxAdditionalFeeContribution btcutil.Amount
+	AdditionalFeeOutputIndex     int
+	// DisableOutputSubstitution forbids the receiver to change the payment output.
+	DisableOutputSubstitution bool
+	// MinFeeRatePerKb is the minimum fee rate of the payjoin transaction, 0 if there is none.
+	MinFeeRatePerKb btcutil.Amount
+}
+
+// inputVSize returns the virtual size of an input spending an output of the given class, used to
+// limit the fee contribution of the sender. Only the segwit inputs supported by NewPSBT() are
+// supported.
+func inputVSize(class txscript.ScriptClass) (int64, bool) {
+	switch class {
+	case txscript.ScriptHashTy: // P2WPKH-P2SH
+		return 91, true
+	case txscript.WitnessV0PubKeyHashTy:
+		return 68, true
+	case txscript.WitnessV1TaprootTy:
+		return 58, true
+	}
+	return 0, false
+}
+
+// Sender requests a payjoin proposal for an original transaction from the receiver and checks it.
+type Sender struct {
+	original           *PSBT
+	paymentOutputIndex int
+	params             Params
+	isOurs             func(pkScript []byte) bool
+
+	originalSpentOutputs []*wire.TxOut
+	originalFee          btcutil.Amount
+	originalVSize        int64
+	inputClass           txscript.ScriptClass
+}
+
+// NewSender returns a sender for the given original PSBT, see NewPSBT(). paymentOutputIndex is
+// the index of the output paying the receiver. isOurs returns true if an output script belongs to
+// the wallet of the sender.
+func NewSender(
+	original *PSBT,
+	paymentOutputIndex int,
+	params Params,
+	isOurs func(pkScript []byte) bool,
+) (*Sender, error) {
+	tx := original.UnsignedTx
+	if paymentOutputIndex < 0 || paymentOutputIndex >= len(tx.TxOut) {
+		return nil, errp.New("Invalid payment output index")
+	}
+	if params.MaxAdditionalFeeContribution > 0 &&
+		(params.AdditionalFeeOutputIndex < 0 || params.AdditionalFeeOutputIndex >= len(tx.TxOut) ||
+			params.AdditionalFeeOutputIndex == paymentOutputIndex) {
+		return nil, errp.New("Invalid additional fee output index")
+	}
+	sender := &Sender{
+		original:             original,
+		paymentOutputIndex:   paymentOutputIndex,
+		params:               params,
+		isOurs:               isOurs,
+		originalSpentOutputs: make([]*wire.TxOut, len(tx.TxIn)),
+	}
+	var inputsSum, outputsSum btcutil.Amount
+	for index, txIn := range tx.TxIn {
+		input := original.Inputs[index]
+		if !input.Finalized() {
+			return nil, errp.Newf("Input %d of the original transaction is not finalized", index)
+		}
+		spentOutput, err := input.SpentOutput(txIn.PreviousOutPoint)
+		if err != nil {
+			return nil, err
+		}
+		if spentOutput == nil {
+			return nil, errp.Newf("Output spent by input %d missing", index)
+		}
+		class := txscript.GetScriptClass(spentOutput.PkScript)
+		if _, ok := inputVSize(class); !ok {
+			return nil, errp.Newf("Unsupported type of input %d", index)
+		}
+		if index == 0 {
+			sender.inputClass = class
+		} else if class != sender.inputClass {
+			return nil, errp.New("Payjoin requires all inputs to be of the same type")
+		}
+		sender.originalSpentOutputs[index] = spentOutput
+		inputsSum += btcutil.Amount(spentOutput.Value)
+	}
+	for _, txOut := range tx.TxOut {
+		outputsSum += btcutil.Amount(txOut.Value)
+	}
+	if inputsSum < outputsSum {
+		return nil, errp.New("The outputs of the original transaction exceed its inputs")
+	}
+	sender.originalFee = inputsSum - outputsSum
+	sender.originalVSize = mempool.GetTxVirtualSize(btcutil.NewTx(original.Extract()))
+	return sender, nil
+}
+
+// requestURL returns the URL of the endpoint with the parameters of the sender.
+func (sender *Sender) requestURL(endpoint *Endpoint) (string, error) {
+	requestURL, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return "", errp.WithMessage(ErrInvalidEndpoint, err.Error())
+	}
+	query := requestURL.Query()
+	query.Set("v", "1")
+	if sender.params.MaxAdditionalFeeContribution > 0 {
+		query.Set("additionalfeeoutputindex", strconv.Itoa(sender.params.AdditionalFeeOutputIndex))
+		query.Set("maxadditionalfeecontribution",
+			strconv.FormatInt(int64(sender.params.MaxAdditionalFeeContribution), 10))
+	}
+	if sender.params.DisableOutputSubstitution {
+		query.Set("disableoutputsubstitution", "true")
+	}
+	if sender.params.MinFeeRatePerKb > 0 {
+		query.Set("minfeerate",
+			strconv.FormatFloat(float64(sender.params.MinFeeRatePerKb)/1000, 'f', -1, 64))
+	}
+	requestURL.RawQuery = query.Encode()
+	return requestURL.String(), nil
+}
+
+// Request posts the original PSBT to the endpoint of the receiver and returns its proposal once it
+// passed CheckProposal(). The inputs of the sender in the proposal still need to be signed.
+func (sender *Sender) Request(
+	ctx context.Context, httpClient *http.Client, endpoint *Endpoint) (*PSBT, error) {
+	if endpoint.DisableOutputSubstitution {
+		sender.params.DisableOutputSubstitution = true
+	}
+	requestURL, err := sender.requestURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	body, err := sender.original.EncodeBase64()
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, strings.NewReader(body))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "text/plain")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		var receiverError struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		if err := json.Unmarshal(responseBody, &receiverError); err != nil {
+			receiverError.ErrorCode = response.Status
+		}
+		return nil, errp.WithMessage(ErrReceiverRejected,
+			fmt.Sprintf("%s: %s", receiverError.ErrorCode, receiverError.Message))
+	}
+	proposal, err := DecodePSBTBase64(string(bytes.TrimSpace(responseBody)))
+	if err != nil {
+		return nil, errp.WithMessage(ErrInvalidProposal, err.Error())
+	}
+	if err := sender.CheckProposal(proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func invalidProposal(format string, args ...interface{}) error {
+	return errp.WithMessage(ErrInvalidProposal, fmt.Sprintf(format, args...))
+}
+
+// checkInputs checks the inputs of the proposal and returns their sum.
+func (sender *Sender) checkInputs(proposal *PSBT) (btcutil.Amount, error) {
+	originalTx := sender.original.UnsignedTx
+	originalIndices := make(map[wire.OutPoint]int, len(originalTx.TxIn))
+	for index, txIn := range originalTx.TxIn {
+		originalIndices[txIn.PreviousOutPoint] = index
+	}
+	senderInputs := map[wire.OutPoint]struct{}{}
+	var sum btcutil.Amount
+	for index, txIn := range proposal.UnsignedTx.TxIn {
+		input := proposal.Inputs[index]
+		if input.HasKeypaths {
+			return 0, invalidProposal("input %d contains keypaths", index)
+		}
+		if input.HasPartialSigs {
+			return 0, invalidProposal("input %d contains partial signatures", index)
+		}
+		var spentOutput *wire.TxOut
+		if originalIndex, ok := originalIndices[txIn.PreviousOutPoint]; ok {
+			if _, ok := senderInputs[txIn.PreviousOutPoint]; ok {
+				return 0, invalidProposal("input %d spends an input of the sender twice", index)
+			}
+			senderInputs[txIn.PreviousOutPoint] = struct{}{}
+			if txIn.Sequence != originalTx.TxIn[originalIndex].Sequence {
+				return 0, invalidProposal("the sequence of input %d was changed", index)
+			}
+			if input.Finalized() {
+				return 0, invalidProposal("input %d of the sender is finalized", index)
+			}
+			if input.WitnessUTXO != nil || input.NonWitnessUTXO != nil {
+				return 0, invalidProposal("input %d of the sender contains UTXO information", index)
+			}
+			spentOutput = sender.originalSpentOutputs[originalIndex]
+		} else {
+			if !input.Finalized() {
+				return 0, invalidProposal("input %d of the receiver is not finalized", index)
+			}
+			var err error
+			spentOutput, err = input.SpentOutput(txIn.PreviousOutPoint)
+			if err != nil {
+				return 0, invalidProposal("input %d of the receiver: %v", index, err)
+			}
+			if spentOutput == nil {
+				return 0, invalidProposal("input %d of the receiver lacks UTXO information", index)
+			}
+			if sender.isOurs(spentOutput.PkScript) {
+				return 0, invalidProposal("input %d of the receiver spends an output of the sender", index)
+			}
+		}
+		if txIn.Sequence != proposal.UnsignedTx.TxIn[0].Sequence {
+			return 0, invalidProposal("the inputs have different sequence numbers")
+		}
+		if txscript.GetScriptClass(spentOutput.PkScript) != sender.inputClass {
+			return 0, invalidProposal("input %d is of a different type than the inputs of the sender", index)
+		}
+		if spentOutput.Value < 0 || spentOutput.Value > btcutil.MaxSatoshi {
+			return 0, invalidProposal("the amount spent by input %d is invalid", index)
+		}
+		sum += btcutil.Amount(spentOutput.Value)
+	}
+	if len(senderInputs) != len(originalTx.TxIn) {
+		return 0, invalidProposal("inputs of the sender were removed")
+	}
+	return sum, nil
+}
+
+// CheckProposal checks that the proposal of the receiver only adds inputs and outputs of the
+// receiver to the original transaction, and that our fee contribution stays within the limits
+// of the parameters, as specified in BIP78. A proposal which fails these checks must not be
+// signed.
+func (sender *Sender) CheckProposal(proposal *PSBT) error {
+	originalTx := sender.original.UnsignedTx
+	proposalTx := proposal.UnsignedTx
+	if len(proposal.Inputs) != len(proposalTx.TxIn) || len(proposal.Outputs) != len(proposalTx.TxOut) {
+		return invalidProposal("the PSBT does not match its transaction")
+	}
+	if proposalTx.Version != originalTx.Version {
+		return invalidProposal("the transaction version was changed")
+	}
+	if proposalTx.LockTime != originalTx.LockTime {
+		return invalidProposal("the lock time was changed")
+	}
+	inputsSum, err := sender.checkInputs(proposal)
+	if err != nil {
+		return err
+	}
+
+	var outputsSum btcutil.Amount
+	for index, txOut := range proposalTx.TxOut {
+		if proposal.Outputs[index].HasKeypaths {
+			return invalidProposal("output %d contains keypaths", index)
+		}
+		if txOut.Value < 0 || txOut.Value > btcutil.MaxSatoshi {
+			return invalidProposal("the amount of output %d is invalid", index)
+		}
+		outputsSum += btcutil.Amount(txOut.Value)
+	}
+	if inputsSum < outputsSum {
+		return invalidProposal("the outputs exceed the inputs")
+	}
+	fee := inputsSum - outputsSum
+	if fee < sender.originalFee {
+		return invalidProposal("the fee was decreased")
+	}
+
+	// The outputs of the original transaction are matched with the outputs of the proposal by
+	// their script, as the receiver may shuffle them.
+	matched := make([]bool, len(proposalTx.TxOut))
+	matchOutput := func(pkScript []byte) *wire.TxOut {
+		for index, txOut := range proposalTx.TxOut {
+			if !matched[index] && bytes.Equal(txOut.PkScript, pkScript) {
+				matched[index] = true
+				return txOut
+			}
+		}
+		return nil
+	}
+	addedInputs := int64(len(proposalTx.TxIn) - len(originalTx.TxIn))
+	for index, originalOutput := range originalTx.TxOut {
+		if index == sender.paymentOutputIndex && !sender.params.DisableOutputSubstitution {
+			continue
+		}
+		txOut := matchOutput(originalOutput.PkScript)
+		if txOut == nil {
+			return invalidProposal("output %d was removed", index)
+		}
+		contribution := btcutil.Amount(originalOutput.Value - txOut.Value)
+		if contribution <= 0 {
+			continue
+		}
+		if sender.params.MaxAdditionalFeeContribution == 0 || index != sender.params.AdditionalFeeOutputIndex {
+			return invalidProposal("the amount of output %d was decreased", index)
+		}
+		if contribution > sender.params.MaxAdditionalFeeContribution {
+			return invalidProposal("the fee contribution of %d sat exceeds the maximum", contribution)
+		}
+		if contribution > fee-sender.originalFee {
+			return invalidProposal("the fee contribution of %d sat does not only pay for the fee", contribution)
+		}
+		// The fee of the additional inputs at the fee rate of the original transaction.
+		inputVSize, _ := inputVSize(sender.inputClass)
+		maxContribution := btcutil.Amount(
+			int64(sender.originalFee) * inputVSize * addedInputs / sender.originalVSize)
+		if contribution > maxContribution {
+			return invalidProposal(
+				"the fee contribution of %d sat exceeds the fee of the additional inputs", contribution)
+		}
+	}
+	return nil
+}
+
+// CheckFeeRate checks that the fee rate of the signed payjoin transaction paying the given fee is
+// at least Params.MinFeeRatePerKb.
+func (sender *Sender) CheckFeeRate(signedTx *wire.MsgTx, fee btcutil.Amount) error {
+	if sender.params.MinFeeRatePerKb == 0 {
+		return nil
+	}
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(signedTx))
+	if int64(fee)*1000 < int64(sender.params.MinFeeRatePerKb)*vsize {
+		return invalidProposal("the fee rate is below the minimum fee rate")
+	}
+	return nil
+}
@@ -0,0 +1,583 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payjoin_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/payjoin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+const sequence = wire.MaxTxInSequenceNum - 2
+
+func p2wpkhScript(seed string) []byte {
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).AddData(btcutil.Hash160([]byte(seed))).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+func p2trScript(seed string) []byte {
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).AddData(chainhash.HashB([]byte(seed))).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// dummyWitness has the size of a P2WPKH witness.
+var dummyWitness = wire.TxWitness{make([]byte, 72), make([]byte, 33)}
+
+// fixture is an original transaction of the sender, paying 50000 sat to the receiver with 49000 sat
+// change and a fee of 1000 sat at a size of 141 vbytes.
+type fixture struct {
+	senderScript        []byte
+	changeScript        []byte
+	receiverScript      []byte
+	receiverInputScript []byte
+	senderOutPoint      wire.OutPoint
+	receiverOutPoint    wire.OutPoint
+	receiverPrevTx      *wire.MsgTx
+	signedOriginal      *wire.MsgTx
+	original            *payjoin.PSBT
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		senderScript:        p2wpkhScript("sender"),
+		changeScript:        p2wpkhScript("change"),
+		receiverScript:      p2wpkhScript("receiver"),
+		receiverInputScript: p2wpkhScript("receiver input"),
+		senderOutPoint:      wire.OutPoint{Hash: chainhash.HashH([]byte("sender tx")), Index: 1},
+	}
+	f.receiverPrevTx = wire.NewMsgTx(2)
+	f.receiverPrevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	f.receiverPrevTx.AddTxOut(wire.NewTxOut(30000, f.receiverInputScript))
+	f.receiverOutPoint = wire.OutPoint{Hash: f.receiverPrevTx.TxHash(), Index: 0}
+
+	f.signedOriginal = wire.NewMsgTx(2)
+	f.signedOriginal.LockTime = 100
+	txIn := wire.NewTxIn(&f.senderOutPoint, nil, dummyWitness)
+	txIn.Sequence = sequence
+	f.signedOriginal.AddTxIn(txIn)
+	f.signedOriginal.AddTxOut(wire.NewTxOut(50000, f.receiverScript))
+	f.signedOriginal.AddTxOut(wire.NewTxOut(49000, f.changeScript))
+	original, err := payjoin.NewPSBT(f.signedOriginal, f.prevOutputs())
+	if err != nil {
+		panic(err)
+	}
+	f.original = original
+	return f
+}
+
+func (f *fixture) prevOutputs() txscript.PrevOutputFetcher {
+	return txscript.NewMultiPrevOutFetcher(map[wire.OutPoint]*wire.TxOut{
+		f.senderOutPoint: wire.NewTxOut(100000, f.senderScript),
+	})
+}
+
+func (f *fixture) isOurs(pkScript []byte) bool {
+	return bytes.Equal(pkScript, f.senderScript) || bytes.Equal(pkScript, f.changeScript)
+}
+
+func (f *fixture) sender(t *testing.T, params payjoin.Params) *payjoin.Sender {
+	t.Helper()
+	sender, err := payjoin.NewSender(f.original, 0, params, f.isOurs)
+	require.NoError(t, err)
+	return sender
+}
+
+// proposal returns a valid proposal, adding an input of 30000 sat of the receiver and increasing
+// the payment output by the same amount minus 400 sat, which the receiver takes from our change to
+// pay for the fee of its input.
+func (f *fixture) proposal() *payjoin.PSBT {
+	tx := f.original.UnsignedTx.Copy()
+	txIn := wire.NewTxIn(&f.receiverOutPoint, nil, nil)
+	txIn.Sequence = sequence
+	tx.AddTxIn(txIn)
+	tx.TxOut[0].Value = 80000
+	tx.TxOut[1].Value = 48600
+	return &payjoin.PSBT{
+		UnsignedTx: tx,
+		Inputs: []*payjoin.PSBTInput{
+			{},
+			{
+				WitnessUTXO:        wire.NewTxOut(30000, f.receiverInputScript),
+				FinalScriptWitness: dummyWitness,
+			},
+		},
+		Outputs: []*payjoin.PSBTOutput{{}, {}},
+	}
+}
+
+var defaultParams = payjoin.Params{
+	MaxAdditionalFeeContribution: 500,
+	AdditionalFeeOutputIndex:     1,
+}
+
+func TestEndpointFromURI(t *testing.T) {
+	endpoint, err := payjoin.EndpointFromURI(
+		"bitcoin:bc1qxyz?amount=1&pj=https://example.com/pj%3Fa%3Db")
+	require.NoError(t, err)
+	require.Equal(t, &payjoin.Endpoint{URL: "https://example.com/pj?a=b"}, endpoint)
+
+	endpoint, err = payjoin.EndpointFromURI(
+		"bitcoin:bc1qxyz?pj=http://payjoinxyz.onion/pj&pjos=0")
+	require.NoError(t, err)
+	require.Equal(t,
+		&payjoin.Endpoint{URL: "http://payjoinxyz.onion/pj", DisableOutputSubstitution: true},
+		endpoint)
+
+	endpoint, err = payjoin.EndpointFromURI("bitcoin:bc1qxyz?amount=1")
+	require.NoError(t, err)
+	require.Nil(t, endpoint)
+
+	_, err = payjoin.EndpointFromURI("bitcoin:bc1qxyz?pj=http://example.com/pj")
+	require.Equal(t, payjoin.ErrInvalidEndpoint, errp.Cause(err))
+	_, err = payjoin.EndpointFromURI("bitcoin:bc1qxyz?pj=ftp://example.com/pj")
+	require.Equal(t, payjoin.ErrInvalidEndpoint, errp.Cause(err))
+}
+
+func TestNewSender(t *testing.T) {
+	f := newFixture()
+	_, err := payjoin.NewSender(f.original, 2, payjoin.Params{}, f.isOurs)
+	require.Error(t, err)
+	// The payment output can't pay for the fee of the receiver.
+	_, err = payjoin.NewSender(f.original, 0,
+		payjoin.Params{MaxAdditionalFeeContribution: 1, AdditionalFeeOutputIndex: 0}, f.isOurs)
+	require.Error(t, err)
+
+	unfinalized := *f.original
+	unfinalized.Inputs = []*payjoin.PSBTInput{{WitnessUTXO: f.original.Inputs[0].WitnessUTXO}}
+	_, err = payjoin.NewSender(&unfinalized, 0, payjoin.Params{}, f.isOurs)
+	require.Error(t, err)
+
+	// Inputs of different types.
+	tx := f.signedOriginal.Copy()
+	otherOutPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("other")), Index: 0}
+	tx.AddTxIn(wire.NewTxIn(&otherOutPoint, nil, wire.TxWitness{make([]byte, 64)}))
+	mixed, err := payjoin.NewPSBT(tx, txscript.NewMultiPrevOutFetcher(map[wire.OutPoint]*wire.TxOut{
+		f.senderOutPoint: wire.NewTxOut(100000, f.senderScript),
+		otherOutPoint:    wire.NewTxOut(100000, p2trScript("sender")),
+	}))
+	require.NoError(t, err)
+	_, err = payjoin.NewSender(mixed, 0, payjoin.Params{}, f.isOurs)
+	require.Error(t, err)
+}
+
+func TestCheckProposal(t *testing.T) {
+	tests := []struct {
+		name   string
+		params payjoin.Params
+		modify func(f *fixture, proposal *payjoin.PSBT)
+		// errorMessage is empty if the proposal is valid.
+		errorMessage string
+	}{
+		{
+			name:   "valid",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {},
+		},
+		{
+			name:   "valid shuffled",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				tx := proposal.UnsignedTx
+				tx.TxIn[0], tx.TxIn[1] = tx.TxIn[1], tx.TxIn[0]
+				proposal.Inputs[0], proposal.Inputs[1] = proposal.Inputs[1], proposal.Inputs[0]
+				tx.TxOut[0], tx.TxOut[1] = tx.TxOut[1], tx.TxOut[0]
+			},
+		},
+		{
+			name:   "valid without fee contribution",
+			params: payjoin.Params{},
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 79600
+				proposal.UnsignedTx.TxOut[1].Value = 49000
+			},
+		},
+		{
+			name:   "valid additional output of the receiver",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 60000
+				proposal.UnsignedTx.AddTxOut(wire.NewTxOut(20000, p2wpkhScript("receiver change")))
+				proposal.Outputs = append(proposal.Outputs, &payjoin.PSBTOutput{})
+			},
+		},
+		{
+			name:   "valid substituted payment output",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].PkScript = p2wpkhScript("other receiver")
+				proposal.UnsignedTx.TxOut[0].Value = 40000
+			},
+		},
+		{
+			name:   "substituted payment output",
+			params: payjoin.Params{DisableOutputSubstitution: true},
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].PkScript = p2wpkhScript("other receiver")
+				proposal.UnsignedTx.TxOut[1].Value = 49000
+			},
+			errorMessage: "output 0 was removed",
+		},
+		{
+			name:   "decreased payment output",
+			params: payjoin.Params{DisableOutputSubstitution: true},
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 40000
+				proposal.UnsignedTx.TxOut[1].Value = 49000
+			},
+			errorMessage: "the amount of output 0 was decreased",
+		},
+		{
+			name:   "mismatching PSBT",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs = proposal.Inputs[:1]
+			},
+			errorMessage: "does not match its transaction",
+		},
+		{
+			name:   "changed version",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.Version = 1
+			},
+			errorMessage: "version",
+		},
+		{
+			name:   "changed lock time",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.LockTime = 0
+			},
+			errorMessage: "lock time",
+		},
+		{
+			name:   "removed sender input",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxIn = proposal.UnsignedTx.TxIn[1:]
+				proposal.Inputs = proposal.Inputs[1:]
+			},
+			errorMessage: "inputs of the sender were removed",
+		},
+		{
+			name:   "duplicate sender input",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				txIn := *proposal.UnsignedTx.TxIn[0]
+				proposal.UnsignedTx.TxIn = append(proposal.UnsignedTx.TxIn, &txIn)
+				proposal.Inputs = append(proposal.Inputs, &payjoin.PSBTInput{})
+			},
+			errorMessage: "spends an input of the sender twice",
+		},
+		{
+			name:   "changed sender sequence",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				for _, txIn := range proposal.UnsignedTx.TxIn {
+					txIn.Sequence = wire.MaxTxInSequenceNum
+				}
+			},
+			errorMessage: "the sequence of input 0 was changed",
+		},
+		{
+			name:   "mixed sequences",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxIn[1].Sequence = wire.MaxTxInSequenceNum
+			},
+			errorMessage: "different sequence numbers",
+		},
+		{
+			name:   "finalized sender input",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[0].FinalScriptWitness = dummyWitness
+			},
+			errorMessage: "input 0 of the sender is finalized",
+		},
+		{
+			name:   "sender input with witness UTXO",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[0].WitnessUTXO = wire.NewTxOut(100000, f.senderScript)
+			},
+			errorMessage: "input 0 of the sender contains UTXO information",
+		},
+		{
+			name:   "sender input with non-witness UTXO",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[0].NonWitnessUTXO = wire.NewMsgTx(2)
+			},
+			errorMessage: "input 0 of the sender contains UTXO information",
+		},
+		{
+			name:   "unfinalized receiver input",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].FinalScriptWitness = nil
+			},
+			errorMessage: "input 1 of the receiver is not finalized",
+		},
+		{
+			name:   "receiver input without UTXO",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].WitnessUTXO = nil
+			},
+			errorMessage: "input 1 of the receiver lacks UTXO information",
+		},
+		{
+			name:   "receiver input with mismatching previous transaction",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].WitnessUTXO = nil
+				proposal.Inputs[1].NonWitnessUTXO = wire.NewMsgTx(1)
+			},
+			errorMessage: "input 1 of the receiver:",
+		},
+		{
+			// The receiver must not make us sign another of our coins.
+			name:   "receiver input spending from the sender",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].WitnessUTXO.PkScript = f.changeScript
+			},
+			errorMessage: "input 1 of the receiver spends an output of the sender",
+		},
+		{
+			name:   "mixed input types",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].WitnessUTXO.PkScript = p2trScript("receiver")
+			},
+			errorMessage: "input 1 is of a different type",
+		},
+		{
+			name:   "input keypaths",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[0].HasKeypaths = true
+			},
+			errorMessage: "input 0 contains keypaths",
+		},
+		{
+			name:   "partial signatures",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Inputs[1].HasPartialSigs = true
+			},
+			errorMessage: "input 1 contains partial signatures",
+		},
+		{
+			name:   "output keypaths",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.Outputs[1].HasKeypaths = true
+			},
+			errorMessage: "output 1 contains keypaths",
+		},
+		{
+			name:   "negative output",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = -1
+			},
+			errorMessage: "the amount of output 0 is invalid",
+		},
+		{
+			name:   "outputs exceeding inputs",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 90000
+			},
+			errorMessage: "the outputs exceed the inputs",
+		},
+		{
+			name:   "decreased fee",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 81000
+			},
+			errorMessage: "the fee was decreased",
+		},
+		{
+			name:   "removed change output",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut = proposal.UnsignedTx.TxOut[:1]
+				proposal.Outputs = proposal.Outputs[:1]
+			},
+			errorMessage: "output 1 was removed",
+		},
+		{
+			name:   "change output paying to the receiver",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[1].PkScript = f.receiverScript
+			},
+			errorMessage: "output 1 was removed",
+		},
+		{
+			name:         "decreased change without fee contribution",
+			params:       payjoin.Params{},
+			modify:       func(f *fixture, proposal *payjoin.PSBT) {},
+			errorMessage: "the amount of output 1 was decreased",
+		},
+		{
+			name:         "fee contribution exceeding the maximum",
+			params:       payjoin.Params{MaxAdditionalFeeContribution: 300, AdditionalFeeOutputIndex: 1},
+			modify:       func(f *fixture, proposal *payjoin.PSBT) {},
+			errorMessage: "exceeds the maximum",
+		},
+		{
+			name:   "fee contribution paying the receiver",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[0].Value = 80400
+			},
+			errorMessage: "does not only pay for the fee",
+		},
+		{
+			// 1000 sat * 68 vbytes / 141 vbytes = 482 sat.
+			name:   "fee contribution exceeding the fee of the additional input",
+			params: payjoin.Params{MaxAdditionalFeeContribution: 1000, AdditionalFeeOutputIndex: 1},
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[1].Value = 49000 - 483
+			},
+			errorMessage: "exceeds the fee of the additional inputs",
+		},
+		{
+			name:   "fee contribution at the fee of the additional input",
+			params: payjoin.Params{MaxAdditionalFeeContribution: 1000, AdditionalFeeOutputIndex: 1},
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxOut[1].Value = 49000 - 482
+			},
+		},
+		{
+			name:   "fee contribution without additional inputs",
+			params: defaultParams,
+			modify: func(f *fixture, proposal *payjoin.PSBT) {
+				proposal.UnsignedTx.TxIn = proposal.UnsignedTx.TxIn[:1]
+				proposal.Inputs = proposal.Inputs[:1]
+				proposal.UnsignedTx.TxOut[0].Value = 50000
+				proposal.UnsignedTx.TxOut[1].Value = 48900
+			},
+			errorMessage: "exceeds the fee of the additional inputs",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture()
+			proposal := f.proposal()
+			test.modify(f, proposal)
+			err := f.sender(t, test.params).CheckProposal(proposal)
+			if test.errorMessage == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, payjoin.ErrInvalidProposal, errp.Cause(err))
+			require.Contains(t, err.Error(), test.errorMessage)
+		})
+	}
+}
+
+func TestCheckFeeRate(t *testing.T) {
+	f := newFixture()
+	// 141 vbytes.
+	signedTx := f.signedOriginal
+	require.NoError(t, f.sender(t, payjoin.Params{}).CheckFeeRate(signedTx, 0))
+	sender := f.sender(t, payjoin.Params{MinFeeRatePerKb: 2000})
+	require.NoError(t, sender.CheckFeeRate(signedTx, 282))
+	require.Equal(t, payjoin.ErrInvalidProposal, errp.Cause(sender.CheckFeeRate(signedTx, 281)))
+}
+
+func TestRequest(t *testing.T) {
+	f := newFixture()
+	var response func(w http.ResponseWriter)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		require.Equal(t, "1", r.URL.Query().Get("v"))
+		require.Equal(t, "1", r.URL.Query().Get("additionalfeeoutputindex"))
+		require.Equal(t, "500", r.URL.Query().Get("maxadditionalfeecontribution"))
+		require.Equal(t, "true", r.URL.Query().Get("disableoutputsubstitution"))
+		require.Equal(t, "1.5", r.URL.Query().Get("minfeerate"))
+		require.Equal(t, "b", r.URL.Query().Get("a"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		original, err := payjoin.DecodePSBTBase64(string(body))
+		require.NoError(t, err)
+		require.Equal(t, f.original, original)
+		response(w)
+	}))
+	defer server.Close()
+	endpoint := &payjoin.Endpoint{URL: server.URL + "/pj?a=b", DisableOutputSubstitution: true}
+	params := defaultParams
+	params.MinFeeRatePerKb = 1500
+
+	response = func(w http.ResponseWriter) {
+		encoded, err := f.proposal().EncodeBase64()
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(encoded + "\n"))
+	}
+	proposal, err := f.sender(t, params).Request(context.Background(), server.Client(), endpoint)
+	require.NoError(t, err)
+	require.Equal(t, f.proposal(), proposal)
+
+	response = func(w http.ResponseWriter) {
+		invalid := f.proposal()
+		invalid.UnsignedTx.TxOut[1].Value = 40000
+		encoded, err := invalid.EncodeBase64()
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(encoded))
+	}
+	_, err = f.sender(t, params).Request(context.Background(), server.Client(), endpoint)
+	require.Equal(t, payjoin.ErrInvalidProposal, errp.Cause(err))
+
+	response = func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte("garbage"))
+	}
+	_, err = f.sender(t, params).Request(context.Background(), server.Client(), endpoint)
+	require.Equal(t, payjoin.ErrInvalidProposal, errp.Cause(err))
+
+	response = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errorCode": "unavailable", "message": "no coins"}`))
+	}
+	_, err = f.sender(t, params).Request(context.Background(), server.Client(), endpoint)
+	require.Equal(t, payjoin.ErrReceiverRejected, errp.Cause(err))
+	require.Contains(t, err.Error(), "unavailable: no coins")
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"net/url"
+	"strings"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// paymentURIScheme returns the URI scheme of payment requests for this coin.
+func (coin *Coin) paymentURIScheme() string {
+	switch coin.code {
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		return "litecoin"
+	default:
+		return "bitcoin"
+	}
+}
+
+// bip21Escape percent-encodes a query parameter value of a BIP21 URI. Spaces are encoded as %20, as
+// BIP21 does not define `+` as a space.
+func bip21Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// PaymentURI returns the BIP21 URI requesting a payment to the given address, so that the wallet
+// of the payer can pre-fill the amount. The amount is optional (nil), as is the label (empty).
+func (coin *Coin) PaymentURI(address string, amount *coinpkg.Amount, label string) string {
+	var params []string
+	if amount != nil {
+		// BIP21 amounts are always in the default unit, e.g. BTC, without thousands separators.
+		formatted := coinpkg.FormatBtcAmount(amount.BigInt(), coinpkg.BtcUnitDefault)
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+		params = append(params, "amount="+formatted)
+	}
+	if label != "" {
+		params = append(params, "label="+bip21Escape(label))
+	}
+	uri := coin.paymentURIScheme() + ":" + address
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// PaymentURI returns the BIP21 URI requesting a payment to the receive address with the given ID.
+// The amount is given in the unit selected for display and parsed like in the send flow. The amount
+// and label are optional (empty).
+func (account *Account) PaymentURI(addressID string, amount string, label string) (string, error) {
+	address, _, err := account.LookupReceiveAddress(addressID)
+	if err != nil {
+		return "", err
+	}
+	var parsedAmount *coinpkg.Amount
+	if amount != "" {
+		parsed, err := coinpkg.NewSendAmount(amount).Amount(account.coin.formatUnit.SatsPerUnit(), false)
+		if err != nil {
+			return "", err
+		}
+		parsedAmount = &parsed
+	}
+	return account.coin.PaymentURI(address.EncodeForHumans(), parsedAmount, label), nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/transactionsdb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// PersistedTransactions returns the transactions and notes of the account. If the account is not
+// initialized, e.g. because it is archived, they are read from the files persisted by a previous
+// sync, without initializing the account. An error is returned if the account was never synced.
+func (account *Account) PersistedTransactions() (accounts.OrderedTransactions, *notes.Notes, error) {
+	unlock := account.initializedLock.RLock()
+	initialized := account.initialized
+	if initialized {
+		unlock()
+		txs, err := account.Transactions()
+		if err != nil {
+			return nil, nil, err
+		}
+		return txs, account.Notes(), nil
+	}
+	// Initialize() waits until the db is closed again.
+	defer unlock()
+	if account.closed {
+		return nil, nil, errp.New("account was closed")
+	}
+	if _, err := os.Stat(account.dbFilename()); err != nil {
+		return nil, nil, errp.Newf("no persisted transactions: %v", err)
+	}
+	db, err := transactionsdb.NewReadOnlyDB(account.dbFilename())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			account.log.WithError(err).Error("couldn't close db")
+		}
+	}()
+
+	isChange, err := account.persistedChangeAddresses(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	tipHeight := 0
+	if theHeaders := account.coin.Headers(); theHeaders != nil {
+		tipHeight = theHeaders.TipHeight()
+	}
+	txs, err := transactions.PersistedTransactions(account.coin.Net(), db, tipHeight, isChange, account.log)
+	if err != nil {
+		return nil, nil, err
+	}
+	txNotes, err := notes.LoadNotes(path.Join(
+		account.Config().NotesFolder,
+		fmt.Sprintf("account-%s.json", account.Config().Config.Code),
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+	return txs, txNotes, nil
+}
+
+// persistedChangeAddresses derives the change addresses of the account up to the gap limit after
+// the last address used according to the address histories persisted in the db. It returns a
+// function checking if a script hash belongs to one of them.
+func (account *Account) persistedChangeAddresses(
+	db transactions.DBInterface) (func(blockchain.ScriptHashHex) bool, error) {
+	isAddressUsed := func(address *addresses.AccountAddress) (bool, error) {
+		history, err := transactions.DBView(db, func(dbTx transactions.DBTxInterface) (blockchain.TxHistory, error) {
+			return dbTx.AddressHistory(address.PubkeyScriptHashHex())
+		})
+		if err != nil {
+			return false, err
+		}
+		return len(history) > 0, nil
+	}
+	persistedLimits, err := transactions.DBView(db, func(dbTx transactions.DBTxInterface) (int, error) {
+		limits, err := dbTx.GapLimits()
+		return int(limits.Change), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	changeAddresses := []*addresses.AddressChain{}
+	for _, signingConfiguration := range account.Config().Config.SigningConfigurations {
+		gapLimit := max(persistedLimits, int(account.defaultGapLimits(signingConfiguration).Change))
+		chain := addresses.NewAddressChain(
+			signingConfiguration, account.coin.Net(), min(gapLimit, maxGapLimit), 1,
+			account.derivationCache, isAddressUsed, account.log)
+		for {
+			added, err := chain.EnsureAddresses()
+			if err != nil {
+				return nil, err
+			}
+			if len(added) == 0 {
+				break
+			}
+		}
+		changeAddresses = append(changeAddresses, chain)
+	}
+	return func(scriptHashHex blockchain.ScriptHashHex) bool {
+		for _, chain := range changeAddresses {
+			if chain.LookupByScriptHashHex(scriptHashHex) != nil {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
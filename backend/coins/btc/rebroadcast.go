@@ -0,0 +1,101 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rebroadcastInterval is the interval at which unconfirmed outgoing transactions are broadcast
+	// again.
+	rebroadcastInterval = 30 * time.Minute
+	// rebroadcastDelay is the time between a reconnect and the rebroadcast, giving the account time
+	// to sync first, so that transactions which confirmed in the meantime are not broadcast again.
+	rebroadcastDelay = time.Minute
+)
+
+// alreadyBroadcastErrors are parts of the error messages returned by servers when broadcasting a
+// transaction they already know, in their mempool or in a block.
+var alreadyBroadcastErrors = []string{
+	"txn-already-in-mempool",
+	"txn-already-known",
+	"already in block chain",
+	"transaction already in mempool",
+}
+
+// isAlreadyBroadcastError returns true if the broadcast error means that the server already knows
+// the transaction, which counts as a successful broadcast.
+func isAlreadyBroadcastError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, alreadyBroadcast := range alreadyBroadcastErrors {
+		if strings.Contains(message, alreadyBroadcast) {
+			return true
+		}
+	}
+	return false
+}
+
+// rebroadcastLoop broadcasts the unconfirmed outgoing transactions again periodically and shortly
+// after a reconnect, in case the servers dropped them from their mempool, e.g. after a restart.
+func (account *Account) rebroadcastLoop(reconnected <-chan struct{}, quitChan <-chan struct{}) {
+	timer := time.NewTimer(rebroadcastInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-quitChan:
+			return
+		case <-reconnected:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(rebroadcastDelay)
+		case <-timer.C:
+			account.rebroadcast()
+			timer.Reset(rebroadcastInterval)
+		}
+	}
+}
+
+// rebroadcast broadcasts the outgoing transactions which are not confirmed or replaced yet.
+func (account *Account) rebroadcast() {
+	if account.isClosed() || account.Offline() != nil {
+		return
+	}
+	outgoingTxs, err := account.transactions.OutgoingTransactionsToBroadcast()
+	if err != nil {
+		account.log.WithError(err).Error("Could not get the outgoing transactions to broadcast")
+		return
+	}
+	for _, outgoingTx := range outgoingTxs {
+		txHash := outgoingTx.Tx.TxHash()
+		log := account.log.WithFields(logrus.Fields{"txHash": txHash})
+		err := account.coin.Blockchain().TransactionBroadcast(account.ctx, outgoingTx.Tx)
+		switch {
+		case err == nil:
+			log.Info("Broadcast outgoing transaction again")
+		case isAlreadyBroadcastError(err):
+			log.Debug("Outgoing transaction is already known by the server")
+		default:
+			log.WithError(err).Warn("Could not broadcast outgoing transaction again")
+		}
+		if err := account.transactions.MarkOutgoingTransactionBroadcast(txHash, time.Now()); err != nil {
+			log.WithError(err).Error("Could not record the broadcast of the outgoing transaction")
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAlreadyBroadcastError(t *testing.T) {
+	require.True(t, isAlreadyBroadcastError(errors.New(
+		"the transaction was rejected by network rules.\n\ntxn-already-in-mempool")))
+	require.True(t, isAlreadyBroadcastError(errors.New("Transaction already in block chain")))
+	require.True(t, isAlreadyBroadcastError(errors.New("txn-already-known")))
+	require.False(t, isAlreadyBroadcastError(errors.New("bad-txns-inputs-missingorspent")))
+	require.False(t, isAlreadyBroadcastError(errors.New("failed to read from socket: EOF")))
+}
@@ -15,6 +15,8 @@
 package btc
 
 import (
+	"fmt"
+
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
@@ -22,11 +24,16 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
+// ErrInvalidTaprootSignature is returned if the signature of a taproot input provided by the
+// keystore does not verify against the taproot output key, e.g. because of a bug in the signer.
+const ErrInvalidTaprootSignature errp.ErrorCode = "invalidTaprootSignature"
+
 // ProposedTransaction contains all the info needed to sign a btc transaction.
 type ProposedTransaction struct {
 	TXProposal *maketx.TxProposal
@@ -37,7 +44,38 @@ type ProposedTransaction struct {
 	// Signatures collects the signatures, one per transaction input.
 	Signatures []*types.Signature
 	SigHashes  *txscript.TxSigHashes
-	FormatUnit coin.BtcUnit
+	// SigHashType is the sighash type of all signatures not overridden in
+	// TXProposal.SigHashTypes, e.g. txscript.SigHashAll | txscript.SigHashAnyOneCanPay for
+	// collaborative transactions. The zero value, txscript.SigHashDefault, signs all inputs and
+	// outputs. Use InputSigHashType() to get the sighash type of an input.
+	SigHashType txscript.SigHashType
+	FormatUnit  coin.BtcUnit
+}
+
+// InputSigHashType returns the sighash type used to sign the input at the given index, which spends
+// from the given address.
+func (p *ProposedTransaction) InputSigHashType(inputIndex int, address *addresses.AccountAddress) txscript.SigHashType {
+	outPoint := p.TXProposal.Transaction.TxIn[inputIndex].PreviousOutPoint
+	if sigHashType, ok := p.TXProposal.SigHashTypes[outPoint]; ok {
+		return address.SigHashType(sigHashType)
+	}
+	return address.SigHashType(p.SigHashType)
+}
+
+// UsesDefaultSigHashTypes returns true if all inputs are signed with SIGHASH_ALL, or with
+// SIGHASH_DEFAULT for taproot inputs.
+func (p *ProposedTransaction) UsesDefaultSigHashTypes() bool {
+	for index, txIn := range p.TXProposal.Transaction.TxIn {
+		spentOutput, ok := p.TXProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return false
+		}
+		address := p.GetAccountAddress(spentOutput.ScriptHashHex())
+		if p.InputSigHashType(index, address) != address.SigHashType(txscript.SigHashDefault) {
+			return false
+		}
+	}
+	return true
 }
 
 // signTransaction signs all inputs. It assumes all outputs spent belong to this
@@ -77,7 +115,15 @@ func (account *Account) signTransaction(
 		if signature == nil {
 			return errp.New("Signature missing")
 		}
-		input.SignatureScript, input.Witness = address.SignatureScript(*signature)
+		if address.Configuration.ScriptType() == signing.ScriptTypeP2TR {
+			err := verifyTaprootSignature(txProposal.Transaction, index, previousOutputs,
+				proposedTransaction.SigHashes, proposedTransaction.InputSigHashType(index, address), signature)
+			if err != nil {
+				return err
+			}
+		}
+		input.SignatureScript, input.Witness = address.SignatureScript(
+			*signature, proposedTransaction.InputSigHashType(index, address))
 	}
 
 	// Sanity check: see if the created transaction is valid.
@@ -89,6 +135,46 @@ func (account *Account) signTransaction(
 	return nil
 }
 
+// verifyTaprootSignature checks the Schnorr signature of the taproot input at the given index against
+// the output key of the spent output and the sighash of the input, so that a bad signature is
+// caught before the transaction is broadcast.
+func verifyTaprootSignature(
+	transaction *wire.MsgTx,
+	index int,
+	previousOutputs maketx.PreviousOutputs,
+	sigHashes *txscript.TxSigHashes,
+	sigHashType txscript.SigHashType,
+	signature *types.Signature,
+) error {
+	spentOutput, ok := previousOutputs[transaction.TxIn[index].PreviousOutPoint]
+	if !ok {
+		return errp.Newf("Output spent by input %d missing", index)
+	}
+	_, witnessProgram, err := txscript.ExtractWitnessProgramInfo(spentOutput.PkScript)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	outputKey, err := schnorr.ParsePubKey(witnessProgram)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	sigHash, err := txscript.CalcTaprootSignatureHash(
+		sigHashes, sigHashType, transaction, index, previousOutputs)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	schnorrSignature, err := schnorr.ParseSignature(signature.SerializeCompact())
+	if err != nil {
+		return errp.WithMessage(ErrInvalidTaprootSignature,
+			fmt.Sprintf("the signature of input %d is malformed: %v", index, err))
+	}
+	if !schnorrSignature.Verify(sigHash, outputKey) {
+		return errp.WithMessage(ErrInvalidTaprootSignature,
+			fmt.Sprintf("the signature of input %d does not match the taproot output key", index))
+	}
+	return nil
+}
+
 func txValidityCheck(transaction *wire.MsgTx, previousOutputs maketx.PreviousOutputs,
 	sigHashes *txscript.TxSigHashes) error {
 	for index, txIn := range transaction.TxIn {
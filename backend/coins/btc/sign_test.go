@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTaprootSignature(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(privateKey.PubKey()))
+	require.NoError(t, err)
+
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("tx")), Index: 0}
+	previousOutputs := maketx.PreviousOutputs{
+		outPoint: &transactions.SpendableOutput{TxOut: wire.NewTxOut(1e8, pkScript)},
+	}
+	tx := &wire.MsgTx{
+		Version: wire.TxVersion,
+		TxIn:    []*wire.TxIn{wire.NewTxIn(&outPoint, nil, nil)},
+		TxOut:   []*wire.TxOut{wire.NewTxOut(1e8-1000, pkScript)},
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, previousOutputs)
+
+	sign := func(sigHashType txscript.SigHashType) *types.Signature {
+		sigHash, err := txscript.CalcTaprootSignatureHash(sigHashes, sigHashType, tx, 0, previousOutputs)
+		require.NoError(t, err)
+		signature, err := schnorr.Sign(txscript.TweakTaprootPrivKey(*privateKey, nil), sigHash)
+		require.NoError(t, err)
+		serialized := signature.Serialize()
+		return &types.Signature{
+			R: new(big.Int).SetBytes(serialized[:32]),
+			S: new(big.Int).SetBytes(serialized[32:]),
+		}
+	}
+
+	signature := sign(txscript.SigHashDefault)
+	require.NoError(t, btc.TstVerifyTaprootSignature(
+		tx, 0, previousOutputs, sigHashes, txscript.SigHashDefault, signature))
+
+	// Signed with a different sighash type.
+	err = btc.TstVerifyTaprootSignature(
+		tx, 0, previousOutputs, sigHashes, txscript.SigHashAll, signature)
+	require.Equal(t, btc.ErrInvalidTaprootSignature, errp.Cause(err))
+
+	// Tampered signature.
+	tampered := &types.Signature{R: signature.R, S: new(big.Int).Add(signature.S, big.NewInt(1))}
+	err = btc.TstVerifyTaprootSignature(
+		tx, 0, previousOutputs, sigHashes, txscript.SigHashDefault, tampered)
+	require.Equal(t, btc.ErrInvalidTaprootSignature, errp.Cause(err))
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// subscriptionsCheckInterval is the interval at which the subscriptions are checked.
+	subscriptionsCheckInterval = 10 * time.Minute
+	// subscriptionsCheckDelay is the time between a reconnect and the check of the subscriptions,
+	// giving the connection time to re-subscribe all script hashes first.
+	subscriptionsCheckDelay = time.Minute
+)
+
+// SubscriptionsHealth is the result of the checks that all addresses of the account are subscribed
+// to on the active connection.
+type SubscriptionsHealth struct {
+	// LastCheck is the time of the last check. Zero if there was no check yet.
+	LastCheck time.Time
+	// Repaired is the total number of subscriptions which were missing and subscribed to again.
+	Repaired int
+}
+
+// SubscriptionsHealth returns the result of the subscription checks.
+func (account *Account) SubscriptionsHealth() SubscriptionsHealth {
+	defer account.subscriptionsHealthLock.RLock()()
+	return account.subscriptionsHealth
+}
+
+// monitoredAddresses returns all addresses whose status is subscribed to.
+func (account *Account) monitoredAddresses() []*addresses.AccountAddress {
+	result := []*addresses.AccountAddress{}
+	for _, subacc := range account.subaccounts {
+		result = append(result, subacc.receiveAddresses.Addresses()...)
+		result = append(result, subacc.changeAddresses.Addresses()...)
+	}
+	return result
+}
+
+// checkSubscriptions makes sure that every monitored address is subscribed to on the active
+// connection, and resubscribes to the addresses whose subscription was dropped, e.g. during a
+// reconnect. Subscriptions whose request did not complete yet, e.g. on a slow server, are not
+// missing. The connection is shared by all accounts of the coin, so subscriptions of other accounts
+// are not a discrepancy.
+func (account *Account) checkSubscriptions() {
+	if account.isClosed() || account.coin.Blockchain().ConnectionError() != nil {
+		return
+	}
+	subscribed := map[blockchain.ScriptHashHex]struct{}{}
+	subscribedList, pendingList := account.coin.Blockchain().ScriptHashSubscriptions()
+	for _, scriptHashHex := range subscribedList {
+		subscribed[scriptHashHex] = struct{}{}
+	}
+	for _, scriptHashHex := range pendingList {
+		subscribed[scriptHashHex] = struct{}{}
+	}
+	monitored := account.monitoredAddresses()
+	missing := []*addresses.AccountAddress{}
+	for _, address := range monitored {
+		if _, ok := subscribed[address.PubkeyScriptHashHex()]; !ok {
+			missing = append(missing, address)
+		}
+	}
+
+	func() {
+		defer account.subscriptionsHealthLock.Lock()()
+		account.subscriptionsHealth.LastCheck = time.Now()
+		account.subscriptionsHealth.Repaired += len(missing)
+	}()
+	if len(missing) == 0 {
+		return
+	}
+	account.log.WithFields(logrus.Fields{
+		"monitored":  len(monitored),
+		"subscribed": len(subscribedList),
+		"pending":    len(pendingList),
+		"missing":    len(missing),
+	}).Warn("Subscriptions are missing on the active connection, resubscribing")
+	for _, address := range missing {
+		account.resubscribeAddress(address)
+	}
+}
+
+// resubscribeAddress sends the subscription request for an address subscribed to with
+// subscribeAddress again, without registering another subscription.
+func (account *Account) resubscribeAddress(address *addresses.AccountAddress) {
+	account.coin.Blockchain().ScriptHashResubscribe(
+		account.ctx,
+		account.Synchronizer.IncRequestsCounter,
+		address.PubkeyScriptHashHex(),
+		func(status string) {
+			go account.onAddressStatus(address, status)
+		},
+	)
+}
+
+// subscriptionsHealthLoop checks the subscriptions periodically and shortly after each reconnect,
+// until quitChan is closed.
+func (account *Account) subscriptionsHealthLoop(reconnected <-chan struct{}, quitChan <-chan struct{}) {
+	timer := time.NewTimer(subscriptionsCheckInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-quitChan:
+			return
+		case <-reconnected:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(subscriptionsCheckDelay)
+		case <-timer.C:
+			account.checkSubscriptions()
+			timer.Reset(subscriptionsCheckInterval)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+)
+
+// TstCheckSubscriptions exports checkSubscriptions for testing.
+func (account *Account) TstCheckSubscriptions() {
+	account.checkSubscriptions()
+}
+
+// TstGetAddress exports getAddress for testing.
+func (account *Account) TstGetAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+	return account.getAddress(scriptHashHex)
+}
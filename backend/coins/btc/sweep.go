@@ -0,0 +1,310 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SweepArgs are the arguments needed to sweep all funds controlled by a standalone private key.
+type SweepArgs struct {
+	// WIF is the private key in Wallet Import Format.
+	WIF           string
+	FeeTargetCode accounts.FeeTargetCode
+	// Only applies if FeeTargetCode == Custom. It is provided in sat/vB.
+	CustomFee string
+}
+
+// sweepScript is one of the output scripts a standalone private key can control.
+type sweepScript struct {
+	pkScript []byte
+	// redeemScript is the P2SH redeem script for P2WPKH-P2SH outputs, nil otherwise.
+	redeemScript []byte
+	// configuration is used only to estimate the size of the inputs spending this script.
+	configuration *signing.Configuration
+}
+
+// sweepScripts returns the output scripts controlled by the key: P2PKH, P2WPKH and P2WPKH-P2SH.
+// Keys which are flagged to use an uncompressed public key can only control P2PKH outputs.
+func sweepScripts(wif *btcutil.WIF, net *chaincfg.Params) ([]*sweepScript, error) {
+	publicKey := wif.PrivKey.PubKey()
+	// The extended key is a container for the public key so that the tx size estimation can work
+	// with it like with any account address. The chain code is irrelevant.
+	extendedKey := hdkeychain.NewExtendedKey(
+		net.HDPublicKeyID[:], publicKey.SerializeCompressed(), make([]byte, 32), []byte{0, 0, 0, 0},
+		0, 0, false)
+	makeConfiguration := func(scriptType signing.ScriptType) *signing.Configuration {
+		return signing.NewBitcoinConfiguration(
+			scriptType, nil, signing.NewEmptyAbsoluteKeypath(), extendedKey)
+	}
+
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(wif.SerializePubKey()), net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2pkhScript, err := txscript.PayToAddrScript(p2pkhAddress)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	scripts := []*sweepScript{{
+		pkScript:      p2pkhScript,
+		configuration: makeConfiguration(signing.ScriptTypeP2PKH),
+	}}
+	if !wif.CompressPubKey {
+		return scripts, nil
+	}
+
+	p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(publicKey.SerializeCompressed()), net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2wpkhScript, err := txscript.PayToAddrScript(p2wpkhAddress)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2shAddress, err := btcutil.NewAddressScriptHash(p2wpkhScript, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2shScript, err := txscript.PayToAddrScript(p2shAddress)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return append(scripts,
+		&sweepScript{
+			pkScript:      p2wpkhScript,
+			configuration: makeConfiguration(signing.ScriptTypeP2WPKH),
+		},
+		&sweepScript{
+			pkScript:      p2shScript,
+			redeemScript:  p2wpkhScript,
+			configuration: makeConfiguration(signing.ScriptTypeP2WPKHP2SH),
+		},
+	), nil
+}
+
+// sweepUTXOs queries the blockchain backend for the unspent outputs paying to any of the given
+// scripts. The outputs of all scripts are combined.
+func (account *Account) sweepUTXOs(scripts []*sweepScript) (
+	map[wire.OutPoint]*wire.TxOut, map[wire.OutPoint]*sweepScript, error) {
+	txs := map[chainhash.Hash]*wire.MsgTx{}
+	for _, script := range scripts {
+		history, err := account.coin.Blockchain().ScriptHashGetHistory(
+			account.ctx, blockchain.NewScriptHashHex(script.pkScript))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, txInfo := range history {
+			txHash := txInfo.TXHash.Hash()
+			if _, ok := txs[txHash]; ok {
+				continue
+			}
+			tx, err := account.coin.Blockchain().TransactionGet(account.ctx, txHash)
+			if err != nil {
+				return nil, nil, err
+			}
+			txs[txHash] = tx
+		}
+	}
+
+	spent := map[wire.OutPoint]struct{}{}
+	for _, tx := range txs {
+		for _, txIn := range tx.TxIn {
+			spent[txIn.PreviousOutPoint] = struct{}{}
+		}
+	}
+	utxos := map[wire.OutPoint]*wire.TxOut{}
+	utxoScripts := map[wire.OutPoint]*sweepScript{}
+	for txHash, tx := range txs {
+		for index, txOut := range tx.TxOut {
+			outPoint := *wire.NewOutPoint(&txHash, uint32(index))
+			if _, ok := spent[outPoint]; ok {
+				continue
+			}
+			for _, script := range scripts {
+				if bytes.Equal(txOut.PkScript, script.pkScript) {
+					utxos[outPoint] = txOut
+					utxoScripts[outPoint] = script
+					break
+				}
+			}
+		}
+	}
+	return utxos, utxoScripts, nil
+}
+
+// decodeSweepKey decodes a private key in Wallet Import Format for the network of the account.
+func (account *Account) decodeSweepKey(wifString string) (*btcutil.WIF, error) {
+	wifString = strings.TrimSpace(wifString)
+	if wifString == "" {
+		return nil, errp.WithStack(errors.ErrPrivateKeyEmpty)
+	}
+	wif, err := btcutil.DecodeWIF(wifString)
+	if err != nil {
+		return nil, errp.WithStack(errors.ErrInvalidPrivateKey)
+	}
+	if !wif.IsForNet(account.coin.Net()) {
+		return nil, errp.WithStack(errors.ErrPrivateKeyWrongNetwork)
+	}
+	return wif, nil
+}
+
+// sweepAddress returns the address the swept funds are sent to, which is the next unused receive
+// address of the preferred script type. It is not marked as handed out, see NextReceiveAddress().
+func (account *Account) sweepAddress() (*addresses.AccountAddress, error) {
+	chain, err := account.receiveAddressChain(nil)
+	if err != nil {
+		return nil, err
+	}
+	unused, err := chain.GetUnused()
+	if err != nil {
+		return nil, err
+	}
+	return unused[0], nil
+}
+
+// SweepProposal creates and signs a transaction sending all funds controlled by the given private
+// key to the next receive address of the account. The transaction is not broadcast. The inputs
+// are signed locally with the private key, without the keystore. The key is neither added to the
+// account nor persisted, and is wiped from memory once the transaction is signed.
+func (account *Account) SweepProposal(args *SweepArgs) (*maketx.TxProposal, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	wif, err := account.decodeSweepKey(args.WIF)
+	if err != nil {
+		return nil, err
+	}
+	defer wif.PrivKey.Zero()
+	scripts, err := sweepScripts(wif, account.coin.Net())
+	if err != nil {
+		return nil, err
+	}
+	utxos, utxoScripts, err := account.sweepUTXOs(scripts)
+	if err != nil {
+		return nil, err
+	}
+	if len(utxos) == 0 {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	account.log.Infof("Sweeping %d outputs", len(utxos))
+
+	feeRatePerKb, err := account.getFeePerKb(&accounts.TxProposalArgs{
+		FeeTargetCode: args.FeeTargetCode,
+		CustomFee:     args.CustomFee,
+	})
+	if err != nil {
+		return nil, err
+	}
+	wireUTXO := make(map[wire.OutPoint]maketx.UTXO, len(utxos))
+	for outPoint, txOut := range utxos {
+		wireUTXO[outPoint] = maketx.UTXO{
+			TxOut:         txOut,
+			Configuration: utxoScripts[outPoint].configuration,
+		}
+	}
+	account.Synchronizer.WaitSynchronized()
+	address, err := account.sweepAddress()
+	if err != nil {
+		return nil, err
+	}
+	txProposal, err := maketx.NewTxSpendAll(
+		account.coin,
+		wireUTXO,
+		address.PubkeyScript(),
+		nil,
+		feeRatePerKb,
+		nil,
+		account.log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !wif.CompressPubKey {
+		// The size estimation assumes compressed public keys, which are 32 bytes shorter.
+		extraFee := feeRatePerKb * btcutil.Amount(32*len(txProposal.Transaction.TxIn)) / 1000
+		// The reduced output must still not be dust, or the transaction would be rejected.
+		if txProposal.Amount < extraFee+maketx.DustThreshold(address.PubkeyScript()) {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+		txProposal.Amount -= extraFee
+		txProposal.Fee += extraFee
+		txProposal.Transaction.TxOut[0].Value = int64(txProposal.Amount)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(txProposal.Transaction, txProposal.PreviousOutputs)
+	for index, txIn := range txProposal.Transaction.TxIn {
+		script := utxoScripts[txIn.PreviousOutPoint]
+		txOut := utxos[txIn.PreviousOutPoint]
+		switch script.configuration.ScriptType() {
+		case signing.ScriptTypeP2PKH:
+			txIn.SignatureScript, err = txscript.SignatureScript(
+				txProposal.Transaction, index, script.pkScript, txscript.SigHashAll,
+				wif.PrivKey, wif.CompressPubKey)
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+		case signing.ScriptTypeP2WPKH, signing.ScriptTypeP2WPKHP2SH:
+			witnessScript := script.pkScript
+			if script.redeemScript != nil {
+				witnessScript = script.redeemScript
+				txIn.SignatureScript, err = txscript.NewScriptBuilder().AddData(script.redeemScript).Script()
+				if err != nil {
+					return nil, errp.WithStack(err)
+				}
+			}
+			txIn.Witness, err = txscript.WitnessSignature(
+				txProposal.Transaction, sigHashes, index, txOut.Value, witnessScript,
+				txscript.SigHashAll, wif.PrivKey, true)
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+		}
+	}
+	if err := txValidityCheck(txProposal.Transaction, txProposal.PreviousOutputs, sigHashes); err != nil {
+		return nil, err
+	}
+	return txProposal, nil
+}
+
+// Sweep creates, signs and broadcasts a transaction sending all funds controlled by the given
+// private key to the account. See SweepProposal().
+func (account *Account) Sweep(args *SweepArgs) (*maketx.TxProposal, error) {
+	txProposal, err := account.SweepProposal(args)
+	if err != nil {
+		return nil, err
+	}
+	account.log.Info("Broadcasting sweep transaction")
+	if err := account.coin.Blockchain().TransactionBroadcast(account.ctx, txProposal.Transaction); err != nil {
+		return nil, err
+	}
+	return txProposal, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweep(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	privKey, _ := btcec.PrivKeyFromBytes(chainhash.HashB([]byte("sweep")))
+	wif, err := btcutil.NewWIF(privKey, net, true)
+	require.NoError(t, err)
+
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(pubKeyHash, net)
+	require.NoError(t, err)
+	p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, net)
+	require.NoError(t, err)
+	p2pkhScript, err := txscript.PayToAddrScript(p2pkhAddress)
+	require.NoError(t, err)
+	p2wpkhScript, err := txscript.PayToAddrScript(p2wpkhAddress)
+	require.NoError(t, err)
+
+	// Funds received on two different address types, one of which was partially spent already.
+	fundingTx := wire.NewMsgTx(wire.TxVersion)
+	fundingTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	fundingTx.AddTxOut(wire.NewTxOut(100000, p2wpkhScript))
+	fundingTx.AddTxOut(wire.NewTxOut(50000, p2pkhScript))
+	fundingTx.AddTxOut(wire.NewTxOut(20000, p2pkhScript))
+	fundingTxHash := fundingTx.TxHash()
+	spendingTx := wire.NewMsgTx(wire.TxVersion)
+	spendingTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingTxHash, 2), nil, nil))
+	txs := map[chainhash.Hash]*wire.MsgTx{
+		fundingTx.TxHash():  fundingTx,
+		spendingTx.TxHash(): spendingTx,
+	}
+	histories := map[blockchain.ScriptHashHex]blockchain.TxHistory{
+		blockchain.NewScriptHashHex(p2wpkhScript): {
+			{Height: 10, TXHash: blockchain.TXHash(fundingTx.TxHash())},
+		},
+		blockchain.NewScriptHashHex(p2pkhScript): {
+			{Height: 10, TXHash: blockchain.TXHash(fundingTx.TxHash())},
+			{Height: 11, TXHash: blockchain.TXHash(spendingTx.TxHash())},
+		},
+	}
+
+	var broadcastTx *wire.MsgTx
+	mock := &blockchainMock.BlockchainMock{
+		MockRegisterOnConnectionErrorChangedEvent: func(func(error)) {},
+		MockRelayFee: func() (btcutil.Amount, error) { return 1000, nil },
+		MockScriptHashGetHistory: func(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+			return histories[scriptHashHex], nil
+		},
+		MockTransactionGet: func(txHash chainhash.Hash) (*wire.MsgTx, error) {
+			return txs[txHash], nil
+		},
+		MockTransactionBroadcast: func(tx *wire.MsgTx) error {
+			broadcastTx = tx
+			return nil
+		},
+	}
+	account := mockAccountWithBlockchain(t, nil, mock)
+	require.NoError(t, account.Initialize())
+
+	args := &btc.SweepArgs{
+		WIF:           wif.String(),
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "1",
+	}
+	txProposal, err := account.SweepProposal(args)
+	require.NoError(t, err)
+	require.Nil(t, broadcastTx)
+	require.Len(t, txProposal.Transaction.TxIn, 2)
+	require.Len(t, txProposal.Transaction.TxOut, 1)
+	require.Equal(t, btcutil.Amount(150000), txProposal.Total())
+	require.Equal(t, int64(txProposal.Amount), txProposal.Transaction.TxOut[0].Value)
+	receiveAddress, err := account.NextReceiveAddress(nil)
+	require.NoError(t, err)
+	require.Equal(t, receiveAddress.Address.PubkeyScript(), txProposal.Transaction.TxOut[0].PkScript)
+	for _, txIn := range txProposal.Transaction.TxIn {
+		require.NotEqual(t, uint32(2), txIn.PreviousOutPoint.Index)
+	}
+
+	txProposal, err = account.Sweep(args)
+	require.NoError(t, err)
+	require.Equal(t, txProposal.Transaction, broadcastTx)
+
+	// Key for the wrong network.
+	mainnetWIF, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
+	require.NoError(t, err)
+	_, err = account.SweepProposal(&btc.SweepArgs{
+		WIF:           mainnetWIF.String(),
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "1",
+	})
+	require.Equal(t, errors.ErrPrivateKeyWrongNetwork, errp.Cause(err))
+
+	// Empty and malformatted keys.
+	for wifString, expectedErr := range map[string]error{
+		"":                  errors.ErrPrivateKeyEmpty,
+		"  \n":              errors.ErrPrivateKeyEmpty,
+		wif.String()[1:]:    errors.ErrInvalidPrivateKey,
+		"not a private key": errors.ErrInvalidPrivateKey,
+	} {
+		_, err = account.SweepProposal(&btc.SweepArgs{
+			WIF:           wifString,
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "1",
+		})
+		require.Equal(t, expectedErr, errp.Cause(err), wifString)
+	}
+
+	// Key without funds.
+	emptyKey, _ := btcec.PrivKeyFromBytes(chainhash.HashB([]byte("empty")))
+	emptyWIF, err := btcutil.NewWIF(emptyKey, net, true)
+	require.NoError(t, err)
+	_, err = account.SweepProposal(&btc.SweepArgs{
+		WIF:           emptyWIF.String(),
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "1",
+	})
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+
+	// The output of an uncompressed key would be dust after the fee of the larger inputs is added.
+	uncompressedKey, _ := btcec.PrivKeyFromBytes(chainhash.HashB([]byte("uncompressed")))
+	uncompressedWIF, err := btcutil.NewWIF(uncompressedKey, net, false)
+	require.NoError(t, err)
+	uncompressedAddress, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(uncompressedKey.PubKey().SerializeUncompressed()), net)
+	require.NoError(t, err)
+	uncompressedScript, err := txscript.PayToAddrScript(uncompressedAddress)
+	require.NoError(t, err)
+	smallFundingTx := wire.NewMsgTx(wire.TxVersion)
+	smallFundingTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 2}, nil, nil))
+	smallFundingTx.AddTxOut(wire.NewTxOut(2300, uncompressedScript))
+	txs[smallFundingTx.TxHash()] = smallFundingTx
+	histories[blockchain.NewScriptHashHex(uncompressedScript)] = blockchain.TxHistory{
+		{Height: 12, TXHash: blockchain.TXHash(smallFundingTx.TxHash())},
+	}
+	uncompressedArgs := &btc.SweepArgs{
+		WIF:           uncompressedWIF.String(),
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "10",
+	}
+	_, err = account.SweepProposal(uncompressedArgs)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+
+	// Enough left after the fee.
+	uncompressedArgs.CustomFee = "5"
+	txProposal, err = account.SweepProposal(uncompressedArgs)
+	require.NoError(t, err)
+	require.Equal(t, int64(txProposal.Amount), txProposal.Transaction.TxOut[0].Value)
+	require.Equal(t, btcutil.Amount(2300), txProposal.Total())
+}
@@ -15,6 +15,8 @@
 package synchronizer
 
 import (
+	"time"
+
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/sirupsen/logrus"
 )
@@ -79,3 +81,21 @@ func (synchronizer *Synchronizer) WaitSynchronized() {
 	}
 	<-wait
 }
+
+// WaitSynchronizedTimeout is like WaitSynchronized(), but waits at most for the given duration.
+// Returns false if the pending tasks did not finish in time.
+func (synchronizer *Synchronizer) WaitSynchronizedTimeout(timeout time.Duration) bool {
+	unlock := synchronizer.waitLock.RLock()
+	n := synchronizer.requestsCounter
+	wait := synchronizer.wait
+	unlock()
+	if n == 0 {
+		return true
+	}
+	select {
+	case <-wait:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package throttle implements an adaptive rate limit of the transaction downloads from a
+// blockchain server.
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInitialRate  = 10
+	defaultMinRate      = 1
+	defaultMaxRate      = 50
+	defaultBurst        = 10
+	defaultSlowResponse = 2 * time.Second
+
+	// decreaseFactor is applied to the rate after an error or a slow response.
+	decreaseFactor = 0.5
+	// pollInterval is how often a request waiting for requests of a higher priority checks again.
+	pollInterval = 10 * time.Millisecond
+)
+
+// Priority of a request. When the rate limit is reached, the waiting requests of a higher priority
+// are served first.
+type Priority int
+
+const (
+	// PriorityHistorical is for requests which are not needed to compute the balance, e.g. previous
+	// transactions which are only needed to display fees.
+	PriorityHistorical Priority = iota
+	// PriorityBalance is for requests needed to compute the balance, i.e. the transactions funding
+	// and spending the outputs of the account.
+	PriorityBalance
+
+	numPriorities
+)
+
+// Throttle is a token bucket rate limit whose rate adapts to the health of the server. Errors and
+// slow responses halve the rate, healthy responses increase it again, similar to the congestion
+// control of TCP. It is safe for concurrent use.
+type Throttle struct {
+	minRate      float64
+	maxRate      float64
+	burst        float64
+	slowResponse time.Duration
+
+	// rate is the current rate in requests per second.
+	rate       float64
+	tokens     float64
+	lastRefill time.Time
+	// waiting is the number of waiting requests by priority.
+	waiting [numPriorities]int
+	mu      sync.Mutex
+
+	now func() time.Time
+	log *logrus.Entry
+}
+
+// New creates a throttle configured by cfg. Zero or invalid values of cfg are replaced by the
+// defaults.
+func New(cfg config.TxFetchThrottle, log *logrus.Entry) *Throttle {
+	minRate := cfg.MinRate
+	if minRate <= 0 {
+		minRate = defaultMinRate
+	}
+	maxRate := cfg.MaxRate
+	if maxRate <= 0 {
+		maxRate = defaultMaxRate
+	}
+	if maxRate < minRate {
+		maxRate = minRate
+	}
+	initialRate := cfg.InitialRate
+	if initialRate <= 0 {
+		initialRate = defaultInitialRate
+	}
+	initialRate = min(max(initialRate, minRate), maxRate)
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = defaultBurst
+	}
+	slowResponse := time.Duration(cfg.SlowResponseMillis) * time.Millisecond
+	if slowResponse <= 0 {
+		slowResponse = defaultSlowResponse
+	}
+	throttle := &Throttle{
+		minRate:      minRate,
+		maxRate:      maxRate,
+		burst:        float64(burst),
+		slowResponse: slowResponse,
+		rate:         initialRate,
+		tokens:       float64(burst),
+		now:          time.Now,
+		log:          log.WithField("group", "throttle"),
+	}
+	throttle.lastRefill = throttle.now()
+	return throttle
+}
+
+// Rate returns the current rate limit in requests per second.
+func (throttle *Throttle) Rate() float64 {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	return throttle.rate
+}
+
+// refill adds the tokens accumulated since the last refill. `mu` must be held.
+func (throttle *Throttle) refill() {
+	now := throttle.now()
+	elapsed := now.Sub(throttle.lastRefill).Seconds()
+	if elapsed > 0 {
+		throttle.tokens = min(throttle.burst, throttle.tokens+elapsed*throttle.rate)
+	}
+	throttle.lastRefill = now
+}
+
+// higherPriorityWaiting returns true if requests of a higher priority are waiting. `mu` must be
+// held.
+func (throttle *Throttle) higherPriorityWaiting(priority Priority) bool {
+	for higher := priority + 1; higher < numPriorities; higher++ {
+		if throttle.waiting[higher] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until a request of the given priority may be made. The returned function must be
+// called with the error of the request once it finished, so the rate can adapt to the response
+// time and errors of the server.
+func (throttle *Throttle) Wait(priority Priority) func(error) {
+	throttle.mu.Lock()
+	throttle.waiting[priority]++
+	for {
+		throttle.refill()
+		if throttle.tokens >= 1 && !throttle.higherPriorityWaiting(priority) {
+			throttle.tokens--
+			throttle.waiting[priority]--
+			break
+		}
+		delay := pollInterval
+		if throttle.tokens < 1 {
+			delay = max(delay, time.Duration((1-throttle.tokens)/throttle.rate*float64(time.Second)))
+		}
+		throttle.mu.Unlock()
+		time.Sleep(delay)
+		throttle.mu.Lock()
+	}
+	throttle.mu.Unlock()
+	start := throttle.now()
+	return func(err error) {
+		throttle.adapt(throttle.now().Sub(start), err)
+	}
+}
+
+// adapt lowers the rate after an error or a slow response and raises it after a healthy one.
+func (throttle *Throttle) adapt(responseTime time.Duration, err error) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	// Refill at the old rate before changing it.
+	throttle.refill()
+	if err == nil && responseTime <= throttle.slowResponse {
+		// Additive increase: about one request per second more for each second of healthy
+		// requests.
+		throttle.rate = min(throttle.maxRate, throttle.rate+1/throttle.rate)
+		return
+	}
+	previousRate := throttle.rate
+	throttle.rate = max(throttle.minRate, throttle.rate*decreaseFactor)
+	if throttle.rate != previousRate {
+		throttle.log.WithError(err).
+			WithField("response-time", responseTime).
+			WithField("rate", throttle.rate).
+			Warn("Server unhealthy, lowering the transaction download rate")
+	}
+}
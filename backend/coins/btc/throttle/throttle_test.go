@@ -0,0 +1,112 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/stretchr/testify/require"
+)
+
+var log = logging.Get().WithGroup("throttle_test")
+
+func TestNew(t *testing.T) {
+	throttle := New(config.TxFetchThrottle{}, log)
+	require.Equal(t, float64(defaultInitialRate), throttle.Rate())
+	require.Equal(t, float64(defaultBurst), throttle.burst)
+	require.Equal(t, defaultSlowResponse, throttle.slowResponse)
+
+	// The initial rate is clamped to the configured range.
+	throttle = New(config.TxFetchThrottle{InitialRate: 100, MinRate: 2, MaxRate: 20}, log)
+	require.Equal(t, float64(20), throttle.Rate())
+	throttle = New(config.TxFetchThrottle{InitialRate: 1, MinRate: 2, MaxRate: 20}, log)
+	require.Equal(t, float64(2), throttle.Rate())
+}
+
+func TestAdapt(t *testing.T) {
+	throttle := New(config.TxFetchThrottle{
+		InitialRate:        8,
+		MinRate:            1,
+		MaxRate:            9,
+		SlowResponseMillis: 1000,
+	}, log)
+
+	throttle.adapt(time.Second, nil)
+	require.Equal(t, 8.125, throttle.Rate())
+
+	throttle.adapt(time.Second+time.Millisecond, nil)
+	require.Equal(t, 4.0625, throttle.Rate())
+
+	throttle.adapt(time.Millisecond, errors.New("error"))
+	require.InDelta(t, 2.03125, throttle.Rate(), 1e-9)
+
+	for i := 0; i < 10; i++ {
+		throttle.adapt(0, errors.New("error"))
+	}
+	require.Equal(t, float64(1), throttle.Rate())
+
+	for i := 0; i < 1000; i++ {
+		throttle.adapt(0, nil)
+	}
+	require.Equal(t, float64(9), throttle.Rate())
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	throttle := New(config.TxFetchThrottle{InitialRate: 20, MaxRate: 20, Burst: 2}, log)
+	start := time.Now()
+	throttle.Wait(PriorityBalance)(nil)
+	throttle.Wait(PriorityBalance)(nil)
+	require.Less(t, time.Since(start), 40*time.Millisecond)
+	// The burst is used up, the next request waits for a new token.
+	throttle.Wait(PriorityBalance)(nil)
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestWaitPriority(t *testing.T) {
+	throttle := New(config.TxFetchThrottle{InitialRate: 5, MaxRate: 5, Burst: 1}, log)
+	throttle.Wait(PriorityBalance)(nil)
+
+	var order []Priority
+	var orderMu sync.Mutex
+	var wg sync.WaitGroup
+	wait := func(priority Priority) {
+		defer wg.Done()
+		throttle.Wait(priority)(nil)
+		orderMu.Lock()
+		defer orderMu.Unlock()
+		order = append(order, priority)
+	}
+	waiting := func(priority Priority) func() bool {
+		return func() bool {
+			throttle.mu.Lock()
+			defer throttle.mu.Unlock()
+			return throttle.waiting[priority] == 1
+		}
+	}
+
+	// The historical request waits first, but the balance request is served first.
+	wg.Add(2)
+	go wait(PriorityHistorical)
+	require.Eventually(t, waiting(PriorityHistorical), time.Second, time.Millisecond)
+	go wait(PriorityBalance)
+	require.Eventually(t, waiting(PriorityBalance), time.Second, time.Millisecond)
+	wg.Wait()
+	require.Equal(t, []Priority{PriorityBalance, PriorityHistorical}, order)
+}
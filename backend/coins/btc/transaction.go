@@ -16,7 +16,7 @@
 package btc
 
 import (
-	"math/big"
+	"sort"
 	"strconv"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
@@ -36,6 +36,10 @@ import (
 // unitSatoshi is 1 BTC (default unit) in Satoshi.
 const unitSatoshi = 1e8
 
+// maxRecipients is the maximum number of recipients of a transaction, which keeps the number of
+// outputs to confirm on the device manageable.
+const maxRecipients = 100
+
 // getFeePerKb returns the fee rate to be used in a new transaction. It is deduced from the supplied
 // fee target (priority) if one is given, or the provided args.FeePerKb if the fee taret is
 // `FeeTargetCodeCustom`.
@@ -124,90 +128,224 @@ func (account *Account) pickChangeAddress(utxos map[wire.OutPoint]maketx.UTXO) (
 	return unusedAddresses[0], nil
 }
 
-// newTx creates a new tx to the given recipient address. It also returns a set of used account
+// hasMinConfirmations returns true if the output has enough confirmations to be spent according to
+// the MinConfirmations setting of the account. Unverified outputs are never spent, see
+// isUnverified().
+func (account *Account) hasMinConfirmations(output *transactions.SpendableOutput) bool {
+	accountConfig := account.Config().Config
+	if account.isUnverified(output) {
+		return false
+	}
+	if output.Confirmations >= accountConfig.MinConfirmations {
+		return true
+	}
+	return accountConfig.SpendUnconfirmedChange && output.OwnInputs
+}
+
+// newTx creates a new tx to the given recipients. It also returns a set of used account
 // outputs, which contains all outputs that spent in the tx. Those are needed to be able to sign the
 // transaction. selectedUTXOs restricts the available coins; if empty, no restriction is applied and
-// all unspent coins can be used.
+// all unspent coins can be used. Coins without the minimum number of confirmations configured for
+// the account are not used. Coins in the flag list of the account are only used if selected, and
+// not together with coins of a different origin, see SetFlags(). Frozen coins are only used if
+// selected and args.SpendFrozen is set, see SetOutputFrozen(). Hidden dust is never spent, see
+// isHiddenDust(). If args.CoverUnconfirmedParents is
+// set, the fee is raised to pay for the unconfirmed parents of the spent coins, see
+// coverUnconfirmedParents().
 func (account *Account) newTx(args *accounts.TxProposalArgs) (
 	map[wire.OutPoint]*transactions.SpendableOutput, *maketx.TxProposal, error) {
 
 	account.log.Debug("Prepare new transaction")
 
-	address, err := account.coin.DecodeAddress(args.RecipientAddress)
-	if err != nil {
-		return nil, nil, err
+	recipients := args.Recipients
+	if len(recipients) == 0 {
+		recipients = []accounts.Recipient{{Address: args.RecipientAddress, Amount: args.Amount}}
 	}
-	pkScript, err := util.PkScriptFromAddress(address)
-	if err != nil {
-		return nil, nil, err
+	if len(recipients) > maxRecipients {
+		return nil, nil, errp.WithStack(errors.ErrTooManyRecipients)
+	}
+	// recipientError reports which recipient is invalid if a list of recipients was given.
+	recipientError := func(index int, err error) error {
+		validationErr, ok := errp.Cause(err).(errors.TxValidationError)
+		if !ok || len(args.Recipients) == 0 {
+			return err
+		}
+		return errp.WithStack(&errors.RecipientError{Index: index, Err: validationErr})
+	}
+	sendAll := recipients[0].Amount.SendAll()
+	pkScripts := make([][]byte, len(recipients))
+	for index, recipient := range recipients {
+		if recipient.Amount.SendAll() && len(recipients) > 1 {
+			return nil, nil, errp.WithStack(errors.ErrSendAllMultipleRecipients)
+		}
+		address, err := account.coin.DecodeAddress(recipient.Address)
+		if err != nil {
+			return nil, nil, recipientError(index, err)
+		}
+		pkScripts[index], err = util.PkScriptFromAddress(address)
+		if err != nil {
+			return nil, nil, recipientError(index, err)
+		}
+	}
+	var outputs []*wire.TxOut
+	if !sendAll {
+		outputs = make([]*wire.TxOut, len(recipients))
+		for index, recipient := range recipients {
+			allowZero := false
+			parsedAmount, err := recipient.Amount.Amount(account.coin.formatUnit.SatsPerUnit(), allowZero)
+			if err != nil {
+				return nil, nil, recipientError(index, err)
+			}
+			parsedAmountInt64, err := parsedAmount.Int64()
+			if err != nil {
+				return nil, nil, recipientError(index, errp.WithStack(errors.ErrInvalidAmount))
+			}
+			outputs[index] = wire.NewTxOut(parsedAmountInt64, pkScripts[index])
+			if err := maketx.ValidateOutput(outputs[index], account.coin.maxSupply()); err != nil {
+				return nil, nil, recipientError(index, err)
+			}
+		}
+	}
+	if !args.SpendFrozen {
+		for outPoint := range args.SelectedUTXOs {
+			if account.isFrozen(outPoint) {
+				return nil, nil, errp.WithStack(errors.ErrFrozenOutputSelected)
+			}
+		}
 	}
 	utxo, err := account.transactions.SpendableOutputs()
 	if err != nil {
 		return nil, nil, err
 	}
+	// All coins, and the subset of coins which have enough confirmations to be spent.
 	wireUTXO := make(map[wire.OutPoint]maketx.UTXO, len(utxo))
+	confirmedWireUTXO := make(map[wire.OutPoint]maketx.UTXO, len(utxo))
+	// Outputs not passed to the coin selection, recorded in the selection trace.
+	rejectedUTXO := map[wire.OutPoint]maketx.UTXO{}
+	rejectedReasons := map[wire.OutPoint]maketx.SelectionReason{}
+	// Labels of the selected flagged coins, and whether coins which are not flagged are selected.
+	selectedFlagLabels := map[string]struct{}{}
+	selectedUnflagged := false
 	for outPoint, txOut := range utxo {
-		// Apply coin control.
+		output := maketx.UTXO{
+			TxOut: txOut.TxOut,
+			Configuration: account.getAddress(
+				blockchain.NewScriptHashHex(txOut.TxOut.PkScript)).Configuration,
+		}
+		// Hidden dust is never spent.
+		if account.isHiddenDust(txOut) {
+			rejectedUTXO[outPoint] = output
+			rejectedReasons[outPoint] = maketx.SelectionReasonDust
+			continue
+		}
+		// Frozen coins are only spent if selected explicitly and allowed, see above.
+		if _, selected := args.SelectedUTXOs[outPoint]; !selected && account.isFrozen(outPoint) {
+			rejectedUTXO[outPoint] = output
+			rejectedReasons[outPoint] = maketx.SelectionReasonFrozen
+			continue
+		}
+		flagLabel := account.flagLabel(outPoint, txOut.TxOut)
+		// Apply coin control. Flagged coins are only spent if selected explicitly.
 		if len(args.SelectedUTXOs) != 0 {
 			if _, ok := args.SelectedUTXOs[outPoint]; !ok {
+				rejectedUTXO[outPoint] = output
+				rejectedReasons[outPoint] = maketx.SelectionReasonCoinControl
 				continue
 			}
+			if flagLabel != "" {
+				selectedFlagLabels[flagLabel] = struct{}{}
+			} else {
+				selectedUnflagged = true
+			}
+		} else if flagLabel != "" {
+			rejectedUTXO[outPoint] = output
+			rejectedReasons[outPoint] = maketx.SelectionReasonFlagged
+			continue
 		}
-		wireUTXO[outPoint] = maketx.UTXO{
-			TxOut: txOut.TxOut,
-			Configuration: account.getAddress(
-				blockchain.NewScriptHashHex(txOut.TxOut.PkScript)).Configuration,
+		wireUTXO[outPoint] = output
+		if account.hasMinConfirmations(txOut) {
+			confirmedWireUTXO[outPoint] = output
+		} else {
+			rejectedUTXO[outPoint] = output
+			rejectedReasons[outPoint] = maketx.SelectionReasonUnconfirmed
 		}
 	}
+	// Flagged coins are never spent together with coins of a different origin.
+	if len(selectedFlagLabels) > 1 || (len(selectedFlagLabels) == 1 && selectedUnflagged) {
+		return nil, nil, errp.WithStack(errors.ErrFlaggedCoinsMixed)
+	}
 	feeRatePerKb, err := account.getFeePerKb(args)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	var txProposal *maketx.TxProposal
-	if args.Amount.SendAll() {
-		txProposal, err = maketx.NewTxSpendAll(
-			account.coin,
-			wireUTXO,
-			pkScript,
-			feeRatePerKb,
-			account.log,
-		)
+	var dataOutput *wire.TxOut
+	if len(args.OpReturnData) != 0 {
+		dataOutput, err = maketx.NewDataOutput(args.OpReturnData)
 		if err != nil {
 			return nil, nil, err
 		}
-	} else {
-		allowZero := false
+	}
 
-		unit := int64(unitSatoshi)
-		if account.coin.formatUnit == coin.BtcUnitSats {
-			unit = 1
-		}
-		parsedAmount, err := args.Amount.Amount(big.NewInt(unit), allowZero)
-		if err != nil {
-			return nil, nil, err
-		}
-		parsedAmountInt64, err := parsedAmount.Int64()
-		if err != nil {
-			return nil, nil, errp.WithStack(errors.ErrInvalidAmount)
+	makeTx := func(
+		wireUTXO map[wire.OutPoint]maketx.UTXO, feeRatePerKb btcutil.Amount) (*maketx.TxProposal, error) {
+		if sendAll {
+			return maketx.NewTxSpendAll(
+				account.coin,
+				wireUTXO,
+				pkScripts[0],
+				dataOutput,
+				feeRatePerKb,
+				args.SelectionSeed,
+				account.log,
+			)
 		}
 		changeAddress, err := account.pickChangeAddress(wireUTXO)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		account.log.Infof("Change address script type: %s", changeAddress.Configuration.ScriptType())
-		txProposal, err = maketx.NewTx(
+		return maketx.NewTx(
 			account.coin,
 			wireUTXO,
-			wire.NewTxOut(parsedAmountInt64, pkScript),
+			outputs,
+			dataOutput,
 			feeRatePerKb,
 			changeAddress,
+			args.SelectionSeed,
 			account.log,
 		)
+	}
+
+	txProposal, err := makeTx(confirmedWireUTXO, feeRatePerKb)
+	if errp.Cause(err) == errors.ErrInsufficientFunds && len(confirmedWireUTXO) < len(wireUTXO) {
+		// Tell the user if the funds would suffice if the coins without enough confirmations were
+		// spent too.
+		if _, errAll := makeTx(wireUTXO, feeRatePerKb); errAll == nil {
+			return nil, nil, errp.WithStack(errors.ErrInsufficientConfirmedFunds)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if args.CoverUnconfirmedParents {
+		txProposal, err = account.coverUnconfirmedParents(txProposal, feeRatePerKb,
+			func(feeRatePerKb btcutil.Amount) (*maketx.TxProposal, error) {
+				return makeTx(confirmedWireUTXO, feeRatePerKb)
+			})
 		if err != nil {
 			return nil, nil, err
 		}
 	}
+	rejectedOutPoints := make([]wire.OutPoint, 0, len(rejectedUTXO))
+	for outPoint := range rejectedUTXO {
+		rejectedOutPoints = append(rejectedOutPoints, outPoint)
+	}
+	sort.Slice(rejectedOutPoints, func(i, j int) bool {
+		return rejectedOutPoints[i].String() < rejectedOutPoints[j].String()
+	})
+	for _, outPoint := range rejectedOutPoints {
+		txProposal.SelectionTrace.AddRejected(outPoint, rejectedUTXO[outPoint], rejectedReasons[outPoint])
+	}
 	account.log.Debugf("creating tx with %d inputs, %d outputs",
 		len(txProposal.Transaction.TxIn), len(txProposal.Transaction.TxOut))
 	return utxo, txProposal, nil
@@ -216,15 +354,18 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 // getAddress returns the address in the account with the given `scriptHashHex`. Returns nil if the
 // address does not exist in the account.
 func (account *Account) getAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
-	for _, subacc := range account.subaccounts {
-		if address := subacc.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
-			return address
-		}
-		if address := subacc.changeAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
-			return address
-		}
+	defer account.addressesByScriptHashLock.RLock()()
+	return account.addressesByScriptHash[scriptHashHex]
+}
+
+// ActiveSelectionTrace returns how the inputs of the active tx proposal were selected, or nil if
+// there is no active tx proposal.
+func (account *Account) ActiveSelectionTrace() *maketx.SelectionTrace {
+	defer account.activeTxProposalLock.RLock()()
+	if account.activeTxProposal == nil {
+		return nil
 	}
-	return nil
+	return account.activeTxProposal.SelectionTrace
 }
 
 // SendTx implements accounts.Interface.
@@ -235,22 +376,42 @@ func (account *Account) SendTx() error {
 	if txProposal == nil {
 		return errp.New("No active tx proposal")
 	}
+	if err := account.BaseAccount.CheckRatesPin(); err != nil {
+		// The proposal has to be made again with the latest rates.
+		defer account.activeTxProposalLock.Lock()()
+		account.activeTxProposal = nil
+		return err
+	}
 
 	account.log.Info("Signing and sending transaction")
-	if err := account.signTransaction(txProposal, account.coin.Blockchain().TransactionGet); err != nil {
-		return errp.WithMessage(err, "Failed to sign transaction")
+	if !txProposal.Signed() {
+		if err := account.SignTransactionProposal(txProposal); err != nil {
+			return errp.WithMessage(err, "Failed to sign transaction")
+		}
 	}
 
 	account.log.Info("Signed transaction is broadcasted")
-	if err := account.coin.Blockchain().TransactionBroadcast(txProposal.Transaction); err != nil {
+	if err := account.BroadcastTransactionProposal(txProposal); err != nil {
 		return err
 	}
 
+	if err := account.flagChange(txProposal.TxProposal); err != nil {
+		// Not critical.
+		account.log.WithError(err).Error("Failed to flag the change of a transaction spending flagged coins")
+	}
+
+	txID := txProposal.Transaction.TxHash().String()
 	note := account.BaseAccount.GetAndClearProposedTxNote()
-	if err := account.SetTxNote(txProposal.Transaction.TxHash().String(), note); err != nil {
+	if err := account.SetTxNote(txID, note); err != nil {
 		// Not critical.
 		account.log.WithError(err).Error("Failed to save transaction note when sending a tx")
 	}
+	account.BaseAccount.UnpinRatesSnapshot(
+		txID,
+		coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.Total())),
+	)
 	return nil
 }
 
@@ -264,12 +425,13 @@ func (account *Account) TxProposal(
 	defer account.activeTxProposalLock.Lock()()
 
 	account.log.Debug("Proposing transaction")
-	_, txProposal, err := account.newTx(args)
+	txProposal, err := account.NewTransactionProposal(args)
 	if err != nil {
 		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
 	}
 
 	account.activeTxProposal = txProposal
+	account.BaseAccount.PinRatesSnapshot()
 
 	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
 	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
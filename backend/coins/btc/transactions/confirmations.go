@@ -0,0 +1,164 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transactions
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// numConfirmationsComplete is the number of confirmations needed for a tx to be considered
+// complete.
+const numConfirmationsComplete = 6
+
+// confirmationThresholds are the confirmation counts at which a change in the number of
+// confirmations of a tx is reported: when the tx confirms and when it is complete.
+var confirmationThresholds = []int{1, numConfirmationsComplete}
+
+// countConfirmations returns the number of confirmations of a tx at the given height, given the
+// current tip height. Unconfirmed txs, txs seen while the tip is unknown and txs in blocks above the
+// verified tip have 0 confirmations.
+func countConfirmations(height int, tipHeight int) int {
+	if height <= 0 || tipHeight <= 0 || height > tipHeight {
+		return 0
+	}
+	return tipHeight - height + 1
+}
+
+// crossesConfirmationThreshold returns true if the confirmation count changed from one side of a
+// threshold to the other, in either direction. Going back happens e.g. after a reorg.
+func crossesConfirmationThreshold(before, after int) bool {
+	for _, threshold := range confirmationThresholds {
+		if (before >= threshold) != (after >= threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateConfirmations reads the heights of all transactions and computes their confirmation
+// counts. It is only used to load the transactions when the instance is created. Afterwards, the
+// heights are kept up to date by updateTxConfirmations(), so that a new tip does not require
+// reading the database, see updateTipConfirmations().
+func (transactions *Transactions) updateConfirmations() {
+	if transactions.isClosed() {
+		return
+	}
+	heights, err := DBView(transactions.db, func(dbTx DBTxInterface) (map[chainhash.Hash]int, error) {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return nil, err
+		}
+		heights := make(map[chainhash.Hash]int, len(txHashes))
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return nil, err
+			}
+			_, heights[txHash] = txInfo.Status()
+		}
+		return heights, nil
+	})
+	if err != nil {
+		transactions.log.WithError(err).Error("Failed to compute the confirmations")
+		return
+	}
+	transactions.applyConfirmations(heights, nil, false)
+}
+
+// updateTxConfirmations reads the heights of the given transactions, e.g. the ones touched by an
+// address history update, and updates their confirmation counts, so that the work does not grow
+// with the number of transactions of the account. Transactions which were deleted are forgotten.
+func (transactions *Transactions) updateTxConfirmations(txHashes []chainhash.Hash) {
+	if transactions.isClosed() || len(txHashes) == 0 {
+		return
+	}
+	type result struct {
+		heights map[chainhash.Hash]int
+		deleted []chainhash.Hash
+	}
+	res, err := DBView(transactions.db, func(dbTx DBTxInterface) (*result, error) {
+		res := &result{heights: make(map[chainhash.Hash]int, len(txHashes))}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return nil, err
+			}
+			if txInfo.Tx == nil {
+				res.deleted = append(res.deleted, txHash)
+				continue
+			}
+			_, res.heights[txHash] = txInfo.Status()
+		}
+		return res, nil
+	})
+	if err != nil {
+		transactions.log.WithError(err).Error("Failed to compute the confirmations")
+		return
+	}
+	transactions.applyConfirmations(res.heights, res.deleted, false)
+}
+
+// updateTipConfirmations recomputes the confirmation counts of the known transactions after the
+// tip changed. The heights of the transactions are not affected by a new tip, so the database is
+// not read.
+func (transactions *Transactions) updateTipConfirmations() {
+	if transactions.isClosed() {
+		return
+	}
+	transactions.applyConfirmations(nil, nil, true)
+}
+
+// applyConfirmations stores the heights of the given txs and forgets the deleted ones. Then it
+// recomputes the confirmation counts of the given txs, or of all known txs if `tipChanged` is true,
+// and reports the txs which crossed a confirmation threshold. Txs seen for the first time are not
+// reported.
+func (transactions *Transactions) applyConfirmations(
+	heights map[chainhash.Hash]int, deleted []chainhash.Hash, tipChanged bool) {
+	type change struct {
+		txHash           chainhash.Hash
+		numConfirmations int
+	}
+	var changes []change
+	func() {
+		defer transactions.confirmationsLock.Lock()()
+		if transactions.txHeights == nil {
+			transactions.txHeights = map[chainhash.Hash]int{}
+			transactions.confirmations = map[chainhash.Hash]int{}
+		}
+		for _, txHash := range deleted {
+			delete(transactions.txHeights, txHash)
+			delete(transactions.confirmations, txHash)
+		}
+		for txHash, height := range heights {
+			transactions.txHeights[txHash] = height
+		}
+		recompute := heights
+		if tipChanged {
+			recompute = transactions.txHeights
+		}
+		tipHeight := transactions.headersTipHeight
+		for txHash, height := range recompute {
+			after := countConfirmations(height, tipHeight)
+			before, ok := transactions.confirmations[txHash]
+			transactions.confirmations[txHash] = after
+			if ok && crossesConfirmationThreshold(before, after) {
+				changes = append(changes, change{txHash: txHash, numConfirmations: after})
+			}
+		}
+	}()
+	for _, change := range changes {
+		transactions.onConfirmationsChanged(change.txHash, change.numConfirmations)
+	}
+}
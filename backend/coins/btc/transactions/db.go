@@ -32,6 +32,8 @@ type DBTxInfo struct {
 	Verified         *bool           `json:"Verified"`
 	HeaderTimestamp  *time.Time      `json:"ts"`
 	CreatedTimestamp *time.Time      `json:"created"`
+	// MerkleProof is the proof of inclusion of the tx in its block, stored when the tx is verified.
+	MerkleProof *MerkleProof `json:"merkleProof,omitempty"`
 
 	// TxHash is the same as Tx.TxHash(), but since we already have this value in the database, it
 	// is faster to access it this way than to recompute it.  It is not serialized and stored in the
@@ -39,6 +41,38 @@ type DBTxInfo struct {
 	TxHash chainhash.Hash `json:"-"`
 }
 
+// MerkleProof is the proof that a tx is included in a block, as returned by the server and verified
+// against the block header.
+type MerkleProof struct {
+	// BlockHash is the hash of the header the proof was verified against.
+	BlockHash chainhash.Hash `json:"blockHash"`
+	// Pos is the position of the tx in the block.
+	Pos int `json:"pos"`
+	// Merkle is the merkle branch from the tx to the merkle root.
+	Merkle []blockchain.TXHash `json:"merkle"`
+}
+
+// MerkleRoot returns the merkle root of the block computed from the given tx hash and the proof.
+// It matches the merkle root of the block header if the tx is included in the block.
+func (proof *MerkleProof) MerkleRoot(txHash chainhash.Hash) chainhash.Hash {
+	return hashMerkleRoot(proof.Merkle, txHash, proof.Pos)
+}
+
+// OutgoingTransaction is a transaction sent by the account. It is kept until it confirms or is
+// replaced, and broadcast again in the meantime in case the servers dropped it from their mempool.
+type OutgoingTransaction struct {
+	Tx *wire.MsgTx `json:"tx"`
+	// LastBroadcast is the time of the last broadcast attempt.
+	LastBroadcast time.Time `json:"lastBroadcast"`
+}
+
+// Status returns the confirmation status of the tx and the height of the block containing it,
+// which is 0 if the tx is unconfirmed. `Height` is stored as encoded by the Electrum protocol, so
+// it can be -1 for unconfirmed txs and must not be used as a block height directly.
+func (txInfo *DBTxInfo) Status() (blockchain.TxStatus, int) {
+	return blockchain.NormalizeTxHeight(txInfo.Height)
+}
+
 // DBTxInterface needs to be implemented to persist all wallet/transaction related data.
 type DBTxInterface interface {
 	// Commit closes the transaction, writing the changes.
@@ -68,8 +102,13 @@ type DBTxInterface interface {
 	// UnverifiedTransactions retrieves all stored transaction hashes of unverified transactions.
 	UnverifiedTransactions() ([]chainhash.Hash, error)
 
-	// MarkTxVerified marks a tx as verified. Stores timestamp of the header this tx appears in.
-	MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) error
+	// MarkTxVerified marks a tx as verified. Stores timestamp of the header this tx appears in and
+	// the merkle proof the tx was verified with.
+	MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time, merkleProof *MerkleProof) error
+
+	// MarkTxUnverified marks a tx as unverified again, e.g. after a reorg, and removes the stored
+	// header timestamp and merkle proof.
+	MarkTxUnverified(txHash chainhash.Hash) error
 
 	// PutInput stores a transaction input. It is referenced by the output it spends. The
 	// transaction hash of the transaction this input was found in is recorded. TODO: store slice of
@@ -99,6 +138,25 @@ type DBTxInterface interface {
 	// AddressHistory retrieves an address history. If not found, returns an empty history.
 	AddressHistory(blockchain.ScriptHashHex) (blockchain.TxHistory, error)
 
+	// PutOutgoingTransaction stores an outgoing transaction, replacing the stored one with the same
+	// hash.
+	PutOutgoingTransaction(*OutgoingTransaction) error
+
+	// OutgoingTransaction retrieves an outgoing transaction. `nil, nil` is returned if not found.
+	OutgoingTransaction(chainhash.Hash) (*OutgoingTransaction, error)
+
+	// OutgoingTransactions retrieves all stored outgoing transactions.
+	OutgoingTransactions() ([]*OutgoingTransaction, error)
+
+	// DeleteOutgoingTransaction deletes an outgoing transaction (nothing happens if not found).
+	DeleteOutgoingTransaction(chainhash.Hash)
+
+	// PutHandedOutAddress marks a receive address as handed out to receive funds.
+	PutHandedOutAddress(blockchain.ScriptHashHex) error
+
+	// IsHandedOutAddress returns true if the receive address was handed out to receive funds.
+	IsHandedOutAddress(blockchain.ScriptHashHex) (bool, error)
+
 	// PutGapLimits stores the gap limits for receive and change addresses.
 	PutGapLimits(types.GapLimits) error
 
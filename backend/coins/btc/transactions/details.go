@@ -0,0 +1,153 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transactions
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	btcdBlockchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TxInputDetails describes an input of a transaction.
+type TxInputDetails struct {
+	PreviousOutPoint wire.OutPoint
+	// Address is the address of the spent output. Empty if the spent output is unknown, or if its
+	// script can't be decoded to an address.
+	Address string
+	// Value is the value of the spent output. nil if the spent output is unknown.
+	Value *btcutil.Amount
+	// Ours is true if the spent output belongs to the account.
+	Ours bool
+}
+
+// TxOutputDetails describes an output of a transaction.
+type TxOutputDetails struct {
+	Index uint32
+	// Address is the address the output pays to, or unknownAddress if the script can't be decoded
+	// to an address.
+	Address string
+	Value   btcutil.Amount
+	// Ours is true if the output belongs to the account.
+	Ours bool
+	// Change is true if the output belongs to a change address of the account.
+	Change bool
+}
+
+// TxDetails describes all inputs and outputs of a transaction.
+type TxDetails struct {
+	TxHash  chainhash.Hash
+	Inputs  []*TxInputDetails
+	Outputs []*TxOutputDetails
+	Size    int64
+	VSize   int64
+	Weight  int64
+	// Fee and FeeRatePerKb are nil unless the values of all spent outputs are known.
+	Fee          *btcutil.Amount
+	FeeRatePerKb *btcutil.Amount
+}
+
+// previousOutput returns the output spent by an input if it is known, i.e. if it belongs to the
+// account or the transaction creating it is stored because it touches the account.
+func (transactions *Transactions) previousOutput(
+	dbTx DBTxInterface, outPoint wire.OutPoint) (*wire.TxOut, bool, error) {
+	txOut, err := dbTx.Output(outPoint)
+	if err != nil {
+		return nil, false, err
+	}
+	if txOut != nil {
+		return txOut, true, nil
+	}
+	previousTxInfo, err := dbTx.TxInfo(outPoint.Hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if previousTxInfo == nil || previousTxInfo.Tx == nil ||
+		outPoint.Index >= uint32(len(previousTxInfo.Tx.TxOut)) {
+		return nil, false, nil
+	}
+	return previousTxInfo.Tx.TxOut[outPoint.Index], false, nil
+}
+
+// TxDetails returns the decoded inputs and outputs of a transaction of the account. The values of
+// the spent outputs are known for the outputs of the account and of the other stored transactions.
+// nil is returned if the transaction is not found.
+func (transactions *Transactions) TxDetails(
+	txHash chainhash.Hash,
+	isChange func(blockchain.ScriptHashHex) bool) (*TxDetails, error) {
+	transactions.synchronizer.WaitSynchronized()
+	return DBView(transactions.db, func(dbTx DBTxInterface) (*TxDetails, error) {
+		txInfo, err := dbTx.TxInfo(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil || txInfo.Tx == nil {
+			return nil, nil
+		}
+		btcutilTx := btcutil.NewTx(txInfo.Tx)
+		details := &TxDetails{
+			TxHash:  txHash,
+			Inputs:  []*TxInputDetails{},
+			Outputs: []*TxOutputDetails{},
+			Size:    int64(txInfo.Tx.SerializeSize()),
+			VSize:   mempool.GetTxVirtualSize(btcutilTx),
+			Weight:  btcdBlockchain.GetTransactionWeight(btcutilTx),
+		}
+		var sumInputs, sumOutputs btcutil.Amount
+		allInputsKnown := true
+		for _, txIn := range txInfo.Tx.TxIn {
+			input := &TxInputDetails{PreviousOutPoint: txIn.PreviousOutPoint}
+			previousOutput, ours, err := transactions.previousOutput(dbTx, txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+			if previousOutput != nil {
+				value := btcutil.Amount(previousOutput.Value)
+				input.Value = &value
+				input.Ours = ours
+				if address := transactions.outputToAddress(previousOutput.PkScript); address != unknownAddress {
+					input.Address = address
+				}
+				sumInputs += value
+			} else {
+				allInputsKnown = false
+			}
+			details.Inputs = append(details.Inputs, input)
+		}
+		for index, txOut := range txInfo.Tx.TxOut {
+			output, err := dbTx.Output(wire.OutPoint{Hash: txHash, Index: uint32(index)})
+			if err != nil {
+				return nil, err
+			}
+			details.Outputs = append(details.Outputs, &TxOutputDetails{
+				Index:   uint32(index),
+				Address: transactions.outputToAddress(txOut.PkScript),
+				Value:   btcutil.Amount(txOut.Value),
+				Ours:    output != nil,
+				Change:  output != nil && isChange(getScriptHashHex(output)),
+			})
+			sumOutputs += btcutil.Amount(txOut.Value)
+		}
+		if allInputsKnown {
+			fee := sumInputs - sumOutputs
+			feeRatePerKb := fee * 1000 / btcutil.Amount(details.VSize)
+			details.Fee = &fee
+			details.FeeRatePerKb = &feeRatePerKb
+		}
+		return details, nil
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transactions
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PutOutgoingTransaction stores a transaction sent by the account together with the time it was
+// broadcast, so it can be broadcast again until it confirms, see OutgoingTransactionsToBroadcast().
+func (transactions *Transactions) PutOutgoingTransaction(tx *wire.MsgTx, broadcastTime time.Time) error {
+	return transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		return dbTx.PutOutgoingTransaction(&OutgoingTransaction{Tx: tx, LastBroadcast: broadcastTime})
+	})
+}
+
+// OutgoingTransactionsToBroadcast returns the stored outgoing transactions which need to be
+// broadcast again. Outgoing transactions which confirmed or which were replaced by another
+// transaction spending one of their inputs are removed and not broadcast anymore.
+func (transactions *Transactions) OutgoingTransactionsToBroadcast() ([]*OutgoingTransaction, error) {
+	result := []*OutgoingTransaction{}
+	err := transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		outgoingTxs, err := dbTx.OutgoingTransactions()
+		if err != nil {
+			return err
+		}
+		for _, outgoingTx := range outgoingTxs {
+			txHash := outgoingTx.Tx.TxHash()
+			done, err := transactions.isConfirmedOrReplaced(dbTx, txHash, outgoingTx.Tx)
+			if err != nil {
+				return err
+			}
+			if done {
+				transactions.log.WithField("txHash", txHash).
+					Info("Outgoing transaction confirmed or replaced, not broadcasting it anymore")
+				dbTx.DeleteOutgoingTransaction(txHash)
+				continue
+			}
+			result = append(result, outgoingTx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (transactions *Transactions) isConfirmedOrReplaced(
+	dbTx DBTxInterface, txHash chainhash.Hash, tx *wire.MsgTx) (bool, error) {
+	txInfo, err := dbTx.TxInfo(txHash)
+	if err != nil {
+		return false, err
+	}
+	if txInfo != nil && txInfo.Tx != nil {
+		if status, _ := txInfo.Status(); status == blockchain.TxStatusConfirmed {
+			return true, nil
+		}
+	}
+	for _, txIn := range tx.TxIn {
+		spentBy, err := dbTx.Input(txIn.PreviousOutPoint)
+		if err != nil {
+			return false, err
+		}
+		if spentBy != nil && *spentBy != txHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MarkOutgoingTransactionBroadcast records the time of the last broadcast attempt of an outgoing
+// transaction. Nothing happens if the transaction is not stored anymore.
+func (transactions *Transactions) MarkOutgoingTransactionBroadcast(
+	txHash chainhash.Hash, broadcastTime time.Time) error {
+	return transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		outgoingTx, err := dbTx.OutgoingTransaction(txHash)
+		if err != nil || outgoingTx == nil {
+			return err
+		}
+		outgoingTx.LastBroadcast = broadcastTime
+		return dbTx.PutOutgoingTransaction(outgoingTx)
+	})
+}
@@ -15,10 +15,14 @@
 package transactions
 
 import (
+	"context"
+	"time"
+
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/synchronizer"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/throttle"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
@@ -35,6 +39,14 @@ import (
 // SpendableOutput is an unspent coin.
 type SpendableOutput struct {
 	*wire.TxOut
+	// Confirmations is the number of confirmations of the tx creating the output. 0 if it is
+	// unconfirmed.
+	Confirmations int
+	// OwnInputs is true if all inputs of the tx creating the output are ours, e.g. for change.
+	OwnInputs bool
+	// Verified is true if the tx creating the output was verified to be included in its block
+	// against the block headers (SPV). It is false for unconfirmed outputs.
+	Verified bool
 }
 
 // ScriptHashHex returns the hash of the PkScript of the output, in hex format.
@@ -59,10 +71,36 @@ type Transactions struct {
 
 	unsubscribeHeadersEvent func()
 
+	// txHeights holds the height of each tx, see blockchain.TxInfo.Status(), and confirmations
+	// the number of confirmations computed from it, as of the last confirmations update. Both are
+	// kept in memory so that a new tip does not require reading all txs from the database.
+	txHeights         map[chainhash.Hash]int
+	confirmations     map[chainhash.Hash]int
+	confirmationsLock locker.Locker
+	// onConfirmationsChanged is called when a tx crossed a confirmation threshold, see
+	// confirmationThresholds.
+	onConfirmationsChanged func(txHash chainhash.Hash, numConfirmations int)
+	// onVerificationChanged is called when a tx was verified against the block headers, or marked
+	// unverified again after a reorg.
+	onVerificationChanged func(txHash chainhash.Hash)
+
+	// ordered caches the result of Transactions() until the database or the chain tip changes, so
+	// the history is not rebuilt and sorted on every request. orderedGeneration is incremented on
+	// every invalidation, see invalidateOrdered().
+	ordered           accounts.OrderedTransactions
+	orderedGeneration uint64
+	orderedLock       locker.Locker
+
 	synchronizer *synchronizer.Synchronizer
 	blockchain   blockchain.Interface
-	notifier     accounts.Notifier
-	log          *logrus.Entry
+	// fetchThrottle limits the rate of the transaction downloads.
+	fetchThrottle *throttle.Throttle
+	notifier      accounts.Notifier
+	log           *logrus.Entry
+
+	// ctx is cancelled on Close(), cancelling pending downloads.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	closed     bool
 	closedLock locker.Locker
@@ -75,9 +113,13 @@ func NewTransactions(
 	headers headers.Interface,
 	synchronizer *synchronizer.Synchronizer,
 	blockchain blockchain.Interface,
+	fetchThrottle *throttle.Throttle,
 	notifier accounts.Notifier,
+	onConfirmationsChanged func(txHash chainhash.Hash, numConfirmations int),
+	onVerificationChanged func(txHash chainhash.Hash),
 	log *logrus.Entry,
 ) *Transactions {
+	ctx, cancel := context.WithCancel(context.Background())
 	transactions := &Transactions{
 		net:     net,
 		db:      db,
@@ -85,17 +127,25 @@ func NewTransactions(
 
 		headersTipHeight: headers.TipHeight(),
 
-		synchronizer: synchronizer,
-		blockchain:   blockchain,
-		notifier:     notifier,
-		log:          log.WithFields(logrus.Fields{"group": "transactions", "net": net.Name}),
+		onConfirmationsChanged: onConfirmationsChanged,
+		onVerificationChanged:  onVerificationChanged,
+
+		synchronizer:  synchronizer,
+		blockchain:    blockchain,
+		fetchThrottle: fetchThrottle,
+		notifier:      notifier,
+		log:           log.WithFields(logrus.Fields{"group": "transactions", "net": net.Name}),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
+	transactions.updateConfirmations()
 	transactions.unsubscribeHeadersEvent = headers.SubscribeEvent(transactions.onHeadersEvent)
 	return transactions
 }
 
 // Close cleans up when finished using.
 func (transactions *Transactions) Close() {
+	transactions.cancel()
 	defer transactions.closedLock.Lock()()
 	if transactions.closed {
 		transactions.log.Debug("account aleady closed")
@@ -125,10 +175,22 @@ func (transactions *Transactions) processTxForAddress(
 		transactions.log.WithError(err).Error("Failed notifier.Put")
 	}
 
-	// Newly confirmed tx. Try to verify it.
-	if txInfo.Height <= 0 && height > 0 {
-		transactions.log.Debug("Try to verify newly confirmed tx")
-		go transactions.verifyTransaction(txHash, height)
+	previousStatus, previousHeight := txInfo.Status()
+	status, blockHeight := blockchain.NormalizeTxHeight(height)
+	if status == blockchain.TxStatusConfirmed {
+		switch {
+		case previousStatus != blockchain.TxStatusConfirmed:
+			// Newly confirmed tx. Try to verify it.
+			transactions.log.Debug("Try to verify newly confirmed tx")
+			go transactions.verifyTransaction(txHash, blockHeight)
+		case previousHeight != blockHeight:
+			// Moved to another block by a reorg. The stored proof is for the previous block.
+			transactions.log.Debug("Try to verify tx moved to another block")
+			if err := dbTx.MarkTxUnverified(txHash); err != nil {
+				transactions.log.WithError(err).Panic("Failed to mark tx unverified")
+			}
+			go transactions.verifyTransaction(txHash, blockHeight)
+		}
 	}
 
 	if err := dbTx.AddAddressToTx(txHash, scriptHashHex); err != nil {
@@ -208,11 +270,16 @@ func (transactions *Transactions) SpendableOutputs() (map[wire.OutPoint]*Spendab
 				if err != nil {
 					return nil, err
 				}
-				confirmed := txInfo.Height > 0
+				status, height := txInfo.Status()
+				confirmed := status == blockchain.TxStatusConfirmed
+				ownInputs := transactions.allInputsOurs(dbTx, txInfo.Tx)
 
-				if confirmed || transactions.allInputsOurs(dbTx, txInfo.Tx) {
+				if confirmed || ownInputs {
 					result[outPoint] = &SpendableOutput{
-						TxOut: txOut,
+						TxOut:         txOut,
+						Confirmations: countConfirmations(height, transactions.headersTipHeight),
+						OwnInputs:     ownInputs,
+						Verified:      confirmed && txInfo.Verified != nil && *txInfo.Verified,
 					}
 				}
 			}
@@ -221,6 +288,75 @@ func (transactions *Transactions) SpendableOutputs() (map[wire.OutPoint]*Spendab
 	})
 }
 
+// UnspentOutput is an unspent output of the wallet along with the tx creating it.
+type UnspentOutput struct {
+	*wire.TxOut
+	// Tx is the tx creating the output.
+	Tx *wire.MsgTx
+	// Height is the height of the block containing Tx. 0 if it is unconfirmed.
+	Height int
+	// MerkleProof is the proof that Tx is included in its block. nil if Tx is not verified yet.
+	MerkleProof *MerkleProof
+}
+
+// UnspentOutputs returns all unspent outputs, including unconfirmed ones. Unlike
+// SpendableOutputs(), it does not wait for the synchronization to finish. The outputs are read in a
+// single database transaction, so they form a consistent snapshot even while syncing.
+func (transactions *Transactions) UnspentOutputs() (map[wire.OutPoint]*UnspentOutput, error) {
+	return DBView(transactions.db, func(dbTx DBTxInterface) (map[wire.OutPoint]*UnspentOutput, error) {
+		outputs, err := dbTx.Outputs()
+		if err != nil {
+			return nil, err
+		}
+		result := map[wire.OutPoint]*UnspentOutput{}
+		for outPoint, txOut := range outputs {
+			if transactions.isInputSpent(dbTx, outPoint) {
+				continue
+			}
+			txInfo, err := dbTx.TxInfo(outPoint.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if txInfo == nil {
+				return nil, errp.Newf("tx of output %s not found", outPoint)
+			}
+			_, height := txInfo.Status()
+			result[outPoint] = &UnspentOutput{
+				TxOut:       txOut,
+				Tx:          txInfo.Tx,
+				Height:      height,
+				MerkleProof: txInfo.MerkleProof,
+			}
+		}
+		return result, nil
+	})
+}
+
+// HasOutput returns true if the outpoint is an output of one of the transactions of the wallet.
+// The output does not need to belong to the wallet, e.g. it can be the recipient output of an
+// outgoing transaction.
+func (transactions *Transactions) HasOutput(outPoint wire.OutPoint) (bool, error) {
+	return DBView(transactions.db, func(dbTx DBTxInterface) (bool, error) {
+		txInfo, err := dbTx.TxInfo(outPoint.Hash)
+		if err != nil {
+			return false, err
+		}
+		return txInfo != nil && txInfo.Tx != nil && outPoint.Index < uint32(len(txInfo.Tx.TxOut)), nil
+	})
+}
+
+// HasInput returns true if the outpoint is spent by an input of one of the transactions of the
+// wallet.
+func (transactions *Transactions) HasInput(outPoint wire.OutPoint) (bool, error) {
+	return DBView(transactions.db, func(dbTx DBTxInterface) (bool, error) {
+		txHash, err := dbTx.Input(outPoint)
+		if err != nil {
+			return false, err
+		}
+		return txHash != nil, nil
+	})
+}
+
 func (transactions *Transactions) isInputSpent(dbTx DBTxInterface, outPoint wire.OutPoint) bool {
 	input, err := dbTx.Input(outPoint)
 	if err != nil {
@@ -229,8 +365,10 @@ func (transactions *Transactions) isInputSpent(dbTx DBTxInterface, outPoint wire
 	return input != nil
 }
 
+// removeTxForAddress removes the address from the tx. It returns true if the tx does not touch
+// any of our addresses anymore and was deleted.
 func (transactions *Transactions) removeTxForAddress(
-	dbTx DBTxInterface, scriptHashHex blockchain.ScriptHashHex, txHash chainhash.Hash) {
+	dbTx DBTxInterface, scriptHashHex blockchain.ScriptHashHex, txHash chainhash.Hash) bool {
 	transactions.log.Debug("Remove transaction for address")
 	txInfo, err := dbTx.TxInfo(txHash)
 	if err != nil {
@@ -239,7 +377,7 @@ func (transactions *Transactions) removeTxForAddress(
 	if txInfo == nil {
 		// Not yet indexed.
 		transactions.log.Debug("Transaction hash not listed")
-		return
+		return false
 	}
 
 	transactions.log.Debug("Deleting transaction address")
@@ -264,21 +402,26 @@ func (transactions *Transactions) removeTxForAddress(
 		}
 
 		dbTx.DeleteTx(txHash)
-		if err := transactions.notifier.Delete(txHash[:]); err != nil {
-			transactions.log.WithError(err).Error("Failed notifier.Delete")
-		}
 	}
+	return empty
 }
 
 // UpdateAddressHistory should be called when initializing a wallet address, or when the history of
 // an address changes (a new transaction that touches it appears or disappears). The transactions
-// are downloaded and indexed.
-func (transactions *Transactions) UpdateAddressHistory(scriptHashHex blockchain.ScriptHashHex, txs []*blockchain.TxInfo) {
+// are downloaded and indexed. It returns the number of transactions which had to be downloaded, and
+// the time spent downloading them.
+func (transactions *Transactions) UpdateAddressHistory(
+	scriptHashHex blockchain.ScriptHashHex, txs []*blockchain.TxInfo) (int, time.Duration) {
 	if transactions.isClosed() {
 		transactions.log.Debug("UpdateAddressHistory after the instance was closed")
-		return
+		return 0, 0
 	}
-	err := DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
+	numDownloaded := 0
+	var downloadDuration time.Duration
+	// The txs which were added, removed or changed height. Only these are processed and have their
+	// confirmations updated.
+	var changedTxs []chainhash.Hash
+	err := transactions.dbUpdate(func(dbTx DBTxInterface) error {
 		txsSet := map[chainhash.Hash]struct{}{}
 		for _, txInfo := range txs {
 			txsSet[txInfo.TXHash.Hash()] = struct{}{}
@@ -290,14 +433,22 @@ func (transactions *Transactions) UpdateAddressHistory(scriptHashHex blockchain.
 		if err != nil {
 			return err
 		}
+		previousHeights := make(map[chainhash.Hash]int, len(previousHistory))
 		for _, entry := range previousHistory {
+			previousHeights[entry.TXHash.Hash()] = entry.Height
 			if _, txOK := txsSet[entry.TXHash.Hash()]; txOK {
 				continue
 			}
 			// A tx was previously in the address history but is not anymore.  If the tx was already
 			// downloaded and indexed, it will be removed.  If it is currently downloading (enqueued for
 			// indexing), it will not be processed.
-			transactions.removeTxForAddress(dbTx, scriptHashHex, entry.TXHash.Hash())
+			txHash := entry.TXHash.Hash()
+			changedTxs = append(changedTxs, txHash)
+			if transactions.removeTxForAddress(dbTx, scriptHashHex, txHash) {
+				if err := transactions.notifier.Delete(txHash[:]); err != nil {
+					transactions.log.WithError(err).Error("Failed notifier.Delete")
+				}
+			}
 		}
 
 		if err := dbTx.PutAddressHistory(scriptHashHex, txs); err != nil {
@@ -306,33 +457,93 @@ func (transactions *Transactions) UpdateAddressHistory(scriptHashHex blockchain.
 		for _, txInfo := range txs {
 			txHash := txInfo.TXHash.Hash()
 			height := txInfo.Height
-			tx := transactions.getTransactionCached(dbTx, txHash)
+			if previousHeight, ok := previousHeights[txHash]; ok && previousHeight == height {
+				// Already processed with the previous history, which is stored in the same db
+				// transaction as the processed txs.
+				continue
+			}
+			changedTxs = append(changedTxs, txHash)
+			started := time.Now()
+			tx, downloaded, err := transactions.getTransactionCached(dbTx, txHash)
+			if err != nil {
+				return err
+			}
+			if downloaded {
+				numDownloaded++
+				downloadDuration += time.Since(started)
+			}
 			transactions.processTxForAddress(dbTx, scriptHashHex, txHash, tx, height)
 		}
 		return nil
 	})
 	if err != nil {
+		if transactions.ctx.Err() != nil {
+			// The changes were rolled back and are made again by the next sync.
+			transactions.log.WithError(err).Debug("UpdateAddressHistory cancelled by Close()")
+			return 0, 0
+		}
 		transactions.log.WithError(err).Panic("Failed to update address history")
 	}
+	transactions.updateTxConfirmations(changedTxs)
+	return numDownloaded, downloadDuration
+}
+
+// RewindAddressHistory removes the transactions confirmed at or above `fromHeight` and the
+// unconfirmed transactions from the history of the address. The history then does not match the
+// status reported by the backend anymore, and the removed transactions are downloaded and indexed
+// again by the next UpdateAddressHistory(). If fromHeight is 0, the whole history is removed.
+//
+// The removed transactions are kept in the notifier, so that they are not reported as new again.
+func (transactions *Transactions) RewindAddressHistory(scriptHashHex blockchain.ScriptHashHex, fromHeight int) {
+	if transactions.isClosed() {
+		transactions.log.Debug("RewindAddressHistory after the instance was closed")
+		return
+	}
+	var removedTxs []chainhash.Hash
+	err := transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		history, err := dbTx.AddressHistory(scriptHashHex)
+		if err != nil {
+			return err
+		}
+		rewoundHistory := blockchain.TxHistory{}
+		for _, entry := range history {
+			if status, height := entry.Status(); status == blockchain.TxStatusConfirmed && height < fromHeight {
+				rewoundHistory = append(rewoundHistory, entry)
+				continue
+			}
+			transactions.removeTxForAddress(dbTx, scriptHashHex, entry.TXHash.Hash())
+			removedTxs = append(removedTxs, entry.TXHash.Hash())
+		}
+		return dbTx.PutAddressHistory(scriptHashHex, rewoundHistory)
+	})
+	if err != nil {
+		transactions.log.WithError(err).Panic("Failed to rewind address history")
+	}
+	transactions.updateTxConfirmations(removedTxs)
 }
 
-// getTransactionsCached requires transactions lock.
+// getTransactionsCached requires transactions lock. The returned bool is true if the tx was not in
+// the database and had to be downloaded. An error is returned if the download failed.
 func (transactions *Transactions) getTransactionCached(
 	dbTx DBTxInterface,
 	txHash chainhash.Hash,
-) *wire.MsgTx {
+) (*wire.MsgTx, bool, error) {
 	txInfo, err := dbTx.TxInfo(txHash)
 	if err != nil {
 		transactions.log.WithError(err).Panic("Failed to retrieve transaction info")
 	}
 	if txInfo.Tx != nil {
-		return txInfo.Tx
+		return txInfo.Tx, false, nil
 	}
-	tx, err := transactions.blockchain.TransactionGet(txHash)
+	// The transactions in the history of our addresses fund or spend our outputs, so they are needed
+	// for the balance.
+	done := transactions.fetchThrottle.Wait(throttle.PriorityBalance)
+	tx, err := transactions.blockchain.TransactionGet(transactions.ctx, txHash)
+	done(err)
 	if err != nil {
-		transactions.log.WithError(err).Panic("TransactionGet failed")
+		return nil, false, errp.WithMessage(err, "TransactionGet failed")
 	}
-	return tx
+	return tx, true, nil
 }
 
 // Balance computes the confirmed and unconfirmed balance of the account.
@@ -353,7 +564,8 @@ func (transactions *Transactions) Balance() (*accounts.Balance, error) {
 			if err != nil {
 				return nil, err
 			}
-			confirmed := txInfo.Height > 0
+			status, _ := txInfo.Status()
+			confirmed := status == blockchain.TxStatusConfirmed
 			if confirmed || transactions.allInputsOurs(dbTx, txInfo.Tx) {
 				available += txOut.Value
 			} else {
@@ -364,11 +576,14 @@ func (transactions *Transactions) Balance() (*accounts.Balance, error) {
 	})
 }
 
+// unknownAddress is shown instead of the address of an output whose script can't be decoded.
+const unknownAddress = "<unknown address>"
+
 func (transactions *Transactions) outputToAddress(pkScript []byte) string {
 	extractedAddress, err := util.AddressFromPkScript(pkScript, transactions.net)
 	// unknown addresses and multisig scripts ignored.
 	if err != nil {
-		return "<unknown address>"
+		return unknownAddress
 	}
 	return extractedAddress.String()
 }
@@ -396,6 +611,7 @@ func (transactions *Transactions) txInfo(
 	var sumAllOutputs, sumOurReceive, sumOurChange btcutil.Amount
 	receiveAddresses := []accounts.AddressAndAmount{}
 	sendAddresses := []accounts.AddressAndAmount{}
+	externalAddresses := []accounts.AddressAndAmount{}
 	allOutputsOurs := true
 	for index, txOut := range txInfo.Tx.TxOut {
 		sumAllOutputs += btcutil.Amount(txOut.Value)
@@ -423,6 +639,7 @@ func (transactions *Transactions) txInfo(
 		} else {
 			allOutputsOurs = false
 			sendAddresses = append(sendAddresses, addressAndAmount)
+			externalAddresses = append(externalAddresses, addressAndAmount)
 		}
 	}
 
@@ -433,7 +650,24 @@ func (transactions *Transactions) txInfo(
 	var txType accounts.TxType
 	var feeP *coin.Amount
 	var feeRatePerKbP *btcutil.Amount
-	if allInputsOurs {
+	var grossInP, grossOutP *coin.Amount
+	someInputsOurs := sumOurInputs > 0
+	if someInputsOurs && !allInputsOurs && !allOutputsOurs {
+		// Inputs and outputs of external parties are mixed with ours. Our gross in- and outflows
+		// are reported separately, as the values of the external inputs are unknown. For the
+		// same reason, the fee is not reported: we only paid a share of it, which is included in
+		// the net amount.
+		txType = accounts.TxTypeComplex
+		grossIn := coin.NewAmountFromInt64(int64(sumOurReceive + sumOurChange))
+		grossOut := coin.NewAmountFromInt64(int64(sumOurInputs))
+		grossInP = &grossIn
+		grossOutP = &grossOut
+		result = sumOurReceive + sumOurChange - sumOurInputs
+		if result < 0 {
+			result = -result
+		}
+		addresses = append(receiveAddresses, externalAddresses...)
+	} else if allInputsOurs {
 		feeValue := sumOurInputs - sumAllOutputs
 		fee := coin.NewAmountFromInt64(int64(feeValue))
 		feeP = &fee
@@ -464,12 +698,20 @@ func (transactions *Transactions) txInfo(
 		}
 
 	}
-	numConfirmations := 0
-	if txInfo.Height > 0 && transactions.headersTipHeight > 0 {
-		numConfirmations = transactions.headersTipHeight - txInfo.Height + 1
+	_, height := txInfo.Status()
+	numConfirmations := countConfirmations(height, transactions.headersTipHeight)
+
+	verified := txInfo.Verified != nil && *txInfo.Verified
+
+	var lastBroadcast *time.Time
+	outgoingTx, err := dbTx.OutgoingTransaction(txInfo.TxHash)
+	if err != nil {
+		transactions.log.WithError(err).Panic("OutgoingTransaction() failed")
+	}
+	if outgoingTx != nil {
+		lastBroadcast = &outgoingTx.LastBroadcast
 	}
 
-	const numConfirmationsComplete = 6
 	status := accounts.TxStatusPending
 	if numConfirmations >= numConfirmationsComplete {
 		status = accounts.TxStatusComplete
@@ -481,7 +723,7 @@ func (transactions *Transactions) txInfo(
 		InternalID:               txInfo.TxHash.String(),
 		NumConfirmations:         numConfirmations,
 		NumConfirmationsComplete: numConfirmationsComplete,
-		Height:                   txInfo.Height,
+		Height:                   height,
 		Status:                   status,
 		Type:                     txType,
 		Amount:                   coin.NewAmountFromInt64(int64(result)),
@@ -491,15 +733,69 @@ func (transactions *Transactions) txInfo(
 		VSize:            vsize,
 		Size:             int64(txInfo.Tx.SerializeSize()),
 		Weight:           btcdBlockchain.GetTransactionWeight(btcutilTx),
+		GrossIn:          grossInP,
+		GrossOut:         grossOutP,
 		CreatedTimestamp: txInfo.CreatedTimestamp,
+		Verified:         &verified,
+		LastBroadcast:    lastBroadcast,
 		IsErc20:          false,
 	}
 }
 
-// Transactions returns an ordered list of transactions.
+// dbUpdate is like DBUpdate(), but also invalidates the ordered transactions cached by
+// Transactions(). All writes of this package must use it.
+func (transactions *Transactions) dbUpdate(f func(DBTxInterface) error) error {
+	defer transactions.invalidateOrdered()
+	return DBUpdate(transactions.db, f)
+}
+
+// invalidateOrdered drops the ordered transactions cached by Transactions().
+func (transactions *Transactions) invalidateOrdered() {
+	defer transactions.orderedLock.Lock()()
+	transactions.ordered = nil
+	transactions.orderedGeneration++
+}
+
+// Transactions returns an ordered list of transactions. The list is cached until the next change of
+// the database or of the chain tip, so isChange must not change between calls. The returned
+// transactions are shared between callers and must not be modified.
 func (transactions *Transactions) Transactions(
 	isChange func(blockchain.ScriptHashHex) bool) (accounts.OrderedTransactions, error) {
 	transactions.synchronizer.WaitSynchronized()
+	unlock := transactions.orderedLock.RLock()
+	ordered, generation := transactions.ordered, transactions.orderedGeneration
+	unlock()
+	if ordered != nil {
+		// Copied so the callers can not reorder the cached list.
+		return append(accounts.OrderedTransactions{}, ordered...), nil
+	}
+	ordered, err := transactions.orderedTransactions(isChange)
+	if err != nil {
+		return nil, err
+	}
+	defer transactions.orderedLock.Lock()()
+	// Not cached if the database changed while the list was built, as it might be outdated.
+	if transactions.orderedGeneration == generation {
+		transactions.ordered = ordered
+	}
+	return append(accounts.OrderedTransactions{}, ordered...), nil
+}
+
+// PersistedTransactions returns the ordered transactions persisted in the db by a previous sync,
+// without syncing them. The confirmations are counted from the given tip height.
+func PersistedTransactions(
+	net *chaincfg.Params,
+	db DBInterface,
+	tipHeight int,
+	isChange func(blockchain.ScriptHashHex) bool,
+	log *logrus.Entry,
+) (accounts.OrderedTransactions, error) {
+	transactions := &Transactions{net: net, db: db, headersTipHeight: tipHeight, log: log}
+	return transactions.orderedTransactions(isChange)
+}
+
+func (transactions *Transactions) orderedTransactions(
+	isChange func(blockchain.ScriptHashHex) bool) (accounts.OrderedTransactions, error) {
 	return DBView(transactions.db, func(dbTx DBTxInterface) (accounts.OrderedTransactions, error) {
 		txs := []*accounts.TransactionData{}
 		txHashes, err := dbTx.Transactions()
@@ -15,8 +15,10 @@
 package transactions_test
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	accountsMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
@@ -25,10 +27,13 @@ import (
 	blockchainpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/transactionsdb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
 	headersMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/synchronizer"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/throttle"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil"
@@ -65,7 +70,7 @@ func (blockchain *BlockchainMock) RegisterTxs(txs ...*wire.MsgTx) {
 
 // TransactionGet by default automatically calls the callback which processes the tx. Overwrite
 // default behavior by setting the TransactionGetFunc var.
-func (blockchain *BlockchainMock) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
+func (blockchain *BlockchainMock) TransactionGet(_ context.Context, txHash chainhash.Hash) (*wire.MsgTx, error) {
 	tx, ok := blockchain.transactions[txHash]
 	if !ok {
 		panic("you need to first register the transaction with the mock backend")
@@ -77,6 +82,11 @@ func (blockchain *BlockchainMock) ConnectionError() error {
 	return nil
 }
 
+type confirmationsChange struct {
+	txHash           chainhash.Hash
+	numConfirmations int
+}
+
 type transactionsSuite struct {
 	suite.Suite
 
@@ -85,9 +95,13 @@ type transactionsSuite struct {
 	synchronizer   *synchronizer.Synchronizer
 	blockchainMock *BlockchainMock
 	headersMock    *headersMock.Interface
+	onHeadersEvent func(headers.Event)
 	notifierMock   *accountsMock.Notifier
 	transactions   *transactions.Transactions
 
+	confirmationsChanges chan confirmationsChange
+	verificationChanges  chan chainhash.Hash
+
 	log *logrus.Entry
 }
 
@@ -107,16 +121,29 @@ func (s *transactionsSuite) SetupTest() {
 		panic(err)
 	}
 	s.headersMock = &headersMock.Interface{}
-	s.headersMock.On("SubscribeEvent", mock.AnythingOfType("func(headers.Event)")).Return(func() {})
+	s.headersMock.On("SubscribeEvent", mock.AnythingOfType("func(headers.Event)")).
+		Run(func(args mock.Arguments) {
+			s.onHeadersEvent = args.Get(0).(func(headers.Event))
+		}).
+		Return(func() {})
 	s.headersMock.On("TipHeight").Return(15).Once()
 	s.notifierMock = &accountsMock.Notifier{}
+	s.confirmationsChanges = make(chan confirmationsChange, 10)
+	s.verificationChanges = make(chan chainhash.Hash, 10)
 	s.transactions = transactions.NewTransactions(
 		s.net,
 		db,
 		s.headersMock,
 		s.synchronizer,
 		s.blockchainMock,
+		throttle.New(config.TxFetchThrottle{InitialRate: 1000, MaxRate: 1000, Burst: 1000}, s.log),
 		s.notifierMock,
+		func(txHash chainhash.Hash, numConfirmations int) {
+			s.confirmationsChanges <- confirmationsChange{txHash, numConfirmations}
+		},
+		func(txHash chainhash.Hash) {
+			s.verificationChanges <- txHash
+		},
 		s.log,
 	)
 }
@@ -173,7 +200,8 @@ func (s *transactionsSuite) TestUpdateAddressHistorySingleTxReceive() {
 	s.Require().NoError(err)
 	s.Require().Equal(newBalance(expectedAmount, 0), balance)
 	utxo := &transactions.SpendableOutput{
-		TxOut: wire.NewTxOut(int64(expectedAmount), address.PubkeyScript()),
+		TxOut:         wire.NewTxOut(int64(expectedAmount), address.PubkeyScript()),
+		Confirmations: 6,
 	}
 	spendableOutputs, err := s.transactions.SpendableOutputs()
 	s.Require().NoError(err)
@@ -239,6 +267,17 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 	s.Require().Len(spendableOutputs, 1)
 	s.Require().NotContains(spendableOutputs, wire.OutPoint{Hash: tx12.TxHash(), Index: 0})
 	s.Require().Contains(spendableOutputs, wire.OutPoint{Hash: tx22.TxHash(), Index: 0})
+	// The unconfirmed outputs which can't be spent yet are still unspent.
+	unspentOutputs, err := s.transactions.UnspentOutputs()
+	s.Require().NoError(err)
+	s.Require().Len(unspentOutputs, 3)
+	s.Require().NotContains(unspentOutputs, wire.OutPoint{Hash: tx12.TxHash(), Index: 0})
+	unspentOutput := unspentOutputs[wire.OutPoint{Hash: tx11.TxHash(), Index: 0}]
+	s.Require().NotNil(unspentOutput)
+	s.Require().Equal(tx11.TxHash(), unspentOutput.Tx.TxHash())
+	s.Require().Equal(0, unspentOutput.Height)
+	s.Require().Nil(unspentOutput.MerkleProof)
+	s.Require().Equal(10, unspentOutputs[wire.OutPoint{Hash: tx22.TxHash(), Index: 0}].Height)
 	// Send output generated from tx22 to an internal address, unconfirmed. The new output needs to
 	// be spendable, as it is our own.
 	tx22Spend := newTx(tx22.TxHash(), 0, address2, 4000)
@@ -257,6 +296,40 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 	s.Require().Contains(spendableOutputs, wire.OutPoint{Hash: tx22Spend.TxHash(), Index: 0})
 }
 
+func (s *transactionsSuite) TestHasInputAndOutput() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address, otherAddress := addresses[0], addresses[1]
+	fundingOutPoint := wire.OutPoint{Hash: chainhash.HashH(nil), Index: 0}
+	tx := newTx(fundingOutPoint.Hash, fundingOutPoint.Index, address, 1000)
+	spendTx := newTx(tx.TxHash(), 0, otherAddress, 1000)
+	s.blockchainMock.RegisterTxs(tx, spendTx)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx.TxHash()), Height: 0},
+		{TXHash: blockchainpkg.TXHash(spendTx.TxHash()), Height: 0},
+	})
+
+	for _, test := range []struct {
+		outPoint  wire.OutPoint
+		hasInput  bool
+		hasOutput bool
+	}{
+		{fundingOutPoint, true, false},
+		{wire.OutPoint{Hash: tx.TxHash(), Index: 0}, true, true},
+		{wire.OutPoint{Hash: tx.TxHash(), Index: 1}, false, false},
+		// The recipient output of the outgoing tx.
+		{wire.OutPoint{Hash: spendTx.TxHash(), Index: 0}, false, true},
+		{wire.OutPoint{Hash: chainhash.HashH([]byte("unknown")), Index: 0}, false, false},
+	} {
+		hasInput, err := s.transactions.HasInput(test.outPoint)
+		s.Require().NoError(err)
+		s.Require().Equal(test.hasInput, hasInput, test.outPoint.String())
+		hasOutput, err := s.transactions.HasOutput(test.outPoint)
+		s.Require().NoError(err)
+		s.Require().Equal(test.hasOutput, hasOutput, test.outPoint.String())
+	}
+}
+
 func (s *transactionsSuite) TestBalance() {
 	balance, err := s.transactions.Balance()
 	s.Require().NoError(err)
@@ -320,6 +393,54 @@ func (s *transactionsSuite) TestBalance() {
 	s.Require().Equal(newBalance(expectedAmount2, 0), balance)
 }
 
+// TestComplexTransaction checks that a transaction mixing our inputs and outputs with external ones,
+// e.g. a coinjoin, is reported with its net effect and our gross in- and outflows.
+func (s *transactionsSuite) TestComplexTransaction() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 1000)
+	externalPkScript := append([]byte{0x00, 0x14}, make([]byte, 20)...)
+	coinjoinTx := &wire.MsgTx{
+		Version: wire.TxVersion,
+		TxIn: []*wire.TxIn{
+			wire.NewTxIn(&wire.OutPoint{Hash: tx1.TxHash(), Index: 0}, nil, nil),
+			wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("external")), Index: 0}, nil, nil),
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(900, address.PubkeyScript()),
+			wire.NewTxOut(1500, externalPkScript),
+		},
+	}
+	s.blockchainMock.RegisterTxs(tx1, coinjoinTx)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
+		{TXHash: blockchainpkg.TXHash(coinjoinTx.TxHash()), Height: 0},
+	})
+	balance, err := s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(0, 900), balance)
+
+	transactions, err := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 2)
+	var complexTx *accounts.TransactionData
+	for _, tx := range transactions {
+		if tx.TxID == coinjoinTx.TxHash().String() {
+			complexTx = tx
+		}
+	}
+	s.Require().NotNil(complexTx)
+	s.Require().Equal(accounts.TxTypeComplex, complexTx.Type)
+	s.Require().Equal(coin.NewAmountFromInt64(100), complexTx.Amount)
+	s.Require().Equal(coin.NewAmountFromInt64(900), *complexTx.GrossIn)
+	s.Require().Equal(coin.NewAmountFromInt64(1000), *complexTx.GrossOut)
+	s.Require().Nil(complexTx.Fee)
+	s.Require().Len(complexTx.Addresses, 2)
+	// The ordered transactions account for the net effect.
+	s.Require().Equal(coin.NewAmountFromInt64(900), transactions[0].Balance)
+}
+
 func (s *transactionsSuite) TestRemoveTransaction() {
 	addresses, err := s.addressChain.EnsureAddresses()
 	s.Require().NoError(err)
@@ -370,3 +491,452 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 	s.Require().NoError(err)
 	s.Require().Len(transactions, 2)
 }
+
+// TestRewindAddressHistory checks that rewinding removes the recent transactions of an address
+// until its history is updated again.
+func (s *transactionsSuite) TestRewindAddressHistory() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 12)
+	tx2 := newTx(chainhash.HashH(nil), 1, address, 34)
+	tx3 := newTx(chainhash.HashH(nil), 2, address, 56)
+	s.blockchainMock.RegisterTxs(tx1, tx2, tx3)
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil)
+	s.headersMock.On("VerifiedHeaderByHeight", 12).Return(nil, nil)
+	history := []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 12},
+		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 0},
+	}
+	s.updateAddressHistory(address, history)
+	balance, err := s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(12+34, 56), balance)
+
+	// The notifier mock fails on calls to Delete(), as the removed txs must not be reported as new
+	// again when they are added back.
+	s.transactions.RewindAddressHistory(address.PubkeyScriptHashHex(), 12)
+	balance, err = s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(12, 0), balance)
+	transactions, err := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 1)
+	s.Require().Equal(tx1.TxHash().String(), transactions[0].TxID)
+
+	s.updateAddressHistory(address, history)
+	balance, err = s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(12+34, 56), balance)
+
+	s.transactions.RewindAddressHistory(address.PubkeyScriptHashHex(), 0)
+	transactions, err = s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	s.Require().Empty(transactions)
+}
+
+// TestVerification checks that confirmed transactions are verified against the block headers, and
+// verified again after a reorg.
+func (s *transactionsSuite) TestVerification() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	s.blockchainMock.RegisterTxs(tx1)
+
+	isVerified := func() bool {
+		transactions, err := s.transactions.Transactions(
+			func(blockchainpkg.ScriptHashHex) bool { return false })
+		s.Require().NoError(err)
+		s.Require().Len(transactions, 1)
+		s.Require().NotNil(transactions[0].Verified)
+		return *transactions[0].Verified
+	}
+	isOutputVerified := func() bool {
+		utxos, err := s.transactions.SpendableOutputs()
+		s.Require().NoError(err)
+		s.Require().Len(utxos, 1)
+		return utxos[wire.OutPoint{Hash: tx1.TxHash(), Index: 0}].Verified
+	}
+
+	// The tx is the only one in its block, so the merkle root is the tx hash.
+	header := &wire.BlockHeader{MerkleRoot: tx1.TxHash(), Timestamp: time.Unix(1700000000, 0)}
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(header, nil)
+	s.blockchainMock.On("GetMerkle", mock.Anything, tx1.TxHash(), 10).Return(
+		&blockchainpkg.GetMerkleResult{Merkle: []blockchainpkg.TXHash{}, Pos: 0}, nil)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+	})
+	s.Require().Eventually(isVerified, time.Second, 10*time.Millisecond)
+	s.Require().Equal(tx1.TxHash(), <-s.verificationChanges)
+	s.Require().True(isOutputVerified())
+
+	// The tx is in a different block after the reorg and the server's merkle proof does not match
+	// the new header. The tx is verified again right away, in case the headers are synced already.
+	otherHeader := &wire.BlockHeader{MerkleRoot: chainhash.HashH([]byte("other"))}
+	s.headersMock.ExpectedCalls = nil
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(otherHeader, nil)
+	s.onHeadersEvent(headers.EventReorg)
+	s.Require().False(isVerified())
+	s.Require().False(isOutputVerified())
+	s.Require().Equal(tx1.TxHash(), <-s.verificationChanges)
+	transactions, err := s.transactions.Transactions(
+		func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	s.Require().Nil(transactions[0].Timestamp)
+	s.Require().Never(isVerified, 100*time.Millisecond, 10*time.Millisecond)
+
+	s.onHeadersEvent(headers.EventSynced)
+	s.Require().Never(isVerified, 100*time.Millisecond, 10*time.Millisecond)
+	s.Require().Empty(s.verificationChanges)
+
+	s.headersMock.ExpectedCalls = nil
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(header, nil)
+	s.onHeadersEvent(headers.EventSynced)
+	s.Require().Eventually(isVerified, time.Second, 10*time.Millisecond)
+	s.Require().Equal(tx1.TxHash(), <-s.verificationChanges)
+
+	// The server reports the tx in another block, e.g. if the reorg was processed before the
+	// history. The proof of the previous block is dropped and the tx is verified in the new block.
+	s.headersMock.On("VerifiedHeaderByHeight", 11).Return(otherHeader, nil)
+	s.blockchainMock.On("GetMerkle", mock.Anything, tx1.TxHash(), 11).Return(
+		&blockchainpkg.GetMerkleResult{Merkle: []blockchainpkg.TXHash{}, Pos: 0}, nil)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 11},
+	})
+	s.Require().False(isVerified())
+	s.Require().Never(isVerified, 100*time.Millisecond, 10*time.Millisecond)
+
+	s.headersMock.ExpectedCalls = nil
+	s.headersMock.On("VerifiedHeaderByHeight", 11).Return(header, nil)
+	s.onHeadersEvent(headers.EventSynced)
+	s.Require().Eventually(isVerified, time.Second, 10*time.Millisecond)
+}
+
+// TestUnconfirmedParents checks that the mempool height encodings (0 and -1) are treated as
+// unconfirmed, including when the parent confirms later.
+func (s *transactionsSuite) TestUnconfirmedParents() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	otherAddress := addresses[2]
+	parent := newTx(chainhash.HashH(nil), 0, otherAddress, 1000)
+	child := newTx(parent.TxHash(), 0, address, 900)
+	s.blockchainMock.RegisterTxs(child)
+	isChange := func(blockchainpkg.ScriptHashHex) bool { return false }
+	requireHeights := func(expectedHeight int, expectedConfirmations int) {
+		s.T().Helper()
+		transactions, err := s.transactions.Transactions(isChange)
+		s.Require().NoError(err)
+		s.Require().Len(transactions, 1)
+		s.Require().Equal(expectedHeight, transactions[0].Height)
+		s.Require().Equal(expectedConfirmations, transactions[0].NumConfirmations)
+	}
+
+	// The child spends the unconfirmed parent.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(child.TxHash()), Height: -1},
+	})
+	requireHeights(0, 0)
+	balance, err := s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(0, 900), balance)
+	spendableOutputs, err := s.transactions.SpendableOutputs()
+	s.Require().NoError(err)
+	s.Require().Empty(spendableOutputs)
+
+	// The parent confirms, the child is still in the mempool.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(child.TxHash()), Height: 0},
+	})
+	requireHeights(0, 0)
+
+	// The child confirms.
+	s.headersMock.On("VerifiedHeaderByHeight", 14).Return(nil, nil).Once()
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(child.TxHash()), Height: 14},
+	})
+	requireHeights(14, 2)
+	balance, err = s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(900, 0), balance)
+	s.Require().Equal(confirmationsChange{child.TxHash(), 2}, <-s.confirmationsChanges)
+}
+
+// TestConfirmationsChanged checks that crossing a confirmation threshold is reported, also when the
+// confirmations go back after a reorg.
+func (s *transactionsSuite) TestConfirmationsChanged() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	s.blockchainMock.RegisterTxs(tx1)
+	s.headersMock.On("VerifiedHeaderByHeight", 15).Return(nil, nil)
+
+	requireChanges := func(expected ...confirmationsChange) {
+		s.T().Helper()
+		for _, change := range expected {
+			s.Require().Equal(change, <-s.confirmationsChanges)
+		}
+		select {
+		case change := <-s.confirmationsChanges:
+			s.Require().Fail("unexpected confirmations change", change)
+		default:
+		}
+	}
+	newTip := func(height int) {
+		s.headersMock.On("TipHeight").Return(height).Once()
+		s.onHeadersEvent(headers.EventNewTip)
+	}
+
+	// New unconfirmed tx.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
+	})
+	requireChanges()
+	// Confirmed in the tip block.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 15},
+	})
+	requireChanges(confirmationsChange{tx1.TxHash(), 1})
+	newTip(19)
+	requireChanges()
+	newTip(20)
+	requireChanges(confirmationsChange{tx1.TxHash(), 6})
+	newTip(21)
+	requireChanges()
+	// A reorg moves the tx back to the mempool.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
+	})
+	requireChanges(confirmationsChange{tx1.TxHash(), 0})
+	// Mined again, in a block further back than the tip.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 15},
+	})
+	requireChanges(confirmationsChange{tx1.TxHash(), 7})
+	// A reorg to a shorter chain moves the tip back.
+	newTip(18)
+	requireChanges(confirmationsChange{tx1.TxHash(), 4})
+	// Rewinding the history forgets the tx, so it is seen for the first time when it is added
+	// again.
+	s.transactions.RewindAddressHistory(address.PubkeyScriptHashHex(), 0)
+	requireChanges()
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+	})
+	requireChanges()
+	newTip(30)
+	requireChanges()
+}
+
+// TestTransactionsCached checks that the ordered transactions are cached until the history or the
+// chain tip changes.
+func (s *transactionsSuite) TestTransactionsCached() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	tx2 := newTx(chainhash.HashH([]byte{1}), 0, address, 456)
+	s.blockchainMock.RegisterTxs(tx1, tx2)
+	s.headersMock.On("VerifiedHeaderByHeight", 15).Return(nil, nil)
+	isChange := func(blockchainpkg.ScriptHashHex) bool { return false }
+
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 15},
+	})
+	txs, err := s.transactions.Transactions(isChange)
+	s.Require().NoError(err)
+	s.Require().Len(txs, 1)
+	s.Require().Equal(1, txs[0].NumConfirmations)
+	// Modifying the returned list does not affect the cache.
+	txs[0] = nil
+	cached, err := s.transactions.Transactions(isChange)
+	s.Require().NoError(err)
+	s.Require().Len(cached, 1)
+	s.Require().NotNil(cached[0])
+	again, err := s.transactions.Transactions(isChange)
+	s.Require().NoError(err)
+	s.Require().Same(cached[0], again[0])
+
+	// A new tip updates the confirmations.
+	s.headersMock.On("TipHeight").Return(16).Once()
+	s.onHeadersEvent(headers.EventNewTip)
+	txs, err = s.transactions.Transactions(isChange)
+	s.Require().NoError(err)
+	s.Require().Equal(2, txs[0].NumConfirmations)
+
+	// A new tx is listed first.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 15},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+	txs, err = s.transactions.Transactions(isChange)
+	s.Require().NoError(err)
+	s.Require().Len(txs, 2)
+	s.Require().Equal(tx2.TxHash().String(), txs[0].TxID)
+	s.Require().Equal(tx1.TxHash().String(), txs[1].TxID)
+}
+
+// TestUpdateAddressHistoryIncremental checks that only the txs which are new or changed height are
+// processed when the history of an address changes.
+func (s *transactionsSuite) TestUpdateAddressHistoryIncremental() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	tx2 := newTx(chainhash.HashH(nil), 1, address, 456)
+	s.blockchainMock.RegisterTxs(tx1, tx2)
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil)
+
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+	})
+	s.notifierMock.AssertNumberOfCalls(s.T(), "Put", 1)
+
+	// A new payment only processes the new tx.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+	s.notifierMock.AssertNumberOfCalls(s.T(), "Put", 2)
+	balance, err := s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(123, 456), balance)
+
+	// The confirmation of the new tx only processes that tx again.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 10},
+	})
+	s.notifierMock.AssertNumberOfCalls(s.T(), "Put", 3)
+	balance, err = s.transactions.Balance()
+	s.Require().NoError(err)
+	s.Require().Equal(newBalance(579, 0), balance)
+
+	// Unchanged history.
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 10},
+	})
+	s.notifierMock.AssertNumberOfCalls(s.T(), "Put", 3)
+}
+
+// TestOutgoingTransactions checks that outgoing transactions are broadcast again until they confirm
+// or are replaced.
+func (s *transactionsSuite) TestOutgoingTransactions() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	toBroadcast := func() []chainhash.Hash {
+		outgoingTxs, err := s.transactions.OutgoingTransactionsToBroadcast()
+		s.Require().NoError(err)
+		result := []chainhash.Hash{}
+		for _, outgoingTx := range outgoingTxs {
+			result = append(result, outgoingTx.Tx.TxHash())
+		}
+		return result
+	}
+	s.Require().Empty(toBroadcast())
+
+	sentTime := time.Unix(1700000000, 0)
+	tx1 := newTx(chainhash.HashH([]byte("1")), 0, address, 123)
+	tx2 := newTx(chainhash.HashH([]byte("2")), 0, address, 456)
+	s.Require().NoError(s.transactions.PutOutgoingTransaction(tx1, sentTime))
+	s.Require().NoError(s.transactions.PutOutgoingTransaction(tx2, sentTime))
+	s.Require().ElementsMatch([]chainhash.Hash{tx1.TxHash(), tx2.TxHash()}, toBroadcast())
+
+	// Unconfirmed, the last broadcast is shown with the tx.
+	s.blockchainMock.RegisterTxs(tx1)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
+	})
+	s.Require().NoError(s.transactions.MarkOutgoingTransactionBroadcast(tx1.TxHash(), sentTime.Add(time.Hour)))
+	transactions, err := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 1)
+	s.Require().NotNil(transactions[0].LastBroadcast)
+	s.Require().True(sentTime.Add(time.Hour).Equal(*transactions[0].LastBroadcast))
+	s.Require().ElementsMatch([]chainhash.Hash{tx1.TxHash(), tx2.TxHash()}, toBroadcast())
+
+	// tx1 confirms and tx2 is replaced by tx3 spending the same output.
+	tx3 := newTx(chainhash.HashH([]byte("2")), 0, address, 400)
+	s.blockchainMock.RegisterTxs(tx3)
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(nil, nil).Once()
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 0},
+	})
+	s.Require().Empty(toBroadcast())
+	transactions, err = s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	for _, transaction := range transactions {
+		s.Require().Nil(transaction.LastBroadcast)
+	}
+}
+
+// TestTxDetails checks the decoded inputs and outputs of a transaction.
+func (s *transactionsSuite) TestTxDetails() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	changeAddress := addresses[1]
+	isChange := func(scriptHashHex blockchainpkg.ScriptHashHex) bool {
+		return scriptHashHex == changeAddress.PubkeyScriptHashHex()
+	}
+
+	details, err := s.transactions.TxDetails(chainhash.HashH(nil), isChange)
+	s.Require().NoError(err)
+	s.Require().Nil(details)
+
+	// Received from an unknown output: the fee is unknown.
+	tx1 := newTx(chainhash.HashH(nil), 3, address, 10000)
+	s.blockchainMock.RegisterTxs(tx1)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
+	})
+	details, err = s.transactions.TxDetails(tx1.TxHash(), isChange)
+	s.Require().NoError(err)
+	s.Require().Equal(tx1.TxHash(), details.TxHash)
+	s.Require().Equal([]*transactions.TxInputDetails{
+		{PreviousOutPoint: wire.OutPoint{Hash: chainhash.HashH(nil), Index: 3}},
+	}, details.Inputs)
+	s.Require().Equal([]*transactions.TxOutputDetails{
+		{Index: 0, Address: address.EncodeForHumans(), Value: 10000, Ours: true},
+	}, details.Outputs)
+	s.Require().Nil(details.Fee)
+	s.Require().Nil(details.FeeRatePerKb)
+
+	// Spends the received output to an external address and to change.
+	tx2 := newTx(tx1.TxHash(), 0, address, 0)
+	externalScript := []byte{0x51}
+	tx2.TxOut = []*wire.TxOut{
+		wire.NewTxOut(6000, externalScript),
+		wire.NewTxOut(3000, changeAddress.PubkeyScript()),
+	}
+	s.blockchainMock.RegisterTxs(tx2)
+	s.updateAddressHistory(changeAddress, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+	details, err = s.transactions.TxDetails(tx2.TxHash(), isChange)
+	s.Require().NoError(err)
+	inputValue := btcutil.Amount(10000)
+	s.Require().Equal([]*transactions.TxInputDetails{
+		{
+			PreviousOutPoint: wire.OutPoint{Hash: tx1.TxHash(), Index: 0},
+			Address:          address.EncodeForHumans(),
+			Value:            &inputValue,
+			Ours:             true,
+		},
+	}, details.Inputs)
+	s.Require().Equal([]*transactions.TxOutputDetails{
+		{Index: 0, Address: "<unknown address>", Value: 6000},
+		{Index: 1, Address: changeAddress.EncodeForHumans(), Value: 3000, Ours: true, Change: true},
+	}, details.Outputs)
+	s.Require().NotNil(details.Fee)
+	s.Require().Equal(btcutil.Amount(1000), *details.Fee)
+	s.Require().Equal(btcutil.Amount(1000*1000/details.VSize), *details.FeeRatePerKb)
+	s.Require().Equal(int64(tx2.SerializeSize()), details.Size)
+}
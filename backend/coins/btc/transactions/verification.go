@@ -21,6 +21,11 @@ import (
 )
 
 func (transactions *Transactions) onHeadersEvent(event headers.Event) {
+	// The event could have been dispatched right before the instance was closed and unsubscribed.
+	if transactions.isClosed() {
+		transactions.log.Debug("Headers event after the instance was closed")
+		return
+	}
 	switch event {
 	case headers.EventSynced:
 		transactions.verifyTransactions()
@@ -28,6 +33,50 @@ func (transactions *Transactions) onHeadersEvent(event headers.Event) {
 		done := transactions.synchronizer.IncRequestsCounter()
 		transactions.headersTipHeight = transactions.headers.TipHeight()
 		done()
+		// The number of confirmations of the cached transactions changed.
+		transactions.invalidateOrdered()
+		transactions.updateTipConfirmations()
+	case headers.EventReorg:
+		transactions.unverifyReorgedTransactions()
+	}
+}
+
+// unverifyReorgedTransactions marks the verified transactions in the blocks reverted by a reorg as
+// unverified. They are verified again against the new headers right away, in case the headers were
+// already synced when this event is handled, and otherwise once the headers are synced.
+func (transactions *Transactions) unverifyReorgedTransactions() {
+	done := transactions.synchronizer.IncRequestsCounter()
+	defer done()
+	fromHeight := transactions.headersTipHeight - headers.ReorgLimit
+	unverified := map[chainhash.Hash]int{}
+	err := transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return err
+		}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return err
+			}
+			_, height := txInfo.Status()
+			if txInfo.Verified == nil || !*txInfo.Verified || height <= fromHeight {
+				continue
+			}
+			if err := dbTx.MarkTxUnverified(txHash); err != nil {
+				return err
+			}
+			unverified[txHash] = height
+		}
+		return nil
+	})
+	if err != nil {
+		transactions.log.WithError(err).Error("Failed to mark reorged transactions unverified")
+		return
+	}
+	for txHash, height := range unverified {
+		transactions.onVerificationChanged(txHash)
+		go transactions.verifyTransaction(txHash, height)
 	}
 }
 
@@ -43,7 +92,7 @@ func (transactions *Transactions) unverifiedTransactions() (map[chainhash.Hash]i
 			if err != nil {
 				return nil, err
 			}
-			result[txHash] = txInfo.Height
+			_, result[txHash] = txInfo.Status()
 		}
 		return result, nil
 	})
@@ -90,7 +139,7 @@ func (transactions *Transactions) verifyTransaction(txHash chainhash.Hash, heigh
 	done := transactions.synchronizer.IncRequestsCounter()
 	defer done()
 
-	merkle, err := transactions.blockchain.GetMerkle(txHash, height)
+	merkle, err := transactions.blockchain.GetMerkle(transactions.ctx, txHash, height)
 	if err != nil {
 		// TODO
 		transactions.log.WithError(err).Error("GetMerkle")
@@ -103,10 +152,16 @@ func (transactions *Transactions) verifyTransaction(txHash chainhash.Hash, heigh
 	}
 	transactions.log.Debugf("Merkle root verification succeeded")
 
-	err = DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
-		return dbTx.MarkTxVerified(txHash, header.Timestamp)
+	err = transactions.dbUpdate(func(dbTx DBTxInterface) error {
+		return dbTx.MarkTxVerified(txHash, header.Timestamp, &MerkleProof{
+			BlockHash: header.BlockHash(),
+			Pos:       merkle.Pos,
+			Merkle:    merkle.Merkle,
+		})
 	})
 	if err != nil {
 		transactions.log.WithError(err).Error("MarkTXVerified")
+		return
 	}
+	transactions.onVerificationChanged(txHash)
 }
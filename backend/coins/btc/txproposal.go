@@ -0,0 +1,136 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TransactionProposal is a transaction which was built from the selected inputs and the outputs,
+// but not signed yet. It can be inspected, e.g. to let the user confirm it, before it is signed
+// with SignTransactionProposal() and broadcast with BroadcastTransactionProposal().
+type TransactionProposal struct {
+	*maketx.TxProposal
+	// InputAddresses are the account addresses spent by the inputs, in the order of the inputs.
+	InputAddresses []*addresses.AccountAddress
+	signed         bool
+}
+
+// TotalIn returns the sum of the values of the spent outputs.
+func (p *TransactionProposal) TotalIn() btcutil.Amount {
+	var total btcutil.Amount
+	for _, txIn := range p.Transaction.TxIn {
+		total += btcutil.Amount(p.PreviousOutputs[txIn.PreviousOutPoint].TxOut.Value)
+	}
+	return total
+}
+
+// TotalOut returns the sum of the values of all outputs, including the change.
+func (p *TransactionProposal) TotalOut() btcutil.Amount {
+	var total btcutil.Amount
+	for _, txOut := range p.Transaction.TxOut {
+		total += btcutil.Amount(txOut.Value)
+	}
+	return total
+}
+
+// VSize returns the size of the transaction in vbytes. Before signing, this is the worst case
+// estimate of the size of the signed transaction.
+func (p *TransactionProposal) VSize() int {
+	if p.signed {
+		weight := p.Transaction.SerializeSizeStripped()*3 + p.Transaction.SerializeSize()
+		return (weight + 3) / 4
+	}
+	inputConfigurations := make([]*signing.Configuration, len(p.InputAddresses))
+	for index, address := range p.InputAddresses {
+		inputConfigurations[index] = address.Configuration
+	}
+	return maketx.EstimateSignedTxSize(p.Transaction, inputConfigurations)
+}
+
+// Signed returns true if the transaction was signed with SignTransactionProposal().
+func (p *TransactionProposal) Signed() bool {
+	return p.signed
+}
+
+// NewTransactionProposal builds a transaction from the given args without signing it.
+func (account *Account) NewTransactionProposal(args *accounts.TxProposalArgs) (*TransactionProposal, error) {
+	_, txProposal, err := account.newTx(args)
+	if err != nil {
+		return nil, err
+	}
+	return account.newTransactionProposal(txProposal)
+}
+
+// newTransactionProposal looks up the account addresses spent by the inputs of the transaction.
+func (account *Account) newTransactionProposal(txProposal *maketx.TxProposal) (*TransactionProposal, error) {
+	inputAddresses := make([]*addresses.AccountAddress, len(txProposal.Transaction.TxIn))
+	for index, txIn := range txProposal.Transaction.TxIn {
+		spentOutput, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.Newf("output spent by input %d not found", index)
+		}
+		address := account.getAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			return nil, errp.Newf("address spent by input %d not found", index)
+		}
+		inputAddresses[index] = address
+	}
+	return &TransactionProposal{
+		TxProposal:     txProposal,
+		InputAddresses: inputAddresses,
+	}, nil
+}
+
+// SignTransactionProposal signs all inputs of the proposal with the keystore of the account.
+func (account *Account) SignTransactionProposal(p *TransactionProposal) error {
+	if p.signed {
+		return errp.New("The transaction is already signed")
+	}
+	getPrevTx := func(txHash chainhash.Hash) (*wire.MsgTx, error) {
+		return account.coin.Blockchain().TransactionGet(account.ctx, txHash)
+	}
+	if err := account.signTransaction(p.TxProposal, getPrevTx); err != nil {
+		return err
+	}
+	p.signed = true
+	return nil
+}
+
+// BroadcastTransactionProposal broadcasts the signed proposal. The transaction is stored and
+// broadcast again until it confirms, see rebroadcastLoop().
+func (account *Account) BroadcastTransactionProposal(p *TransactionProposal) error {
+	if !p.signed {
+		return errp.New("The transaction is not signed")
+	}
+	if err := account.coin.Blockchain().TransactionBroadcast(account.ctx, p.Transaction); err != nil {
+		return err
+	}
+	// Kept until it confirms, so it can be broadcast again if the servers drop it.
+	if err := account.transactions.PutOutgoingTransaction(p.Transaction, time.Now()); err != nil {
+		// Not critical.
+		account.log.WithError(err).Error("Failed to store the outgoing transaction")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionProposal(t *testing.T) {
+	inputAddresses := []*addresses.AccountAddress{
+		addressesTest.GetAddress(signing.ScriptTypeP2WPKH),
+		addressesTest.GetAddress(signing.ScriptTypeP2TR),
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := maketx.PreviousOutputs{}
+	for index, address := range inputAddresses {
+		outPoint := wire.OutPoint{Index: uint32(index)}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(int64(100000*(index+1)), address.PubkeyScript()),
+		}
+	}
+	tx.AddTxOut(wire.NewTxOut(250000, addressesTest.GetAddress(signing.ScriptTypeP2PKH).PubkeyScript()))
+	tx.AddTxOut(wire.NewTxOut(49000, inputAddresses[0].PubkeyScript()))
+
+	proposal := &btc.TransactionProposal{
+		TxProposal: &maketx.TxProposal{
+			Amount:          250000,
+			Fee:             1000,
+			Transaction:     tx,
+			PreviousOutputs: previousOutputs,
+		},
+		InputAddresses: inputAddresses,
+	}
+	require.False(t, proposal.Signed())
+	require.Equal(t, btcutil.Amount(300000), proposal.TotalIn())
+	require.Equal(t, btcutil.Amount(299000), proposal.TotalOut())
+	require.Equal(t, proposal.Fee, proposal.TotalIn()-proposal.TotalOut())
+	require.Equal(t,
+		maketx.EstimateSignedTxSize(tx, []*signing.Configuration{
+			inputAddresses[0].Configuration,
+			inputAddresses[1].Configuration,
+		}),
+		proposal.VSize())
+	// 2 inputs (P2WPKH, P2TR), a P2PKH output and a P2WPKH change output.
+	require.Equal(t, 201, proposal.VSize())
+}
+
+func TestTxProposalRecipients(t *testing.T) {
+	blockchain := &blockchainMock.BlockchainMock{}
+	blockchain.MockRegisterOnConnectionErrorChangedEvent = func(f func(error)) {}
+	blockchain.MockRelayFee = func() (btcutil.Amount, error) { return 1000, nil }
+	account := mockAccountWithBlockchain(t, nil, blockchain)
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH).EncodeForHumans()
+	newArgs := func(recipients ...accounts.Recipient) *accounts.TxProposalArgs {
+		return &accounts.TxProposalArgs{
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "1",
+			Recipients:    recipients,
+		}
+	}
+
+	// The invalid recipient is reported by its index.
+	_, err := account.NewTransactionProposal(newArgs(
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.001")},
+		accounts.Recipient{Address: "invalid", Amount: coin.NewSendAmount("0.001")},
+	))
+	require.Equal(t,
+		&errors.RecipientError{Index: 1, Err: errors.ErrInvalidAddress},
+		errp.Cause(err))
+	_, err = account.NewTransactionProposal(newArgs(
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.001")},
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.001")},
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.00000001")},
+	))
+	require.Equal(t,
+		&errors.RecipientError{Index: 2, Err: errors.ErrDustAmount},
+		errp.Cause(err))
+
+	// Sending all funds is only possible to a single recipient.
+	_, err = account.NewTransactionProposal(newArgs(
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmountAll()},
+		accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.001")},
+	))
+	require.Equal(t, errors.ErrSendAllMultipleRecipients, errp.Cause(err))
+
+	recipients := make([]accounts.Recipient, 101)
+	for index := range recipients {
+		recipients[index] = accounts.Recipient{Address: address, Amount: coin.NewSendAmount("0.001")}
+	}
+	_, err = account.NewTransactionProposal(newArgs(recipients...))
+	require.Equal(t, errors.ErrTooManyRecipients, errp.Cause(err))
+
+	// Valid recipients, but the account has no funds.
+	_, err = account.NewTransactionProposal(newArgs(recipients[:100]...))
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
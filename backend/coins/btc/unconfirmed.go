@@ -0,0 +1,125 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// maxPackageFeeRounds limits how often a tx is made again with a higher fee rate to cover its
+// unconfirmed parents, as every round can select other coins.
+const maxPackageFeeRounds = 3
+
+// ActiveUnconfirmedInputs returns the outpoints spent by the active tx proposal which are not
+// confirmed yet, e.g. unconfirmed change, sorted. The tx can only confirm together with or after
+// the txs creating them. Returns nil if there is no active tx proposal.
+func (account *Account) ActiveUnconfirmedInputs() []string {
+	unlock := account.activeTxProposalLock.RLock()
+	txProposal := account.activeTxProposal
+	unlock()
+	if txProposal == nil {
+		return nil
+	}
+	result := []string{}
+	for _, txIn := range txProposal.Transaction.TxIn {
+		prevOut, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if ok && prevOut.Confirmations == 0 {
+			result = append(result, txIn.PreviousOutPoint.String())
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// packageFeeDeficit returns the fee which is missing for the tx and its unconfirmed parents to pay
+// the fee rate together, i.e. as a package which miners include at once (CPFP). It is 0 if the tx
+// spends no unconfirmed outputs, or if the parents pay enough fees. Parents whose fee is unknown
+// and further unconfirmed ancestors are not considered.
+func (account *Account) packageFeeDeficit(
+	txProposal *maketx.TxProposal, feeRatePerKb btcutil.Amount) (btcutil.Amount, int, error) {
+	inputConfigurations := make([]*signing.Configuration, len(txProposal.Transaction.TxIn))
+	parents := map[chainhash.Hash]struct{}{}
+	for index, txIn := range txProposal.Transaction.TxIn {
+		prevOut, ok := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return 0, 0, errp.Newf("output spent by input %d not found", index)
+		}
+		address := account.getAddress(prevOut.ScriptHashHex())
+		if address == nil {
+			return 0, 0, errp.Newf("address spent by input %d not found", index)
+		}
+		inputConfigurations[index] = address.Configuration
+		if prevOut.Confirmations == 0 {
+			parents[txIn.PreviousOutPoint.Hash] = struct{}{}
+		}
+	}
+	vsize := maketx.EstimateSignedTxSize(txProposal.Transaction, inputConfigurations)
+	if len(parents) == 0 {
+		return 0, vsize, nil
+	}
+	var parentsFee btcutil.Amount
+	var parentsVSize int64
+	for parent := range parents {
+		details, err := account.transactions.TxDetails(parent, account.isChange)
+		if err != nil {
+			return 0, 0, err
+		}
+		if details == nil || details.Fee == nil {
+			continue
+		}
+		parentsFee += *details.Fee
+		parentsVSize += details.VSize
+	}
+	packageFee := feeRatePerKb * btcutil.Amount(parentsVSize+int64(vsize)) / 1000
+	deficit := packageFee - parentsFee - txProposal.Fee
+	if deficit < 0 {
+		deficit = 0
+	}
+	return deficit, vsize, nil
+}
+
+// coverUnconfirmedParents makes the tx again with makeTx at a higher fee rate until it pays for the
+// missing fees of its unconfirmed parents, see packageFeeDeficit(), so that the package is mined
+// at the requested fee rate.
+func (account *Account) coverUnconfirmedParents(
+	txProposal *maketx.TxProposal,
+	feeRatePerKb btcutil.Amount,
+	makeTx func(feeRatePerKb btcutil.Amount) (*maketx.TxProposal, error),
+) (*maketx.TxProposal, error) {
+	for round := 0; round < maxPackageFeeRounds; round++ {
+		deficit, vsize, err := account.packageFeeDeficit(txProposal, feeRatePerKb)
+		if err != nil {
+			return nil, err
+		}
+		if deficit == 0 {
+			return txProposal, nil
+		}
+		// Rounded up, so that the fee of the new tx covers the deficit if its size stays the same.
+		childFeeRatePerKb := ((txProposal.Fee+deficit)*1000 + btcutil.Amount(vsize) - 1) / btcutil.Amount(vsize)
+		account.log.Infof("Raising the fee rate from %s/kvB to %s/kvB to cover %s of unconfirmed parents",
+			feeRatePerKb, childFeeRatePerKb, deficit)
+		txProposal, err = makeTx(childFeeRatePerKb)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return txProposal, nil
+}
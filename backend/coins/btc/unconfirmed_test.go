@@ -0,0 +1,73 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendUnconfirmedChange(t *testing.T) {
+	account, sent := fundedAccount(t, func(*btc.Account) {})
+	require.Eventually(t, func() bool {
+		balance, err := account.Balance()
+		require.NoError(t, err)
+		return balance.Available().BigInt().Int64() == 71000
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The confirmed coins do not suffice, so the unconfirmed change is spent.
+	args := &accounts.TxProposalArgs{
+		RecipientAddress: addressesTest.GetAddress(signing.ScriptTypeP2WPKH).EncodeForHumans(),
+		Amount:           coin.NewSendAmount("0.0006"),
+		FeeTargetCode:    accounts.FeeTargetCodeCustom,
+		CustomFee:        "20",
+	}
+	_, _, _, err := account.TxProposal(args)
+	require.NoError(t, err)
+	change := wire.OutPoint{Hash: sent.TxHash(), Index: 1}
+	require.Contains(t, account.ActiveUnconfirmedInputs(), change.String())
+
+	txProposal, err := account.NewTransactionProposal(args)
+	require.NoError(t, err)
+
+	// The parent pays less than the fee rate, so the fee is raised to cover it.
+	args.CoverUnconfirmedParents = true
+	covered, err := account.NewTransactionProposal(args)
+	require.NoError(t, err)
+	require.Greater(t, covered.Fee, txProposal.Fee)
+	parentVSize := mempool.GetTxVirtualSize(btcutil.NewTx(sent))
+	const parentFee = 1000
+	require.GreaterOrEqual(t, int64(covered.Fee)+parentFee, 20*(parentVSize+int64(covered.VSize())))
+
+	// At a lower fee rate, the parent pays enough.
+	args.CustomFee = "1"
+	args.CoverUnconfirmedParents = false
+	txProposal, err = account.NewTransactionProposal(args)
+	require.NoError(t, err)
+	args.CoverUnconfirmedParents = true
+	covered, err = account.NewTransactionProposal(args)
+	require.NoError(t, err)
+	require.Equal(t, txProposal.Fee, covered.Fee)
+}
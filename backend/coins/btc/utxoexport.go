@@ -0,0 +1,259 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// UTXOExportVersion is the version of the UTXO export format. It is incremented when the format
+// changes in an incompatible way.
+const UTXOExportVersion = 1
+
+// UTXOExport is a machine-readable export of the UTXO set of an account, so that the holdings can
+// be verified externally, see VerifyUTXOExport().
+type UTXOExport struct {
+	Version int            `json:"version"`
+	Coin    coinpkg.Code   `json:"coin"`
+	UTXOs   []ExportedUTXO `json:"utxos"`
+	// ProofOfReserves is set if a message to sign was provided.
+	ProofOfReserves *ProofOfReserves `json:"proofOfReserves,omitempty"`
+}
+
+// ExportedUTXO is an unspent output in the UTXO export.
+type ExportedUTXO struct {
+	// Outpoint is formatted as `<txid>:<output index>`.
+	Outpoint string `json:"outpoint"`
+	// Amount is in satoshi.
+	Amount int64 `json:"amount"`
+	// Script is the hex encoded pubkey script of the output.
+	Script          string             `json:"script"`
+	ScriptType      signing.ScriptType `json:"scriptType"`
+	Address         string             `json:"address"`
+	RootFingerprint string             `json:"rootFingerprint"`
+	Keypath         string             `json:"keypath"`
+	// Height is the height of the block containing the tx of the output, 0 if unconfirmed.
+	Height int `json:"height"`
+	// RawTx is the hex encoded tx creating the output, so that the amount and script can be checked
+	// against the txid.
+	RawTx string `json:"rawTx"`
+	// MerkleProof is set if the tx was verified against the block headers.
+	MerkleProof *ExportedMerkleProof `json:"merkleProof,omitempty"`
+}
+
+// ExportedMerkleProof is the proof that the tx of an exported UTXO is included in a block.
+type ExportedMerkleProof struct {
+	BlockHash string `json:"blockHash"`
+	// Pos is the position of the tx in the block.
+	Pos int `json:"pos"`
+	// Merkle is the merkle branch from the txid to the merkle root, as hex encoded hashes in the
+	// byte order used by block explorers.
+	Merkle []string `json:"merkle"`
+}
+
+// ProofOfReserves contains the signatures of a message by the keys of the addresses holding the
+// exported UTXOs.
+type ProofOfReserves struct {
+	Message string `json:"message"`
+	// Signatures maps addresses to the base64 encoded signature of the message in the Electrum
+	// format, the same as used when signing a message with an address of the account.
+	Signatures map[string]string `json:"signatures"`
+}
+
+// ExportUTXOs returns all unspent outputs of the account, including unconfirmed ones. The UTXO set
+// and the addresses are read under the addresses lock, so they are consistent even while syncing.
+// If proofOfReservesMessage is not empty, the message is signed with the key of every address
+// holding a UTXO, which requires the keystore to be connected.
+func (account *Account) ExportUTXOs(proofOfReservesMessage string) (*UTXOExport, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account must be initialized")
+	}
+	export := &UTXOExport{
+		Version: UTXOExportVersion,
+		Coin:    account.coin.Code(),
+		UTXOs:   []ExportedUTXO{},
+	}
+	utxoAddresses := map[string]*addresses.AccountAddress{}
+	err := func() error {
+		defer account.addressesByScriptHashLock.RLock()()
+		utxos, err := account.transactions.UnspentOutputs()
+		if err != nil {
+			return err
+		}
+		for outPoint, utxo := range utxos {
+			address, ok := account.addressesByScriptHash[blockchain.NewScriptHashHex(utxo.PkScript)]
+			if !ok {
+				return errp.Newf("address of output %s not found", outPoint)
+			}
+			exported, err := exportUTXO(outPoint, utxo, address)
+			if err != nil {
+				return err
+			}
+			export.UTXOs = append(export.UTXOs, *exported)
+			utxoAddresses[exported.Address] = address
+		}
+		return nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(export.UTXOs, func(i, j int) bool {
+		return export.UTXOs[i].Outpoint < export.UTXOs[j].Outpoint
+	})
+
+	if proofOfReservesMessage == "" {
+		return export, nil
+	}
+	keystore, err := account.Config().ConnectKeystore()
+	if err != nil {
+		return nil, err
+	}
+	if !keystore.CanSignMessage(account.coin.Code()) {
+		return nil, errp.Newf("The connected device or keystore cannot sign messages for %s",
+			account.coin.Code())
+	}
+	export.ProofOfReserves = &ProofOfReserves{
+		Message:    proofOfReservesMessage,
+		Signatures: map[string]string{},
+	}
+	for encodedAddress, address := range utxoAddresses {
+		signature, err := keystore.SignBTCMessage(
+			[]byte(proofOfReservesMessage),
+			address.AbsoluteKeypath(),
+			address.Configuration.ScriptType(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		export.ProofOfReserves.Signatures[encodedAddress] = base64.StdEncoding.EncodeToString(signature)
+	}
+	return export, nil
+}
+
+func exportUTXO(
+	outPoint wire.OutPoint,
+	utxo *transactions.UnspentOutput,
+	address *addresses.AccountAddress,
+) (*ExportedUTXO, error) {
+	var rawTx bytes.Buffer
+	if err := utxo.Tx.Serialize(&rawTx); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	exported := &ExportedUTXO{
+		Outpoint:        outPoint.String(),
+		Amount:          utxo.Value,
+		Script:          hex.EncodeToString(utxo.PkScript),
+		ScriptType:      address.Configuration.ScriptType(),
+		Address:         address.EncodeForHumans(),
+		RootFingerprint: hex.EncodeToString(address.Configuration.BitcoinSimple.KeyInfo.RootFingerprint),
+		Keypath:         address.AbsoluteKeypath().Encode(),
+		Height:          utxo.Height,
+		RawTx:           hex.EncodeToString(rawTx.Bytes()),
+	}
+	if utxo.MerkleProof != nil {
+		merkle := make([]string, len(utxo.MerkleProof.Merkle))
+		for i, hash := range utxo.MerkleProof.Merkle {
+			merkle[i] = chainhash.Hash(hash).String()
+		}
+		exported.MerkleProof = &ExportedMerkleProof{
+			BlockHash: utxo.MerkleProof.BlockHash.String(),
+			Pos:       utxo.MerkleProof.Pos,
+			Merkle:    merkle,
+		}
+	}
+	return exported, nil
+}
+
+// VerifyUTXOExport checks the UTXOs of the export against the given block headers, indexed by
+// height. For every UTXO, the amount and script must match the output of the raw tx, whose hash
+// must match the outpoint. If the UTXO has a merkle proof, the header at its height must match the
+// block hash of the proof, and its merkle root the one computed from the proof. The outpoints of
+// the UTXOs without a merkle proof, e.g. unconfirmed ones, are returned, as their inclusion in the
+// chain is not proven. The proof of reserves signatures are not checked.
+func VerifyUTXOExport(export *UTXOExport, headers map[int]*wire.BlockHeader) ([]string, error) {
+	if export.Version != UTXOExportVersion {
+		return nil, errp.Newf("unsupported export version %d", export.Version)
+	}
+	unproven := []string{}
+	for _, utxo := range export.UTXOs {
+		if err := verifyExportedUTXO(&utxo, headers); err != nil {
+			return nil, errp.WithMessage(err, utxo.Outpoint)
+		}
+		if utxo.MerkleProof == nil {
+			unproven = append(unproven, utxo.Outpoint)
+		}
+	}
+	return unproven, nil
+}
+
+func verifyExportedUTXO(utxo *ExportedUTXO, headers map[int]*wire.BlockHeader) error {
+	outPoint, err := wire.NewOutPointFromString(utxo.Outpoint)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	rawTx, err := hex.DecodeString(utxo.RawTx)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return errp.WithStack(err)
+	}
+	txHash := tx.TxHash()
+	if txHash != outPoint.Hash {
+		return errp.New("the raw tx does not match the outpoint")
+	}
+	if int(outPoint.Index) >= len(tx.TxOut) {
+		return errp.New("the output index is out of range")
+	}
+	txOut := tx.TxOut[outPoint.Index]
+	if txOut.Value != utxo.Amount || hex.EncodeToString(txOut.PkScript) != utxo.Script {
+		return errp.New("the amount or script does not match the raw tx")
+	}
+	if utxo.MerkleProof == nil {
+		return nil
+	}
+	header, ok := headers[utxo.Height]
+	if !ok || header == nil {
+		return errp.Newf("missing header at height %d", utxo.Height)
+	}
+	if header.BlockHash().String() != utxo.MerkleProof.BlockHash {
+		return errp.Newf("the header at height %d does not match the block hash", utxo.Height)
+	}
+	proof := &transactions.MerkleProof{Pos: utxo.MerkleProof.Pos}
+	for _, encodedHash := range utxo.MerkleProof.Merkle {
+		hash, err := chainhash.NewHashFromStr(encodedHash)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		proof.Merkle = append(proof.Merkle, blockchain.TXHash(*hash))
+	}
+	if proof.MerkleRoot(txHash) != header.MerkleRoot {
+		return errp.New("the merkle proof does not match the merkle root of the header")
+	}
+	return nil
+}
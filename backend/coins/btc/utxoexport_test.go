@@ -0,0 +1,119 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportUTXOsEmpty(t *testing.T) {
+	account := mockAccount(t, nil)
+	_, err := account.ExportUTXOs("")
+	require.Error(t, err)
+
+	require.NoError(t, account.Initialize())
+	defer account.Close()
+	export, err := account.ExportUTXOs("")
+	require.NoError(t, err)
+	require.Equal(t, &btc.UTXOExport{
+		Version: btc.UTXOExportVersion,
+		Coin:    coinpkg.CodeTBTC,
+		UTXOs:   []btc.ExportedUTXO{},
+	}, export)
+}
+
+func TestVerifyUTXOExport(t *testing.T) {
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	tx.AddTxOut(wire.NewTxOut(100000, address.PubkeyScript()))
+	var rawTx bytes.Buffer
+	require.NoError(t, tx.Serialize(&rawTx))
+	txHash := tx.TxHash()
+
+	// A block containing the tx and one other tx.
+	otherTxHash := chainhash.HashH([]byte("other tx"))
+	header := &wire.BlockHeader{
+		MerkleRoot: chainhash.DoubleHashH(append(txHash[:], otherTxHash[:]...)),
+	}
+	headers := map[int]*wire.BlockHeader{100: header}
+
+	makeExport := func() *btc.UTXOExport {
+		return &btc.UTXOExport{
+			Version: btc.UTXOExportVersion,
+			Coin:    coinpkg.CodeTBTC,
+			UTXOs: []btc.ExportedUTXO{
+				{
+					Outpoint: wire.NewOutPoint(&txHash, 1).String(),
+					Amount:   100000,
+					Script:   hex.EncodeToString(address.PubkeyScript()),
+					Height:   100,
+					RawTx:    hex.EncodeToString(rawTx.Bytes()),
+					MerkleProof: &btc.ExportedMerkleProof{
+						BlockHash: header.BlockHash().String(),
+						Pos:       0,
+						Merkle:    []string{otherTxHash.String()},
+					},
+				},
+			},
+		}
+	}
+
+	unproven, err := btc.VerifyUTXOExport(makeExport(), headers)
+	require.NoError(t, err)
+	require.Empty(t, unproven)
+
+	// Without a proof, the UTXO is reported as unproven.
+	export := makeExport()
+	export.UTXOs[0].MerkleProof = nil
+	unproven, err = btc.VerifyUTXOExport(export, headers)
+	require.NoError(t, err)
+	require.Equal(t, []string{export.UTXOs[0].Outpoint}, unproven)
+
+	// Amount not matching the tx.
+	export = makeExport()
+	export.UTXOs[0].Amount = 200000
+	_, err = btc.VerifyUTXOExport(export, headers)
+	require.Error(t, err)
+
+	// Wrong position in the block.
+	export = makeExport()
+	export.UTXOs[0].MerkleProof.Pos = 1
+	_, err = btc.VerifyUTXOExport(export, headers)
+	require.Error(t, err)
+
+	// Header missing or not matching the block hash.
+	_, err = btc.VerifyUTXOExport(makeExport(), map[int]*wire.BlockHeader{})
+	require.Error(t, err)
+	_, err = btc.VerifyUTXOExport(makeExport(), map[int]*wire.BlockHeader{100: {Nonce: 1}})
+	require.Error(t, err)
+
+	// Unknown version.
+	export = makeExport()
+	export.Version = btc.UTXOExportVersion + 1
+	_, err = btc.VerifyUTXOExport(export, headers)
+	require.Error(t, err)
+}
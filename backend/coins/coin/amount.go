@@ -38,8 +38,9 @@ func NewAmountFromInt64(amount int64) Amount {
 }
 
 // NewAmountFromString parses a user given coin amount, converting it from the default coin unit to
-// the smallest unit.
+// the smallest unit. Thousands separators (') as produced by FormatBtcAmount are ignored.
 func NewAmountFromString(s string, unit *big.Int) (Amount, error) {
+	s = strings.ReplaceAll(s, "'", "")
 	// big.Rat parsing accepts rationals like "2/3". Exclude those, we only want decimals.
 	if strings.ContainsRune(s, '/') {
 		return Amount{}, errp.Newf("could not parse %q", s)
@@ -0,0 +1,114 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin
+
+import (
+	"net/url"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// BlockExplorerURLKind is the kind of object that can be opened in a block explorer.
+type BlockExplorerURLKind string
+
+const (
+	// BlockExplorerTx is a transaction, identified by its transaction ID.
+	BlockExplorerTx BlockExplorerURLKind = "tx"
+	// BlockExplorerAddress is an address.
+	BlockExplorerAddress BlockExplorerURLKind = "address"
+	// BlockExplorerBlock is a block, identified by its height.
+	BlockExplorerBlock BlockExplorerURLKind = "block"
+)
+
+// BlockExplorer is a website to view transactions, addresses and blocks in.
+type BlockExplorer struct {
+	// Name is shown to the user, e.g. "mempool.space".
+	Name string `json:"name"`
+	// URL is the base URL of the explorer, e.g. "https://mempool.space/". It identifies the
+	// explorer in the config.
+	URL string `json:"url"`
+	// TxPath is appended to URL, followed by the transaction ID, e.g. "tx/".
+	TxPath string `json:"-"`
+	// AddressPath is appended to URL, followed by the address, e.g. "address/".
+	AddressPath string `json:"-"`
+	// BlockPath is appended to URL, followed by the block height, e.g. "block/". Empty if the
+	// explorer can't show a block by its height.
+	BlockPath string `json:"-"`
+}
+
+// ValidateBlockExplorerURL checks that the URL can be used as the base URL of a block explorer. Only
+// absolute https URLs are allowed, so that e.g. "javascript:" URLs can never be opened.
+func ValidateBlockExplorerURL(explorerURL string) error {
+	parsed, err := url.Parse(explorerURL)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return errp.Newf("block explorer URL must be an https URL: %q", explorerURL)
+	}
+	return nil
+}
+
+// path returns the path of the given kind, and false if the kind is unknown.
+func (explorer BlockExplorer) path(kind BlockExplorerURLKind) (string, bool) {
+	switch kind {
+	case BlockExplorerTx:
+		return explorer.TxPath, true
+	case BlockExplorerAddress:
+		return explorer.AddressPath, true
+	case BlockExplorerBlock:
+		return explorer.BlockPath, true
+	default:
+		return "", false
+	}
+}
+
+// URLPrefix returns the URL to which the ID of the given kind is appended to view it. It is empty
+// if there is no explorer, e.g. for regtest, or if the explorer has no page for the kind.
+func (explorer BlockExplorer) URLPrefix(kind BlockExplorerURLKind) string {
+	path, ok := explorer.path(kind)
+	if explorer.URL == "" || path == "" || !ok {
+		return ""
+	}
+	return explorer.URL + path
+}
+
+// TxURLPrefix returns the URL to which a transaction ID is appended to view the transaction.
+func (explorer BlockExplorer) TxURLPrefix() string {
+	return explorer.URLPrefix(BlockExplorerTx)
+}
+
+// BuildURL returns the URL of the transaction, address or block with the given ID. The ID is
+// escaped, so it can not change the path or add a query to the URL. The URL is empty if there is no
+// explorer, e.g. for regtest, or if the explorer has no page for the kind.
+func (explorer BlockExplorer) BuildURL(kind BlockExplorerURLKind, id string) (string, error) {
+	path, ok := explorer.path(kind)
+	if !ok {
+		return "", errp.Newf("unknown block explorer URL kind %q", kind)
+	}
+	if id == "" {
+		return "", errp.New("block explorer ID must not be empty")
+	}
+	if explorer.URL == "" {
+		return "", nil
+	}
+	if err := ValidateBlockExplorerURL(explorer.URL); err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+	return explorer.URL + path + url.PathEscape(id), nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockExplorerBuildURL(t *testing.T) {
+	explorer := BlockExplorer{
+		Name:        "mempool.space",
+		URL:         "https://mempool.space/",
+		TxPath:      "tx/",
+		AddressPath: "address/",
+		BlockPath:   "block/",
+	}
+	require.Equal(t, "https://mempool.space/tx/", explorer.TxURLPrefix())
+	require.Equal(t, "https://mempool.space/tx/", explorer.URLPrefix(BlockExplorerTx))
+	require.Equal(t, "https://mempool.space/address/", explorer.URLPrefix(BlockExplorerAddress))
+	require.Equal(t, "https://mempool.space/block/", explorer.URLPrefix(BlockExplorerBlock))
+	require.Equal(t, "", explorer.URLPrefix("unknown"))
+
+	u, err := explorer.BuildURL(BlockExplorerTx, "0d5f28d6a6b0c8d0a8d3e8b6f6b5a7e1b3e8f0a7c9b1d2e3f4a5b6c7d8e9f001")
+	require.NoError(t, err)
+	require.Equal(t,
+		"https://mempool.space/tx/0d5f28d6a6b0c8d0a8d3e8b6f6b5a7e1b3e8f0a7c9b1d2e3f4a5b6c7d8e9f001", u)
+
+	u, err = explorer.BuildURL(BlockExplorerAddress, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	require.NoError(t, err)
+	require.Equal(t, "https://mempool.space/address/bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", u)
+
+	// The ID can not escape the path.
+	u, err = explorer.BuildURL(BlockExplorerAddress, "../../evil?x=1#y z")
+	require.NoError(t, err)
+	require.Equal(t, "https://mempool.space/address/..%2F..%2Fevil%3Fx=1%23y%20z", u)
+
+	u, err = explorer.BuildURL(BlockExplorerBlock, "840000")
+	require.NoError(t, err)
+	require.Equal(t, "https://mempool.space/block/840000", u)
+
+	_, err = explorer.BuildURL("unknown", "1")
+	require.Error(t, err)
+	_, err = explorer.BuildURL(BlockExplorerTx, "")
+	require.Error(t, err)
+
+	// The explorer has no page for blocks.
+	explorer.BlockPath = ""
+	require.Equal(t, "", explorer.URLPrefix(BlockExplorerBlock))
+	u, err = explorer.BuildURL(BlockExplorerBlock, "840000")
+	require.NoError(t, err)
+	require.Equal(t, "", u)
+
+	// No explorer, e.g. for regtest.
+	require.Equal(t, "", BlockExplorer{}.TxURLPrefix())
+	require.Equal(t, "", BlockExplorer{}.URLPrefix(BlockExplorerAddress))
+	for _, kind := range []BlockExplorerURLKind{BlockExplorerTx, BlockExplorerAddress, BlockExplorerBlock} {
+		u, err = BlockExplorer{}.BuildURL(kind, "1")
+		require.NoError(t, err)
+		require.Equal(t, "", u)
+	}
+}
+
+func TestValidateBlockExplorerURL(t *testing.T) {
+	require.NoError(t, ValidateBlockExplorerURL("https://mempool.space/"))
+	require.NoError(t, ValidateBlockExplorerURL("https://mempool.space/testnet4/"))
+
+	for _, invalid := range []string{
+		"",
+		"javascript:alert(1)//",
+		"JavaScript:alert(1)",
+		"http://mempool.space/",
+		"file:///etc/passwd",
+		"https:///tx/",
+		"mempool.space/",
+	} {
+		require.Error(t, ValidateBlockExplorerURL(invalid), invalid)
+		if invalid == "" {
+			// No explorer, see TestBlockExplorerBuildURL.
+			continue
+		}
+		_, err := BlockExplorer{URL: invalid}.BuildURL(BlockExplorerTx, "1")
+		require.Error(t, err, invalid)
+	}
+}
@@ -14,6 +14,8 @@
 
 package coin
 
+import "math/big"
+
 // Code represents a unique coin code. Usually the coin acronym in lowercase. ERC20-Tokens are also coins.
 type Code string
 
@@ -22,6 +24,10 @@ const (
 	CodeBTC Code = "btc"
 	// CodeTBTC is Bitcoin Testnet.
 	CodeTBTC Code = "tbtc"
+	// CodeTBTC4 is Bitcoin Testnet4.
+	CodeTBTC4 Code = "tbtc4"
+	// CodeSBTC is Bitcoin Signet.
+	CodeSBTC Code = "sbtc"
 	// CodeRBTC is Bitcoin Regtest.
 	CodeRBTC Code = "rbtc"
 	// CodeLTC is Litecoin.
@@ -47,11 +53,34 @@ const (
 	BtcUnitDefault BtcUnit = "default"
 	// BtcUnitSats formats the value as satoshis. Applies to both Bitcoin mainnet and testnet.
 	BtcUnitSats BtcUnit = "sat"
+	// BtcUnitMilliBTC formats the value as milli-bitcoins (1 mBTC = 100'000 sat). Applies to both
+	// Bitcoin mainnet and testnet.
+	BtcUnitMilliBTC BtcUnit = "mBTC"
 )
 
+// Decimals returns the number of decimals of the unit, i.e. the unit is 10^decimals satoshis.
+// Unknown units are treated like BtcUnitDefault.
+func (unit BtcUnit) Decimals() int {
+	switch unit {
+	case BtcUnitSats:
+		return 0
+	case BtcUnitMilliBTC:
+		return 5
+	default:
+		return 8
+	}
+}
+
+// SatsPerUnit returns the number of satoshis in one unit.
+func (unit BtcUnit) SatsPerUnit() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(unit.Decimals())), nil)
+}
+
 // TestnetCoins is the subset of all coins which are available in testnet mode.
 var TestnetCoins = map[Code]struct{}{
 	CodeTBTC:   {},
+	CodeTBTC4:  {},
+	CodeSBTC:   {},
 	CodeTLTC:   {},
 	CodeGOETH:  {},
 	CodeSEPETH: {},
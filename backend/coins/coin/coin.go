@@ -45,6 +45,14 @@ type Coin interface {
 	// GetFormatUnit sets the unit used to format the amount, e.g. "BTC" or "sat".
 	GetFormatUnit(isFee bool) string
 
+	// ActiveFiats returns the fiat currencies selected by the user, e.g. "USD" and "EUR". Fiat
+	// conversions of amounts are limited to these currencies. If none were selected, conversions
+	// to all currencies are provided.
+	ActiveFiats() []string
+
+	// SetActiveFiats sets the fiat currencies selected by the user. See ActiveFiats().
+	SetActiveFiats(fiats []string)
+
 	// Number of decimal places in the standard unit, e.g. 8 for Bitcoin. Must be in the range
 	// [0..31].
 	Decimals(isFee bool) uint
@@ -73,16 +81,40 @@ type Coin interface {
 	// BlockExplorerTransactionURLPrefix returns the URL prefix of the block explorer.
 	BlockExplorerTransactionURLPrefix() string
 
+	// BlockExplorerURL returns the URL of a transaction, address or block in the selected block
+	// explorer, or an empty string if there is none.
+	BlockExplorerURL(kind BlockExplorerURLKind, id string) (string, error)
+
+	// BlockExplorerURLPrefix returns the URL to which the ID of a transaction, address or block is
+	// appended to view it in the selected block explorer, or an empty string if there is none.
+	BlockExplorerURLPrefix(kind BlockExplorerURLKind) string
+
+	// SetBlockExplorer selects the block explorer and notifies observers about the change.
+	SetBlockExplorer(explorer BlockExplorer)
+
 	// Initialize initializes the coin by connecting to a full node, downloading the headers, etc.
-	Initialize()
+	Initialize() error
 
 	// SmallestUnit returns the name of the smallest unit of a given coin
 	SmallestUnit() string
 
+	// ValidateAddress checks that the address is a valid recipient address of this coin and returns
+	// its type, e.g. "p2wpkh" for Bitcoin. The returned error is an accounts/errors.TxValidationError
+	// describing why the address is invalid.
+	ValidateAddress(address string) (string, error)
+
 	// Close shuts down all resources obtained by the coin (network connections, databases, etc.).
 	Close() error
 }
 
+// RatesObserver is implemented by coins which observe the rate updater on behalf of their accounts.
+// The coin stops observing the rate updater when it is closed.
+type RatesObserver interface {
+	// ObserveRates calls f whenever new latest or historical rates are available while the coin
+	// is initialized. The returned function unobserves.
+	ObserveRates(f func()) func()
+}
+
 // DecimalsExp returns the conversion exponential from the smallest unit to the standard unit
 // (BTC, LTC; ETH, etc.). e.g. 1e8 for Bitcoin/Litecoin, 1e18 for Ethereum, etc.
 func DecimalsExp(coin Coin) *big.Int {
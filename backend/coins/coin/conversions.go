@@ -2,15 +2,20 @@ package coin
 
 import (
 	"math/big"
-	"strings"
+	"regexp"
 	"time"
 
 	ratesPkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 )
 
 // Btc2Sat is the sat equivalent of 1 BTC.
 const btc2SatUnit = 1e8
 
+// btcAmountRegexp matches the amounts produced by FormatBtcAmount: decimals, optionally negative
+// and with thousands separators. Exponents, fractions and hexadecimal numbers are not accepted.
+var btcAmountRegexp = regexp.MustCompile(`^-?[0-9][0-9']*(\.[0-9]+)?$`)
+
 // Sat2Btc converts a big.Rat amount of Sat in an equivalent amount of BTC.
 func Sat2Btc(amount *big.Rat) *big.Rat {
 	return new(big.Rat).Quo(amount, big.NewRat(btc2SatUnit, 1))
@@ -21,6 +26,26 @@ func Btc2Sat(amount *big.Rat) *big.Rat {
 	return new(big.Rat).Mul(amount, big.NewRat(btc2SatUnit, 1))
 }
 
+// FormatBtcAmount formats an amount of satoshis in the given unit. Satoshis are formatted as an
+// integer with thousands separators, like FormatAsCurrency, other units with all their decimals.
+func FormatBtcAmount(amount *big.Int, unit BtcUnit) string {
+	if unit == BtcUnitSats {
+		return numberFormatBtc.Format(new(big.Rat).SetInt(amount), 0)
+	}
+	return new(big.Rat).SetFrac(amount, unit.SatsPerUnit()).FloatString(unit.Decimals())
+}
+
+// ParseBtcAmount parses an amount given in the given unit and returns it in satoshis. It is the
+// inverse of FormatBtcAmount, including the thousands separators. The amount is parsed as a fixed
+// point decimal, so amounts which are not a whole number of satoshis are rejected instead of being
+// rounded.
+func ParseBtcAmount(amount string, unit BtcUnit) (Amount, error) {
+	if !btcAmountRegexp.MatchString(amount) {
+		return Amount{}, errp.Newf("could not parse %q", amount)
+	}
+	return NewAmountFromString(amount, unit.SatsPerUnit())
+}
+
 // FormatAsPlainCurrency handles formatting for currencies in a simplified way.
 // This should be used when `FormatAsCurrency` can't be used because a simpler formatting is needed (e.g. to populate forms in the frontend).
 func FormatAsPlainCurrency(amount *big.Rat, currency string) string {
@@ -36,48 +61,120 @@ func FormatAsPlainCurrency(amount *big.Rat, currency string) string {
 	return formatted
 }
 
-// FormatAsCurrency handles formatting for currencies.
+// FormatAsCurrency handles formatting for currencies. Fiat amounts are rounded half to even to 2
+// decimals and formatted in the locale set by SetLocale. BTC and sat amounts are formatted like
+// Bitcoin amounts, see FormatBtcAmount, as the frontend localizes them.
 func FormatAsCurrency(amount *big.Rat, currency string) string {
-	formatted := FormatAsPlainCurrency(amount, currency)
-	position := strings.Index(formatted, ".") - 3
-	for position > 0 {
-		formatted = formatted[:position] + "'" + formatted[position:]
-		position -= 3
+	return FormatAsCurrencyInLocale(amount, currency, currentNumberFormat())
+}
+
+// FormatAsCurrencyInLocale is like FormatAsCurrency, using the given number format for fiat
+// amounts.
+func FormatAsCurrencyInLocale(amount *big.Rat, currency string, format NumberFormat) string {
+	switch currency {
+	case ratesPkg.BTC.String():
+		return numberFormatBtc.Format(amount, 8)
+	case ratesPkg.SAT.String():
+		return numberFormatBtc.Format(amount, 0)
+	default:
+		return format.Format(amount, 2)
 	}
-	return formatted
 }
 
-// Conversions handles fiat conversions.
-func Conversions(amount Amount, coin Coin, isFee bool, ratesUpdater *ratesPkg.RateUpdater, formatBtcAsSats bool) map[string]string {
+// formatConversion formats an amount converted to the given currency. If formatBtcAsSats is true,
+// conversions to BTC are formatted in sats.
+func formatConversion(amount *big.Rat, currency string, formatBtcAsSats bool) string {
+	if formatBtcAsSats && currency == ratesPkg.BTC.String() {
+		return FormatAsCurrency(Btc2Sat(amount), ratesPkg.SAT.String())
+	}
+	return FormatAsCurrency(amount, currency)
+}
+
+// includeConversion returns true if the conversion to the given currency should be computed. Only
+// the conversions to the active fiat currencies of the coin are included, unless allConversions
+// is true or the coin has no active fiat currencies.
+func includeConversion(coin Coin, currency string, allConversions bool) bool {
+	activeFiats := coin.ActiveFiats()
+	if allConversions || len(activeFiats) == 0 {
+		return true
+	}
+	for _, fiat := range activeFiats {
+		if fiat == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Conversions handles fiat conversions. If allConversions is false, only the conversions to the
+// active fiat currencies of the coin are returned.
+func Conversions(amount Amount, coin Coin, isFee bool, ratesUpdater *ratesPkg.RateUpdater, formatBtcAsSats bool, allConversions bool) map[string]string {
+	return conversionsWithRates(amount, coin, isFee, ratesUpdater.LatestPrice(), formatBtcAsSats, allConversions)
+}
+
+// FormattedAmount with unit and conversions.
+type FormattedAmount struct {
+	Amount      string            `json:"amount"`
+	Unit        string            `json:"unit"`
+	Conversions map[string]string `json:"conversions"`
+}
+
+// FormatAmountAsJSON formats the amount including its fiat conversions to the active fiat
+// currencies of the coin. If allConversions is true, the conversions to all available currencies are included.
+func FormatAmountAsJSON(amount Amount, coin Coin, isFee bool, ratesUpdater *ratesPkg.RateUpdater, formatBtcAsSats bool, allConversions bool) FormattedAmount {
+	return FormattedAmount{
+		Amount:      coin.FormatAmount(amount, isFee),
+		Unit:        coin.GetFormatUnit(isFee),
+		Conversions: Conversions(amount, coin, isFee, ratesUpdater, formatBtcAsSats, allConversions),
+	}
+}
+
+// ConversionsFromSnapshot handles fiat conversions using the rates of the given snapshot instead of
+// the latest rates. If the snapshot is nil, no conversions are returned.
+func ConversionsFromSnapshot(amount Amount, coin Coin, isFee bool, snapshot *ratesPkg.Snapshot, formatBtcAsSats bool, allConversions bool) map[string]string {
+	if snapshot == nil {
+		return map[string]string{}
+	}
+	return conversionsWithRates(amount, coin, isFee, snapshot.Rates, formatBtcAsSats, allConversions)
+}
+
+func conversionsWithRates(amount Amount, coin Coin, isFee bool, rates map[string]map[string]float64, formatBtcAsSats bool, allConversions bool) map[string]string {
 	conversions := map[string]string{}
-	rates := ratesUpdater.LatestPrice()
 	if rates != nil {
 		unit := coin.Unit(isFee)
-
-		conversions = map[string]string{}
 		for key, value := range rates[unit] {
+			if !includeConversion(coin, key, allConversions) {
+				continue
+			}
 			convertedAmount := new(big.Rat).Mul(new(big.Rat).SetFloat64(coin.ToUnit(amount, isFee)), new(big.Rat).SetFloat64(value))
-			conversions[key] = FormatAsCurrency(convertedAmount, key)
+			conversions[key] = formatConversion(convertedAmount, key, formatBtcAsSats)
 		}
 	}
 	return conversions
 }
 
-// ConversionsAtTime handles fiat conversions at a specific time.
-func ConversionsAtTime(amount Amount, coin Coin, isFee bool, ratesUpdater *ratesPkg.RateUpdater, formatBtcAsSats bool, timeStamp *time.Time) map[string]string {
+// ConversionsAtTime handles fiat conversions at a specific time. If allConversions is false, only
+// the conversions to the active fiat currencies of the coin are returned. If no historical rate is
+// available for a currency, e.g. for very recent transactions or gaps in the rates API, the latest
+// rate is used instead and the second result is true.
+func ConversionsAtTime(amount Amount, coin Coin, isFee bool, ratesUpdater *ratesPkg.RateUpdater, formatBtcAsSats bool, allConversions bool, timeStamp *time.Time) (map[string]string, bool) {
 	conversions := map[string]string{}
+	atLatestRate := false
 	lastRates := ratesUpdater.LatestPrice()
 	if lastRates != nil {
 		unit := coin.Unit(isFee)
-		for currency := range lastRates[unit] {
-			value := ratesUpdater.HistoricalPriceAt(string(coin.Code()), currency, *timeStamp)
-			if value == 0 {
-				conversions[currency] = ""
-			} else {
-				convertedAmount := new(big.Rat).Mul(new(big.Rat).SetFloat64(coin.ToUnit(amount, isFee)), new(big.Rat).SetFloat64(value))
-				conversions[currency] = FormatAsCurrency(convertedAmount, currency)
+		for currency, latestValue := range lastRates[unit] {
+			if !includeConversion(coin, currency, allConversions) {
+				continue
+			}
+			value, ok := ratesUpdater.HistoricalRate(string(coin.Code()), currency, *timeStamp)
+			if !ok {
+				value = latestValue
+				atLatestRate = true
 			}
+			convertedAmount := new(big.Rat).Mul(new(big.Rat).SetFloat64(coin.ToUnit(amount, isFee)), new(big.Rat).SetFloat64(value))
+			conversions[currency] = formatConversion(convertedAmount, currency, formatBtcAsSats)
 		}
 	}
-	return conversions
+	return conversions, atLatestRate
 }
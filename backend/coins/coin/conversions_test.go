@@ -17,8 +17,11 @@ package coin_test
 import (
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,3 +34,135 @@ func TestBtc2Sat(t *testing.T) {
 	require.Equal(t, "123456789", coin.Btc2Sat(new(big.Rat).SetFloat64(1.23456789)).FloatString(0))
 	require.Equal(t, "12345", coin.Btc2Sat(new(big.Rat).SetFloat64(0.00012345)).FloatString(0))
 }
+
+func TestFormatBtcAmount(t *testing.T) {
+	require.Equal(t, "1.23456789", coin.FormatBtcAmount(big.NewInt(123456789), coin.BtcUnitDefault))
+	require.Equal(t, "1234.56789", coin.FormatBtcAmount(big.NewInt(123456789), coin.BtcUnitMilliBTC))
+	require.Equal(t, "0.00001", coin.FormatBtcAmount(big.NewInt(1), coin.BtcUnitMilliBTC))
+	require.Equal(t, "123'456'789", coin.FormatBtcAmount(big.NewInt(123456789), coin.BtcUnitSats))
+	require.Equal(t, "123", coin.FormatBtcAmount(big.NewInt(123), coin.BtcUnitSats))
+	require.Equal(t, "1'000", coin.FormatBtcAmount(big.NewInt(1000), coin.BtcUnitSats))
+	require.Equal(t, "-100'000", coin.FormatBtcAmount(big.NewInt(-100000), coin.BtcUnitSats))
+	require.Equal(t, "0", coin.FormatBtcAmount(big.NewInt(0), coin.BtcUnitSats))
+}
+
+func TestParseBtcAmount(t *testing.T) {
+	for _, test := range []struct {
+		amount string
+		unit   coin.BtcUnit
+		sats   int64
+	}{
+		{"1.23456789", coin.BtcUnitDefault, 123456789},
+		{"1234.56789", coin.BtcUnitMilliBTC, 123456789},
+		{"123'456'789", coin.BtcUnitSats, 123456789},
+		{"123456789", coin.BtcUnitSats, 123456789},
+	} {
+		amount, err := coin.ParseBtcAmount(test.amount, test.unit)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(test.sats), amount.BigInt())
+		// Formatting and parsing roundtrips.
+		require.Equal(t, test.sats, mustParseBtc(t, coin.FormatBtcAmount(amount.BigInt(), test.unit), test.unit))
+	}
+
+	_, err := coin.ParseBtcAmount("1.5", coin.BtcUnitSats)
+	require.Error(t, err)
+	_, err = coin.ParseBtcAmount("0.000001", coin.BtcUnitMilliBTC)
+	require.Error(t, err)
+	_, err = coin.ParseBtcAmount("abc", coin.BtcUnitDefault)
+	require.Error(t, err)
+	for _, invalid := range []string{"1e-8", "0x10", "1/2", "+1", " 1", "1.", ".1"} {
+		_, err = coin.ParseBtcAmount(invalid, coin.BtcUnitDefault)
+		require.Error(t, err, invalid)
+	}
+}
+
+func mustParseBtc(t *testing.T, amount string, unit coin.BtcUnit) int64 {
+	t.Helper()
+	parsed, err := coin.ParseBtcAmount(amount, unit)
+	require.NoError(t, err)
+	sats, err := parsed.Int64()
+	require.NoError(t, err)
+	return sats
+}
+
+func TestConversionsFromSnapshot(t *testing.T) {
+	var activeFiats []string
+	btcCoin := &mocks.CoinMock{
+		ActiveFiatsFunc: func() []string { return activeFiats },
+		UnitFunc:        func(isFee bool) string { return "BTC" },
+		ToUnitFunc: func(amount coin.Amount, isFee bool) float64 {
+			sat, err := amount.Int64()
+			require.NoError(t, err)
+			return float64(sat) / 1e8
+		},
+	}
+	snapshot := &rates.Snapshot{
+		ID: "1",
+		Rates: map[string]map[string]float64{
+			"BTC": {"USD": 20000, "EUR": 18000, "CHF": 16000},
+		},
+	}
+	require.Equal(t,
+		map[string]string{"USD": "10'000.00", "EUR": "9'000.00", "CHF": "8'000.00"},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(5e7), btcCoin, false, snapshot, false, false),
+	)
+	require.Equal(t,
+		map[string]string{},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(5e7), btcCoin, false, nil, false, false),
+	)
+
+	// Only the conversions to the active fiats are returned, unless all conversions are requested.
+	activeFiats = []string{"EUR", "CHF"}
+	require.Equal(t,
+		map[string]string{"EUR": "9'000.00", "CHF": "8'000.00"},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(5e7), btcCoin, false, snapshot, false, false),
+	)
+	require.Equal(t,
+		map[string]string{"USD": "10'000.00", "EUR": "9'000.00", "CHF": "8'000.00"},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(5e7), btcCoin, false, snapshot, false, true),
+	)
+
+	// Conversions to BTC are formatted in sats if requested.
+	ethCoin := &mocks.CoinMock{
+		ActiveFiatsFunc: func() []string { return nil },
+		UnitFunc:        func(isFee bool) string { return "ETH" },
+		ToUnitFunc:      func(amount coin.Amount, isFee bool) float64 { return 2 },
+	}
+	snapshot.Rates["ETH"] = map[string]float64{"USD": 1500, "BTC": 0.075}
+	require.Equal(t,
+		map[string]string{"USD": "3'000.00", "BTC": "0.15000000"},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(2), ethCoin, false, snapshot, false, false),
+	)
+	require.Equal(t,
+		map[string]string{"USD": "3'000.00", "BTC": "15'000'000"},
+		coin.ConversionsFromSnapshot(coin.NewAmountFromInt64(2), ethCoin, false, snapshot, true, false),
+	)
+}
+
+func TestConversionsAtTime(t *testing.T) {
+	btcCoin := &mocks.CoinMock{
+		CodeFunc:        func() coin.Code { return coin.CodeBTC },
+		ActiveFiatsFunc: func() []string { return nil },
+		UnitFunc:        func(isFee bool) string { return "BTC" },
+		ToUnitFunc: func(amount coin.Amount, isFee bool) float64 {
+			sat, err := amount.Int64()
+			require.NoError(t, err)
+			return float64(sat) / 1e8
+		},
+	}
+	updater := rates.MockRateUpdater()
+	defer updater.Stop()
+
+	timestamp := time.Unix(1598918700, 0)
+	conversions, atLatestRate := coin.ConversionsAtTime(
+		coin.NewAmountFromInt64(1e8), btcCoin, false, updater, false, false, &timestamp)
+	require.Equal(t, map[string]string{"USD": "2.00"}, conversions)
+	require.False(t, atLatestRate)
+
+	// No historical rate available, the latest rate is used.
+	timestamp = time.Now()
+	conversions, atLatestRate = coin.ConversionsAtTime(
+		coin.NewAmountFromInt64(1e8), btcCoin, false, updater, false, false, &timestamp)
+	require.Equal(t, map[string]string{"USD": "21.00"}, conversions)
+	require.True(t, atLatestRate)
+}
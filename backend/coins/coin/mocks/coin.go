@@ -20,9 +20,18 @@ var _ coin.Coin = &CoinMock{}
 //
 // 		// make and configure a mocked coin.Coin
 // 		mockedCoin := &CoinMock{
+// 			ActiveFiatsFunc: func() []string {
+// 				panic("mock out the ActiveFiats method")
+// 			},
 // 			BlockExplorerTransactionURLPrefixFunc: func() string {
 // 				panic("mock out the BlockExplorerTransactionURLPrefix method")
 // 			},
+// 			BlockExplorerURLFunc: func(kind coin.BlockExplorerURLKind, id string) (string, error) {
+// 				panic("mock out the BlockExplorerURL method")
+// 			},
+// 			BlockExplorerURLPrefixFunc: func(kind coin.BlockExplorerURLKind) string {
+// 				panic("mock out the BlockExplorerURLPrefix method")
+// 			},
 // 			CloseFunc: func() error {
 // 				panic("mock out the Close method")
 // 			},
@@ -38,7 +47,7 @@ var _ coin.Coin = &CoinMock{}
 // 			GetFormatUnitFunc: func(isFee bool) string {
 // 				panic("mock out the GetFormatUnit method")
 // 			},
-// 			InitializeFunc: func()  {
+// 			InitializeFunc: func() error {
 // 				panic("mock out the Initialize method")
 // 			},
 // 			NameFunc: func() string {
@@ -50,9 +59,15 @@ var _ coin.Coin = &CoinMock{}
 // 			ParseAmountFunc: func(amount string) (coin.Amount, error) {
 // 				panic("mock out the ParseAmount method")
 // 			},
+// 			SetActiveFiatsFunc: func(fiats []string)  {
+// 				panic("mock out the SetActiveFiats method")
+// 			},
 // 			SetAmountFunc: func(amount *big.Rat, isFee bool) coin.Amount {
 // 				panic("mock out the SetAmount method")
 // 			},
+// 			SetBlockExplorerFunc: func(explorer coin.BlockExplorer)  {
+// 				panic("mock out the SetBlockExplorer method")
+// 			},
 // 			SmallestUnitFunc: func() string {
 // 				panic("mock out the SmallestUnit method")
 // 			},
@@ -62,6 +77,9 @@ var _ coin.Coin = &CoinMock{}
 // 			UnitFunc: func(isFee bool) string {
 // 				panic("mock out the Unit method")
 // 			},
+// 			ValidateAddressFunc: func(address string) (string, error) {
+// 				panic("mock out the ValidateAddress method")
+// 			},
 // 		}
 //
 // 		// use mockedCoin in code that requires coin.Coin
@@ -69,9 +87,18 @@ var _ coin.Coin = &CoinMock{}
 //
 // 	}
 type CoinMock struct {
+	// ActiveFiatsFunc mocks the ActiveFiats method.
+	ActiveFiatsFunc func() []string
+
 	// BlockExplorerTransactionURLPrefixFunc mocks the BlockExplorerTransactionURLPrefix method.
 	BlockExplorerTransactionURLPrefixFunc func() string
 
+	// BlockExplorerURLFunc mocks the BlockExplorerURL method.
+	BlockExplorerURLFunc func(kind coin.BlockExplorerURLKind, id string) (string, error)
+
+	// BlockExplorerURLPrefixFunc mocks the BlockExplorerURLPrefix method.
+	BlockExplorerURLPrefixFunc func(kind coin.BlockExplorerURLKind) string
+
 	// CloseFunc mocks the Close method.
 	CloseFunc func() error
 
@@ -88,7 +115,7 @@ type CoinMock struct {
 	GetFormatUnitFunc func(isFee bool) string
 
 	// InitializeFunc mocks the Initialize method.
-	InitializeFunc func()
+	InitializeFunc func() error
 
 	// NameFunc mocks the Name method.
 	NameFunc func() string
@@ -99,9 +126,15 @@ type CoinMock struct {
 	// ParseAmountFunc mocks the ParseAmount method.
 	ParseAmountFunc func(amount string) (coin.Amount, error)
 
+	// SetActiveFiatsFunc mocks the SetActiveFiats method.
+	SetActiveFiatsFunc func(fiats []string)
+
 	// SetAmountFunc mocks the SetAmount method.
 	SetAmountFunc func(amount *big.Rat, isFee bool) coin.Amount
 
+	// SetBlockExplorerFunc mocks the SetBlockExplorer method.
+	SetBlockExplorerFunc func(explorer coin.BlockExplorer)
+
 	// SmallestUnitFunc mocks the SmallestUnit method.
 	SmallestUnitFunc func() string
 
@@ -111,11 +144,29 @@ type CoinMock struct {
 	// UnitFunc mocks the Unit method.
 	UnitFunc func(isFee bool) string
 
+	// ValidateAddressFunc mocks the ValidateAddress method.
+	ValidateAddressFunc func(address string) (string, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// ActiveFiats holds details about calls to the ActiveFiats method.
+		ActiveFiats []struct {
+		}
 		// BlockExplorerTransactionURLPrefix holds details about calls to the BlockExplorerTransactionURLPrefix method.
 		BlockExplorerTransactionURLPrefix []struct {
 		}
+		// BlockExplorerURL holds details about calls to the BlockExplorerURL method.
+		BlockExplorerURL []struct {
+			// Kind is the kind argument value.
+			Kind coin.BlockExplorerURLKind
+			// Id is the id argument value.
+			Id string
+		}
+		// BlockExplorerURLPrefix holds details about calls to the BlockExplorerURLPrefix method.
+		BlockExplorerURLPrefix []struct {
+			// Kind is the kind argument value.
+			Kind coin.BlockExplorerURLKind
+		}
 		// Close holds details about calls to the Close method.
 		Close []struct {
 		}
@@ -155,6 +206,11 @@ type CoinMock struct {
 			// Amount is the amount argument value.
 			Amount string
 		}
+		// SetActiveFiats holds details about calls to the SetActiveFiats method.
+		SetActiveFiats []struct {
+			// Fiats is the fiats argument value.
+			Fiats []string
+		}
 		// SetAmount holds details about calls to the SetAmount method.
 		SetAmount []struct {
 			// Amount is the amount argument value.
@@ -162,6 +218,11 @@ type CoinMock struct {
 			// IsFee is the isFee argument value.
 			IsFee bool
 		}
+		// SetBlockExplorer holds details about calls to the SetBlockExplorer method.
+		SetBlockExplorer []struct {
+			// Explorer is the explorer argument value.
+			Explorer coin.BlockExplorer
+		}
 		// SmallestUnit holds details about calls to the SmallestUnit method.
 		SmallestUnit []struct {
 		}
@@ -177,8 +238,16 @@ type CoinMock struct {
 			// IsFee is the isFee argument value.
 			IsFee bool
 		}
+		// ValidateAddress holds details about calls to the ValidateAddress method.
+		ValidateAddress []struct {
+			// Address is the address argument value.
+			Address string
+		}
 	}
+	lockActiveFiats                       sync.RWMutex
 	lockBlockExplorerTransactionURLPrefix sync.RWMutex
+	lockBlockExplorerURL                  sync.RWMutex
+	lockBlockExplorerURLPrefix            sync.RWMutex
 	lockClose                             sync.RWMutex
 	lockCode                              sync.RWMutex
 	lockDecimals                          sync.RWMutex
@@ -188,10 +257,39 @@ type CoinMock struct {
 	lockName                              sync.RWMutex
 	lockObserve                           sync.RWMutex
 	lockParseAmount                       sync.RWMutex
+	lockSetActiveFiats                    sync.RWMutex
 	lockSetAmount                         sync.RWMutex
+	lockSetBlockExplorer                  sync.RWMutex
 	lockSmallestUnit                      sync.RWMutex
 	lockToUnit                            sync.RWMutex
 	lockUnit                              sync.RWMutex
+	lockValidateAddress                   sync.RWMutex
+}
+
+// ActiveFiats calls ActiveFiatsFunc.
+func (mock *CoinMock) ActiveFiats() []string {
+	if mock.ActiveFiatsFunc == nil {
+		panic("CoinMock.ActiveFiatsFunc: method is nil but Coin.ActiveFiats was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockActiveFiats.Lock()
+	mock.calls.ActiveFiats = append(mock.calls.ActiveFiats, callInfo)
+	mock.lockActiveFiats.Unlock()
+	return mock.ActiveFiatsFunc()
+}
+
+// ActiveFiatsCalls gets all the calls that were made to ActiveFiats.
+// Check the length with:
+//     len(mockedCoin.ActiveFiatsCalls())
+func (mock *CoinMock) ActiveFiatsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockActiveFiats.RLock()
+	calls = mock.calls.ActiveFiats
+	mock.lockActiveFiats.RUnlock()
+	return calls
 }
 
 // BlockExplorerTransactionURLPrefix calls BlockExplorerTransactionURLPrefixFunc.
@@ -220,6 +318,72 @@ func (mock *CoinMock) BlockExplorerTransactionURLPrefixCalls() []struct {
 	return calls
 }
 
+// BlockExplorerURL calls BlockExplorerURLFunc.
+func (mock *CoinMock) BlockExplorerURL(kind coin.BlockExplorerURLKind, id string) (string, error) {
+	if mock.BlockExplorerURLFunc == nil {
+		panic("CoinMock.BlockExplorerURLFunc: method is nil but Coin.BlockExplorerURL was just called")
+	}
+	callInfo := struct {
+		Kind coin.BlockExplorerURLKind
+		Id string
+	}{
+		Kind: kind,
+		Id: id,
+	}
+	mock.lockBlockExplorerURL.Lock()
+	mock.calls.BlockExplorerURL = append(mock.calls.BlockExplorerURL, callInfo)
+	mock.lockBlockExplorerURL.Unlock()
+	return mock.BlockExplorerURLFunc(kind, id)
+}
+
+// BlockExplorerURLCalls gets all the calls that were made to BlockExplorerURL.
+// Check the length with:
+//     len(mockedCoin.BlockExplorerURLCalls())
+func (mock *CoinMock) BlockExplorerURLCalls() []struct {
+	Kind coin.BlockExplorerURLKind
+	Id string
+} {
+	var calls []struct {
+		Kind coin.BlockExplorerURLKind
+		Id string
+	}
+	mock.lockBlockExplorerURL.RLock()
+	calls = mock.calls.BlockExplorerURL
+	mock.lockBlockExplorerURL.RUnlock()
+	return calls
+}
+
+// BlockExplorerURLPrefix calls BlockExplorerURLPrefixFunc.
+func (mock *CoinMock) BlockExplorerURLPrefix(kind coin.BlockExplorerURLKind) string {
+	if mock.BlockExplorerURLPrefixFunc == nil {
+		panic("CoinMock.BlockExplorerURLPrefixFunc: method is nil but Coin.BlockExplorerURLPrefix was just called")
+	}
+	callInfo := struct {
+		Kind coin.BlockExplorerURLKind
+	}{
+		Kind: kind,
+	}
+	mock.lockBlockExplorerURLPrefix.Lock()
+	mock.calls.BlockExplorerURLPrefix = append(mock.calls.BlockExplorerURLPrefix, callInfo)
+	mock.lockBlockExplorerURLPrefix.Unlock()
+	return mock.BlockExplorerURLPrefixFunc(kind)
+}
+
+// BlockExplorerURLPrefixCalls gets all the calls that were made to BlockExplorerURLPrefix.
+// Check the length with:
+//     len(mockedCoin.BlockExplorerURLPrefixCalls())
+func (mock *CoinMock) BlockExplorerURLPrefixCalls() []struct {
+	Kind coin.BlockExplorerURLKind
+} {
+	var calls []struct {
+		Kind coin.BlockExplorerURLKind
+	}
+	mock.lockBlockExplorerURLPrefix.RLock()
+	calls = mock.calls.BlockExplorerURLPrefix
+	mock.lockBlockExplorerURLPrefix.RUnlock()
+	return calls
+}
+
 // Close calls CloseFunc.
 func (mock *CoinMock) Close() error {
 	if mock.CloseFunc == nil {
@@ -370,7 +534,7 @@ func (mock *CoinMock) GetFormatUnitCalls() []struct {
 }
 
 // Initialize calls InitializeFunc.
-func (mock *CoinMock) Initialize() {
+func (mock *CoinMock) Initialize() error {
 	if mock.InitializeFunc == nil {
 		panic("CoinMock.InitializeFunc: method is nil but Coin.Initialize was just called")
 	}
@@ -379,7 +543,7 @@ func (mock *CoinMock) Initialize() {
 	mock.lockInitialize.Lock()
 	mock.calls.Initialize = append(mock.calls.Initialize, callInfo)
 	mock.lockInitialize.Unlock()
-	mock.InitializeFunc()
+	return mock.InitializeFunc()
 }
 
 // InitializeCalls gets all the calls that were made to Initialize.
@@ -483,6 +647,37 @@ func (mock *CoinMock) ParseAmountCalls() []struct {
 	return calls
 }
 
+// SetActiveFiats calls SetActiveFiatsFunc.
+func (mock *CoinMock) SetActiveFiats(fiats []string) {
+	if mock.SetActiveFiatsFunc == nil {
+		panic("CoinMock.SetActiveFiatsFunc: method is nil but Coin.SetActiveFiats was just called")
+	}
+	callInfo := struct {
+		Fiats []string
+	}{
+		Fiats: fiats,
+	}
+	mock.lockSetActiveFiats.Lock()
+	mock.calls.SetActiveFiats = append(mock.calls.SetActiveFiats, callInfo)
+	mock.lockSetActiveFiats.Unlock()
+	mock.SetActiveFiatsFunc(fiats)
+}
+
+// SetActiveFiatsCalls gets all the calls that were made to SetActiveFiats.
+// Check the length with:
+//     len(mockedCoin.SetActiveFiatsCalls())
+func (mock *CoinMock) SetActiveFiatsCalls() []struct {
+	Fiats []string
+} {
+	var calls []struct {
+		Fiats []string
+	}
+	mock.lockSetActiveFiats.RLock()
+	calls = mock.calls.SetActiveFiats
+	mock.lockSetActiveFiats.RUnlock()
+	return calls
+}
+
 // SetAmount calls SetAmountFunc.
 func (mock *CoinMock) SetAmount(amount *big.Rat, isFee bool) coin.Amount {
 	if mock.SetAmountFunc == nil {
@@ -518,6 +713,37 @@ func (mock *CoinMock) SetAmountCalls() []struct {
 	return calls
 }
 
+// SetBlockExplorer calls SetBlockExplorerFunc.
+func (mock *CoinMock) SetBlockExplorer(explorer coin.BlockExplorer) {
+	if mock.SetBlockExplorerFunc == nil {
+		panic("CoinMock.SetBlockExplorerFunc: method is nil but Coin.SetBlockExplorer was just called")
+	}
+	callInfo := struct {
+		Explorer coin.BlockExplorer
+	}{
+		Explorer: explorer,
+	}
+	mock.lockSetBlockExplorer.Lock()
+	mock.calls.SetBlockExplorer = append(mock.calls.SetBlockExplorer, callInfo)
+	mock.lockSetBlockExplorer.Unlock()
+	mock.SetBlockExplorerFunc(explorer)
+}
+
+// SetBlockExplorerCalls gets all the calls that were made to SetBlockExplorer.
+// Check the length with:
+//     len(mockedCoin.SetBlockExplorerCalls())
+func (mock *CoinMock) SetBlockExplorerCalls() []struct {
+	Explorer coin.BlockExplorer
+} {
+	var calls []struct {
+		Explorer coin.BlockExplorer
+	}
+	mock.lockSetBlockExplorer.RLock()
+	calls = mock.calls.SetBlockExplorer
+	mock.lockSetBlockExplorer.RUnlock()
+	return calls
+}
+
 // SmallestUnit calls SmallestUnitFunc.
 func (mock *CoinMock) SmallestUnit() string {
 	if mock.SmallestUnitFunc == nil {
@@ -609,3 +835,34 @@ func (mock *CoinMock) UnitCalls() []struct {
 	mock.lockUnit.RUnlock()
 	return calls
 }
+
+// ValidateAddress calls ValidateAddressFunc.
+func (mock *CoinMock) ValidateAddress(address string) (string, error) {
+	if mock.ValidateAddressFunc == nil {
+		panic("CoinMock.ValidateAddressFunc: method is nil but Coin.ValidateAddress was just called")
+	}
+	callInfo := struct {
+		Address string
+	}{
+		Address: address,
+	}
+	mock.lockValidateAddress.Lock()
+	mock.calls.ValidateAddress = append(mock.calls.ValidateAddress, callInfo)
+	mock.lockValidateAddress.Unlock()
+	return mock.ValidateAddressFunc(address)
+}
+
+// ValidateAddressCalls gets all the calls that were made to ValidateAddress.
+// Check the length with:
+//     len(mockedCoin.ValidateAddressCalls())
+func (mock *CoinMock) ValidateAddressCalls() []struct {
+	Address string
+} {
+	var calls []struct {
+		Address string
+	}
+	mock.lockValidateAddress.RLock()
+	calls = mock.calls.ValidateAddress
+	mock.lockValidateAddress.RUnlock()
+	return calls
+}
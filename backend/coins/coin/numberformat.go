@@ -0,0 +1,161 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// NumberFormat contains the separators used to format numbers.
+type NumberFormat struct {
+	// Group is the thousands separator, e.g. "," in "1,234.56".
+	Group string
+	// Decimal is the decimal separator, e.g. "." in "1,234.56".
+	Decimal string
+}
+
+var (
+	numberFormatEnglish = NumberFormat{Group: ",", Decimal: "."}
+	numberFormatDot     = NumberFormat{Group: ".", Decimal: ","}
+	numberFormatSpace   = NumberFormat{Group: " ", Decimal: ","}
+	numberFormatSwiss   = NumberFormat{Group: "’", Decimal: "."}
+	// numberFormatBtc is the locale independent format of Bitcoin amounts, see FormatBtcAmount.
+	numberFormatBtc = NumberFormat{Group: "'", Decimal: "."}
+)
+
+// numberFormatsByLanguage maps languages to their number format. Languages not listed use the
+// English format.
+var numberFormatsByLanguage = map[string]NumberFormat{
+	"de": numberFormatDot,
+	"es": numberFormatDot,
+	"id": numberFormatDot,
+	"it": numberFormatDot,
+	"nl": numberFormatDot,
+	"pt": numberFormatDot,
+	"tr": numberFormatDot,
+	"da": numberFormatDot,
+	"el": numberFormatDot,
+	"bg": numberFormatSpace,
+	"cs": numberFormatSpace,
+	"fi": numberFormatSpace,
+	"fr": numberFormatSpace,
+	"hu": numberFormatSpace,
+	"nb": numberFormatSpace,
+	"pl": numberFormatSpace,
+	"ru": numberFormatSpace,
+	"sk": numberFormatSpace,
+	"sv": numberFormatSpace,
+	"uk": numberFormatSpace,
+}
+
+// numberFormatsByLocale maps locales whose number format differs from their language's.
+var numberFormatsByLocale = map[string]NumberFormat{
+	"de-ch": numberFormatSwiss,
+	"de-li": numberFormatSwiss,
+	"it-ch": numberFormatSwiss,
+	"pt-pt": numberFormatSpace,
+}
+
+// NumberFormatForLocale returns the number format of a locale like "de_DE", "de-CH", "en_US.UTF-8"
+// or "fr". Unknown and empty locales use the English format, e.g. "1,234.56".
+func NumberFormatForLocale(locale string) NumberFormat {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if format, ok := numberFormatsByLocale[locale]; ok {
+		return format
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if format, ok := numberFormatsByLanguage[language]; ok {
+		return format
+	}
+	return numberFormatEnglish
+}
+
+var (
+	numberFormatMu sync.RWMutex
+	// numberFormat is used by FormatAsCurrency. Until a locale is set, fiat amounts are formatted
+	// locale independently, e.g. "1'234.56".
+	numberFormat = numberFormatBtc
+)
+
+// SetLocale sets the locale used by FormatAsCurrency to format fiat amounts. An empty locale
+// restores the locale independent format.
+func SetLocale(locale string) {
+	numberFormatMu.Lock()
+	defer numberFormatMu.Unlock()
+	if locale == "" {
+		numberFormat = numberFormatBtc
+		return
+	}
+	numberFormat = NumberFormatForLocale(locale)
+}
+
+func currentNumberFormat() NumberFormat {
+	numberFormatMu.RLock()
+	defer numberFormatMu.RUnlock()
+	return numberFormat
+}
+
+// roundHalfEven returns amount*10^decimals rounded to the nearest integer, rounding ties to the
+// even integer.
+func roundHalfEven(amount *big.Rat, decimals int) *big.Int {
+	scaled := new(big.Rat).Mul(amount, new(big.Rat).SetInt(
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// Compare 2*|remainder| with the denominator to find out if the fraction is above, below or
+	// exactly at one half.
+	cmp := new(big.Int).Abs(new(big.Int).Lsh(remainder, 1)).Cmp(scaled.Denom())
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		if scaled.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+// Format formats the amount with the given number of decimals, rounding half to even.
+func (format NumberFormat) Format(amount *big.Rat, decimals int) string {
+	rounded := roundHalfEven(amount, decimals)
+	sign := ""
+	if rounded.Sign() < 0 {
+		sign = "-"
+		rounded.Neg(rounded)
+	}
+	digits := rounded.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	integer, fraction := digits[:len(digits)-decimals], digits[len(digits)-decimals:]
+	var result strings.Builder
+	result.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			result.WriteString(format.Group)
+		}
+		result.WriteRune(digit)
+	}
+	if decimals > 0 {
+		result.WriteString(format.Decimal)
+		result.WriteString(fraction)
+	}
+	return result.String()
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coin_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func rat(t *testing.T, s string) *big.Rat {
+	t.Helper()
+	r, ok := new(big.Rat).SetString(s)
+	require.True(t, ok)
+	return r
+}
+
+func TestNumberFormatForLocale(t *testing.T) {
+	require.Equal(t, coin.NumberFormat{Group: ",", Decimal: "."}, coin.NumberFormatForLocale("en_US"))
+	require.Equal(t, coin.NumberFormat{Group: ".", Decimal: ","}, coin.NumberFormatForLocale("de_DE"))
+	require.Equal(t, coin.NumberFormat{Group: ".", Decimal: ","}, coin.NumberFormatForLocale("de-AT.UTF-8"))
+	require.Equal(t, coin.NumberFormat{Group: "’", Decimal: "."}, coin.NumberFormatForLocale("de-CH"))
+	require.Equal(t, coin.NumberFormat{Group: " ", Decimal: ","}, coin.NumberFormatForLocale("fr"))
+	require.Equal(t, coin.NumberFormat{Group: ",", Decimal: "."}, coin.NumberFormatForLocale(""))
+	require.Equal(t, coin.NumberFormat{Group: ",", Decimal: "."}, coin.NumberFormatForLocale("xx_YY"))
+}
+
+func TestFormatAsCurrencyInLocale(t *testing.T) {
+	deDE := coin.NumberFormatForLocale("de_DE")
+	enUS := coin.NumberFormatForLocale("en_US")
+
+	require.Equal(t, "1.234,56", coin.FormatAsCurrencyInLocale(rat(t, "1234.56"), "EUR", deDE))
+	require.Equal(t, "1,234.56", coin.FormatAsCurrencyInLocale(rat(t, "1234.56"), "USD", enUS))
+	require.Equal(t, "1,234,567.00", coin.FormatAsCurrencyInLocale(rat(t, "1234567"), "USD", enUS))
+	require.Equal(t, "0.00", coin.FormatAsCurrencyInLocale(rat(t, "0"), "USD", enUS))
+	require.Equal(t, "0.05", coin.FormatAsCurrencyInLocale(rat(t, "0.05"), "USD", enUS))
+
+	// Negative amounts.
+	require.Equal(t, "-1,234.56", coin.FormatAsCurrencyInLocale(rat(t, "-1234.56"), "USD", enUS))
+	require.Equal(t, "-123.00", coin.FormatAsCurrencyInLocale(rat(t, "-123"), "USD", enUS))
+	require.Equal(t, "-0.01", coin.FormatAsCurrencyInLocale(rat(t, "-0.01"), "USD", enUS))
+
+	// Rounding half to even.
+	require.Equal(t, "0.12", coin.FormatAsCurrencyInLocale(rat(t, "0.125"), "USD", enUS))
+	require.Equal(t, "0.14", coin.FormatAsCurrencyInLocale(rat(t, "0.135"), "USD", enUS))
+	require.Equal(t, "0.13", coin.FormatAsCurrencyInLocale(rat(t, "0.1251"), "USD", enUS))
+	require.Equal(t, "-0.12", coin.FormatAsCurrencyInLocale(rat(t, "-0.125"), "USD", enUS))
+	require.Equal(t, "-0.14", coin.FormatAsCurrencyInLocale(rat(t, "-0.135"), "USD", enUS))
+
+	// BTC and sat amounts are not localized.
+	require.Equal(t, "1'234.56789012", coin.FormatAsCurrencyInLocale(rat(t, "1234.56789012"), "BTC", deDE))
+	require.Equal(t, "1'234'568", coin.FormatAsCurrencyInLocale(rat(t, "1234567.89"), "sat", deDE))
+}
+
+func TestSetLocale(t *testing.T) {
+	defer coin.SetLocale("")
+	amount := rat(t, "1234.56")
+	require.Equal(t, "1'234.56", coin.FormatAsCurrency(amount, "EUR"))
+	coin.SetLocale("de_DE")
+	require.Equal(t, "1.234,56", coin.FormatAsCurrency(amount, "EUR"))
+	coin.SetLocale("en_US")
+	require.Equal(t, "1,234.56", coin.FormatAsCurrency(amount, "EUR"))
+	coin.SetLocale("")
+	require.Equal(t, "1'234.56", coin.FormatAsCurrency(amount, "EUR"))
+}
@@ -114,7 +114,7 @@ func NewAccount(
 	log *logrus.Entry,
 ) *Account {
 	log = log.WithField("group", "eth").
-		WithFields(logrus.Fields{"coin": accountCoin.String(), "code": config.Config.Code, "name": config.Config.Name})
+		WithFields(logrus.Fields{"coin": accountCoin.String(), "code": config.Config.Code, "name": accounts.NameLogField(config.Config)})
 	log.Debug("Creating new account")
 
 	account := &Account{
@@ -213,7 +213,9 @@ func (account *Account) Initialize() error {
 		account.signingConfiguration.ExtendedPublicKey(),
 	)
 
-	account.coin.Initialize()
+	if err := account.coin.Initialize(); err != nil {
+		return err
+	}
 	done := account.Synchronizer.IncRequestsCounter()
 	go account.poll(done)
 
@@ -498,6 +500,9 @@ type TxProposal struct {
 }
 
 func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error) {
+	if len(args.Recipients) != 0 {
+		return nil, errp.WithStack(errors.ErrMultipleRecipientsNotSupported)
+	}
 	if !IsValidEthAddress(args.RecipientAddress) {
 		return nil, errp.WithStack(errors.ErrInvalidAddress)
 	}
@@ -677,6 +682,12 @@ func (account *Account) SendTx() error {
 	if txProposal == nil {
 		return errp.New("No active tx proposal")
 	}
+	if err := account.BaseAccount.CheckRatesPin(); err != nil {
+		// The proposal has to be made again with the latest rates.
+		defer account.updateLock.Lock()()
+		account.activeTxProposal = nil
+		return err
+	}
 
 	keystore, err := account.Config().ConnectKeystore()
 	if err != nil {
@@ -697,11 +708,14 @@ func (account *Account) SendTx() error {
 		return err
 	}
 
+	txID := txProposal.Tx.Hash().Hex()
 	note := account.BaseAccount.GetAndClearProposedTxNote()
-	if err := account.SetTxNote(txProposal.Tx.Hash().Hex(), note); err != nil {
+	if err := account.SetTxNote(txID, note); err != nil {
 		// Not critical.
 		account.log.WithError(err).Error("Failed to save transaction note when sending a tx")
 	}
+	amount, fee, total := txProposalAmounts(txProposal)
+	account.BaseAccount.UnpinRatesSnapshot(txID, amount, fee, total)
 	account.enqueueUpdateCh <- struct{}{}
 	return nil
 }
@@ -776,14 +790,22 @@ func (account *Account) TxProposal(
 		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
 	}
 	account.activeTxProposal = txProposal
+	account.BaseAccount.PinRatesSnapshot()
+
+	amount, fee, total := txProposalAmounts(txProposal)
+	return amount, fee, total, nil
+}
 
+// txProposalAmounts returns the amount, fee and total of a tx proposal. The fee of ERC20 token
+// transactions is paid in ETH, so it is not part of their total.
+func txProposalAmounts(txProposal *TxProposal) (coin.Amount, coin.Amount, coin.Amount) {
 	var total *big.Int
-	if account.coin.erc20Token != nil {
+	if txProposal.Coin.erc20Token != nil {
 		total = txProposal.Value
 	} else {
 		total = new(big.Int).Add(txProposal.Value, txProposal.Fee)
 	}
-	return coin.NewAmount(txProposal.Value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total), nil
+	return coin.NewAmount(txProposal.Value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total)
 }
 
 // GetUnusedReceiveAddresses implements accounts.Interface.
@@ -16,10 +16,13 @@
 package eth
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
@@ -27,6 +30,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
@@ -44,14 +48,20 @@ type TransactionsSource interface {
 // Coin models an Ethereum coin.
 type Coin struct {
 	observable.Implementation
-	client                rpcclient.Interface
-	code                  coin.Code
-	name                  string
-	unit                  string
-	feeUnit               string
-	net                   *params.ChainConfig
-	blockExplorerTxPrefix string
-	erc20Token            *erc20.Token
+	client  rpcclient.Interface
+	code    coin.Code
+	name    string
+	unit    string
+	feeUnit string
+	net     *params.ChainConfig
+	// blockExplorerMu guards blockExplorer, which can be changed by the user at any time.
+	blockExplorerMu sync.RWMutex
+	blockExplorer   coinpkg.BlockExplorer
+	erc20Token      *erc20.Token
+	// activeFiatsMu guards activeFiats, which can be changed by the user at any time.
+	activeFiatsMu sync.RWMutex
+	// activeFiats are the fiat currencies selected by the user, e.g. 'USD' and 'EUR'.
+	activeFiats []string
 
 	transactionsSource TransactionsSource
 
@@ -70,18 +80,18 @@ func NewCoin(
 	unit string,
 	feeUnit string,
 	net *params.ChainConfig,
-	blockExplorerTxPrefix string,
+	blockExplorer coinpkg.BlockExplorer,
 	transactionsSource TransactionsSource,
 	erc20Token *erc20.Token,
 ) *Coin {
 	return &Coin{
-		client:                client,
-		code:                  code,
-		name:                  name,
-		unit:                  unit,
-		feeUnit:               feeUnit,
-		net:                   net,
-		blockExplorerTxPrefix: blockExplorerTxPrefix,
+		client:        client,
+		code:          code,
+		name:          name,
+		unit:          unit,
+		feeUnit:       feeUnit,
+		net:           net,
+		blockExplorer: blockExplorer,
 
 		transactionsSource: transactionsSource,
 
@@ -109,7 +119,7 @@ func (coin *Coin) Net() *params.ChainConfig { return coin.net }
 func (coin *Coin) ChainID() uint64 { return coin.net.ChainID.Uint64() }
 
 // Initialize implements coin.Coin.
-func (coin *Coin) Initialize() {}
+func (coin *Coin) Initialize() error { return nil }
 
 // Name implements coin.Coin.
 func (coin *Coin) Name() string {
@@ -134,6 +144,20 @@ func (coin *Coin) GetFormatUnit(isFee bool) string {
 	return coin.Unit(isFee)
 }
 
+// ActiveFiats implements coin.Coin.
+func (coin *Coin) ActiveFiats() []string {
+	coin.activeFiatsMu.RLock()
+	defer coin.activeFiatsMu.RUnlock()
+	return coin.activeFiats
+}
+
+// SetActiveFiats implements coin.Coin.
+func (coin *Coin) SetActiveFiats(fiats []string) {
+	coin.activeFiatsMu.Lock()
+	defer coin.activeFiatsMu.Unlock()
+	coin.activeFiats = append([]string(nil), fiats...)
+}
+
 // Decimals implements coin.Coin.
 func (coin *Coin) Decimals(isFee bool) uint {
 	if !isFee && coin.erc20Token != nil {
@@ -184,7 +208,35 @@ func (coin *Coin) ParseAmount(amount string) (coinpkg.Amount, error) {
 
 // BlockExplorerTransactionURLPrefix implements coin.Coin.
 func (coin *Coin) BlockExplorerTransactionURLPrefix() string {
-	return coin.blockExplorerTxPrefix
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.TxURLPrefix()
+}
+
+// BlockExplorerURL implements coin.Coin.
+func (coin *Coin) BlockExplorerURL(kind coinpkg.BlockExplorerURLKind, id string) (string, error) {
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.BuildURL(kind, id)
+}
+
+// BlockExplorerURLPrefix implements coin.Coin.
+func (coin *Coin) BlockExplorerURLPrefix(kind coinpkg.BlockExplorerURLKind) string {
+	coin.blockExplorerMu.RLock()
+	defer coin.blockExplorerMu.RUnlock()
+	return coin.blockExplorer.URLPrefix(kind)
+}
+
+// SetBlockExplorer implements coin.Coin.
+func (coin *Coin) SetBlockExplorer(explorer coinpkg.BlockExplorer) {
+	coin.blockExplorerMu.Lock()
+	coin.blockExplorer = explorer
+	coin.blockExplorerMu.Unlock()
+	coin.Notify(observable.Event{
+		Subject: fmt.Sprintf("coins/%s/block-explorer", coin.code),
+		Action:  action.Replace,
+		Object:  explorer,
+	})
 }
 
 // TransactionsSource returns an instance of TransactionsSource.
@@ -201,6 +253,19 @@ func (coin *Coin) SmallestUnit() string {
 	return "wei"
 }
 
+// ValidateAddress implements coin.Coin. Ethereum addresses have no type, the returned type is
+// always "address".
+func (coin *Coin) ValidateAddress(address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", errp.WithStack(errors.ErrInvalidAddress)
+	}
+	// Validate checksum if the address is mixed case, see https://github.com/ethereum/EIPs/blob/master/EIPS/eip-55.md
+	if isMixedCase(address) && address != common.HexToAddress(address).Hex() {
+		return "", errp.WithStack(errors.ErrAddressInvalidChecksum)
+	}
+	return "address", nil
+}
+
 // ERC20Token returns nil for a normal Ethereum coin, or the erc20 token details for an erc20 token.
 func (coin *Coin) ERC20Token() *erc20.Token {
 	return coin.erc20Token
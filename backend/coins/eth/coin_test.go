@@ -18,8 +18,10 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/stretchr/testify/suite"
 )
@@ -38,7 +40,7 @@ func (s *testSuite) SetupTest() {
 		"ETH",
 		"ETH",
 		params.MainnetChainConfig,
-		"",
+		coin.BlockExplorer{},
 		nil,
 		nil,
 	)
@@ -50,7 +52,7 @@ func (s *testSuite) SetupTest() {
 		"TOK",
 		"ETH",
 		params.MainnetChainConfig,
-		"",
+		coin.BlockExplorer{},
 		nil,
 		erc20.NewToken("0x0000000000000000000000000000000000000001", 12),
 	)
@@ -143,3 +145,16 @@ func (s *testSuite) TestUnit() {
 	s.Require().Equal("ETH", s.ERC20Coin.Unit(true))
 	s.Require().Equal("TOK", s.ERC20Coin.Unit(false))
 }
+
+func (s *testSuite) TestValidateAddress() {
+	addressType, err := s.coin.ValidateAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	s.Require().NoError(err)
+	s.Require().Equal("address", addressType)
+	_, err = s.coin.ValidateAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	s.Require().NoError(err)
+
+	_, err = s.coin.ValidateAddress("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	s.Require().Equal(errors.ErrAddressInvalidChecksum, errp.Cause(err))
+	_, err = s.coin.ValidateAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA")
+	s.Require().Equal(errors.ErrInvalidAddress, errp.Cause(err))
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"strings"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+)
+
+const (
+	// blockExplorerTxIDPlaceholder is replaced by the transaction ID in
+	// CoinMetadataExplorer.TxURLTemplate.
+	blockExplorerTxIDPlaceholder = "{txid}"
+	// blockExplorerAddressPlaceholder is replaced by the address in
+	// CoinMetadataExplorer.AddressURLTemplate.
+	blockExplorerAddressPlaceholder = "{address}"
+)
+
+// CoinMetadataExplorer contains the URL templates of the block explorer selected for a coin.
+type CoinMetadataExplorer struct {
+	Name string `json:"name"`
+	// TxURLTemplate is the URL of a transaction, with "{txid}" in place of the transaction ID.
+	TxURLTemplate string `json:"txURLTemplate"`
+	// AddressURLTemplate is the URL of an address, with "{address}" in place of the address.
+	AddressURLTemplate string `json:"addressURLTemplate"`
+}
+
+// CoinMetadata describes a coin, so that the frontend can render it without hard-coding it.
+type CoinMetadata struct {
+	Code coinpkg.Code `json:"code"`
+	Name string       `json:"name"`
+	Unit string       `json:"unit"`
+	// Decimals is the number of decimal places of the unit.
+	Decimals uint `json:"decimals"`
+	Testnet  bool `json:"testnet"`
+	// ScriptTypes are the script types of new accounts of Bitcoin-based coins. Empty for other coins.
+	ScriptTypes []signing.ScriptType `json:"scriptTypes"`
+	Explorer    CoinMetadataExplorer `json:"explorer"`
+	// Icon is a stable identifier of the icon of the coin, e.g. "btc". Testnets use the icon of their
+	// mainnet.
+	Icon string `json:"icon"`
+	// ParentCode is the code of the coin a token lives on, e.g. "eth" for ERC20 tokens. Empty for
+	// coins which are not tokens.
+	ParentCode coinpkg.Code `json:"parentCode,omitempty"`
+}
+
+// coinIcon returns the icon identifier of a coin.
+func coinIcon(code coinpkg.Code) string {
+	switch code {
+	case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC:
+		return "btc"
+	case coinpkg.CodeLTC, coinpkg.CodeTLTC:
+		return "ltc"
+	case coinpkg.CodeETH, coinpkg.CodeGOETH, coinpkg.CodeSEPETH:
+		return "eth"
+	}
+	// ERC20 tokens, e.g. "eth-erc20-usdt" -> "usdt".
+	return strings.TrimPrefix(string(code), "eth-erc20-")
+}
+
+// coinMetadata returns the metadata of the coin with the given code.
+func (backend *Backend) coinMetadata(code coinpkg.Code, parentCode coinpkg.Code) (*CoinMetadata, error) {
+	coin, err := backend.Coin(code)
+	if err != nil {
+		return nil, err
+	}
+	_, testnet := coinpkg.TestnetCoins[code]
+	scriptTypes := []signing.ScriptType{}
+	for _, cfg := range btcScriptTypesWithKeypaths(code, 0) {
+		scriptTypes = append(scriptTypes, cfg.scriptType)
+	}
+	var explorer CoinMetadataExplorer
+	if blockExplorer := backend.BlockExplorer(code); blockExplorer.URL != "" {
+		explorer = CoinMetadataExplorer{
+			Name:               blockExplorer.Name,
+			TxURLTemplate:      blockExplorer.URL + blockExplorer.TxPath + blockExplorerTxIDPlaceholder,
+			AddressURLTemplate: blockExplorer.URL + blockExplorer.AddressPath + blockExplorerAddressPlaceholder,
+		}
+	}
+	return &CoinMetadata{
+		Code:        code,
+		Name:        coin.Name(),
+		Unit:        coin.Unit(false),
+		Decimals:    coin.Decimals(false),
+		Testnet:     testnet,
+		ScriptTypes: scriptTypes,
+		Explorer:    explorer,
+		Icon:        coinIcon(code),
+		ParentCode:  parentCode,
+	}, nil
+}
+
+// CoinsMetadata returns the metadata of all coins of the network the backend runs on, followed by
+// the supported ERC20 tokens if Ethereum mainnet is one of them.
+func (backend *Backend) CoinsMetadata() ([]*CoinMetadata, error) {
+	result := []*CoinMetadata{}
+	for _, code := range backend.networkCoinCodes() {
+		metadata, err := backend.coinMetadata(code, "")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, metadata)
+		if code != coinpkg.CodeETH {
+			continue
+		}
+		for _, token := range erc20Tokens {
+			metadata, err := backend.coinMetadata(token.code, coinpkg.CodeETH)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, metadata)
+		}
+	}
+	return result, nil
+}
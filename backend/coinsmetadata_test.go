@@ -0,0 +1,110 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoinsMetadata(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	metadata, err := b.CoinsMetadata()
+	require.NoError(t, err)
+	require.Len(t, metadata, 3+len(erc20Tokens))
+	byCode := map[coinpkg.Code]*CoinMetadata{}
+	for _, entry := range metadata {
+		byCode[entry.Code] = entry
+	}
+
+	jsonOf := func(code coinpkg.Code) string {
+		entry, ok := byCode[code]
+		require.True(t, ok, code)
+		jsonBytes, err := json.Marshal(entry)
+		require.NoError(t, err)
+		return string(jsonBytes)
+	}
+
+	require.JSONEq(t, `{
+		"code": "btc",
+		"name": "Bitcoin",
+		"unit": "BTC",
+		"decimals": 8,
+		"testnet": false,
+		"scriptTypes": ["p2wpkh", "p2tr", "p2wpkh-p2sh", "p2pkh"],
+		"explorer": {
+			"name": "blockstream.info",
+			"txURLTemplate": "https://blockstream.info/tx/{txid}",
+			"addressURLTemplate": "https://blockstream.info/address/{address}"
+		},
+		"icon": "btc"
+	}`, jsonOf(coinpkg.CodeBTC))
+	require.JSONEq(t, `{
+		"code": "eth",
+		"name": "Ethereum",
+		"unit": "ETH",
+		"decimals": 18,
+		"testnet": false,
+		"scriptTypes": [],
+		"explorer": {
+			"name": "etherscan.io",
+			"txURLTemplate": "https://etherscan.io/tx/{txid}",
+			"addressURLTemplate": "https://etherscan.io/address/{address}"
+		},
+		"icon": "eth"
+	}`, jsonOf(coinpkg.CodeETH))
+	// Tokens are listed from the token registry, and use the block explorer of their parent coin.
+	require.JSONEq(t, `{
+		"code": "eth-erc20-usdc",
+		"name": "USD Coin",
+		"unit": "USDC",
+		"decimals": 6,
+		"testnet": false,
+		"scriptTypes": [],
+		"explorer": {
+			"name": "etherscan.io",
+			"txURLTemplate": "https://etherscan.io/tx/{txid}",
+			"addressURLTemplate": "https://etherscan.io/address/{address}"
+		},
+		"icon": "usdc",
+		"parentCode": "eth"
+	}`, jsonOf("eth-erc20-usdc"))
+	for _, token := range erc20Tokens {
+		require.Equal(t, coinpkg.CodeETH, byCode[token.code].ParentCode)
+	}
+}
+
+func TestCoinsMetadataTestnet(t *testing.T) {
+	b := newBackend(t, testnetEnabled, regtestDisabled)
+	defer b.Close()
+
+	metadata, err := b.CoinsMetadata()
+	require.NoError(t, err)
+	codes := []coinpkg.Code{}
+	for _, entry := range metadata {
+		require.True(t, entry.Testnet)
+		require.Empty(t, entry.ParentCode)
+		codes = append(codes, entry.Code)
+	}
+	require.Equal(t, []coinpkg.Code{
+		coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeTLTC,
+		coinpkg.CodeGOETH, coinpkg.CodeSEPETH,
+	}, codes)
+}
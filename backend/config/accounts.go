@@ -57,6 +57,66 @@ type Account struct {
 	// only applies to ETH, and the elements are ERC20 token codes (e.g. "eth-erc20-usdt",
 	// "eth-erc20-bat", etc).
 	ActiveTokens []string `json:"activeTokens,omitempty"`
+	// ShowUsedAddresses is true if receive addresses which already have a transaction history are
+	// listed when receiving. By default, only unused addresses are shown, as reusing addresses hurts
+	// privacy.
+	ShowUsedAddresses bool `json:"showUsedAddresses,omitempty"`
+	// MinConfirmations is the number of confirmations an output needs to be spent, to reduce the
+	// risk of spending outputs which are removed by a reorg. 0 means that confirmed outputs and our
+	// own unconfirmed outputs, e.g. change, can be spent.
+	MinConfirmations int `json:"minConfirmations,omitempty"`
+	// SpendUnconfirmedChange is true if outputs of our own transactions, e.g. change, can be spent
+	// before they reach MinConfirmations. Only applies if MinConfirmations is set.
+	SpendUnconfirmedChange bool `json:"spendUnconfirmedChange,omitempty"`
+	// RequireVerifiedTxs is true if confirmed transactions paying to the account are only counted
+	// as available and spent once they were verified against the block headers (SPV), so that a
+	// lying server can't fake a confirmed payment. Until then, their outputs are incoming.
+	RequireVerifiedTxs bool `json:"requireVerifiedTxs,omitempty"`
+	// DustThreshold is the value in satoshi below which received outputs are hidden, e.g. spam of
+	// a dusting attack: they are not counted in the balance, not spent and receive transactions
+	// paying only such outputs are not listed. They are still stored. 0 hides nothing.
+	DustThreshold int64 `json:"dustThreshold,omitempty"`
+	// ShowOwnDust is true if outputs of our own transactions, e.g. small self-transfers, are not
+	// hidden even if they are below DustThreshold.
+	ShowOwnDust bool `json:"showOwnDust,omitempty"`
+	// PreferredScriptType is the script type of the receive addresses which are handed out first in
+	// a unified account, e.g. signing.ScriptTypeP2TR. If empty or not one of the script types of
+	// the account, the order of the signing configurations applies.
+	PreferredScriptType signing.ScriptType `json:"preferredScriptType,omitempty"`
+	// ReceiveGapLimit is the receive gap limit extended by the user to hand out more receive
+	// addresses, see btc.Account.ExtendReceiveGapLimit(). It is kept here as the account database is
+	// only a cache, which can be deleted. 0 if it was never extended.
+	ReceiveGapLimit uint16 `json:"receiveGapLimit,omitempty"`
+	// WatchOnly is true if the account was imported from an extended public key only. Unlike
+	// accounts with Watch set, there is no keystore which can sign for it, so nothing can ever be
+	// sent from it.
+	WatchOnly bool `json:"watchOnly,omitempty"`
+	// Rotation is set while the funds of this account are moved to a new account. It is persisted
+	// so that an interrupted rotation can be resumed.
+	Rotation *AccountRotation `json:"rotation,omitempty"`
+}
+
+// AccountRotationStage is the stage of an account rotation.
+type AccountRotationStage string
+
+const (
+	// AccountRotationStageCreated means the new account was created, but the consolidation
+	// transaction was not sent yet.
+	AccountRotationStageCreated AccountRotationStage = "created"
+	// AccountRotationStageSending means the consolidation transaction is being signed and
+	// broadcast. If the app was interrupted in this stage, it is unknown if the transaction was
+	// broadcast.
+	AccountRotationStageSending AccountRotationStage = "sending"
+	// AccountRotationStageBroadcast means the consolidation transaction was broadcast, but the
+	// account was not archived yet.
+	AccountRotationStageBroadcast AccountRotationStage = "broadcast"
+)
+
+// AccountRotation holds the state of moving all funds of an account to the next account of the
+// same coin, after which the account is archived.
+type AccountRotation struct {
+	NewAccountCode accountsTypes.Code   `json:"newAccountCode"`
+	Stage          AccountRotationStage `json:"stage"`
 }
 
 // SetTokenActive activates/deactivates an token on an account. `tokenCode` must be an ERC20 token
@@ -96,6 +156,45 @@ type Keystore struct {
 	// this field yet but it may be helpful in the future if we want to remind users to connect
 	// their device, e.g. to check that they still know their device password.
 	LastConnected time.Time `json:"lastConnected"`
+	// Birthday is the wallet birthday, which can be set when restoring a wallet. Nil if unknown.
+	Birthday *WalletBirthday `json:"birthday,omitempty"`
+	// AccountsDiscovery is the progress of the accounts discovery per coin, so that an interrupted
+	// discovery is resumed instead of restarted.
+	AccountsDiscovery map[coin.Code]*AccountsDiscoveryProgress `json:"accountsDiscovery,omitempty"`
+}
+
+// AccountsDiscoveryProgress is the persisted progress of the accounts discovery of one coin.
+type AccountsDiscoveryProgress struct {
+	// LastCheckedAccountNumber is the highest account number whose transaction history was fully
+	// checked. -1 if no account was checked yet.
+	LastCheckedAccountNumber int `json:"lastCheckedAccountNumber"`
+	// Canceled is true if the user canceled the discovery. No more accounts are added for scanning
+	// until the discovery is retried.
+	Canceled bool `json:"canceled"`
+}
+
+// WalletBirthday is the earliest date or block height at which a wallet can have transactions. It
+// is only an optimization hint to speed up the headers sync. Transactions
+// before the birthday are never hidden if they are found, and the birthday is discarded in that
+// case.
+type WalletBirthday struct {
+	// Date is the birthday as a date. Zero if the birthday is a block height.
+	Date time.Time `json:"date"`
+	// Height is the birthday as a block height of the Bitcoin blockchain. 0 if the birthday is a
+	// date.
+	Height int `json:"height"`
+}
+
+// IsBefore returns true if a transaction confirmed at the given height and time happened before
+// the birthday. Unconfirmed transactions are never before the birthday.
+func (birthday *WalletBirthday) IsBefore(height int, timestamp *time.Time) bool {
+	if height <= 0 {
+		return false
+	}
+	if birthday.Height > 0 && height < birthday.Height {
+		return true
+	}
+	return !birthday.Date.IsZero() && timestamp != nil && timestamp.Before(birthday.Date)
 }
 
 // AccountsConfig persists the list of accounts added to the app.
@@ -16,6 +16,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -93,6 +94,24 @@ func TestSetTokenActive(t *testing.T) {
 	require.Equal(t, []string{"TOKEN-2"}, acct.ActiveTokens)
 }
 
+func TestWalletBirthdayIsBefore(t *testing.T) {
+	byHeight := &WalletBirthday{Height: 800000}
+	require.True(t, byHeight.IsBefore(799999, nil))
+	require.False(t, byHeight.IsBefore(800000, nil))
+	// Unconfirmed.
+	require.False(t, byHeight.IsBefore(0, nil))
+	require.False(t, byHeight.IsBefore(-1, nil))
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	byDate := &WalletBirthday{Date: date}
+	before := date.Add(-time.Hour)
+	after := date.Add(time.Hour)
+	require.True(t, byDate.IsBefore(10, &before))
+	require.False(t, byDate.IsBefore(10, &after))
+	// Timestamp not known yet.
+	require.False(t, byDate.IsBefore(10, nil))
+}
+
 func TestGetOrAddKeystore(t *testing.T) {
 	cfg := &AccountsConfig{}
 	fp1 := []byte("aaaa")
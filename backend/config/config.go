@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
@@ -25,14 +26,44 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 )
 
+// ServerType is the protocol spoken by a backend server. See the list of consts below.
+type ServerType string
+
+const (
+	// ServerTypeElectrum is an Electrum protocol server. This is the default if no type is set.
+	ServerTypeElectrum ServerType = "electrum"
+	// ServerTypeEsplora is an Esplora/Electrs HTTP REST server.
+	ServerTypeEsplora ServerType = "esplora"
+)
+
 // ServerInfo holds information about the backend server(s).
 type ServerInfo struct {
 	Server  string `json:"server"`
 	TLS     bool   `json:"tls"`
 	PEMCert string `json:"pemCert"`
+	// CertFingerprint optionally pins the TLS certificate of the server. It is the hex encoded
+	// SHA-256 hash of the DER encoded certificate. Colons between the bytes are allowed.
+	CertFingerprint string `json:"certFingerprint,omitempty"`
+	// Type is the server protocol. If empty, it is derived from the server address: http(s) URLs
+	// are Esplora servers, everything else is an Electrum server.
+	Type ServerType `json:"type,omitempty"`
+}
+
+// ServerType returns the protocol spoken by the server.
+func (s *ServerInfo) ServerType() ServerType {
+	if s.Type != "" {
+		return s.Type
+	}
+	if strings.HasPrefix(s.Server, "http://") || strings.HasPrefix(s.Server, "https://") {
+		return ServerTypeEsplora
+	}
+	return ServerTypeElectrum
 }
 
 func (s *ServerInfo) String() string {
+	if s.ServerType() == ServerTypeEsplora {
+		return s.Server
+	}
 	if s.TLS {
 		return s.Server + ":s"
 	}
@@ -44,6 +75,22 @@ type btcCoinConfig struct {
 	ElectrumServers []*ServerInfo `json:"electrumServers"`
 }
 
+// TxFetchThrottle configures the rate limit of transaction downloads from the blockchain servers,
+// which protects against being banned by public servers when syncing wallets with many
+// transactions. The rate adapts between MinRate and MaxRate depending on the health of the server.
+// Zero values are replaced by the defaults.
+type TxFetchThrottle struct {
+	// InitialRate is the rate in transactions per second at which the downloads start.
+	InitialRate float64 `json:"initialRate"`
+	// MinRate and MaxRate bound the adapted rate, in transactions per second.
+	MinRate float64 `json:"minRate"`
+	MaxRate float64 `json:"maxRate"`
+	// Burst is the number of transactions which can be downloaded at once after being idle.
+	Burst int `json:"burst"`
+	// SlowResponseMillis is the response time in milliseconds above which the rate is lowered.
+	SlowResponseMillis int `json:"slowResponseMillis"`
+}
+
 // ETHTransactionsSource  where to get Ethereum transactions from. See the list of consts
 // below.
 type ETHTransactionsSource string
@@ -76,12 +123,19 @@ type Backend struct {
 
 	Authentication bool `json:"authentication"`
 
-	BTC  btcCoinConfig `json:"btc"`
-	TBTC btcCoinConfig `json:"tbtc"`
-	RBTC btcCoinConfig `json:"rbtc"`
-	LTC  btcCoinConfig `json:"ltc"`
-	TLTC btcCoinConfig `json:"tltc"`
-	ETH  ethCoinConfig `json:"eth"`
+	// ViewOnly disables sending from all accounts, e.g. for app instances on less-trusted machines
+	// which are only used for monitoring. It can only be changed via Backend.SetViewOnly(), which
+	// requires the connected device to confirm disabling it.
+	ViewOnly bool `json:"viewOnly"`
+
+	BTC   btcCoinConfig `json:"btc"`
+	TBTC  btcCoinConfig `json:"tbtc"`
+	TBTC4 btcCoinConfig `json:"tbtc4"`
+	SBTC  btcCoinConfig `json:"sbtc"`
+	RBTC  btcCoinConfig `json:"rbtc"`
+	LTC   btcCoinConfig `json:"ltc"`
+	TLTC  btcCoinConfig `json:"tltc"`
+	ETH   ethCoinConfig `json:"eth"`
 
 	// Removed in v4.35 - don't reuse these two keys.
 	TETH struct{} `json:"teth"`
@@ -102,6 +156,28 @@ type Backend struct {
 
 	// BtcUnit is the unit used to represent Bitcoin amounts. See `coin.BtcUnit` for details.
 	BtcUnit coin.BtcUnit `json:"btcUnit"`
+
+	// BlockExplorers contains the base URL of the block explorer selected by the user, by coin
+	// code. Coins without an entry use their default block explorer.
+	BlockExplorers map[coin.Code]string `json:"blockExplorers"`
+
+	// TxFetchThrottle limits the rate of transaction downloads of the bitcoin-based coins.
+	TxFetchThrottle TxFetchThrottle `json:"txFetchThrottle"`
+
+	// HeadersPruneDepth, if not 0, prunes the stored block headers of the bitcoin-based coins more
+	// than this number of blocks below the tip, to save storage. See
+	// headers.Headers.SetPruneDepth().
+	HeadersPruneDepth int `json:"headersPruneDepth"`
+
+	// BlockchainRequestTimeoutSeconds, if not 0, is the timeout in seconds of a single request to the
+	// Electrum servers of the bitcoin-based coins. A request that times out fails with
+	// blockchain.ErrRequestTimeout.
+	BlockchainRequestTimeoutSeconds int `json:"blockchainRequestTimeoutSeconds"`
+
+	// ElectrumMaxMessageSize, if not 0, is the maximum size in bytes of a single message received
+	// from an Electrum server. Servers sending larger messages are disconnected. See
+	// electrum.SetMaxMessageSize().
+	ElectrumMaxMessageSize int `json:"electrumMaxMessageSize"`
 }
 
 // DeprecatedCoinActive returns the Active setting for a coin by code.  This call is should not be
@@ -109,7 +185,7 @@ type Backend struct {
 // kept in the accounts config.
 func (backend Backend) DeprecatedCoinActive(code coin.Code) bool {
 	switch code {
-	case coin.CodeBTC, coin.CodeTBTC, coin.CodeRBTC:
+	case coin.CodeBTC, coin.CodeTBTC, coin.CodeTBTC4, coin.CodeSBTC, coin.CodeRBTC:
 		return backend.DeprecatedBitcoinActive
 	case coin.CodeLTC, coin.CodeTLTC:
 		return backend.DeprecatedLitecoinActive
@@ -142,6 +218,44 @@ hbWFoWMI
 -----END CERTIFICATE-----
 `
 
+// O=Internet Security Research Group, CN=ISRG Root X1
+// Serial: 8210cfb0d240e3594463e0bb63828b00.
+// Root of the publicly trusted certificates of the mempool.space electrum servers, used for the
+// Bitcoin testnet4 and signet, which are not served by the Shift Crypto servers.
+const isrgRootX1 = `
+-----BEGIN CERTIFICATE-----
+MIIFazCCA1OgAwIBAgIRAIIQz7DSQONZRGPgu2OCiwAwDQYJKoZIhvcNAQELBQAw
+TzELMAkGA1UEBhMCVVMxKTAnBgNVBAoTIEludGVybmV0IFNlY3VyaXR5IFJlc2Vh
+cmNoIEdyb3VwMRUwEwYDVQQDEwxJU1JHIFJvb3QgWDEwHhcNMTUwNjA0MTEwNDM4
+WhcNMzUwNjA0MTEwNDM4WjBPMQswCQYDVQQGEwJVUzEpMCcGA1UEChMgSW50ZXJu
+ZXQgU2VjdXJpdHkgUmVzZWFyY2ggR3JvdXAxFTATBgNVBAMTDElTUkcgUm9vdCBY
+MTCCAiIwDQYJKoZIhvcNAQEBBQADggIPADCCAgoCggIBAK3oJHP0FDfzm54rVygc
+h77ct984kIxuPOZXoHj3dcKi/vVqbvYATyjb3miGbESTtrFj/RQSa78f0uoxmyF+
+0TM8ukj13Xnfs7j/EvEhmkvBioZxaUpmZmyPfjxwv60pIgbz5MDmgK7iS4+3mX6U
+A5/TR5d8mUgjU+g4rk8Kb4Mu0UlXjIB0ttov0DiNewNwIRt18jA8+o+u3dpjq+sW
+T8KOEUt+zwvo/7V3LvSye0rgTBIlDHCNAymg4VMk7BPZ7hm/ELNKjD+Jo2FR3qyH
+B5T0Y3HsLuJvW5iB4YlcNHlsdu87kGJ55tukmi8mxdAQ4Q7e2RCOFvu396j3x+UC
+B5iPNgiV5+I3lg02dZ77DnKxHZu8A/lJBdiB3QW0KtZB6awBdpUKD9jf1b0SHzUv
+KBds0pjBqAlkd25HN7rOrFleaJ1/ctaJxQZBKT5ZPt0m9STJEadao0xAH0ahmbWn
+OlFuhjuefXKnEgV4We0+UXgVCwOPjdAvBbI+e0ocS3MFEvzG6uBQE3xDk3SzynTn
+jh8BCNAw1FtxNrQHusEwMFxIt4I7mKZ9YIqioymCzLq9gwQbooMDQaHWBfEbwrbw
+qHyGO0aoSCqI3Haadr8faqU9GY/rOPNk3sgrDQoo//fb4hVC1CLQJ13hef4Y53CI
+rU7m2Ys6xt0nUW7/vGT1M0NPAgMBAAGjQjBAMA4GA1UdDwEB/wQEAwIBBjAPBgNV
+HRMBAf8EBTADAQH/MB0GA1UdDgQWBBR5tFnme7bl5AFzgAiIyBpY9umbbjANBgkq
+hkiG9w0BAQsFAAOCAgEAVR9YqbyyqFDQDLHYGmkgJykIrGF1XIpu+ILlaS/V9lZL
+ubhzEFnTIZd+50xx+7LSYK05qAvqFyFWhfFQDlnrzuBZ6brJFe+GnY+EgPbk6ZGQ
+3BebYhtF8GaV0nxvwuo77x/Py9auJ/GpsMiu/X1+mvoiBOv/2X/qkSsisRcOj/KK
+NFtY2PwByVS5uCbMiogziUwthDyC3+6WVwW6LLv3xLfHTjuCvjHIInNzktHCgKQ5
+ORAzI4JMPJ+GslWYHb4phowim57iaztXOoJwTdwJx4nLCgdNbOhdjsnvzqvHu7Ur
+TkXWStAmzOVyyghqpZXjFaH3pO3JLF+l+/+sKAIuvtd7u+Nxe5AW0wdeRlN8NwdC
+jNPElpzVmbUq4JUagEiuTDkHzsxHpFKVK7q4+63SM1N95R1NbdWhscdCb+ZAJzVc
+oyi3B43njTOQ5yOf+1CceWxG1bQVs5ZufpsMljq4Ui0/1lvh+wjChP4kqKOJ2qxq
+4RgqsahDYVvTH9w7jXbyLeiNdd8XM2w9U/t7y0Ff/9yi0GE44Za4rF2LN9d11TPA
+mRGunUHBcnWEvgJBQl9nJEiU0Zsnvgc/ubhPgXRR4Xq37Z0j4r7g1SgEEzwxA57d
+emyPxgcYxn/eR44/KJ4EBs+lVDR3veyJm+kXQ99b21/+jh5Xos1AnX5iItreGCc=
+-----END CERTIFICATE-----
+`
+
 // NewDefaultAppConfig returns the default app config.
 func NewDefaultAppConfig() AppConfig {
 	return AppConfig{
@@ -183,6 +297,24 @@ func NewDefaultAppConfig() AppConfig {
 					},
 				},
 			},
+			TBTC4: btcCoinConfig{
+				ElectrumServers: []*ServerInfo{
+					{
+						Server:  "mempool.space:40002",
+						TLS:     true,
+						PEMCert: isrgRootX1,
+					},
+				},
+			},
+			SBTC: btcCoinConfig{
+				ElectrumServers: []*ServerInfo{
+					{
+						Server:  "mempool.space:60602",
+						TLS:     true,
+						PEMCert: isrgRootX1,
+					},
+				},
+			},
 			RBTC: btcCoinConfig{
 				ElectrumServers: []*ServerInfo{
 					{
@@ -125,3 +125,12 @@ func TestMigrationsAtLoad(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, cfg2, cfg3)
 }
+
+func TestServerInfoServerType(t *testing.T) {
+	require.Equal(t, ServerTypeElectrum, (&ServerInfo{Server: "btc1.shiftcrypto.io:443"}).ServerType())
+	require.Equal(t, ServerTypeEsplora, (&ServerInfo{Server: "https://blockstream.info/api"}).ServerType())
+	require.Equal(t, ServerTypeEsplora, (&ServerInfo{Server: "http://127.0.0.1:3002"}).ServerType())
+	require.Equal(t, ServerTypeEsplora, (&ServerInfo{Server: "10.0.0.1:3002", Type: ServerTypeEsplora}).ServerType())
+	require.Equal(t, "https://blockstream.info/api", (&ServerInfo{Server: "https://blockstream.info/api"}).String())
+	require.Equal(t, "btc1.shiftcrypto.io:443:s", (&ServerInfo{Server: "btc1.shiftcrypto.io:443", TLS: true}).String())
+}
@@ -166,7 +166,7 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		if isSegwit {
 			var err error
 			signatureHash, err = txscript.CalcWitnessSigHash(subScript, btcProposedTx.SigHashes,
-				txscript.SigHashAll, transaction, index, spentOutput.Value)
+				btcProposedTx.InputSigHashType(index, address), transaction, index, spentOutput.Value)
 			if err != nil {
 				return errp.Wrap(err, "Failed to calculate SegWit signature hash")
 			}
@@ -174,7 +174,7 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		} else {
 			var err error
 			signatureHash, err = txscript.CalcSignatureHash(
-				subScript, txscript.SigHashAll, transaction, index)
+				subScript, btcProposedTx.InputSigHashType(index, address), transaction, index)
 			if err != nil {
 				return errp.Wrap(err, "Failed to calculate legacy signature hash")
 			}
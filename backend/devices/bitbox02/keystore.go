@@ -22,6 +22,7 @@ import (
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/netparams"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -107,7 +108,7 @@ func (keystore *keystore) SupportsAccount(coin coinpkg.Coin, meta interface{}) b
 		if scriptType == signing.ScriptTypeP2TR {
 			// Taproot available since v9.10.0.
 			switch coin.Code() {
-			case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+			case coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC, coinpkg.CodeRBTC:
 				return keystore.device.Version().AtLeast(semver.NewSemVer(9, 10, 0))
 			default:
 				return false
@@ -221,7 +222,7 @@ func (keystore *keystore) VerifyExtendedPublicKey(
 			if !ok {
 				msgXPubType = messages.BTCPubRequest_XPUB
 			}
-		case chaincfg.TestNet3Params.Net, ltc.TestNet4Params.Net:
+		case chaincfg.TestNet3Params.Net, netparams.TestNet4, netparams.SigNetParams.Net, ltc.TestNet4Params.Net:
 			msgXPubType = messages.BTCPubRequest_TPUB
 		default:
 			msgXPubType = messages.BTCPubRequest_XPUB
@@ -291,6 +292,10 @@ func (keystore *keystore) ExtendedPublicKey(
 }
 
 func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransaction) error {
+	// The BitBox02 signs with SIGHASH_ALL, or SIGHASH_DEFAULT for taproot inputs.
+	if !btcProposedTx.UsesDefaultSigHashTypes() {
+		return errp.New("Sighash types other than SIGHASH_ALL are not supported by the BitBox02")
+	}
 	tx := btcProposedTx.TXProposal.Transaction
 
 	// scriptConfigs represent the script configurations of a specific account and include the
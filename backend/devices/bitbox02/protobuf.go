@@ -24,10 +24,12 @@ import (
 // conversions from types used by the wallet to types defined in the protobuf messages.
 
 var btcMsgCoinMap = map[coin.Code]messages.BTCCoin{
-	coin.CodeBTC:  messages.BTCCoin_BTC,
-	coin.CodeTBTC: messages.BTCCoin_TBTC,
-	coin.CodeLTC:  messages.BTCCoin_LTC,
-	coin.CodeTLTC: messages.BTCCoin_TLTC,
+	coin.CodeBTC:   messages.BTCCoin_BTC,
+	coin.CodeTBTC:  messages.BTCCoin_TBTC,
+	coin.CodeTBTC4: messages.BTCCoin_TBTC,
+	coin.CodeSBTC:  messages.BTCCoin_TBTC,
+	coin.CodeLTC:   messages.BTCCoin_LTC,
+	coin.CodeTLTC:  messages.BTCCoin_TLTC,
 }
 
 var btcMsgScriptTypeMap = map[signing.ScriptType]messages.BTCScriptConfig_SimpleType{
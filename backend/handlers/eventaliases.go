@@ -0,0 +1,148 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+)
+
+// emitEventSubjectAliases controls whether events are also emitted under the deprecated aliases of
+// their subject. Set it to false to check that the frontend works with the new subjects only.
+const emitEventSubjectAliases = true
+
+// eventSubjectAlias declares a deprecated subject under which the events of a renamed subject are
+// still emitted during the transition period.
+type eventSubjectAlias struct {
+	// subject is the new subject. If it ends with "/", it matches all subjects with this prefix,
+	// and the rest of the subject is appended to the alias.
+	subject string
+	// alias is the deprecated subject.
+	alias string
+}
+
+// eventSubjectAliases lists the renamed event subjects. An entry can be removed once no frontend
+// reports consuming the alias anymore, see postEventAliasConsumed.
+var eventSubjectAliases = []eventSubjectAlias{}
+
+// aliasEvent is an event emitted under a deprecated subject alias.
+type aliasEvent struct {
+	observable.Event
+	// ReplacedBy is the new subject of the event.
+	ReplacedBy string `json:"replacedBy"`
+}
+
+// eventAliases keeps track of the deprecated aliases which were consumed by a frontend.
+type eventAliases struct {
+	consumed map[string]struct{}
+	lock     locker.Locker
+}
+
+// lookupEventSubjectAlias returns the deprecated alias of the given subject, or false if the
+// subject has none.
+func lookupEventSubjectAlias(aliases []eventSubjectAlias, subject string) (string, bool) {
+	for _, entry := range aliases {
+		if strings.HasSuffix(entry.subject, "/") {
+			if strings.HasPrefix(subject, entry.subject) {
+				return entry.alias + strings.TrimPrefix(subject, entry.subject), true
+			}
+			continue
+		}
+		if subject == entry.subject {
+			return entry.alias, true
+		}
+	}
+	return "", false
+}
+
+// relayEvent sends the event to the frontend, and a copy under the deprecated alias of its subject
+// if there is one.
+func (handlers *Handlers) relayEvent(event observable.Event) {
+	handlers.backendEvents.broadcast(event)
+	if !emitEventSubjectAliases {
+		return
+	}
+	if alias, ok := lookupEventSubjectAlias(eventSubjectAliases, event.Subject); ok {
+		aliased := event
+		aliased.Subject = alias
+		handlers.backendEvents.broadcast(aliasEvent{Event: aliased, ReplacedBy: event.Subject})
+	}
+}
+
+// postEventAliasConsumed is called by the frontend the first time it handles an event emitted
+// under a deprecated alias. In dev mode, the aliases consumed so far are logged, so we know which
+// aliases are still needed.
+func (handlers *Handlers) postEventAliasConsumed(r *http.Request) interface{} {
+	var jsonBody struct {
+		Subject    string `json:"subject"`
+		ReplacedBy string `json:"replacedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		handlers.log.WithError(err).Error("postEventAliasConsumed")
+		return nil
+	}
+	unlock := handlers.eventAliases.lock.Lock()
+	handlers.eventAliases.consumed[jsonBody.Subject] = struct{}{}
+	consumed := make([]string, 0, len(handlers.eventAliases.consumed))
+	for subject := range handlers.eventAliases.consumed {
+		consumed = append(consumed, subject)
+	}
+	unlock()
+	if handlers.apiData.devMode {
+		sort.Strings(consumed)
+		handlers.log.
+			WithField("replacedBy", jsonBody.ReplacedBy).
+			Warnf("The frontend consumed deprecated event subjects: %s", strings.Join(consumed, ", "))
+	}
+	return nil
+}
+
+// deprecatedEventSubject describes a deprecated event subject alias.
+type deprecatedEventSubject struct {
+	Subject     string `json:"subject"`
+	Deprecated  bool   `json:"deprecated"`
+	Replacement string `json:"replacement"`
+	Emitted     bool   `json:"emitted"`
+	Consumed    bool   `json:"consumed"`
+}
+
+// getDeprecatedEventSubjects lists the deprecated event subject aliases with their replacement.
+func (handlers *Handlers) getDeprecatedEventSubjects(*http.Request) interface{} {
+	defer handlers.eventAliases.lock.RLock()()
+	result := []deprecatedEventSubject{}
+	for _, entry := range eventSubjectAliases {
+		consumed := false
+		for subject := range handlers.eventAliases.consumed {
+			if subject == entry.alias ||
+				(strings.HasSuffix(entry.alias, "/") && strings.HasPrefix(subject, entry.alias)) {
+				consumed = true
+				break
+			}
+		}
+		result = append(result, deprecatedEventSubject{
+			Subject:     entry.alias,
+			Deprecated:  true,
+			Replacement: entry.subject,
+			Emitted:     emitEventSubjectAliases,
+			Consumed:    consumed,
+		})
+	}
+	return result
+}
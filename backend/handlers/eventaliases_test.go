@@ -0,0 +1,40 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupEventSubjectAlias(t *testing.T) {
+	aliases := []eventSubjectAlias{
+		{subject: "rates/historical", alias: "rates/daily"},
+		{subject: "accounts/", alias: "account/"},
+	}
+	alias, ok := lookupEventSubjectAlias(aliases, "rates/historical")
+	require.True(t, ok)
+	require.Equal(t, "rates/daily", alias)
+
+	alias, ok = lookupEventSubjectAlias(aliases, "accounts/v0-btc-0/synced")
+	require.True(t, ok)
+	require.Equal(t, "account/v0-btc-0/synced", alias)
+
+	_, ok = lookupEventSubjectAlias(aliases, "rates/historical/other")
+	require.False(t, ok)
+	_, ok = lookupEventSubjectAlias(aliases, "accounts")
+	require.False(t, ok)
+}
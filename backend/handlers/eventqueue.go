@@ -0,0 +1,218 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/sirupsen/logrus"
+)
+
+// eventQueueSize is the maximum number of events queued for a client which does not consume them
+// fast enough.
+const eventQueueSize = 1000
+
+// resyncEventSubject is the subject of the event which tells the frontend to reload its state, as
+// events were dropped, see Handlers.Events().
+const resyncEventSubject = "backend/resync"
+
+// eventQueue is the bounded queue of events not yet delivered to one client. Pushing to it never
+// blocks. A queued Replace event is superseded by a newer Replace event of the same subject, so
+// only distinct events count towards the size. If the queue still overflows, the client is
+// dropped, as it would miss events otherwise.
+type eventQueue struct {
+	size int
+	// events are the queued events in order. Superseded Replace events are set to nil and skipped
+	// when the events are delivered.
+	events []interface{}
+	// count is the number of events which are not nil.
+	count int
+	// replaceIndex is the index in events of the queued Replace event per subject.
+	replaceIndex map[string]int
+	// signal has a buffer of one and receives a value when events are pushed.
+	signal chan struct{}
+	// dropped is closed when the client is dropped.
+	dropped chan struct{}
+	closed  bool
+	lock    locker.Locker
+}
+
+func newEventQueue(size int) *eventQueue {
+	return &eventQueue{
+		size:         size,
+		replaceIndex: map[string]int{},
+		signal:       make(chan struct{}, 1),
+		dropped:      make(chan struct{}),
+	}
+}
+
+// replaceSubject returns the subject of a Replace event, whose older occurrences can be dropped as
+// they are superseded by the newer one.
+func replaceSubject(event interface{}) (string, bool) {
+	switch event := event.(type) {
+	case observable.Event:
+		return event.Subject, event.Action == action.Replace
+	case aliasEvent:
+		return event.Subject, event.Action == action.Replace
+	}
+	return "", false
+}
+
+// compact removes the superseded events. It is called when they make up at least half of the
+// events, so that pushing stays O(1) amortized.
+func (queue *eventQueue) compact() {
+	kept := make([]interface{}, 0, queue.count)
+	for _, event := range queue.events {
+		if event == nil {
+			continue
+		}
+		if subject, ok := replaceSubject(event); ok {
+			queue.replaceIndex[subject] = len(kept)
+		}
+		kept = append(kept, event)
+	}
+	queue.events = kept
+}
+
+// push adds an event to the queue without blocking. It returns false if the client is or was
+// dropped because the queue overflowed.
+func (queue *eventQueue) push(event interface{}) bool {
+	defer queue.lock.Lock()()
+	if queue.closed {
+		return false
+	}
+	if subject, ok := replaceSubject(event); ok {
+		if index, ok := queue.replaceIndex[subject]; ok {
+			queue.events[index] = nil
+			queue.count--
+		}
+		queue.replaceIndex[subject] = len(queue.events)
+	}
+	queue.events = append(queue.events, event)
+	queue.count++
+	if queue.count > queue.size {
+		queue.closed = true
+		queue.events = nil
+		queue.replaceIndex = nil
+		close(queue.dropped)
+		return false
+	}
+	if len(queue.events) >= 2*queue.size {
+		queue.compact()
+	}
+	select {
+	case queue.signal <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// popAll removes and returns all queued events.
+func (queue *eventQueue) popAll() []interface{} {
+	defer queue.lock.Lock()()
+	if queue.closed {
+		return nil
+	}
+	events := make([]interface{}, 0, queue.count)
+	for _, event := range queue.events {
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	queue.events = nil
+	queue.count = 0
+	queue.replaceIndex = map[string]int{}
+	return events
+}
+
+// forward delivers the queued events to `send` until quit or queue.dropped is closed, or until
+// `send` returns false. It is run by the dedicated writer goroutine of the client. `send` can block
+// without affecting the producers of events.
+func (queue *eventQueue) forward(send func(event interface{}) bool, quit <-chan struct{}) {
+	for {
+		select {
+		case <-quit:
+			return
+		case <-queue.dropped:
+			return
+		case <-queue.signal:
+			for _, event := range queue.popAll() {
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// isDropped returns true if the client was dropped because its queue overflowed.
+func (queue *eventQueue) isDropped() bool {
+	select {
+	case <-queue.dropped:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventBroadcaster delivers every event to all subscribed clients, each through its own queue.
+//
+// Until the first client subscribes, the events are kept in a startup queue, which becomes the
+// queue of the first client, so that the events fired while starting up are not lost.
+type eventBroadcaster struct {
+	queues map[*eventQueue]struct{}
+	// startupQueue holds the events until the first client subscribes. nil afterwards.
+	startupQueue *eventQueue
+	lock         locker.Locker
+	log          *logrus.Entry
+}
+
+func newEventBroadcaster(log *logrus.Entry) *eventBroadcaster {
+	startupQueue := newEventQueue(eventQueueSize)
+	return &eventBroadcaster{
+		queues:       map[*eventQueue]struct{}{startupQueue: {}},
+		startupQueue: startupQueue,
+		log:          log,
+	}
+}
+
+// subscribe registers a new client. The returned function unregisters it again.
+func (broadcaster *eventBroadcaster) subscribe() (*eventQueue, func()) {
+	defer broadcaster.lock.Lock()()
+	queue := broadcaster.startupQueue
+	broadcaster.startupQueue = nil
+	if queue == nil || queue.isDropped() {
+		queue = newEventQueue(eventQueueSize)
+		broadcaster.queues[queue] = struct{}{}
+	}
+	return queue, func() {
+		defer broadcaster.lock.Lock()()
+		delete(broadcaster.queues, queue)
+	}
+}
+
+// broadcast queues the event for all clients. It never blocks on slow clients. Clients whose
+// queue overflows are dropped.
+func (broadcaster *eventBroadcaster) broadcast(event interface{}) {
+	defer broadcaster.lock.Lock()()
+	for queue := range broadcaster.queues {
+		if !queue.push(event) {
+			broadcaster.log.WithField("queueSize", queue.size).
+				Error("Dropping event client: it does not consume the events fast enough")
+			delete(broadcaster.queues, queue)
+		}
+	}
+}
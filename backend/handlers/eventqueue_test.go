@@ -0,0 +1,213 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/stretchr/testify/require"
+)
+
+func replaceEvent(subject string, object interface{}) observable.Event {
+	return observable.Event{Subject: subject, Action: action.Replace, Object: object}
+}
+
+func reloadEvent(subject string) observable.Event {
+	return observable.Event{Subject: subject, Action: action.Reload}
+}
+
+func TestEventQueueCoalesce(t *testing.T) {
+	queue := newEventQueue(3)
+	require.True(t, queue.push(replaceEvent("a", 1)))
+	require.True(t, queue.push(replaceEvent("b", 1)))
+	require.True(t, queue.push(reloadEvent("c")))
+	// Would overflow, but the first event is superseded by this one.
+	require.True(t, queue.push(replaceEvent("a", 2)))
+	require.Equal(t,
+		[]interface{}{replaceEvent("b", 1), reloadEvent("c"), replaceEvent("a", 2)},
+		queue.popAll())
+
+	// Superseded events do not accumulate.
+	for i := 0; i < 100; i++ {
+		require.True(t, queue.push(replaceEvent("a", i)))
+	}
+	require.Less(t, len(queue.events), 2*queue.size)
+	require.Equal(t, []interface{}{replaceEvent("a", 99)}, queue.popAll())
+}
+
+func TestEventQueueOverflow(t *testing.T) {
+	queue := newEventQueue(3)
+	require.True(t, queue.push(reloadEvent("a")))
+	require.True(t, queue.push(reloadEvent("b")))
+	require.True(t, queue.push(replaceEvent("c", 1)))
+	require.False(t, queue.isDropped())
+	// Reload events are not coalesced, so the queue overflows and the client is dropped.
+	require.False(t, queue.push(reloadEvent("d")))
+	require.True(t, queue.isDropped())
+	require.False(t, queue.push(reloadEvent("e")))
+	require.Empty(t, queue.popAll())
+
+	// Forwarding stops once the client is dropped.
+	queue.forward(func(interface{}) bool {
+		require.Fail(t, "no events expected")
+		return true
+	}, nil)
+}
+
+// TestEventBroadcasterStalledClient checks that a client which does not consume events neither
+// blocks the producer nor the other clients, and that it is dropped once its queue overflows.
+func TestEventBroadcasterStalledClient(t *testing.T) {
+	broadcaster := newEventBroadcaster(logging.Get().WithGroup("handlers_test"))
+	// Replaces the startup queue.
+	_, unsubscribeStartup := broadcaster.subscribe()
+	unsubscribeStartup()
+
+	stalled, unsubscribeStalled := broadcaster.subscribe()
+	defer unsubscribeStalled()
+	stalledDone := make(chan struct{})
+	block := make(chan struct{})
+	defer close(block)
+	go func() {
+		defer close(stalledDone)
+		stalled.forward(func(event interface{}) bool {
+			select {
+			case <-block:
+			case <-stalled.dropped:
+				return false
+			}
+			return true
+		}, nil)
+	}()
+
+	healthy, unsubscribeHealthy := broadcaster.subscribe()
+	defer unsubscribeHealthy()
+	var received int64
+	var lastRate atomic.Value
+	quit := make(chan struct{})
+	defer close(quit)
+	go healthy.forward(func(event interface{}) bool {
+		if event := event.(observable.Event); event.Subject == "rates" {
+			lastRate.Store(event.Object)
+			return true
+		}
+		atomic.AddInt64(&received, 1)
+		return true
+	}, quit)
+
+	// Replace events of the same subject are coalesced, so the stalled client is not dropped.
+	for i := 0; i < 10*eventQueueSize; i++ {
+		broadcaster.broadcast(replaceEvent("rates", i))
+	}
+	require.Eventually(t,
+		func() bool { return lastRate.Load() == 10*eventQueueSize-1 },
+		10*time.Second, time.Millisecond)
+	require.False(t, stalled.isDropped())
+
+	// Distinct events overflow the queue of the stalled client, but the healthy client receives
+	// all of them.
+	const batches = 10
+	const batchSize = eventQueueSize / 2
+	for batch := 0; batch < batches; batch++ {
+		for i := 0; i < batchSize; i++ {
+			broadcaster.broadcast(reloadEvent(fmt.Sprintf("account/%d", batch*batchSize+i)))
+		}
+		require.Eventually(t,
+			func() bool { return atomic.LoadInt64(&received) == int64((batch+1)*batchSize) },
+			10*time.Second, time.Millisecond)
+	}
+	<-stalledDone
+	require.True(t, stalled.isDropped())
+	require.Len(t, broadcaster.queues, 1)
+	require.Contains(t, broadcaster.queues, healthy)
+}
+
+func TestEventsResync(t *testing.T) {
+	log := logging.Get().WithGroup("handlers_test")
+	handlers := &Handlers{backendEvents: newEventBroadcaster(log), log: log}
+	quit := make(chan struct{})
+	defer close(quit)
+	events := handlers.Events(quit)
+	for i := 0; i < 2*eventQueueSize+2; i++ {
+		handlers.backendEvents.broadcast(reloadEvent(fmt.Sprintf("account/%d", i)))
+	}
+	// The events were not received, so they are dropped and the frontend is told to resync.
+	require.Equal(t,
+		observable.Event{Subject: resyncEventSubject, Action: action.Replace, Object: true},
+		<-events)
+	// The events broadcast after the client subscribed again are delivered, possibly with another
+	// resync if they overflowed the new queue too, followed by the next event.
+	handlers.backendEvents.broadcast(reloadEvent("new"))
+	for event := range events {
+		if event == reloadEvent("new") {
+			break
+		}
+		subject := event.(observable.Event).Subject
+		require.True(t, subject == resyncEventSubject || strings.HasPrefix(subject, "account/"), subject)
+	}
+}
+
+func TestEventsQuit(t *testing.T) {
+	log := logging.Get().WithGroup("handlers_test")
+	handlers := &Handlers{backendEvents: newEventBroadcaster(log), log: log}
+	numQueues := func() int {
+		defer handlers.backendEvents.lock.Lock()()
+		return len(handlers.backendEvents.queues)
+	}
+
+	// Closing quit stops delivering the events and unsubscribes.
+	quit := make(chan struct{})
+	events := handlers.Events(quit)
+	handlers.backendEvents.broadcast(reloadEvent("a"))
+	require.Equal(t, reloadEvent("a"), <-events)
+	require.Equal(t, 1, numQueues())
+	close(quit)
+	require.Eventually(t, func() bool { return numQueues() == 0 }, 10*time.Second, time.Millisecond)
+
+	// Also while the resync event is not received.
+	quit = make(chan struct{})
+	handlers.Events(quit)
+	for i := 0; i < eventQueueSize+1; i++ {
+		handlers.backendEvents.broadcast(reloadEvent(fmt.Sprintf("account/%d", i)))
+	}
+	// The overflowing queue was dropped and the client subscribed again.
+	require.Eventually(t, func() bool { return numQueues() == 1 }, 10*time.Second, time.Millisecond)
+	close(quit)
+	require.Eventually(t, func() bool { return numQueues() == 0 }, 10*time.Second, time.Millisecond)
+}
+
+func TestEventBroadcasterStartup(t *testing.T) {
+	broadcaster := newEventBroadcaster(logging.Get().WithGroup("handlers_test"))
+	broadcaster.broadcast(reloadEvent("a"))
+	broadcaster.broadcast(reloadEvent("b"))
+
+	// The first client receives the events fired before it subscribed.
+	first, unsubscribeFirst := broadcaster.subscribe()
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := broadcaster.subscribe()
+	defer unsubscribeSecond()
+	require.Equal(t, []interface{}{reloadEvent("a"), reloadEvent("b")}, first.popAll())
+	require.Empty(t, second.popAll())
+
+	broadcaster.broadcast(reloadEvent("c"))
+	require.Equal(t, []interface{}{reloadEvent("c")}, first.popAll())
+	require.Equal(t, []interface{}{reloadEvent("c")}, second.popAll())
+}
@@ -25,13 +25,16 @@ import (
 	"math/big"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsErrors "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/banners"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	accountHandlers "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/handlers"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -48,12 +51,14 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/exchanges"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/jsonp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -84,12 +89,20 @@ type Backend interface {
 	Register(device device.Interface) error
 	Deregister(deviceID string)
 	RatesUpdater() *rates.RateUpdater
+	UpdateActiveFiats()
+	SetActiveFiats(fiats []string) error
+	AvailableBlockExplorers(coinpkg.Code) []coinpkg.BlockExplorer
+	BlockExplorer(coinpkg.Code) coinpkg.BlockExplorer
+	SetBlockExplorer(code coinpkg.Code, explorerURL string) error
+	UpdateLocale()
 	DownloadCert(string) (string, error)
 	CheckElectrumServer(*config.ServerInfo) error
 	RegisterTestKeystore(string)
+	RegisterTestMnemonicKeystore(mnemonic string, passphrase string, forceMainnet bool) error
 	NotifyUser(string)
 	SystemOpen(string) error
 	ReinitializeAccounts()
+	RetryConnections()
 	CheckForUpdateIgnoringErrors() *backend.UpdateFile
 	Banners() *banners.Banners
 	Environment() backend.Environment
@@ -99,8 +112,18 @@ type Backend interface {
 	ChartData() (*backend.Chart, error)
 	SupportedCoins(keystore.Keystore) []coinpkg.Code
 	CanAddAccount(coinpkg.Code, keystore.Keystore) (string, bool)
+	PreviewAccountAddresses(coinCode coinpkg.Code) ([]backend.AddressPreview, error)
+	CoinsMetadata() ([]*backend.CoinMetadata, error)
 	CreateAndPersistAccountConfig(coinCode coinpkg.Code, name string, keystore keystore.Keystore) (accountsTypes.Code, error)
+	ImportWatchOnlyAccount(coinCode coinpkg.Code, name string, extendedPublicKey string, scriptType signing.ScriptType) (accountsTypes.Code, error)
 	SetAccountActive(accountCode accountsTypes.Code, active bool) error
+	SetAccountShowUsedAddresses(accountCode accountsTypes.Code, show bool) error
+	SetAccountMinConfirmations(accountCode accountsTypes.Code, minConfirmations int, spendUnconfirmedChange bool) error
+	SetAccountRequireVerifiedTxs(accountCode accountsTypes.Code, require bool) error
+	SetAccountDustThreshold(accountCode accountsTypes.Code, dustThreshold int64, showOwnDust bool) error
+	SetAccountPreferredScriptType(accountCode accountsTypes.Code, scriptType signing.ScriptType) error
+	ViewOnly() bool
+	SetViewOnly(viewOnly bool) error
 	SetTokenActive(accountCode accountsTypes.Code, tokenCode string, active bool) error
 	RenameAccount(accountCode accountsTypes.Code, name string) error
 	AOPP() backend.AOPP
@@ -115,7 +138,23 @@ type Backend interface {
 	ForceAuth()
 	CancelConnectKeystore()
 	SetWatchonly(rootFingerprint []byte, watchonly bool) error
+	SetWalletBirthday(rootFingerprint []byte, birthday *config.WalletBirthday) error
+	ObserveAccount(code accountsTypes.Code)
+	UnobserveAccount(code accountsTypes.Code)
 	LookupEthAccountCode(address string) (accountsTypes.Code, string, error)
+	SearchTransactions(args backend.SearchArgs) *backend.SearchResult
+	AccountRotation(code accountsTypes.Code) *config.AccountRotation
+	StartAccountRotation(code accountsTypes.Code) (accountsTypes.Code, error)
+	AccountRotationProposal(
+		code accountsTypes.Code,
+		feeTargetCode accounts.FeeTargetCode,
+		customFee string,
+	) (*backend.AccountRotationProposal, error)
+	SendAccountRotation(code accountsTypes.Code) error
+	CancelAccountRotation(code accountsTypes.Code) error
+	AccountsDiscovery() ([]*backend.AccountsDiscovery, error)
+	RetryAccountsDiscovery(coinCode coinpkg.Code) error
+	CancelAccountsDiscovery(coinCode coinpkg.Code) error
 }
 
 // Handlers provides a web api to the backend.
@@ -126,7 +165,8 @@ type Handlers struct {
 	// backend to secure the API call. The data is fed into the static javascript app
 	// that is served, so the client knows where and how to connect to.
 	apiData           *ConnectionData
-	backendEvents     chan interface{}
+	backendEvents     *eventBroadcaster
+	eventAliases      eventAliases
 	websocketUpgrader websocket.Upgrader
 	log               *logrus.Entry
 }
@@ -163,13 +203,14 @@ func NewHandlers(
 		Router:        router,
 		backend:       backend,
 		apiData:       connData,
-		backendEvents: make(chan interface{}, 1000),
+		backendEvents: newEventBroadcaster(log),
+		eventAliases:  eventAliases{consumed: map[string]struct{}{}},
 		websocketUpgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 			CheckOrigin:     func(r *http.Request) bool { return true },
 		},
-		log: logging.Get().WithGroup("handlers"),
+		log: log,
 	}
 
 	getAPIRouter := func(subrouter *mux.Router) func(string, func(*http.Request) (interface{}, error)) *mux.Route {
@@ -212,17 +253,40 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/detect-dark-theme", handlers.getDetectDarkTheme).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/version", handlers.getVersion).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/testing", handlers.getTesting).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/view-only", handlers.getViewOnly).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/set-view-only", handlers.postSetViewOnly).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/events/deprecated-subjects", handlers.getDeprecatedEventSubjects).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/events/alias-consumed", handlers.postEventAliasConsumed).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/account-add", handlers.postAddAccount).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-import-watch-only", handlers.postImportWatchOnlyAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/keystores", handlers.getKeystores).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/accounts", handlers.getAccounts).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/balance", handlers.getAccountsBalance).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/coins-balance", handlers.getCoinsTotalBalance).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts/total-balance", handlers.getAccountsTotalBalance).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/set-account-active", handlers.postSetAccountActive).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-account-show-used-addresses", handlers.postSetAccountShowUsedAddresses).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-account-min-confirmations", handlers.postSetAccountMinConfirmations).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-account-dust-threshold", handlers.postSetAccountDustThreshold).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-account-require-verified-txs", handlers.postSetAccountRequireVerifiedTxs).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-account-preferred-script-type", handlers.postSetAccountPreferredScriptType).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-token-active", handlers.postSetTokenActive).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/rename-account", handlers.postRenameAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/accounts/reinitialize", handlers.postAccountsReinitialize).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/retry-connections", handlers.postRetryConnections).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-observe", handlers.postAccountObserve(true)).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-unobserve", handlers.postAccountObserve(false)).Methods("POST")
 	getAPIRouter(apiRouter)("/account-summary", handlers.getAccountSummary).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/search", handlers.getSearch).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/rates/active-fiats", handlers.postActiveFiats).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-rotation/{code}", handlers.getAccountRotation).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/account-rotation/{code}/start", handlers.postStartAccountRotation).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-rotation/{code}/proposal", handlers.postAccountRotationProposal).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-rotation/{code}/send", handlers.postSendAccountRotation).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-rotation/{code}/cancel", handlers.postCancelAccountRotation).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts-discovery", handlers.getAccountsDiscovery).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/accounts-discovery/{code}/retry", handlers.postAccountsDiscovery(true)).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/accounts-discovery/{code}/cancel", handlers.postAccountsDiscovery(false)).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/supported-coins", handlers.getSupportedCoins).Methods("GET")
 	getAPIRouter(apiRouter)("/test/register", handlers.postRegisterTestKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/test/deregister", handlers.postDeregisterTestKeystore).Methods("POST")
@@ -231,10 +295,19 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/coins/convert-from-fiat", handlers.getConvertFromFiat).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tltc/headers/status", handlers.getHeadersStatus(coinpkg.CodeTLTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tbtc/headers/status", handlers.getHeadersStatus(coinpkg.CodeTBTC)).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/tbtc4/headers/status", handlers.getHeadersStatus(coinpkg.CodeTBTC4)).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/sbtc/headers/status", handlers.getHeadersStatus(coinpkg.CodeSBTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/ltc/headers/status", handlers.getHeadersStatus(coinpkg.CodeLTC)).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/btc/headers/status", handlers.getHeadersStatus(coinpkg.CodeBTC)).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/coins/btc/set-unit", handlers.postBtcFormatUnit).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/coins/btc/parse-external-amount", handlers.getBTCParseExternalAmount).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/metadata", handlers.getCoinsMetadata).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/{code}/address-preview", handlers.getAddressPreview).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/block-explorers", handlers.getBlockExplorers).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/server-info", handlers.getServerInfo).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/{code}/connection/status", handlers.getConnectionStatus).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/{code}/validate-address", handlers.postValidateAddress).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/coins/{code}/block-explorer", handlers.postBlockExplorer).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/certs/download", handlers.postCertsDownload).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/electrum/check", handlers.postElectrumCheck).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/socksproxy/check", handlers.postSocksProxyCheck).Methods("POST")
@@ -252,6 +325,7 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/aopp/choose-account", handlers.postAOPPChooseAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/cancel-connect-keystore", handlers.postCancelConnectKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-watchonly", handlers.postSetWatchonly).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/set-wallet-birthday", handlers.postSetWalletBirthday).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/on-auth-setting-changed", handlers.postOnAuthSettingChanged).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/export-log", handlers.postExportLog).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/accounts/eth-account-code", handlers.lookupEthAccountCode).Methods("POST")
@@ -269,7 +343,7 @@ func NewHandlers(
 		if _, ok := accountHandlersMap[accountCode]; !ok {
 			accountHandlersMap[accountCode] = accountHandlers.NewHandlers(getAPIRouter(
 				apiRouter.PathPrefix(fmt.Sprintf("/account/%s", accountCode)).Subrouter(),
-			), log)
+			), backend.ViewOnly, log)
 		}
 		accHandlers := accountHandlersMap[accountCode]
 		log.WithField("account-handlers", accHandlers).Debug("Account handlers")
@@ -340,17 +414,59 @@ func NewHandlers(
 	events := backend.Start()
 	go func() {
 		for {
-			handlers.backendEvents <- <-events
+			handlers.backendEvents.broadcast(<-events)
 		}
 	}()
-	backend.Observe(func(event observable.Event) { handlers.backendEvents <- event })
+	backend.Observe(handlers.relayEvent)
 
 	return handlers
 }
 
-// Events returns the push notifications channel.
-func (handlers *Handlers) Events() <-chan interface{} {
-	return handlers.backendEvents
+// Events returns a channel delivering the push notifications until quit is closed, which must be
+// done when the events are not received anymore, e.g. when the bridge is shut down. The events are
+// queued until they are received. The events fired before the first call are delivered to the
+// first caller.
+//
+// If the events are not received fast enough, the queue overflows and the queued events are
+// dropped. The channel then delivers a resyncEventSubject event, so that the frontend reloads its
+// state, followed by the new events.
+func (handlers *Handlers) Events(quit <-chan struct{}) <-chan interface{} {
+	events := make(chan interface{})
+	queue, unsubscribe := handlers.backendEvents.subscribe()
+	go func() {
+		defer func() { unsubscribe() }()
+		for {
+			queue.forward(func(event interface{}) bool {
+				select {
+				case events <- event:
+					return true
+				case <-queue.dropped:
+					return false
+				case <-quit:
+					return false
+				}
+			}, quit)
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			unsubscribe()
+			handlers.log.Error("Events were dropped, asking the frontend to resync")
+			// Subscribed before the resync, so that no event fired after it is missed.
+			queue, unsubscribe = handlers.backendEvents.subscribe()
+			select {
+			case events <- observable.Event{
+				Subject: resyncEventSubject,
+				Action:  action.Replace,
+				Object:  true,
+			}:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return events
 }
 
 func writeJSON(w io.Writer, value interface{}) {
@@ -376,18 +492,24 @@ type accountJSON struct {
 	// Multiple accounts can belong to the same keystore. For now we replicate the keystore info in
 	// the accounts. In the future the getAccountsHandler() could return the accounts grouped
 	// keystore.
-	Keystore              keystoreJSON       `json:"keystore"`
-	Active                bool               `json:"active"`
-	BitsuranceStatus      string             `json:"bitsuranceStatus"`
-	Watch                 bool               `json:"watch"`
-	CoinCode              coinpkg.Code       `json:"coinCode"`
-	CoinUnit              string             `json:"coinUnit"`
-	CoinName              string             `json:"coinName"`
-	Code                  accountsTypes.Code `json:"code"`
-	Name                  string             `json:"name"`
-	IsToken               bool               `json:"isToken"`
-	ActiveTokens          []activeToken      `json:"activeTokens,omitempty"`
-	BlockExplorerTxPrefix string             `json:"blockExplorerTxPrefix"`
+	Keystore                   keystoreJSON       `json:"keystore"`
+	Active                     bool               `json:"active"`
+	BitsuranceStatus           string             `json:"bitsuranceStatus"`
+	Watch                      bool               `json:"watch"`
+	WatchOnly                  bool               `json:"watchOnly"`
+	CoinCode                   coinpkg.Code       `json:"coinCode"`
+	CoinUnit                   string             `json:"coinUnit"`
+	CoinName                   string             `json:"coinName"`
+	Code                       accountsTypes.Code `json:"code"`
+	Name                       string             `json:"name"`
+	IsToken                    bool               `json:"isToken"`
+	ActiveTokens               []activeToken      `json:"activeTokens,omitempty"`
+	BlockExplorerTxPrefix      string             `json:"blockExplorerTxPrefix"`
+	BlockExplorerAddressPrefix string             `json:"blockExplorerAddressPrefix"`
+	BlockExplorerBlockPrefix   string             `json:"blockExplorerBlockPrefix"`
+	// PreferredScriptType is the script type of the receive addresses handed out first, empty if
+	// not set.
+	PreferredScriptType signing.ScriptType `json:"preferredScriptType,omitempty"`
 }
 
 func newAccountJSON(
@@ -403,17 +525,21 @@ func newAccountJSON(
 			Keystore:  keystore,
 			Connected: keystoreConnected,
 		},
-		Active:                !account.Config().Config.Inactive,
-		BitsuranceStatus:      account.Config().Config.InsuranceStatus,
-		Watch:                 watch != nil && *watch,
-		CoinCode:              account.Coin().Code(),
-		CoinUnit:              account.Coin().Unit(false),
-		CoinName:              account.Coin().Name(),
-		Code:                  account.Config().Config.Code,
-		Name:                  account.Config().Config.Name,
-		IsToken:               isToken,
-		ActiveTokens:          activeTokens,
-		BlockExplorerTxPrefix: account.Coin().BlockExplorerTransactionURLPrefix(),
+		Active:                     !account.Config().Config.Inactive,
+		BitsuranceStatus:           account.Config().Config.InsuranceStatus,
+		Watch:                      watch != nil && *watch,
+		WatchOnly:                  account.Config().Config.WatchOnly,
+		CoinCode:                   account.Coin().Code(),
+		CoinUnit:                   account.Coin().Unit(false),
+		CoinName:                   account.Coin().Name(),
+		Code:                       account.Config().Config.Code,
+		Name:                       account.Config().Config.Name,
+		IsToken:                    isToken,
+		ActiveTokens:               activeTokens,
+		BlockExplorerTxPrefix:      account.Coin().BlockExplorerTransactionURLPrefix(),
+		BlockExplorerAddressPrefix: account.Coin().BlockExplorerURLPrefix(coinpkg.BlockExplorerAddress),
+		BlockExplorerBlockPrefix:   account.Coin().BlockExplorerURLPrefix(coinpkg.BlockExplorerBlock),
+		PreferredScriptType:        account.Config().Config.PreferredScriptType,
 	}
 }
 
@@ -453,7 +579,30 @@ func (handlers *Handlers) postAppConfig(r *http.Request) (interface{}, error) {
 	if err := json.NewDecoder(r.Body).Decode(&appConfig); err != nil {
 		return nil, errp.WithStack(err)
 	}
-	return nil, handlers.backend.Config().SetAppConfig(appConfig)
+	// The view-only mode can only be changed with postSetViewOnly(), as disabling it needs to be
+	// confirmed on the device.
+	appConfig.Backend.ViewOnly = handlers.backend.ViewOnly()
+	if err := handlers.backend.Config().SetAppConfig(appConfig); err != nil {
+		return nil, err
+	}
+	handlers.backend.UpdateActiveFiats()
+	handlers.backend.UpdateLocale()
+	return nil, nil
+}
+
+func (handlers *Handlers) postActiveFiats(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var fiats []string
+	if err := json.NewDecoder(r.Body).Decode(&fiats); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetActiveFiats(fiats); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
 }
 
 // getNativeLocaleHandler returns user preferred UI language as reported
@@ -530,14 +679,51 @@ func (handlers *Handlers) getDetectDarkTheme(r *http.Request) interface{} {
 	return handlers.backend.Environment().DetectDarkTheme()
 }
 
+// getVersion returns the app version, and whether the app is in view-only mode, so the frontend can
+// hide the send UI from the start.
 func (handlers *Handlers) getVersion(*http.Request) interface{} {
-	return backend.Version.String()
+	return struct {
+		Version  string `json:"version"`
+		ViewOnly bool   `json:"viewOnly"`
+	}{
+		Version:  backend.Version.String(),
+		ViewOnly: handlers.backend.ViewOnly(),
+	}
 }
 
 func (handlers *Handlers) getTesting(*http.Request) interface{} {
 	return handlers.backend.Testing()
 }
 
+func (handlers *Handlers) getViewOnly(*http.Request) interface{} {
+	return handlers.backend.ViewOnly()
+}
+
+func (handlers *Handlers) postSetViewOnly(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		Aborted      bool   `json:"aborted,omitempty"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	var viewOnly bool
+	if err := json.NewDecoder(r.Body).Decode(&viewOnly); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	err := handlers.backend.SetViewOnly(viewOnly)
+	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
+		return response{Success: false, Aborted: true}
+	}
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not set the view-only mode")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
 func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 	var jsonBody struct {
 		CoinCode coinpkg.Code `json:"coinCode"`
@@ -571,6 +757,39 @@ func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 	return response{Success: true, AccountCode: accountCode}
 }
 
+// postImportWatchOnlyAccount adds a watch-only account from an extended public key, from which
+// nothing can be sent.
+func (handlers *Handlers) postImportWatchOnlyAccount(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode          coinpkg.Code       `json:"coinCode"`
+		Name              string             `json:"name"`
+		ExtendedPublicKey string             `json:"extendedPublicKey"`
+		ScriptType        signing.ScriptType `json:"scriptType"`
+	}
+
+	type response struct {
+		Success      bool               `json:"success"`
+		AccountCode  accountsTypes.Code `json:"accountCode,omitempty"`
+		ErrorMessage string             `json:"errorMessage,omitempty"`
+		ErrorCode    string             `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+
+	accountCode, err := handlers.backend.ImportWatchOnlyAccount(
+		jsonBody.CoinCode, jsonBody.Name, jsonBody.ExtendedPublicKey, jsonBody.ScriptType)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not import watch-only account")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, AccountCode: accountCode}
+}
+
 func (handlers *Handlers) getKeystores(*http.Request) interface{} {
 	type json struct {
 		Type keystore.Type `json:"type"`
@@ -674,25 +893,136 @@ func (handlers *Handlers) postBtcFormatUnit(r *http.Request) interface{} {
 	}
 
 	unit := request.Unit
+	switch unit {
+	case coinpkg.BtcUnitDefault, coinpkg.BtcUnitSats, coinpkg.BtcUnitMilliBTC:
+	default:
+		return response{Success: false, ErrorMessage: fmt.Sprintf("unknown unit %q", unit)}
+	}
 
 	// update BTC format unit for Coins
-	btcCoin, err := handlers.backend.Coin(coinpkg.CodeBTC)
+	for _, code := range []coinpkg.Code{
+		coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeTBTC4, coinpkg.CodeSBTC,
+	} {
+		btcCoin, err := handlers.backend.Coin(code)
+		if err != nil {
+			return response{Success: false}
+		}
+		btcCoin.(*btc.Coin).SetFormatUnit(unit)
+	}
+
+	// update BTC format unit for fiat conversions
+	for _, account := range handlers.backend.Accounts() {
+		account.Config().BtcCurrencyUnit = unit
+	}
+
+	return response{Success: true}
+}
+
+// getCoinsMetadata returns the metadata of all coins and tokens, so the frontend can render coins
+// without hard-coding them.
+func (handlers *Handlers) getCoinsMetadata(*http.Request) (interface{}, error) {
+	return handlers.backend.CoinsMetadata()
+}
+
+// getAddressPreview returns the first receive address per script type of the account that would be
+// created for the coin, without creating it.
+func (handlers *Handlers) getAddressPreview(r *http.Request) (interface{}, error) {
+	return handlers.backend.PreviewAccountAddresses(coinpkg.Code(mux.Vars(r)["code"]))
+}
+
+// getBlockExplorers returns the block explorers the user can choose from for a coin, and the URL of
+// the selected one.
+func (handlers *Handlers) getBlockExplorers(r *http.Request) interface{} {
+	code := coinpkg.Code(mux.Vars(r)["code"])
+	available := handlers.backend.AvailableBlockExplorers(code)
+	if available == nil {
+		available = []coinpkg.BlockExplorer{}
+	}
+	return map[string]interface{}{
+		"available": available,
+		"selected":  handlers.backend.BlockExplorer(code).URL,
+	}
+}
+
+// getServerInfo returns the software, the agreed protocol version and the banner of the server a
+// bitcoin-based coin is connected to.
+func (handlers *Handlers) getServerInfo(r *http.Request) interface{} {
+	type response struct {
+		Success      bool                   `json:"success"`
+		ErrorMessage string                 `json:"errorMessage,omitempty"`
+		ServerInfo   *blockchain.ServerInfo `json:"serverInfo,omitempty"`
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
 	if err != nil {
-		return response{Success: false}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return response{Success: false, ErrorMessage: "Server info is only available for bitcoin-based coins"}
+	}
+	serverInfo, err := btcCoin.ServerInfo()
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not get the server info")
+		return response{Success: false, ErrorMessage: err.Error()}
 	}
-	btcCoin.(*btc.Coin).SetFormatUnit(unit)
+	return response{Success: true, ServerInfo: serverInfo}
+}
 
-	btcCoin, err = handlers.backend.Coin(coinpkg.CodeTBTC)
+// getConnectionStatus returns the state of the connection of a bitcoin-based coin to its blockchain
+// backend.
+func (handlers *Handlers) getConnectionStatus(r *http.Request) (interface{}, error) {
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
 	if err != nil {
-		return response{Success: false}
+		return nil, err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return nil, errp.New("The connection status is only available for bitcoin-based coins")
 	}
-	btcCoin.(*btc.Coin).SetFormatUnit(unit)
+	return btcCoin.ConnectionStatus(), nil
+}
 
-	// update BTC format unit for fiat conversions
-	for _, account := range handlers.backend.Accounts() {
-		account.Config().BtcCurrencyUnit = unit
+// postValidateAddress validates a recipient address for a coin, without requiring an account of
+// that coin. It returns the address type if it is valid, or the reason why it is not.
+func (handlers *Handlers) postValidateAddress(r *http.Request) (interface{}, error) {
+	var address string
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	coin, err := handlers.backend.Coin(coinpkg.Code(mux.Vars(r)["code"]))
+	if err != nil {
+		return nil, err
+	}
+	addressType, err := coin.ValidateAddress(address)
+	if err != nil {
+		validationErr, ok := errp.Cause(err).(accountsErrors.TxValidationError)
+		if !ok {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"success":   false,
+			"errorCode": validationErr.Error(),
+		}, nil
 	}
+	return map[string]interface{}{
+		"success":     true,
+		"addressType": addressType,
+	}, nil
+}
 
+func (handlers *Handlers) postBlockExplorer(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	var explorerURL string
+	if err := json.NewDecoder(r.Body).Decode(&explorerURL); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetBlockExplorer(coinpkg.Code(mux.Vars(r)["code"]), explorerURL); err != nil {
+		handlers.log.WithError(err).Error("Could not set the block explorer")
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
 	return response{Success: true}
 }
 
@@ -722,7 +1052,7 @@ func (handlers *Handlers) getAccountsBalance(*http.Request) (interface{}, error)
 			if err != nil {
 				return nil, err
 			}
-			amount := b.Available()
+			amount := b.Owned()
 			if _, ok := totalPerCoin[coinCode]; !ok {
 				totalPerCoin[coinCode] = amount.BigInt()
 
@@ -735,7 +1065,8 @@ func (handlers *Handlers) getAccountsBalance(*http.Request) (interface{}, error)
 				account.Coin(),
 				false,
 				account.Config().RateUpdater,
-				util.FormatBtcAsSat(handlers.backend.Config().AppConfig().Backend.BtcUnit))
+				util.FormatBtcAsSat(handlers.backend.Config().AppConfig().Backend.BtcUnit),
+				false)
 		}
 
 		totalAmount[rootFingerprint] = make(map[coin.Code]accountHandlers.FormattedAmount)
@@ -783,7 +1114,7 @@ func (handlers *Handlers) getCoinsTotalBalance(_ *http.Request) (interface{}, er
 		if err != nil {
 			return nil, err
 		}
-		amount := b.Available()
+		amount := b.Owned()
 
 		if totalBalance, exists := totalCoinsBalances[coinCode]; exists {
 			totalBalance.Add(totalBalance, amount.BigInt())
@@ -810,6 +1141,7 @@ func (handlers *Handlers) getCoinsTotalBalance(_ *http.Request) (interface{}, er
 					false,
 					handlers.backend.RatesUpdater(),
 					util.FormatBtcAsSat(handlers.backend.Config().AppConfig().Backend.BtcUnit),
+					false,
 				),
 			},
 		})
@@ -856,6 +1188,110 @@ func (handlers *Handlers) postSetAccountActive(r *http.Request) interface{} {
 	return response{Success: true}
 }
 
+func (handlers *Handlers) postSetAccountShowUsedAddresses(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode accountsTypes.Code `json:"accountCode"`
+		Show        bool               `json:"show"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountShowUsedAddresses(jsonBody.AccountCode, jsonBody.Show); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) postSetAccountMinConfirmations(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode            accountsTypes.Code `json:"accountCode"`
+		MinConfirmations       int                `json:"minConfirmations"`
+		SpendUnconfirmedChange bool               `json:"spendUnconfirmedChange"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountMinConfirmations(
+		jsonBody.AccountCode, jsonBody.MinConfirmations, jsonBody.SpendUnconfirmedChange); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) postSetAccountDustThreshold(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode   accountsTypes.Code `json:"accountCode"`
+		DustThreshold int64              `json:"dustThreshold"`
+		ShowOwnDust   bool               `json:"showOwnDust"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountDustThreshold(
+		jsonBody.AccountCode, jsonBody.DustThreshold, jsonBody.ShowOwnDust); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) postSetAccountRequireVerifiedTxs(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode        accountsTypes.Code `json:"accountCode"`
+		RequireVerifiedTxs bool               `json:"requireVerifiedTxs"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountRequireVerifiedTxs(jsonBody.AccountCode, jsonBody.RequireVerifiedTxs); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) postSetAccountPreferredScriptType(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode accountsTypes.Code `json:"accountCode"`
+		ScriptType  signing.ScriptType `json:"scriptType"`
+	}
+
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	if err := handlers.backend.SetAccountPreferredScriptType(jsonBody.AccountCode, jsonBody.ScriptType); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
 func (handlers *Handlers) postSetTokenActive(r *http.Request) interface{} {
 	var jsonBody struct {
 		AccountCode accountsTypes.Code `json:"accountCode"`
@@ -906,6 +1342,13 @@ func (handlers *Handlers) postAccountsReinitialize(*http.Request) interface{} {
 	return nil
 }
 
+// postRetryConnections is called by the frontend when the network connectivity was restored, so
+// that the blockchain backends don't wait for their next scheduled connection attempt.
+func (handlers *Handlers) postRetryConnections(*http.Request) interface{} {
+	handlers.backend.RetryConnections()
+	return nil
+}
+
 func (handlers *Handlers) getDevicesRegistered(*http.Request) interface{} {
 	jsonDevices := map[string]string{}
 	for deviceID, device := range handlers.backend.DevicesRegistered() {
@@ -922,6 +1365,9 @@ func (handlers *Handlers) postRegisterTestKeystore(r *http.Request) (interface{}
 	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
 		return nil, errp.WithStack(err)
 	}
+	if mnemonic := jsonBody["mnemonic"]; mnemonic != "" {
+		return nil, handlers.backend.RegisterTestMnemonicKeystore(mnemonic, jsonBody["passphrase"], false)
+	}
 	pin := jsonBody["pin"]
 	handlers.backend.RegisterTestKeystore(pin)
 	return nil, nil
@@ -1132,19 +1578,24 @@ func (handlers *Handlers) eventsHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	sendChan, quitChan := runWebsocket(conn, handlers.apiData, handlers.log)
+	queue, unsubscribe := handlers.backendEvents.subscribe()
+	// Each client has its own writer goroutine, so a slow client does not block the backend or
+	// other clients.
 	go func() {
-		for {
+		defer unsubscribe()
+		queue.forward(func(event interface{}) bool {
 			select {
+			case sendChan <- jsonp.MustMarshal(event):
+				return true
 			case <-quitChan:
-				return
-			default:
-				select {
-				case <-quitChan:
-					return
-				case event := <-handlers.backendEvents:
-					sendChan <- jsonp.MustMarshal(event)
-				}
+				return false
+			case <-queue.dropped:
+				return false
 			}
+		}, quitChan)
+		if queue.isDropped() {
+			// Closing sendChan closes the connection, so the frontend knows it missed events.
+			close(sendChan)
 		}
 	}()
 }
@@ -1457,6 +1908,170 @@ func (handlers *Handlers) postSetWatchonly(r *http.Request) interface{} {
 	return response{Success: true}
 }
 
+func (handlers *Handlers) postSetWalletBirthday(r *http.Request) interface{} {
+	type response struct {
+		Success bool `json:"success"`
+	}
+	var request struct {
+		RootFingerprint jsonp.HexBytes `json:"rootFingerprint"`
+		// Nil to clear the birthday.
+		Birthday *config.WalletBirthday `json:"birthday"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return response{Success: false}
+	}
+	if err := handlers.backend.SetWalletBirthday([]byte(request.RootFingerprint), request.Birthday); err != nil {
+		handlers.log.WithError(err).Error("Could not set the wallet birthday")
+		return response{Success: false}
+	}
+	return response{Success: true}
+}
+
+// postAccountObserve registers (observe=true) or releases (observe=false) the interest of a
+// frontend view in the events of an account.
+func (handlers *Handlers) postAccountObserve(observe bool) func(*http.Request) interface{} {
+	return func(r *http.Request) interface{} {
+		type response struct {
+			Success bool `json:"success"`
+		}
+		var accountCode accountsTypes.Code
+		if err := json.NewDecoder(r.Body).Decode(&accountCode); err != nil {
+			return response{Success: false}
+		}
+		if observe {
+			handlers.backend.ObserveAccount(accountCode)
+		} else {
+			handlers.backend.UnobserveAccount(accountCode)
+		}
+		return response{Success: true}
+	}
+}
+
+// getSearch searches the transactions of all accounts. The query parameters are `q` (the search
+// query), `limit` (optional maximum number of hits) and `includeArchived` ("true" to also search
+// inactive accounts).
+func (handlers *Handlers) getSearch(r *http.Request) interface{} {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+	return handlers.backend.SearchTransactions(backend.SearchArgs{
+		Query:           query.Get("q"),
+		Limit:           limit,
+		IncludeArchived: query.Get("includeArchived") == "true",
+	})
+}
+
+func (handlers *Handlers) getAccountRotation(r *http.Request) interface{} {
+	return handlers.backend.AccountRotation(accountsTypes.Code(mux.Vars(r)["code"]))
+}
+
+func (handlers *Handlers) postStartAccountRotation(r *http.Request) interface{} {
+	type response struct {
+		Success        bool               `json:"success"`
+		NewAccountCode accountsTypes.Code `json:"newAccountCode,omitempty"`
+		ErrorMessage   string             `json:"errorMessage,omitempty"`
+		ErrorCode      string             `json:"errorCode,omitempty"`
+	}
+	newCode, err := handlers.backend.StartAccountRotation(accountsTypes.Code(mux.Vars(r)["code"]))
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not start the account rotation")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, NewAccountCode: newCode}
+}
+
+func (handlers *Handlers) postAccountRotationProposal(r *http.Request) interface{} {
+	var jsonBody struct {
+		FeeTarget string `json:"feeTarget"`
+		CustomFee string `json:"customFee"`
+	}
+	type response struct {
+		Success      bool                             `json:"success"`
+		Proposal     *backend.AccountRotationProposal `json:"proposal,omitempty"`
+		ErrorMessage string                           `json:"errorMessage,omitempty"`
+		ErrorCode    string                           `json:"errorCode,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	feeTargetCode, err := accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	proposal, err := handlers.backend.AccountRotationProposal(
+		accountsTypes.Code(mux.Vars(r)["code"]), feeTargetCode, jsonBody.CustomFee)
+	if err != nil {
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Proposal: proposal}
+}
+
+func (handlers *Handlers) postSendAccountRotation(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+	}
+	if err := handlers.backend.SendAccountRotation(accountsTypes.Code(mux.Vars(r)["code"])); err != nil {
+		handlers.log.WithError(err).Error("Could not send the account rotation transaction")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) postCancelAccountRotation(r *http.Request) interface{} {
+	type response struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+	}
+	if err := handlers.backend.CancelAccountRotation(accountsTypes.Code(mux.Vars(r)["code"])); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true}
+}
+
+func (handlers *Handlers) getAccountsDiscovery(*http.Request) (interface{}, error) {
+	return handlers.backend.AccountsDiscovery()
+}
+
+// postAccountsDiscovery retries the accounts discovery of a coin if retry is true, and cancels it
+// otherwise.
+func (handlers *Handlers) postAccountsDiscovery(retry bool) func(*http.Request) interface{} {
+	return func(r *http.Request) interface{} {
+		type response struct {
+			Success      bool   `json:"success"`
+			ErrorMessage string `json:"errorMessage,omitempty"`
+			ErrorCode    string `json:"errorCode,omitempty"`
+		}
+		coinCode := coinpkg.Code(mux.Vars(r)["code"])
+		var err error
+		if retry {
+			err = handlers.backend.RetryAccountsDiscovery(coinCode)
+		} else {
+			err = handlers.backend.CancelAccountsDiscovery(coinCode)
+		}
+		if err != nil {
+			handlers.log.WithError(err).WithField("coinCode", coinCode).Error("accounts discovery")
+			if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+				return response{Success: false, ErrorCode: string(errCode)}
+			}
+			return response{Success: false, ErrorMessage: err.Error()}
+		}
+		return response{Success: true}
+	}
+}
+
 func (handlers *Handlers) postOnAuthSettingChanged(r *http.Request) interface{} {
 	handlers.backend.Environment().OnAuthSettingChanged(
 		handlers.backend.Config().AppConfig().Backend.Authentication)
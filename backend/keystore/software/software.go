@@ -16,11 +16,14 @@
 package software
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
@@ -33,7 +36,9 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/pbkdf2"
@@ -70,6 +75,41 @@ func NewKeystoreFromPIN(pin string) *Keystore {
 	return NewKeystore(master)
 }
 
+// NewKeystoreFromMnemonic creates a keystore from a BIP39 mnemonic and an optional passphrase, e.g.
+// to run the backend against a known wallet in automated tests. The net determines the version of
+// the extended keys.
+//
+// The words are not checked against the BIP39 word list and the checksum is not verified, so a typo
+// results in a different wallet. Only lowercase ASCII is accepted, for which the Unicode
+// normalization of BIP39 has no effect.
+func NewKeystoreFromMnemonic(mnemonic string, passphrase string, net *chaincfg.Params) (*Keystore, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, errp.Newf("A mnemonic must have 12, 15, 18, 21 or 24 words, not %d", len(words))
+	}
+	for _, word := range words {
+		for _, char := range word {
+			if char < 'a' || char > 'z' {
+				return nil, errp.New("A mnemonic may only contain lowercase ASCII words")
+			}
+		}
+	}
+	for _, char := range passphrase {
+		if char > 0x7f {
+			return nil, errp.New("The passphrase may only contain ASCII characters")
+		}
+	}
+	seed := pbkdf2.Key(
+		[]byte(strings.Join(words, " ")), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewKeystore(master), nil
+}
+
 // Type implements keystore.Keystore.
 func (keystore *Keystore) Type() keystorePkg.Type {
 	return keystorePkg.TypeSoftware
@@ -211,7 +251,7 @@ func (keystore *Keystore) SignTransaction(
 		if address.Configuration.ScriptType() == signing.ScriptTypeP2TR {
 			prv = txscript.TweakTaprootPrivKey(*prv, nil)
 			signatureHash, err := txscript.CalcTaprootSignatureHash(
-				btcProposedTx.SigHashes, txscript.SigHashDefault, transaction,
+				btcProposedTx.SigHashes, btcProposedTx.InputSigHashType(index, address), transaction,
 				index, btcProposedTx.TXProposal.PreviousOutputs)
 			if err != nil {
 				return errp.Wrap(err, "Failed to calculate Taproot signature hash")
@@ -232,7 +272,7 @@ func (keystore *Keystore) SignTransaction(
 			if isSegwit {
 				var err error
 				signatureHash, err = txscript.CalcWitnessSigHash(subScript, btcProposedTx.SigHashes,
-					txscript.SigHashAll, transaction, index, spentOutput.Value)
+					btcProposedTx.InputSigHashType(index, address), transaction, index, spentOutput.Value)
 				if err != nil {
 					return errp.Wrap(err, "Failed to calculate SegWit signature hash")
 				}
@@ -240,7 +280,7 @@ func (keystore *Keystore) SignTransaction(
 			} else {
 				var err error
 				signatureHash, err = txscript.CalcSignatureHash(
-					subScript, txscript.SigHashAll, transaction, index)
+					subScript, btcProposedTx.InputSigHashType(index, address), transaction, index)
 				if err != nil {
 					return errp.Wrap(err, "Failed to calculate legacy signature hash")
 				}
@@ -260,13 +300,40 @@ func (keystore *Keystore) SignTransaction(
 }
 
 // CanSignMessage implements keystore.Keystore.
-func (keystore *Keystore) CanSignMessage(coin.Code) bool {
-	return false
+func (keystore *Keystore) CanSignMessage(code coin.Code) bool {
+	switch code {
+	case coin.CodeBTC, coin.CodeTBTC, coin.CodeTBTC4, coin.CodeSBTC, coin.CodeRBTC:
+		return true
+	default:
+		return false
+	}
 }
 
 // SignBTCMessage implements keystore.Keystore.
 func (keystore *Keystore) SignBTCMessage(message []byte, keypath signing.AbsoluteKeypath, scriptType signing.ScriptType) ([]byte, error) {
-	return nil, errp.New("unsupported")
+	switch scriptType {
+	case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH:
+	default:
+		// Like the BitBox02, which does not support BIP322 signatures for taproot.
+		return nil, errp.Newf("scriptType not supported: %s", scriptType)
+	}
+	xprv, err := keypath.Derive(keystore.master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	var buf bytes.Buffer
+	if err := wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n"); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := wire.WriteVarBytes(&buf, 0, message); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	// The recovery header is 27+4+recID for compressed keys for all script types, as in Electrum.
+	return ecdsa.SignCompact(prv, chainhash.DoubleHashB(buf.Bytes()), true), nil
 }
 
 // SignETHMessage implements keystore.Keystore.
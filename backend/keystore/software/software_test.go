@@ -15,9 +15,22 @@
 package software
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,3 +45,225 @@ func TestRootFingerprint(t *testing.T) {
 	// Verified by comparing to the root fingerprint produced by the BitBox02 and Electrum.
 	require.Equal(t, []byte{0xfb, 0x70, 0x89, 0xbd}, rootFingerprint)
 }
+
+func TestSignTransactionSigHashTypes(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), net)
+	require.NoError(t, err)
+	keystore := NewKeystore(master)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'")
+	require.NoError(t, err)
+	xprv, err := keypath.Derive(master)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+
+	scriptTypes := []signing.ScriptType{
+		signing.ScriptTypeP2PKH,
+		signing.ScriptTypeP2WPKHP2SH,
+		signing.ScriptTypeP2WPKH,
+		signing.ScriptTypeP2TR,
+	}
+	sigHashTypes := []txscript.SigHashType{
+		txscript.SigHashDefault,
+		txscript.SigHashAll,
+		txscript.SigHashSingle,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+	}
+	for _, scriptType := range scriptTypes {
+		for _, sigHashType := range sigHashTypes {
+			address := addresses.NewAccountAddress(
+				signing.NewBitcoinConfiguration(scriptType, []byte{1, 2, 3, 4}, keypath, xpub),
+				signing.NewEmptyRelativeKeypath().Child(0, false).Child(0, false),
+				net,
+				logging.Get().WithGroup("software_test"),
+			)
+			outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("prevtx")), Index: 0}
+			previousOutputs := maketx.PreviousOutputs{
+				outPoint: &transactions.SpendableOutput{
+					TxOut: wire.NewTxOut(10000, address.PubkeyScript()),
+				},
+			}
+			tx := wire.NewMsgTx(wire.TxVersion)
+			tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+			tx.AddTxOut(wire.NewTxOut(9000, address.PubkeyScript()))
+			sigHashes := txscript.NewTxSigHashes(tx, previousOutputs)
+			proposedTx := &btc.ProposedTransaction{
+				TXProposal: &maketx.TxProposal{
+					Transaction:     tx,
+					PreviousOutputs: previousOutputs,
+				},
+				GetAccountAddress: func(blockchain.ScriptHashHex) *addresses.AccountAddress {
+					return address
+				},
+				SigHashes:   sigHashes,
+				SigHashType: sigHashType,
+			}
+			require.NoError(t, keystore.SignTransaction(proposedTx))
+			tx.TxIn[0].SignatureScript, tx.TxIn[0].Witness = address.SignatureScript(
+				*proposedTx.Signatures[0], sigHashType)
+
+			if scriptType == signing.ScriptTypeP2TR {
+				// SIGHASH_DEFAULT omits the trailing sighash byte.
+				if sigHashType == txscript.SigHashDefault {
+					require.Len(t, tx.TxIn[0].Witness[0], 64)
+				} else {
+					require.Len(t, tx.TxIn[0].Witness[0], 65)
+					require.Equal(t, byte(sigHashType), tx.TxIn[0].Witness[0][64])
+				}
+			}
+			engine, err := txscript.NewEngine(address.PubkeyScript(), tx, 0,
+				txscript.StandardVerifyFlags, nil, sigHashes, 10000, previousOutputs)
+			require.NoError(t, err)
+			require.NoError(t, engine.Execute(), "%s %v", scriptType, sigHashType)
+		}
+	}
+}
+
+func TestSignTransactionPerInputSigHashTypes(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), net)
+	require.NoError(t, err)
+	keystore := NewKeystore(master)
+	xpub := func(keypath signing.AbsoluteKeypath) *hdkeychain.ExtendedKey {
+		xprv, err := keypath.Derive(master)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		return xpub
+	}
+	newAddress := func(scriptType signing.ScriptType, keypath string) *addresses.AccountAddress {
+		absoluteKeypath, err := signing.NewAbsoluteKeypath(keypath)
+		require.NoError(t, err)
+		return addresses.NewAccountAddress(
+			signing.NewBitcoinConfiguration(scriptType, []byte{1, 2, 3, 4}, absoluteKeypath, xpub(absoluteKeypath)),
+			signing.NewEmptyRelativeKeypath().Child(0, false).Child(0, false),
+			net,
+			logging.Get().WithGroup("software_test"),
+		)
+	}
+	inputAddresses := []*addresses.AccountAddress{
+		newAddress(signing.ScriptTypeP2WPKH, "m/84'/1'/0'"),
+		newAddress(signing.ScriptTypeP2TR, "m/86'/1'/0'"),
+		newAddress(signing.ScriptTypeP2PKH, "m/44'/1'/0'"),
+	}
+	addressesByScriptHash := map[blockchain.ScriptHashHex]*addresses.AccountAddress{}
+	previousOutputs := maketx.PreviousOutputs{}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for index, address := range inputAddresses {
+		addressesByScriptHash[address.PubkeyScriptHashHex()] = address
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("prevtx")), Index: uint32(index)}
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(10000, address.PubkeyScript()),
+		}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	}
+	// SIGHASH_SINGLE requires an output at the index of the input.
+	for _, address := range inputAddresses {
+		tx.AddTxOut(wire.NewTxOut(9000, address.PubkeyScript()))
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, previousOutputs)
+	proposedTx := &btc.ProposedTransaction{
+		TXProposal: &maketx.TxProposal{
+			Transaction:     tx,
+			PreviousOutputs: previousOutputs,
+		},
+		GetAccountAddress: func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+			return addressesByScriptHash[scriptHashHex]
+		},
+		SigHashes: sigHashes,
+	}
+	require.True(t, proposedTx.UsesDefaultSigHashTypes())
+	// Explicit SIGHASH_ALL is the default for non-taproot inputs only.
+	proposedTx.TXProposal.SigHashTypes = map[wire.OutPoint]txscript.SigHashType{
+		tx.TxIn[0].PreviousOutPoint: txscript.SigHashAll,
+	}
+	require.True(t, proposedTx.UsesDefaultSigHashTypes())
+	proposedTx.TXProposal.SigHashTypes = map[wire.OutPoint]txscript.SigHashType{
+		tx.TxIn[1].PreviousOutPoint: txscript.SigHashAll,
+	}
+	require.False(t, proposedTx.UsesDefaultSigHashTypes())
+
+	proposedTx.TXProposal.SigHashTypes = map[wire.OutPoint]txscript.SigHashType{
+		tx.TxIn[0].PreviousOutPoint: txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		tx.TxIn[1].PreviousOutPoint: txscript.SigHashSingle,
+	}
+	require.False(t, proposedTx.UsesDefaultSigHashTypes())
+	require.Equal(t, txscript.SigHashAll|txscript.SigHashAnyOneCanPay,
+		proposedTx.InputSigHashType(0, inputAddresses[0]))
+	require.Equal(t, txscript.SigHashSingle, proposedTx.InputSigHashType(1, inputAddresses[1]))
+	require.Equal(t, txscript.SigHashAll, proposedTx.InputSigHashType(2, inputAddresses[2]))
+
+	require.NoError(t, keystore.SignTransaction(proposedTx))
+	for index, address := range inputAddresses {
+		tx.TxIn[index].SignatureScript, tx.TxIn[index].Witness = address.SignatureScript(
+			*proposedTx.Signatures[index], proposedTx.InputSigHashType(index, address))
+	}
+	// The taproot signature has the sighash byte appended.
+	require.Len(t, tx.TxIn[1].Witness[0], 65)
+	require.Equal(t, byte(txscript.SigHashSingle), tx.TxIn[1].Witness[0][64])
+	for index, address := range inputAddresses {
+		engine, err := txscript.NewEngine(address.PubkeyScript(), tx, index,
+			txscript.StandardVerifyFlags, nil, sigHashes, 10000, previousOutputs)
+		require.NoError(t, err)
+		require.NoError(t, engine.Execute(), "input %d", index)
+	}
+}
+
+func TestNewKeystoreFromMnemonic(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(
+		"awkward squirrel wait rubber biology escape toe daring still pause fitness vendor",
+		"",
+		&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t,
+		"xprv9s21ZrQH143K3uDh9hiNXB3a9GVzcCujEmCwmZA9g8m4i5nUDVdLHJjsLMPzV26vj8Q7ceGrUhX119Y3XzGhJqq5K6LWP1h6gjv2cbkMEH1",
+		keystore.master.String())
+
+	// BIP39 test vector with a passphrase.
+	keystore, err = NewKeystoreFromMnemonic(
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"TREZOR",
+		&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t,
+		"xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF",
+		keystore.master.String())
+
+	_, err = NewKeystoreFromMnemonic("abandon abandon about", "", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = NewKeystoreFromMnemonic(
+		"Abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
+
+func TestSignBTCMessage(t *testing.T) {
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	keystore := NewKeystore(master)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'/0/0")
+	require.NoError(t, err)
+	xprv, err := keypath.Derive(master)
+	require.NoError(t, err)
+	publicKey, err := xprv.ECPubKey()
+	require.NoError(t, err)
+
+	message := []byte("message")
+	signature, err := keystore.SignBTCMessage(message, keypath, signing.ScriptTypeP2WPKH)
+	require.NoError(t, err)
+	require.Len(t, signature, 65)
+
+	var buf bytes.Buffer
+	require.NoError(t, wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n"))
+	require.NoError(t, wire.WriteVarBytes(&buf, 0, message))
+	recovered, compressed, err := ecdsa.RecoverCompact(signature, chainhash.DoubleHashB(buf.Bytes()))
+	require.NoError(t, err)
+	require.True(t, compressed)
+	require.True(t, recovered.IsEqual(publicKey))
+
+	_, err = keystore.SignBTCMessage(message, keypath, signing.ScriptTypeP2TR)
+	require.Error(t, err)
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -54,10 +55,61 @@ type bip329BitBoxApp struct {
 type bip329Type string
 
 const (
-	bip329TypeTx   bip329Type = "tx"
-	bip329TypeXpub bip329Type = "xpub"
+	bip329TypeTx     bip329Type = "tx"
+	bip329TypeAddr   bip329Type = "addr"
+	bip329TypeInput  bip329Type = "input"
+	bip329TypeOutput bip329Type = "output"
+	bip329TypeXpub   bip329Type = "xpub"
 )
 
+// addressNotesAccount is implemented by accounts which support notes for their receive addresses,
+// see btc.Account.
+type addressNotesAccount interface {
+	ExportAddressNotes() (map[string]string, error)
+	ImportAddressNote(encodedAddress string, note string) (bool, bool, error)
+}
+
+// outPointNotesAccount is implemented by accounts which support notes for the inputs and outputs of
+// their transactions, see btc.Account. Both are keyed by an outpoint ("txid:index").
+type outPointNotesAccount interface {
+	ExportInputNotes() (map[string]string, error)
+	ExportOutputNotes() (map[string]string, error)
+	ImportInputNote(outPoint string, note string) (bool, bool, error)
+	ImportOutputNote(outPoint string, note string) (bool, bool, error)
+}
+
+// syncedNotesAccount is implemented by accounts which match imported notes against their synced
+// transactions and addresses, see btc.Account.
+type syncedNotesAccount interface {
+	WaitSynchronizedTimeout(timeout time.Duration) bool
+}
+
+// notesImportSyncTimeout is how long an import waits in total for the accounts to be synced before
+// matching the notes against what is known so far.
+const notesImportSyncTimeout = 30 * time.Second
+
+// writeBIP329Entries writes one entry per note, sorted by ref for a deterministic export.
+func writeBIP329Entries(
+	writer io.Writer, entryType bip329Type, notesByRef map[string]string, bitboxApp *bip329BitBoxApp) error {
+	refs := make([]string, 0, len(notesByRef))
+	for ref := range notesByRef {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		entry := bip329Entry{
+			Type:      entryType,
+			Ref:       ref,
+			Label:     notesByRef[ref],
+			BitBoxApp: bitboxApp,
+		}
+		if err := json.NewEncoder(writer).Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki#specification
 // Extended with a proprietary field "bitboxapp".
 type bip329Entry struct {
@@ -145,12 +197,42 @@ func (backend *Backend) exportNotes(writer io.Writer) error {
 				return err
 			}
 		}
+
+		bitboxApp := &bip329BitBoxApp{
+			CoinCode:    account.Config().Config.CoinCode,
+			AccountCode: accountCode,
+		}
+		if addressNotesAcct, ok := account.(addressNotesAccount); ok {
+			addressNotes, err := addressNotesAcct.ExportAddressNotes()
+			if err != nil {
+				return err
+			}
+			if err := writeBIP329Entries(writer, bip329TypeAddr, addressNotes, bitboxApp); err != nil {
+				return err
+			}
+		}
+		if outPointNotesAcct, ok := account.(outPointNotesAccount); ok {
+			inputNotes, err := outPointNotesAcct.ExportInputNotes()
+			if err != nil {
+				return err
+			}
+			if err := writeBIP329Entries(writer, bip329TypeInput, inputNotes, bitboxApp); err != nil {
+				return err
+			}
+			outputNotes, err := outPointNotesAcct.ExportOutputNotes()
+			if err != nil {
+				return err
+			}
+			if err := writeBIP329Entries(writer, bip329TypeOutput, outputNotes, bitboxApp); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-// ExportNotes exports the transactions and accounts labels of all accounts of all
-// connected/remembered keystores. Deactivated accounts are included in the export, except for
+// ExportNotes exports the transaction, receive address, input, output and account labels of all
+// accounts of all connected/remembered keystores. The export has one JSON object per line. Deactivated accounts are included in the export, except for
 // deactivated ERC-20 accounts. We export to a file using an extended version of BIP-329:
 // https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki
 func (backend *Backend) ExportNotes() error {
@@ -195,6 +277,46 @@ type ImportNotesResult struct {
 	AccountCount int `json:"accountCount"`
 	// TransactionCount is the number of transaction notes updated.
 	TransactionCount int `json:"transactionCount"`
+	// AddressCount is the number of address notes updated.
+	AddressCount int `json:"addressCount"`
+	// InputCount is the number of input notes updated.
+	InputCount int `json:"inputCount"`
+	// OutputCount is the number of output notes updated.
+	OutputCount int `json:"outputCount"`
+}
+
+// importAccountNote imports a note into the account given in the BitBoxApp data of the entry, or,
+// without the BitBoxApp data, into the first account the ref belongs to. waitSynced is called
+// before a note is imported into an account. importNote returns whether the ref belongs to the
+// account and whether the note changed. Returns whether the note changed.
+func (backend *Backend) importAccountNote(
+	entry *bip329Entry,
+	waitSynced func(account accounts.Interface) error,
+	importNote func(account accounts.Interface) (bool, bool, error),
+) (bool, error) {
+	candidates := backend.Accounts()
+	if entry.BitBoxApp != nil {
+		candidates = nil
+		if account := backend.Accounts().lookup(entry.BitBoxApp.AccountCode); account != nil {
+			candidates = append(candidates, account)
+		}
+	}
+	for _, account := range candidates {
+		if account.FatalError() || account.Config().Config.HiddenBecauseUnused {
+			continue
+		}
+		if err := waitSynced(account); err != nil {
+			return false, err
+		}
+		found, changed, err := importNote(account)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			return changed, nil
+		}
+	}
+	return false, nil
 }
 
 // ImportNotes imports notes from a jsonlines document according to BIP-329:
@@ -202,7 +324,8 @@ type ImportNotesResult struct {
 //
 // Only accounts of connected/remembered keystores are considered, also deactivated accounts (except
 // for deactivated ERC-20 accounts). If a label in the import does not belong to one of them, it is
-// ignored.
+// ignored. The imported labels are merged into the existing ones: a label in the import replaces the
+// label of the same item, labels of other items are kept.
 func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error) {
 	sanityCheck := func() error {
 		scanner := bufio.NewScanner(bytes.NewReader(jsonLines))
@@ -225,6 +348,31 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 
 	result := &ImportNotesResult{}
 
+	// Each account is waited for once, and all accounts together at most until syncDeadline, so
+	// that the import does not hang while offline.
+	syncDeadline := time.Now().Add(notesImportSyncTimeout)
+	waited := map[accountsTypes.Code]struct{}{}
+	waitSynced := func(account accounts.Interface) error {
+		code := account.Config().Config.Code
+		if _, ok := waited[code]; ok {
+			return nil
+		}
+		waited[code] = struct{}{}
+		syncedAccount, ok := account.(syncedNotesAccount)
+		if !ok {
+			return nil
+		}
+		// Starts the sync if the account was not initialized yet.
+		if err := account.Initialize(); err != nil {
+			return err
+		}
+		if !syncedAccount.WaitSynchronizedTimeout(time.Until(syncDeadline)) {
+			backend.log.WithField("code", code).Warning(
+				"Account not synced in time, importing its notes based on what is known so far")
+		}
+		return nil
+	}
+
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
@@ -310,6 +458,45 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 			if changed {
 				result.TransactionCount += 1
 			}
+
+		case bip329TypeAddr:
+			// Import receive address note. Without the BitBoxApp data, the address is looked up in
+			// all accounts supporting address notes.
+			changed, err := backend.importAccountNote(&entry, waitSynced, func(account accounts.Interface) (bool, bool, error) {
+				addressNotesAcct, ok := account.(addressNotesAccount)
+				if !ok {
+					return false, false, nil
+				}
+				return addressNotesAcct.ImportAddressNote(ref, label)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				result.AddressCount += 1
+			}
+
+		case bip329TypeInput, bip329TypeOutput:
+			// Import input or output note, keyed by outpoint.
+			input := entry.Type == bip329TypeInput
+			changed, err := backend.importAccountNote(&entry, waitSynced, func(account accounts.Interface) (bool, bool, error) {
+				outPointNotesAcct, ok := account.(outPointNotesAccount)
+				if !ok {
+					return false, false, nil
+				}
+				if input {
+					return outPointNotesAcct.ImportInputNote(ref, label)
+				}
+				return outPointNotesAcct.ImportOutputNote(ref, label)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if changed && input {
+				result.InputCount += 1
+			} else if changed {
+				result.OutputCount += 1
+			}
 		}
 	}
 
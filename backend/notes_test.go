@@ -20,8 +20,10 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
@@ -32,6 +34,49 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
+// The outpoints of the mocked BTC transaction, see outPointNotesAccountMock.
+const (
+	btcInputOutPoint  = "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:0"
+	btcOutputOutPoint = "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:1"
+)
+
+// outPointNotesAccountMock adds input and output notes to an account mock. The mocked transaction
+// spends btcInputOutPoint and creates btcOutputOutPoint.
+type outPointNotesAccountMock struct {
+	*accountsMocks.InterfaceMock
+	// waits counts the calls to WaitSynchronizedTimeout().
+	waits int
+}
+
+func (account *outPointNotesAccountMock) WaitSynchronizedTimeout(timeout time.Duration) bool {
+	account.waits++
+	return false
+}
+
+func (account *outPointNotesAccountMock) ExportInputNotes() (map[string]string, error) {
+	return account.Notes().InputNotes(), nil
+}
+
+func (account *outPointNotesAccountMock) ExportOutputNotes() (map[string]string, error) {
+	return account.Notes().OutputNotes(), nil
+}
+
+func (account *outPointNotesAccountMock) ImportInputNote(outPoint string, note string) (bool, bool, error) {
+	if outPoint != btcInputOutPoint {
+		return false, false, nil
+	}
+	changed, err := account.Notes().SetInputNote(outPoint, note)
+	return true, changed, err
+}
+
+func (account *outPointNotesAccountMock) ImportOutputNote(outPoint string, note string) (bool, bool, error) {
+	if outPoint != btcOutputOutPoint {
+		return false, false, nil
+	}
+	changed, err := account.Notes().SetOutputNote(outPoint, note)
+	return true, changed, err
+}
+
 type notesTestSuite struct {
 	suite.Suite
 	backend *Backend
@@ -84,7 +129,7 @@ func (s *notesTestSuite) SetupTest() {
 		accountMock.NotesFunc = notesFunc(config.Config.Code)
 		accountMock.TransactionsFunc = transactionsFunc(config.Config.Code)
 
-		return accountMock
+		return &outPointNotesAccountMock{InterfaceMock: accountMock}
 	}
 	s.backend.makeEthAccount = func(config *accounts.AccountConfig, coin *eth.Coin, httpClient *http.Client, log *logrus.Entry) accounts.Interface {
 		accountMock := MockEthAccount(config, coin, httpClient, log)
@@ -266,3 +311,38 @@ INVALID LINE
 	s.Require().NotNil(btcAcct)
 	s.Require().Equal("", btcAcct.Notes().TxNote("btc-tx-id"))
 }
+
+func (s *notesTestSuite) TestInputAndOutputNotes() {
+	btcAcct := s.backend.Accounts().lookup("v0-55555555-btc-0")
+	s.Require().NotNil(btcAcct)
+	_, err := btcAcct.Notes().SetInputNote(btcInputOutPoint, "paid rent")
+	s.Require().NoError(err)
+	_, err = btcAcct.Notes().SetOutputNote(btcOutputOutPoint, "change")
+	s.Require().NoError(err)
+	_, err = btcAcct.Notes().SetTxNote("btc-tx-id", "rent")
+	s.Require().NoError(err)
+
+	var export bytes.Buffer
+	s.Require().NoError(s.backend.exportNotes(&export))
+	s.Require().Contains(export.String(), `{"type":"tx","ref":"btc-tx-id","label":"rent","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"input","ref":"`+btcInputOutPoint+`","label":"paid rent","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"output","ref":"`+btcOutputOutPoint+`","label":"change","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+`)
+
+	// The import is merged into the existing labels. Labels of unknown outpoints are ignored.
+	_, err = btcAcct.Notes().SetOutputNote(btcOutputOutPoint, "")
+	s.Require().NoError(err)
+	result, err := s.backend.ImportNotes([]byte(`{"type":"input","ref":"` + btcInputOutPoint + `","label":"paid rent"}
+{"type":"output","ref":"` + btcOutputOutPoint + `","label":"my change","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"output","ref":"` + btcInputOutPoint + `","label":"unknown output"}
+{"type":"input","ref":"` + btcOutputOutPoint + `","label":"unknown input","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+`))
+	s.Require().NoError(err)
+	s.Require().Equal(&ImportNotesResult{OutputCount: 1}, result)
+	s.Require().Equal(map[string]string{btcInputOutPoint: "paid rent"}, btcAcct.Notes().InputNotes())
+	s.Require().Equal(map[string]string{btcOutputOutPoint: "my change"}, btcAcct.Notes().OutputNotes())
+	s.Require().Equal("rent", btcAcct.Notes().TxNote("btc-tx-id"))
+	// The account is waited for once per import, and the import continues if it is not synced in
+	// time.
+	s.Require().Equal(1, btcAcct.(*outPointNotesAccountMock).waits)
+}
@@ -26,6 +26,17 @@ import (
 const (
 	bucketUnnotifiedKey = "unnotified"
 	bucketSeenKey       = "seen"
+	// bucketTxEventsKey contains the IDs of the transactions for which EventNewTransaction was
+	// emitted, mapped to txEventConfirmed if the event was emitted for the confirmed tx.
+	bucketTxEventsKey = "txEvents"
+	// keyHasTransactions is set in the bucket of an account or keystore once a transaction was seen
+	// in it.
+	keyHasTransactions = "hasTransactions"
+)
+
+const (
+	txEventUnconfirmed byte = iota
+	txEventConfirmed
 )
 
 // Notifier implements accounts.Notifier, storing the data of all accounts in a bbolt db.
@@ -48,13 +59,28 @@ func (notifier *Notifier) Close() error {
 }
 
 type notifierForAccount struct {
-	db          *bbolt.DB
-	accountCode accountsTypes.Code
+	db              *bbolt.DB
+	accountCode     accountsTypes.Code
+	rootFingerprint []byte
+}
+
+// ForAccount returns a Notifier for a specific account. rootFingerprint identifies the keystore of
+// the account.
+func (notifier *Notifier) ForAccount(
+	accountCode accountsTypes.Code, rootFingerprint []byte) accounts.Notifier {
+	return &notifierForAccount{
+		db:              notifier.db,
+		accountCode:     accountCode,
+		rootFingerprint: rootFingerprint,
+	}
+}
+
+func accountBucketName(accountCode accountsTypes.Code) []byte {
+	return []byte(fmt.Sprintf("account-%s", accountCode))
 }
 
-// ForAccount returns a Notifier for a specific account.
-func (notifier *Notifier) ForAccount(accountCode accountsTypes.Code) accounts.Notifier {
-	return &notifierForAccount{db: notifier.db, accountCode: accountCode}
+func keystoreBucketName(rootFingerprint []byte) []byte {
+	return []byte(fmt.Sprintf("keystore-%x", rootFingerprint))
 }
 
 func (notifier *notifierForAccount) write(
@@ -64,7 +90,7 @@ func (notifier *notifierForAccount) write(
 		return errp.WithStack(err)
 	}
 	defer func() { _ = tx.Rollback() }()
-	bucketAccount, err := tx.CreateBucketIfNotExists([]byte(fmt.Sprintf("account-%s", notifier.accountCode)))
+	bucketAccount, err := tx.CreateBucketIfNotExists(accountBucketName(notifier.accountCode))
 	if err != nil {
 		return errp.WithStack(err)
 	}
@@ -89,7 +115,7 @@ func (notifier *notifierForAccount) read(
 		return errp.WithStack(err)
 	}
 	defer func() { _ = tx.Rollback() }()
-	bucketAccount := tx.Bucket([]byte(fmt.Sprintf("account-%s", notifier.accountCode)))
+	bucketAccount := tx.Bucket(accountBucketName(notifier.accountCode))
 	if bucketAccount == nil {
 		f(nil, nil)
 	} else {
@@ -145,6 +171,22 @@ func (notifier *notifierForAccount) UnnotifiedCount() (int, error) {
 	return unnotified, nil
 }
 
+// HasTransactions implements accounts.Notifier.
+func (notifier *notifierForAccount) HasTransactions() (bool, bool, error) {
+	tx, err := notifier.db.Begin(false)
+	if err != nil {
+		return false, false, errp.WithStack(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	hasTransactions := func(bucketName []byte) bool {
+		bucket := tx.Bucket(bucketName)
+		return bucket != nil && bucket.Get([]byte(keyHasTransactions)) != nil
+	}
+	return hasTransactions(accountBucketName(notifier.accountCode)),
+		hasTransactions(keystoreBucketName(notifier.rootFingerprint)),
+		nil
+}
+
 // MarkAllNotified implements accounts.Notifier.
 func (notifier *notifierForAccount) MarkAllNotified() error {
 	return notifier.write(func(bucketUnnotified, bucketSeen *bbolt.Bucket) error {
@@ -160,3 +202,105 @@ func (notifier *notifierForAccount) MarkAllNotified() error {
 		return nil
 	})
 }
+
+// newTransactionEvents returns the transactions for which EventNewTransaction should be emitted:
+// transactions which were not seen before, and transactions which got their first confirmation.
+// They are recorded so that each event is only emitted once, also across restarts and rescans.
+// When called for the first time for an account, all transactions are recorded without returning
+// them, so that the transaction history of a newly added or restored account does not result in an
+// event per transaction.
+func (notifier *Notifier) newTransactionEvents(
+	accountCode accountsTypes.Code, txs []*accounts.TransactionData) ([]*accounts.TransactionData, error) {
+	tx, err := notifier.db.Begin(true)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	bucketAccount, err := tx.CreateBucketIfNotExists(accountBucketName(accountCode))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	initialized := bucketAccount.Bucket([]byte(bucketTxEventsKey)) != nil
+	bucketTxEvents, err := bucketAccount.CreateBucketIfNotExists([]byte(bucketTxEventsKey))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result := []*accounts.TransactionData{}
+	for _, txData := range txs {
+		id := []byte(txData.InternalID)
+		stage := txEventUnconfirmed
+		if txData.NumConfirmations > 0 {
+			stage = txEventConfirmed
+		}
+		previous := bucketTxEvents.Get(id)
+		if previous != nil && previous[0] >= stage {
+			continue
+		}
+		if err := bucketTxEvents.Put(id, []byte{stage}); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if initialized {
+			result = append(result, txData)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return result, nil
+}
+
+// firstTransaction is the result of recordHasTransactions.
+type firstTransaction struct {
+	// account is true if the first transaction of the account just arrived.
+	account bool
+	// keystore is true if no account of the keystore had a transaction before.
+	keystore bool
+}
+
+// recordHasTransactions records that the account and its keystore have transactions, if `txs` is
+// not empty. `newTxs` are the transactions newTransactionEvents returned for `txs`. The first
+// transaction of the account is only reported if it is a new arrival, i.e. if all transactions are
+// new, so that the discovered history of a restored account does not count. Once recorded, the
+// first transaction is never reported again.
+func (notifier *Notifier) recordHasTransactions(
+	accountCode accountsTypes.Code,
+	rootFingerprint []byte,
+	txs []*accounts.TransactionData,
+	newTxs []*accounts.TransactionData,
+) (*firstTransaction, error) {
+	if len(txs) == 0 {
+		return &firstTransaction{}, nil
+	}
+	tx, err := notifier.db.Begin(true)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	// record sets the flag in the bucket and returns true if it was not set before.
+	record := func(bucketName []byte) (bool, error) {
+		bucket, err := tx.CreateBucketIfNotExists(bucketName)
+		if err != nil {
+			return false, errp.WithStack(err)
+		}
+		if bucket.Get([]byte(keyHasTransactions)) != nil {
+			return false, nil
+		}
+		return true, errp.WithStack(bucket.Put([]byte(keyHasTransactions), []byte{1}))
+	}
+	firstForAccount, err := record(accountBucketName(accountCode))
+	if err != nil {
+		return nil, err
+	}
+	firstForKeystore, err := record(keystoreBucketName(rootFingerprint))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	isNewArrival := len(newTxs) > 0 && len(newTxs) == len(txs)
+	return &firstTransaction{
+		account:  firstForAccount && isNewArrival,
+		keystore: firstForAccount && isNewArrival && firstForKeystore,
+	}, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransactionEvents(t *testing.T) {
+	filename := test.TstTempFile("notifier-")
+	notifier, err := NewNotifier(filename)
+	require.NoError(t, err)
+
+	tx := func(id string, numConfirmations int) *accounts.TransactionData {
+		return &accounts.TransactionData{TxID: id, InternalID: id, NumConfirmations: numConfirmations}
+	}
+	ids := func(txs []*accounts.TransactionData) []string {
+		result := []string{}
+		for _, tx := range txs {
+			result = append(result, tx.InternalID)
+		}
+		return result
+	}
+
+	// The history of a restored account does not produce events.
+	history := []*accounts.TransactionData{}
+	for i := 0; i < 500; i++ {
+		history = append(history, tx(fmt.Sprintf("old-%d", i), 100))
+	}
+	history = append(history, tx("old-unconfirmed", 0))
+	newTxs, err := notifier.newTransactionEvents("account-code", history)
+	require.NoError(t, err)
+	require.Empty(t, newTxs)
+
+	// A new unconfirmed tx, and the old unconfirmed tx confirms.
+	txs := append(history[:500:500], tx("new", 0), tx("old-unconfirmed", 1))
+	newTxs, err = notifier.newTransactionEvents("account-code", txs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"new", "old-unconfirmed"}, ids(newTxs))
+
+	// No change, no events.
+	newTxs, err = notifier.newTransactionEvents("account-code", txs)
+	require.NoError(t, err)
+	require.Empty(t, newTxs)
+
+	// The events are remembered across restarts.
+	require.NoError(t, notifier.Close())
+	notifier, err = NewNotifier(filename)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, notifier.Close()) }()
+	txs = append(txs, tx("new-confirmed", 1))
+	txs[500] = tx("new", 2)
+	newTxs, err = notifier.newTransactionEvents("account-code", txs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"new", "new-confirmed"}, ids(newTxs))
+
+	// A reorg does not make the confirmation event fire again.
+	txs[500] = tx("new", 0)
+	newTxs, err = notifier.newTransactionEvents("account-code", txs)
+	require.NoError(t, err)
+	require.Empty(t, newTxs)
+	txs[500] = tx("new", 1)
+	newTxs, err = notifier.newTransactionEvents("account-code", txs)
+	require.NoError(t, err)
+	require.Empty(t, newTxs)
+
+	// Other accounts are independent.
+	newTxs, err = notifier.newTransactionEvents("other-account-code", txs)
+	require.NoError(t, err)
+	require.Empty(t, newTxs)
+}
+
+func TestRecordHasTransactions(t *testing.T) {
+	filename := test.TstTempFile("notifier-")
+	notifier, err := NewNotifier(filename)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, notifier.Close()) }()
+
+	fingerprint := []byte{1, 2, 3, 4}
+	tx := func(id string) *accounts.TransactionData {
+		return &accounts.TransactionData{TxID: id, InternalID: id}
+	}
+	record := func(code accountsTypes.Code, txs []*accounts.TransactionData) *firstTransaction {
+		newTxs, err := notifier.newTransactionEvents(code, txs)
+		require.NoError(t, err)
+		first, err := notifier.recordHasTransactions(code, fingerprint, txs, newTxs)
+		require.NoError(t, err)
+		return first
+	}
+	hasTransactions := func(code accountsTypes.Code) (bool, bool) {
+		account, keystore, err := notifier.ForAccount(code, fingerprint).HasTransactions()
+		require.NoError(t, err)
+		return account, keystore
+	}
+
+	// A new, empty account.
+	require.Equal(t, &firstTransaction{}, record("account-1", nil))
+	account, keystore := hasTransactions("account-1")
+	require.False(t, account)
+	require.False(t, keystore)
+
+	// The first tx arrives.
+	txs := []*accounts.TransactionData{tx("tx-1")}
+	require.Equal(t, &firstTransaction{account: true, keystore: true}, record("account-1", txs))
+	account, keystore = hasTransactions("account-1")
+	require.True(t, account)
+	require.True(t, keystore)
+
+	// Only fired once.
+	txs = append(txs, tx("tx-2"))
+	require.Equal(t, &firstTransaction{}, record("account-1", txs))
+
+	// The first tx of another account of the same keystore.
+	require.Equal(t, &firstTransaction{}, record("account-2", nil))
+	account, keystore = hasTransactions("account-2")
+	require.False(t, account)
+	require.True(t, keystore)
+	require.Equal(t,
+		&firstTransaction{account: true, keystore: false},
+		record("account-2", []*accounts.TransactionData{tx("tx-3")}))
+
+	// The history of a restored account does not count.
+	require.Equal(t, &firstTransaction{}, record("account-3", txs))
+	account, _ = hasTransactions("account-3")
+	require.True(t, account)
+
+	// An account which already had transactions before the flag was recorded.
+	require.Equal(t, &firstTransaction{}, record("account-4", nil))
+	_, err = notifier.newTransactionEvents("account-4", txs)
+	require.NoError(t, err)
+	require.Equal(t, &firstTransaction{}, record("account-4", append(txs, tx("tx-4"))))
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"math"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	// HistoricalRatesEventSubject is the Subject of the event generated when daily rates requested
+	// by HistoricalRate have been fetched.
+	HistoricalRatesEventSubject = "rates/historical"
+
+	// dailyBucketPrefix prefixes the historyDB buckets containing the daily rates, e.g.
+	// "daily-btcUSD".
+	dailyBucketPrefix = "daily-"
+
+	day = 24 * time.Hour
+
+	// maxDailyFetches is the maximum number of coin/fiat pairs whose daily rates are fetched
+	// concurrently.
+	maxDailyFetches = 2
+	// dailyRetryInterval is the time after which daily rates are fetched again after a failure. It
+	// doubles with every failure in a row, up to maxDailyRetryInterval.
+	dailyRetryInterval    = 5 * time.Second
+	maxDailyRetryInterval = 10 * time.Minute
+)
+
+// HistoricalRate returns the exchange rate of the given coin/fiat pair at the given time. The
+// second result is false if no historical rate is available, in which case the caller should fall
+// back to the latest rate.
+//
+// The hourly history maintained by ReconfigureHistory is used if it covers the given time.
+// Otherwise, the daily rate of the day (UTC) is used. Daily rates are fetched lazily in the
+// background the first time they are requested and are persisted in the history database, so
+// HistoricalRate never blocks on the network. The days requested for a coin/fiat pair are fetched
+// together in one request, see fetchDailyRates. A HistoricalRatesEventSubject event is fired once
+// the requested rates have been fetched.
+func (updater *RateUpdater) HistoricalRate(coin, fiat string, at time.Time) (float64, bool) {
+	if value := updater.HistoricalPriceAt(coin, fiat, at); value != 0 {
+		return value, true
+	}
+	dayStart := at.UTC().Truncate(day)
+	// The rate of the current day is not final yet and is covered by the hourly history.
+	if dayStart.Add(day).After(time.Now()) {
+		return 0, false
+	}
+	// Without a HTTP client, e.g. in MockRateUpdater, no rates can be fetched.
+	if updater.httpClient == nil || geckoCoin[coin] == "" || toGeckoFiat[fiat] == "" {
+		return 0, false
+	}
+
+	key := coin + fiat
+	updater.dailyMu.Lock()
+	defer updater.dailyMu.Unlock()
+	rates, ok := updater.daily[key]
+	if !ok {
+		rates = map[int64]float64{}
+		persisted, err := updater.loadHistoryBucket(dailyBucketPrefix + key)
+		if err != nil {
+			// Non-critical: the rates are fetched again.
+			updater.log.Errorf("loadHistoryBucket(%q): %v", dailyBucketPrefix+key, err)
+		}
+		for _, rate := range persisted {
+			rates[rate.timestamp.Unix()] = rate.value
+		}
+		updater.daily[key] = rates
+	}
+	// A zero value means the rates API has no data for this day.
+	if value, ok := rates[dayStart.Unix()]; ok {
+		return value, value != 0
+	}
+	pending, ok := updater.dailyPending[key]
+	if !ok {
+		pending = map[int64]struct{}{}
+		updater.dailyPending[key] = pending
+	}
+	pending[dayStart.Unix()] = struct{}{}
+	if _, ok := updater.dailyFetching[key]; !ok {
+		updater.dailyFetching[key] = struct{}{}
+		go updater.fetchDailyRates(coin, fiat)
+	}
+	return 0, false
+}
+
+// fetchDailyRates fetches the pending daily rates of the coin/fiat pair until there are none left.
+// The pending days are fetched in one request per maxGeckoRange. Failed requests are retried with
+// an exponential backoff.
+func (updater *RateUpdater) fetchDailyRates(coin, fiat string) {
+	key := coin + fiat
+	retryInterval := updater.dailyRetryInterval
+	for {
+		updater.dailyMu.Lock()
+		pending := updater.dailyPending[key]
+		if len(pending) == 0 {
+			delete(updater.dailyFetching, key)
+			// Notify only once all rates requested in one go are fetched to avoid flooding the
+			// frontend with reloads.
+			notify := len(updater.dailyFetching) == 0 && updater.dailyUpdated
+			if notify {
+				updater.dailyUpdated = false
+			}
+			updater.dailyMu.Unlock()
+			if notify {
+				updater.Notify(observable.Event{
+					Subject: HistoricalRatesEventSubject,
+					Action:  action.Reload,
+				})
+			}
+			return
+		}
+		first, last := int64(math.MaxInt64), int64(math.MinInt64)
+		for dayStart := range pending {
+			if dayStart < first {
+				first = dayStart
+			}
+			if dayStart > last {
+				last = dayStart
+			}
+		}
+		updater.dailyMu.Unlock()
+
+		start := time.Unix(first, 0).UTC()
+		end := time.Unix(last, 0).UTC().Add(day)
+		if end.Sub(start) > maxGeckoRange {
+			end = start.Add(maxGeckoRange)
+		}
+		err := updater.fetchDailyRange(coin, fiat, start, end)
+		if err == nil {
+			retryInterval = updater.dailyRetryInterval
+			continue
+		}
+		if updater.dailyCtx.Err() != nil {
+			return
+		}
+		updater.log.Errorf("fetchDailyRange(%s, %s, %s, %s): %v", coin, fiat, start, end, err)
+		select {
+		case <-updater.dailyCtx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+		retryInterval *= 2
+		if retryInterval > maxDailyRetryInterval {
+			retryInterval = maxDailyRetryInterval
+		}
+	}
+}
+
+// fetchDailyRange fetches and stores the average rates of the days in [start, end). The pending
+// days in this range are not pending anymore afterwards, even if there are no rates for them.
+func (updater *RateUpdater) fetchDailyRange(coin, fiat string, start, end time.Time) error {
+	select {
+	case updater.dailyFetchSem <- struct{}{}:
+	case <-updater.dailyCtx.Done():
+		return updater.dailyCtx.Err()
+	}
+	fetchedRates, err := updater.fetchGeckoMarketRange(
+		updater.dailyCtx, coin, fiat, fixedTimeRange(start, end))
+	<-updater.dailyFetchSem
+	if err != nil {
+		return err
+	}
+
+	sums := map[int64]float64{}
+	counts := map[int64]int{}
+	for _, rate := range fetchedRates {
+		dayStart := rate.timestamp.UTC().Truncate(day).Unix()
+		sums[dayStart] += rate.value
+		counts[dayStart]++
+	}
+
+	key := coin + fiat
+	var persist []exchangeRate
+	updater.dailyMu.Lock()
+	pending := updater.dailyPending[key]
+	for dayStart := start; dayStart.Before(end); dayStart = dayStart.Add(day) {
+		unix := dayStart.Unix()
+		var value float64
+		if counts[unix] > 0 {
+			value = sums[unix] / float64(counts[unix])
+		}
+		_, requested := pending[unix]
+		delete(pending, unix)
+		if value == 0 && !requested {
+			continue
+		}
+		updater.daily[key][unix] = value
+		// Gaps are not persisted, so they are retried after a restart.
+		if value != 0 {
+			persist = append(persist, exchangeRate{value: value, timestamp: dayStart})
+		}
+	}
+	updater.dailyUpdated = true
+	updater.dailyMu.Unlock()
+
+	if len(persist) > 0 {
+		if err := updater.dumpHistoryBucket(dailyBucketPrefix+key, persist); err != nil {
+			// Non-critical: can continue without persistent DB.
+			updater.log.Errorf("dumpHistoryBucket(%q): %v", dailyBucketPrefix+key, err)
+		}
+	}
+	return nil
+}
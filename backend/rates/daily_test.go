@@ -0,0 +1,149 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoricalRate(t *testing.T) {
+	day1 := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC) // gap in the rates API
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/coins/bitcoin/market_chart/range", r.URL.Path, "URL path")
+		from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		assert.NoError(t, err)
+		to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		assert.NoError(t, err)
+		// Both days are fetched in one request.
+		assert.Equal(t, day1.Unix(), from)
+		assert.Equal(t, day2.Add(24*time.Hour).Unix(), to)
+		fmt.Fprintf(w, `{"prices": [[%d, 100.0], [%d, 200.0]]}`,
+			day1.Add(time.Hour).Unix()*1000, day1.Add(2*time.Hour).Unix()*1000)
+	}))
+	defer ts.Close()
+
+	dbdir := test.TstTempDir("TestHistoricalRate")
+	defer os.RemoveAll(dbdir)
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	updater.coingeckoURL = ts.URL
+	updater.history = map[string][]exchangeRate{
+		"btcUSD": {
+			{value: 2, timestamp: time.Date(2020, 9, 10, 0, 0, 0, 0, time.UTC)},
+			{value: 3, timestamp: time.Date(2020, 9, 11, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	notified := make(chan struct{}, 10)
+	updater.Observe(func(event observable.Event) {
+		if event.Subject == HistoricalRatesEventSubject {
+			notified <- struct{}{}
+		}
+	})
+
+	// Covered by the hourly history.
+	value, ok := updater.HistoricalRate("btc", "USD", time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	require.Equal(t, 2.5, value)
+
+	// Not available yet, fetched in the background.
+	_, ok = updater.HistoricalRate("btc", "USD", day1.Add(12*time.Hour))
+	require.False(t, ok)
+	_, ok = updater.HistoricalRate("btc", "USD", day2.Add(12*time.Hour))
+	require.False(t, ok)
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no event")
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	value, ok = updater.HistoricalRate("btc", "USD", day1.Add(23*time.Hour))
+	require.True(t, ok)
+	require.Equal(t, 150.0, value)
+	// Gaps are not fetched again.
+	_, ok = updater.HistoricalRate("btc", "USD", day2)
+	require.False(t, ok)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Very recent rates are not fetched.
+	_, ok = updater.HistoricalRate("btc", "USD", time.Now())
+	require.False(t, ok)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	updater.Stop()
+
+	// The daily rates are persisted.
+	updater2 := NewRateUpdater(http.DefaultClient, dbdir)
+	defer updater2.Stop()
+	updater2.coingeckoURL = "unused"
+	value, ok = updater2.HistoricalRate("btc", "USD", day1)
+	require.True(t, ok)
+	require.Equal(t, 150.0, value)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestHistoricalRateRetry(t *testing.T) {
+	day1 := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first two requests fail.
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"prices": [[%d, 100.0]]}`, day1.Add(time.Hour).Unix()*1000)
+	}))
+	defer ts.Close()
+
+	dbdir := test.TstTempDir("TestHistoricalRateRetry")
+	defer os.RemoveAll(dbdir)
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	defer updater.Stop()
+	updater.coingeckoURL = ts.URL
+	updater.dailyRetryInterval = time.Millisecond
+	notified := make(chan struct{}, 10)
+	updater.Observe(func(event observable.Event) {
+		if event.Subject == HistoricalRatesEventSubject {
+			notified <- struct{}{}
+		}
+	})
+
+	_, ok := updater.HistoricalRate("btc", "USD", day1)
+	require.False(t, ok)
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no event")
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	value, ok := updater.HistoricalRate("btc", "USD", day1)
+	require.True(t, ok)
+	require.Equal(t, 100.0, value)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"sort"
+	"strings"
+)
+
+// regionFiat maps ISO 3166-1 regions to the fiat currency used there, for all supported fiat
+// currencies.
+var regionFiat = map[string]Fiat{
+	"US": USD, "CH": CHF, "LI": CHF, "GB": GBP, "JP": JPY, "KR": KRW, "CN": CNY, "RU": RUB,
+	"CA": CAD, "AU": AUD, "IL": ILS, "SG": SGD, "HK": HKD, "BR": BRL, "NO": NOK, "SE": SEK,
+	"PL": PLN, "CZ": CZK,
+	"AT": EUR, "BE": EUR, "CY": EUR, "DE": EUR, "EE": EUR, "ES": EUR, "FI": EUR, "FR": EUR,
+	"GR": EUR, "HR": EUR, "IE": EUR, "IT": EUR, "LT": EUR, "LU": EUR, "LV": EUR, "MT": EUR,
+	"NL": EUR, "PT": EUR, "SI": EUR, "SK": EUR,
+}
+
+// languageFiat maps languages to a fiat currency for locales without a region, for languages
+// which are mostly spoken in one currency area.
+var languageFiat = map[string]Fiat{
+	"ja": JPY, "ko": KRW, "zh": CNY, "ru": RUB, "he": ILS, "nb": NOK, "no": NOK, "sv": SEK,
+	"pl": PLN, "cs": CZK, "de": EUR, "fr": EUR, "it": EUR, "es": EUR, "nl": EUR, "fi": EUR,
+}
+
+// FiatFromLocale returns the fiat currency matching the given locale, e.g. "de-CH", "de_CH.UTF-8"
+// or "ja". USD is returned if the locale is unknown or its currency is not supported.
+func FiatFromLocale(locale string) Fiat {
+	locale = strings.SplitN(locale, ".", 2)[0]
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 {
+		return USD
+	}
+	// The region is the first two letter part after the language, e.g. "CH" in "de-Latn-CH".
+	for _, part := range parts[1:] {
+		if len(part) != 2 {
+			continue
+		}
+		if fiat, ok := regionFiat[strings.ToUpper(part)]; ok {
+			return fiat
+		}
+		return USD
+	}
+	if fiat, ok := languageFiat[strings.ToLower(parts[0])]; ok {
+		return fiat
+	}
+	return USD
+}
+
+// IsSupportedFiat returns true if rates can be fetched for the given fiat currency.
+func IsSupportedFiat(fiat string) bool {
+	_, ok := toGeckoFiat[fiat]
+	return ok
+}
+
+// SetActiveFiats sets the fiat currencies the latest rates are fetched for. If the currencies
+// changed, the latest rates are refreshed immediately, which fires a RatesEventSubject event.
+// If no fiat currencies are set, the rates of all supported currencies are fetched.
+func (updater *RateUpdater) SetActiveFiats(fiats []string) {
+	fiats = append([]string(nil), fiats...)
+	sort.Strings(fiats)
+	updater.fiatsMu.Lock()
+	changed := strings.Join(fiats, ",") != strings.Join(updater.fiats, ",")
+	updater.fiats = fiats
+	updater.fiatsMu.Unlock()
+	if !changed {
+		return
+	}
+	select {
+	case updater.refresh <- struct{}{}:
+	default:
+		// A refresh is already pending.
+	}
+}
+
+// geckoVsCurrencies returns the CoinGecko currencies the latest rates are fetched for.
+func (updater *RateUpdater) geckoVsCurrencies() string {
+	updater.fiatsMu.RLock()
+	defer updater.fiatsMu.RUnlock()
+	if len(updater.fiats) == 0 {
+		return simplePriceAllCurrencies
+	}
+	var currencies []string
+	seen := map[string]struct{}{}
+	for _, fiat := range updater.fiats {
+		geckoFiat, ok := toGeckoFiat[fiat]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[geckoFiat]; ok {
+			continue
+		}
+		seen[geckoFiat] = struct{}{}
+		currencies = append(currencies, geckoFiat)
+	}
+	return strings.Join(currencies, ",")
+}
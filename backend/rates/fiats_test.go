@@ -0,0 +1,55 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiatFromLocale(t *testing.T) {
+	for locale, fiat := range map[string]Fiat{
+		"de-CH":       CHF,
+		"de_CH.UTF-8": CHF,
+		"de":          EUR,
+		"en-GB":       GBP,
+		"en":          USD,
+		"en-US":       USD,
+		"en-IN":       USD,
+		"ja":          JPY,
+		"zh-Hans-HK":  HKD,
+		"":            USD,
+		"C":           USD,
+	} {
+		require.Equal(t, fiat, FiatFromLocale(locale), locale)
+	}
+}
+
+func TestGeckoVsCurrencies(t *testing.T) {
+	updater := NewRateUpdater(nil, "/dev/null")
+	defer updater.Stop()
+	require.Equal(t, simplePriceAllCurrencies, updater.geckoVsCurrencies())
+
+	updater.SetActiveFiats([]string{"EUR", "sat", "BTC", "unknown"})
+	require.Equal(t, "btc,eur", updater.geckoVsCurrencies())
+	// A refresh is triggered.
+	require.Len(t, updater.refresh, 1)
+
+	// Unchanged, no refresh.
+	<-updater.refresh
+	updater.SetActiveFiats([]string{"BTC", "EUR", "sat", "unknown"})
+	require.Len(t, updater.refresh, 0)
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+)
+
+const (
+	// latestRatesFilename is the file in the rates cache directory the latest rates are persisted
+	// in, so they are available when starting the app offline.
+	latestRatesFilename = "latest-rates.json"
+
+	// DefaultMaxLatestRatesAge is the default age after which the latest rates are treated as
+	// unavailable, see SetMaxLatestRatesAge.
+	DefaultMaxLatestRatesAge = 7 * 24 * time.Hour
+
+	// latestRatesStaleAfter is the age after which the latest rates are reported as stale. The rates
+	// are updated every `interval` while online.
+	latestRatesStaleAfter = 5 * interval
+)
+
+// persistedLatestRates is the JSON data of the latest rates file.
+type persistedLatestRates struct {
+	Timestamp time.Time                     `json:"timestamp"`
+	Rates     map[string]map[string]float64 `json:"rates"`
+}
+
+// LatestRates is the object of the RatesEventSubject events.
+type LatestRates struct {
+	// Rates is keyed by a crypto coin with values mapped by fiat rates, like LatestPrice(). Nil if no
+	// rates are available.
+	Rates map[string]map[string]float64 `json:"rates"`
+	// Timestamp is the time the rates were fetched. It can be long ago if the rates were loaded from
+	// disk or could not be updated since, see Stale.
+	Timestamp time.Time `json:"timestamp"`
+	// Stale is true if the rates could not be updated recently, e.g. when offline.
+	Stale bool `json:"stale"`
+}
+
+// RatesUpdateFailed is the object of the RatesUpdateFailedEventSubject events.
+type RatesUpdateFailed struct {
+	// Error is the reason the rates could not be fetched.
+	Error string `json:"error"`
+	// RetryInterval is the number of seconds after which fetching the rates is retried.
+	RetryInterval int `json:"retryInterval"`
+}
+
+// SetMaxLatestRatesAge sets the age after which the latest rates are treated as unavailable
+// instead of being shown. Values of 0 or less restore DefaultMaxLatestRatesAge.
+// SetMaxLatestRatesAge is unsafe for concurrent use.
+func (updater *RateUpdater) SetMaxLatestRatesAge(maxAge time.Duration) {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxLatestRatesAge
+	}
+	updater.maxLatestAge = maxAge
+}
+
+// isLatestStale returns true if the latest rates were not updated recently. The caller must hold
+// lastMu.
+func (updater *RateUpdater) isLatestStale() bool {
+	return time.Since(updater.lastUpdated) > latestRatesStaleAfter
+}
+
+// latestRates returns the object of RatesEventSubject events.
+func (updater *RateUpdater) latestRates() *LatestRates {
+	updater.lastMu.RLock()
+	defer updater.lastMu.RUnlock()
+	return &LatestRates{
+		Rates:     updater.latestPrice(),
+		Timestamp: updater.lastUpdated,
+		Stale:     updater.isLatestStale(),
+	}
+}
+
+func (updater *RateUpdater) notifyLatest() {
+	updater.Notify(observable.Event{
+		Subject: RatesEventSubject,
+		Action:  action.Replace,
+		Object:  updater.latestRates(),
+	})
+}
+
+// onUpdateLastFailed is called when the latest rates could not be fetched. The previous rates are
+// kept, as they remain usable until they are older than the max age. Every failure is notified
+// under RatesUpdateFailedEventSubject, so the frontend can show that the rates are temporarily
+// unavailable until the next RatesEventSubject event. The first failure in a row is also notified
+// under RatesEventSubject so that the staleness can be shown.
+func (updater *RateUpdater) onUpdateLastFailed(err error) {
+	updater.Notify(observable.Event{
+		Subject: RatesUpdateFailedEventSubject,
+		Action:  action.Replace,
+		Object: &RatesUpdateFailed{
+			Error:         err.Error(),
+			RetryInterval: int(interval.Seconds()),
+		},
+	})
+	updater.lastMu.Lock()
+	alreadyFailed := updater.lastFailed
+	updater.lastFailed = true
+	updater.lastMu.Unlock()
+	if alreadyFailed {
+		return
+	}
+	updater.notifyLatest()
+}
+
+// loadLatest loads the persisted latest rates. They are discarded if they are older than the max
+// age.
+func (updater *RateUpdater) loadLatest() error {
+	contents, err := os.ReadFile(updater.latestRatesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errp.WithStack(err)
+	}
+	var persisted persistedLatestRates
+	if err := json.Unmarshal(contents, &persisted); err != nil {
+		return errp.WithStack(err)
+	}
+	if persisted.Rates == nil || time.Since(persisted.Timestamp) > updater.maxLatestAge {
+		return nil
+	}
+	updater.last = persisted.Rates
+	updater.lastUpdated = persisted.Timestamp
+	return nil
+}
+
+// persistLatest writes the latest rates to disk. The caller must hold lastMu.
+func (updater *RateUpdater) persistLatest() error {
+	contents, err := json.Marshal(persistedLatestRates{
+		Timestamp: updater.lastUpdated,
+		Rates:     updater.last,
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	// Write to a temporary file first so that a crash does not leave a truncated file behind.
+	tmpFile := updater.latestRatesFile + ".tmp"
+	if err := os.WriteFile(tmpFile, contents, 0600); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := os.Rename(tmpFile, updater.latestRatesFile); err != nil {
+		return errp.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestRatesPersisted(t *testing.T) {
+	var failing atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, `{"bitcoin": {"usd": 20000.0}}`)
+	}))
+	defer ts.Close()
+
+	dbdir := test.TstTempDir("TestLatestRatesPersisted")
+	defer os.RemoveAll(dbdir)
+
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	updater.coingeckoURL = ts.URL
+	require.Nil(t, updater.LatestPrice())
+
+	events := make(chan *LatestRates, 10)
+	failedEvents := make(chan *RatesUpdateFailed, 10)
+	updater.Observe(func(event observable.Event) {
+		switch event.Subject {
+		case RatesEventSubject:
+			events <- event.Object.(*LatestRates)
+		case RatesUpdateFailedEventSubject:
+			failedEvents <- event.Object.(*RatesUpdateFailed)
+		}
+	})
+
+	updater.updateLast(context.Background())
+	event := <-events
+	require.False(t, event.Stale)
+	require.Equal(t, 20000.0, event.Rates["BTC"]["USD"])
+
+	// Failing to update keeps the rates and notifies once.
+	failing.Store(true)
+	updater.updateLast(context.Background())
+	updater.updateLast(context.Background())
+	event = <-events
+	require.Equal(t, 20000.0, event.Rates["BTC"]["USD"])
+	require.Len(t, events, 0)
+	require.Equal(t, 20000.0, updater.LatestPrice()["BTC"]["USD"])
+	// Every failure is notified with its reason.
+	require.Len(t, failedEvents, 2)
+	failed := <-failedEvents
+	require.Contains(t, failed.Error, "bad response code")
+	require.Equal(t, 60, failed.RetryInterval)
+
+	updater.Stop()
+
+	// The rates are loaded when starting offline.
+	offlineUpdater := NewRateUpdater(http.DefaultClient, dbdir)
+	defer offlineUpdater.Stop()
+	require.Equal(t, 20000.0, offlineUpdater.LatestPrice()["BTC"]["USD"])
+	require.False(t, offlineUpdater.latestRates().Stale)
+
+	// Rates older than the max age are unavailable.
+	offlineUpdater.SetMaxLatestRatesAge(time.Nanosecond)
+	require.Nil(t, offlineUpdater.LatestPrice())
+}
+
+func TestLatestRatesStale(t *testing.T) {
+	dbdir := test.TstTempDir("TestLatestRatesStale")
+	defer os.RemoveAll(dbdir)
+
+	write := func(timestamp time.Time) {
+		contents, err := json.Marshal(persistedLatestRates{
+			Timestamp: timestamp,
+			Rates:     map[string]map[string]float64{"BTC": {"USD": 1}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dbdir, latestRatesFilename), contents, 0600))
+	}
+
+	threeHoursAgo := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	write(threeHoursAgo)
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	latest := updater.latestRates()
+	require.True(t, latest.Stale)
+	require.True(t, threeHoursAgo.Equal(latest.Timestamp))
+	require.Equal(t, 1.0, latest.Rates["BTC"]["USD"])
+	updater.Stop()
+
+	write(time.Now().Add(-DefaultMaxLatestRatesAge - time.Hour))
+	updater = NewRateUpdater(http.DefaultClient, dbdir)
+	require.Nil(t, updater.LatestPrice())
+	updater.Stop()
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	var rate atomic.Int64
+	rate.Store(20000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"bitcoin": {"usd": %d}}`, rate.Load())
+	}))
+	defer ts.Close()
+
+	dbdir := test.TstTempDir("TestLatestSnapshot")
+	defer os.RemoveAll(dbdir)
+
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	defer updater.Stop()
+	updater.coingeckoURL = ts.URL
+	require.Nil(t, updater.LatestSnapshot())
+
+	updater.updateLast(context.Background())
+	snapshot := updater.LatestSnapshot()
+	require.NotNil(t, snapshot)
+	require.Equal(t, 20000.0, snapshot.Rates["BTC"]["USD"])
+
+	// The snapshot is a copy.
+	snapshot.Rates["BTC"]["USD"] = 1
+	require.Equal(t, 20000.0, updater.LatestPrice()["BTC"]["USD"])
+
+	// Snapshots can be taken while the rates are updated.
+	rate.Store(30000)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		updater.updateLast(context.Background())
+	}()
+	_ = updater.LatestSnapshot()
+	<-done
+	newSnapshot := updater.LatestSnapshot()
+	require.Equal(t, 30000.0, newSnapshot.Rates["BTC"]["USD"])
+	require.NotEqual(t, snapshot.ID, newSnapshot.ID)
+	require.Equal(t, 1.0, snapshot.Rates["BTC"]["USD"])
+}
@@ -22,26 +22,31 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
-	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/ratelimit"
 	"github.com/sirupsen/logrus"
 	"go.etcd.io/bbolt"
 )
 
 const (
-	// Latest rates are fetched for all these (coin, fiat) pairs.
+	// Latest rates are fetched for all these (coin, fiat) pairs. Only the active fiat currencies
+	// are fetched if they are set, see SetActiveFiats.
 	simplePriceAllIDs        = "bitcoin,litecoin,ethereum,basic-attention-token,dai,chainlink,maker,usd-coin,tether,0x,wrapped-bitcoin,pax-gold"
 	simplePriceAllCurrencies = "usd,eur,chf,gbp,jpy,krw,cny,rub,cad,aud,ils,btc,sgd,hkd,brl,nok,sek,pln,czk"
 	// RatesEventSubject is the Subject of the event generated by new rates fetching.
 	RatesEventSubject = "rates"
+	// RatesUpdateFailedEventSubject is the Subject of the event generated when fetching the latest
+	// rates failed.
+	RatesUpdateFailedEventSubject = "rates/update-failed"
 
 	// ErrRatesNotAvailable is raised when the latest rates have note been fetched yet.
 	ErrRatesNotAvailable errp.ErrorCode = "ratesNotAvailable"
@@ -96,10 +101,28 @@ type RateUpdater struct {
 	httpClient *http.Client
 	log        *logrus.Entry
 
-	// last contains most recent conversion to fiat, keyed by a coin.
+	lastMu sync.RWMutex // guards last, lastVersion, lastUpdated and lastFailed
+	// last contains most recent conversion to fiat, keyed by a coin. The map is replaced, never
+	// modified, when the rates are updated.
 	last map[string]map[string]float64
+	// lastVersion is incremented each time last changes. It identifies the rates snapshot.
+	lastVersion uint64
+	// lastUpdated is the time last was fetched.
+	lastUpdated time.Time
+	// lastFailed is true if the last attempt to update last failed.
+	lastFailed bool
+	// maxLatestAge is the age after which last is treated as unavailable.
+	maxLatestAge time.Duration
+	// latestRatesFile is where last is persisted, see latestRatesFilename.
+	latestRatesFile string
 	// stopLastUpdateLoop is the cancel function of the lastUpdateLoop context.
 	stopLastUpdateLoop context.CancelFunc
+	// refresh triggers an immediate update of the latest rates in lastUpdateLoop.
+	refresh chan struct{}
+
+	fiatsMu sync.RWMutex // guards fiats
+	// fiats contains the fiat currencies the latest rates are fetched for, see SetActiveFiats.
+	fiats []string
 
 	// historyDB is an internal cached copy of history, transparent to the users.
 	// While RateUpdater can function without a valid historyDB,
@@ -120,6 +143,27 @@ type RateUpdater struct {
 	coingeckoURL string
 	// All requests to coingeckoURL are rate-limited using geckoLimiter.
 	geckoLimiter *ratelimit.LimitedCall
+
+	dailyMu sync.Mutex // guards daily, dailyPending, dailyFetching and dailyUpdated
+	// daily contains the daily rates fetched by HistoricalRate, keyed by coin+fiat pair and then by
+	// the unix timestamp of the start of the day (UTC). A zero rate means no data is available.
+	daily map[string]map[int64]float64
+	// dailyPending contains the days whose rates were requested but are not fetched yet, keyed by
+	// coin+fiat pair and then by the unix timestamp of the start of the day (UTC).
+	dailyPending map[string]map[int64]struct{}
+	// dailyFetching contains the coin+fiat pairs whose daily rates are being fetched, see
+	// fetchDailyRates.
+	dailyFetching map[string]struct{}
+	// dailyUpdated is true if daily rates were fetched since the last HistoricalRatesEventSubject
+	// event.
+	dailyUpdated bool
+	// dailyFetchSem limits the number of concurrent daily rates requests to maxDailyFetches.
+	dailyFetchSem chan struct{}
+	// dailyRetryInterval is the initial backoff after a failed daily rates request.
+	dailyRetryInterval time.Duration
+	// dailyCtx is the context of the daily rates requests, canceled by Stop.
+	dailyCtx  context.Context
+	stopDaily context.CancelFunc
 }
 
 // NewRateUpdater returns a new rates updater.
@@ -129,8 +173,9 @@ type RateUpdater struct {
 // To stay within acceptable rate limits defined by CoinGeckoRateLimit, callers can
 // use util/ratelimit package.
 //
-// Both Last and PriceAt of the newly created updater always return zero values
-// until data is fetched from the external APIs. To make the updater start fetching data
+// The latest rates persisted by a previous run are loaded from dbdir, so that LatestPrice is
+// available offline. Otherwise, both LatestPrice and HistoricalPriceAt of the newly created updater
+// return zero values until data is fetched from the external APIs. To make the updater start fetching data
 // the caller can use StartCurrentRates and ReconfigureHistory, respectively.
 //
 // The caller is advised to always call Stop as soon as the updater is no longer needed
@@ -147,16 +192,32 @@ func NewRateUpdater(client *http.Client, dbdir string) *RateUpdater {
 		db = &bbolt.DB{}
 	}
 	apiURL := shiftGeckoMirrorAPIV3
-	return &RateUpdater{
-		last:         make(map[string]map[string]float64),
-		history:      make(map[string][]exchangeRate),
-		historyGo:    make(map[string]context.CancelFunc),
-		historyDB:    db,
-		log:          log,
-		httpClient:   client,
-		coingeckoURL: apiURL,
-		geckoLimiter: ratelimit.NewLimitedCall(apiRateLimit(apiURL)),
+	dailyCtx, stopDaily := context.WithCancel(context.Background())
+	updater := &RateUpdater{
+		last:               make(map[string]map[string]float64),
+		history:            make(map[string][]exchangeRate),
+		historyGo:          make(map[string]context.CancelFunc),
+		historyDB:          db,
+		log:                log,
+		httpClient:         client,
+		coingeckoURL:       apiURL,
+		geckoLimiter:       ratelimit.NewLimitedCall(apiRateLimit(apiURL)),
+		daily:              make(map[string]map[int64]float64),
+		dailyPending:       make(map[string]map[int64]struct{}),
+		dailyFetching:      make(map[string]struct{}),
+		dailyFetchSem:      make(chan struct{}, maxDailyFetches),
+		dailyRetryInterval: dailyRetryInterval,
+		dailyCtx:           dailyCtx,
+		stopDaily:          stopDaily,
+		refresh:            make(chan struct{}, 1),
+		maxLatestAge:       DefaultMaxLatestRatesAge,
+
+		latestRatesFile: filepath.Join(dbdir, latestRatesFilename),
 	}
+	if err := updater.loadLatest(); err != nil {
+		log.WithError(err).Error("Could not load the persisted latest rates")
+	}
+	return updater
 }
 
 // SetCoingeckoURL overrides the default URL the rates updater connects to. Useful for testing.
@@ -164,13 +225,68 @@ func (updater *RateUpdater) SetCoingeckoURL(url string) {
 	updater.coingeckoURL = url
 }
 
+// TstRefreshLatest makes the loop started by StartCurrentRates fetch the latest rates right away
+// instead of waiting for the next interval. Only to be used in tests.
+func (updater *RateUpdater) TstRefreshLatest() {
+	select {
+	case updater.refresh <- struct{}{}:
+	default:
+		// A refresh is already pending.
+	}
+}
+
 // LatestPrice returns the most recent conversion rates.
 // The returned map is keyed by a crypto coin with values mapped by fiat rates.
 // RateUpdater assumes the returned value is never modified by the callers.
+// Returns nil if the rates are older than the max age, see SetMaxLatestRatesAge.
 func (updater *RateUpdater) LatestPrice() map[string]map[string]float64 {
+	updater.lastMu.RLock()
+	defer updater.lastMu.RUnlock()
+	return updater.latestPrice()
+}
+
+// latestPrice is LatestPrice without locking. The caller must hold lastMu.
+func (updater *RateUpdater) latestPrice() map[string]map[string]float64 {
+	if time.Since(updater.lastUpdated) > updater.maxLatestAge {
+		return nil
+	}
 	return updater.last
 }
 
+// Snapshot is an immutable copy of the latest rates at a point in time. It can be used to display
+// consistent fiat values over a period of time even if the latest rates change in the meantime.
+type Snapshot struct {
+	// ID identifies the rates update the snapshot was taken from.
+	ID string
+	// Timestamp is the time the rates were fetched.
+	Timestamp time.Time
+	// Rates is keyed by a crypto coin with values mapped by fiat rates, like LatestPrice().
+	Rates map[string]map[string]float64
+}
+
+// LatestSnapshot returns a snapshot of the most recent conversion rates. Returns nil if the rates
+// have not been fetched yet.
+func (updater *RateUpdater) LatestSnapshot() *Snapshot {
+	updater.lastMu.RLock()
+	defer updater.lastMu.RUnlock()
+	last := updater.latestPrice()
+	if last == nil {
+		return nil
+	}
+	rates := make(map[string]map[string]float64, len(last))
+	for coin, fiatRates := range last {
+		rates[coin] = make(map[string]float64, len(fiatRates))
+		for fiat, rate := range fiatRates {
+			rates[coin][fiat] = rate
+		}
+	}
+	return &Snapshot{
+		ID:        strconv.FormatUint(updater.lastVersion, 10),
+		Timestamp: updater.lastUpdated,
+		Rates:     rates,
+	}
+}
+
 // LatestPriceForPair returns the conversion rate for the given (coin, fiat) pair. Returns an error
 // if the rates have not been fetched yet. `coinUnit` values are the same as `coin.Unit`.
 func (updater *RateUpdater) LatestPriceForPair(coinUnit, fiat string) (float64, error) {
@@ -239,6 +355,7 @@ func (updater *RateUpdater) StartCurrentRates() {
 // Stop is unsafe for concurrent use.
 func (updater *RateUpdater) Stop() {
 	updater.stopAllHistory()
+	updater.stopDaily()
 	if updater.stopLastUpdateLoop != nil {
 		updater.stopLastUpdateLoop()
 	}
@@ -257,6 +374,8 @@ func (updater *RateUpdater) lastUpdateLoop(ctx context.Context) {
 			return
 		case <-time.After(interval):
 			// continue
+		case <-updater.refresh:
+			// continue
 		}
 	}
 }
@@ -264,13 +383,13 @@ func (updater *RateUpdater) lastUpdateLoop(ctx context.Context) {
 func (updater *RateUpdater) updateLast(ctx context.Context) {
 	param := url.Values{
 		"ids":           {simplePriceAllIDs},
-		"vs_currencies": {simplePriceAllCurrencies},
+		"vs_currencies": {updater.geckoVsCurrencies()},
 	}
 	endpoint := fmt.Sprintf("%s/simple/price?%s", updater.coingeckoURL, param.Encode())
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		updater.log.WithError(err).Error("could not create request")
-		updater.last = nil
+		updater.onUpdateLastFailed(err)
 		return
 	}
 
@@ -302,7 +421,7 @@ func (updater *RateUpdater) updateLast(ctx context.Context) {
 	})
 	if callErr != nil {
 		updater.log.WithError(callErr).Errorf("updateLast")
-		updater.last = nil
+		updater.onUpdateLastFailed(callErr)
 		return
 	}
 	// Convert the map with coingecko coin/fiat codes to a map of coin/fiat units.
@@ -347,13 +466,23 @@ func (updater *RateUpdater) updateLast(ctx context.Context) {
 		}
 	}
 
-	if reflect.DeepEqual(rates, updater.last) {
+	updater.lastMu.Lock()
+	changed := !reflect.DeepEqual(rates, updater.last)
+	// Unchanged rates are notified too if they were stale, so that the staleness is updated.
+	wasStale := updater.lastFailed || updater.isLatestStale()
+	updater.last = rates
+	updater.lastUpdated = time.Now()
+	updater.lastFailed = false
+	if changed {
+		updater.lastVersion++
+	}
+	err = updater.persistLatest()
+	updater.lastMu.Unlock()
+	if err != nil {
+		updater.log.WithError(err).Error("Could not persist the latest rates")
+	}
+	if !changed && !wasStale {
 		return
 	}
-	updater.last = rates
-	updater.Notify(observable.Event{
-		Subject: RatesEventSubject,
-		Action:  action.Replace,
-		Object:  rates,
-	})
+	updater.notifyLatest()
 }
@@ -0,0 +1,572 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/arguments"
+	electrumTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/software"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// The scenario runner executes scripted scenarios against a fully wired regtest backend: real
+// accounts, connected to two fake Electrum servers serving the same chain, a keystore which only
+// signs once the user approved on the device, and a fake rates API. A scenario is an ordered list
+// of steps, which drive the servers, the device, the rates and the user, or assert on the events
+// emitted by the backend and the state of the account.
+
+// scenarioTimeout is how long a step waits for the backend to react.
+const scenarioTimeout = 20 * time.Second
+
+// stubKeystore is a software keystore which blocks signing until the user approves or rejects on
+// the device, see approveOnDevice().
+type stubKeystore struct {
+	*software.Keystore
+	approvals chan bool
+}
+
+// SignTransaction implements keystore.Keystore.
+func (ks *stubKeystore) SignTransaction(proposal interface{}) error {
+	if !<-ks.approvals {
+		return keystore.ErrSigningAborted
+	}
+	return ks.Keystore.SignTransaction(proposal)
+}
+
+// fakeRates serves the simple price endpoint of the CoinGecko API.
+type fakeRates struct {
+	btcUSD float64
+	fail   bool
+	mu     sync.Mutex
+}
+
+func (rates *fakeRates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+	if rates.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]map[string]float64{
+		"bitcoin": {"usd": rates.btcUSD},
+	})
+}
+
+type scenarioEnv struct {
+	backend  *Backend
+	account  accounts.Interface
+	chain    *electrumTest.Chain
+	servers  []*electrumTest.Server
+	keystore *stubKeystore
+	rates    *fakeRates
+
+	// txs are the transactions of the scenario by their label.
+	txs map[string]*wire.MsgTx
+	// dropped is the server dropped by the last serverDrops step.
+	dropped *electrumTest.Server
+	// sendResult receives the result of SendTx started by a send step.
+	sendResult chan error
+
+	// events are the events emitted by the backend so far, see formatEvent().
+	events []string
+	// eventsChecked is the number of events matched by expectEvents steps so far.
+	eventsChecked int
+	eventsMu      sync.Mutex
+}
+
+// formatEvent formats events as "<subject>" or "<subject> <data>", e.g. "rates" or
+// "account syncdone". New transaction events also contain the direction and number of
+// confirmations, e.g. "account newTransaction receive 0".
+func formatEvent(event interface{}) string {
+	switch event := event.(type) {
+	case AccountEvent:
+		if meta, ok := event.Meta.(NewTransactionEventMeta); ok {
+			return fmt.Sprintf("%s %s %s %d", event.Type, event.Data, meta.Direction, meta.NumConfirmations)
+		}
+		return fmt.Sprintf("%s %s", event.Type, event.Data)
+	case backendEvent:
+		return fmt.Sprintf("%s %s", event.Type, event.Data)
+	case observable.Event:
+		return event.Subject
+	default:
+		return fmt.Sprintf("%T", event)
+	}
+}
+
+func (env *scenarioEnv) recordEvent(event interface{}) {
+	env.eventsMu.Lock()
+	defer env.eventsMu.Unlock()
+	env.events = append(env.events, formatEvent(event))
+}
+
+func newScenarioEnv(t *testing.T) *scenarioEnv {
+	t.Helper()
+	env := &scenarioEnv{
+		chain:      electrumTest.NewChain(&chaincfg.RegressionNetParams),
+		keystore:   &stubKeystore{Keystore: keystoreHelper1(), approvals: make(chan bool)},
+		rates:      &fakeRates{btcUSD: 30000},
+		txs:        map[string]*wire.MsgTx{},
+		sendResult: make(chan error, 1),
+	}
+	for i := 0; i < 2; i++ {
+		server, err := env.chain.NewServer()
+		require.NoError(t, err)
+		t.Cleanup(server.Close)
+		env.servers = append(env.servers, server)
+	}
+	ratesServer := httptest.NewServer(env.rates)
+	t.Cleanup(ratesServer.Close)
+
+	b, err := NewBackend(
+		arguments.NewArguments(
+			test.TstTempDir("scenario"),
+			true, true,
+			false,
+			&types.GapLimits{Receive: 20, Change: 6}),
+		environment{},
+	)
+	require.NoError(t, err)
+	env.backend = b
+	b.tstCheckAccountUsed = func(accounts.Interface) bool {
+		return false
+	}
+	b.ratesUpdater.SetCoingeckoURL(ratesServer.URL)
+	require.NoError(t, b.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.RBTC.ElectrumServers = []*config.ServerInfo{
+			env.servers[0].ServerInfo(),
+			env.servers[1].ServerInfo(),
+		}
+		return nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event := <-b.events:
+				env.recordEvent(event)
+			case <-done:
+				return
+			}
+		}
+	}()
+	unobserve := b.Observe(func(event observable.Event) { env.recordEvent(event) })
+	t.Cleanup(func() {
+		unobserve()
+		require.NoError(t, b.Close())
+		close(done)
+	})
+
+	b.registerKeystore(env.keystore)
+	for _, account := range b.Accounts() {
+		if account.Coin().Code() == coinpkg.CodeRBTC {
+			env.account = account
+			break
+		}
+	}
+	require.NotNil(t, env.account)
+	b.ObserveAccount(env.account.Config().Config.Code)
+	require.NoError(t, env.account.Initialize())
+	b.ratesUpdater.StartCurrentRates()
+	require.Eventually(t, env.account.Synced, scenarioTimeout, 10*time.Millisecond)
+	return env
+}
+
+// step is one step of a scenario.
+type step struct {
+	name string
+	run  func(t *testing.T, env *scenarioEnv)
+}
+
+// scenario is an ordered list of steps.
+type scenario struct {
+	name  string
+	steps []step
+}
+
+// runScenario executes the steps of the scenario against a new environment.
+func runScenario(t *testing.T, s scenario) {
+	t.Helper()
+	t.Run(s.name, func(t *testing.T) {
+		env := newScenarioEnv(t)
+		for index, step := range s.steps {
+			t.Logf("step %d: %s", index+1, step.name)
+			step.run(t, env)
+		}
+	})
+}
+
+// receive adds a transaction paying the amount to an unused receive address of the account to the
+// mempool. The transaction can be referred to by the label in later steps.
+func receive(label string, amount int64) step {
+	return step{
+		name: fmt.Sprintf("%s pays %d sat to the account", label, amount),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			receiveAddresses := env.account.GetUnusedReceiveAddresses()
+			require.NotEmpty(t, receiveAddresses)
+			require.NotEmpty(t, receiveAddresses[0].Addresses)
+			address, err := btcutil.DecodeAddress(
+				receiveAddresses[0].Addresses[0].EncodeForHumans(), &chaincfg.RegressionNetParams)
+			require.NoError(t, err)
+			pkScript, err := txscript.PayToAddrScript(address)
+			require.NoError(t, err)
+			// The funds come from outside of the account, from an output unknown to the chain.
+			prevHash := chainhash.HashH([]byte(label))
+			tx := wire.NewMsgTx(wire.TxVersion)
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+			tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+			env.txs[label] = tx
+			env.chain.AddToMempool(tx)
+		},
+	}
+}
+
+// mine adds a block with the labeled transactions. Without labels, an empty block arrives.
+func mine(labels ...string) step {
+	return step{
+		name: fmt.Sprintf("a block with %v arrives", labels),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			env.chain.Mine(env.labeledTxs(t, labels)...)
+		},
+	}
+}
+
+// reorg replaces the last depth blocks by the given blocks, each a list of transaction labels.
+func reorg(depth int, blocks ...[]string) step {
+	return step{
+		name: fmt.Sprintf("reorg of %d blocks to %v", depth, blocks),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			newBlocks := make([][]*wire.MsgTx, len(blocks))
+			for index, labels := range blocks {
+				newBlocks[index] = env.labeledTxs(t, labels)
+			}
+			env.chain.Reorg(depth, newBlocks...)
+		},
+	}
+}
+
+func (env *scenarioEnv) labeledTxs(t *testing.T, labels []string) []*wire.MsgTx {
+	t.Helper()
+	txs := make([]*wire.MsgTx, len(labels))
+	for index, label := range labels {
+		tx, ok := env.txs[label]
+		require.True(t, ok, "unknown transaction %s", label)
+		txs[index] = tx
+	}
+	return txs
+}
+
+// serverDrops drops the server the backend is connected to.
+func serverDrops() step {
+	return step{
+		name: "the connected server drops",
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			for _, server := range env.servers {
+				if server.Connections() > 0 {
+					env.dropped = server
+					server.Drop()
+					return
+				}
+			}
+			require.Fail(t, "no server is connected")
+		},
+	}
+}
+
+// expectReconnected waits for the backend to connect to a server other than the one dropped by the
+// last serverDrops step.
+func expectReconnected() step {
+	return step{
+		name: "the backend connects to another server",
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			require.NotNil(t, env.dropped)
+			require.True(t, assertEventually(func() bool {
+				for _, server := range env.servers {
+					if server != env.dropped && server.Connections() > 0 {
+						return true
+					}
+				}
+				return false
+			}))
+		},
+	}
+}
+
+// serverRestores lets the server dropped by the last serverDrops step accept connections again.
+func serverRestores() step {
+	return step{
+		name: "the dropped server is back",
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			require.NotNil(t, env.dropped)
+			env.dropped.Restore()
+		},
+	}
+}
+
+// send proposes a transaction sending the amount to an external address and starts signing and
+// sending it, which waits for the user to approve on the device. The sent transaction can be
+// referred to by the label in later steps, once sent, see expectSent().
+func send(amount string) step {
+	return step{
+		name: fmt.Sprintf("the user sends %s", amount),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			recipient, err := btcutil.NewAddressWitnessPubKeyHash(
+				make([]byte, 20), &chaincfg.RegressionNetParams)
+			require.NoError(t, err)
+			_, _, _, err = env.account.TxProposal(&accounts.TxProposalArgs{
+				RecipientAddress: recipient.EncodeAddress(),
+				Amount:           coinpkg.NewSendAmount(amount),
+				FeeTargetCode:    accounts.FeeTargetCodeCustom,
+				CustomFee:        "1",
+			})
+			require.NoError(t, err)
+			go func() {
+				env.sendResult <- env.account.SendTx()
+			}()
+		},
+	}
+}
+
+// approveOnDevice approves the transaction being signed on the device.
+func approveOnDevice() step {
+	return step{
+		name: "the user approves on the device",
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			select {
+			case env.keystore.approvals <- true:
+			case <-time.After(scenarioTimeout):
+				require.Fail(t, "the device was not asked to sign")
+			}
+		},
+	}
+}
+
+// expectSent waits for the send started by a send step to succeed and labels the sent
+// transaction.
+func expectSent(label string) step {
+	return step{
+		name: fmt.Sprintf("%s is sent", label),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			select {
+			case err := <-env.sendResult:
+				require.NoError(t, err)
+			case <-time.After(scenarioTimeout):
+				require.Fail(t, "the transaction was not sent")
+			}
+			broadcasts := env.chain.Broadcasts()
+			require.NotEmpty(t, broadcasts)
+			env.txs[label] = env.chain.Tx(broadcasts[len(broadcasts)-1])
+			require.NotNil(t, env.txs[label])
+		},
+	}
+}
+
+// expectBroadcastAvoidingDropped checks that the labeled transaction was not broadcast through the
+// server dropped by the last serverDrops step.
+func expectBroadcastAvoidingDropped(label string) step {
+	return step{
+		name: fmt.Sprintf("%s is broadcast through another server", label),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			require.NotNil(t, env.dropped)
+			require.NotContains(t, env.dropped.Broadcasts(), env.txs[label].TxHash())
+		},
+	}
+}
+
+// ratesUpdate serves the new BTC/USD rate and makes the backend fetch it.
+func ratesUpdate(btcUSD float64) step {
+	return step{
+		name: fmt.Sprintf("the BTC/USD rate changes to %v", btcUSD),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			env.rates.mu.Lock()
+			env.rates.btcUSD = btcUSD
+			env.rates.fail = false
+			env.rates.mu.Unlock()
+			env.backend.ratesUpdater.TstRefreshLatest()
+		},
+	}
+}
+
+// ratesFail makes the rates API fail and the backend fetch the rates.
+func ratesFail() step {
+	return step{
+		name: "the rates API fails",
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			env.rates.mu.Lock()
+			env.rates.fail = true
+			env.rates.mu.Unlock()
+			env.backend.ratesUpdater.TstRefreshLatest()
+		},
+	}
+}
+
+// expectRate waits until the latest BTC/USD rate of the backend is the given rate.
+func expectRate(btcUSD float64) step {
+	return step{
+		name: fmt.Sprintf("the BTC/USD rate is %v", btcUSD),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			require.True(t, assertEventually(func() bool {
+				rate, err := env.backend.ratesUpdater.LatestPriceForPair("RBTC", "USD")
+				return err == nil && rate == btcUSD
+			}), "the BTC/USD rate is not %v", btcUSD)
+		},
+	}
+}
+
+// expectEvents waits until the backend emitted the given events in this order, see formatEvent().
+// Other events may be emitted in between. Only events emitted after the ones matched by the
+// previous expectEvents step are considered.
+func expectEvents(expected ...string) step {
+	return step{
+		name: fmt.Sprintf("the backend emits %s", strings.Join(expected, ", ")),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			matched := func() bool {
+				env.eventsMu.Lock()
+				defer env.eventsMu.Unlock()
+				remaining := expected
+				for index := env.eventsChecked; index < len(env.events); index++ {
+					if env.events[index] != remaining[0] {
+						continue
+					}
+					remaining = remaining[1:]
+					if len(remaining) == 0 {
+						env.eventsChecked = index + 1
+						return true
+					}
+				}
+				return false
+			}
+			if !assertEventually(matched) {
+				env.eventsMu.Lock()
+				defer env.eventsMu.Unlock()
+				require.Fail(t, "events not emitted",
+					"expected %v in the events since the last check: %v",
+					expected, env.events[env.eventsChecked:])
+			}
+		},
+	}
+}
+
+// expectBalance waits until the account is synced with the given available and incoming balance,
+// in satoshi.
+func expectBalance(available, incoming int64) step {
+	return step{
+		name: fmt.Sprintf("the balance is %d sat available and %d sat incoming", available, incoming),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			var actualAvailable, actualIncoming int64
+			ok := assertEventually(func() bool {
+				if !env.account.Synced() {
+					return false
+				}
+				balance, err := env.account.Balance()
+				if err != nil {
+					return false
+				}
+				actualAvailable, _ = balance.Available().Int64()
+				actualIncoming, _ = balance.Incoming().Int64()
+				return actualAvailable == available && actualIncoming == incoming
+			})
+			require.True(t, ok, "the balance is %d sat available and %d sat incoming",
+				actualAvailable, actualIncoming)
+		},
+	}
+}
+
+// expectTx waits until the labeled transaction is in the account at the given height, verified
+// against the headers if it is confirmed.
+func expectTx(label string, height int) step {
+	return step{
+		name: fmt.Sprintf("%s is in the account at height %d", label, height),
+		run: func(t *testing.T, env *scenarioEnv) {
+			t.Helper()
+			tx, ok := env.txs[label]
+			require.True(t, ok, "unknown transaction %s", label)
+			var found *accounts.TransactionData
+			ok = assertEventually(func() bool {
+				found = nil
+				if !env.account.Synced() {
+					return false
+				}
+				txs, err := env.account.Transactions()
+				if err != nil {
+					return false
+				}
+				for _, txData := range txs {
+					if txData.TxID == tx.TxHash().String() {
+						found = txData
+					}
+				}
+				if found == nil || found.Height != height {
+					return false
+				}
+				return height <= 0 || (found.Verified != nil && *found.Verified)
+			})
+			if !ok {
+				require.NotNil(t, found, "%s is not in the account", label)
+				require.Fail(t, "unexpected transaction", "%s is at height %d, verified: %v",
+					label, found.Height, found.Verified)
+			}
+		},
+	}
+}
+
+// assertEventually returns true once the condition is met, and false if it is not met within
+// scenarioTimeout.
+func assertEventually(condition func() bool) bool {
+	deadline := time.Now().Add(scenarioTimeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
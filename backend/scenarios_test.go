@@ -0,0 +1,93 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+)
+
+// TestScenarios runs the scenarios, see scenario_test.go.
+func TestScenarios(t *testing.T) {
+	if testing.Short() {
+		t.Skip("scenarios run a full backend")
+	}
+
+	// When the connected server drops while the user approves a transaction on the device, the
+	// backend must move to the next server and broadcast the transaction through it.
+	runScenario(t, scenario{
+		name: "server drops while signing",
+		steps: []step{
+			receive("funding", 100_000_000),
+			mine("funding"),
+			expectBalance(100_000_000, 0),
+			send("0.5"),
+			serverDrops(),
+			expectReconnected(),
+			approveOnDevice(),
+			expectSent("payment"),
+			expectBroadcastAvoidingDropped("payment"),
+			expectTx("payment", 0),
+			expectEvents("account newTransaction send 0"),
+			serverRestores(),
+			mine("payment"),
+			expectTx("payment", 2),
+			expectEvents("account newTransaction send 1"),
+		},
+	})
+
+	// Transactions confirmed in blocks which get reorged out must move to their height in the new
+	// chain and be verified again, or go back to the mempool if they are not part of it.
+	runScenario(t, scenario{
+		name: "reorg moves a confirmed transaction",
+		steps: []step{
+			receive("funding", 100_000_000),
+			mine(),
+			mine("funding"),
+			expectTx("funding", 2),
+			expectBalance(100_000_000, 0),
+			reorg(1, []string{}, []string{"funding"}),
+			expectTx("funding", 3),
+			reorg(2, []string{}, []string{}, []string{}),
+			expectTx("funding", 0),
+			expectBalance(0, 100_000_000),
+		},
+	})
+
+	// Handling the end of a sync used to deadlock when the new transactions were announced, which
+	// stopped the account from ever syncing again. Incoming transactions must be announced when
+	// they arrive and again when they confirm, and a failure to fetch the rates must be notified
+	// without losing the last known rates.
+	runScenario(t, scenario{
+		name: "incoming payment and rates updates",
+		steps: []step{
+			expectRate(30000),
+			receive("payment", 1_000_000),
+			expectEvents("account newTransaction receive 0"),
+			expectBalance(0, 1_000_000),
+			mine("payment"),
+			expectEvents("account newTransaction receive 1"),
+			expectBalance(1_000_000, 0),
+			ratesUpdate(40000),
+			expectEvents("rates"),
+			expectRate(40000),
+			ratesFail(),
+			expectEvents("rates/update-failed", "rates"),
+			expectRate(40000),
+			ratesUpdate(41000),
+			expectEvents("rates"),
+			expectRate(41000),
+		},
+	})
+}
@@ -0,0 +1,278 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+const (
+	defaultSearchLimit   = 50
+	defaultSearchTimeout = 2 * time.Second
+)
+
+// SearchArgs are the arguments of SearchTransactions().
+type SearchArgs struct {
+	// Query is matched against the tx ID (prefix), the addresses, the notes and the amount of the
+	// transactions.
+	Query string
+	// Limit is the maximum number of hits. 0 means defaultSearchLimit.
+	Limit int
+	// Timeout is the time budget of the search. 0 means defaultSearchTimeout.
+	Timeout time.Duration
+	// IncludeArchived includes inactive accounts. They are not initialized for the search, so only
+	// those whose transactions were persisted by a previous sync are searched.
+	IncludeArchived bool
+}
+
+// persistedTransactionsReader is implemented by accounts whose transactions and notes can be read
+// from the files persisted by a previous sync without initializing the account.
+type persistedTransactionsReader interface {
+	PersistedTransactions() (accounts.OrderedTransactions, *notes.Notes, error)
+}
+
+// SearchMatch is the transaction field which matched a search query.
+type SearchMatch string
+
+const (
+	// SearchMatchTxID means the tx ID starts with the query.
+	SearchMatchTxID SearchMatch = "txID"
+	// SearchMatchAddress means one of the addresses of the tx is the query.
+	SearchMatchAddress SearchMatch = "address"
+	// SearchMatchNote means the note of the tx contains the query.
+	SearchMatchNote SearchMatch = "note"
+	// SearchMatchAmount means the amount of the tx equals the query.
+	SearchMatchAmount SearchMatch = "amount"
+)
+
+// SearchHit is a transaction matching a search query. The internal ID identifies the transaction
+// within its account.
+type SearchHit struct {
+	InternalID       string                  `json:"internalID"`
+	TxID             string                  `json:"txID"`
+	Matches          []SearchMatch           `json:"matches"`
+	Type             accounts.TxType         `json:"type"`
+	Amount           coinpkg.FormattedAmount `json:"amount"`
+	Time             *string                 `json:"time"`
+	NumConfirmations int                     `json:"numConfirmations"`
+	Note             string                  `json:"note"`
+}
+
+// SearchAccountResult contains the hits in one account, newest first.
+type SearchAccountResult struct {
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	AccountName string             `json:"accountName"`
+	CoinCode    coinpkg.Code       `json:"coinCode"`
+	Archived    bool               `json:"archived"`
+	Hits        []*SearchHit       `json:"hits"`
+}
+
+// SearchResult is the result of SearchTransactions().
+type SearchResult struct {
+	// Accounts contains the accounts with at least one hit, in the order of the accounts list.
+	Accounts []*SearchAccountResult `json:"accounts"`
+	// Truncated is true if there are more hits than the limit, or if not all accounts could be
+	// searched, e.g. because they are still syncing or the time budget was exceeded.
+	Truncated bool `json:"truncated"`
+}
+
+// searchMatches returns the fields of the transaction matching the query.
+func searchMatches(
+	coin coinpkg.Coin, tx *accounts.TransactionData, note string, query string) []SearchMatch {
+	matches := []SearchMatch{}
+	lowerQuery := strings.ToLower(query)
+	if strings.HasPrefix(strings.ToLower(tx.TxID), lowerQuery) {
+		matches = append(matches, SearchMatchTxID)
+	}
+	for _, address := range tx.Addresses {
+		if strings.EqualFold(address.Address, query) {
+			matches = append(matches, SearchMatchAddress)
+			break
+		}
+	}
+	if strings.Contains(strings.ToLower(note), lowerQuery) {
+		matches = append(matches, SearchMatchNote)
+	}
+	if amount, err := coin.ParseAmount(query); err == nil &&
+		amount.BigInt().Cmp(tx.Amount.BigInt()) == 0 {
+		matches = append(matches, SearchMatchAmount)
+	}
+	return matches
+}
+
+// searchAccount returns up to `limit` hits in the account. It returns nil if the transactions of
+// the account are not available. The transactions of archived accounts are read from the files
+// persisted by a previous sync.
+func (backend *Backend) searchAccount(
+	account accounts.Interface, query string, limit int) *SearchAccountResult {
+	config := account.Config()
+	var transactions accounts.OrderedTransactions
+	txNote := account.TxNote
+	if config.Config.Inactive {
+		reader, ok := account.(persistedTransactionsReader)
+		if !ok {
+			return nil
+		}
+		persistedTransactions, txNotes, err := reader.PersistedTransactions()
+		if err != nil {
+			backend.log.WithError(err).Info("Archived account can not be searched")
+			return nil
+		}
+		transactions = persistedTransactions
+		txNote = txNotes.TxNote
+	} else {
+		var err error
+		transactions, err = account.Transactions()
+		if err != nil {
+			return nil
+		}
+	}
+	result := &SearchAccountResult{
+		AccountCode: config.Config.Code,
+		AccountName: config.Config.Name,
+		CoinCode:    account.Coin().Code(),
+		Archived:    config.Config.Inactive,
+		Hits:        []*SearchHit{},
+	}
+	for _, tx := range transactions {
+		if len(result.Hits) == limit {
+			break
+		}
+		note := txNote(tx.InternalID)
+		matches := searchMatches(account.Coin(), tx, note, query)
+		if len(matches) == 0 {
+			continue
+		}
+		var formattedTime *string
+		if tx.Timestamp != nil {
+			t := tx.Timestamp.Format(time.RFC3339)
+			formattedTime = &t
+		} else if tx.CreatedTimestamp != nil {
+			t := tx.CreatedTimestamp.Format(time.RFC3339)
+			formattedTime = &t
+		}
+		result.Hits = append(result.Hits, &SearchHit{
+			InternalID: tx.InternalID,
+			TxID:       tx.TxID,
+			Matches:    matches,
+			Type:       tx.Type,
+			Amount: coinpkg.FormatAmountAsJSON(
+				tx.Amount,
+				account.Coin(),
+				false,
+				config.RateUpdater,
+				util.FormatBtcAsSat(config.BtcCurrencyUnit),
+				false,
+			),
+			Time:             formattedTime,
+			NumConfirmations: tx.NumConfirmations,
+			Note:             note,
+		})
+	}
+	return result
+}
+
+// SearchTransactions searches the transactions of all accounts in parallel. If the time budget is
+// exceeded, the hits of the accounts searched so far are returned.
+func (backend *Backend) SearchTransactions(args SearchArgs) *SearchResult {
+	result := &SearchResult{Accounts: []*SearchAccountResult{}}
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return result
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	timeout := args.Timeout
+	if timeout <= 0 {
+		timeout = defaultSearchTimeout
+	}
+
+	toSearch := []accounts.Interface{}
+	for _, account := range backend.Accounts() {
+		if account.FatalError() {
+			continue
+		}
+		if account.Config().Config.Inactive {
+			if args.IncludeArchived {
+				toSearch = append(toSearch, account)
+			}
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			backend.log.WithError(err).Error("Could not initialize the account for the search")
+			result.Truncated = true
+			continue
+		}
+		toSearch = append(toSearch, account)
+	}
+
+	type accountResult struct {
+		index  int
+		result *SearchAccountResult
+	}
+	// Buffered so that searches finishing after the time budget do not block.
+	results := make(chan accountResult, len(toSearch))
+	for index, account := range toSearch {
+		index, account := index, account
+		go func() {
+			// Search one more than the limit to know if the results are truncated.
+			results <- accountResult{index: index, result: backend.searchAccount(account, query, limit+1)}
+		}()
+	}
+	accountResults := make([]*SearchAccountResult, len(toSearch))
+	timeoutChan := time.After(timeout)
+collect:
+	for range toSearch {
+		select {
+		case res := <-results:
+			accountResults[res.index] = res.result
+		case <-timeoutChan:
+			backend.log.Warn("Search time budget exceeded, returning partial results")
+			result.Truncated = true
+			break collect
+		}
+	}
+
+	numHits := 0
+	for _, accountResult := range accountResults {
+		if accountResult == nil {
+			// Not searched in time or the transactions are not available yet.
+			result.Truncated = true
+			continue
+		}
+		if len(accountResult.Hits) == 0 {
+			continue
+		}
+		if numHits+len(accountResult.Hits) > limit {
+			accountResult.Hits = accountResult.Hits[:limit-numHits]
+			result.Truncated = true
+		}
+		if len(accountResult.Hits) == 0 {
+			continue
+		}
+		numHits += len(accountResult.Hits)
+		result.Accounts = append(result.Accounts, accountResult)
+	}
+	return result
+}
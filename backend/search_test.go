@@ -0,0 +1,206 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+// persistedAccount is an archived account whose transactions were persisted by a previous sync.
+type persistedAccount struct {
+	*accountsMocks.InterfaceMock
+	notes *notes.Notes
+	err   error
+}
+
+func (account *persistedAccount) PersistedTransactions() (
+	accounts.OrderedTransactions, *notes.Notes, error) {
+	if account.err != nil {
+		return nil, nil, account.err
+	}
+	txs := accounts.OrderedTransactions{}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("%s-tx-%d", account.Config().Config.Code, i)
+		txs = append(txs, &accounts.TransactionData{
+			TxID:       id,
+			InternalID: id,
+			Type:       accounts.TxTypeReceive,
+			Amount:     coinpkg.NewAmountFromInt64(int64(i * 1000)),
+		})
+	}
+	return txs, account.notes, nil
+}
+
+func TestSearchTransactions(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	codes := addTestBtcAccounts(t, b, 3)
+
+	// Each account has the txs <code>-tx-0, <code>-tx-1, ... receiving i*1000 sat on
+	// address-<code>-<i>. The notes are "note <i>".
+	setTransactions := func(code accountsTypes.Code, count int) *accountsMocks.InterfaceMock {
+		account := b.Accounts().lookup(code).(*accountsMocks.InterfaceMock)
+		txs := accounts.OrderedTransactions{}
+		for i := 0; i < count; i++ {
+			id := fmt.Sprintf("%s-tx-%d", code, i)
+			txs = append(txs, &accounts.TransactionData{
+				TxID:       id,
+				InternalID: id,
+				Type:       accounts.TxTypeReceive,
+				Amount:     coinpkg.NewAmountFromInt64(int64(i * 1000)),
+				Addresses: []accounts.AddressAndAmount{
+					{Address: fmt.Sprintf("address-%s-%d", code, i)},
+				},
+			})
+		}
+		account.TransactionsFunc = func() (accounts.OrderedTransactions, error) { return txs, nil }
+		account.TxNoteFunc = func(internalID string) string {
+			for j := 0; j < count; j++ {
+				if internalID == fmt.Sprintf("%s-tx-%d", code, j) {
+					return fmt.Sprintf("Note %d", j)
+				}
+			}
+			return ""
+		}
+		return account
+	}
+	for _, code := range codes {
+		setTransactions(code, 5)
+	}
+	hitIDs := func(result *SearchResult) map[accountsTypes.Code][]string {
+		ids := map[accountsTypes.Code][]string{}
+		for _, accountResult := range result.Accounts {
+			for _, hit := range accountResult.Hits {
+				ids[accountResult.AccountCode] = append(ids[accountResult.AccountCode], hit.InternalID)
+			}
+		}
+		return ids
+	}
+
+	// Empty query.
+	result := b.SearchTransactions(SearchArgs{Query: " "})
+	require.Empty(t, result.Accounts)
+	require.False(t, result.Truncated)
+
+	// Tx ID prefix.
+	result = b.SearchTransactions(SearchArgs{Query: string(codes[1]) + "-TX-3"})
+	require.False(t, result.Truncated)
+	require.Equal(t, map[accountsTypes.Code][]string{codes[1]: {string(codes[1]) + "-tx-3"}}, hitIDs(result))
+	require.Equal(t, []SearchMatch{SearchMatchTxID}, result.Accounts[0].Hits[0].Matches)
+	require.Equal(t, "test-btc-account-1", result.Accounts[0].AccountName)
+	require.Equal(t, coinpkg.CodeBTC, result.Accounts[0].CoinCode)
+
+	// Address.
+	result = b.SearchTransactions(SearchArgs{Query: fmt.Sprintf("address-%s-2", codes[2])})
+	require.Equal(t, map[accountsTypes.Code][]string{codes[2]: {string(codes[2]) + "-tx-2"}}, hitIDs(result))
+	require.Equal(t, []SearchMatch{SearchMatchAddress}, result.Accounts[0].Hits[0].Matches)
+
+	// Note, in all accounts.
+	result = b.SearchTransactions(SearchArgs{Query: "note 4"})
+	require.Equal(t, map[accountsTypes.Code][]string{
+		codes[0]: {string(codes[0]) + "-tx-4"},
+		codes[1]: {string(codes[1]) + "-tx-4"},
+		codes[2]: {string(codes[2]) + "-tx-4"},
+	}, hitIDs(result))
+	require.Equal(t, []SearchMatch{SearchMatchNote}, result.Accounts[0].Hits[0].Matches)
+	require.Equal(t, "Note 4", result.Accounts[0].Hits[0].Note)
+
+	// Amount, in the unit of the coin.
+	result = b.SearchTransactions(SearchArgs{Query: "0.00003"})
+	require.Len(t, result.Accounts, 3)
+	require.Equal(t, []SearchMatch{SearchMatchAmount}, result.Accounts[0].Hits[0].Matches)
+	require.Equal(t, "0.00003000", result.Accounts[0].Hits[0].Amount.Amount)
+
+	// Limit.
+	result = b.SearchTransactions(SearchArgs{Query: "note", Limit: 7})
+	require.True(t, result.Truncated)
+	ids := hitIDs(result)
+	require.Len(t, ids[codes[0]], 5)
+	require.Len(t, ids[codes[1]], 2)
+	require.NotContains(t, ids, codes[2])
+	result = b.SearchTransactions(SearchArgs{Query: "note", Limit: 15})
+	require.False(t, result.Truncated)
+
+	// An account which is not synced yet.
+	account := b.Accounts().lookup(codes[0]).(*accountsMocks.InterfaceMock)
+	account.TransactionsFunc = func() (accounts.OrderedTransactions, error) {
+		return nil, errors.New("not synced")
+	}
+	result = b.SearchTransactions(SearchArgs{Query: "note 4"})
+	require.True(t, result.Truncated)
+	require.Len(t, result.Accounts, 2)
+
+	setTransactions(codes[0], 5)
+
+	// Archived accounts are only searched on request, and only if their transactions were
+	// persisted.
+	account = b.Accounts().lookup(codes[2]).(*accountsMocks.InterfaceMock)
+	account.Config().Config.Inactive = true
+	account.InitializeFunc = func() error {
+		require.Fail(t, "archived accounts must not be initialized")
+		return nil
+	}
+	account.TransactionsFunc = func() (accounts.OrderedTransactions, error) {
+		require.Fail(t, "archived accounts are not initialized")
+		return nil, nil
+	}
+	result = b.SearchTransactions(SearchArgs{Query: "note 4"})
+	require.Len(t, result.Accounts, 2)
+	result = b.SearchTransactions(SearchArgs{Query: "note 4", IncludeArchived: true})
+	require.Len(t, result.Accounts, 2)
+	require.True(t, result.Truncated)
+
+	archivedNotes, err := notes.LoadNotes(path.Join(test.TstTempDir("search"), "notes.json"))
+	require.NoError(t, err)
+	_, err = archivedNotes.SetTxNote(fmt.Sprintf("%s-tx-4", codes[2]), "archived note 4")
+	require.NoError(t, err)
+	persisted := &persistedAccount{InterfaceMock: account, notes: archivedNotes}
+	for i, acct := range b.accounts {
+		if acct == accounts.Interface(account) {
+			b.accounts[i] = persisted
+		}
+	}
+	result = b.SearchTransactions(SearchArgs{Query: "note 4", IncludeArchived: true})
+	require.Len(t, result.Accounts, 3)
+	require.True(t, result.Accounts[2].Archived)
+	require.Equal(t, "archived note 4", result.Accounts[2].Hits[0].Note)
+	persisted.err = errors.New("never synced")
+	result = b.SearchTransactions(SearchArgs{Query: "note 4", IncludeArchived: true})
+	require.Len(t, result.Accounts, 2)
+	require.True(t, result.Truncated)
+
+	// An account exceeding the time budget.
+	setTransactions(codes[0], 5).TransactionsFunc = func() (accounts.OrderedTransactions, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	}
+	start := time.Now()
+	result = b.SearchTransactions(SearchArgs{Query: "note 4", Timeout: 50 * time.Millisecond})
+	require.Less(t, time.Since(start), time.Second)
+	require.True(t, result.Truncated)
+	require.Len(t, result.Accounts, 1)
+}
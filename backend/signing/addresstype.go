@@ -0,0 +1,138 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// AddressType is the type of the addresses created from a signing configuration.
+type AddressType int
+
+const (
+	// AddressTypeP2PKH is a Bitcoin-based PayToPubKeyHash address.
+	AddressTypeP2PKH AddressType = iota + 1
+	// AddressTypeP2WPKHP2SH is a Bitcoin-based segwit v0 PayToPubKeyHash address wrapped in p2sh.
+	AddressTypeP2WPKHP2SH
+	// AddressTypeP2WPKH is a Bitcoin-based segwit v0 PayToPubKeyHash address.
+	AddressTypeP2WPKH
+	// AddressTypeP2TR is a Bitcoin-based BIP-86 segwit v1 PayToTaproot address.
+	AddressTypeP2TR
+	// AddressTypeEthereum is an Ethereum address.
+	AddressTypeEthereum
+)
+
+// String returns a short name of the address type to be used in logs, etc.
+func (addressType AddressType) String() string {
+	switch addressType {
+	case AddressTypeP2PKH:
+		return string(ScriptTypeP2PKH)
+	case AddressTypeP2WPKHP2SH:
+		return string(ScriptTypeP2WPKHP2SH)
+	case AddressTypeP2WPKH:
+		return string(ScriptTypeP2WPKH)
+	case AddressTypeP2TR:
+		return string(ScriptTypeP2TR)
+	case AddressTypeEthereum:
+		return "ethereum"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(addressType))
+	}
+}
+
+// IsSegwit returns true for segwit (v0 and v1) address types.
+func (addressType AddressType) IsSegwit() bool {
+	switch addressType {
+	case AddressTypeP2WPKHP2SH, AddressTypeP2WPKH, AddressTypeP2TR:
+		return true
+	default:
+		return false
+	}
+}
+
+var addressTypeByScriptType = map[ScriptType]AddressType{
+	ScriptTypeP2PKH:      AddressTypeP2PKH,
+	ScriptTypeP2WPKHP2SH: AddressTypeP2WPKHP2SH,
+	ScriptTypeP2WPKH:     AddressTypeP2WPKH,
+	ScriptTypeP2TR:       AddressTypeP2TR,
+}
+
+// InvalidConfigurationError is returned by AddressType if addresses can't be created from a
+// configuration.
+type InvalidConfigurationError struct {
+	// Configuration is a summary of the invalid configuration, see Configuration.String().
+	Configuration string
+	Reason        string
+}
+
+func (err *InvalidConfigurationError) Error() string {
+	return fmt.Sprintf("invalid signing configuration %s: %s", err.Configuration, err.Reason)
+}
+
+// AddressType validates that addresses can be created from the configuration and returns their
+// type. The configuration must contain exactly one key, an extended public key, and a supported
+// script type. For Bitcoin-based configurations, the coin type of BIP44-like keypaths
+// (m/purpose'/coin'/...) must match the network. `net` is ignored for Ethereum configurations.
+// The returned error is an *InvalidConfigurationError.
+func (configuration *Configuration) AddressType(net *chaincfg.Params) (AddressType, error) {
+	invalid := func(reason string) (AddressType, error) {
+		summary := "empty"
+		if configuration != nil && (configuration.BitcoinSimple != nil) != (configuration.EthereumSimple != nil) {
+			summary = configuration.String()
+		}
+		return 0, &InvalidConfigurationError{Configuration: summary, Reason: reason}
+	}
+	if configuration == nil {
+		return invalid("no configuration")
+	}
+	var keyInfo *KeyInfo
+	switch {
+	case configuration.BitcoinSimple != nil && configuration.EthereumSimple != nil:
+		return invalid("more than one key")
+	case configuration.BitcoinSimple != nil:
+		keyInfo = &configuration.BitcoinSimple.KeyInfo
+	case configuration.EthereumSimple != nil:
+		keyInfo = &configuration.EthereumSimple.KeyInfo
+	default:
+		return invalid("no key")
+	}
+	if keyInfo.ExtendedPublicKey == nil {
+		return invalid("missing extended public key")
+	}
+	if keyInfo.ExtendedPublicKey.IsPrivate() {
+		return invalid("extended key is private")
+	}
+	if configuration.EthereumSimple != nil {
+		return AddressTypeEthereum, nil
+	}
+
+	addressType, ok := addressTypeByScriptType[configuration.BitcoinSimple.ScriptType]
+	if !ok {
+		return invalid(fmt.Sprintf("unsupported script type %q", configuration.BitcoinSimple.ScriptType))
+	}
+	if net == nil {
+		return invalid("no network")
+	}
+	keypath := keyInfo.AbsoluteKeypath.ToUInt32()
+	if len(keypath) >= 2 && keypath[0] >= hdkeychain.HardenedKeyStart && keypath[1] >= hdkeychain.HardenedKeyStart {
+		if coinType := keypath[1] - hdkeychain.HardenedKeyStart; coinType != net.HDCoinType {
+			return invalid(fmt.Sprintf("coin type %d does not match network %s", coinType, net.Name))
+		}
+	}
+	return addressType, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressType(t *testing.T) {
+	xprv, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+	rootFingerprint := []byte{1, 2, 3, 4}
+	mainnet, testnet := &chaincfg.MainNetParams, &chaincfg.TestNet3Params
+
+	valid := []struct {
+		configuration *Configuration
+		net           *chaincfg.Params
+		addressType   AddressType
+	}{
+		{NewBitcoinConfiguration(ScriptTypeP2PKH, rootFingerprint, mustKeypath("m/44'/0'/0'"), xpub), mainnet, AddressTypeP2PKH},
+		{NewBitcoinConfiguration(ScriptTypeP2WPKHP2SH, rootFingerprint, mustKeypath("m/49'/1'/0'"), xpub), testnet, AddressTypeP2WPKHP2SH},
+		{NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'/0/5"), xpub), mainnet, AddressTypeP2WPKH},
+		{NewBitcoinConfiguration(ScriptTypeP2TR, rootFingerprint, mustKeypath("m/86'/0'/0'"), xpub), mainnet, AddressTypeP2TR},
+		// Non BIP44-like keypaths are not checked against the network.
+		{NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/0/1"), xpub), mainnet, AddressTypeP2WPKH},
+		{NewEthereumConfiguration(rootFingerprint, mustKeypath("m/44'/60'/0'/0/0"), xpub), nil, AddressTypeEthereum},
+	}
+	for _, test := range valid {
+		addressType, err := test.configuration.AddressType(test.net)
+		require.NoError(t, err)
+		require.Equal(t, test.addressType, addressType)
+	}
+	require.True(t, AddressTypeP2TR.IsSegwit())
+	require.False(t, AddressTypeP2PKH.IsSegwit())
+	require.Equal(t, "p2wpkh-p2sh", AddressTypeP2WPKHP2SH.String())
+
+	invalid := []struct {
+		configuration *Configuration
+		net           *chaincfg.Params
+	}{
+		{nil, mainnet},
+		{&Configuration{}, mainnet},
+		{
+			&Configuration{
+				BitcoinSimple:  NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub).BitcoinSimple,
+				EthereumSimple: NewEthereumConfiguration(rootFingerprint, mustKeypath("m/44'/60'/0'/0/0"), xpub).EthereumSimple,
+			},
+			mainnet,
+		},
+		{&Configuration{BitcoinSimple: &BitcoinSimple{ScriptType: ScriptTypeP2WPKH}}, mainnet},
+		{&Configuration{BitcoinSimple: &BitcoinSimple{
+			ScriptType: ScriptTypeP2WPKH,
+			KeyInfo:    KeyInfo{AbsoluteKeypath: mustKeypath("m/84'/0'/0'"), ExtendedPublicKey: xprv},
+		}}, mainnet},
+		{NewBitcoinConfiguration("p2wsh", rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub), mainnet},
+		{NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub), nil},
+		{NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub), testnet},
+		{NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/1'/0'"), xpub), mainnet},
+	}
+	for _, test := range invalid {
+		_, err := test.configuration.AddressType(test.net)
+		require.Error(t, err)
+		var configurationErr *InvalidConfigurationError
+		require.ErrorAs(t, err, &configurationErr)
+	}
+}
@@ -70,6 +70,9 @@ func (ki *KeyInfo) UnmarshalJSON(bytes []byte) error {
 	if err != nil {
 		return errp.Wrap(err, "Could not read an extended public key.")
 	}
+	if extendedPublicKey.IsPrivate() {
+		return errp.New("An extended key is private! Only extended public keys are accepted.")
+	}
 	ki.ExtendedPublicKey = extendedPublicKey
 	return nil
 }
@@ -94,6 +97,29 @@ type Configuration struct {
 	EthereumSimple *EthereumSimple `json:"ethereumSimple,omitempty"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler. Configurations are persisted in the accounts config,
+// so invalid ones are rejected instead of failing later when the account is loaded.
+func (configuration *Configuration) UnmarshalJSON(bytes []byte) error {
+	// Avoids infinite recursion, as the alias type does not have the UnmarshalJSON method.
+	type configurationAlias Configuration
+	var decoded configurationAlias
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		return errp.Wrap(err, "Could not unmarshal a signing configuration")
+	}
+	switch {
+	case decoded.BitcoinSimple != nil && decoded.EthereumSimple != nil:
+		return errp.New("A signing configuration can't be both a Bitcoin and an Ethereum configuration")
+	case decoded.BitcoinSimple != nil:
+		if !decoded.BitcoinSimple.ScriptType.known() {
+			return errp.Newf("Unknown script type: %q", decoded.BitcoinSimple.ScriptType)
+		}
+	case decoded.EthereumSimple == nil:
+		return errp.New("Unknown signing configuration type")
+	}
+	*configuration = Configuration(decoded)
+	return nil
+}
+
 // NewBitcoinConfiguration creates a new configuration.
 func NewBitcoinConfiguration(
 	scriptType ScriptType,
@@ -74,6 +74,35 @@ func TestEncodeDecode(t *testing.T) {
 		cfgDecodedEth.EthereumSimple.KeyInfo.AbsoluteKeypath.Encode())
 }
 
+func TestDecodeInvalid(t *testing.T) {
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	xpub, err := master.Neuter()
+	require.NoError(t, err)
+	keyInfo := func(keypath string, key *hdkeychain.ExtendedKey) string {
+		return `{"rootFingerprint":"01020304","keypath":"` + keypath + `","xpub":"` + key.String() + `"}`
+	}
+	validKeyInfo := keyInfo("m/84'/1'/0'", xpub)
+
+	var cfg Configuration
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"bitcoinSimple":{"keyInfo":`+validKeyInfo+`,"scriptType":"p2tr"}}`), &cfg))
+	require.Equal(t, ScriptTypeP2TR, cfg.ScriptType())
+
+	for _, encoded := range []string{
+		`{}`,
+		`{"bitcoinSimple":{"keyInfo":` + validKeyInfo + `,"scriptType":"p2wsh"}}`,
+		`{"bitcoinSimple":{"keyInfo":` + validKeyInfo + `}}`,
+		`{"bitcoinSimple":{"keyInfo":` + keyInfo("m/84'/1'/x'", xpub) + `,"scriptType":"p2wpkh"}}`,
+		`{"bitcoinSimple":{"keyInfo":` + keyInfo("m/84'/1'/0'", master) + `,"scriptType":"p2wpkh"}}`,
+		`{"bitcoinSimple":{"keyInfo":{"rootFingerprint":"01020304","keypath":"m/84'/1'/0'","xpub":"xpub"},"scriptType":"p2wpkh"}}`,
+		`{"ethereumSimple":{"keyInfo":{"rootFingerprint":"xyz","keypath":"m/44'/60'/0'/0","xpub":"` + xpub.String() + `"}}}`,
+		`{"bitcoinSimple":{"keyInfo":` + validKeyInfo + `,"scriptType":"p2wpkh"},"ethereumSimple":{"keyInfo":` + validKeyInfo + `}}`,
+	} {
+		require.Error(t, json.Unmarshal([]byte(encoded), &Configuration{}), encoded)
+	}
+}
+
 func TestContainsRootFingerprint(t *testing.T) {
 	xpub, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.TestNet3Params)
 	require.NoError(t, err)
@@ -0,0 +1,246 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var descriptorChecksumGenerator = [5]uint64{
+	0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+func descriptorPolymod(checksum uint64, value uint64) uint64 {
+	top := checksum >> 35
+	checksum = (checksum&0x7ffffffff)<<5 ^ value
+	for i, generator := range descriptorChecksumGenerator {
+		if (top>>i)&1 == 1 {
+			checksum ^= generator
+		}
+	}
+	return checksum
+}
+
+// descriptorChecksum computes the checksum of an output descriptor as specified in BIP380.
+func descriptorChecksum(descriptor string) (string, error) {
+	checksum := uint64(1)
+	var groups []uint64
+	for _, char := range descriptor {
+		position := strings.IndexRune(descriptorInputCharset, char)
+		if position < 0 {
+			return "", errp.Newf("Invalid character in descriptor: %q", char)
+		}
+		checksum = descriptorPolymod(checksum, uint64(position&31))
+		groups = append(groups, uint64(position>>5))
+		if len(groups) == 3 {
+			checksum = descriptorPolymod(checksum, groups[0]*9+groups[1]*3+groups[2])
+			groups = nil
+		}
+	}
+	switch len(groups) {
+	case 1:
+		checksum = descriptorPolymod(checksum, groups[0])
+	case 2:
+		checksum = descriptorPolymod(checksum, groups[0]*3+groups[1])
+	}
+	for i := 0; i < 8; i++ {
+		checksum = descriptorPolymod(checksum, 0)
+	}
+	checksum ^= 1
+	result := make([]byte, 8)
+	for i := range result {
+		result[i] = descriptorChecksumCharset[(checksum>>(5*(7-i)))&31]
+	}
+	return string(result), nil
+}
+
+// Descriptors are the output descriptors (BIP380) of the receive and change addresses of a Bitcoin
+// signing configuration, including their checksums.
+type Descriptors struct {
+	Receive string `json:"receive"`
+	Change  string `json:"change"`
+}
+
+// Descriptors returns the output descriptors of the receive (`/0/*`) and change (`/1/*`) addresses
+// of a Bitcoin configuration, e.g. to import the account into another wallet as a watch-only
+// wallet. The extended public key is encoded with the version bytes of the given network (xpub,
+// tpub), as expected in descriptors.
+func (configuration *Configuration) Descriptors(net *chaincfg.Params) (*Descriptors, error) {
+	if configuration.BitcoinSimple == nil {
+		return nil, errp.New("Descriptors are only available for Bitcoin configurations")
+	}
+	keyInfo := configuration.BitcoinSimple.KeyInfo
+	xpub, err := hdkeychain.NewKeyFromString(keyInfo.ExtendedPublicKey.String())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	xpub.SetNet(net)
+	keyOrigin := hex.EncodeToString(keyInfo.RootFingerprint)
+	if len(keyInfo.AbsoluteKeypath) != 0 {
+		keyOrigin += "/" + keypath(keyInfo.AbsoluteKeypath).encode()
+	}
+
+	descriptor := func(chain int) (string, error) {
+		key := fmt.Sprintf("[%s]%s/%d/*", keyOrigin, xpub.String(), chain)
+		var script string
+		switch configuration.ScriptType() {
+		case ScriptTypeP2PKH:
+			script = fmt.Sprintf("pkh(%s)", key)
+		case ScriptTypeP2WPKHP2SH:
+			script = fmt.Sprintf("sh(wpkh(%s))", key)
+		case ScriptTypeP2WPKH:
+			script = fmt.Sprintf("wpkh(%s)", key)
+		case ScriptTypeP2TR:
+			script = fmt.Sprintf("tr(%s)", key)
+		default:
+			return "", errp.Newf("Unsupported script type: %s", configuration.ScriptType())
+		}
+		checksum, err := descriptorChecksum(script)
+		if err != nil {
+			return "", err
+		}
+		return script + "#" + checksum, nil
+	}
+	receive, err := descriptor(0)
+	if err != nil {
+		return nil, err
+	}
+	change, err := descriptor(1)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptors{Receive: receive, Change: change}, nil
+}
+
+// splitDescriptorFragment splits a script fragment like `wpkh(KEY)` into its name and argument.
+func splitDescriptorFragment(fragment string) (string, string, error) {
+	open := strings.Index(fragment, "(")
+	if open <= 0 || !strings.HasSuffix(fragment, ")") {
+		return "", "", errp.Newf("Invalid script fragment: %s", fragment)
+	}
+	return fragment[:open], fragment[open+1 : len(fragment)-1], nil
+}
+
+// parseDescriptorKey parses a key expression with its origin, e.g.
+// `[3442193e/84'/0'/0']xpub.../0/*`, and returns the root fingerprint, the keypath of the origin
+// and the extended public key, using the version bytes of mainnet as in the rest of the app.
+func parseDescriptorKey(key string) ([]byte, AbsoluteKeypath, *hdkeychain.ExtendedKey, error) {
+	if !strings.HasPrefix(key, "[") {
+		return nil, nil, nil, errp.New("The key origin (root fingerprint and keypath) is missing")
+	}
+	closing := strings.Index(key, "]")
+	if closing < 0 {
+		return nil, nil, nil, errp.New("Invalid key origin")
+	}
+	origin := strings.SplitN(key[1:closing], "/", 2)
+	rootFingerprint, err := hex.DecodeString(origin[0])
+	if err != nil || len(rootFingerprint) != 4 {
+		return nil, nil, nil, errp.Newf("Invalid root fingerprint: %s", origin[0])
+	}
+	path := "m"
+	if len(origin) == 2 {
+		path += "/" + strings.NewReplacer("h", hardenedKeySymbol, "H", hardenedKeySymbol).Replace(origin[1])
+	}
+	absoluteKeypath, err := NewAbsoluteKeypath(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	xpubStr, suffix, _ := strings.Cut(key[closing+1:], "/")
+	switch suffix {
+	case "", "0/*", "1/*", "<0;1>/*":
+	default:
+		return nil, nil, nil, errp.Newf("Unsupported derivation: /%s", suffix)
+	}
+	xpub, err := hdkeychain.NewKeyFromString(xpubStr)
+	if err != nil {
+		return nil, nil, nil, errp.Wrap(err, "Invalid extended public key")
+	}
+	if xpub.IsPrivate() {
+		return nil, nil, nil, errp.New("Descriptors with private keys are not supported")
+	}
+	xpub.SetNet(&chaincfg.MainNetParams)
+	return rootFingerprint, absoluteKeypath, xpub, nil
+}
+
+// NewConfigurationFromDescriptor parses the output descriptor (BIP380) of a single-signature
+// Bitcoin account, e.g. exported from another wallet, and returns the corresponding configuration.
+// Supported are `pkh(KEY)`, `sh(wpkh(KEY))`, `wpkh(KEY)` and `tr(KEY)`, where KEY is an extended
+// public key with its origin. The derivation of the receive or change chain (`/0/*`, `/1/*` or
+// `/<0;1>/*`) is optional, and so is the checksum, but it is validated if present.
+//
+// Multisig descriptors like `wsh(sortedmulti(...))` are rejected, as there are no multisig
+// configurations.
+func NewConfigurationFromDescriptor(descriptor string) (*Configuration, error) {
+	descriptor = strings.TrimSpace(descriptor)
+	if body, checksum, ok := strings.Cut(descriptor, "#"); ok {
+		expected, err := descriptorChecksum(body)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != expected {
+			return nil, errp.Newf("Invalid descriptor checksum: %s", checksum)
+		}
+		descriptor = body
+	}
+
+	name, key, err := splitDescriptorFragment(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	var scriptType ScriptType
+	switch name {
+	case "pkh":
+		scriptType = ScriptTypeP2PKH
+	case "wpkh":
+		scriptType = ScriptTypeP2WPKH
+	case "sh":
+		innerName, innerKey, err := splitDescriptorFragment(key)
+		if err != nil {
+			return nil, err
+		}
+		if innerName != "wpkh" {
+			return nil, errp.Newf("Unsupported script fragment: sh(%s(...))", innerName)
+		}
+		scriptType = ScriptTypeP2WPKHP2SH
+		key = innerKey
+	case "tr":
+		if strings.Contains(key, ",") {
+			return nil, errp.New("Taproot descriptors with script paths are not supported")
+		}
+		scriptType = ScriptTypeP2TR
+	case "wsh":
+		return nil, errp.New("Multisig descriptors are not supported")
+	default:
+		return nil, errp.Newf("Unsupported script fragment: %s(...)", name)
+	}
+	rootFingerprint, absoluteKeypath, xpub, err := parseDescriptorKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewBitcoinConfiguration(scriptType, rootFingerprint, absoluteKeypath, xpub), nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	// Test vectors of BIP380 and of the descriptors documentation of Bitcoin Core.
+	checksum, err := descriptorChecksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+	checksum, err = descriptorChecksum("pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)")
+	require.NoError(t, err)
+	require.Equal(t, "ml40v0wf", checksum)
+
+	_, err = descriptorChecksum("raw(deadbeef)é")
+	require.Error(t, err)
+}
+
+func TestDescriptors(t *testing.T) {
+	const xpubStr = "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"
+	xpub, err := hdkeychain.NewKeyFromString(xpubStr)
+	require.NoError(t, err)
+	rootFingerprint := []byte{0x34, 0x42, 0x19, 0x3e}
+
+	descriptors, err := NewBitcoinConfiguration(
+		ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub,
+	).Descriptors(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, &Descriptors{
+		Receive: "wpkh([3442193e/84'/0'/0']" + xpubStr + "/0/*)#2m9v62rv",
+		Change:  "wpkh([3442193e/84'/0'/0']" + xpubStr + "/1/*)#m0qd8ln5",
+	}, descriptors)
+
+	descriptors, err = NewBitcoinConfiguration(
+		ScriptTypeP2WPKHP2SH, rootFingerprint, mustKeypath("m/49'/0'/0'"), xpub,
+	).Descriptors(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "sh(wpkh([3442193e/49'/0'/0']"+xpubStr+"/0/*))#4ty4dm42", descriptors.Receive)
+
+	descriptors, err = NewBitcoinConfiguration(
+		ScriptTypeP2PKH, rootFingerprint, mustKeypath("m/44'/0'/0'"), xpub,
+	).Descriptors(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "pkh([3442193e/44'/0'/0']"+xpubStr+"/0/*)#pytdd97s", descriptors.Receive)
+
+	descriptors, err = NewBitcoinConfiguration(
+		ScriptTypeP2TR, rootFingerprint, mustKeypath("m/86'/0'/0'"), xpub,
+	).Descriptors(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "tr([3442193e/86'/0'/0']"+xpubStr+"/1/*)#9qjv6svh", descriptors.Change)
+
+	// Testnet descriptors use tpub.
+	descriptors, err = NewBitcoinConfiguration(
+		ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/1'/0'"), xpub,
+	).Descriptors(&chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(descriptors.Receive, "wpkh([3442193e/84'/1'/0']tpub"))
+	// The configuration is not modified.
+	require.Equal(t, xpubStr, xpub.String())
+
+	_, err = NewEthereumConfiguration(rootFingerprint, mustKeypath("m/44'/60'/0'/0"), xpub).
+		Descriptors(&chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestNewConfigurationFromDescriptor(t *testing.T) {
+	const xpubStr = "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"
+	xpub, err := hdkeychain.NewKeyFromString(xpubStr)
+	require.NoError(t, err)
+	rootFingerprint := []byte{0x34, 0x42, 0x19, 0x3e}
+
+	// Exported descriptors can be imported again.
+	for _, configuration := range []*Configuration{
+		NewBitcoinConfiguration(ScriptTypeP2PKH, rootFingerprint, mustKeypath("m/44'/0'/0'"), xpub),
+		NewBitcoinConfiguration(ScriptTypeP2WPKHP2SH, rootFingerprint, mustKeypath("m/49'/0'/0'"), xpub),
+		NewBitcoinConfiguration(ScriptTypeP2WPKH, rootFingerprint, mustKeypath("m/84'/0'/0'"), xpub),
+		NewBitcoinConfiguration(ScriptTypeP2TR, rootFingerprint, mustKeypath("m/86'/1'/0'"), xpub),
+	} {
+		for _, net := range []*chaincfg.Params{&chaincfg.MainNetParams, &chaincfg.TestNet3Params} {
+			descriptors, err := configuration.Descriptors(net)
+			require.NoError(t, err)
+			for _, descriptor := range []string{descriptors.Receive, descriptors.Change} {
+				imported, err := NewConfigurationFromDescriptor(descriptor)
+				require.NoError(t, err, descriptor)
+				require.Equal(t, configuration.String(), imported.String())
+				require.Equal(t, configuration.ScriptType(), imported.ScriptType())
+				require.Equal(t, xpubStr, imported.ExtendedPublicKey().String())
+			}
+		}
+	}
+
+	// Without checksum and derivation, with the `h` hardened notation and the multipath derivation.
+	for _, descriptor := range []string{
+		"wpkh([3442193e/84'/0'/0']" + xpubStr + ")",
+		"wpkh([3442193e/84h/0h/0h]" + xpubStr + "/0/*)",
+		" wpkh([3442193e/84'/0'/0']" + xpubStr + "/<0;1>/*) ",
+	} {
+		imported, err := NewConfigurationFromDescriptor(descriptor)
+		require.NoError(t, err, descriptor)
+		require.Equal(t, ScriptTypeP2WPKH, imported.ScriptType())
+		require.Equal(t, "m/84'/0'/0'", imported.AbsoluteKeypath().Encode())
+		require.Equal(t, rootFingerprint, []byte(imported.BitcoinSimple.KeyInfo.RootFingerprint))
+	}
+
+	for _, descriptor := range []string{
+		// Wrong checksum.
+		"wpkh([3442193e/84'/0'/0']" + xpubStr + "/0/*)#m0qd8ln5",
+		// No key origin.
+		"wpkh(" + xpubStr + "/0/*)",
+		"wpkh([3442193e/84'/0'/0'" + xpubStr + "/0/*)",
+		"wpkh([34421/84'/0'/0']" + xpubStr + "/0/*)",
+		"wpkh([3442193e/84'/x/0']" + xpubStr + "/0/*)",
+		// Unsupported derivation.
+		"wpkh([3442193e/84'/0'/0']" + xpubStr + "/2/*)",
+		"wpkh([3442193e/84'/0'/0']" + xpubStr + "/0/1)",
+		// Invalid or private keys.
+		"wpkh([3442193e/84'/0'/0']xpubinvalid/0/*)",
+		"wpkh([3442193e/84'/0'/0']xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi/0/*)",
+		// Unsupported script fragments.
+		"wsh(sortedmulti(2,[3442193e/48'/0'/0'/2']" + xpubStr + "/0/*,[3442193e/48'/0'/1'/2']" + xpubStr + "/0/*))",
+		"sh(multi(1,[3442193e/45']" + xpubStr + "/0/*))",
+		"tr([3442193e/86'/0'/0']" + xpubStr + "/0/*,{pk([3442193e/86'/0'/1']" + xpubStr + "/0/*)})",
+		"combo([3442193e/84'/0'/0']" + xpubStr + "/0/*)",
+		"addr(bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq)",
+		"wpkh([3442193e/84'/0'/0']" + xpubStr + "/0/*",
+		"",
+	} {
+		_, err := NewConfigurationFromDescriptor(descriptor)
+		require.Error(t, err, descriptor)
+	}
+}
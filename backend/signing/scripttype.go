@@ -30,3 +30,12 @@ const (
 	// ScriptTypeP2TR is a BIP-86 segwit v1 PayToTaproot output.
 	ScriptTypeP2TR ScriptType = "p2tr"
 )
+
+// known returns true if the script type is one of the script types above.
+func (scriptType ScriptType) known() bool {
+	switch scriptType {
+	case ScriptTypeP2PKH, ScriptTypeP2WPKHP2SH, ScriptTypeP2WPKH, ScriptTypeP2TR:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/BitBoxSwiss/bitbox02-api-go/api/firmware"
+)
+
+const (
+	// ErrViewOnly is returned when proposing, signing or broadcasting a transaction or signing a
+	// message while the app is in view-only mode.
+	ErrViewOnly errp.ErrorCode = "viewOnly"
+	// ErrViewOnlyConfirmationUnsupported is returned when the view-only mode can't be disabled because
+	// the connected keystore can't confirm it.
+	ErrViewOnlyConfirmationUnsupported errp.ErrorCode = "viewOnlyConfirmationUnsupported"
+	// ErrViewOnlyKeystoreRequired is returned when the view-only mode can't be disabled because no
+	// keystore is connected to confirm it.
+	ErrViewOnlyKeystoreRequired errp.ErrorCode = "viewOnlyKeystoreRequired"
+
+	// viewOnlyDisableMessage is the message shown on the device to confirm disabling the view-only
+	// mode. The signature is discarded.
+	viewOnlyDisableMessage = "Disable view-only mode of the BitBoxApp"
+	// viewOnlyDisableKeypath is the keypath used to sign viewOnlyDisableMessage.
+	viewOnlyDisableKeypath = "m/84'/0'/0'/0/0"
+)
+
+// ViewOnly returns true if the app is in view-only mode, in which all accounts can be viewed and
+// receive funds, but nothing can be sent or signed.
+func (backend *Backend) ViewOnly() bool {
+	return backend.config.AppConfig().Backend.ViewOnly
+}
+
+// checkViewOnly returns ErrViewOnly if the app is in view-only mode.
+func (backend *Backend) checkViewOnly() error {
+	if backend.ViewOnly() {
+		return errp.WithStack(ErrViewOnly)
+	}
+	return nil
+}
+
+// confirmDisableViewOnly asks the user to confirm disabling the view-only mode on the keystore.
+func confirmDisableViewOnly(ks keystore.Keystore) error {
+	if !ks.CanSignMessage(coinpkg.CodeBTC) {
+		return errp.WithStack(ErrViewOnlyConfirmationUnsupported)
+	}
+	keypath, err := signing.NewAbsoluteKeypath(viewOnlyDisableKeypath)
+	if err != nil {
+		return err
+	}
+	_, err = ks.SignBTCMessage([]byte(viewOnlyDisableMessage), keypath, signing.ScriptTypeP2WPKH)
+	if firmware.IsErrorAbort(err) {
+		return errp.WithStack(errp.ErrUserAbort)
+	}
+	return err
+}
+
+// SetViewOnly enables or disables the view-only mode. Disabling it requires the user to confirm on
+// the connected keystore, so ErrViewOnlyKeystoreRequired is returned if there is none.
+// errp.ErrUserAbort is returned if the user aborts.
+func (backend *Backend) SetViewOnly(viewOnly bool) error {
+	if !viewOnly && backend.ViewOnly() {
+		ks := backend.Keystore()
+		if ks == nil {
+			return errp.WithStack(ErrViewOnlyKeystoreRequired)
+		}
+		if err := confirmDisableViewOnly(ks); err != nil {
+			return err
+		}
+	}
+	err := backend.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.ViewOnly = viewOnly
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	backend.Notify(observable.Event{
+		Subject: "view-only",
+		Action:  action.Replace,
+		Object:  viewOnly,
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetViewOnly(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	require.False(t, b.ViewOnly())
+	require.NoError(t, b.SetViewOnly(true))
+	require.True(t, b.ViewOnly())
+	require.True(t, b.Config().AppConfig().Backend.ViewOnly)
+
+	// Without a keystore, it can't be confirmed.
+	require.Equal(t, ErrViewOnlyKeystoreRequired, errp.Cause(b.SetViewOnly(false)))
+	require.True(t, b.ViewOnly())
+	require.NoError(t, b.SetViewOnly(true))
+
+	ks := makeBitBox02Multi()
+	b.registerKeystore(ks)
+
+	_, err := b.AccountRotationProposal("v0-55555555-btc-0", accounts.FeeTargetCodeNormal, "")
+	require.Equal(t, ErrViewOnly, errp.Cause(err))
+	require.Equal(t, ErrViewOnly, errp.Cause(b.SendAccountRotation("v0-55555555-btc-0")))
+
+	// The keystore can't confirm.
+	ks.CanSignMessageFunc = func(coinpkg.Code) bool { return false }
+	require.Equal(t, ErrViewOnlyConfirmationUnsupported, errp.Cause(b.SetViewOnly(false)))
+	require.True(t, b.ViewOnly())
+
+	// The user aborts on the device.
+	ks.CanSignMessageFunc = func(code coinpkg.Code) bool { return code == coinpkg.CodeBTC }
+	ks.SignBTCMessageFunc = func([]byte, signing.AbsoluteKeypath, signing.ScriptType) ([]byte, error) {
+		return nil, errp.ErrUserAbort
+	}
+	require.Equal(t, errp.ErrUserAbort, errp.Cause(b.SetViewOnly(false)))
+	require.True(t, b.ViewOnly())
+
+	// The user confirms on the device.
+	var confirmedMessage string
+	ks.SignBTCMessageFunc = func(message []byte, keypath signing.AbsoluteKeypath, scriptType signing.ScriptType) ([]byte, error) {
+		confirmedMessage = string(message)
+		require.Equal(t, viewOnlyDisableKeypath, keypath.Encode())
+		require.Equal(t, signing.ScriptTypeP2WPKH, scriptType)
+		return []byte("signature"), nil
+	}
+	require.NoError(t, b.SetViewOnly(false))
+	require.False(t, b.ViewOnly())
+	require.Equal(t, viewOnlyDisableMessage, confirmedMessage)
+
+	// Enabling does not need a confirmation.
+	ks.SignBTCMessageFunc = nil
+	require.NoError(t, b.SetViewOnly(true))
+	require.True(t, b.ViewOnly())
+}
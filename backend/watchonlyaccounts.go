@@ -0,0 +1,131 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"strings"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// ErrInvalidExtendedPublicKey is returned when importing a watch-only account from an extended
+// public key which can't be parsed, is private, or does not match the coin, script type or
+// account level.
+const ErrInvalidExtendedPublicKey errp.ErrorCode = "invalidExtendedPublicKey"
+
+// ImportWatchOnlyAccount adds a watch-only account of a Bitcoin based coin from an account-level
+// extended public key, e.g. exported from another wallet. Both the plain (xpub/tpub) and the
+// script type specific (ypub/zpub) formats are accepted.
+//
+// The account syncs and shows its balance like any other account, but as no keystore can sign for
+// it, sending fails with errors.ErrWatchOnly. Its keystore entry is identified by the fingerprint
+// of the extended public key, as the real root fingerprint is unknown, and marked watchonly so the
+// account is always loaded.
+//
+// `name` is the account name, shown to the user. If empty, a default name will be set.
+func (backend *Backend) ImportWatchOnlyAccount(
+	coinCode coinpkg.Code,
+	name string,
+	extendedPublicKey string,
+	scriptType signing.ScriptType,
+) (accountsTypes.Code, error) {
+	coin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return "", errp.New("watch-only accounts are only supported for Bitcoin based coins")
+	}
+	xpub, err := hdkeychain.NewKeyFromString(strings.TrimSpace(extendedPublicKey))
+	if err != nil || xpub.IsPrivate() {
+		return "", errp.WithStack(ErrInvalidExtendedPublicKey)
+	}
+	scriptTypeVersion := btc.XPubVersionForScriptType(btcCoin, scriptType)
+	if !bytes.Equal(xpub.Version(), scriptTypeVersion[:]) &&
+		!bytes.Equal(xpub.Version(), btcCoin.Net().HDPublicKeyID[:]) {
+		return "", errp.WithStack(ErrInvalidExtendedPublicKey)
+	}
+	// Accounts are at m/purpose'/coin'/account', see btcScriptTypesWithKeypaths().
+	if xpub.Depth() != 3 || xpub.ChildIndex() < hardenedKeystart ||
+		xpub.ChildIndex()-hardenedKeystart >= accountsHardLimit {
+		return "", errp.WithStack(ErrInvalidExtendedPublicKey)
+	}
+	accountNumber := uint16(xpub.ChildIndex() - hardenedKeystart)
+	var keypath *signing.AbsoluteKeypath
+	for _, cfg := range btcScriptTypesWithKeypaths(coinCode, accountNumber) {
+		if cfg.scriptType == scriptType {
+			keypath = &cfg.keypath
+			break
+		}
+	}
+	if keypath == nil {
+		return "", errp.Newf("script type %s is not supported for %s", scriptType, coinCode)
+	}
+	publicKey, err := xpub.ECPubKey()
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	// Signing configurations store the plain xpub format.
+	xpub, err = xpub.CloneWithVersion(btcCoin.Net().HDPublicKeyID[:])
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	xpubFingerprint := btcutil.Hash160(publicKey.SerializeCompressed())[:4]
+
+	if name == "" {
+		name = defaultAccountName(coin, accountNumber)
+	}
+	accountCode := watchOnlyAccountCode(xpubFingerprint, coinCode)
+	backend.log.
+		WithField("accountCode", accountCode).
+		WithField("coinCode", coinCode).
+		Info("Importing watch-only account")
+	watch := true
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		err := backend.persistAccount(config.Account{
+			Watch:     &watch,
+			WatchOnly: true,
+			CoinCode:  coinCode,
+			Name:      name,
+			Code:      accountCode,
+			SigningConfigurations: signing.Configurations{
+				signing.NewBitcoinConfiguration(scriptType, xpubFingerprint, *keypath, xpub),
+			},
+		}, accountsConfig)
+		if err != nil {
+			return err
+		}
+		keystore := accountsConfig.GetOrAddKeystore(xpubFingerprint)
+		keystore.Watchonly = true
+		if keystore.Name == "" {
+			keystore.Name = name
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return accountCode, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportWatchOnlyAccount(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	coin, err := b.Coin(coinpkg.CodeBTC)
+	require.NoError(t, err)
+	keypath := mustKeypath("m/84'/0'/0'")
+	xpub, err := keystoreHelper1().ExtendedPublicKey(coin, keypath)
+	require.NoError(t, err)
+	zpub, err := xpub.CloneWithVersion([]byte{0x04, 0xb2, 0x47, 0x46})
+	require.NoError(t, err)
+	tpub, err := xpub.CloneWithVersion(chaincfg.TestNet3Params.HDPublicKeyID[:])
+	require.NoError(t, err)
+	addressLevelXPub, err := xpub.Derive(0)
+	require.NoError(t, err)
+
+	for _, invalid := range []struct {
+		xpub       string
+		scriptType signing.ScriptType
+	}{
+		{"", signing.ScriptTypeP2WPKH},
+		{"not-an-xpub", signing.ScriptTypeP2WPKH},
+		// Private keys are rejected.
+		{"xprv9s21ZrQH143K3gie3VFLgx8JcmqZNsBcBc6vAdJrsf4bPRhx69U8qZe3EYAyvRWyQdEfz7ZpyYtL8jW2d2Lfkfh6g2zivq8JdZPQqxoxLwB", signing.ScriptTypeP2WPKH},
+		// Testnet key in a mainnet account.
+		{tpub.String(), signing.ScriptTypeP2WPKH},
+		// zpub with a different script type.
+		{zpub.String(), signing.ScriptTypeP2PKH},
+		// Not an account-level key.
+		{addressLevelXPub.String(), signing.ScriptTypeP2WPKH},
+	} {
+		_, err := b.ImportWatchOnlyAccount(coinpkg.CodeBTC, "", invalid.xpub, invalid.scriptType)
+		require.Equal(t, ErrInvalidExtendedPublicKey, errp.Cause(err), invalid.xpub)
+	}
+	require.Empty(t, b.Config().AccountsConfig().Accounts)
+
+	accountCode, err := b.ImportWatchOnlyAccount(
+		coinpkg.CodeBTC, "", " "+zpub.String()+"\n", signing.ScriptTypeP2WPKH)
+	require.NoError(t, err)
+	account := b.Accounts().lookup(accountCode)
+	require.NotNil(t, account)
+	require.True(t, account.Config().Config.WatchOnly)
+	require.Equal(t, "Bitcoin", account.Config().Config.Name)
+	signingConfigurations := account.Config().Config.SigningConfigurations
+	require.Len(t, signingConfigurations, 1)
+	require.Equal(t, signing.ScriptTypeP2WPKH, signingConfigurations[0].ScriptType())
+	require.Equal(t, keypath, signingConfigurations[0].AbsoluteKeypath())
+	require.Equal(t, xpub.String(), signingConfigurations[0].ExtendedPublicKey().String())
+
+	// Signing is refused without asking for a keystore.
+	_, err = account.Config().ConnectKeystore()
+	require.Equal(t, errors.ErrWatchOnly, errp.Cause(err))
+
+	// The same key can't be imported twice.
+	_, err = b.ImportWatchOnlyAccount(coinpkg.CodeBTC, "", xpub.String(), signing.ScriptTypeP2WPKH)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+
+	// The account stays loaded independent of the connected keystore.
+	b.registerKeystore(makeBitBox02Multi())
+	require.NotNil(t, b.Accounts().lookup(accountCode))
+	b.DeregisterKeystore()
+	require.NotNil(t, b.Accounts().lookup(accountCode))
+}
@@ -19,7 +19,7 @@ import type { ChartData } from '@/routes/account/summary/chart';
 import type { TDetailStatus } from './bitsurance';
 import type { SuccessResponse } from './response';
 
-export type NativeCoinCode = 'btc' | 'tbtc' | 'rbtc' | 'ltc' | 'tltc' | 'eth' | 'goeth' | 'sepeth';
+export type NativeCoinCode = 'btc' | 'tbtc' | 'tbtc4' | 'sbtc' | 'rbtc' | 'ltc' | 'tltc' | 'eth' | 'goeth' | 'sepeth';
 
 export type AccountCode = string;
 
@@ -27,7 +27,7 @@ export type Fiat = 'AUD' | 'BRL' | 'BTC' | 'CAD' | 'CHF' | 'CNY' | 'CZK' | 'EUR'
 
 export type ConversionUnit = Fiat | 'sat'
 
-export type CoinUnit = 'BTC' | 'sat' | 'LTC' | 'ETH' | 'TBTC' | 'tsat' | 'TLTC' | 'GOETH' | 'SEPETH';
+export type CoinUnit = 'BTC' | 'sat' | 'mBTC' | 'LTC' | 'ETH' | 'TBTC' | 'tsat' | 'mTBTC' | 'TLTC' | 'GOETH' | 'SEPETH';
 
 export type ERC20TokenUnit = 'USDT' | 'USDC' | 'LINK' | 'BAT' | 'MKR' | 'ZRX' | 'WBTC' | 'PAXG' | 'DAI';
 
@@ -58,6 +58,8 @@ export interface IAccount {
   keystore: TKeystore;
   active: boolean;
   watch: boolean;
+  // True if the account was imported from an extended public key, so nothing can be sent from it.
+  watchOnly: boolean;
   coinCode: CoinCode;
   coinUnit: string;
   coinName: string;
@@ -66,7 +68,12 @@ export interface IAccount {
   isToken: boolean;
   activeTokens?: IActiveToken[];
   blockExplorerTxPrefix: string;
+  // Empty if there is no block explorer or it has no page for addresses or blocks.
+  blockExplorerAddressPrefix: string;
+  blockExplorerBlockPrefix: string;
   bitsuranceStatus?: TDetailStatus;
+  // The script type of the receive addresses shown first, not set if the default order applies.
+  preferredScriptType?: ScriptType;
 }
 
 export const getAccounts = (): Promise<IAccount[]> => {
@@ -133,6 +140,9 @@ export interface IStatus {
     synced: boolean;
     fatalError: boolean;
     offlineError: string | null;
+    hasTransactions: boolean;
+    keystoreHasTransactions: boolean;
+    viewOnly: boolean;
 }
 
 export const getStatus = (code: AccountCode): Promise<IStatus> => {
@@ -166,8 +176,14 @@ export type TSigningConfiguration = {
     ethereumSimple: TEthereumSimple;
 }
 
+export type TDescriptors = {
+    receive: string;
+    change: string;
+}
+
 export type TSigningConfigurationList = null | {
     signingConfigurations: TSigningConfiguration[];
+    descriptors?: TDescriptors[];
 }
 
 export const getInfo = (code: AccountCode) => {
@@ -210,6 +226,17 @@ export interface IBalance {
     available: IAmount;
     hasIncoming: boolean;
     incoming: IAmount;
+    // Frozen coins are not included in `available`, BTC/LTC only.
+    hasFrozen?: boolean;
+    frozen?: IAmount;
+    // Change of our own unconfirmed transactions which can't be spent until it confirms, according
+    // to the account settings. Not included in `available`, BTC/LTC only.
+    hasPendingChange?: boolean;
+    pendingChange?: IAmount;
+    // Confirmed payments which are not verified against the block headers yet, if the account
+    // requires it. Included in `incoming`, BTC/LTC only.
+    hasUnverified?: boolean;
+    unverified?: IAmount;
 }
 
 export const getBalance = (code: AccountCode): Promise<IBalance> => {
@@ -220,24 +247,34 @@ export interface ITransaction {
     addresses: string[];
     amount: IAmount;
     amountAtTime: IAmount | null;
+    amountAtTimeIsLatest: boolean;
+    // Converted with the rates shown when the transaction was sent with the app.
+    amountAtSend?: IAmount;
+    feeAtSend?: IAmount;
     fee: IAmount;
     feeRatePerKb: IAmount;
+    grossIn?: IAmount;
+    grossOut?: IAmount;
     gas: number;
     nonce: number | null;
     internalID: string;
+    lastBroadcast?: string | null;
     note: string;
     numConfirmations: number;
     numConfirmationsComplete: number;
     size: number;
     status: 'complete' | 'pending' | 'failed';
     time: string | null;
-    type: 'send' | 'receive' | 'self';
+    type: 'send' | 'receive' | 'self' | 'complex';
     txID: string;
+    verified: boolean | null;
     vsize: number;
     weight: number;
 }
 
-export type TTransactions = { success: false } | { success: true; list: ITransaction[]; };
+export type TTransactions = { success: false } | { success: true; list: ITransaction[]; total: number; matched: number; };
+
+export type TTransactionsSummary = { success: false } | { success: true; total: number; newest: ITransaction[]; };
 
 export interface INoteTx {
     internalTxID: string;
@@ -251,18 +288,111 @@ export const postNotesTx = (code: AccountCode, {
   return apiPost(`account/${code}/notes/tx`, { internalTxID, note });
 };
 
+export const postNotesAddress = (
+  code: AccountCode,
+  addressID: string,
+  note: string,
+): Promise<null> => {
+  return apiPost(`account/${code}/notes/address`, { addressID, note });
+};
+
 export const proposeTxNote = (code: AccountCode, note: string): Promise<null> => {
   return apiPost(`account/${code}/propose-tx-note`, note);
 };
 
-export const getTransactionList = (code: AccountCode): Promise<TTransactions> => {
-  return apiGet(`account/${code}/transactions`);
+export type TTransactionsPage = {
+  offset: number;
+  limit: number;
+};
+
+export type TTransactionDirection = 'receive' | 'send' | 'sendSelf' | 'complex';
+
+export type TTransactionsFilter = {
+  direction?: TTransactionDirection[];
+  // Time range in RFC3339 format, both inclusive.
+  from?: string;
+  to?: string;
+  // Amount range in the unit of the coin, both inclusive.
+  minAmount?: string;
+  maxAmount?: string;
+  // Text contained in the note or in one of the addresses.
+  q?: string;
+};
+
+/**
+ * Returns the transactions of the account, newest first. If a page is given, only the
+ * transactions of that page are returned. `total` is the number of all transactions and
+ * `matched` the number of transactions matching the filter.
+ */
+export const getTransactionList = (
+  code: AccountCode,
+  page?: TTransactionsPage,
+  filter?: TTransactionsFilter,
+): Promise<TTransactions> => {
+  const params = new URLSearchParams();
+  if (page) {
+    params.set('offset', `${page.offset}`);
+    params.set('limit', `${page.limit}`);
+  }
+  if (filter) {
+    if (filter.direction && filter.direction.length > 0) {
+      params.set('direction', filter.direction.join(','));
+    }
+    (['from', 'to', 'minAmount', 'maxAmount', 'q'] as const).forEach(key => {
+      const value = filter[key];
+      if (value) {
+        params.set(key, value);
+      }
+    });
+  }
+  const query = params.toString();
+  return apiGet(`account/${code}/transactions${query ? `?${query}` : ''}`);
+};
+
+/**
+ * Returns the number of transactions of the account and the `newest` most recent ones.
+ */
+export const getTransactionsSummary = (
+  code: AccountCode,
+  newest: number,
+): Promise<TTransactionsSummary> => {
+  return apiGet(`account/${code}/transactions/summary?newest=${newest}`);
 };
 
 export const getTransaction = (code: AccountCode, id: ITransaction['internalID']): Promise<ITransaction | null> => {
   return apiGet(`account/${code}/transaction?id=${id}`);
 };
 
+export interface ITransactionInput {
+    outPoint: string;
+    address: string;
+    value: IAmount | null;
+    ours: boolean;
+}
+
+export interface ITransactionOutput {
+    index: number;
+    address: string;
+    value: IAmount;
+    ours: boolean;
+    change: boolean;
+}
+
+export interface ITransactionDetails {
+    txID: string;
+    inputs: ITransactionInput[];
+    outputs: ITransactionOutput[];
+    size: number;
+    vsize: number;
+    weight: number;
+    fee: IAmount | null;
+    feeRate: string | null;
+}
+
+export const getTransactionDetails = (code: AccountCode, txID: string): Promise<ITransactionDetails | null> => {
+  return apiGet(`account/${code}/transaction-details?id=${txID}`);
+};
+
 export interface IExport {
     success: boolean;
     path: string;
@@ -273,6 +403,18 @@ export const exportAccount = (code: AccountCode): Promise<IExport | null> => {
   return apiPost(`account/${code}/export`);
 };
 
+/**
+ * Exports the UTXOs of a Bitcoin-based account with their merkle proofs as a versioned JSON file.
+ * If `proofOfReservesMessage` is not empty, the message is signed with every address holding a UTXO.
+ * Resolves to null if the user canceled choosing the file.
+ */
+export const exportUTXOs = (
+  code: AccountCode,
+  proofOfReservesMessage: string = '',
+): Promise<Omit<IExport, 'path'> | null> => {
+  return apiPost(`account/${code}/export-utxos`, { proofOfReservesMessage });
+};
+
 export const verifyXPub = (
   code: AccountCode,
   signingConfigIndex: number,
@@ -283,11 +425,17 @@ export const verifyXPub = (
 export interface IReceiveAddress {
     addressID: string;
     address: string;
+    reused?: boolean;
+    note?: string;
 }
 
 export interface ReceiveAddressList {
     scriptType: ScriptType | null;
     addresses: IReceiveAddress[];
+    usedAddresses?: IReceiveAddress[];
+    // Number of addresses that can still be handed out before the gap limit is reached. BTC only.
+    headroom?: number;
+    warning?: 'gapLimitLow';
 }
 
 export const getReceiveAddressList = (code: AccountCode) => {
@@ -296,6 +444,71 @@ export const getReceiveAddressList = (code: AccountCode) => {
   };
 };
 
+export type TReceiveAddressResult = IReceiveAddress & {
+  warning?: 'addressReused';
+};
+
+export const getReceiveAddress = (
+  code: AccountCode,
+  addressID: string,
+): Promise<TReceiveAddressResult> => {
+  return apiGet(`account/${code}/receive-address?addressID=${encodeURIComponent(addressID)}`);
+};
+
+export type TNextReceiveAddress = {
+  success: true;
+  address: string;
+  addressID: string;
+  headroom: number;
+  warning?: 'gapLimitLow';
+} | {
+  success: false;
+  errorCode: 'gapLimitExhausted';
+  // The receive gap limit offered by extendReceiveGapLimit(), if it can still be increased.
+  gapLimitExtension?: number;
+};
+
+/**
+ * Hands out the next receive address of the given script type, or of the preferred one if null.
+ */
+export const nextReceiveAddress = (
+  code: AccountCode,
+  scriptType: ScriptType | null,
+): Promise<TNextReceiveAddress> => {
+  return apiPost(`account/${code}/receive-address/next`, { scriptType });
+};
+
+export const extendReceiveGapLimit = (
+  code: AccountCode,
+): Promise<{ success: true; gapLimit: number }> => {
+  return apiPost(`account/${code}/receive-gap-limit/extend`);
+};
+
+export type TPaymentURI = {
+  success: true;
+  // BIP21 URI, e.g. `bitcoin:<address>?amount=0.001&label=...`.
+  uri: string;
+  // PNG data URI of the QR code of the URI.
+  qrCode: string;
+} | {
+  success: false;
+  errorCode: 'invalidAmount';
+};
+
+/**
+ * Returns the BIP21 payment URI and its QR code for a receive address. The amount is optional
+ * and given in the unit selected for display.
+ */
+export const getPaymentURI = (
+  code: AccountCode,
+  addressID: string,
+  amount: string,
+  label: string,
+): Promise<TPaymentURI> => {
+  const params = new URLSearchParams({ addressID, amount, label });
+  return apiGet(`account/${code}/payment-uri?${params.toString()}`);
+};
+
 export type TTxInput = {
   address: string;
   amount: string;
@@ -303,6 +516,45 @@ export type TTxInput = {
   customFee: string;
   sendAll: 'yes' | 'no';
   selectedUTXOs: string[],
+  opReturnData?: string;
+  // Decimal seed to reproduce the order of the inputs and outputs, BTC/LTC only.
+  selectionSeed?: string;
+  // Requests the selection trace in the response, BTC/LTC only.
+  debug?: boolean;
+  // Replaces address, amount and sendAll to pay multiple recipients in one transaction, BTC/LTC
+  // only. Sending all is only possible with a single recipient.
+  recipients?: TRecipient[];
+  // Allows spending frozen outputs selected in `selectedUTXOS`, BTC/LTC only.
+  spendFrozen?: boolean;
+  // Raises the fee if unconfirmed outputs are spent, so that the transaction and its unconfirmed
+  // parents pay the fee rate together, BTC/LTC only.
+  coverUnconfirmedParents?: boolean;
+};
+
+export type TRecipient = {
+  address: string;
+  amount: string;
+  sendAll?: 'yes' | 'no';
+};
+
+export type TSelectionCandidate = {
+  outpoint: string;
+  value: number;
+  scriptType: ScriptType;
+  selected: boolean;
+  reason?: 'targetReached' | 'unconfirmed' | 'coinControl' | 'flagged' | 'frozen' | 'dust';
+};
+
+export type TSelectionTrace = {
+  seed: string;
+  feeRounds: number;
+  candidates: TSelectionCandidate[];
+  change: 'added' | 'dust' | 'none';
+};
+
+export type TFlaggedOutput = {
+  outPoint: string;
+  label: string;
 };
 
 export type TTxProposalResult = {
@@ -310,8 +562,18 @@ export type TTxProposalResult = {
   fee: IAmount;
   success: true;
   total: IAmount;
+  ratesSnapshotID: string | null;
+  // Inputs flagged by the user, BTC/LTC only.
+  flaggedInputs?: TFlaggedOutput[];
+  // Spent outpoints which are not confirmed yet, e.g. change, BTC/LTC only.
+  unconfirmedInputs?: string[];
+  debug?: {
+    selection: TSelectionTrace | null;
+  };
 } | {
   errorCode: string;
+  // The index of the invalid recipient if `recipients` was given.
+  recipientIndex?: number;
   success: false;
 };
 
@@ -327,12 +589,81 @@ export interface ISendTx {
     success?: boolean;
     errorMessage?: string;
     errorCode?: string;
+    ratesSnapshotID?: string | null;
 }
 
 export const sendTx = (code: AccountCode): Promise<ISendTx> => {
   return apiPost(`account/${code}/sendtx`);
 };
 
+export type TConsolidationInput = {
+  feeTarget: FeeTargetCode;
+  customFee: string;
+  // Fee rate in sat/vB expected when the coins are spent later. Defaults to the normal fee target.
+  futureFee?: string;
+  // Defaults to the maximum of 600.
+  maxInputs?: number;
+};
+
+export type TConsolidationProposalResult = {
+  success: true;
+  inputs: number;
+  amount: IAmount;
+  fee: IAmount;
+  // Fee expected to be saved when spending the consolidated coins later.
+  saving: IAmount;
+  total: IAmount;
+  ratesSnapshotID: string | null;
+} | {
+  errorCode: string;
+  success: false;
+};
+
+// Proposes a transaction consolidating the smallest coins of a BTC/LTC account into one. It is
+// signed and sent with sendTx().
+export const consolidationProposal = (
+  code: AccountCode,
+  input: TConsolidationInput,
+): Promise<TConsolidationProposalResult> => {
+  return apiPost(`account/${code}/consolidation-proposal`, input);
+};
+
+export type TSweepInput = {
+  // Private key in Wallet Import Format. It is only used to sign the sweep transaction and is not
+  // stored.
+  wif: string;
+  feeTarget: FeeTargetCode;
+  customFee: string;
+};
+
+export type TSweepResult = {
+  success: true;
+  txID: string;
+  amount: IAmount;
+  fee: IAmount;
+  total: IAmount;
+} | {
+  errorCode: string;
+  success: false;
+};
+
+// Creates and signs a transaction sending all funds of a private key, e.g. of a paper wallet, to
+// the next receive address of a BTC/LTC account, without broadcasting it.
+export const sweepProposal = (
+  code: AccountCode,
+  input: TSweepInput,
+): Promise<TSweepResult> => {
+  return apiPost(`account/${code}/sweep-proposal`, input);
+};
+
+// Like sweepProposal(), but also broadcasts the transaction.
+export const sweep = (
+  code: AccountCode,
+  input: TSweepInput,
+): Promise<TSweepResult> => {
+  return apiPost(`account/${code}/sweep`, input);
+};
+
 export type FeeTargetCode = 'custom' | 'low' | 'economy' | 'normal' | 'high';
 
 export interface IProposeTxData {
@@ -369,6 +700,23 @@ export const verifyAddress = (code: AccountCode, addressID: string): Promise<boo
   return apiPost(`account/${code}/verify-address`, addressID);
 };
 
+export type TAddressValidationErrorCode = 'invalidAddress'
+  | 'addressWrongNetwork'
+  | 'addressInvalidChecksum'
+  | 'addressUnsupportedType';
+
+export type TValidateAddressResult = {
+  success: true;
+  addressType: string;
+} | {
+  success: false;
+  errorCode: TAddressValidationErrorCode;
+};
+
+export const validateAddress = (code: AccountCode, address: string): Promise<TValidateAddressResult> => {
+  return apiPost(`account/${code}/validate-address`, address);
+};
+
 export type TUTXO = {
   outPoint: string;
   txId: string;
@@ -378,12 +726,42 @@ export type TUTXO = {
   note: string;
   scriptType: ScriptType;
   addressReused: boolean;
+  // Label of the origin if the output is in the flag list of the account, empty otherwise.
+  flag: string;
+  // Frozen outputs are only spent if selected and `spendFrozen` is set.
+  frozen: boolean;
 };
 
 export const getUTXOs = (code: AccountCode): Promise<TUTXO[]> => {
   return apiGet(`account/${code}/utxos`);
 };
 
+// Maps flagged addresses and outpoints to the label of the origin of the coins.
+export type TFlags = { [addressOrOutPoint: string]: string };
+
+export const getFlags = (code: AccountCode): Promise<TFlags> => {
+  return apiGet(`account/${code}/flags`);
+};
+
+export const setFlags = (
+  code: AccountCode,
+  flags: TFlags,
+): Promise<{ success: true } | { success: false; errorMessage: string }> => {
+  return apiPost(`account/${code}/flags`, flags);
+};
+
+export const getFrozenOutputs = (code: AccountCode): Promise<string[]> => {
+  return apiGet(`account/${code}/frozen-outputs`);
+};
+
+export const setOutputFrozen = (
+  code: AccountCode,
+  outPoint: string,
+  frozen: boolean,
+): Promise<{ success: true } | { success: false; errorMessage: string }> => {
+  return apiPost(`account/${code}/frozen-outputs`, { outPoint, frozen });
+};
+
 type TSecureOutput = {
     hasSecureOutput: boolean;
     optional: boolean;
@@ -409,10 +787,42 @@ export const addAccount = (coinCode: string, name: string): Promise<TAddAccount>
   });
 };
 
+export type TImportWatchOnlyAccount = {
+  success: boolean;
+  accountCode?: string;
+  errorCode?: 'accountAlreadyExists' | 'invalidExtendedPublicKey';
+  errorMessage?: string;
+}
+
+/**
+ * Imports a watch-only account from an account-level extended public key (xpub/ypub/zpub).
+ * Nothing can be sent from such an account.
+ */
+export const importWatchOnlyAccount = (
+  coinCode: string,
+  name: string,
+  extendedPublicKey: string,
+  scriptType: ScriptType,
+): Promise<TImportWatchOnlyAccount> => {
+  return apiPost('account-import-watch-only', {
+    coinCode,
+    name,
+    extendedPublicKey,
+    scriptType,
+  });
+};
+
 export const connectKeystore = (code: AccountCode): Promise<{ success: boolean; }> => {
   return apiPost(`account/${code}/connect-keystore`);
 };
 
+export const rescan = (
+  code: AccountCode,
+  fromHeight: number = 0,
+): Promise<{ success: boolean; errorMessage?: string; }> => {
+  return apiPost(`account/${code}/rescan`, { fromHeight });
+};
+
 export type TSignMessage = { success: false, aborted?: boolean; errorMessage?: string; } | { success: true; signature: string; }
 
 export type TSignWalletConnectTx = {
@@ -0,0 +1,78 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import type { AccountCode, FeeTargetCode, IAmount } from './account';
+import { apiGet, apiPost } from '@/utils/request';
+import { TSubscriptionCallback, subscribeEndpoint } from './subscribe';
+
+export type TAccountRotationStage = 'created' | 'sending' | 'broadcast' | 'done' | 'canceled';
+
+export type TAccountRotation = {
+  newAccountCode: AccountCode;
+  stage: TAccountRotationStage;
+};
+
+export type TAccountRotationStatus = TAccountRotation & {
+  accountCode: AccountCode;
+};
+
+export type TAccountRotationWarning = 'privacy' | 'highFee';
+
+export type TAccountRotationProposal = {
+  recipientAddress: string;
+  amount: IAmount;
+  fee: IAmount;
+  total: IAmount;
+  warnings: TAccountRotationWarning[];
+};
+
+type TErrorResponse = {
+  success: false;
+  errorMessage?: string;
+  errorCode?: string;
+};
+
+export const getAccountRotation = (code: AccountCode): Promise<TAccountRotation | null> => {
+  return apiGet(`account-rotation/${code}`);
+};
+
+export const startAccountRotation = (
+  code: AccountCode,
+): Promise<TErrorResponse | { success: true; newAccountCode: AccountCode; }> => {
+  return apiPost(`account-rotation/${code}/start`);
+};
+
+export const proposeAccountRotation = (
+  code: AccountCode,
+  feeTarget: FeeTargetCode | '',
+  customFee: string = '',
+): Promise<TErrorResponse | { success: true; proposal: TAccountRotationProposal; }> => {
+  return apiPost(`account-rotation/${code}/proposal`, { feeTarget, customFee });
+};
+
+export const sendAccountRotation = (code: AccountCode): Promise<TErrorResponse | { success: true; }> => {
+  return apiPost(`account-rotation/${code}/send`);
+};
+
+export const cancelAccountRotation = (code: AccountCode): Promise<TErrorResponse | { success: true; }> => {
+  return apiPost(`account-rotation/${code}/cancel`);
+};
+
+export const syncAccountRotation = (
+  cb: TSubscriptionCallback<TAccountRotationStatus>
+) => (
+  subscribeEndpoint('account-rotation', cb)
+);
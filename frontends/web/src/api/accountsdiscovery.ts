@@ -0,0 +1,56 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import type { AccountCode, CoinCode } from './account';
+import { apiGet, apiPost } from '@/utils/request';
+import { TSubscriptionCallback, subscribeEndpoint } from './subscribe';
+
+export type TAccountsDiscoveryStatus = 'running' | 'done' | 'failed' | 'canceled';
+
+export type TAccountsDiscovery = {
+  coinCode: CoinCode;
+  status: TAccountsDiscoveryStatus;
+  lastCheckedAccountNumber: number;
+  usedAccounts: number;
+  failedAccountCode?: AccountCode;
+  errorCode?: 'accountsDiscoveryInitialize' | 'accountsDiscoveryTransactions';
+};
+
+type TResponse = {
+  success: true;
+} | {
+  success: false;
+  errorMessage?: string;
+  errorCode?: string;
+};
+
+export const getAccountsDiscovery = (): Promise<TAccountsDiscovery[]> => {
+  return apiGet('accounts-discovery');
+};
+
+export const retryAccountsDiscovery = (coinCode: CoinCode): Promise<TResponse> => {
+  return apiPost(`accounts-discovery/${coinCode}/retry`);
+};
+
+export const cancelAccountsDiscovery = (coinCode: CoinCode): Promise<TResponse> => {
+  return apiPost(`accounts-discovery/${coinCode}/cancel`);
+};
+
+export const syncAccountsDiscovery = (
+  cb: TSubscriptionCallback<TAccountsDiscovery>
+) => (
+  subscribeEndpoint('accounts-discovery', cb)
+);
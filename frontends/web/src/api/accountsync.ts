@@ -15,6 +15,7 @@
  */
 
 import { TUnsubscribe } from '@/utils/transport-common';
+import { apiPost } from '@/utils/request';
 import * as accountAPI from './account';
 import { TSubscriptionCallback, subscribeEndpoint } from './subscribe';
 import { subscribe as subscribeLegacy } from '@/utils/event-legacy';
@@ -76,3 +77,109 @@ export const syncdone = (
     }
   });
 };
+
+export type TSyncProgress = {
+  addressesSubscribed: number;
+  addressesTotal: number;
+  historiesFetched: number;
+  historiesTotal: number;
+  transactionsIndexed: number;
+};
+
+/**
+ * Subscribes the given function on the "syncProgress" event, fired while
+ * the account is syncing, at most every few hundred milliseconds. The counts
+ * start at zero with every "syncstarted" event.
+ * Returns a method to unsubscribe.
+ */
+export const syncProgress = (
+  cb: (code: accountAPI.AccountCode, progress: TSyncProgress) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('syncProgress', event => {
+    if (event.type === 'account' && event.code && event.meta) {
+      cb(event.code, event.meta);
+    }
+  });
+};
+
+export type TNewTransaction = {
+  txID: string;
+  internalID: string;
+  direction: 'receive' | 'send' | 'sendSelf';
+  amount: accountAPI.IAmount;
+  address: string;
+  numConfirmations: number;
+  // Position of the tx in the transaction list, newest first, -1 if not listed.
+  index: number;
+  // Number of transactions in the transaction list.
+  total: number;
+};
+
+/**
+ * Subscribes the given function on the "newTransaction" event, fired once
+ * when a new transaction is seen and once more when it gets its first
+ * confirmation. Not fired for the transaction history of newly added accounts.
+ * Returns a method to unsubscribe.
+ */
+export const newTransaction = (
+  cb: (code: accountAPI.AccountCode, tx: TNewTransaction) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('newTransaction', event => {
+    if (event.type === 'account' && event.code && event.meta) {
+      cb(event.code, event.meta);
+    }
+  });
+};
+
+export type TFirstTransaction = {
+  firstForKeystore: boolean;
+};
+
+/**
+ * Subscribes the given function on the "firstTransaction" event, fired once
+ * when the very first transaction of an account arrives. Not fired for the
+ * transaction history of newly added or restored accounts.
+ * Returns a method to unsubscribe.
+ */
+export const firstTransaction = (
+  cb: (code: accountAPI.AccountCode, meta: TFirstTransaction) => void,
+): TUnsubscribe => {
+  return subscribeLegacy('firstTransaction', event => {
+    if (event.type === 'account' && event.code && event.meta) {
+      cb(event.code, event.meta);
+    }
+  });
+};
+
+export type TTxConfirmations = {
+  txID: string;
+  numConfirmations: number;
+};
+
+/**
+ * Subscribes the given function on the "account/<CODE>/tx-confirmations" event,
+ * fired when a transaction of a BTC account confirms, reaches the number of
+ * confirmations to be complete, or falls back below one of these after a reorg.
+ * Returns a method to unsubscribe.
+ */
+export const syncTxConfirmations = (
+  code: accountAPI.AccountCode,
+  cb: (txConfirmations: TTxConfirmations) => void,
+): TUnsubscribe => {
+  return subscribeEndpoint(`account/${code}/tx-confirmations`, cb);
+};
+
+/**
+ * Registers interest in the events of the given account. The backend only
+ * sends account events (e.g. statusChanged, syncdone, synced-addresses-count)
+ * for accounts which are observed by at least one view.
+ * Returns a method to unobserve.
+ */
+export const observeAccount = (
+  code: accountAPI.AccountCode,
+): TUnsubscribe => {
+  apiPost('account-observe', code).catch(console.error);
+  return () => {
+    apiPost('account-unobserve', code).catch(console.error);
+  };
+};
@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-import type { AccountCode, CoinCode, ERC20CoinCode } from './account';
+import type { AccountCode, CoinCode, ERC20CoinCode, Fiat, IAmount, ITransaction, ScriptType } from './account';
 import type { FailResponse, SuccessResponse } from './response';
 import { apiGet, apiPost } from '@/utils/request';
 import { TSubscriptionCallback, subscribeEndpoint } from './subscribe';
@@ -40,6 +40,61 @@ export const setAccountActive = (accountCode: AccountCode, active: boolean): Pro
   return apiPost('set-account-active', { accountCode, active });
 };
 
+export const setAccountShowUsedAddresses = (accountCode: AccountCode, show: boolean): Promise<ISuccess> => {
+  return apiPost('set-account-show-used-addresses', { accountCode, show });
+};
+
+export const setAccountMinConfirmations = (
+  accountCode: AccountCode,
+  minConfirmations: number,
+  spendUnconfirmedChange: boolean,
+): Promise<ISuccess> => {
+  return apiPost('set-account-min-confirmations', { accountCode, minConfirmations, spendUnconfirmedChange });
+};
+
+// Outputs below the threshold in satoshi are hidden, unless they are our own and `showOwnDust` is
+// set. 0 hides nothing.
+export const setAccountDustThreshold = (
+  accountCode: AccountCode,
+  dustThreshold: number,
+  showOwnDust: boolean,
+): Promise<ISuccess> => {
+  return apiPost('set-account-dust-threshold', { accountCode, dustThreshold, showOwnDust });
+};
+
+export const setAccountRequireVerifiedTxs = (
+  accountCode: AccountCode,
+  requireVerifiedTxs: boolean,
+): Promise<ISuccess> => {
+  return apiPost('set-account-require-verified-txs', { accountCode, requireVerifiedTxs });
+};
+
+// An empty script type resets the preference.
+export const setAccountPreferredScriptType = (
+  accountCode: AccountCode,
+  scriptType: ScriptType | '',
+): Promise<ISuccess> => {
+  return apiPost('set-account-preferred-script-type', { accountCode, scriptType });
+};
+
+export const getViewOnly = (): Promise<boolean> => {
+  return apiGet('view-only');
+};
+
+export type TSetViewOnlyResult = ISuccess & {
+  aborted?: boolean;
+};
+
+export const setViewOnly = (viewOnly: boolean): Promise<TSetViewOnlyResult> => {
+  return apiPost('set-view-only', viewOnly);
+};
+
+export const subscribeViewOnly = (
+  cb: TSubscriptionCallback<boolean>
+) => {
+  return subscribeEndpoint('view-only', cb);
+};
+
 export const setTokenActive = (
   accountCode: AccountCode,
   tokenCode: ERC20CoinCode,
@@ -56,6 +111,66 @@ export const reinitializeAccounts = (): Promise<null> => {
   return apiPost('accounts/reinitialize');
 };
 
+/**
+ * Makes the blockchain connections which wait to retry after all servers failed connect
+ * immediately, e.g. when the network connectivity was restored.
+ */
+export const retryConnections = (): Promise<null> => {
+  return apiPost('retry-connections');
+};
+
+export const setActiveFiats = (fiats: Fiat[]): Promise<ISuccess> => {
+  return apiPost('rates/active-fiats', fiats);
+};
+
+export type TLatestRates = {
+  rates: { [unit: string]: { [fiat: string]: number } } | null;
+  // RFC 3339 time the rates were fetched, possibly loaded from disk.
+  timestamp: string;
+  stale: boolean;
+};
+
+/**
+ * Subscribes to the latest exchange rates. Rates which could not be updated recently are
+ * marked as stale.
+ */
+export const subscribeLatestRates = (
+  cb: TSubscriptionCallback<TLatestRates>
+) => {
+  return subscribeEndpoint('rates', cb);
+};
+
+export type TRatesUpdateFailed = {
+  error: string;
+  // Seconds after which fetching the rates is retried.
+  retryInterval: number;
+};
+
+/**
+ * Subscribes to failures to fetch the latest exchange rates. The rates are temporarily
+ * unavailable until the next update received via subscribeLatestRates().
+ */
+export const subscribeRatesUpdateFailed = (
+  cb: TSubscriptionCallback<TRatesUpdateFailed>
+) => {
+  return subscribeEndpoint('rates/update-failed', cb);
+};
+
+export type TAccountCacheQuarantined = {
+  code: AccountCode;
+  name: string;
+};
+
+/**
+ * Subscribes to accounts whose cached data did not match the keystore. The cache was discarded
+ * and the account is synced from scratch.
+ */
+export const subscribeAccountCacheQuarantined = (
+  cb: TSubscriptionCallback<TAccountCacheQuarantined>
+) => {
+  return subscribeEndpoint('accounts/cache-quarantined', cb);
+};
+
 export const getTesting = (): Promise<boolean> => {
   return apiGet('testing');
 };
@@ -111,6 +226,17 @@ export const setWatchonly = (rootFingerprint: string, watchonly: boolean): Promi
   return apiPost('set-watchonly', { rootFingerprint, watchonly });
 };
 
+export type TWalletBirthday = {
+  // RFC 3339 date. Omitted if the birthday is a block height.
+  date?: string;
+  // Block height. Omitted if the birthday is a date.
+  height?: number;
+};
+
+export const setWalletBirthday = (rootFingerprint: string, birthday: TWalletBirthday | null): Promise<ISuccess> => {
+  return apiPost('set-wallet-birthday', { rootFingerprint, birthday });
+};
+
 export const authenticate = (force: boolean = false): Promise<void> => {
   return apiPost('authenticate', force);
 };
@@ -144,6 +270,9 @@ export const exportNotes = (): Promise<(FailResponse & { aborted: boolean; }) |
 export type TImportNotes = {
   accountCount: number;
   transactionCount: number;
+  addressCount: number;
+  inputCount: number;
+  outputCount: number;
 };
 
 export const importNotes = (fileContents: ArrayBuffer): Promise<FailResponse | (SuccessResponse & { data: TImportNotes; })> => {
@@ -152,3 +281,36 @@ export const importNotes = (fileContents: ArrayBuffer): Promise<FailResponse | (
     .join('');
   return apiPost('notes/import', hexString);
 };
+
+export type TSearchMatch = 'txID' | 'address' | 'note' | 'amount';
+
+export type TSearchHit = {
+  internalID: string;
+  txID: string;
+  matches: TSearchMatch[];
+  type: ITransaction['type'];
+  amount: IAmount;
+  time: string | null;
+  numConfirmations: number;
+  note: string;
+};
+
+export type TSearchAccountResult = {
+  accountCode: AccountCode;
+  accountName: string;
+  coinCode: CoinCode;
+  archived: boolean;
+  hits: TSearchHit[];
+};
+
+export type TSearchResult = {
+  accounts: TSearchAccountResult[];
+  truncated: boolean;
+};
+
+export const searchTransactions = (
+  query: string,
+  includeArchived: boolean = false,
+): Promise<TSearchResult> => {
+  return apiGet(`search?q=${encodeURIComponent(query)}&includeArchived=${includeArchived}`);
+};
@@ -15,17 +15,21 @@
  */
 
 import { subscribeEndpoint, TSubscriptionCallback } from './subscribe';
-import type { CoinCode, Fiat } from './account';
+import type { CoinCode, Fiat, ScriptType, TValidateAddressResult } from './account';
 import type { ISuccess } from './backend';
 import { apiPost, apiGet } from '@/utils/request';
 
-export type BtcUnit = 'default' | 'sat';
+export type BtcUnit = 'default' | 'sat' | 'mBTC';
 
 export type TStatus = {
     targetHeight: number;
     tip: number;
     tipAtInitTime: number;
     tipHashHex: string;
+    percentage: number;
+    headersPerSecond: number;
+    etaSeconds: number | null;
+    claimedTipGap: number;
 }
 
 export const subscribeCoinHeaders = (coinCode: CoinCode) => (
@@ -38,6 +42,90 @@ export const setBtcUnit = (unit: BtcUnit): Promise<ISuccess> => {
   return apiPost('coins/btc/set-unit', { unit });
 };
 
+export type TAddressPreview = {
+  scriptType: ScriptType;
+  keypath: string;
+  address: string;
+};
+
+export const getAddressPreview = (coinCode: CoinCode): Promise<TAddressPreview[]> => {
+  return apiGet(`coins/${coinCode}/address-preview`);
+};
+
+export type TBlockExplorer = {
+  name: string;
+  url: string;
+};
+
+export type TBlockExplorers = {
+  available: TBlockExplorer[];
+  selected: string;
+};
+
+export const getBlockExplorers = (coinCode: CoinCode): Promise<TBlockExplorers> => {
+  return apiGet(`coins/${coinCode}/block-explorers`);
+};
+
+export const setBlockExplorer = (coinCode: CoinCode, url: string): Promise<ISuccess> => {
+  return apiPost(`coins/${coinCode}/block-explorer`, url);
+};
+
+export const subscribeBlockExplorer = (coinCode: CoinCode) => (
+  (cb: TSubscriptionCallback<TBlockExplorer>) => (
+    subscribeEndpoint(`coins/${coinCode}/block-explorer`, cb)
+  )
+);
+
+export type TServerInfo = {
+  server: string;
+  software: string;
+  protocolVersion: string;
+  addressFamily: '' | 'ipv4' | 'ipv6';
+  banner: string;
+};
+
+export type TServerInfoResponse = {
+  success: true;
+  serverInfo: TServerInfo;
+} | {
+  success: false;
+  errorMessage: string;
+};
+
+export const getServerInfo = (coinCode: CoinCode): Promise<TServerInfoResponse> => {
+  return apiGet(`coins/${coinCode}/server-info`);
+};
+
+export type TConnectionState = 'connecting' | 'connected' | 'failover' | 'disconnected';
+
+export type TConnectionStatus = {
+  state: TConnectionState;
+  server: string;
+  error: string | null;
+  nextAttempt: string | null;
+};
+
+export const getConnectionStatus = (coinCode: CoinCode): Promise<TConnectionStatus> => {
+  return apiGet(`coins/${coinCode}/connection/status`);
+};
+
+/**
+ * Subscribes to the connection state of a coin's blockchain backend, which changes on every
+ * (re)connect and failover independent of the sync progress.
+ */
+export const subscribeConnectionStatus = (coinCode: CoinCode) => (
+  (cb: TSubscriptionCallback<TConnectionStatus>) => (
+    subscribeEndpoint(`coins/${coinCode}/connection/status`, cb)
+  )
+);
+
+export const validateCoinAddress = (
+  coinCode: CoinCode,
+  address: string,
+): Promise<TValidateAddressResult> => {
+  return apiPost(`coins/${coinCode}/validate-address`, address);
+};
+
 export type TAmount = {
   success: boolean;
   amount: string;
@@ -84,3 +172,27 @@ export const convertToCurrency = ({
 }: TConvertCurrency): Promise<TConvertToCurrencyResponse> => {
   return apiGet(`coins/convert-to-plain-fiat?from=${coinCode}&to=${fiatUnit}&amount=${amount}`);
 };
+
+export type TCoinMetadata = {
+  code: CoinCode;
+  name: string;
+  unit: string;
+  decimals: number;
+  testnet: boolean;
+  scriptTypes: ScriptType[];
+  explorer: {
+    name: string;
+    // URL with `{txid}` in place of the transaction ID.
+    txURLTemplate: string;
+    // URL with `{address}` in place of the address.
+    addressURLTemplate: string;
+  };
+  // Stable icon identifier, e.g. 'btc'. Testnets use the icon of their mainnet.
+  icon: string;
+  // Set for tokens, e.g. 'eth' for ERC20 tokens.
+  parentCode?: CoinCode;
+};
+
+export const getCoinsMetadata = (): Promise<TCoinMetadata[]> => {
+  return apiGet('coins/metadata');
+};
@@ -29,10 +29,16 @@ export const downloadCert = (electrumServer: string): Promise<TCertResponse> =>
   return apiPost('certs/download', electrumServer);
 };
 
+export type TServerType = 'electrum' | 'esplora';
+
 export type TElectrumServer = {
   server: string;
   tls: boolean;
   pemCert: string;
+  // Optional hex encoded SHA-256 fingerprint of the server certificate.
+  certFingerprint?: string;
+  // If not set, http(s) URLs are Esplora servers and everything else is an Electrum server.
+  type?: TServerType;
 };
 
 type TCheckElectrumResponse = SuccessResponse | {
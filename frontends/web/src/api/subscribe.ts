@@ -58,3 +58,14 @@ export const backendConnected = (
 ): TUnsubscribe => {
   return subscribeEndpoint('backend/connected', cb);
 };
+
+/**
+ * Subscribes the given function to the backend/resync event, which
+ * the backend sends if it had to drop events because they were not
+ * received fast enough. The frontend state is outdated in this case.
+ */
+export const backendResync = (
+  cb: () => void
+): TUnsubscribe => {
+  return subscribeEndpoint('backend/resync', cb);
+};
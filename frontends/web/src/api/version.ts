@@ -25,7 +25,15 @@ export type TUpdateFile = {
     description: string;
 }
 
-export const getVersion = (): Promise<string> => {
+/**
+ * Describes the app version and whether the app is in view-only mode, in which sending is disabled.
+ */
+export type TVersion = {
+    version: string;
+    viewOnly: boolean;
+}
+
+export const getVersion = (): Promise<TVersion> => {
   return apiGet('version');
 };
 
@@ -29,6 +29,7 @@ import { getDeviceList } from './api/devices';
 import { syncDeviceList } from './api/devicessync';
 import { syncNewTxs } from './api/transactions';
 import { notifyUser } from './api/system';
+import { retryConnections } from './api/backend';
 import { ConnectedApp } from './connected';
 import { Alert } from './components/alert/Alert';
 import { Aopp } from './components/aopp/aopp';
@@ -62,6 +63,14 @@ export const App = () => {
     });
   }, [t]);
 
+  useEffect(() => {
+    // Don't wait for the next scheduled reconnection attempt when the network comes back, e.g.
+    // when airplane mode is turned off.
+    const onOnline = () => retryConnections();
+    window.addEventListener('online', onOnline);
+    return () => window.removeEventListener('online', onOnline);
+  }, []);
+
   const maybeRoute = useCallback(() => {
     const currentURL = window.location.pathname;
     const isIndex = currentURL === '/' || currentURL === '/index.html' || currentURL === '/android_asset/web/index.html' || currentURL.endsWith('/assets/web/index.html');
@@ -91,6 +91,18 @@ describe('Amount formatting', () => {
         expect(validateSpacing(values, allSpacedElements)).toBeTruthy();
       });
 
+      it('12\'345\'678 ' + coin + ' with thousands separators gets spaced', () => {
+        const { getByTestId } = render(<Amount amount="12'345'678" unit={coin} />);
+        const blocks = getByTestId('amountBlocks');
+        const values = [
+          '12',
+          '345',
+          '678',
+        ];
+        const allSpacedElements = [...blocks.children];
+        expect(validateSpacing(values, allSpacedElements)).toBeTruthy();
+      });
+
       it('1234567 ' + coin + ' with removeBtcTrailingZeroes enabled gets spaced', () => {
         const { getByTestId } = render(<Amount amount="1234567" unit={coin} removeBtcTrailingZeroes/>);
         const blocks = getByTestId('amountBlocks');
@@ -268,21 +280,25 @@ describe('Amount formatting', () => {
   describe('fiat amounts', () => {
     let fiatCoins: ConversionUnit[] = ['USD', 'EUR', 'CHF'];
     fiatCoins.forEach(coin => {
-      it('1\'340.25 ' + coin + ' with removeBtcTrailingZeroes enabled stays 1\'340.25', () => {
-        const { container } = render(<Amount amount="1'340.25" unit={coin} removeBtcTrailingZeroes/>);
+      it('1’340.25 ' + coin + ' with removeBtcTrailingZeroes enabled stays 1’340.25', () => {
+        const { container } = render(<Amount amount="1’340.25" unit={coin} removeBtcTrailingZeroes/>);
         expect(container).toHaveTextContent('1’340.25');
       });
       it('218.00 ' + coin + ' with removeBtcTrailingZeroes enabled stays 218.00', () => {
         const { container } = render(<Amount amount="218.00" unit={coin} removeBtcTrailingZeroes/>);
         expect(container).toHaveTextContent('218.00');
       });
-      it('1\'340.25 ' + coin + ' with removeBtcTrailingZeroes disabled stays 1\'340.25', () => {
-        const { container } = render(<Amount amount="1'340.25" unit={coin}/>);
+      it('1’340.25 ' + coin + ' with removeBtcTrailingZeroes disabled stays 1’340.25', () => {
+        const { container } = render(<Amount amount="1’340.25" unit={coin}/>);
         expect(container).toHaveTextContent('1’340.25');
       });
-      it('218.00 ' + coin + ' with removeBtcTrailingZeroes disabled stays 218.00', () => {
-        const { container } = render(<Amount amount="218.00" unit={coin}/>);
-        expect(container).toHaveTextContent('218.00');
+      it('1.340,25 ' + coin + ' localized by the backend stays 1.340,25', () => {
+        const { container } = render(<Amount amount="1.340,25" unit={coin}/>);
+        expect(container).toHaveTextContent('1.340,25');
+      });
+      it('-1,340.25 ' + coin + ' localized by the backend stays -1,340.25', () => {
+        const { container } = render(<Amount amount="-1,340.25" unit={coin}/>);
+        expect(container).toHaveTextContent('-1,340.25');
       });
 
     });
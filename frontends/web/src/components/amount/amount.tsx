@@ -16,7 +16,7 @@
 
 import { useContext } from 'react';
 import { AppContext } from '@/contexts/AppContext';
-import { CoinUnit, ConversionUnit } from '@/api/account';
+import { CoinUnit, ConversionUnit, Fiat } from '@/api/account';
 import style from './amount.module.css';
 import { LocalizationContext } from '@/contexts/localization-context';
 
@@ -27,7 +27,19 @@ type TProps = {
   alwaysShowAmounts?: boolean
 };
 
+// fiat amounts are already localized by the backend
+const localizedFiats: Fiat[] = [
+  'AUD', 'BRL', 'CAD', 'CHF', 'CNY', 'CZK', 'EUR', 'GBP', 'HKD', 'ILS',
+  'JPY', 'KRW', 'NOK', 'PLN', 'RUB', 'SEK', 'SGD', 'USD',
+];
+
+const isLocalizedFiat = (unit: CoinUnit | ConversionUnit) => (
+  (localizedFiats as string[]).includes(unit)
+);
+
 const formatSats = (amount: string): JSX.Element => {
+  // the backend groups sats with a thousands separator, the blocks are grouped here instead
+  amount = amount.replace(/'/g, '');
   const blocks: JSX.Element[] = [];
   const blockSize = 3;
 
@@ -116,10 +128,19 @@ export const Amount = ({
     } else {
       return formatBtc(amount, group, decimal);
     }
+  case 'mBTC':
+  case 'mTBTC':
+    if (removeBtcTrailingZeroes && amount.includes('.')) {
+      return formatLocalizedAmount(amount.replace(/\.?0+$/, ''), group, decimal);
+    }
+    break;
   case 'sat':
   case 'tsat':
     return formatSats(amount);
   }
 
+  if (isLocalizedFiat(unit)) {
+    return amount;
+  }
   return formatLocalizedAmount(amount, group, decimal);
 };
\ No newline at end of file
@@ -77,6 +77,52 @@ export const Balance = ({
           </p>
         )
       }
+      {
+        balance.hasFrozen && balance.frozen && (
+          <p className={style.pendingBalance}>
+            {t('account.frozen')}
+            <span data-testid="frozenBalance">
+              {' '}<Amount
+                amount={balance.frozen.amount}
+                unit={balance.frozen.unit}
+                removeBtcTrailingZeroes/>
+              {' '}{balance.frozen.unit}
+            </span>
+          </p>
+        )
+      }
+      {
+        balance.hasPendingChange && balance.pendingChange && (
+          <p className={style.pendingBalance}>
+            {t('account.pendingChange')}
+            <span data-testid="pendingChangeBalance">
+              {' '}<Amount
+                amount={balance.pendingChange.amount}
+                unit={balance.pendingChange.unit}
+                removeBtcTrailingZeroes/>
+              {' '}{balance.pendingChange.unit} /
+              <span className={style.incomingConversion}>
+                {' '}
+                <FiatConversion amount={balance.pendingChange} noBtcZeroes/>
+              </span>
+            </span>
+          </p>
+        )
+      }
+      {
+        balance.hasUnverified && balance.unverified && (
+          <p className={style.pendingBalance}>
+            {t('account.unverified')}
+            <span data-testid="unverifiedBalance">
+              {' '}<Amount
+                amount={balance.unverified.amount}
+                unit={balance.unverified.unit}
+                removeBtcTrailingZeroes/>
+              {' '}{balance.unverified.unit}
+            </span>
+          </p>
+        )
+      }
     </header>
   );
 };
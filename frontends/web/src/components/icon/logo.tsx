@@ -78,6 +78,8 @@ type LogoMap = {
 const logoMap: LogoMap = {
   'btc': [BTC, BTC_GREY],
   'tbtc': [BTC, BTC_GREY],
+  'tbtc4': [BTC, BTC_GREY],
+  'sbtc': [BTC, BTC_GREY],
   'rbtc': [BTC, BTC_GREY],
   'ltc': [LTC, LTC_GREY],
   'tltc': [LTC, LTC_GREY],
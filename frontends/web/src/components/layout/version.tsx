@@ -26,7 +26,7 @@ const Version = () => {
   if (!version) {
     return null;
   }
-  return <p>{t('footer.appVersion')} {version}</p>;
+  return <p>{t('footer.appVersion')} {version.version}</p>;
 };
 
 export { Version };
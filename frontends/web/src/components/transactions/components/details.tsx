@@ -22,11 +22,13 @@ import { Dialog } from '@/components/dialog/dialog';
 import { FiatConversion } from '@/components/rates/rates';
 import { Amount } from '@/components/amount/amount';
 import { Note } from '@/components/transactions/note';
+import { convertDateToLocaleString } from '@/utils/date';
 import { TxDetail } from './detail';
 import { Arrow } from './arrow';
 import { TxDateDetail } from './date';
 import { TxStatusDetail } from './status';
 import { TxDetailCopyableValues } from './address-or-txid';
+import { TxInputsOutputs } from './inputs-outputs';
 import parentStyle from '@/components/transactions/transaction.module.css';
 
 type TProps = {
@@ -62,7 +64,7 @@ export const TxDetailsDialog = ({
   typeClassName,
   explorerURL,
 }: TProps) => {
-  const { t } = useTranslation();
+  const { i18n, t } = useTranslation();
 
   const [transactionInfo, setTransactionInfo] = useState<ITransaction | null>(null);
 
@@ -123,7 +125,17 @@ export const TxDetailsDialog = ({
                 <FiatConversion noAction />
               }
             </span>
+            {transactionInfo.amountAtTimeIsLatest && (
+              <span title={t('transaction.details.fiatAtTimeIsLatest')}>*</span>
+            )}
           </TxDetail>
+          {transactionInfo.amountAtSend && (
+            <TxDetail label={t('transaction.details.fiatAtSend')}>
+              <span className={`${parentStyle.fiat} ${typeClassName}`}>
+                <FiatConversion amount={transactionInfo.amountAtSend} sign={sign} noAction />
+              </span>
+            </TxDetail>
+          )}
           <TxDetail label={t('transaction.details.amount')}>
             <span className={`${parentStyle.amount} ${typeClassName}`}>
               {sign}
@@ -184,6 +196,18 @@ export const TxDetailsDialog = ({
               </TxDetail>
             ) : null
           }
+          {
+            transactionInfo.vsize ? (
+              <TxInputsOutputs accountCode={accountCode} txID={transactionInfo.txID} />
+            ) : null
+          }
+          {
+            transactionInfo.lastBroadcast ? (
+              <TxDetail label={t('transaction.lastBroadcast')}>
+                {convertDateToLocaleString(transactionInfo.lastBroadcast, i18n.language)}
+              </TxDetail>
+            ) : null
+          }
           <TxDetailCopyableValues
             label={t('transaction.explorer')}
             values={[transactionInfo.txID]}
@@ -0,0 +1,86 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { AccountCode, IAmount, ITransactionDetails, getTransactionDetails } from '@/api/account';
+import { Amount } from '@/components/amount/amount';
+import { TxDetail } from './detail';
+import parentStyle from '@/components/transactions/transaction.module.css';
+
+type TProps = {
+  accountCode: AccountCode;
+  txID: string;
+}
+
+const TxValue = ({ value }: { value: IAmount }) => (
+  <>
+    <Amount amount={value.amount} unit={value.unit} />
+    {' '}
+    <span className={parentStyle.currencyUnit}>{value.unit}</span>
+  </>
+);
+
+export const TxInputsOutputs = ({ accountCode, txID }: TProps) => {
+  const { t } = useTranslation();
+  const [details, setDetails] = useState<ITransactionDetails | null>(null);
+
+  useEffect(() => {
+    getTransactionDetails(accountCode, txID)
+      .then(setDetails)
+      .catch(console.error);
+  }, [accountCode, txID]);
+
+  if (!details) {
+    return null;
+  }
+  return (
+    <>
+      {details.inputs.map(input => (
+        <TxDetail key={input.outPoint} label={t('transaction.input')}>
+          {input.address || input.outPoint}
+          {input.value ? (
+            <>
+              {' '}
+              <TxValue value={input.value} />
+            </>
+          ) : null}
+        </TxDetail>
+      ))}
+      {details.outputs.map(output => (
+        <TxDetail
+          key={output.index}
+          label={
+            output.change ? t('transaction.outputChange')
+              : output.ours ? t('transaction.outputOurs')
+                : t('transaction.output')
+          }>
+          {output.address}
+          {' '}
+          <TxValue value={output.value} />
+        </TxDetail>
+      ))}
+      {details.feeRate ? (
+        <TxDetail label={t('transaction.feeRate')}>
+          {details.feeRate}
+          {' '}
+          <span className={parentStyle.currencyUnit}>sat/vB</span>
+        </TxDetail>
+      ) : null}
+    </>
+  );
+};
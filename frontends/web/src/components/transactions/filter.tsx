@@ -0,0 +1,94 @@
+/**
+ * Copyright 2024 Shift Crypto AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { useTranslation } from 'react-i18next';
+import { TTransactionDirection, TTransactionsFilter } from '@/api/account';
+import { Input, Select } from '@/components/forms';
+import style from './transactions.module.css';
+
+type TProps = {
+  filter: TTransactionsFilter;
+  onChange: (filter: TTransactionsFilter) => void;
+};
+
+// The date inputs use local days, the backend expects RFC3339 times.
+const startOfDay = (date: string) => new Date(`${date}T00:00:00`).toISOString();
+const endOfDay = (date: string) => new Date(`${date}T23:59:59.999`).toISOString();
+const toDate = (time?: string) => {
+  if (!time) {
+    return '';
+  }
+  const date = new Date(time);
+  const pad = (n: number) => `${n}`.padStart(2, '0');
+  return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}`;
+};
+
+export const TransactionsFilter = ({ filter, onChange }: TProps) => {
+  const { t } = useTranslation();
+  const update = (changes: Partial<TTransactionsFilter>) => onChange({ ...filter, ...changes });
+  const directions: { value: TTransactionDirection | '', text: string }[] = [
+    { value: '', text: t('transactions.filter.allDirections') },
+    { value: 'receive', text: t('transactions.filter.receive') },
+    { value: 'send', text: t('transactions.filter.send') },
+    { value: 'sendSelf', text: t('transactions.filter.sendSelf') },
+  ];
+  return (
+    <div className={style.filter}>
+      <Input
+        id="transactionsSearch"
+        type="search"
+        placeholder={t('transactions.filter.search')}
+        value={filter.q || ''}
+        onInput={e => update({ q: e.target.value })} />
+      <Select
+        id="transactionsDirection"
+        options={directions}
+        value={filter.direction?.[0] || ''}
+        onChange={e => {
+          const value = (e.target as HTMLSelectElement).value as TTransactionDirection | '';
+          update({ direction: value ? [value] : undefined });
+        }} />
+      <Input
+        id="transactionsFrom"
+        type="date"
+        label={t('transactions.filter.from')}
+        value={toDate(filter.from)}
+        onInput={e => update({ from: e.target.value ? startOfDay(e.target.value) : undefined })} />
+      <Input
+        id="transactionsTo"
+        type="date"
+        label={t('transactions.filter.to')}
+        value={toDate(filter.to)}
+        onInput={e => update({ to: e.target.value ? endOfDay(e.target.value) : undefined })} />
+      <Input
+        id="transactionsMinAmount"
+        inputMode="decimal"
+        label={t('transactions.filter.minAmount')}
+        value={filter.minAmount || ''}
+        onInput={e => update({ minAmount: e.target.value || undefined })} />
+      <Input
+        id="transactionsMaxAmount"
+        inputMode="decimal"
+        label={t('transactions.filter.maxAmount')}
+        value={filter.maxAmount || ''}
+        onInput={e => update({ maxAmount: e.target.value || undefined })} />
+    </div>
+  );
+};
+
+export const isFilterActive = (filter: TTransactionsFilter) => Object.values(filter).some(value => (
+  Array.isArray(value) ? value.length > 0 : !!value
+));
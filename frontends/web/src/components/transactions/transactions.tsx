@@ -16,8 +16,9 @@
  */
 
 import { useTranslation } from 'react-i18next';
-import { AccountCode, TTransactions } from '@/api/account';
+import { AccountCode, TTransactions, TTransactionsFilter } from '@/api/account';
 import { Transaction } from './transaction';
+import { isFilterActive, TransactionsFilter } from './filter';
 import { Button } from '@/components/forms';
 import style from './transactions.module.css';
 
@@ -26,6 +27,9 @@ type TProps = {
     explorerURL: string;
     transactions?: TTransactions;
     handleExport: () => void;
+    onLoadMore?: () => void;
+    filter?: TTransactionsFilter;
+    onFilterChange?: (filter: TTransactionsFilter) => void;
 };
 
 export const Transactions = ({
@@ -33,6 +37,9 @@ export const Transactions = ({
   explorerURL,
   transactions,
   handleExport,
+  onLoadMore,
+  filter,
+  onFilterChange,
 }: TProps) => {
   const { t } = useTranslation();
 
@@ -49,6 +56,9 @@ export const Transactions = ({
           {t('account.export')}
         </Button>
       </div>
+      { filter && onFilterChange && (
+        <TransactionsFilter filter={filter} onChange={onFilterChange} />
+      ) }
       <div className={[style.columns, style.headers, style.showOnMedium].join(' ')}>
         <div className={style.type}>{t('transaction.details.type')}</div>
         <div className={style.date}>{t('transaction.details.date')}</div>
@@ -70,11 +80,20 @@ export const Transactions = ({
           <div className={`flex flex-row flex-center ${style.empty}`}>
             { transactions && !transactions.success ? (
               <p>{t('transactions.errorLoadTransactions')}</p>
+            ) : filter && isFilterActive(filter) ? (
+              <p>{t('transactions.filter.noMatch')}</p>
             ) : (
               <p>{t('transactions.placeholder')}</p>
             ) }
           </div>
         ) }
+      { onLoadMore && transactions && transactions.success && transactions.list.length < transactions.matched && (
+        <div className={`flex flex-row flex-center ${style.loadMore}`}>
+          <Button secondary onClick={onLoadMore}>
+            {t('transactions.loadMore')}
+          </Button>
+        </div>
+      ) }
     </div>
   );
 };
@@ -16,7 +16,7 @@
  */
 
 import { ReactNode, useEffect, useState } from 'react';
-import { backendConnected } from './api/subscribe';
+import { backendConnected, backendResync } from './api/subscribe';
 
 type TProps = {
     children: ReactNode;
@@ -31,6 +31,11 @@ export const ConnectedApp = ({ children }: TProps) => {
     });
   }, []);
 
+  useEffect(() => {
+    // Events were dropped, so all state is reloaded.
+    return backendResync(() => window.location.reload());
+  }, []);
+
   if (!connected) {
     return (
       <div className="app" style={{ padding: 40 }}>
@@ -19,7 +19,7 @@ import { RatesContext } from './RatesContext';
 import { Fiat } from '@/api/account';
 import { BtcUnit } from '@/api/coins';
 import { getConfig, setConfig } from '@/utils/config';
-import { reinitializeAccounts } from '@/api/backend';
+import { setActiveFiats } from '@/api/backend';
 import { equal } from '@/utils/equal';
 
 type TProps = {
@@ -28,7 +28,7 @@ type TProps = {
 
 export const RatesProvider = ({ children }: TProps) => {
   const [defaultCurrency, setDefaultCurrency] = useState<Fiat>('USD');
-  const [activeCurrencies, setActiveCurrencies] = useState<Fiat[]>(['USD', 'EUR', 'CHF']);
+  const [activeCurrencies, setActiveCurrencies] = useState<Fiat[]>(['USD']);
   const [btcUnit, setBtcUnit] = useState<BtcUnit>('default');
 
   useEffect(() => {
@@ -58,7 +58,7 @@ export const RatesProvider = ({ children }: TProps) => {
   // and in RatesContext context's (local) state
   const updateDefaultCurrency = async (fiat: Fiat) => {
     if (!activeCurrencies.includes(fiat)) {
-      addToActiveCurrencies(fiat);
+      await addToActiveCurrencies(fiat);
     }
     await setConfig({ backend: { mainFiat: fiat } });
     setDefaultCurrency(fiat);
@@ -68,23 +68,26 @@ export const RatesProvider = ({ children }: TProps) => {
   // into the active currencies list
   const addToActiveCurrencies = async (fiat: Fiat) => {
     const selected = [...activeCurrencies, fiat];
-    await setConfig({ backend: { fiatList: selected } });
-    handleChangeSelectedFiat(selected);
+    await handleChangeSelectedFiat(selected);
   };
 
   // this is a method to unselect / remove a currency
   // from the active currencies list
   const removeFromActiveCurrencies = async (fiat: Fiat) => {
     const selected = activeCurrencies.filter(item => !equal(item, fiat));
-    await setConfig({ backend: { fiatList: selected } });
-    handleChangeSelectedFiat(selected);
+    await handleChangeSelectedFiat(selected);
   };
 
-  const handleChangeSelectedFiat = (selected: Fiat[]) => {
-    setActiveCurrencies(selected);
-    // Need to reconfigure currency exchange rates updater
-    // which is done during accounts reset.
-    reinitializeAccounts();
+  const handleChangeSelectedFiat = async (selected: Fiat[]) => {
+    // The backend only fetches the rates of the active currencies
+    // and refreshes them immediately.
+    const result = await setActiveFiats(selected);
+    if (!result.success) {
+      console.error(result.errorMessage);
+      return;
+    }
+    // The default currency may have changed if it was removed.
+    await updateRatesConfig();
   };
 
   return (
@@ -15,11 +15,11 @@
  * limitations under the License.
  */
 
-import { useCallback, useEffect, useState } from 'react';
+import { useCallback, useEffect, useRef, useState } from 'react';
 import { useTranslation } from 'react-i18next';
 import { Link } from 'react-router-dom';
 import * as accountApi from '@/api/account';
-import { statusChanged, syncAddressesCount, syncdone } from '@/api/accountsync';
+import { newTransaction, observeAccount, statusChanged, syncAddressesCount, syncdone, TNewTransaction } from '@/api/accountsync';
 import { bitsuranceLookup } from '@/api/bitsurance';
 import { TDevices } from '@/api/devices';
 import { getExchangeBuySupported, SupportedExchanges } from '@/api/exchanges';
@@ -33,6 +33,7 @@ import { Header } from '@/components/layout';
 import { Spinner } from '@/components/spinner/Spinner';
 import { Status } from '@/components/status/status';
 import { Transactions } from '@/components/transactions/transactions';
+import { isFilterActive } from '@/components/transactions/filter';
 import { useLoad } from '@/hooks/api';
 import { HideAmountsButton } from '@/components/hideamountsbutton/hideamountsbutton';
 import style from './account.module.css';
@@ -48,6 +49,9 @@ import { A } from '@/components/anchor/anchor';
 import { getConfig, setConfig } from '@/utils/config';
 import { i18n } from '@/i18n/i18n';
 
+// Number of transactions loaded at once, see getTransactionList().
+const transactionsPageSize = 100;
+
 type Props = {
   accounts: accountApi.IAccount[];
   code: accountApi.AccountCode;
@@ -65,6 +69,11 @@ export const Account = ({
   const [status, setStatus] = useState<accountApi.IStatus>();
   const [syncedAddressesCount, setSyncedAddressesCount] = useState<number>();
   const [transactions, setTransactions] = useState<accountApi.TTransactions>();
+  // Number of transactions to load when the account changed, grows when more are loaded.
+  const transactionsLimit = useRef(transactionsPageSize);
+  const [transactionsFilter, setTransactionsFilter] = useState<accountApi.TTransactionsFilter>({});
+  // Kept in a ref too, so the account change handlers use the current filter.
+  const transactionsFilterRef = useRef(transactionsFilter);
   const [usesProxy, setUsesProxy] = useState<boolean>();
   const [insured, setInsured] = useState<boolean>(false);
   const [uncoveredFunds, setUncoveredFunds] = useState<string[]>([]);
@@ -142,7 +151,11 @@ export const Account = ({
           }
           setBalance(newBalance);
         }),
-        accountApi.getTransactionList(code).then(newTransactions => {
+        accountApi.getTransactionList(
+          code,
+          { offset: 0, limit: transactionsLimit.current },
+          transactionsFilterRef.current,
+        ).then(newTransactions => {
           if (currentCode !== code) {
             // Results came in after the account was switched. Ignore.
             return;
@@ -176,14 +189,85 @@ export const Account = ({
       .catch(console.error);
   }, [onAccountChanged, code]);
 
+  const loadMoreTransactions = useCallback(() => {
+    if (!transactions || !transactions.success) {
+      return;
+    }
+    const currentCode = code;
+    const offset = transactions.list.length;
+    accountApi.getTransactionList(currentCode, { offset, limit: transactionsPageSize }, transactionsFilter)
+      .then(page => {
+        if (currentCode !== code || !page.success) {
+          return;
+        }
+        transactionsLimit.current = offset + transactionsPageSize;
+        setTransactions(current => {
+          if (!current || !current.success) {
+            return current;
+          }
+          // The list may have shifted in between if new transactions arrived.
+          const loaded = new Set(current.list.map(tx => tx.internalID));
+          const list = current.list.concat(page.list.filter(tx => !loaded.has(tx.internalID)));
+          return { ...current, list, total: page.total, matched: page.matched };
+        });
+      })
+      .catch(console.error);
+  }, [code, transactions, transactionsFilter]);
+
+  const onTransactionsFilterChange = useCallback((filter: accountApi.TTransactionsFilter) => {
+    setTransactionsFilter(filter);
+    transactionsFilterRef.current = filter;
+    transactionsLimit.current = transactionsPageSize;
+    const currentCode = code;
+    accountApi.getTransactionList(currentCode, { offset: 0, limit: transactionsPageSize }, filter)
+      .then(newTransactions => {
+        if (currentCode !== code || transactionsFilterRef.current !== filter) {
+          // Results came in after the account or the filter was changed. Ignore.
+          return;
+        }
+        setTransactions(newTransactions);
+      })
+      .catch(console.error);
+  }, [code]);
+
+  const onNewTransaction = useCallback((tx: TNewTransaction) => {
+    if (tx.index < 0 || isFilterActive(transactionsFilterRef.current)) {
+      // The position in the filtered list is unknown, it is updated when the sync is done.
+      return;
+    }
+    const currentCode = code;
+    accountApi.getTransaction(currentCode, tx.internalID)
+      .then(newTx => {
+        if (currentCode !== code || newTx === null) {
+          return;
+        }
+        setTransactions(current => {
+          if (!current || !current.success) {
+            return current;
+          }
+          const list = current.list.filter(loaded => loaded.internalID !== newTx.internalID);
+          if (tx.index > list.length) {
+            // Not part of the loaded transactions.
+            return { ...current, total: tx.total, matched: tx.total };
+          }
+          list.splice(tx.index, 0, newTx);
+          return { ...current, list, total: tx.total, matched: tx.total };
+        });
+      })
+      .catch(console.error);
+  }, [code]);
+
+  useEffect(() => observeAccount(code), [code]);
+
   useEffect(() => {
     const subscriptions = [
       syncAddressesCount(code)(setSyncedAddressesCount),
       statusChanged((eventCode) => eventCode === code && onStatusChanged()),
       syncdone((eventCode) => eventCode === code && onAccountChanged(code, status)),
+      newTransaction((eventCode, tx) => eventCode === code && onNewTransaction(tx)),
     ];
     return () => unsubscribe(subscriptions);
-  }, [code, onAccountChanged, onStatusChanged, status]);
+  }, [code, onAccountChanged, onNewTransaction, onStatusChanged, status]);
 
   const exportAccount = () => {
     if (status === undefined || status.fatalError) {
@@ -204,6 +288,9 @@ export const Account = ({
     setStatus(undefined);
     setSyncedAddressesCount(0);
     setTransactions(undefined);
+    transactionsLimit.current = transactionsPageSize;
+    setTransactionsFilter({});
+    transactionsFilterRef.current = {};
     onStatusChanged();
   }, [code, onStatusChanged]);
 
@@ -262,13 +349,13 @@ export const Account = ({
     && !balance.hasIncoming
     && transactions
     && transactions.success
-    && transactions.list.length === 0;
+    && transactions.total === 0;
 
 
   const actionButtonsProps = {
     code,
     coinCode: account.coinCode,
-    canSend: balance && balance.hasAvailable,
+    canSend: balance && balance.hasAvailable && !account.watchOnly,
     exchangeBuySupported,
     account
   };
@@ -326,6 +413,9 @@ export const Account = ({
               handleExport={exportAccount}
               explorerURL={account.blockExplorerTxPrefix}
               transactions={transactions}
+              onLoadMore={loadMoreTransactions}
+              filter={transactionsFilter}
+              onFilterChange={onTransactionsFilterChange}
             /> }
           </div>
         </div>
@@ -334,7 +424,7 @@ export const Account = ({
         account={account}
         unit={balance?.available.unit}
         hasIncomingBalance={balance && balance.hasIncoming}
-        hasTransactions={transactions !== undefined && transactions.success && transactions.list.length > 0}
+        hasTransactions={transactions !== undefined && transactions.success && transactions.total > 0}
         hasNoBalance={balance && balance.available.amount === '0'}
       />
     </div>
@@ -19,7 +19,7 @@ import React, { useEffect, useRef, useState } from 'react';
 import { useTranslation } from 'react-i18next';
 import { useLoad } from '@/hooks/api';
 import * as accountApi from '@/api/account';
-import { getScriptName, isEthereumBased } from '@/routes/account/utils';
+import { getScriptName, isBitcoinOnly, isEthereumBased } from '@/routes/account/utils';
 import { alertUser } from '@/components/alert/Alert';
 import { CopyableInput } from '@/components/copy/Copy';
 import { Dialog, DialogButtons } from '@/components/dialog/dialog';
@@ -83,7 +83,11 @@ export const Receive = ({
   useEffect(() => {
     if (receiveAddresses) {
       // All script types that are present in the addresses delivered by the backend. Will be empty for if there are no such addresses, e.g. in Ethereum.
-      availableScriptTypes.current = scriptTypes.filter(sc => getIndexOfMatchingScriptType(receiveAddresses, sc) >= 0);
+      // The backend delivers the addresses of the preferred script type of the account first, which is then the default.
+      const preferredScriptType = receiveAddresses[0]?.scriptType;
+      availableScriptTypes.current = scriptTypes
+        .filter(sc => getIndexOfMatchingScriptType(receiveAddresses, sc) >= 0)
+        .sort((a, b) => Number(b === preferredScriptType) - Number(a === preferredScriptType));
     }
   }, [receiveAddresses]);
 
@@ -133,7 +137,7 @@ export const Receive = ({
 
   let uriPrefix = '';
   if (account) {
-    if (account.coinCode === 'btc' || account.coinCode === 'tbtc') {
+    if (isBitcoinOnly(account.coinCode)) {
       uriPrefix = 'bitcoin:';
     } else if (account.coinCode === 'ltc' || account.coinCode === 'tltc') {
       uriPrefix = 'litecoin:';
@@ -15,12 +15,12 @@
  * limitations under the License.
  */
 
-import React, { useEffect, useRef, useState } from 'react';
+import React, { useCallback, useEffect, useRef, useState } from 'react';
 import { useTranslation } from 'react-i18next';
 import { useLoad } from '@/hooks/api';
 import { UseBackButton } from '@/hooks/backbutton';
 import * as accountApi from '@/api/account';
-import { getScriptName, isEthereumBased } from '@/routes/account/utils';
+import { getScriptName, isBitcoinOnly, isEthereumBased } from '@/routes/account/utils';
 import { CopyableInput } from '@/components/copy/Copy';
 import { Dialog, DialogButtons } from '@/components/dialog/dialog';
 import { Button, Radio } from '@/components/forms';
@@ -121,12 +121,18 @@ export const Receive = ({
   const [addressType, setAddressType] = useState<number>(0);
   const [addressTypeDialog, setAddressTypeDialog] = useState<boolean>(false);
   const [currentAddresses, setCurrentAddresses] = useState<accountApi.IReceiveAddress[]>();
-  const [currentAddressIndex, setCurrentAddressIndex] = useState<number>(0);
+  // Result of the last receive address handout, BTC based accounts only.
+  const [handout, setHandout] = useState<accountApi.TNextReceiveAddress>();
+  const [extendingGapLimit, setExtendingGapLimit] = useState<boolean>(false);
 
   const account = accounts.find(({ code: accountCode }) => accountCode === code);
   const insured = account?.bitsuranceStatus === 'active';
+  // BTC based accounts hand out receive addresses one by one up to the gap limit. Ethereum
+  // based accounts only have one address.
+  const handsOutAddresses = !!account && !isEthereumBased(account.coinCode);
 
   // first array index: address types. second array index: unused addresses of that address type.
+  // For BTC based accounts, this is only used to find the available address types.
   const receiveAddresses = useLoad(accountApi.getReceiveAddressList(code));
 
   const availableScriptTypes = useRef<accountApi.ScriptType[]>();
@@ -136,20 +142,57 @@ export const Receive = ({
   useEffect(() => {
     if (receiveAddresses) {
       // All script types that are present in the addresses delivered by the backend. Will be empty for if there are no such addresses, e.g. in Ethereum.
-      availableScriptTypes.current = scriptTypes.filter(sc => getIndexOfMatchingScriptType(receiveAddresses, sc) >= 0);
+      // The backend delivers the addresses of the preferred script type of the account first, which is then the default.
+      const preferredScriptType = receiveAddresses[0]?.scriptType;
+      availableScriptTypes.current = scriptTypes
+        .filter(sc => getIndexOfMatchingScriptType(receiveAddresses, sc) >= 0)
+        .sort((a, b) => Number(b === preferredScriptType) - Number(a === preferredScriptType));
     }
   }, [receiveAddresses]);
 
+  // Hands out the next receive address of the chosen address type and adds it to the addresses
+  // shown, so that the user can go back to the ones handed out before.
+  const handOutAddress = useCallback(async () => {
+    const scriptType = availableScriptTypes.current?.[addressType] || null;
+    const result = await accountApi.nextReceiveAddress(code, scriptType);
+    setHandout(result);
+    if (result.success) {
+      const { address, addressID } = result;
+      setCurrentAddresses(addresses => [...(addresses || []), { address, addressID }]);
+    }
+  }, [addressType, code]);
+
+  useEffect(() => {
+    // Show the address handed out last.
+    if (handsOutAddresses && currentAddresses) {
+      setActiveIndex(currentAddresses.length - 1);
+    }
+  }, [handsOutAddresses, currentAddresses]);
+
   useEffect(() => {
     if (receiveAddresses && availableScriptTypes.current) {
-      let addressIndex = availableScriptTypes.current.length > 0 ? getIndexOfMatchingScriptType(receiveAddresses, availableScriptTypes.current[addressType]) : 0;
-      if (addressIndex === -1) {
-        addressIndex = 0;
+      if (handsOutAddresses) {
+        setCurrentAddresses(undefined);
+        handOutAddress();
+      } else {
+        let addressIndex = availableScriptTypes.current.length > 0 ? getIndexOfMatchingScriptType(receiveAddresses, availableScriptTypes.current[addressType]) : 0;
+        if (addressIndex === -1) {
+          addressIndex = 0;
+        }
+        setCurrentAddresses(receiveAddresses[addressIndex].addresses);
       }
-      setCurrentAddressIndex(addressIndex);
-      setCurrentAddresses(receiveAddresses[addressIndex].addresses);
     }
-  }, [addressType, availableScriptTypes, receiveAddresses]);
+  }, [addressType, availableScriptTypes, receiveAddresses, handsOutAddresses, handOutAddress]);
+
+  const extendGapLimit = async () => {
+    setExtendingGapLimit(true);
+    try {
+      await accountApi.extendReceiveGapLimit(code);
+      await handOutAddress();
+    } finally {
+      setExtendingGapLimit(false);
+    }
+  };
 
   const handleAddressTypeChosen = (addressType: number) => {
     setActiveIndex(0);
@@ -157,8 +200,8 @@ export const Receive = ({
     setAddressTypeDialog(false);
   };
 
-  const verifyAddress = async (addressesIndex: number) => {
-    if (!receiveAddresses || code === undefined) {
+  const verifyAddress = async () => {
+    if (!currentAddresses || code === undefined) {
       return;
     }
     const connectResult = await accountApi.connectKeystore(code);
@@ -176,7 +219,7 @@ export const Receive = ({
     // For devices with a display, the dialog is dismissed by tapping the device.
     setVerifying('secure');
     try {
-      await accountApi.verifyAddress(code, receiveAddresses[addressesIndex].addresses[activeIndex].addressID);
+      await accountApi.verifyAddress(code, currentAddresses[activeIndex].addressID);
     } finally {
       setVerifying(false);
     }
@@ -191,14 +234,22 @@ export const Receive = ({
 
   const next = (e: React.SyntheticEvent, numAddresses: number) => {
     e.preventDefault();
-    if (!verifying && activeIndex < numAddresses - 1) {
+    if (verifying) {
+      return;
+    }
+    if (activeIndex < numAddresses - 1) {
       setActiveIndex(activeIndex + 1);
+    } else if (handsOutAddresses) {
+      handOutAddress();
     }
   };
 
+  // For BTC based accounts, a new address can be handed out after the last one.
+  const hasNext = (numAddresses: number) => activeIndex < numAddresses - 1 || (handsOutAddresses && !!handout?.success);
+
   let uriPrefix = '';
   if (account) {
-    if (account.coinCode === 'btc' || account.coinCode === 'tbtc') {
+    if (isBitcoinOnly(account.coinCode)) {
       uriPrefix = 'bitcoin:';
     } else if (account.coinCode === 'ltc' || account.coinCode === 'tltc') {
       uriPrefix = 'litecoin:';
@@ -220,13 +271,33 @@ export const Receive = ({
           <Header title={<h2>{t('receive.title', { accountName: account?.coinName })}</h2>} />
           <div className="content narrow isVerticallyCentered">
             <div className="box large text-center">
+              { handout?.success && handout.warning === 'gapLimitLow' && (
+                <Message type="warning">
+                  {t('receive.gapLimitLow', { count: handout.headroom })}
+                </Message>
+              )}
+              { handout && !handout.success && (
+                <Message type="warning">
+                  {t('receive.gapLimitExhausted')}
+                  { handout.gapLimitExtension !== undefined && (
+                    <div className="buttons">
+                      <Button
+                        disabled={extendingGapLimit}
+                        onClick={extendGapLimit}
+                        secondary>
+                        {t('receive.extendGapLimit', { gapLimit: handout.gapLimitExtension })}
+                      </Button>
+                    </div>
+                  )}
+                </Message>
+              )}
               { currentAddresses && (
                 <div style={{ position: 'relative' }}>
                   <div className={style.qrCodeContainer}>
                     <QRCode data={undefined} />
                   </div>
                   <div className={style.labels}>
-                    { currentAddresses.length > 1 && (
+                    { (currentAddresses.length > 1 || handsOutAddresses) && (
                       <button
                         className={style.previous}
                         onClick={previous}>
@@ -240,11 +311,11 @@ export const Receive = ({
                     <p className={style.label}>
                       {t('receive.label')} {currentAddresses.length > 1 ? `(${activeIndex + 1}/${currentAddresses.length})` : ''}
                     </p>
-                    { currentAddresses.length > 1 && (
+                    { (currentAddresses.length > 1 || handsOutAddresses) && (
                       <button
                         className={style.next}
                         onClick={e => next(e, currentAddresses.length)}>
-                        {(verifying || activeIndex >= currentAddresses.length - 1) ? (
+                        {(verifying || !hasNext(currentAddresses.length)) ? (
                           <ArrowCirlceRight height="24" width="24" />
                         ) : (
                           <ArrowCirlceRightActive height="24" width="24" title={t('button.next')} />
@@ -273,7 +344,7 @@ export const Receive = ({
                   <div className="buttons">
                     <Button
                       disabled={verifying !== false}
-                      onClick={() => verifyAddress(currentAddressIndex)}
+                      onClick={verifyAddress}
                       primary>
                       {t('receive.verifyBitBox02')}
                     </Button>
@@ -17,7 +17,7 @@
 
 import { ChangeEvent, Component } from 'react';
 import * as accountApi from '@/api/account';
-import { syncdone } from '@/api/accountsync';
+import { observeAccount, syncdone } from '@/api/accountsync';
 import { BtcUnit, convertFromCurrency, convertToCurrency, parseExternalBtcAmount } from '@/api/coins';
 import { View, ViewContent } from '@/components/view/view';
 import { TDevices, hasMobileChannel } from '@/api/devices';
@@ -34,7 +34,7 @@ import { getConfig } from '@/utils/config';
 import { FeeTargets } from './feetargets';
 import { signConfirm, signProgress, TSignProgress } from '@/api/devicessync';
 import { UnsubscribeList, unsubscribe } from '@/utils/subscriptions';
-import { isBitcoinBased, findAccount } from '@/routes/account/utils';
+import { isBitcoinBased, isBitcoinOnly, findAccount } from '@/routes/account/utils';
 import { ConfirmingWaitDialog } from './components/dialogs/confirm-wait-dialog';
 import { SendGuide } from './send-guide';
 import { MessageWaitDialog } from './components/dialogs/message-wait-dialog';
@@ -63,6 +63,8 @@ export type State = {
     proposedTotal?: accountApi.IAmount;
     recipientAddress: string;
     proposedAmount?: accountApi.IAmount;
+    flaggedInputs?: accountApi.TFlaggedOutput[];
+    unconfirmedInputs?: string[];
     valid: boolean;
     amount: string;
     fiatAmount: string;
@@ -154,6 +156,7 @@ class Send extends Component<Props, State> {
     });
 
     this.unsubscribeList = [
+      observeAccount(this.props.code),
       signProgress((progress) =>
         this.setState({ signProgress: progress, signConfirm: false })
       ),
@@ -215,6 +218,11 @@ class Send extends Component<Props, State> {
         case 'erc20InsufficientGasFunds':
           alertUser(this.props.t(`send.error.${result.errorCode}`));
           break;
+        case 'txProposalExpired':
+          alertUser(this.props.t(`send.error.${result.errorCode}`));
+          // Propose the transaction again with the latest rates.
+          this.validateAndDisplayFee(false);
+          break;
         default:
           const { errorMessage } = result;
           if (errorMessage) {
@@ -312,6 +320,8 @@ class Send extends Component<Props, State> {
         proposedFee: result.fee,
         proposedAmount: result.amount,
         proposedTotal: result.total,
+        flaggedInputs: result.flaggedInputs,
+        unconfirmedInputs: result.unconfirmedInputs,
         isUpdatingProposal: false,
       });
       if (updateFiat) {
@@ -319,7 +329,12 @@ class Send extends Component<Props, State> {
       }
     } else {
       const errorHandling = txProposalErrorHandling(result.errorCode);
-      this.setState({ ...errorHandling, isUpdatingProposal: false });
+      this.setState({
+        ...errorHandling,
+        flaggedInputs: undefined,
+        unconfirmedInputs: undefined,
+        isUpdatingProposal: false,
+      });
     }
   };
 
@@ -424,7 +439,7 @@ class Send extends Component<Props, State> {
 
     const coinCode = this.getAccount()!.coinCode;
     if (amount) {
-      if (coinCode === 'btc' || coinCode === 'tbtc') {
+      if (isBitcoinOnly(coinCode)) {
         const result = await parseExternalBtcAmount(amount);
         if (result.success) {
           updateState['amount'] = result.amount;
@@ -452,9 +467,24 @@ class Send extends Component<Props, State> {
   private onReceiverAddressInputChange = (recipientAddress: string) => {
     this.setState({ recipientAddress }, () => {
       this.validateAndDisplayFee(true);
+      this.validateAddress(recipientAddress);
     });
   };
 
+  private validateAddress = async (address: string) => {
+    if (!address) {
+      return;
+    }
+    const result = await accountApi.validateAddress(this.getAccount()!.code, address);
+    // ignore results for an address the user has already changed
+    if (address !== this.state.recipientAddress) {
+      return;
+    }
+    if (!result.success) {
+      this.setState({ addressError: this.props.t(`send.error.${result.errorCode}`) });
+    }
+  };
+
   private onCoinAmountChange = (amount: string) => {
     this.convertToFiat(amount);
     this.setState({ amount }, () => {
@@ -495,6 +525,8 @@ class Send extends Component<Props, State> {
       amountError,
       feeError,
       paired,
+      flaggedInputs,
+      unconfirmedInputs,
       signProgress,
       signConfirm,
       coinControl,
@@ -531,6 +563,14 @@ class Send extends Component<Props, State> {
             <Status type="warning" hidden={paired !== false}>
               {t('warning.sendPairing')}
             </Status>
+            <Status type="warning" hidden={!flaggedInputs || flaggedInputs.length === 0}>
+              {t('send.flaggedInputs', {
+                labels: Array.from(new Set((flaggedInputs || []).map(input => input.label))).join(', '),
+              })}
+            </Status>
+            <Status type="info" hidden={!unconfirmedInputs || unconfirmedInputs.length === 0}>
+              {t('send.unconfirmedInputs')}
+            </Status>
             <Header
               title={<h2>{t('send.title', { accountName: account.coinName })}</h2>}
             >
@@ -40,6 +40,11 @@ describe('send services', () => {
       expect(result).toEqual({ amountError: 'send.error.insufficientFunds', proposedFee: undefined });
     });
 
+    it('returns dust amount message on dustAmount error', () => {
+      const result = txProposalErrorHandling('dustAmount');
+      expect(result).toEqual({ amountError: 'send.error.dustAmount', proposedFee: undefined });
+    });
+
     it('returns fee too low message on feeTooLow error', () => {
       const result = txProposalErrorHandling('feeTooLow');
       expect(result).toEqual({ feeError: 'send.error.feeTooLow' });
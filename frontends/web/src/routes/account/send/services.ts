@@ -28,9 +28,15 @@ export const txProposalErrorHandling = (errorCode?: string) => {
   const { t } = i18n;
   switch (errorCode) {
   case 'invalidAddress':
-    return { addressError: t('send.error.invalidAddress') };
+  case 'addressWrongNetwork':
+  case 'addressInvalidChecksum':
+  case 'addressUnsupportedType':
+    return { addressError: t(`send.error.${errorCode}`) };
   case 'invalidAmount':
   case 'insufficientFunds':
+  case 'insufficientConfirmedFunds':
+  case 'flaggedCoinsMixed':
+  case 'dustAmount':
     return { amountError: t(`send.error.${errorCode}`), proposedFee: undefined };
   case 'feeTooLow':
   case 'feesNotAvailable':
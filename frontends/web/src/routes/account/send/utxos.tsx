@@ -141,6 +141,12 @@ export const UTXOs = ({
                           </Badge> :
                           null
                         }
+                        {utxo.flag ?
+                          <Badge type="warning">
+                            {t('send.coincontrol.flagged', { label: utxo.flag })}
+                          </Badge> :
+                          null
+                        }
                       </div>
                     </div>
                     <div className={style.transaction}>
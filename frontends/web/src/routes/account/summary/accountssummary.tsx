@@ -19,7 +19,7 @@ import { useState, useEffect, useRef, useCallback, useContext } from 'react';
 import { useTranslation } from 'react-i18next';
 import * as accountApi from '@/api/account';
 import { TDevices } from '@/api/devices';
-import { statusChanged, syncdone } from '@/api/accountsync';
+import { observeAccount, statusChanged, syncdone } from '@/api/accountsync';
 import { unsubscribe } from '@/utils/subscriptions';
 import { useMountedRef } from '@/hooks/mount';
 import { useSDCard } from '@/hooks/sdcard';
@@ -163,6 +163,12 @@ export const AccountsSummary = ({
     return () => unsubscribe(subscriptions);
   }, [update]);
 
+  useEffect(() => {
+    // the summary shows all accounts, so it observes the events of all of them.
+    const observations = accounts.map(({ code }) => observeAccount(code));
+    return () => unsubscribe(observations);
+  }, [accounts]);
+
 
   useEffect(() => {
     // handles fetching data and runs on component mount
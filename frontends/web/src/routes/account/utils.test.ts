@@ -28,6 +28,8 @@ const createAccount = ({
   return {
     active: true,
     blockExplorerTxPrefix: 'https://blockstream.info/testnet/tx/',
+    blockExplorerAddressPrefix: 'https://blockstream.info/testnet/address/',
+    blockExplorerBlockPrefix: 'https://blockstream.info/testnet/block-height/',
     code: 'v0-123de678-tbtc-0',
     coinCode: 'tbtc' as CoinCode,
     coinName: 'Bitcoin Testnet',
@@ -43,6 +45,7 @@ const createAccount = ({
     },
     name: 'Account 1',
     watch: true,
+    watchOnly: false,
     ...props,
   };
 };
@@ -66,6 +69,8 @@ describe('utils/getAccountsByKeystore', () => {
       {
         active: true,
         blockExplorerTxPrefix: 'https://blockstream.info/testnet/tx/',
+        blockExplorerAddressPrefix: 'https://blockstream.info/testnet/address/',
+        blockExplorerBlockPrefix: 'https://blockstream.info/testnet/block-height/',
         code: 'v0-123de678-tbtc-0',
         coinCode: 'tbtc',
         coinName: 'Bitcoin Testnet',
@@ -79,10 +84,13 @@ describe('utils/getAccountsByKeystore', () => {
           watchonly: true
         },
         name: 'Account 1',
-        watch: true
+        watch: true,
+        watchOnly: false
       }, {
         active: true,
         blockExplorerTxPrefix: 'https://blockstream.info/testnet/tx/',
+        blockExplorerAddressPrefix: 'https://blockstream.info/testnet/address/',
+        blockExplorerBlockPrefix: 'https://blockstream.info/testnet/block-height/',
         code: 'v0-123de678-tbtc-1',
         coinCode: 'tbtc',
         coinName: 'Bitcoin Testnet',
@@ -96,7 +104,8 @@ describe('utils/getAccountsByKeystore', () => {
           watchonly: true
         },
         name: 'Account 2',
-        watch: true
+        watch: true,
+        watchOnly: false
       }
     ];
     const result = getAccountsByKeystore(accounts);
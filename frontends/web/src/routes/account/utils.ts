@@ -28,18 +28,22 @@ export const isBitcoinOnly = (coinCode: CoinCode): boolean => {
   switch (coinCode) {
   case 'btc':
   case 'tbtc':
+  case 'tbtc4':
+  case 'sbtc':
     return true;
   default:
     return false;
   }
 };
 
-export const isBitcoinCoin = (coin: CoinUnit) => (coin === 'BTC') || (coin === 'TBTC') || (coin === 'sat') || (coin === 'tsat');
+export const isBitcoinCoin = (coin: CoinUnit) => (coin === 'BTC') || (coin === 'TBTC') || (coin === 'sat') || (coin === 'tsat') || (coin === 'mBTC') || (coin === 'mTBTC');
 
 export const isBitcoinBased = (coinCode: CoinCode): boolean => {
   switch (coinCode) {
   case 'btc':
   case 'tbtc':
+  case 'tbtc4':
+  case 'sbtc':
   case 'ltc':
   case 'tltc':
     return true;
@@ -56,6 +60,8 @@ export const getCoinCode = (coinCode: CoinCode): CoinCode | undefined => {
   switch (coinCode) {
   case 'btc':
   case 'tbtc':
+  case 'tbtc4':
+  case 'sbtc':
     return 'btc';
   case 'ltc':
   case 'tltc':
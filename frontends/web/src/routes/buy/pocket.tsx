@@ -21,7 +21,7 @@ import { getConfig } from '@/utils/config';
 import { Dialog } from '@/components/dialog/dialog';
 import { confirmation } from '@/components/confirm/Confirm';
 import { verifyAddress, getPocketURL } from '@/api/exchanges';
-import { AccountCode, getInfo, getTransactionList, signAddress } from '@/api/account';
+import { AccountCode, getInfo, getTransactionsSummary, signAddress } from '@/api/account';
 import { Header } from '@/components/layout';
 import { Spinner } from '@/components/spinner/Spinner';
 import { PocketTerms } from '@/components/terms/pocket-terms';
@@ -159,12 +159,12 @@ export const Pocket = ({ code }: TProps) => {
   };
 
   const handleRequestXpub = () => {
-    getTransactionList(code).then(txs => {
+    getTransactionsSummary(code, 0).then(txs => {
       if (!txs.success) {
         alertUser(t('transactions.errorLoadTransactions'));
         return;
       }
-      if (txs.list.length > 0) {
+      if (txs.total > 0) {
         confirmation(t('buy.pocket.previousTransactions'), result => {
           if (result) {
             sendXpub();
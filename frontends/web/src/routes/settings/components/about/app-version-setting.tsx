@@ -31,7 +31,7 @@ export const AppVersion = () => {
 
   const secondaryText = !!update ? t('settings.info.out-of-date') : t('settings.info.up-to-date');
   const icon = !!update ? <RedDot width={8} height={8} /> : <Checked />;
-  const versionNumber = !!version ? version : '-';
+  const versionNumber = !!version ? version.version : '-';
 
   if (update === undefined) {
     return <StyledSkeleton />;
@@ -54,12 +54,21 @@ export const NotesImport = () => {
 
             const result = await importNotes(await file.arrayBuffer());
             if (result.success) {
-              const { accountCount, transactionCount } = result.data;
+              const { accountCount, transactionCount, addressCount, inputCount, outputCount } = result.data;
               alertUser(`${t('settings.notes.import.accountNames', {
                 count: accountCount
               })}
     ${t('settings.notes.import.transactionNotes', {
       count: transactionCount
+    })}
+    ${t('settings.notes.import.addressNotes', {
+      count: addressCount
+    })}
+    ${t('settings.notes.import.inputNotes', {
+      count: inputCount
+    })}
+    ${t('settings.notes.import.outputNotes', {
+      count: outputCount
     })}`);
               fileInput.value = '';
             } else if (result.message) {
@@ -17,6 +17,7 @@
 
 import { apiWebsocket, TUnsubscribe } from './websocket';
 import { TEvent, TPayload, TSubject } from './transport-common';
+import { apiPost } from './request';
 
 export type { TEvent, TUnsubscribe };
 
@@ -37,6 +38,24 @@ interface Subscriptions {
  */
 const subscriptions: Subscriptions = {};
 
+/**
+ * Deprecated subject aliases which have been reported to the backend already.
+ */
+const reportedAliases = new Set<TSubject>();
+
+/**
+ * Reports to the backend that an event was consumed under a deprecated subject alias, so we know
+ * when the alias can be removed.
+ */
+const reportAliasConsumed = (subject: TSubject, replacedBy: TSubject) => {
+  if (reportedAliases.has(subject)) {
+    return;
+  }
+  reportedAliases.add(subject);
+  apiPost('events/alias-consumed', { subject, replacedBy })
+    .catch(console.error);
+};
+
 /**
  * This function dispatches the events from the websocket to the observers.
  */
@@ -46,6 +65,9 @@ const handleEvent = (payload: TPayload): void => {
     && typeof payload.subject === 'string'
   ) {
     if (subscriptions[payload.subject]) {
+      if (payload.replacedBy !== undefined && subscriptions[payload.subject].length > 0) {
+        reportAliasConsumed(payload.subject, payload.replacedBy);
+      }
       for (const observer of subscriptions[payload.subject]) {
         observer(payload);
       }
@@ -31,6 +31,8 @@ export type TEvent = {
   readonly action: TAction;
   readonly object: any;
   readonly subject: TSubject;
+  // Set if the event is emitted under a deprecated alias of the subject `replacedBy`.
+  readonly replacedBy?: TSubject;
 };
 
 /**
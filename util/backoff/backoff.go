@@ -0,0 +1,106 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backoff provides an exponential backoff with jitter, e.g. to space out reconnection
+// attempts so that they don't spam the logs and don't hammer the servers when connectivity returns.
+package backoff
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff computes increasing delays between attempts. The delay starts at the minimum and doubles
+// with each attempt, up to the maximum. It is safe for concurrent use.
+type Backoff struct {
+	minDelay time.Duration
+	maxDelay time.Duration
+	// jitter returns a random duration in [0, d). Replaceable in tests.
+	jitter func(d time.Duration) time.Duration
+
+	// delay is the delay before jitter for the next attempt.
+	delay time.Duration
+	// covers delay.
+	mu sync.Mutex
+
+	retryNow chan struct{}
+}
+
+// New creates a backoff with delays from minDelay up to maxDelay.
+func New(minDelay, maxDelay time.Duration) *Backoff {
+	return &Backoff{
+		minDelay: minDelay,
+		maxDelay: maxDelay,
+		jitter: func(d time.Duration) time.Duration {
+			if d <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(d)))
+		},
+		delay:    minDelay,
+		retryNow: make(chan struct{}, 1),
+	}
+}
+
+// Next returns the delay to wait before the next attempt and doubles the delay for the attempt
+// after it, up to the maximum. The returned delay is randomized to lie between half and all of the
+// current delay, so that many clients which lost their connection at the same time don't all
+// reconnect at the same time.
+func (b *Backoff) Next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delay := b.delay
+	b.delay *= 2
+	if b.delay > b.maxDelay {
+		b.delay = b.maxDelay
+	}
+	return delay/2 + b.jitter(delay/2)
+}
+
+// Reset resets the delay to the minimum, e.g. after a successful attempt.
+func (b *Backoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delay = b.minDelay
+}
+
+// Wait blocks for the given delay, or until RetryNow() is called or quit is closed. It returns
+// false if quit was closed.
+func (b *Backoff) Wait(delay time.Duration, quit <-chan struct{}) bool {
+	// Drop a request to retry which was made while not waiting.
+	select {
+	case <-b.retryNow:
+	default:
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.retryNow:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// RetryNow ends a pending Wait() immediately, e.g. when the network connectivity is restored. It
+// does nothing if there is no pending Wait().
+func (b *Backoff) RetryNow() {
+	select {
+	case b.retryNow <- struct{}{}:
+	default:
+	}
+}
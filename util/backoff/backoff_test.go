@@ -0,0 +1,88 @@
+// Copyright 2024 Shift Crypto AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	b := New(time.Second, 2*time.Minute)
+	// Without jitter, the delays are half of the backoff delay.
+	b.jitter = func(time.Duration) time.Duration { return 0 }
+	for _, expected := range []time.Duration{
+		500 * time.Millisecond,
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		32 * time.Second,
+		time.Minute,
+		time.Minute,
+	} {
+		require.Equal(t, expected, b.Next())
+	}
+	b.Reset()
+	require.Equal(t, 500*time.Millisecond, b.Next())
+
+	// With full jitter, the delays are the full backoff delay.
+	b.Reset()
+	b.jitter = func(d time.Duration) time.Duration { return d }
+	require.Equal(t, time.Second, b.Next())
+	require.Equal(t, 2*time.Second, b.Next())
+
+	// Random jitter stays in range.
+	b = New(time.Second, 2*time.Minute)
+	for i := 0; i < 100; i++ {
+		delay := b.Next()
+		require.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		require.LessOrEqual(t, delay, 2*time.Minute)
+	}
+}
+
+func TestWait(t *testing.T) {
+	b := New(time.Second, 2*time.Minute)
+	quit := make(chan struct{})
+
+	require.True(t, b.Wait(time.Millisecond, quit))
+
+	// A request to retry made while not waiting does not cut short the next wait.
+	b.RetryNow()
+	start := time.Now()
+	require.True(t, b.Wait(20*time.Millisecond, quit))
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	done := make(chan bool)
+	go func() { done <- b.Wait(time.Hour, quit) }()
+	// Retry until the waiting goroutine picked it up.
+	for {
+		b.RetryNow()
+		select {
+		case result := <-done:
+			require.True(t, result)
+		case <-time.After(10 * time.Millisecond):
+			continue
+		}
+		break
+	}
+
+	go func() { done <- b.Wait(time.Hour, quit) }()
+	close(quit)
+	require.False(t, <-done)
+}